@@ -0,0 +1,72 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package external
+
+import (
+	"math/big"
+	"net"
+
+	"github.com/DxChainNetwork/godx/accounts"
+	"github.com/DxChainNetwork/godx/accounts/keystore"
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/common/hexutil"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/rpc"
+)
+
+// SignerAPI is the API served by the external signer process, signing with the accounts of the
+// keystore unlocked in the signer process
+type SignerAPI struct {
+	ks *keystore.KeyStore
+}
+
+// NewSignerAPI creates the signer API signing with the accounts of the keystore
+func NewSignerAPI(ks *keystore.KeyStore) *SignerAPI {
+	return &SignerAPI{ks: ks}
+}
+
+// List returns the addresses of the accounts held by the signer
+func (api *SignerAPI) List() []common.Address {
+	accs := api.ks.Accounts()
+	addresses := make([]common.Address, 0, len(accs))
+	for _, acc := range accs {
+		addresses = append(addresses, acc.Address)
+	}
+	return addresses
+}
+
+// SignHash signs the hash with the unlocked account
+func (api *SignerAPI) SignHash(address common.Address, hash hexutil.Bytes) (hexutil.Bytes, error) {
+	return api.ks.SignHash(accounts.Account{Address: address}, hash)
+}
+
+// SignTransaction signs the RLP encoded transaction with the unlocked account, returning the RLP
+// encoded signed transaction
+func (api *SignerAPI) SignTransaction(address common.Address, rawTx hexutil.Bytes, chainID *hexutil.Big) (hexutil.Bytes, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(rawTx, tx); err != nil {
+		return nil, err
+	}
+	signed, err := api.ks.SignTx(accounts.Account{Address: address}, tx, (*big.Int)(chainID))
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(signed)
+}
+
+// StartSigner serves the signer API over the IPC endpoint. Both the listener and the server
+// returned shall be closed to stop the signer.
+func StartSigner(endpoint string, ks *keystore.KeyStore) (net.Listener, *rpc.Server, error) {
+	apis := []rpc.API{
+		{
+			Namespace: "account",
+			Version:   "1.0",
+			Service:   NewSignerAPI(ks),
+			Public:    false,
+		},
+	}
+	return rpc.StartIPCEndpoint(endpoint, apis)
+}
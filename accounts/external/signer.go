@@ -0,0 +1,220 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+// Package external implements the wallet backed by an external signer process, which holds the
+// keys and signs the hashes and transactions requested over IPC, so that no unlocked key is kept
+// in the node process.
+package external
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/DxChainNetwork/godx"
+	"github.com/DxChainNetwork/godx/accounts"
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/common/hexutil"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/event"
+	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/rpc"
+)
+
+// signerTimeout is the timeout of a request to the external signer
+const signerTimeout = 30 * time.Second
+
+// ExternalBackend is the account backend of the external signer
+type ExternalBackend struct {
+	signers []accounts.Wallet
+}
+
+// NewExternalBackend creates the account backend connected to the external signer listening at
+// the IPC endpoint
+func NewExternalBackend(endpoint string) (*ExternalBackend, error) {
+	signer, err := NewExternalSigner(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &ExternalBackend{signers: []accounts.Wallet{signer}}, nil
+}
+
+// Wallets implements accounts.Backend, returning the external signer
+func (eb *ExternalBackend) Wallets() []accounts.Wallet {
+	return eb.signers
+}
+
+// Subscribe implements accounts.Backend. The external signer never arrives or departs.
+func (eb *ExternalBackend) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
+// ExternalSigner is the wallet whose accounts are held by the external signer process
+type ExternalSigner struct {
+	client   *rpc.Client
+	endpoint string
+
+	cacheMu sync.RWMutex
+	cache   []accounts.Account
+}
+
+// NewExternalSigner connects to the external signer listening at the IPC endpoint
+func NewExternalSigner(endpoint string) (*ExternalSigner, error) {
+	client, err := rpc.DialIPC(context.Background(), endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the external signer: %v", err)
+	}
+	signer := &ExternalSigner{client: client, endpoint: endpoint}
+	signer.Accounts()
+	return signer, nil
+}
+
+// URL implements accounts.Wallet, returning the IPC endpoint of the external signer
+func (s *ExternalSigner) URL() accounts.URL {
+	return accounts.URL{Scheme: "extapi", Path: s.endpoint}
+}
+
+// Status implements accounts.Wallet, returning whether the external signer is reachable
+func (s *ExternalSigner) Status() (string, error) {
+	var addresses []common.Address
+	if err := s.call(&addresses, "account_list"); err != nil {
+		return "offline", err
+	}
+	return "ok", nil
+}
+
+// Open implements accounts.Wallet. The external signer is opened by itself.
+func (s *ExternalSigner) Open(passphrase string) error {
+	return accounts.ErrNotSupported
+}
+
+// Close implements accounts.Wallet. The external signer is closed by itself.
+func (s *ExternalSigner) Close() error {
+	return nil
+}
+
+// Accounts implements accounts.Wallet, returning the accounts held by the external signer. The
+// accounts last retrieved are returned if the external signer is not reachable.
+func (s *ExternalSigner) Accounts() []accounts.Account {
+	var addresses []common.Address
+	if err := s.call(&addresses, "account_list"); err != nil {
+		s.cacheMu.RLock()
+		defer s.cacheMu.RUnlock()
+		return s.cache
+	}
+
+	accs := make([]accounts.Account, 0, len(addresses))
+	for _, addr := range addresses {
+		accs = append(accs, accounts.Account{Address: addr, URL: s.URL()})
+	}
+	s.cacheMu.Lock()
+	s.cache = accs
+	s.cacheMu.Unlock()
+	return accs
+}
+
+// Contains implements accounts.Wallet, returning whether the account is held by the external signer
+func (s *ExternalSigner) Contains(account accounts.Account) bool {
+	s.cacheMu.RLock()
+	defer s.cacheMu.RUnlock()
+	for _, acc := range s.cache {
+		if acc.Address == account.Address {
+			return true
+		}
+	}
+	return false
+}
+
+// Derive implements accounts.Wallet. The external signer does not derive accounts.
+func (s *ExternalSigner) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	return accounts.Account{}, accounts.ErrNotSupported
+}
+
+// SelfDerive implements accounts.Wallet. The external signer does not derive accounts.
+func (s *ExternalSigner) SelfDerive(base accounts.DerivationPath, chain ethereum.ChainStateReader) {
+}
+
+// SignHash implements accounts.Wallet, requesting the external signer to sign the hash
+func (s *ExternalSigner) SignHash(account accounts.Account, hash []byte) ([]byte, error) {
+	var sig hexutil.Bytes
+	if err := s.call(&sig, "account_signHash", account.Address, hexutil.Bytes(hash)); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// SignTx implements accounts.Wallet, requesting the external signer to sign the transaction. The
+// signed transaction must be the same transaction signed by the account.
+func (s *ExternalSigner) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	raw, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	var signedRaw hexutil.Bytes
+	if err := s.call(&signedRaw, "account_signTransaction", account.Address, hexutil.Bytes(raw), (*hexutil.Big)(chainID)); err != nil {
+		return nil, err
+	}
+
+	signed := new(types.Transaction)
+	if err := rlp.DecodeBytes(signedRaw, signed); err != nil {
+		return nil, err
+	}
+	if err := CheckSignedTx(tx, signed, account.Address, chainID); err != nil {
+		return nil, err
+	}
+	return signed, nil
+}
+
+// SignHashWithPassphrase implements accounts.Wallet. The passphrase is held by the external signer.
+func (s *ExternalSigner) SignHashWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// SignTxWithPassphrase implements accounts.Wallet. The passphrase is held by the external signer.
+func (s *ExternalSigner) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// call calls the method of the external signer with the timeout
+func (s *ExternalSigner) call(result interface{}, method string, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), signerTimeout)
+	defer cancel()
+	return s.client.CallContext(ctx, result, method, args...)
+}
+
+// CheckSignedTx checks whether the signed transaction is the unsigned transaction signed by the
+// address from
+func CheckSignedTx(unsigned, signed *types.Transaction, from common.Address, chainID *big.Int) error {
+	if signed.Nonce() != unsigned.Nonce() || signed.Gas() != unsigned.Gas() ||
+		signed.GasPrice().Cmp(unsigned.GasPrice()) != 0 || signed.Value().Cmp(unsigned.Value()) != 0 ||
+		!equalAddress(signed.To(), unsigned.To()) || !bytes.Equal(signed.Data(), unsigned.Data()) {
+		return fmt.Errorf("signed transaction does not match the transaction requested")
+	}
+
+	var signer types.Signer = types.HomesteadSigner{}
+	if chainID != nil {
+		signer = types.NewEIP155Signer(chainID)
+	}
+	sender, err := types.Sender(signer, signed)
+	if err != nil {
+		return err
+	}
+	if sender != from {
+		return fmt.Errorf("transaction signed by %x instead of %x", sender, from)
+	}
+	return nil
+}
+
+func equalAddress(a, b *common.Address) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
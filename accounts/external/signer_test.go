@@ -0,0 +1,140 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package external
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/accounts"
+	"github.com/DxChainNetwork/godx/accounts/keystore"
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/crypto"
+)
+
+// startTestSigner starts the external signer over the keystore in a temporary directory with
+// the account unlocked, and returns the wallet connected to it
+func startTestSigner(t *testing.T) (*ExternalSigner, *keystore.KeyStore, accounts.Account, func()) {
+	dir, err := ioutil.TempDir("", "external-signer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks := keystore.NewKeyStore(filepath.Join(dir, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.NewAccount("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Unlock(account, "password"); err != nil {
+		t.Fatal(err)
+	}
+
+	endpoint := filepath.Join(dir, "signer.ipc")
+	listener, server, err := StartSigner(endpoint, ks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend, err := NewExternalBackend(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := backend.Wallets()[0].(*ExternalSigner)
+	return signer, ks, account, func() {
+		signer.client.Close()
+		listener.Close()
+		server.Stop()
+		os.RemoveAll(dir)
+	}
+}
+
+// Tests that the hashes and transactions are signed by the external signer over IPC.
+func TestExternalSigner(t *testing.T) {
+	signer, ks, account, teardown := startTestSigner(t)
+	defer teardown()
+
+	accs := signer.Accounts()
+	if len(accs) != 1 || accs[0].Address != account.Address {
+		t.Fatalf("expect account %x, got %v", account.Address, accs)
+	}
+	if !signer.Contains(account) {
+		t.Errorf("account not contained by the external signer")
+	}
+	if status, err := signer.Status(); err != nil || status != "ok" {
+		t.Errorf("expect status ok, got %v, %v", status, err)
+	}
+
+	// sign the hash
+	hash := crypto.Keccak256([]byte("storage contract revision"))
+	sig, err := signer.SignHash(account, hash)
+	if err != nil {
+		t.Fatalf("failed to sign hash: %v", err)
+	}
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crypto.PubkeyToAddress(*pub) != account.Address {
+		t.Errorf("hash signed by %x instead of %x", crypto.PubkeyToAddress(*pub), account.Address)
+	}
+
+	// sign the transaction with and without the chain ID
+	tx := types.NewTransaction(3, common.BytesToAddress([]byte{12}), nil, 90000, big.NewInt(1), []byte{0x01})
+	for _, chainID := range []*big.Int{nil, big.NewInt(5)} {
+		signed, err := signer.SignTx(account, tx, chainID)
+		if err != nil {
+			t.Fatalf("failed to sign tx with chain ID %v: %v", chainID, err)
+		}
+		if err := CheckSignedTx(tx, signed, account.Address, chainID); err != nil {
+			t.Errorf("invalid tx signed with chain ID %v: %v", chainID, err)
+		}
+	}
+
+	// the locked account is not able to sign
+	if err := ks.Lock(account.Address); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := signer.SignHash(account, hash); err == nil {
+		t.Errorf("hash signed by the locked account")
+	}
+}
+
+// Tests that the signed transaction not matching the transaction requested is rejected.
+func TestCheckSignedTx(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	other, _ := crypto.GenerateKey()
+	chainID := big.NewInt(5)
+	signer := types.NewEIP155Signer(chainID)
+
+	tx := types.NewTransaction(3, common.BytesToAddress([]byte{12}), nil, 90000, big.NewInt(1), []byte{0x01})
+	valid, _ := types.SignTx(tx, signer, key)
+	otherData, _ := types.SignTx(types.NewTransaction(3, common.BytesToAddress([]byte{12}), nil, 90000, big.NewInt(1), []byte{0x02}), signer, key)
+	otherNonce, _ := types.SignTx(types.NewTransaction(4, common.BytesToAddress([]byte{12}), nil, 90000, big.NewInt(1), []byte{0x01}), signer, key)
+	otherKey, _ := types.SignTx(tx, signer, other)
+	otherChain, _ := types.SignTx(tx, types.NewEIP155Signer(big.NewInt(6)), key)
+
+	tests := []struct {
+		signed *types.Transaction
+		valid  bool
+	}{
+		{valid, true},
+		{otherData, false},
+		{otherNonce, false},
+		{otherKey, false},
+		{otherChain, false},
+	}
+	for i, test := range tests {
+		err := CheckSignedTx(tx, test.signed, from, chainID)
+		if test.valid && err != nil {
+			t.Errorf("test %d: valid tx rejected: %v", i, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("test %d: invalid tx accepted", i)
+		}
+	}
+}
@@ -61,6 +61,7 @@ var (
 		utils.DataDirFlag,
 		utils.KeyStoreDirFlag,
 		utils.NoUSBFlag,
+		utils.ExternalSignerFlag,
 		utils.EthashCacheDirFlag,
 		utils.EthashCachesInMemoryFlag,
 		utils.EthashCachesOnDiskFlag,
@@ -132,6 +133,7 @@ var (
 		utils.EVMInterpreterFlag,
 		configFileFlag,
 		utils.StorageRoleFlag,
+		utils.StorageOfflineSigningFlag,
 	}
 
 	rpcFlags = []cli.Flag{
@@ -178,6 +180,8 @@ func init() {
 		// See accountcmd.go:
 		accountCommand,
 		walletCommand,
+		// See signercmd.go:
+		signerCommand,
 		// See consolecmd.go:
 		consoleCommand,
 		attachCommand,
@@ -4,15 +4,21 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/DxChainNetwork/godx/cmd/utils"
 	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/common/unit"
 	"github.com/DxChainNetwork/godx/node"
 	"github.com/DxChainNetwork/godx/rpc"
 	"github.com/DxChainNetwork/godx/storage"
 	"github.com/DxChainNetwork/godx/storage/storageclient"
+	"github.com/DxChainNetwork/godx/storage/storageclient/contractmanager"
 	"github.com/DxChainNetwork/godx/storage/storageclient/storagehostmanager"
+	"github.com/DxChainNetwork/godx/storage/storageclient/storagehosttree"
 	"github.com/olekukonko/tablewriter"
 
 	"gopkg.in/urfave/cli.v1"
@@ -44,6 +50,11 @@ var (
 		Usage: "Number of hosts that storage client wants to sign the contract with",
 	}
 
+	manualHostIDFlag = cli.StringFlag{
+		Name:  "host",
+		Usage: "Enode ID of the storage host that storage client wants to sign the contract with",
+	}
+
 	contractRenewFlag = cli.StringFlag{
 		Name:  "renew",
 		Usage: "Time for automatic contract renew",
@@ -54,6 +65,66 @@ var (
 		Usage: "Money can be spent for the file storage within in one period",
 	}
 
+	downloadHedgeFlag = cli.StringFlag{
+		Name:  "hedge",
+		Usage: "Maximum number of extra sectors requested speculatively per segment while downloading",
+	}
+
+	segmentCacheFlag = cli.StringFlag{
+		Name:  "cache",
+		Usage: "Size limit of the local cache of the downloaded segments, 0 disables the cache",
+	}
+
+	auditBudgetFlag = cli.StringFlag{
+		Name:  "auditbudget",
+		Usage: "Maximum amount spent auditing the storage hosts in each period, 0 disables the audits",
+	}
+
+	evalConfigFlag = cli.StringSliceFlag{
+		Name:  "eval",
+		Usage: "Storage host evaluation config in the form of key=value, such as weight.price=2",
+	}
+
+	placementFlag = cli.StringSliceFlag{
+		Name:  "constraint",
+		Usage: "Placement constraint of the storage hosts in the form of label:max=K,min=N, such as asn:max=1",
+	}
+
+	hostLabelFlag = cli.StringFlag{
+		Name:  "label",
+		Usage: "Custom label of the storage host in the form of key=value, such as datacenter=A",
+	}
+
+	geoDBFlag = cli.StringFlag{
+		Name:  "geodb",
+		Usage: "Absolute path of the geo database file with the lines in the form of cidr,asn,region",
+	}
+
+	budgetAlertsFlag = cli.StringFlag{
+		Name:  "alerts",
+		Usage: "Comma separated percentages of the fund at which the budget alerts are raised, such as 50,80,100",
+	}
+
+	budgetHardCapFlag = cli.StringFlag{
+		Name:  "cap",
+		Usage: "Hard cap of the spending within a period, at which the uploads and downloads are paused",
+	}
+
+	profileNameFlag = cli.StringFlag{
+		Name:  "name",
+		Usage: "Name of the storage profile",
+	}
+
+	profileHostIDFlag = cli.StringSliceFlag{
+		Name:  "hostid",
+		Usage: "Enode ID of the storage host the contracts of the profile can be signed with, any storage host if not specified",
+	}
+
+	profilePathFlag = cli.StringFlag{
+		Name:  "dxpath",
+		Usage: "Path of the file or directory in the storage client file system bound to the storage profile",
+	}
+
 	fileSourceFlag = cli.StringFlag{
 		Name:  "src",
 		Usage: "Absolute path of the file that is going to be uploaded/downloaded from (source)",
@@ -64,6 +135,11 @@ var (
 		Usage: "Absolute path of the file that is going to ge uploaded/downloaded to (destination)",
 	}
 
+	fileDedupFlag = cli.BoolFlag{
+		Name:  "dedup",
+		Usage: "Share the uploaded sectors of the identical segments among the files uploaded with dedup",
+	}
+
 	filePathFlag = cli.StringFlag{
 		Name:  "filepath",
 		Usage: "Absolute path of the file",
@@ -138,7 +214,7 @@ allowed storage time, and etc.`,
 			gdx sclient hostrank
 
 will display display detailed host's ranking status including detailed evaluation for 
-each of the storage host`,
+each of the storage host, along with the weights of the evaluation factors`,
 		},
 
 		{
@@ -179,6 +255,94 @@ each file, including the file's uploading status and health status'`,
 
 will display detailed contract information based on the provided contractID. The information
 included contractID, revisionNumber, hostID, and etc.'`,
+			Subcommands: []cli.Command{
+				{
+					Name:      "create",
+					Usage:     "Create the contract with the storage host specified",
+					ArgsUsage: "",
+					Action:    utils.MigrateFlags(createContract),
+					Flags: []cli.Flag{
+						manualHostIDFlag,
+						contractFundFlag,
+						contractPeriodFlag,
+					},
+					Description: `
+			gdx sclient contract create [--host arg] [--fund arg] [--period arg]
+
+will create the contract with the storage host specified by the --host flag, which is the storage
+host enode ID. The contract is funded with --fund and lasts for --period. Both of them are optional,
+and are derived from the client settings if not specified`,
+				},
+				{
+					Name:      "renew",
+					Usage:     "Renew the contract before it is about to expire",
+					ArgsUsage: "",
+					Action:    utils.MigrateFlags(renewContract),
+					Flags: []cli.Flag{
+						contractIDFlag,
+						contractFundFlag,
+						contractPeriodFlag,
+					},
+					Description: `
+			gdx sclient contract renew [--contractid arg] [--fund arg] [--period arg]
+
+will renew the contract specified by the --contractid flag right away. The renewed contract is funded
+with --fund, and its end height is extended by --period. Both of them are optional, and are derived
+from the client settings if not specified`,
+				},
+				{
+					Name:      "cancel",
+					Usage:     "Cancel the contract",
+					ArgsUsage: "",
+					Action:    utils.MigrateFlags(cancelContract),
+					Flags: []cli.Flag{
+						contractIDFlag,
+					},
+					Description: `
+			gdx sclient contract cancel [--contractid arg]
+
+will cancel the contract specified by the --contractid flag. The contract canceled will no longer be
+used for file uploading, and will not be renewed`,
+				},
+				{
+					Name:      "pin",
+					Usage:     "Pin the storage host, so that the contract with it is never dropped",
+					ArgsUsage: "",
+					Action:    utils.MigrateFlags(pinHost),
+					Flags: []cli.Flag{
+						manualHostIDFlag,
+					},
+					Description: `
+			gdx sclient contract pin [--host arg]
+
+will pin the storage host specified by the --host flag. The contract with the pinned storage host
+will not be dropped by the contract maintenance due to low evaluation, ip violation, being offline
+or failed renews`,
+				},
+				{
+					Name:      "unpin",
+					Usage:     "Unpin the storage host",
+					ArgsUsage: "",
+					Action:    utils.MigrateFlags(unpinHost),
+					Flags: []cli.Flag{
+						manualHostIDFlag,
+					},
+					Description: `
+			gdx sclient contract unpin [--host arg]
+
+will unpin the storage host specified by the --host flag`,
+				},
+				{
+					Name:      "pinned",
+					Usage:     "Retrieve the storage hosts pinned",
+					ArgsUsage: "",
+					Action:    utils.MigrateFlags(getPinnedHosts),
+					Description: `
+			gdx sclient contract pinned
+
+will display the storage hosts pinned`,
+				},
+			},
 		},
 
 		{
@@ -218,9 +382,13 @@ flag must be used along with this flag to specify the account address`,
 				contractHostFlag,
 				contractRenewFlag,
 				contractFundFlag,
+				downloadHedgeFlag,
+				segmentCacheFlag,
+				auditBudgetFlag,
+				evalConfigFlag,
 			},
 			Description: `
-			gdx sclient setConfig [--period arg] [--host arg] [--renew arg] [--fund arg]
+			gdx sclient setConfig [--period arg] [--host arg] [--renew arg] [--fund arg] [--hedge arg] [--cache arg] [--auditbudget arg] [--eval key=value]
 		
 will configure the client settings used for contract creation, file upload, download, and etc. There are
 multiple flags can be used along with this command to specify the setting:
@@ -228,10 +396,24 @@ multiple flags can be used along with this command to specify the setting:
 2. host: specifies the number of storage hosts that the client want to sign contracts with
 3. renew: specifies the time that the contract will automatically be renewed.
 4. fund: specifies the amount of money the client wants to be used for the storage service
+5. hedge: specifies the maximum number of extra sectors of a segment requested speculatively from the
+   fastest storage hosts while downloading, which bounds the extra download cost of the hedging
+6. cache: specifies the size limit of the local cache of the downloaded segments, the segments cached
+   are not downloaded and paid for again. 0 disables the cache
+7. auditbudget: specifies the maximum amount spent auditing the storage hosts in each period, where
+   random segments of the sectors stored are requested with the Merkle proofs. 0 disables the audits
+8. eval: specifies the storage host evaluation config, and can be used multiple times. The keys are:
+   weight.<factor>: weight of the evaluation factor between 0 and 10, where 0 disables the factor.
+                    factors: presence, deposit, interaction, price, storage, uptime, and the
+                    additional factors registered such as latency
+   presencematurity: time after which the storage host gets the full presence factor
+   minstorage: remaining storage under which the storage host gets the lowest storage factor
+   interactionexp, priceexp, depositexp: exponents of the interaction, price and deposit curves
 
 units:
 currency: [camel, gcamel, dx]
 time: [h, b, d, w, m, y] -> hour, block, day, week, month, year
+data size: [kb, mb, gb, tb, kib, mib, gib, tib]
 
 Note: without using any of those flags, default settings will be used`,
 		},
@@ -244,13 +426,15 @@ Note: without using any of those flags, default settings will be used`,
 			Flags: []cli.Flag{
 				fileSourceFlag,
 				fileDestinationFlag,
+				fileDedupFlag,
 			},
 			Description: `
-			gdx sclient upload [--src arg] [--dst arg]
+			gdx sclient upload [--src arg] [--dst arg] [--dedup]
 		
 will upload the file specified by the client to the storage hosts. This command must be used along
 with two flags to specify the source of the file that is going to be uploaded, and the destination
-that the file is going to be uploaded to. Note: the src must be absolute path: /home/ubuntu/upload.file`,
+that the file is going to be uploaded to. Note: the src must be absolute path: /home/ubuntu/upload.file
+With the dedup flag, segments identical to the ones uploaded with dedup before are not uploaded again`,
 		},
 
 		{
@@ -328,6 +512,204 @@ will retrieve and display the cost that storage client needs to pay within one p
 The cost includes cost for all contracts. In addition, it also provides the contract fund left,
 fund unspent, and fund withhold, along with the withhold fund release block height`,
 		},
+		{
+			Name:      "flushPack",
+			Usage:     "Upload the pack file holding the small files",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(flushPack),
+			Description: `
+			gdx sclient flushPack
+
+will upload the pack file currently holding the small files without waiting for it to be full.
+Small files are packed into shared pack files, which are uploaded once full or after an hour`,
+		},
+		{
+			Name:      "placement",
+			Usage:     "Retrieve the placement constraints of the storage hosts",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(getPlacement),
+			Description: `
+			gdx sclient placement
+
+will display the placement constraints of the storage hosts selected for the contracts and
+the sectors of each segment`,
+		},
+		{
+			Name:      "setPlacement",
+			Usage:     "Configure the placement constraints of the storage hosts",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(setPlacement),
+			Flags: []cli.Flag{
+				placementFlag,
+			},
+			Description: `
+			gdx sclient setPlacement [--constraint label:max=K,min=N]
+
+will configure the placement constraints of the storage hosts selected for the contracts and the
+sectors of each segment. The constraint flag can be used multiple times, and all constraints are
+removed without the flag. For each constraint:
+   max: at most K storage hosts can have the same value of the label
+   min: storage hosts with new values of the label are preferred until N distinct values are selected
+The built-in labels are subnet, and asn and region provided by the geo database. Custom labels
+can be set with setHostLabel`,
+		},
+		{
+			Name:      "setHostLabel",
+			Usage:     "Set the custom label of the storage host",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(setHostLabel),
+			Flags: []cli.Flag{
+				storageHostIDFlag,
+				hostLabelFlag,
+			},
+			Description: `
+			gdx sclient setHostLabel [--hostid arg] [--label key=value]
+
+will set the custom label of the storage host used by the placement constraints, such as
+datacenter=A. The label is removed if the value is empty, such as datacenter=`,
+		},
+		{
+			Name:      "loadGeoDB",
+			Usage:     "Load the geo database providing the asn and region labels of the storage hosts",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(loadGeoDB),
+			Flags: []cli.Flag{
+				geoDBFlag,
+			},
+			Description: `
+			gdx sclient loadGeoDB [--geodb arg]
+
+will load the offline geo database file providing the asn and region labels of the storage hosts.
+Each line of the file is in the form of cidr,asn,region, such as 10.0.0.0/8,AS64512,us-east.
+The lines starting with # are ignored`,
+		},
+		{
+			Name:      "profile",
+			Usage:     "Retrieve the storage profiles",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(getProfiles),
+			Description: `
+			gdx sclient profile
+
+will display the storage profiles. Each profile has its own rent payment, payment address, storage
+hosts and contracts. The files under the paths bound to the profile are only uploaded to the contracts
+of the profile, and the other files belong to the default profile using the client settings`,
+			Subcommands: []cli.Command{
+				{
+					Name:      "set",
+					Usage:     "Create or update the storage profile",
+					ArgsUsage: "",
+					Action:    utils.MigrateFlags(setProfile),
+					Flags: []cli.Flag{
+						profileNameFlag,
+						paymentAddressFlag,
+						profileHostIDFlag,
+						contractFundFlag,
+						contractHostFlag,
+						contractPeriodFlag,
+						contractRenewFlag,
+					},
+					Description: `
+			gdx sclient profile set [--name arg] [--address arg] [--hostid arg] [--fund arg] [--host arg] [--period arg] [--renew arg]
+
+will create the storage profile specified by the --name flag, or update it if it exists. The contracts of
+the profile are paid by --address, and signed with the storage hosts specified by --hostid, which can be
+used multiple times. The rent payment flags are the same as setConfig, and the rent payment not specified
+is derived from the default settings`,
+				},
+				{
+					Name:      "remove",
+					Usage:     "Remove the storage profile",
+					ArgsUsage: "",
+					Action:    utils.MigrateFlags(removeProfile),
+					Flags: []cli.Flag{
+						profileNameFlag,
+					},
+					Description: `
+			gdx sclient profile remove [--name arg]
+
+will remove the storage profile specified by the --name flag. The contracts of the profile must be
+canceled before the profile is removed`,
+				},
+				{
+					Name:      "bind",
+					Usage:     "Bind the path and its subtree to the storage profile",
+					ArgsUsage: "",
+					Action:    utils.MigrateFlags(bindProfilePath),
+					Flags: []cli.Flag{
+						profileNameFlag,
+						profilePathFlag,
+					},
+					Description: `
+			gdx sclient profile bind [--name arg] [--dxpath arg]
+
+will bind the path specified by --dxpath and its subtree to the storage profile specified by --name.
+The files under the path are only uploaded to the contracts of the profile`,
+				},
+				{
+					Name:      "unbind",
+					Usage:     "Remove the binding of the path to the storage profile",
+					ArgsUsage: "",
+					Action:    utils.MigrateFlags(unbindProfilePath),
+					Flags: []cli.Flag{
+						profilePathFlag,
+					},
+					Description: `
+			gdx sclient profile unbind [--dxpath arg]
+
+will remove the binding of the path specified by --dxpath to the storage profile`,
+				},
+				{
+					Name:      "cost",
+					Usage:     "Retrieve the period cost of each storage profile",
+					ArgsUsage: "",
+					Action:    utils.MigrateFlags(getProfileCosts),
+					Description: `
+			gdx sclient profile cost
+
+will display the period cost of each storage profile, including the default profile`,
+				},
+			},
+		},
+		{
+			Name:      "forecast",
+			Usage:     "Retrieve the spending forecast and the budget alerts",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(getSpendingForecast),
+			Description: `
+			gdx sclient forecast
+
+will display the spending of the current period, the spending rates of the recent blocks, the
+spending forecast at the end of the period, and the budget alerts raised recently`,
+		},
+		{
+			Name:      "audits",
+			Usage:     "Retrieve the results of the audits of the storage hosts",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(getAudits),
+			Description: `
+			gdx sclient audits
+
+will display the audit budget and spending of the current period, the number of audits and failures
+of each storage host, and the sectors the storage hosts failed to prove recently. The storage hosts
+are audited periodically within the audit budget configured by 'gdx sclient setConfig --auditbudget'`,
+		},
+		{
+			Name:      "setBudget",
+			Usage:     "Configure the budget alerts and the hard spending cap",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(setBudget),
+			Flags: []cli.Flag{
+				budgetAlertsFlag,
+				budgetHardCapFlag,
+			},
+			Description: `
+			gdx sclient setBudget [--alerts 50,80,100] [--cap arg]
+
+will configure the percentages of the fund at which the budget alerts are raised, and the hard cap
+of the spending within a period. Once the spending reaches the hard cap, the uploads and downloads
+are paused instead of draining the contracts. No hard cap is set without the cap flag`,
+		},
 	},
 }
 
@@ -354,10 +736,14 @@ func getConfig(ctx *cli.Context) error {
 	ExpecedDownload:                %s
 	Max Upload Speed:               %s
 	Max Download Speed:             %s
+	Download Hedge:                 %s
+	Segment Cache Size:             %s
+	Audit Budget:                   %s
 	IP Violation Check Status:      %s
 `, config.RentPayment.Fund, config.RentPayment.Period, config.RentPayment.StorageHosts, config.RentPayment.RenewWindow,
 		config.RentPayment.ExpectedRedundancy, config.RentPayment.ExpectedStorage, config.RentPayment.ExpectedUpload,
-		config.RentPayment.ExpectedDownload, config.MaxUploadSpeed, config.MaxDownloadSpeed, config.EnableIPViolation)
+		config.RentPayment.ExpectedDownload, config.MaxUploadSpeed, config.MaxDownloadSpeed, config.DownloadHedge,
+		config.SegmentCacheSize, config.AuditBudget, config.EnableIPViolation)
 
 	return nil
 }
@@ -450,12 +836,34 @@ func getRanking(ctx *cli.Context) error {
 		return nil
 	}
 
+	var evalConfig storagehostmanager.EvaluationConfig
+	if err = client.Call(&evalConfig, "sclient_evaluationConfig"); err != nil {
+		utils.Fatalf("failed to retrieve the storage host evaluation config: %s", err.Error())
+	}
+	printEvaluationConfig(evalConfig)
+
 	table := hostRankingTable(rankings)
 	table.Render()
 	fmt.Println()
 	return nil
 }
 
+func printEvaluationConfig(config storagehostmanager.EvaluationConfig) {
+	var weights []string
+	for name, weight := range config.Weights {
+		weights = append(weights, fmt.Sprintf("%s=%v", name, weight))
+	}
+	sort.Strings(weights)
+	if len(weights) == 0 {
+		weights = append(weights, "default")
+	}
+	fmt.Printf("Factor Weights:        %s\n", strings.Join(weights, ", "))
+	fmt.Printf("Presence Maturity:     %v blocks\n", config.PresenceMaturity)
+	fmt.Printf("Min Storage:           %v\n", unit.FormatStorage(config.MinStorage, true))
+	fmt.Printf("Curve Exponents:       interaction=%v, price=%v, deposit=%v\n\n",
+		config.InteractionExponent, config.PriceExponent, config.DepositExponent)
+}
+
 func getContracts(ctx *cli.Context) error {
 	client, err := gdxAttach(ctx)
 	if err != nil {
@@ -488,6 +896,127 @@ func getContracts(ctx *cli.Context) error {
 	return nil
 }
 
+func createContract(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	if !ctx.IsSet(manualHostIDFlag.Name) {
+		utils.Fatalf("the --host flag must be used to specify the storage host the contract is signed with")
+	}
+
+	var contract storageclient.ContractMetaDataAPIDisplay
+	err = client.Call(&contract, "sclient_createContract", ctx.String(manualHostIDFlag.Name),
+		ctx.String(contractFundFlag.Name), ctx.String(contractPeriodFlag.Name))
+	if err != nil {
+		utils.Fatalf("failed to create the contract: %s", err.Error())
+	}
+
+	fmt.Printf("Successfully created the contract %s, which ends at %s\n", contract.ID, contract.EndHeight)
+	return nil
+}
+
+func renewContract(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	if !ctx.IsSet(contractIDFlag.Name) {
+		utils.Fatalf("the --contractid flag must be used to specify which contract to be renewed")
+	}
+
+	var contract storageclient.ContractMetaDataAPIDisplay
+	err = client.Call(&contract, "sclient_renewContract", ctx.String(contractIDFlag.Name),
+		ctx.String(contractFundFlag.Name), ctx.String(contractPeriodFlag.Name))
+	if err != nil {
+		utils.Fatalf("failed to renew the contract: %s", err.Error())
+	}
+
+	fmt.Printf("Successfully renewed the contract to %s, which ends at %s\n", contract.ID, contract.EndHeight)
+	return nil
+}
+
+func cancelContract(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	if !ctx.IsSet(contractIDFlag.Name) {
+		utils.Fatalf("the --contractid flag must be used to specify which contract to be canceled")
+	}
+
+	var resp string
+	if err = client.Call(&resp, "sclient_cancelContract", ctx.String(contractIDFlag.Name)); err != nil {
+		utils.Fatalf("failed to cancel the contract: %s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func pinHost(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	if !ctx.IsSet(manualHostIDFlag.Name) {
+		utils.Fatalf("the --host flag must be used to specify which storage host to be pinned")
+	}
+
+	var resp string
+	if err = client.Call(&resp, "sclient_pinHost", ctx.String(manualHostIDFlag.Name)); err != nil {
+		utils.Fatalf("failed to pin the storage host: %s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func unpinHost(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	if !ctx.IsSet(manualHostIDFlag.Name) {
+		utils.Fatalf("the --host flag must be used to specify which storage host to be unpinned")
+	}
+
+	var resp string
+	if err = client.Call(&resp, "sclient_unpinHost", ctx.String(manualHostIDFlag.Name)); err != nil {
+		utils.Fatalf("failed to unpin the storage host: %s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func getPinnedHosts(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var hostIDs []string
+	if err = client.Call(&hostIDs, "sclient_pinnedHosts"); err != nil {
+		utils.Fatalf("failed to retrieve the storage hosts pinned: %s", err.Error())
+	}
+
+	if len(hostIDs) == 0 {
+		fmt.Println("No storage host pinned")
+		return nil
+	}
+	fmt.Println("Pinned Storage Hosts:")
+	for _, id := range hostIDs {
+		fmt.Printf("\t%s\n", id)
+	}
+	return nil
+}
+
 func getFiles(ctx *cli.Context) error {
 	client, err := gdxAttach(ctx)
 	if err != nil {
@@ -644,6 +1173,26 @@ func setClientConfig(ctx *cli.Context) error {
 		settings["renew"] = ctx.String(contractRenewFlag.Name)
 	}
 
+	if ctx.IsSet(downloadHedgeFlag.Name) {
+		settings["hedge"] = ctx.String(downloadHedgeFlag.Name)
+	}
+
+	if ctx.IsSet(segmentCacheFlag.Name) {
+		settings["cachesize"] = ctx.String(segmentCacheFlag.Name)
+	}
+
+	if ctx.IsSet(auditBudgetFlag.Name) {
+		settings["auditbudget"] = ctx.String(auditBudgetFlag.Name)
+	}
+
+	for _, eval := range ctx.StringSlice(evalConfigFlag.Name) {
+		kv := strings.SplitN(eval, "=", 2)
+		if len(kv) != 2 {
+			utils.Fatalf("the evaluation config %s is not in the form of key=value", eval)
+		}
+		settings[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+
 	var resp string
 	if err = client.Call(&resp, "sclient_setConfig", settings); err != nil {
 		utils.Fatalf("%s", err.Error())
@@ -673,7 +1222,8 @@ func fileUpload(ctx *cli.Context) error {
 	}
 
 	var resp string
-	if err = client.Call(&resp, "sclient_upload", source, destination); err != nil {
+	dedup := ctx.Bool(fileDedupFlag.Name)
+	if err = client.Call(&resp, "sclient_upload", source, destination, dedup); err != nil {
 		utils.Fatalf("failed to upload the file: %s", err.Error())
 	}
 
@@ -824,6 +1374,326 @@ func periodCost(ctx *cli.Context) error {
 	return nil
 }
 
+func flushPack(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remove gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var resp string
+	if err = client.Call(&resp, "sclient_flushPack"); err != nil {
+		utils.Fatalf("failed to flush the pack file: %s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func getPlacement(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var constraints []storagehosttree.PlacementConstraint
+	if err = client.Call(&constraints, "sclient_placement"); err != nil {
+		utils.Fatalf("failed to get the placement constraints: %s", err.Error())
+	}
+
+	if len(constraints) == 0 {
+		fmt.Println("No placement constraint")
+		return nil
+	}
+	fmt.Println("Placement Constraints:")
+	for _, c := range constraints {
+		fmt.Printf("\t%s\n", c.String())
+	}
+	return nil
+}
+
+func setPlacement(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	constraints := ctx.StringSlice(placementFlag.Name)
+	if constraints == nil {
+		constraints = []string{}
+	}
+
+	var resp string
+	if err = client.Call(&resp, "sclient_setPlacement", constraints); err != nil {
+		utils.Fatalf("failed to set the placement constraints: %s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func setHostLabel(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	if !ctx.IsSet(storageHostIDFlag.Name) || !ctx.IsSet(hostLabelFlag.Name) {
+		utils.Fatalf("the --hostid and --label flags must be used to specify the storage host label")
+	}
+	kv := strings.SplitN(ctx.String(hostLabelFlag.Name), "=", 2)
+	if len(kv) != 2 || kv[0] == "" {
+		utils.Fatalf("the label must be in the form of key=value")
+	}
+
+	var resp string
+	if err = client.Call(&resp, "sclient_setHostLabel", ctx.String(storageHostIDFlag.Name), kv[0], kv[1]); err != nil {
+		utils.Fatalf("failed to set the host label: %s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func loadGeoDB(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	if !ctx.IsSet(geoDBFlag.Name) {
+		utils.Fatalf("the --geodb flag must be used to specify the geo database file")
+	}
+
+	var resp string
+	if err = client.Call(&resp, "sclient_loadGeoDB", ctx.String(geoDBFlag.Name)); err != nil {
+		utils.Fatalf("failed to load the geo database: %s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func getProfiles(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var profiles []contractmanager.Profile
+	if err = client.Call(&profiles, "sclient_profiles"); err != nil {
+		utils.Fatalf("failed to get the storage profiles: %s", err.Error())
+	}
+
+	if len(profiles) == 0 {
+		fmt.Println("No storage profile")
+		return nil
+	}
+	for _, p := range profiles {
+		fmt.Printf(`Profile %s:
+	PaymentAddress:               %v
+	Fund:                         %v camel
+	StorageHosts:                 %v
+	Period:                       %v blocks
+	RenewWindow:                  %v blocks
+	CurrentPeriod:                %v
+	Hosts:                        %v
+	Paths:                        %v
+`, p.Name, p.PaymentAddress.String(), p.RentPayment.Fund, p.RentPayment.StorageHosts, p.RentPayment.Period,
+			p.RentPayment.RenewWindow, p.CurrentPeriod, p.Hosts, p.Paths)
+	}
+	return nil
+}
+
+func setProfile(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	if !ctx.IsSet(profileNameFlag.Name) {
+		utils.Fatalf("the --name flag must be used to specify the storage profile")
+	}
+
+	var settings = make(map[string]string)
+	if ctx.IsSet(contractPeriodFlag.Name) {
+		settings["period"] = ctx.String(contractPeriodFlag.Name)
+	}
+	if ctx.IsSet(contractHostFlag.Name) {
+		settings["hosts"] = ctx.String(contractHostFlag.Name)
+	}
+	if ctx.IsSet(contractFundFlag.Name) {
+		settings["fund"] = ctx.String(contractFundFlag.Name)
+	}
+	if ctx.IsSet(contractRenewFlag.Name) {
+		settings["renew"] = ctx.String(contractRenewFlag.Name)
+	}
+	hosts := ctx.StringSlice(profileHostIDFlag.Name)
+	if hosts == nil {
+		hosts = []string{}
+	}
+
+	var resp string
+	err = client.Call(&resp, "sclient_setProfile", ctx.String(profileNameFlag.Name), settings,
+		ctx.String(paymentAddressFlag.Name), hosts)
+	if err != nil {
+		utils.Fatalf("failed to set the storage profile: %s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func removeProfile(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	if !ctx.IsSet(profileNameFlag.Name) {
+		utils.Fatalf("the --name flag must be used to specify the storage profile")
+	}
+
+	var resp string
+	if err = client.Call(&resp, "sclient_removeProfile", ctx.String(profileNameFlag.Name)); err != nil {
+		utils.Fatalf("failed to remove the storage profile: %s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func bindProfilePath(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	if !ctx.IsSet(profileNameFlag.Name) || !ctx.IsSet(profilePathFlag.Name) {
+		utils.Fatalf("the --name and --dxpath flags must be used to specify the path bound to the storage profile")
+	}
+
+	var resp string
+	err = client.Call(&resp, "sclient_bindPath", ctx.String(profilePathFlag.Name), ctx.String(profileNameFlag.Name))
+	if err != nil {
+		utils.Fatalf("failed to bind the path: %s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func unbindProfilePath(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	if !ctx.IsSet(profilePathFlag.Name) {
+		utils.Fatalf("the --dxpath flag must be used to specify the path")
+	}
+
+	var resp string
+	if err = client.Call(&resp, "sclient_unbindPath", ctx.String(profilePathFlag.Name)); err != nil {
+		utils.Fatalf("failed to unbind the path: %s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func getProfileCosts(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var costs map[string]storage.PeriodCost
+	if err = client.Call(&costs, "sclient_profileCosts"); err != nil {
+		utils.Fatalf("failed to get the period cost of the storage profiles: %s", err.Error())
+	}
+
+	var names []string
+	for name := range costs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cost := costs[name]
+		fmt.Printf(`Profile %s:
+	ContractFees:                 %v camel
+	UploadCost:                   %v camel
+	DownloadCost:                 %v camel
+	StorageCost:                  %v camel
+	ContractFund:                 %v camel
+	UnspentFund:                  %v camel
+	WithheldFund:                 %v camel
+`, name, cost.ContractFees, cost.UploadCost, cost.DownloadCost, cost.StorageCost, cost.ContractFund,
+			cost.UnspentFund, cost.WithheldFund)
+	}
+	return nil
+}
+
+func getSpendingForecast(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var forecast contractmanager.SpendingForecast
+	if err = client.Call(&forecast, "sclient_spendingForecast"); err != nil {
+		utils.Fatalf("failed to get the spending forecast: %s", err.Error())
+	}
+	var alerts []contractmanager.BudgetAlert
+	if err = client.Call(&alerts, "sclient_budgetAlerts"); err != nil {
+		utils.Fatalf("failed to get the budget alerts: %s", err.Error())
+	}
+
+	fmt.Printf(`Spending Forecast:
+	BlockHeight:                  %v
+	Period:                       %v - %v
+	Fund:                         %v camel
+	HardCap:                      %v camel
+	Paused:                       %v
+	ContractFees:                 %v camel
+	UploadCost:                   %v camel
+	DownloadCost:                 %v camel
+	StorageCost:                  %v camel
+	Spent:                        %v camel
+	UploadRate:                   %v camel/block
+	DownloadRate:                 %v camel/block
+	StorageRate:                  %v camel/block
+	Forecast:                     %v camel
+	ExhaustHeight:                %v
+`, forecast.BlockHeight, forecast.PeriodStart, forecast.PeriodEnd, forecast.Fund, forecast.HardCap, forecast.Paused,
+		forecast.ContractFees, forecast.UploadCost, forecast.DownloadCost, forecast.StorageCost, forecast.Spent,
+		forecast.UploadRate, forecast.DownloadRate, forecast.StorageRate, forecast.Forecast, forecast.ExhaustHeight)
+
+	if len(alerts) == 0 {
+		fmt.Println("No budget alert")
+		return nil
+	}
+	fmt.Println("Budget Alerts:")
+	for _, alert := range alerts {
+		fmt.Printf("\tblock %v: %s\n", alert.BlockHeight, alert.String())
+	}
+	return nil
+}
+
+func setBudget(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var resp string
+	if err = client.Call(&resp, "sclient_setBudget", ctx.String(budgetAlertsFlag.Name), ctx.String(budgetHardCapFlag.Name)); err != nil {
+		utils.Fatalf("failed to set the budget: %s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
 func gdxAttach(ctx *cli.Context) (*rpc.Client, error) {
 	path := node.DefaultDataDir()
 	if ctx.GlobalIsSet(utils.DataDirFlag.Name) {
@@ -866,15 +1736,40 @@ func hostInfoTable(infos []storage.HostInfo) *tablewriter.Table {
 func hostRankingTable(rankings []storagehostmanager.StorageHostRank) *tablewriter.Table {
 	var formattedData [][]string
 
+	// the additional factors are displayed after the built-in factors
+	extraSet := make(map[string]struct{})
+	for _, rank := range rankings {
+		for name := range rank.ExtraFactors {
+			extraSet[name] = struct{}{}
+		}
+	}
+	var extra []string
+	for name := range extraSet {
+		extra = append(extra, name)
+	}
+	sort.Strings(extra)
+
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"ID", "Total Evaluation", "AgeFactor", "DepositFactor",
-		"InteractionFactor", "PriceFactor", "RemainingStorageFactor", "UptimeFactor"})
+	header := []string{"ID", "Total Evaluation", "AgeFactor", "DepositFactor",
+		"InteractionFactor", "PriceFactor", "RemainingStorageFactor", "UptimeFactor"}
+	for _, name := range extra {
+		header = append(header, strings.Title(name)+"Factor")
+	}
+	table.SetHeader(header)
 
 	for _, rank := range rankings {
 		dataEntry := []string{rank.EnodeID, rank.Evaluation.String(), floatToString(rank.PresenceFactor),
 			floatToString(rank.DepositFactor),
 			floatToString(rank.InteractionFactor), floatToString(rank.ContractPriceFactor),
 			floatToString(rank.StorageRemainingFactor), floatToString(rank.UptimeFactor)}
+		for _, name := range extra {
+			factor, exist := rank.ExtraFactors[name]
+			if !exist {
+				dataEntry = append(dataEntry, "-")
+				continue
+			}
+			dataEntry = append(dataEntry, floatToString(factor))
+		}
 
 		formattedData = append(formattedData, dataEntry)
 	}
@@ -897,3 +1792,41 @@ func boolToString(val bool) string {
 	}
 	return "false"
 }
+
+func getAudits(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var report storageclient.AuditReport
+	if err = client.Call(&report, "sclient_audits"); err != nil {
+		utils.Fatalf("failed to get the audit results: %s", err.Error())
+	}
+
+	fmt.Printf(`Audits:
+	PeriodStart:                  %v
+	Budget:                       %v camel
+	Spent:                        %v camel
+`, report.PeriodStart, report.Budget, report.Spent)
+
+	if len(report.Hosts) == 0 {
+		fmt.Println("No storage host audited")
+		return nil
+	}
+	fmt.Println("Storage Hosts:")
+	for _, host := range report.Hosts {
+		fmt.Printf("\t%v: audits %v, failures %v, spent %v camel, last audit %v\n", host.HostID, host.Audits,
+			host.Failures, host.Spent, host.LastAudit.Format(time.RFC3339))
+	}
+
+	if len(report.Failures) == 0 {
+		return nil
+	}
+	fmt.Println("Recent Failures:")
+	for _, failure := range report.Failures {
+		fmt.Printf("\t%v %v: %v segment %v sector %v: %v\n", failure.Time.Format(time.RFC3339), failure.HostID,
+			failure.DxPath, failure.SegmentIndex, failure.MerkleRoot.Hex(), failure.Error)
+	}
+	return nil
+}
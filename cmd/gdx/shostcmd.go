@@ -6,7 +6,11 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
+	"strings"
+
 	"github.com/DxChainNetwork/godx/cmd/utils"
+	"github.com/DxChainNetwork/godx/common/unit"
 	"github.com/DxChainNetwork/godx/storage"
 	"github.com/DxChainNetwork/godx/storage/storagehost"
 
@@ -73,6 +77,161 @@ var (
 		Name:  "folderPath",
 		Usage: "Path of the folder",
 	}
+
+	autoPricingFlag = cli.StringFlag{
+		Name:  "autoPricing",
+		Usage: "BOOL - whether the host adjusts its prices automatically",
+	}
+
+	pricingIntervalFlag = cli.StringFlag{
+		Name:  "interval",
+		Usage: "DURATION - the interval between two automatic price updates",
+	}
+
+	announceThresholdFlag = cli.StringFlag{
+		Name:  "announceThreshold",
+		Usage: "PERCENTAGE - the price change that triggers a re-announcement",
+	}
+
+	minStoragePriceFlag = cli.StringFlag{
+		Name:  "minStoragePrice",
+		Usage: "CURRENCY - the lower bound of the automatic storage price",
+	}
+
+	maxStoragePriceFlag = cli.StringFlag{
+		Name:  "maxStoragePrice",
+		Usage: "CURRENCY - the upper bound of the automatic storage price",
+	}
+
+	minUploadPriceFlag = cli.StringFlag{
+		Name:  "minUploadPrice",
+		Usage: "CURRENCY - the lower bound of the automatic upload bandwidth price",
+	}
+
+	maxUploadPriceFlag = cli.StringFlag{
+		Name:  "maxUploadPrice",
+		Usage: "CURRENCY - the upper bound of the automatic upload bandwidth price",
+	}
+
+	minDownloadPriceFlag = cli.StringFlag{
+		Name:  "minDownloadPrice",
+		Usage: "CURRENCY - the lower bound of the automatic download bandwidth price",
+	}
+
+	maxDownloadPriceFlag = cli.StringFlag{
+		Name:  "maxDownloadPrice",
+		Usage: "CURRENCY - the upper bound of the automatic download bandwidth price",
+	}
+
+	minContractPriceFlag = cli.StringFlag{
+		Name:  "minContractPrice",
+		Usage: "CURRENCY - the lower bound of the automatic contract price",
+	}
+
+	maxContractPriceFlag = cli.StringFlag{
+		Name:  "maxContractPrice",
+		Usage: "CURRENCY - the upper bound of the automatic contract price",
+	}
+
+	minContractDurationFlag = cli.StringFlag{
+		Name:  "minDuration",
+		Usage: "DURATION - the minimum duration of the contracts accepted by the host",
+	}
+
+	minContractFundFlag = cli.StringFlag{
+		Name:  "minFund",
+		Usage: "CURRENCY - the minimum client fund of the contracts accepted by the host",
+	}
+
+	maxStoragePerClientFlag = cli.StringFlag{
+		Name:  "maxClientStorage",
+		Usage: "STORAGE - the maximum size of data a single client can store on the host",
+	}
+
+	maxConcurrentContractsFlag = cli.StringFlag{
+		Name:  "maxContracts",
+		Usage: "the maximum number of concurrent contracts of the host",
+	}
+
+	policyClientFlag = cli.StringFlag{
+		Name:  "client",
+		Usage: "the client address, enode ID or enode URL",
+	}
+
+	policyRemoveFlag = cli.BoolFlag{
+		Name:  "remove",
+		Usage: "remove the client from the list instead of adding it",
+	}
+
+	proofFallbackFlag = cli.StringFlag{
+		Name:  "fallbackAddress",
+		Usage: "the funded account used to submit the storage proof if the payment address fails",
+	}
+
+	proofRetryIntervalFlag = cli.StringFlag{
+		Name:  "retryInterval",
+		Usage: "DURATION - the interval to resubmit the storage proof until it is included",
+	}
+
+	proofAlertWindowFlag = cli.StringFlag{
+		Name:  "alertWindow",
+		Usage: "DURATION - raise alerts when an unconfirmed storage proof is within the window of its deadline",
+	}
+
+	gasPriceBumpFlag = cli.StringFlag{
+		Name:  "gasPriceBump",
+		Usage: "PERCENTAGE - the gas price increase for each storage proof resubmission",
+	}
+
+	maxGasPriceFlag = cli.StringFlag{
+		Name:  "maxGasPrice",
+		Usage: "CURRENCY - the upper bound of the storage proof gas price, zero means no limitation",
+	}
+
+	usageStartFlag = cli.Uint64Flag{
+		Name:  "start",
+		Usage: "the start block height of the usage report window",
+	}
+
+	usageEndFlag = cli.Uint64Flag{
+		Name:  "end",
+		Usage: "the end block height of the usage report window, zero means the latest block",
+	}
+
+	usageFormatFlag = cli.StringFlag{
+		Name:  "format",
+		Usage: "export the usage report in the format, supported formats are json and csv",
+	}
+
+	usageOutputFlag = cli.StringFlag{
+		Name:  "output",
+		Usage: "the file the exported usage report is written to, default to stdout",
+	}
+
+	altAddressesFlag = cli.StringFlag{
+		Name:  "altAddresses",
+		Usage: "comma separated alternative addresses announced along with the node address, in the format of ip or ip:port",
+	}
+
+	autoAnnounceFlag = cli.StringFlag{
+		Name:  "autoAnnounce",
+		Usage: "whether to re-announce the host automatically once the external IP changes",
+	}
+
+	announceIntervalFlag = cli.StringFlag{
+		Name:  "announceInterval",
+		Usage: "DURATION - the minimum duration between the automatic announcements",
+	}
+
+	keyLifetimeFlag = cli.StringFlag{
+		Name:  "keyLifetime",
+		Usage: "DURATION - the lifetime of the announcement key, zero means signing the announcement with the node key",
+	}
+
+	keyAccountsFlag = cli.StringFlag{
+		Name:  "keyAccounts",
+		Usage: "comma separated accounts in the keystore used as the announcement keys in turn",
+	}
 )
 
 var storageHostCommand = cli.Command{
@@ -125,6 +284,119 @@ The values are associated with units.
 	CURRENCY:   {"camel", "gcamel", "dx"}
 	DURATION:   {"h", "b", "d", "w", "m", "y"}`,
 		},
+		{
+			Name:      "pricing",
+			Usage:     "Retrieve the automatic pricing configurations",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(getHostPricing),
+			Description: `
+			gdx shost pricing
+
+will display the configuration of the automatic pricing engine, including whether it is
+enabled, the update interval, the re-announce threshold and the price bounds.`,
+		},
+
+		{
+			Name:      "setPricing",
+			Usage:     "Set the automatic pricing configurations",
+			ArgsUsage: "",
+			Flags: []cli.Flag{
+				autoPricingFlag,
+				pricingIntervalFlag,
+				announceThresholdFlag,
+				minStoragePriceFlag,
+				maxStoragePriceFlag,
+				minUploadPriceFlag,
+				maxUploadPriceFlag,
+				minDownloadPriceFlag,
+				maxDownloadPriceFlag,
+				minContractPriceFlag,
+				maxContractPriceFlag,
+			},
+			Action: utils.MigrateFlags(setHostPricing),
+			Description: `
+			gdx shost setPricing [--autoPricing arg] [--interval arg] [--announceThreshold arg] [--minStoragePrice arg] [--maxStoragePrice arg] ...
+
+change the configuration of the automatic pricing engine. Once enabled, the host derives the
+storage, upload, download and contract prices from the remaining storage, the revenue history
+and the prices of other announced hosts. The prices never go beyond the bounds set by the
+operator, and the host is re-announced when a price moves past the announce threshold.
+
+The values are associated with units.
+	BOOL:       {"true", "false"}
+	CURRENCY:   {"camel", "gcamel", "dx"}
+	DURATION:   {"h", "b", "d", "w", "m", "y"}
+	PERCENTAGE: {"10%", "0.1"}`,
+		},
+
+		{
+			Name:      "policy",
+			Usage:     "Retrieve the contract acceptance policy",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(getHostContractPolicy),
+			Description: `
+			gdx shost policy
+
+will display the policy the host uses to accept storage contracts, including the client
+allowlist and denylist, the minimum contract duration and fund, the maximum storage per
+client and the maximum number of concurrent contracts.`,
+		},
+
+		{
+			Name:      "setPolicy",
+			Usage:     "Set the contract acceptance policy",
+			ArgsUsage: "",
+			Flags: []cli.Flag{
+				minContractDurationFlag,
+				minContractFundFlag,
+				maxStoragePerClientFlag,
+				maxConcurrentContractsFlag,
+			},
+			Action: utils.MigrateFlags(setHostContractPolicy),
+			Description: `
+			gdx shost setPolicy [--minDuration arg] [--minFund arg] [--maxClientStorage arg] [--maxContracts arg]
+
+change the policy the host uses to accept storage contracts. The contract requests not
+meeting the policy are rejected during the negotiation. Zero value means no limitation.
+
+The values are associated with units.
+	CURRENCY:   {"camel", "gcamel", "dx"}
+	DURATION:   {"h", "b", "d", "w", "m", "y"}
+	STORAGE:    {"kb", "mb", "gb", "tb", "kib", "mib", "gib", "tib"}`,
+		},
+
+		{
+			Name:      "allow",
+			Usage:     "Add or remove the client from the contract allowlist",
+			ArgsUsage: "",
+			Flags: []cli.Flag{
+				policyClientFlag,
+				policyRemoveFlag,
+			},
+			Action: utils.MigrateFlags(updateHostAllowlist),
+			Description: `
+			gdx shost allow --client arg [--remove]
+
+add the client address or enode ID to the allowlist of the host. Once the allowlist is not
+empty, only the clients in the allowlist are able to create contracts with the host.`,
+		},
+
+		{
+			Name:      "deny",
+			Usage:     "Add or remove the client from the contract denylist",
+			ArgsUsage: "",
+			Flags: []cli.Flag{
+				policyClientFlag,
+				policyRemoveFlag,
+			},
+			Action: utils.MigrateFlags(updateHostDenylist),
+			Description: `
+			gdx shost deny --client arg [--remove]
+
+add the client address or enode ID to the denylist of the host. The contract requests from
+the clients in the denylist are always rejected.`,
+		},
+
 		{
 			Name:      "setPaymentAddr",
 			Usage:     "Register the account address to be used for the storage services",
@@ -165,6 +437,117 @@ will display the detailed financial metrics for hosting the storage service, inc
 potential revenue.`,
 		},
 
+		{
+			Name:      "proof",
+			Usage:     "Retrieve the storage proof submission configurations",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(getHostProofConfig),
+			Description: `
+			gdx shost proof
+
+will display the configuration for submitting storage proofs, including the fallback account,
+the resubmission interval, the gas price bump, the gas price limit and the alert window.`,
+		},
+
+		{
+			Name:      "setProof",
+			Usage:     "Set the storage proof submission configurations",
+			ArgsUsage: "",
+			Flags: []cli.Flag{
+				proofFallbackFlag,
+				proofRetryIntervalFlag,
+				proofAlertWindowFlag,
+				gasPriceBumpFlag,
+				maxGasPriceFlag,
+			},
+			Action: utils.MigrateFlags(setHostProofConfig),
+			Description: `
+			gdx shost setProof [--fallbackAddress arg] [--retryInterval arg] [--alertWindow arg] [--gasPriceBump arg] [--maxGasPrice arg]
+
+change the configuration for submitting storage proofs. The storage proof is resubmitted with
+a bumped gas price until it is included in a block. If the proof cannot be sent from the
+payment address, it is sent from the fallback account. Alerts are raised when the proof is
+close to its deadline and still not confirmed.
+
+The values are associated with units.
+	CURRENCY:   {"camel", "gcamel", "dx"}
+	DURATION:   {"h", "b", "d", "w", "m", "y"}
+	PERCENTAGE: {"20%", "20"}`,
+		},
+
+		{
+			Name:      "announcement",
+			Usage:     "Retrieve the host announcement configurations",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(getHostAnnounceConfig),
+			Description: `
+			gdx shost announcement
+
+will display the configuration of the host announcement, including the alternative addresses,
+the automatic announcement settings, the announcement key and the last announcement.`,
+		},
+
+		{
+			Name:      "setAnnouncement",
+			Usage:     "Set the host announcement configurations",
+			ArgsUsage: "",
+			Flags: []cli.Flag{
+				altAddressesFlag,
+				autoAnnounceFlag,
+				announceIntervalFlag,
+				keyLifetimeFlag,
+				keyAccountsFlag,
+			},
+			Action: utils.MigrateFlags(setHostAnnounceConfig),
+			Description: `
+			gdx shost setAnnouncement [--altAddresses arg] [--autoAnnounce arg] [--announceInterval arg] [--keyLifetime arg] [--keyAccounts arg]
+
+change the configuration of the host announcement. The alternative addresses are announced along
+with the node address, so that the clients could reach the host through any of them. If auto
+announcement is enabled, the host is re-announced once the external IP changes, at most once
+every announce interval. If the key lifetime is set, the announcement is signed by a rotating
+announcement key certified by the node key. The announcement keys are the key accounts held by
+the account manager, which are used in turn. The extended announcement is only sent once the
+storage fork extending the host announcements is active. The new configuration takes effect on
+the next announcement.
+
+The values are associated with units.
+	DURATION:   {"h", "b", "d", "w", "m", "y"}`,
+		},
+
+		{
+			Name:      "rotateKey",
+			Usage:     "Rotate the host announcement key",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(rotateHostAnnouncementKey),
+			Description: `
+			gdx shost rotateKey
+
+will certify the next announcement key account with the node key. The new key is used from the
+next announcement.`,
+		},
+
+		{
+			Name:      "usage",
+			Usage:     "Retrieve the usage of the storage host by each client",
+			ArgsUsage: "",
+			Flags: []cli.Flag{
+				usageStartFlag,
+				usageEndFlag,
+				usageFormatFlag,
+				usageOutputFlag,
+			},
+			Action: utils.MigrateFlags(getClientUsage),
+			Description: `
+			gdx shost usage [--start arg] [--end arg] [--format arg] [--output arg]
+
+will display the usage of the storage host aggregated by the client payment address, including
+the data stored, the upload and download bandwidth served, the revenue realized and potential,
+and the storage proofs submitted and missed. Only the contracts active between the start and
+end block heights are counted. With the --format flag, the report is exported in json or csv
+format to stdout or the file specified by the --output flag.`,
+		},
+
 		{
 			Name:      "announce",
 			Usage:     "Announce the node as a storage host node",
@@ -351,6 +734,160 @@ func hostConfigFromFlags(ctx *cli.Context) map[string]string {
 	return config
 }
 
+func getHostPricing(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var config storagehost.PricingConfigForDisplay
+	if err = client.Call(&config, "shost_getPricingConfig"); err != nil {
+		utils.Fatalf("failed to get the storage host pricing configuration: %s", err.Error())
+	}
+
+	fmt.Printf(`Host Pricing Configuration:
+	AutoPricing:                   %v
+	UpdateInterval:                %v
+	AnnounceThreshold:             %v
+	MinStoragePrice:               %v
+	MaxStoragePrice:               %v
+	MinUploadBandwidthPrice:       %v
+	MaxUploadBandwidthPrice:       %v
+	MinDownloadBandwidthPrice:     %v
+	MaxDownloadBandwidthPrice:     %v
+	MinContractPrice:              %v
+	MaxContractPrice:              %v
+	LastUpdateHeight:              %v
+	KnownHosts:                    %v
+`, config.AutoPricing, config.UpdateInterval, config.AnnounceThreshold,
+		config.MinStoragePrice, config.MaxStoragePrice, config.MinUploadBandwidthPrice,
+		config.MaxUploadBandwidthPrice, config.MinDownloadBandwidthPrice, config.MaxDownloadBandwidthPrice,
+		config.MinContractPrice, config.MaxContractPrice, config.LastUpdateHeight, config.KnownHosts)
+
+	return nil
+}
+
+// setHostPricing set the automatic pricing configurations
+func setHostPricing(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	// mapping from the flag to the pricing config field
+	fields := map[string]string{
+		autoPricingFlag.Name:       "autoPricing",
+		pricingIntervalFlag.Name:   "updateInterval",
+		announceThresholdFlag.Name: "announceThreshold",
+		minStoragePriceFlag.Name:   "minStoragePrice",
+		maxStoragePriceFlag.Name:   "maxStoragePrice",
+		minUploadPriceFlag.Name:    "minUploadBandwidthPrice",
+		maxUploadPriceFlag.Name:    "maxUploadBandwidthPrice",
+		minDownloadPriceFlag.Name:  "minDownloadBandwidthPrice",
+		maxDownloadPriceFlag.Name:  "maxDownloadBandwidthPrice",
+		minContractPriceFlag.Name:  "minContractPrice",
+		maxContractPriceFlag.Name:  "maxContractPrice",
+	}
+	config := make(map[string]string)
+	for flag, field := range fields {
+		if ctx.IsSet(flag) {
+			config[field] = ctx.String(flag)
+		}
+	}
+
+	var resp string
+	if err = client.Call(&resp, "shost_setPricingConfig", config); err != nil {
+		utils.Fatalf("failed to set pricing config: %v", err)
+	}
+	fmt.Printf("%v\n", resp)
+	return nil
+}
+
+func getHostContractPolicy(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var policy storagehost.ContractPolicyForDisplay
+	if err = client.Call(&policy, "shost_getContractPolicy"); err != nil {
+		utils.Fatalf("failed to get the storage host contract policy: %s", err.Error())
+	}
+
+	fmt.Printf(`Host Contract Policy:
+	ClientAllowlist:               %v
+	ClientDenylist:                %v
+	MinContractDuration:           %v
+	MinContractFund:               %v
+	MaxStoragePerClient:           %v
+	MaxConcurrentContracts:        %v
+`, policy.ClientAllowlist, policy.ClientDenylist, policy.MinContractDuration,
+		policy.MinContractFund, policy.MaxStoragePerClient, policy.MaxConcurrentContracts)
+
+	return nil
+}
+
+// setHostContractPolicy set the contract acceptance policy
+func setHostContractPolicy(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	// mapping from the flag to the contract policy field
+	fields := map[string]string{
+		minContractDurationFlag.Name:    "minContractDuration",
+		minContractFundFlag.Name:        "minContractFund",
+		maxStoragePerClientFlag.Name:    "maxStoragePerClient",
+		maxConcurrentContractsFlag.Name: "maxConcurrentContracts",
+	}
+	policy := make(map[string]string)
+	for flag, field := range fields {
+		if ctx.IsSet(flag) {
+			policy[field] = ctx.String(flag)
+		}
+	}
+
+	var resp string
+	if err = client.Call(&resp, "shost_setContractPolicy", policy); err != nil {
+		utils.Fatalf("failed to set contract policy: %v", err)
+	}
+	fmt.Printf("%v\n", resp)
+	return nil
+}
+
+func updateHostAllowlist(ctx *cli.Context) error {
+	return updateHostPolicyList(ctx, "shost_addAllowlist", "shost_removeAllowlist")
+}
+
+func updateHostDenylist(ctx *cli.Context) error {
+	return updateHostPolicyList(ctx, "shost_addDenylist", "shost_removeDenylist")
+}
+
+// updateHostPolicyList add or remove the client specified by the flag with the given methods
+func updateHostPolicyList(ctx *cli.Context, addMethod, removeMethod string) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	if !ctx.IsSet(policyClientFlag.Name) {
+		utils.Fatalf("the --client flag must be used to specify the client address or enode ID")
+	}
+	entry := ctx.String(policyClientFlag.Name)
+
+	method := addMethod
+	if ctx.Bool(policyRemoveFlag.Name) {
+		method = removeMethod
+	}
+	var resp string
+	if err = client.Call(&resp, method, entry); err != nil {
+		utils.Fatalf("failed to update the client list: %v", err)
+	}
+	fmt.Printf("%v\n", resp)
+	return nil
+}
+
 func setHostPaymentAddress(ctx *cli.Context) error {
 	client, err := gdxAttach(ctx)
 	if err != nil {
@@ -435,6 +972,187 @@ func getFinance(ctx *cli.Context) error {
 	return nil
 }
 
+func getHostProofConfig(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var config storagehost.ProofConfigForDisplay
+	if err = client.Call(&config, "shost_getProofConfig"); err != nil {
+		utils.Fatalf("failed to get the storage host proof configuration: %s", err.Error())
+	}
+
+	fmt.Printf(`Host Proof Configuration:
+	FallbackAddress:               %v
+	RetryInterval:                 %v
+	AlertWindow:                   %v
+	GasPriceBump:                  %v
+	MaxGasPrice:                   %v
+`, config.FallbackAddress, config.RetryInterval, config.AlertWindow, config.GasPriceBump, config.MaxGasPrice)
+
+	return nil
+}
+
+// setHostProofConfig set the storage proof submission configurations
+func setHostProofConfig(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	// mapping from the flag to the proof config field
+	fields := map[string]string{
+		proofFallbackFlag.Name:      "fallbackAddress",
+		proofRetryIntervalFlag.Name: "retryInterval",
+		proofAlertWindowFlag.Name:   "alertWindow",
+		gasPriceBumpFlag.Name:       "gasPriceBump",
+		maxGasPriceFlag.Name:        "maxGasPrice",
+	}
+	config := make(map[string]string)
+	for flag, field := range fields {
+		if ctx.IsSet(flag) {
+			config[field] = ctx.String(flag)
+		}
+	}
+
+	var resp string
+	if err = client.Call(&resp, "shost_setProofConfig", config); err != nil {
+		utils.Fatalf("failed to set proof config: %v", err)
+	}
+	fmt.Printf("%v\n", resp)
+	return nil
+}
+
+// getHostAnnounceConfig retrieve the host announcement configurations
+func getHostAnnounceConfig(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var config storagehost.AnnounceConfigForDisplay
+	if err = client.Call(&config, "shost_getAnnounceConfig"); err != nil {
+		utils.Fatalf("failed to get the storage host announce configuration: %s", err.Error())
+	}
+
+	fmt.Printf(`Host Announce Configuration:
+	AltAddresses:                  %v
+	AutoAnnounce:                  %v
+	AnnounceInterval:              %v
+	KeyLifetime:                   %v
+	KeyAccounts:                   %v
+	AnnouncementKey:               %v
+	KeyExpiry:                     %v
+	LastAnnounceHeight:            %v
+	AnnouncedIP:                   %v
+`, strings.Join(config.AltAddresses, ", "), config.AutoAnnounce, config.AnnounceInterval, config.KeyLifetime,
+		strings.Join(config.KeyAccounts, ", "), config.AnnouncementKey, config.KeyExpiry, config.LastAnnounceHeight, config.AnnouncedIP)
+
+	return nil
+}
+
+// setHostAnnounceConfig set the host announcement configurations
+func setHostAnnounceConfig(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	// mapping from the flag to the announce config field
+	fields := map[string]string{
+		altAddressesFlag.Name:     "altAddresses",
+		autoAnnounceFlag.Name:     "autoAnnounce",
+		announceIntervalFlag.Name: "announceInterval",
+		keyLifetimeFlag.Name:      "keyLifetime",
+		keyAccountsFlag.Name:      "keyAccounts",
+	}
+	config := make(map[string]string)
+	for flag, field := range fields {
+		if ctx.IsSet(flag) {
+			config[field] = ctx.String(flag)
+		}
+	}
+
+	var resp string
+	if err = client.Call(&resp, "shost_setAnnounceConfig", config); err != nil {
+		utils.Fatalf("failed to set announce config: %v", err)
+	}
+	fmt.Printf("%v\n", resp)
+	return nil
+}
+
+// rotateHostAnnouncementKey generate a new announcement key for the host
+func rotateHostAnnouncementKey(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var resp string
+	if err = client.Call(&resp, "shost_rotateAnnouncementKey"); err != nil {
+		utils.Fatalf("failed to rotate the announcement key: %v", err)
+	}
+	fmt.Printf("%v\n", resp)
+	return nil
+}
+
+func getClientUsage(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	start, end := ctx.Uint64(usageStartFlag.Name), ctx.Uint64(usageEndFlag.Name)
+
+	// export the report in the format specified
+	if ctx.IsSet(usageFormatFlag.Name) {
+		var report string
+		if err = client.Call(&report, "shost_exportClientUsage", start, end, ctx.String(usageFormatFlag.Name)); err != nil {
+			utils.Fatalf("failed to export the client usage: %s", err.Error())
+		}
+		if !ctx.IsSet(usageOutputFlag.Name) {
+			fmt.Println(report)
+			return nil
+		}
+		output := ctx.String(usageOutputFlag.Name)
+		if err = ioutil.WriteFile(output, []byte(report), 0644); err != nil {
+			utils.Fatalf("failed to write the client usage report: %s", err.Error())
+		}
+		fmt.Printf("Client usage report has been written to %s\n", output)
+		return nil
+	}
+
+	var usages []storagehost.ClientUsage
+	if err = client.Call(&usages, "shost_getClientUsage", start, end); err != nil {
+		utils.Fatalf("failed to get the client usage: %s", err.Error())
+	}
+	if len(usages) == 0 {
+		fmt.Println("No client has used the storage host in the block window")
+		return nil
+	}
+
+	for _, cu := range usages {
+		fmt.Printf(`Client %v:
+	Contracts:                     %v
+	ActiveContracts:               %v
+	StoredData:                    %v
+	UploadBandwidth:               %v
+	DownloadBandwidth:             %v
+	RealizedRevenue:               %v
+	PotentialRevenue:              %v
+	LostRevenue:                   %v
+	ProofsSubmitted:               %v
+	ProofsMissed:                  %v
+
+`, cu.Client.Hex(), cu.Contracts, cu.ActiveContracts, unit.FormatStorage(cu.StoredBytes, false),
+			unit.FormatStorage(cu.UploadBandwidth, false), unit.FormatStorage(cu.DownloadBandwidth, false),
+			unit.FormatCurrency(cu.RealizedRevenue), unit.FormatCurrency(cu.PotentialRevenue),
+			unit.FormatCurrency(cu.LostRevenue), cu.ProofsSubmitted, cu.ProofsMissed)
+	}
+	return nil
+}
+
 func makeAnnounce(ctx *cli.Context) error {
 	client, err := gdxAttach(ctx)
 	if err != nil {
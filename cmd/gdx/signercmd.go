@@ -0,0 +1,272 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/DxChainNetwork/godx/accounts/external"
+	"github.com/DxChainNetwork/godx/accounts/keystore"
+	"github.com/DxChainNetwork/godx/cmd/utils"
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/common/hexutil"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/internal/ethapi"
+	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/rlp"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	signerEndpointFlag = cli.StringFlag{
+		Name:  "endpoint",
+		Usage: "IPC endpoint the external signer listens at",
+		Value: "signer.ipc",
+	}
+
+	signerFromFlag = cli.StringFlag{
+		Name:  "from",
+		Usage: "Address of the sender of the unsigned transaction",
+	}
+
+	signerNonceFlag = cli.Uint64Flag{
+		Name:  "nonce",
+		Usage: "Nonce of the unsigned transaction",
+	}
+)
+
+var signerCommand = cli.Command{
+	Name:      "signer",
+	Usage:     "External and offline signing of storage contract transactions",
+	ArgsUsage: "",
+	Category:  "ACCOUNT COMMANDS",
+	Description: `
+	gdx signer commands
+
+The keys of the storage host and client are kept out of the internet-facing node, either held
+by an external signer process connected over IPC with the --signer flag, or used offline to sign
+the transactions queued unsigned by the node started with the --storage.offlinesigning flag.`,
+	Subcommands: []cli.Command{
+		{
+			Name:      "serve",
+			Usage:     "Start the external signer holding the keys of the keystore",
+			ArgsUsage: "",
+			Flags: []cli.Flag{
+				utils.DataDirFlag,
+				utils.KeyStoreDirFlag,
+				utils.LightKDFFlag,
+				utils.UnlockedAccountFlag,
+				utils.PasswordFileFlag,
+				signerEndpointFlag,
+			},
+			Action: utils.MigrateFlags(serveSigner),
+			Description: `
+			gdx signer serve --unlock addresses [--password file] [--endpoint path]
+
+will unlock the accounts in the keystore and sign the hashes and transactions requested by the
+node connected over the IPC endpoint, until interrupted. The node is connected to the signer by
+starting it with --signer path.`,
+		},
+
+		{
+			Name:      "pending",
+			Usage:     "List the storage contract transactions waiting to be signed offline",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(getUnsignedStorageTxs),
+			Description: `
+			gdx signer pending
+
+will display the unsigned storage contract transactions queued by the node in JSON, including
+the raw transaction and the chain ID to sign it with.`,
+		},
+
+		{
+			Name:      "sign",
+			Usage:     "Sign the raw unsigned transaction offline",
+			ArgsUsage: "<raw transaction> [chain ID]",
+			Flags: []cli.Flag{
+				utils.DataDirFlag,
+				utils.KeyStoreDirFlag,
+				utils.LightKDFFlag,
+				utils.PasswordFileFlag,
+				signerFromFlag,
+			},
+			Action: utils.MigrateFlags(signStorageTx),
+			Description: `
+			gdx signer sign --from address [--password file] <raw transaction> [chain ID]
+
+will sign the raw unsigned transaction listed by 'gdx signer pending' with the account in the
+keystore, and print the raw signed transaction. The command does not connect to any node, and
+is meant to run on the offline machine holding the keystore.`,
+		},
+
+		{
+			Name:      "submit",
+			Usage:     "Submit the storage contract transaction signed offline",
+			ArgsUsage: "<raw signed transaction>",
+			Action:    utils.MigrateFlags(submitSignedStorageTx),
+			Description: `
+			gdx signer submit <raw signed transaction>
+
+will submit the transaction signed offline to the node. The transaction must be one of the
+unsigned transactions queued by the node.`,
+		},
+
+		{
+			Name:      "discard",
+			Usage:     "Discard the storage contract transaction waiting to be signed offline",
+			ArgsUsage: "",
+			Flags: []cli.Flag{
+				signerFromFlag,
+				signerNonceFlag,
+			},
+			Action: utils.MigrateFlags(discardUnsignedStorageTx),
+			Description: `
+			gdx signer discard --from address --nonce nonce
+
+will remove the unsigned storage contract transaction from the queue of the node.`,
+		},
+	},
+}
+
+func serveSigner(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+
+	passwords := utils.MakePasswordList(ctx)
+	for i, account := range strings.Split(ctx.String(utils.UnlockedAccountFlag.Name), ",") {
+		if account = strings.TrimSpace(account); account != "" {
+			unlockAccount(ctx, ks, account, i, passwords)
+		}
+	}
+
+	endpoint := ctx.String(signerEndpointFlag.Name)
+	listener, server, err := external.StartSigner(endpoint, ks)
+	if err != nil {
+		utils.Fatalf("failed to start the external signer: %v", err)
+	}
+	log.Info("External signer started", "endpoint", endpoint)
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigc)
+	<-sigc
+
+	listener.Close()
+	server.Stop()
+	log.Info("External signer stopped", "endpoint", endpoint)
+	return nil
+}
+
+func getUnsignedStorageTxs(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var txs []*ethapi.UnsignedStorageTx
+	if err = client.Call(&txs, "storagetx_unsignedStorageContractTXs"); err != nil {
+		utils.Fatalf("failed to retrieve the unsigned storage contract transactions: %v", err)
+	}
+	if len(txs) == 0 {
+		fmt.Println("No storage contract transaction waiting to be signed")
+		return nil
+	}
+	out, err := json.MarshalIndent(txs, "", "  ")
+	if err != nil {
+		utils.Fatalf("failed to format the unsigned storage contract transactions: %v", err)
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+func signStorageTx(ctx *cli.Context) error {
+	if len(ctx.Args()) == 0 {
+		utils.Fatalf("the raw unsigned transaction must be specified")
+	}
+	raw, err := hexutil.Decode(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("invalid raw transaction: %v", err)
+	}
+	tx := new(types.Transaction)
+	if err = rlp.DecodeBytes(raw, tx); err != nil {
+		utils.Fatalf("invalid raw transaction: %v", err)
+	}
+	var chainID *hexutil.Big
+	if len(ctx.Args()) > 1 {
+		chainID = new(hexutil.Big)
+		if err = chainID.UnmarshalText([]byte(ctx.Args().Get(1))); err != nil {
+			utils.Fatalf("invalid chain ID: %v", err)
+		}
+	}
+
+	stack, _ := makeConfigNode(ctx)
+	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	account, err := utils.MakeAddress(ks, ctx.String(signerFromFlag.Name))
+	if err != nil {
+		utils.Fatalf("invalid account: %v", err)
+	}
+	password := getPassPhrase("Please enter the password of the account", false, 0, utils.MakePasswordList(ctx))
+	signed, err := ks.SignTxWithPassphrase(account, password, tx, chainID.ToInt())
+	if err != nil {
+		utils.Fatalf("failed to sign the transaction: %v", err)
+	}
+	signedRaw, err := rlp.EncodeToBytes(signed)
+	if err != nil {
+		utils.Fatalf("failed to encode the signed transaction: %v", err)
+	}
+	fmt.Println(hexutil.Encode(signedRaw))
+	return nil
+}
+
+func submitSignedStorageTx(ctx *cli.Context) error {
+	if len(ctx.Args()) == 0 {
+		utils.Fatalf("the raw signed transaction must be specified")
+	}
+	raw, err := hexutil.Decode(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("invalid raw transaction: %v", err)
+	}
+
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+	var hash common.Hash
+	if err = client.Call(&hash, "storagetx_submitSignedStorageContractTX", hexutil.Bytes(raw)); err != nil {
+		utils.Fatalf("failed to submit the signed transaction: %v", err)
+	}
+	fmt.Printf("Storage contract transaction submitted: %s\n", hash.Hex())
+	return nil
+}
+
+func discardUnsignedStorageTx(ctx *cli.Context) error {
+	if !common.IsHexAddress(ctx.String(signerFromFlag.Name)) || !ctx.IsSet(signerNonceFlag.Name) {
+		utils.Fatalf("the sender address and the nonce of the transaction must be specified")
+	}
+	from := common.HexToAddress(ctx.String(signerFromFlag.Name))
+	nonce := hexutil.Uint64(ctx.Uint64(signerNonceFlag.Name))
+
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+	var removed bool
+	if err = client.Call(&removed, "storagetx_discardUnsignedStorageContractTX", from, nonce); err != nil {
+		utils.Fatalf("failed to discard the unsigned transaction: %v", err)
+	}
+	if !removed {
+		fmt.Printf("No unsigned transaction from %s with nonce %d\n", from.Hex(), nonce)
+		return nil
+	}
+	fmt.Printf("Unsigned transaction from %s with nonce %d discarded\n", from.Hex(), nonce)
+	return nil
+}
@@ -71,6 +71,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.DataDirFlag,
 			utils.KeyStoreDirFlag,
 			utils.NoUSBFlag,
+			utils.ExternalSignerFlag,
 			utils.NetworkIdFlag,
 			utils.TestnetFlag,
 			utils.RinkebyFlag,
@@ -236,6 +237,7 @@ var AppHelpFlagGroups = []flagGroup{
 		Name: "STORAGE",
 		Flags: []cli.Flag{
 			utils.StorageRoleFlag,
+			utils.StorageOfflineSigningFlag,
 		},
 	},
 	{
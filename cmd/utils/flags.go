@@ -120,6 +120,10 @@ var (
 		Name:  "keystore",
 		Usage: "Directory for the keystore (default = inside the datadir)",
 	}
+	ExternalSignerFlag = cli.StringFlag{
+		Name:  "signer",
+		Usage: "IPC endpoint of the external signer holding the keys to sign with",
+	}
 	NoUSBFlag = cli.BoolFlag{
 		Name:  "nousb",
 		Usage: "Disables monitoring for and managing USB hardware wallets",
@@ -599,6 +603,10 @@ var (
 		Name:  "role",
 		Usage: "Chooses which role a node can be. There are four options: all, host, client, and none",
 	}
+	StorageOfflineSigningFlag = cli.BoolFlag{
+		Name:  "storage.offlinesigning",
+		Usage: "Queues the storage contract transactions unsigned, to be signed offline and submitted later",
+	}
 )
 
 // MakeDataDir retrieves the currently requested data directory, terminating
@@ -957,6 +965,9 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(NoUSBFlag.Name) {
 		cfg.NoUSB = ctx.GlobalBool(NoUSBFlag.Name)
 	}
+	if ctx.GlobalIsSet(ExternalSignerFlag.Name) {
+		cfg.ExternalSigner = ctx.GlobalString(ExternalSignerFlag.Name)
+	}
 }
 
 func setDataDir(ctx *cli.Context, cfg *node.Config) {
@@ -1214,6 +1225,10 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 		}
 	}
 
+	if ctx.GlobalIsSet(StorageOfflineSigningFlag.Name) {
+		cfg.StorageOfflineSigning = ctx.GlobalBool(StorageOfflineSigningFlag.Name)
+	}
+
 	// If datadir is set, change ethash directory
 	if ctx.GlobalIsSet(DataDirFlag.Name) {
 		cfg.Ethash.DatasetDir = filepath.Join(ctx.GlobalString(DataDirFlag.Name), "Ethash")
@@ -840,8 +840,15 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 		bytes = 0
 		batch = bc.db.NewBatch()
 
+		// The storage transaction lookups read back while indexing the later blocks are
+		// cached until the batch is written
+		lookups = newStorageTxLookups(bc.db, batch, bc.chainConfig)
+
 		chainChangeEvent   *ChainChangeEvent
 		appliedBlockHashes []common.Hash
+
+		// the first block since which the storage contract freezer is complete
+		freezerTail uint64
 	)
 	for i, block := range blockChain {
 		receipts := receiptChain[i]
@@ -866,12 +873,18 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 		rawdb.WriteBody(batch, block.Hash(), block.NumberU64(), block.Body())
 		rawdb.WriteReceipts(batch, block.Hash(), block.NumberU64(), receipts)
 		rawdb.WriteTxLookupEntries(batch, block)
+		lookups.add(block, receipts)
+		freezerTail = block.NumberU64() + 1
 
 		appliedBlockHashes = append(appliedBlockHashes, block.Hash())
 
 		stats.processed++
 
 		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			lookups.flush()
+			if freezerTail > rawdb.ReadContractFreezerTail(bc.db) {
+				rawdb.WriteContractFreezerTail(batch, freezerTail)
+			}
 			if err := batch.Write(); err != nil {
 				return 0, err
 			}
@@ -879,6 +892,14 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 			batch.Reset()
 		}
 	}
+	lookups.flush()
+
+	// The blocks imported without the state are not executed, thus the storage contracts settled
+	// by them are not archived in the storage contract freezer. Record the first block since which
+	// the freezer is complete, so that the missing contracts are reported rather than not found
+	if freezerTail > rawdb.ReadContractFreezerTail(bc.db) {
+		rawdb.WriteContractFreezerTail(batch, freezerTail)
+	}
 	if batch.ValueSize() > 0 {
 		bytes += batch.ValueSize()
 		if err := batch.Write(); err != nil {
@@ -1011,6 +1032,7 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 	// Write other block data using a batch.
 	batch := bc.db.NewBatch()
 	rawdb.WriteReceipts(batch, block.Hash(), block.NumberU64(), receipts)
+	rawdb.WriteFrozenStorageContracts(bc.db, batch, block.Hash(), block.NumberU64(), state.ExpiredStorageContracts())
 
 	// If the total difficulty is higher than our known, add it to the canonical chain
 	// Second clause in the if statement reduces the vulnerability to selfish mining.
@@ -1049,6 +1071,10 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 		rawdb.WriteTxLookupEntries(batch, block)
 		rawdb.WritePreimages(batch, state.Preimages())
 
+		lookups := newStorageTxLookups(bc.db, batch, bc.chainConfig)
+		lookups.add(block, receipts)
+		lookups.flush()
+
 		status = CanonStatTy
 	} else {
 		status = SideStatTy
@@ -1509,9 +1535,21 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) (*ChainChangeEvent,
 	// When transactions get deleted from the database that means the
 	// receipts that were created in the fork must also be deleted
 	batch := bc.db.NewBatch()
+	removed := make(map[common.Hash]struct{})
 	for _, tx := range diff {
 		rawdb.DeleteTxLookupEntry(batch, tx.Hash())
+		removed[tx.Hash()] = struct{}{}
+	}
+	// Re-index the storage contract transactions, removing the entries of the old chain
+	// before adding the ones of the new chain
+	lookups := newStorageTxLookups(bc.db, batch, bc.chainConfig)
+	for _, block := range oldChain {
+		lookups.remove(block, rawdb.ReadReceipts(bc.db, block.Hash(), block.NumberU64()), removed)
+	}
+	for i := len(newChain) - 1; i >= 0; i-- {
+		lookups.add(newChain[i], rawdb.ReadReceipts(bc.db, newChain[i].Hash(), newChain[i].NumberU64()))
 	}
+	lookups.flush()
 	batch.Write()
 
 	if len(deletedLogs) > 0 {
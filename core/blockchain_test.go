@@ -683,6 +683,13 @@ func TestFastVsFullChains(t *testing.T) {
 			t.Errorf("block #%d: canonical hash mismatch: have %v, want %v", i, fhash, ahash)
 		}
 	}
+	// Check that the storage contract freezer is only complete after the fast synced blocks
+	if tail := rawdb.ReadContractFreezerTail(fastDb); tail != uint64(len(blocks))+1 {
+		t.Errorf("fast storage contract freezer tail mismatch: have %d, want %d", tail, len(blocks)+1)
+	}
+	if tail := rawdb.ReadContractFreezerTail(archiveDb); tail != 0 {
+		t.Errorf("archive storage contract freezer tail mismatch: have %d, want 0", tail)
+	}
 }
 
 // Tests that various import methods move the chain head pointers to the correct
@@ -162,3 +162,95 @@ func TestSetupGenesis(t *testing.T) {
 		}
 	}
 }
+
+func TestStorageForkCompatible(t *testing.T) {
+	fork := func(block int64, sectorSize uint64) params.StorageFork {
+		return params.StorageFork{Block: big.NewInt(block), SectorSize: sectorSize, SegmentSize: 64}
+	}
+	storageConfig := func(forks ...params.StorageFork) *params.ChainConfig {
+		return &params.ChainConfig{Storage: &params.StorageConfig{Forks: forks}}
+	}
+	tests := []struct {
+		stored, new *params.ChainConfig
+		head        uint64
+		wantErr     *params.ConfigCompatError
+	}{
+		// the default storage config is the same as the genesis fork with the default parameters
+		{&params.ChainConfig{}, &params.ChainConfig{Storage: params.DefaultStorageConfig}, 10, nil},
+		// the fork not yet activated can be rescheduled, added or removed
+		{storageConfig(fork(0, 1<<22), fork(20, 1<<23)), storageConfig(fork(0, 1<<22), fork(30, 1<<24)), 10, nil},
+		{storageConfig(fork(0, 1<<22)), storageConfig(fork(0, 1<<22), fork(30, 1<<23)), 10, nil},
+		{storageConfig(fork(0, 1<<22), fork(20, 1<<23)), storageConfig(fork(0, 1<<22)), 10, nil},
+		// the fork already activated can't be changed
+		{
+			stored: storageConfig(fork(0, 1<<22), fork(5, 1<<23)),
+			new:    storageConfig(fork(0, 1<<22), fork(8, 1<<23)),
+			head:   10,
+			wantErr: &params.ConfigCompatError{
+				What:         "storage fork 1 block",
+				StoredConfig: big.NewInt(5),
+				NewConfig:    big.NewInt(8),
+				RewindTo:     4,
+			},
+		},
+		{
+			stored: storageConfig(fork(0, 1<<22), fork(5, 1<<23)),
+			new:    storageConfig(fork(0, 1<<22), fork(5, 1<<24)),
+			head:   10,
+			wantErr: &params.ConfigCompatError{
+				What:         "storage fork 1 parameters",
+				StoredConfig: big.NewInt(5),
+				NewConfig:    big.NewInt(5),
+				RewindTo:     4,
+			},
+		},
+		{
+			stored: storageConfig(fork(0, 1<<22), fork(5, 1<<23)),
+			new:    storageConfig(fork(0, 1<<22)),
+			head:   10,
+			wantErr: &params.ConfigCompatError{
+				What:         "storage fork 1 block",
+				StoredConfig: big.NewInt(5),
+				RewindTo:     4,
+			},
+		},
+	}
+	for i, test := range tests {
+		err := test.stored.CheckCompatible(test.new, test.head)
+		if !reflect.DeepEqual(err, test.wantErr) {
+			t.Errorf("test %d: returned error %v, want %v", i, err, test.wantErr)
+		}
+	}
+}
+
+func TestStorageFork(t *testing.T) {
+	config := &params.ChainConfig{Storage: &params.StorageConfig{Forks: []params.StorageFork{
+		{Block: big.NewInt(10), SectorSize: 1 << 22},
+		{Block: big.NewInt(20), SectorSize: 1 << 23},
+	}}}
+	tests := []struct {
+		number     int64
+		sectorSize uint64
+	}{
+		{0, 0},
+		{9, 0},
+		{10, 1 << 22},
+		{19, 1 << 22},
+		{20, 1 << 23},
+	}
+	for i, test := range tests {
+		fork := config.StorageFork(big.NewInt(test.number))
+		if test.sectorSize == 0 {
+			if fork != nil || config.IsStorage(big.NewInt(test.number)) {
+				t.Errorf("test %d: storage active before the first fork", i)
+			}
+			continue
+		}
+		if fork == nil || fork.SectorSize != test.sectorSize {
+			t.Errorf("test %d: unexpected storage fork %v", i, fork)
+		}
+	}
+	if !params.TestChainConfig.IsStorage(new(big.Int)) {
+		t.Errorf("storage not active from genesis by default")
+	}
+}
@@ -0,0 +1,301 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package rawdb
+
+import (
+	"math/big"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+// HostReputation is the track record of a storage host derived from the chain
+type HostReputation struct {
+	ContractsFormed uint64   `json:"contractsFormed"`
+	ProofsSubmitted uint64   `json:"proofsSubmitted"`
+	ProofsMissed    uint64   `json:"proofsMissed"`
+	ProofPayout     *big.Int `json:"proofPayout"`  // host outputs paid out by the storage proofs submitted
+	MissedPayout    *big.Int `json:"missedPayout"` // host outputs paid out by the contracts whose proof was missed
+}
+
+// HostReputationEntry is the track record of a storage host within a section
+type HostReputationEntry struct {
+	Host       common.Address
+	Reputation HostReputation
+}
+
+// FrozenStorageContract is the expired storage contract archived in the storage contract
+// freezer, along with the block in which the storage contract is settled
+type FrozenStorageContract struct {
+	BlockHash   common.Hash                  `json:"blockHash"`
+	BlockNumber uint64                       `json:"blockNumber"`
+	Contract    types.ExpiredStorageContract `json:"contract"`
+}
+
+// StorageTxLookupEntry is the positional metadata of a storage contract transaction in the
+// canonical chain
+type StorageTxLookupEntry struct {
+	TxHash      common.Hash `json:"txHash"`
+	BlockHash   common.Hash `json:"blockHash"`
+	BlockNumber uint64      `json:"blockNumber"`
+	Index       uint64      `json:"index"`
+}
+
+// ContractRevisionLookupEntry is the positional metadata of a commit revision transaction,
+// along with the revision number committed
+type ContractRevisionLookupEntry struct {
+	RevisionNumber uint64 `json:"revisionNumber"`
+	StorageTxLookupEntry
+}
+
+// ReadContractExpiry retrieves the ids of the storage contracts whose proof window ends at
+// the block number
+func ReadContractExpiry(db DatabaseReader, windowEnd uint64) []common.Hash {
+	data, _ := db.Get(contractExpiryKey(windowEnd))
+	if len(data) == 0 {
+		return nil
+	}
+	var ids []common.Hash
+	if err := rlp.DecodeBytes(data, &ids); err != nil {
+		log.Error("Invalid storage contract expiry RLP", "number", windowEnd, "err", err)
+		return nil
+	}
+	return ids
+}
+
+// WriteContractExpiry stores the ids of the storage contracts whose proof window ends at the
+// block number
+func WriteContractExpiry(db DatabaseWriter, windowEnd uint64, ids []common.Hash) {
+	data, err := rlp.EncodeToBytes(ids)
+	if err != nil {
+		log.Crit("Failed to encode storage contract expiry", "err", err)
+	}
+	if err := db.Put(contractExpiryKey(windowEnd), data); err != nil {
+		log.Crit("Failed to store storage contract expiry", "err", err)
+	}
+}
+
+// DeleteContractExpiry removes the ids of the storage contracts whose proof window ends at
+// the block number
+func DeleteContractExpiry(db DatabaseDeleter, windowEnd uint64) {
+	if err := db.Delete(contractExpiryKey(windowEnd)); err != nil {
+		log.Crit("Failed to delete storage contract expiry", "err", err)
+	}
+}
+
+// ReadHostReputations retrieves the track records of the storage hosts belonging to the
+// given section
+func ReadHostReputations(db DatabaseReader, section uint64, head common.Hash) []HostReputationEntry {
+	data, _ := db.Get(hostReputationKey(section, head))
+	if len(data) == 0 {
+		return nil
+	}
+	var entries []HostReputationEntry
+	if err := rlp.DecodeBytes(data, &entries); err != nil {
+		log.Error("Invalid host reputation RLP", "section", section, "head", head, "err", err)
+		return nil
+	}
+	return entries
+}
+
+// WriteHostReputations stores the track records of the storage hosts belonging to the given
+// section
+func WriteHostReputations(db DatabaseWriter, section uint64, head common.Hash, entries []HostReputationEntry) {
+	data, err := rlp.EncodeToBytes(entries)
+	if err != nil {
+		log.Crit("Failed to encode host reputations", "err", err)
+	}
+	if err := db.Put(hostReputationKey(section, head), data); err != nil {
+		log.Crit("Failed to store host reputations", "err", err)
+	}
+}
+
+// ReadFrozenStorageContracts retrieves the storage contract archived in the storage contract
+// freezer by every block settling the storage contract, including the blocks not canonical
+func ReadFrozenStorageContracts(db DatabaseReader, id common.Hash) []FrozenStorageContract {
+	data, _ := db.Get(contractFreezerKey(id))
+	if len(data) == 0 {
+		return nil
+	}
+	var frozen []FrozenStorageContract
+	if err := rlp.DecodeBytes(data, &frozen); err != nil {
+		log.Error("Invalid frozen storage contract RLP", "id", id, "err", err)
+		return nil
+	}
+	return frozen
+}
+
+// ReadFrozenStorageContract retrieves the storage contract archived in the storage contract
+// freezer by the canonical block settling the storage contract
+func ReadFrozenStorageContract(db DatabaseReader, id common.Hash) *FrozenStorageContract {
+	for _, frozen := range ReadFrozenStorageContracts(db, id) {
+		if ReadCanonicalHash(db, frozen.BlockNumber) == frozen.BlockHash {
+			return &frozen
+		}
+	}
+	return nil
+}
+
+// WriteFrozenStorageContracts archives the storage contracts settled by the block in the storage
+// contract freezer. The storage contracts archived by the other blocks are kept, so that the
+// freezer stays valid after the chain reorganization
+func WriteFrozenStorageContracts(db DatabaseReader, batch DatabaseWriter, hash common.Hash, number uint64, contracts []*types.ExpiredStorageContract) {
+	for _, contract := range contracts {
+		var frozen []FrozenStorageContract
+		for _, f := range ReadFrozenStorageContracts(db, contract.ID) {
+			if f.BlockHash != hash {
+				frozen = append(frozen, f)
+			}
+		}
+		frozen = append(frozen, FrozenStorageContract{BlockHash: hash, BlockNumber: number, Contract: *contract})
+
+		data, err := rlp.EncodeToBytes(frozen)
+		if err != nil {
+			log.Crit("Failed to encode frozen storage contracts", "err", err)
+		}
+		if err := batch.Put(contractFreezerKey(contract.ID), data); err != nil {
+			log.Crit("Failed to store frozen storage contracts", "err", err)
+		}
+	}
+}
+
+// ReadContractFreezerTail retrieves the number of the first block since which the storage
+// contracts settled are archived in the storage contract freezer. The blocks imported by fast
+// sync are not executed, thus the storage contracts settled by them are not archived
+func ReadContractFreezerTail(db DatabaseReader) uint64 {
+	data, _ := db.Get(contractFreezerTailKey)
+	if len(data) == 0 {
+		return 0
+	}
+	return new(big.Int).SetBytes(data).Uint64()
+}
+
+// WriteContractFreezerTail stores the number of the first block since which the storage
+// contracts settled are archived in the storage contract freezer
+func WriteContractFreezerTail(db DatabaseWriter, number uint64) {
+	if err := db.Put(contractFreezerTailKey, new(big.Int).SetUint64(number).Bytes()); err != nil {
+		log.Crit("Failed to store the storage contract freezer tail", "err", err)
+	}
+}
+
+// readStorageTxLookupEntry retrieves the storage contract transaction lookup entry of the key
+func readStorageTxLookupEntry(db DatabaseReader, key []byte) *StorageTxLookupEntry {
+	data, _ := db.Get(key)
+	if len(data) == 0 {
+		return nil
+	}
+	entry := new(StorageTxLookupEntry)
+	if err := rlp.DecodeBytes(data, entry); err != nil {
+		log.Error("Invalid storage tx lookup entry RLP", "key", key, "err", err)
+		return nil
+	}
+	return entry
+}
+
+// writeStorageTxLookupEntry stores the storage contract transaction lookup entry of the key
+func writeStorageTxLookupEntry(db DatabaseWriter, key []byte, entry StorageTxLookupEntry) {
+	data, err := rlp.EncodeToBytes(entry)
+	if err != nil {
+		log.Crit("Failed to encode storage tx lookup entry", "err", err)
+	}
+	if err := db.Put(key, data); err != nil {
+		log.Crit("Failed to store storage tx lookup entry", "err", err)
+	}
+}
+
+// ReadContractCreationLookup retrieves the lookup entry of the contract create transaction
+// forming the storage contract
+func ReadContractCreationLookup(db DatabaseReader, id common.Hash) *StorageTxLookupEntry {
+	return readStorageTxLookupEntry(db, contractCreationKey(id))
+}
+
+// WriteContractCreationLookup stores the lookup entry of the contract create transaction
+// forming the storage contract
+func WriteContractCreationLookup(db DatabaseWriter, id common.Hash, entry StorageTxLookupEntry) {
+	writeStorageTxLookupEntry(db, contractCreationKey(id), entry)
+}
+
+// DeleteContractCreationLookup removes the lookup entry of the contract create transaction
+// forming the storage contract
+func DeleteContractCreationLookup(db DatabaseDeleter, id common.Hash) {
+	if err := db.Delete(contractCreationKey(id)); err != nil {
+		log.Crit("Failed to delete contract creation lookup entry", "err", err)
+	}
+}
+
+// ReadStorageProofLookup retrieves the lookup entry of the settlement of the storage contract,
+// which is the storage proof transaction, or the block at the window end of the contract with
+// an empty transaction hash if the storage proof is missed
+func ReadStorageProofLookup(db DatabaseReader, id common.Hash) *StorageTxLookupEntry {
+	return readStorageTxLookupEntry(db, storageProofKey(id))
+}
+
+// WriteStorageProofLookup stores the lookup entry of the settlement of the storage contract
+func WriteStorageProofLookup(db DatabaseWriter, id common.Hash, entry StorageTxLookupEntry) {
+	writeStorageTxLookupEntry(db, storageProofKey(id), entry)
+}
+
+// DeleteStorageProofLookup removes the lookup entry of the settlement of the storage contract
+func DeleteStorageProofLookup(db DatabaseDeleter, id common.Hash) {
+	if err := db.Delete(storageProofKey(id)); err != nil {
+		log.Crit("Failed to delete storage proof lookup entry", "err", err)
+	}
+}
+
+// ReadContractRevisionLookups retrieves the lookup entries of the commit revision transactions
+// of the storage contract, in the order of the revision number
+func ReadContractRevisionLookups(db DatabaseReader, id common.Hash) []ContractRevisionLookupEntry {
+	data, _ := db.Get(contractRevisionKey(id))
+	if len(data) == 0 {
+		return nil
+	}
+	var entries []ContractRevisionLookupEntry
+	if err := rlp.DecodeBytes(data, &entries); err != nil {
+		log.Error("Invalid contract revision lookup entries RLP", "id", id, "err", err)
+		return nil
+	}
+	return entries
+}
+
+// WriteContractRevisionLookups stores the lookup entries of the commit revision transactions
+// of the storage contract
+func WriteContractRevisionLookups(db DatabaseWriter, id common.Hash, entries []ContractRevisionLookupEntry) {
+	data, err := rlp.EncodeToBytes(entries)
+	if err != nil {
+		log.Crit("Failed to encode contract revision lookup entries", "err", err)
+	}
+	if err := db.Put(contractRevisionKey(id), data); err != nil {
+		log.Crit("Failed to store contract revision lookup entries", "err", err)
+	}
+}
+
+// ReadHostAnnouncementLookups retrieves the lookup entries of the host announce transactions
+// sent by the storage host, in the order of the block number
+func ReadHostAnnouncementLookups(db DatabaseReader, address common.Address) []StorageTxLookupEntry {
+	data, _ := db.Get(hostAnnouncementKey(address))
+	if len(data) == 0 {
+		return nil
+	}
+	var entries []StorageTxLookupEntry
+	if err := rlp.DecodeBytes(data, &entries); err != nil {
+		log.Error("Invalid host announcement lookup entries RLP", "address", address, "err", err)
+		return nil
+	}
+	return entries
+}
+
+// WriteHostAnnouncementLookups stores the lookup entries of the host announce transactions
+// sent by the storage host
+func WriteHostAnnouncementLookups(db DatabaseWriter, address common.Address, entries []StorageTxLookupEntry) {
+	data, err := rlp.EncodeToBytes(entries)
+	if err != nil {
+		log.Crit("Failed to encode host announcement lookup entries", "err", err)
+	}
+	if err := db.Put(hostAnnouncementKey(address), data); err != nil {
+		log.Crit("Failed to store host announcement lookup entries", "err", err)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package rawdb
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/ethdb"
+)
+
+// Tests that the storage contracts archived by the blocks of both sides of a reorg are kept,
+// and the one archived by the canonical block is retrieved.
+func TestFrozenStorageContracts(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+
+	id := common.HexToHash("0x01")
+	sideHash, canonHash := common.HexToHash("0x0a"), common.HexToHash("0x0b")
+	side := &types.ExpiredStorageContract{ID: id, WindowEnd: 10, HostMissedProofOutput: big.NewInt(1)}
+	canon := &types.ExpiredStorageContract{ID: id, WindowEnd: 10, HostValidProofOutput: big.NewInt(2), Proved: true}
+
+	if frozen := ReadFrozenStorageContract(db, id); frozen != nil {
+		t.Fatalf("non existent storage contract returned: %+v", frozen)
+	}
+	WriteFrozenStorageContracts(db, db, sideHash, 9, []*types.ExpiredStorageContract{side})
+	WriteFrozenStorageContracts(db, db, canonHash, 8, []*types.ExpiredStorageContract{canon})
+	WriteFrozenStorageContracts(db, db, canonHash, 8, []*types.ExpiredStorageContract{canon})
+	if frozen := ReadFrozenStorageContracts(db, id); len(frozen) != 2 {
+		t.Fatalf("expect the storage contract archived by 2 blocks, got %d", len(frozen))
+	}
+
+	WriteCanonicalHash(db, canonHash, 8)
+	frozen := ReadFrozenStorageContract(db, id)
+	if frozen == nil || frozen.BlockHash != canonHash || frozen.BlockNumber != 8 {
+		t.Fatalf("expect the storage contract archived by the canonical block, got %+v", frozen)
+	}
+	if !frozen.Contract.Proved || frozen.Contract.HostValidProofOutput.Cmp(big.NewInt(2)) != 0 {
+		t.Errorf("archived storage contract mismatch: %+v", frozen.Contract)
+	}
+}
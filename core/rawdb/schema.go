@@ -40,6 +40,9 @@ var (
 	// fastTrieProgressKey tracks the number of trie entries imported during fast sync.
 	fastTrieProgressKey = []byte("TrieSync")
 
+	// contractFreezerTailKey tracks the first block since which the settled storage contracts are archived.
+	contractFreezerTailKey = []byte("ContractFreezerTail")
+
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`, used for indexes).
 	headerPrefix       = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
 	headerTDSuffix     = []byte("t") // headerPrefix + num (uint64 big endian) + hash + headerTDSuffix -> td
@@ -52,11 +55,21 @@ var (
 	txLookupPrefix  = []byte("l") // txLookupPrefix + hash -> transaction/receipt lookup metadata
 	bloomBitsPrefix = []byte("B") // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> bloom bits
 
+	contractExpiryPrefix  = []byte("e") // contractExpiryPrefix + window end (uint64 big endian) -> storage contract ids
+	hostReputationPrefix  = []byte("R") // hostReputationPrefix + section (uint64 big endian) + hash -> host reputations
+	contractFreezerPrefix = []byte("f") // contractFreezerPrefix + contract id -> expired storage contracts archived
+
+	contractCreationPrefix = []byte("sc") // contractCreationPrefix + contract id -> contract create tx lookup metadata
+	contractRevisionPrefix = []byte("sr") // contractRevisionPrefix + contract id -> commit revision tx lookup metadata
+	storageProofPrefix     = []byte("sp") // storageProofPrefix + contract id -> storage proof tx lookup metadata
+	hostAnnouncementPrefix = []byte("sa") // hostAnnouncementPrefix + host address -> host announce tx lookup metadata
+
 	preimagePrefix = []byte("secure-key-")      // preimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-") // config prefix for the db
 
 	// Chain index prefixes (use `i` + single byte to avoid mixing data types).
-	BloomBitsIndexPrefix = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
+	BloomBitsIndexPrefix      = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
+	HostReputationIndexPrefix = []byte("iR") // HostReputationIndexPrefix is the data table of the host reputation indexer to track its progress
 
 	preimageCounter    = metrics.NewRegisteredCounter("db/preimage/total", nil)
 	preimageHitCounter = metrics.NewRegisteredCounter("db/preimage/hits", nil)
@@ -122,6 +135,41 @@ func bloomBitsKey(bit uint, section uint64, hash common.Hash) []byte {
 	return key
 }
 
+// contractExpiryKey = contractExpiryPrefix + window end (uint64 big endian)
+func contractExpiryKey(windowEnd uint64) []byte {
+	return append(contractExpiryPrefix, encodeBlockNumber(windowEnd)...)
+}
+
+// hostReputationKey = hostReputationPrefix + section (uint64 big endian) + hash
+func hostReputationKey(section uint64, hash common.Hash) []byte {
+	return append(append(hostReputationPrefix, encodeBlockNumber(section)...), hash.Bytes()...)
+}
+
+// contractFreezerKey = contractFreezerPrefix + contract id
+func contractFreezerKey(id common.Hash) []byte {
+	return append(contractFreezerPrefix, id.Bytes()...)
+}
+
+// contractCreationKey = contractCreationPrefix + contract id
+func contractCreationKey(id common.Hash) []byte {
+	return append(contractCreationPrefix, id.Bytes()...)
+}
+
+// contractRevisionKey = contractRevisionPrefix + contract id
+func contractRevisionKey(id common.Hash) []byte {
+	return append(contractRevisionPrefix, id.Bytes()...)
+}
+
+// storageProofKey = storageProofPrefix + contract id
+func storageProofKey(id common.Hash) []byte {
+	return append(storageProofPrefix, id.Bytes()...)
+}
+
+// hostAnnouncementKey = hostAnnouncementPrefix + host address
+func hostAnnouncementKey(address common.Address) []byte {
+	return append(hostAnnouncementPrefix, address.Bytes()...)
+}
+
 // preimageKey = preimagePrefix + hash
 func preimageKey(hash common.Hash) []byte {
 	return append(preimagePrefix, hash.Bytes()...)
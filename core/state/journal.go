@@ -135,6 +135,7 @@ type (
 		prev      bool
 		prevDirty bool
 	}
+	archiveContractChange struct{}
 )
 
 func (ch createObjectChange) revert(s *StateDB) {
@@ -236,3 +237,11 @@ func (ch addPreimageChange) revert(s *StateDB) {
 func (ch addPreimageChange) dirtied() *common.Address {
 	return nil
 }
+
+func (ch archiveContractChange) revert(s *StateDB) {
+	s.expiredContracts = s.expiredContracts[:len(s.expiredContracts)-1]
+}
+
+func (ch archiveContractChange) dirtied() *common.Address {
+	return nil
+}
@@ -83,6 +83,9 @@ type StateDB struct {
 
 	preimages map[common.Hash][]byte
 
+	// Storage contracts settled and cleared from the state, to be archived by the blockchain
+	expiredContracts []*types.ExpiredStorageContract
+
 	// Journal of state modifications. This is the backbone of
 	// Snapshot and RevertToSnapshot.
 	journal        *journal
@@ -134,6 +137,7 @@ func (s *StateDB) Reset(root common.Hash) error {
 	s.logs = make(map[common.Hash][]*types.Log)
 	s.logSize = 0
 	s.preimages = make(map[common.Hash][]byte)
+	s.expiredContracts = nil
 	s.clearJournalAndRefund()
 	return nil
 }
@@ -179,6 +183,18 @@ func (s *StateDB) Preimages() map[common.Hash][]byte {
 	return s.preimages
 }
 
+// ArchiveStorageContract records the storage contract whose state is cleared from the state
+// trie, so that it can be archived once the block is written.
+func (s *StateDB) ArchiveStorageContract(contract *types.ExpiredStorageContract) {
+	s.journal.append(archiveContractChange{})
+	s.expiredContracts = append(s.expiredContracts, contract)
+}
+
+// ExpiredStorageContracts returns the storage contracts cleared from the state trie.
+func (s *StateDB) ExpiredStorageContracts() []*types.ExpiredStorageContract {
+	return s.expiredContracts
+}
+
 // AddRefund adds gas to the refund counter
 func (s *StateDB) AddRefund(gas uint64) {
 	s.journal.append(refundChange{prev: s.refund})
@@ -553,6 +569,7 @@ func (s *StateDB) Copy() *StateDB {
 	for hash, preimage := range s.preimages {
 		state.preimages[hash] = preimage
 	}
+	state.expiredContracts = append(state.expiredContracts, s.expiredContracts...)
 	return state
 }
 
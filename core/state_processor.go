@@ -79,7 +79,7 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 
 	// maintenance missed storage proof
 	height := header.Number.Uint64()
-	coinchargemaintenance.MaintenanceMissedProof(height, statedb)
+	coinchargemaintenance.MaintenanceMissedProof(height, statedb, p.config.StorageFork(header.Number))
 
 	// Finalize the block, applying any consensus engine specific extras (e.g. block rewards)
 	p.engine.Finalize(p.bc, header, statedb, block.Transactions(), block.Uncles(), receipts)
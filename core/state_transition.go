@@ -208,7 +208,7 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 	precompiles := vm.PrecompiledEVMFileContracts
 	if contractCreation {
 		ret, _, st.gas, vmerr = evm.Create(sender, st.data, st.gas, st.value)
-	} else if p, ok := precompiles[st.to()]; ok {
+	} else if p, ok := precompiles[st.to()]; ok && evm.ChainConfig().IsStorage(evm.BlockNumber) {
 		st.state.SetNonce(msg.From(), st.state.GetNonce(sender.Address())+1)
 		ret, st.gas, vmerr = evm.ApplyStorageContractTransaction(sender, p, st.data, st.gas)
 	} else {
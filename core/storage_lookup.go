@@ -0,0 +1,298 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package core
+
+import (
+	"sort"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/rawdb"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+// storageLookupWriter is the database or batch the storage contract transaction lookup
+// entries are written into
+type storageLookupWriter interface {
+	rawdb.DatabaseWriter
+	rawdb.DatabaseDeleter
+}
+
+// storageTxLookups maintains the lookup entries of the storage contract transactions in the
+// canonical chain, along with the settlements of the storage contracts whose proof was missed.
+// The entries read back are cached until flushed, as the writes into a batch are not visible
+// to the reads from the database
+type storageTxLookups struct {
+	db     rawdb.DatabaseReader
+	writer storageLookupWriter
+	config *params.ChainConfig
+
+	revisions     map[common.Hash][]rawdb.ContractRevisionLookupEntry
+	announcements map[common.Address][]rawdb.StorageTxLookupEntry
+	expiries      map[uint64][]common.Hash
+	proofs        map[common.Hash]*rawdb.StorageTxLookupEntry // nil entry for the proof lookup removed
+}
+
+// newStorageTxLookups creates the storage contract transaction lookups reading from the db,
+// and writing into the writer
+func newStorageTxLookups(db rawdb.DatabaseReader, writer storageLookupWriter, config *params.ChainConfig) *storageTxLookups {
+	return &storageTxLookups{
+		db:            db,
+		writer:        writer,
+		config:        config,
+		revisions:     make(map[common.Hash][]rawdb.ContractRevisionLookupEntry),
+		announcements: make(map[common.Address][]rawdb.StorageTxLookupEntry),
+		expiries:      make(map[uint64][]common.Hash),
+		proofs:        make(map[common.Hash]*rawdb.StorageTxLookupEntry),
+	}
+}
+
+// StorageCall is a storage contract transaction executed by a transaction, which is either
+// the transaction sent to the precompiled address directly, or the call to the storage
+// contract precompile from a contract
+type StorageCall struct {
+	TxType string
+	Caller *common.Address // caller of the precompile, nil if sent to the precompiled address directly
+	Data   []byte
+}
+
+// StorageCalls returns the storage contract transactions executed by the successful transaction
+// in the order of execution. The calls to the storage contract precompiles from the contracts
+// are recorded by the logs of the receipt
+func StorageCalls(tx *types.Transaction, receipt *types.Receipt) []StorageCall {
+	if receipt == nil || receipt.Status != types.ReceiptStatusSuccessful {
+		return nil
+	}
+	if tx.To() != nil {
+		if txType, ok := vm.PrecompiledEVMFileContracts[*tx.To()]; ok {
+			return []StorageCall{{TxType: txType, Data: tx.Data()}}
+		}
+	}
+	var calls []StorageCall
+	for _, l := range receipt.Logs {
+		if txType, caller, data, ok := vm.StorageCallLog(l); ok {
+			calls = append(calls, StorageCall{TxType: txType, Caller: &caller, Data: data})
+		}
+	}
+	return calls
+}
+
+// add indexes the successful storage contract transactions of the block added to the
+// canonical chain, including the ones called from the contracts. The storage contracts whose
+// proof window ends at the block without the storage proof submitted are settled by
+// MaintenanceMissedProof after the transactions, and their proof lookup entries reference the
+// block with an empty transaction hash
+func (l *storageTxLookups) add(block *types.Block, receipts types.Receipts) {
+	for i, tx := range block.Transactions() {
+		if i >= len(receipts) {
+			break
+		}
+		for _, call := range StorageCalls(tx, receipts[i]) {
+			l.index(block, i, call, false)
+		}
+	}
+	for _, id := range l.contractExpiry(block.NumberU64()) {
+		if l.storageProof(id) == nil {
+			l.proofs[id] = &rawdb.StorageTxLookupEntry{BlockHash: block.Hash(), BlockNumber: block.NumberU64()}
+		}
+	}
+}
+
+// remove deletes the lookup entries of the storage contract transactions of the block, which
+// are removed from the canonical chain, along with the missed proofs settled in the block.
+// The blocks must be removed from the head down
+func (l *storageTxLookups) remove(block *types.Block, receipts types.Receipts, removed map[common.Hash]struct{}) {
+	for _, id := range l.contractExpiry(block.NumberU64()) {
+		if entry := l.storageProof(id); entry != nil && entry.TxHash == (common.Hash{}) && entry.BlockHash == block.Hash() {
+			l.proofs[id] = nil
+		}
+	}
+	for i, tx := range block.Transactions() {
+		if i >= len(receipts) {
+			break
+		}
+		if _, ok := removed[tx.Hash()]; !ok {
+			continue
+		}
+		for _, call := range StorageCalls(tx, receipts[i]) {
+			l.index(block, i, call, true)
+		}
+	}
+}
+
+// index adds or removes the lookup entry of the storage contract transaction executed by the
+// transaction of the block. The entry is removed only if it still references the transaction
+func (l *storageTxLookups) index(block *types.Block, i int, call StorageCall, remove bool) {
+	tx := block.Transactions()[i]
+	entry := rawdb.StorageTxLookupEntry{
+		TxHash:      tx.Hash(),
+		BlockHash:   block.Hash(),
+		BlockNumber: block.NumberU64(),
+		Index:       uint64(i),
+	}
+
+	switch call.TxType {
+	case vm.HostAnnounceTransaction:
+		var host common.Address
+		if call.Caller != nil {
+			host = *call.Caller
+		} else if sender, err := types.Sender(types.MakeSigner(l.config, block.Number()), tx); err == nil {
+			host = sender
+		} else {
+			log.Warn("Failed to derive the sender of host announcement", "hash", tx.Hash(), "err", err)
+			return
+		}
+		entries := removeStorageTxLookup(l.hostAnnouncements(host), tx.Hash())
+		if !remove {
+			entries = append(entries, entry)
+		}
+		l.announcements[host] = entries
+
+	case vm.ContractCreateTransaction:
+		var sc types.StorageContract
+		if err := rlp.DecodeBytes(call.Data, &sc); err != nil {
+			log.Warn("Failed to decode storage contract", "hash", tx.Hash(), "err", err)
+			return
+		}
+		id, ids := sc.ID(), l.contractExpiry(sc.WindowEnd)
+		if !remove {
+			rawdb.WriteContractCreationLookup(l.writer, id, entry)
+			if !containsHash(ids, id) {
+				l.expiries[sc.WindowEnd] = append(ids, id)
+			}
+		} else if stored := rawdb.ReadContractCreationLookup(l.db, id); stored != nil && stored.TxHash == tx.Hash() {
+			rawdb.DeleteContractCreationLookup(l.writer, id)
+			l.expiries[sc.WindowEnd] = removeHash(ids, id)
+		}
+
+	case vm.CommitRevisionTransaction:
+		var rev types.StorageContractRevision
+		if err := rlp.DecodeBytes(call.Data, &rev); err != nil {
+			log.Warn("Failed to decode storage contract revision", "hash", tx.Hash(), "err", err)
+			return
+		}
+		// a transaction may commit several revisions of the contract by the calls from the
+		// contracts, so the entries of the transaction are replaced by the revision number
+		var entries []rawdb.ContractRevisionLookupEntry
+		for _, e := range l.contractRevisions(rev.ParentID) {
+			if (remove && e.TxHash != tx.Hash()) || (!remove && e.RevisionNumber != rev.NewRevisionNumber) {
+				entries = append(entries, e)
+			}
+		}
+		if !remove {
+			entries = append(entries, rawdb.ContractRevisionLookupEntry{RevisionNumber: rev.NewRevisionNumber, StorageTxLookupEntry: entry})
+			sort.Slice(entries, func(i, j int) bool { return entries[i].RevisionNumber < entries[j].RevisionNumber })
+		}
+		l.revisions[rev.ParentID] = entries
+
+	case vm.StorageProofTransaction:
+		var sp types.StorageProof
+		if err := rlp.DecodeBytes(call.Data, &sp); err != nil {
+			log.Warn("Failed to decode storage proof", "hash", tx.Hash(), "err", err)
+			return
+		}
+		if !remove {
+			l.proofs[sp.ParentID] = &entry
+		} else if stored := l.storageProof(sp.ParentID); stored != nil && stored.TxHash == tx.Hash() {
+			l.proofs[sp.ParentID] = nil
+		}
+	}
+}
+
+// hostAnnouncements returns the cached lookup entries of the host announcements of the host
+func (l *storageTxLookups) hostAnnouncements(host common.Address) []rawdb.StorageTxLookupEntry {
+	if entries, ok := l.announcements[host]; ok {
+		return entries
+	}
+	return rawdb.ReadHostAnnouncementLookups(l.db, host)
+}
+
+// contractRevisions returns the cached lookup entries of the revisions of the storage contract
+func (l *storageTxLookups) contractRevisions(id common.Hash) []rawdb.ContractRevisionLookupEntry {
+	if entries, ok := l.revisions[id]; ok {
+		return entries
+	}
+	return rawdb.ReadContractRevisionLookups(l.db, id)
+}
+
+// contractExpiry returns the cached ids of the storage contracts whose proof window ends at
+// the block number
+func (l *storageTxLookups) contractExpiry(windowEnd uint64) []common.Hash {
+	if ids, ok := l.expiries[windowEnd]; ok {
+		return ids
+	}
+	return rawdb.ReadContractExpiry(l.db, windowEnd)
+}
+
+// storageProof returns the cached lookup entry of the settlement of the storage contract
+func (l *storageTxLookups) storageProof(id common.Hash) *rawdb.StorageTxLookupEntry {
+	if entry, ok := l.proofs[id]; ok {
+		return entry
+	}
+	return rawdb.ReadStorageProofLookup(l.db, id)
+}
+
+// flush writes the cached lookup entries into the writer
+func (l *storageTxLookups) flush() {
+	for id, entries := range l.revisions {
+		rawdb.WriteContractRevisionLookups(l.writer, id, entries)
+	}
+	for host, entries := range l.announcements {
+		rawdb.WriteHostAnnouncementLookups(l.writer, host, entries)
+	}
+	for windowEnd, ids := range l.expiries {
+		if len(ids) == 0 {
+			rawdb.DeleteContractExpiry(l.writer, windowEnd)
+		} else {
+			rawdb.WriteContractExpiry(l.writer, windowEnd, ids)
+		}
+	}
+	for id, entry := range l.proofs {
+		if entry == nil {
+			rawdb.DeleteStorageProofLookup(l.writer, id)
+		} else {
+			rawdb.WriteStorageProofLookup(l.writer, id, *entry)
+		}
+	}
+	l.revisions = make(map[common.Hash][]rawdb.ContractRevisionLookupEntry)
+	l.announcements = make(map[common.Address][]rawdb.StorageTxLookupEntry)
+	l.expiries = make(map[uint64][]common.Hash)
+	l.proofs = make(map[common.Hash]*rawdb.StorageTxLookupEntry)
+}
+
+// removeStorageTxLookup returns the lookup entries without the entry of the transaction
+func removeStorageTxLookup(entries []rawdb.StorageTxLookupEntry, hash common.Hash) []rawdb.StorageTxLookupEntry {
+	var left []rawdb.StorageTxLookupEntry
+	for _, entry := range entries {
+		if entry.TxHash != hash {
+			left = append(left, entry)
+		}
+	}
+	return left
+}
+
+// removeHash returns the hashes without the hash
+func removeHash(hashes []common.Hash, hash common.Hash) []common.Hash {
+	var left []common.Hash
+	for _, h := range hashes {
+		if h != hash {
+			left = append(left, h)
+		}
+	}
+	return left
+}
+
+// containsHash checks whether the hash is in the list
+func containsHash(hashes []common.Hash, hash common.Hash) bool {
+	for _, h := range hashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,412 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/consensus/ethash"
+	"github.com/DxChainNetwork/godx/core/rawdb"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage/coinchargemaintenance"
+)
+
+// storageTxTo returns the precompiled address of the storage contract transaction type
+func storageTxTo(txType string) common.Address {
+	for addr, typ := range vm.PrecompiledEVMFileContracts {
+		if typ == txType {
+			return addr
+		}
+	}
+	return common.Address{}
+}
+
+// newStorageTx creates the storage contract transaction of the type with the RLP encoded data
+func newStorageTx(t *testing.T, nonce uint64, txType string, data interface{}) *types.Transaction {
+	enc, err := rlp.EncodeToBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return types.NewTransaction(nonce, storageTxTo(txType), new(big.Int), 0, new(big.Int), enc)
+}
+
+// newLookupBlock creates the block with the transactions, and the receipts of the statuses
+func newLookupBlock(number uint64, txs types.Transactions, statuses ...uint64) (*types.Block, types.Receipts) {
+	receipts := make(types.Receipts, len(txs))
+	for i := range txs {
+		receipts[i] = &types.Receipt{Status: statuses[i]}
+	}
+	header := &types.Header{Number: new(big.Int).SetUint64(number), Extra: []byte{byte(len(txs))}}
+	return types.NewBlock(header, txs, nil, receipts), receipts
+}
+
+// Tests that the storage contract transactions are indexed when added to the canonical chain,
+// and the entries are removed when the transactions are reverted.
+func TestStorageTxLookups(t *testing.T) {
+	var (
+		db     = ethdb.NewMemDatabase()
+		config = params.TestChainConfig
+		ok     = types.ReceiptStatusSuccessful
+		failed = types.ReceiptStatusFailed
+	)
+	key, _ := crypto.GenerateKey()
+	host := crypto.PubkeyToAddress(key.PublicKey)
+	announce, err := types.SignTx(newStorageTx(t, 0, vm.HostAnnounceTransaction, types.HostAnnouncement{NetAddress: "enode://host"}), types.MakeSigner(config, big.NewInt(1)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contract := types.StorageContract{FileSize: 100, WindowStart: 10, WindowEnd: 20}
+	id := contract.ID()
+	create := newStorageTx(t, 1, vm.ContractCreateTransaction, contract)
+	failedRevision := newStorageTx(t, 2, vm.CommitRevisionTransaction, types.StorageContractRevision{ParentID: id, NewRevisionNumber: 9})
+	revision2 := newStorageTx(t, 3, vm.CommitRevisionTransaction, types.StorageContractRevision{ParentID: id, NewRevisionNumber: 2})
+	revision1 := newStorageTx(t, 4, vm.CommitRevisionTransaction, types.StorageContractRevision{ParentID: id, NewRevisionNumber: 1})
+	proof := newStorageTx(t, 5, vm.StorageProofTransaction, types.StorageProof{ParentID: id})
+
+	block1, receipts1 := newLookupBlock(1, types.Transactions{announce, create}, ok, ok)
+	block2, receipts2 := newLookupBlock(2, types.Transactions{failedRevision, revision2, revision1}, failed, ok, ok)
+	block3, receipts3 := newLookupBlock(3, types.Transactions{proof}, ok)
+
+	batch := db.NewBatch()
+	lookups := newStorageTxLookups(db, batch, config)
+	lookups.add(block1, receipts1)
+	lookups.add(block2, receipts2)
+	lookups.add(block3, receipts3)
+	lookups.flush()
+	if err := batch.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	if entry := rawdb.ReadContractCreationLookup(db, id); entry == nil || entry.TxHash != create.Hash() || entry.BlockHash != block1.Hash() || entry.Index != 1 {
+		t.Errorf("unexpected storage contract creation lookup: %+v", entry)
+	}
+	revisions := rawdb.ReadContractRevisionLookups(db, id)
+	if len(revisions) != 2 || revisions[0].RevisionNumber != 1 || revisions[0].TxHash != revision1.Hash() || revisions[1].RevisionNumber != 2 || revisions[1].Index != 1 {
+		t.Errorf("unexpected storage contract revision lookups: %+v", revisions)
+	}
+	if entry := rawdb.ReadStorageProofLookup(db, id); entry == nil || entry.TxHash != proof.Hash() || entry.BlockNumber != 3 {
+		t.Errorf("unexpected storage proof lookup: %+v", entry)
+	}
+	if entries := rawdb.ReadHostAnnouncementLookups(db, host); len(entries) != 1 || entries[0].TxHash != announce.Hash() {
+		t.Errorf("unexpected host announcement lookups: %+v", entries)
+	}
+
+	// Revert the blocks 2 and 3, and the proof is included in the block of the new chain
+	newBlock3, newReceipts3 := newLookupBlock(3, types.Transactions{proof}, ok)
+	removed := map[common.Hash]struct{}{failedRevision.Hash(): {}, revision2.Hash(): {}, revision1.Hash(): {}}
+	batch = db.NewBatch()
+	lookups = newStorageTxLookups(db, batch, config)
+	lookups.remove(block3, receipts3, removed)
+	lookups.remove(block2, receipts2, removed)
+	lookups.add(newBlock3, newReceipts3)
+	lookups.flush()
+	if err := batch.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	if revisions := rawdb.ReadContractRevisionLookups(db, id); len(revisions) != 0 {
+		t.Errorf("expect the revision lookups removed, got %+v", revisions)
+	}
+	if entry := rawdb.ReadStorageProofLookup(db, id); entry == nil || entry.BlockHash != newBlock3.Hash() {
+		t.Errorf("expect the storage proof lookup of the new chain, got %+v", entry)
+	}
+	if entry := rawdb.ReadContractCreationLookup(db, id); entry == nil || entry.TxHash != create.Hash() {
+		t.Errorf("expect the storage contract creation lookup kept, got %+v", entry)
+	}
+
+	// Revert the block 1
+	removed = map[common.Hash]struct{}{announce.Hash(): {}, create.Hash(): {}}
+	lookups = newStorageTxLookups(db, db, config)
+	lookups.remove(block1, receipts1, removed)
+	lookups.flush()
+	if entry := rawdb.ReadContractCreationLookup(db, id); entry != nil {
+		t.Errorf("expect the storage contract creation lookup removed, got %+v", entry)
+	}
+	if entries := rawdb.ReadHostAnnouncementLookups(db, host); len(entries) != 0 {
+		t.Errorf("expect the host announcement lookups removed, got %+v", entries)
+	}
+}
+
+// Tests that the storage contract lookups and the missed proofs settled follow the canonical
+// chain through the chain reorganisations, and that the receipt chain import indexes the
+// same entries as the full block processing.
+func TestStorageTxLookupsReorg(t *testing.T) {
+	var (
+		gendb        = ethdb.NewMemDatabase()
+		clientKey, _ = crypto.GenerateKey()
+		hostKey, _   = crypto.GenerateKey()
+		client       = crypto.PubkeyToAddress(clientKey.PublicKey)
+		host         = crypto.PubkeyToAddress(hostKey.PublicKey)
+		funds        = big.NewInt(1000000000)
+		gspec        = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  GenesisAlloc{client: {Balance: funds}, host: {Balance: funds}},
+		}
+		genesis = gspec.MustCommit(gendb)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainID)
+	)
+	// The storage contract formed in the block 1 misses the storage proof at the block 3
+	sc := types.StorageContract{
+		FileSize:           1,
+		WindowStart:        2,
+		WindowEnd:          3,
+		ClientCollateral:   types.DxcoinCollateral{DxcoinCharge: types.DxcoinCharge{Address: client, Value: big.NewInt(10)}},
+		HostCollateral:     types.DxcoinCollateral{DxcoinCharge: types.DxcoinCharge{Address: host, Value: big.NewInt(20)}},
+		ValidProofOutputs:  []types.DxcoinCharge{{Address: client, Value: big.NewInt(10)}, {Address: host, Value: big.NewInt(20)}},
+		MissedProofOutputs: []types.DxcoinCharge{{Address: client, Value: big.NewInt(10)}, {Address: host, Value: big.NewInt(20)}},
+		UnlockHash:         types.UnlockConditions{PaymentAddresses: []common.Address{client, host}, SignaturesRequired: 2}.UnlockHash(),
+	}
+	for _, key := range []*ecdsa.PrivateKey{clientKey, hostKey} {
+		sig, err := crypto.Sign(sc.RLPHash().Bytes(), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sc.Signatures = append(sc.Signatures, sig)
+	}
+	id := sc.ID()
+	data, err := rlp.EncodeToBytes(sc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	create, err := types.SignTx(types.NewTransaction(0, storageTxTo(vm.ContractCreateTransaction), new(big.Int), 1000000, new(big.Int), data), signer, clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// generate settles the missed proofs after the transactions, as the state processor does
+	generate := func(parent *types.Block, n int, seed byte, formed bool) ([]*types.Block, []types.Receipts) {
+		return GenerateChain(gspec.Config, parent, ethash.NewFaker(), gendb, n, func(i int, b *BlockGen) {
+			b.SetCoinbase(common.Address{seed})
+			if formed && i == 0 {
+				b.AddTx(create)
+			}
+			coinchargemaintenance.MaintenanceMissedProof(b.Number().Uint64(), b.statedb, gspec.Config.StorageFork(b.Number()))
+		})
+	}
+	chainA, receiptsA := generate(genesis, 4, 0xa, true)
+	chainB, _ := generate(genesis, 5, 0xb, false)
+	chainC, receiptsC := generate(chainA[0], 6, 0xc, false)
+
+	check := func(db ethdb.Database, settled *types.Block) {
+		t.Helper()
+		if settled == nil {
+			if entry := rawdb.ReadContractCreationLookup(db, id); entry != nil {
+				t.Errorf("expect the storage contract creation lookup removed, got %+v", entry)
+			}
+			if ids := rawdb.ReadContractExpiry(db, sc.WindowEnd); len(ids) != 0 {
+				t.Errorf("expect the storage contract expiry removed, got %v", ids)
+			}
+			if entry := rawdb.ReadStorageProofLookup(db, id); entry != nil {
+				t.Errorf("expect the missed proof settlement removed, got %+v", entry)
+			}
+			return
+		}
+		if entry := rawdb.ReadContractCreationLookup(db, id); entry == nil || entry.TxHash != create.Hash() || entry.BlockHash != chainA[0].Hash() {
+			t.Errorf("unexpected storage contract creation lookup: %+v", entry)
+		}
+		if ids := rawdb.ReadContractExpiry(db, sc.WindowEnd); len(ids) != 1 || ids[0] != id {
+			t.Errorf("unexpected storage contract expiry: %v", ids)
+		}
+		if entry := rawdb.ReadStorageProofLookup(db, id); entry == nil || entry.TxHash != (common.Hash{}) || entry.BlockHash != settled.Hash() || entry.BlockNumber != sc.WindowEnd {
+			t.Errorf("unexpected missed proof settlement: %+v", entry)
+		}
+	}
+
+	db := ethdb.NewMemDatabase()
+	gspec.MustCommit(db)
+	blockchain, _ := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	defer blockchain.Stop()
+
+	if n, err := blockchain.InsertChain(chainA); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	if receipts := blockchain.GetReceiptsByHash(chainA[0].Hash()); len(receipts) != 1 || receipts[0].Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("expect the storage contract formed, got receipts %+v", receipts)
+	}
+	check(db, chainA[2])
+
+	// Reorg to the chain without the storage contract
+	if n, err := blockchain.InsertChain(chainB); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	if blockchain.CurrentBlock().Hash() != chainB[len(chainB)-1].Hash() {
+		t.Fatalf("expect the chain reorganised to chain B")
+	}
+	check(db, nil)
+
+	// Reorg back to the storage contract, which is settled in another block
+	if n, err := blockchain.InsertChain(chainC); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	if blockchain.CurrentBlock().Hash() != chainC[len(chainC)-1].Hash() {
+		t.Fatalf("expect the chain reorganised to chain C")
+	}
+	check(db, chainC[1])
+
+	// Import the same chain by the receipts
+	fastDb := ethdb.NewMemDatabase()
+	gspec.MustCommit(fastDb)
+	fast, _ := NewBlockChain(fastDb, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	defer fast.Stop()
+
+	blocks := append([]*types.Block{chainA[0]}, chainC...)
+	receipts := append([]types.Receipts{receiptsA[0]}, receiptsC...)
+	headers := make([]*types.Header, len(blocks))
+	for i, block := range blocks {
+		headers[i] = block.Header()
+	}
+	if n, err := fast.InsertHeaderChain(headers, 1); err != nil {
+		t.Fatalf("failed to insert header %d: %v", n, err)
+	}
+	if n, err := fast.InsertReceiptChain(blocks, receipts); err != nil {
+		t.Fatalf("failed to insert receipt %d: %v", n, err)
+	}
+	check(fastDb, chainC[1])
+}
+
+// storageCallCode returns the code calling the contract with the call data. The call frame is
+// reverted after the call if revert is set
+func storageCallCode(to common.Address, revert bool) []byte {
+	code := []byte{
+		// copy the call data to the memory
+		byte(vm.CALLDATASIZE), byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.CALLDATACOPY),
+		// call the contract with the call data, ignoring the result
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.CALLDATASIZE), byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+		byte(vm.PUSH20),
+	}
+	code = append(code, to.Bytes()...)
+	code = append(code, byte(vm.GAS), byte(vm.CALL), byte(vm.POP))
+	if revert {
+		return append(code, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT))
+	}
+	return append(code, byte(vm.STOP))
+}
+
+// Tests that the storage contracts formed by the calls to the storage contract precompile from
+// the contracts are indexed, unless the call is reverted.
+func TestStorageTxLookupsCall(t *testing.T) {
+	var (
+		gendb        = ethdb.NewMemDatabase()
+		senderKey, _ = crypto.GenerateKey()
+		clientKey, _ = crypto.GenerateKey()
+		hostKey, _   = crypto.GenerateKey()
+		sender       = crypto.PubkeyToAddress(senderKey.PublicKey)
+		client       = crypto.PubkeyToAddress(clientKey.PublicKey)
+		host         = crypto.PubkeyToAddress(hostKey.PublicKey)
+		precompile   = storageTxTo(vm.ContractCreateTransaction)
+		caller       = common.HexToAddress("0xca11")
+		reverter     = common.HexToAddress("0x7e7e")
+		outer        = common.HexToAddress("0x0e7e")
+		funds        = big.NewInt(1000000000)
+		gspec        = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc: GenesisAlloc{
+				sender:   {Balance: funds},
+				host:     {Balance: funds},
+				caller:   {Balance: funds, Code: storageCallCode(precompile, false)},
+				reverter: {Balance: funds, Code: storageCallCode(precompile, true)},
+				outer:    {Balance: new(big.Int), Code: storageCallCode(reverter, false)},
+			},
+		}
+		genesis = gspec.MustCommit(gendb)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainID)
+	)
+	// newContract returns the storage contract whose client collateral is paid by the payer
+	newContract := func(payer common.Address, fileSize uint64) (common.Hash, []byte) {
+		sc := types.StorageContract{
+			FileSize:           fileSize,
+			WindowStart:        10,
+			WindowEnd:          20,
+			ClientCollateral:   types.DxcoinCollateral{DxcoinCharge: types.DxcoinCharge{Address: payer, Value: big.NewInt(10)}},
+			HostCollateral:     types.DxcoinCollateral{DxcoinCharge: types.DxcoinCharge{Address: host, Value: big.NewInt(20)}},
+			ValidProofOutputs:  []types.DxcoinCharge{{Address: client, Value: big.NewInt(10)}, {Address: host, Value: big.NewInt(20)}},
+			MissedProofOutputs: []types.DxcoinCharge{{Address: client, Value: big.NewInt(10)}, {Address: host, Value: big.NewInt(20)}},
+			UnlockHash:         types.UnlockConditions{PaymentAddresses: []common.Address{client, host}, SignaturesRequired: 2}.UnlockHash(),
+		}
+		for _, key := range []*ecdsa.PrivateKey{clientKey, hostKey} {
+			sig, err := crypto.Sign(sc.RLPHash().Bytes(), key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sc.Signatures = append(sc.Signatures, sig)
+		}
+		data, err := rlp.EncodeToBytes(sc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sc.ID(), data
+	}
+	calledID, calledData := newContract(caller, 1)
+	revertedID, revertedData := newContract(reverter, 2)
+
+	var txs types.Transactions
+	for i, tx := range []*types.Transaction{
+		types.NewTransaction(0, caller, new(big.Int), 1000000, new(big.Int), calledData),
+		types.NewTransaction(1, outer, new(big.Int), 1000000, new(big.Int), revertedData),
+	} {
+		signed, err := types.SignTx(tx, signer, senderKey)
+		if err != nil {
+			t.Fatalf("failed to sign transaction %d: %v", i, err)
+		}
+		txs = append(txs, signed)
+	}
+	chainA, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), gendb, 1, func(i int, b *BlockGen) {
+		for _, tx := range txs {
+			b.AddTx(tx)
+		}
+	})
+	chainB, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), gendb, 2, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0xb})
+	})
+
+	db := ethdb.NewMemDatabase()
+	gspec.MustCommit(db)
+	blockchain, _ := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	defer blockchain.Stop()
+
+	if n, err := blockchain.InsertChain(chainA); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	receipts := blockchain.GetReceiptsByHash(chainA[0].Hash())
+	if len(receipts) != 2 || receipts[0].Status != types.ReceiptStatusSuccessful || receipts[1].Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("expect the transactions succeeded, got receipts %+v", receipts)
+	}
+	if calls := StorageCalls(txs[0], receipts[0]); len(calls) != 1 || calls[0].TxType != vm.ContractCreateTransaction || *calls[0].Caller != caller {
+		t.Fatalf("unexpected storage calls of the transaction: %+v", calls)
+	}
+	if calls := StorageCalls(txs[1], receipts[1]); len(calls) != 0 {
+		t.Fatalf("expect the reverted storage call not recorded, got %+v", calls)
+	}
+
+	if entry := rawdb.ReadContractCreationLookup(db, calledID); entry == nil || entry.TxHash != txs[0].Hash() || entry.BlockHash != chainA[0].Hash() || entry.Index != 0 {
+		t.Errorf("unexpected storage contract creation lookup: %+v", entry)
+	}
+	if ids := rawdb.ReadContractExpiry(db, 20); len(ids) != 1 || ids[0] != calledID {
+		t.Errorf("unexpected storage contract expiry: %v", ids)
+	}
+	if entry := rawdb.ReadContractCreationLookup(db, revertedID); entry != nil {
+		t.Errorf("expect the reverted storage contract creation not indexed, got %+v", entry)
+	}
+
+	// Reorg to the chain without the storage contract
+	if n, err := blockchain.InsertChain(chainB); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	if entry := rawdb.ReadContractCreationLookup(db, calledID); entry != nil {
+		t.Errorf("expect the storage contract creation lookup removed, got %+v", entry)
+	}
+	if ids := rawdb.ReadContractExpiry(db, 20); len(ids) != 0 {
+		t.Errorf("expect the storage contract expiry removed, got %v", ids)
+	}
+}
@@ -217,6 +217,7 @@ type TxPool struct {
 	currentState  *state.StateDB      // Current state in the blockchain head
 	pendingState  *state.ManagedState // Pending state tracking virtual nonces
 	currentMaxGas uint64              // Current gas limit for transaction caps
+	currentHeight uint64              // Current block number of the blockchain head
 
 	locals  *accountSet // Set of local transaction to exempt from eviction rules
 	journal *txJournal  // Journal of local transaction to back up to disk
@@ -227,6 +228,9 @@ type TxPool struct {
 	all     *txLookup                    // All transactions to allow lookups
 	priced  *txPricedList                // All transactions sorted by price
 
+	revisions   map[common.Hash]*revisionTx // Latest storage contract revisions by the contract ID
+	proofChecks map[common.Hash]proofCheck  // State the storage proofs are verified against by the tx hash
+
 	wg sync.WaitGroup // for shutdown sync
 
 	homestead bool
@@ -248,6 +252,8 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		queue:       make(map[common.Address]*txList),
 		beats:       make(map[common.Address]time.Time),
 		all:         newTxLookup(),
+		revisions:   make(map[common.Hash]*revisionTx),
+		proofChecks: make(map[common.Hash]proofCheck),
 		chainHeadCh: make(chan ChainHeadEvent, chainHeadChanSize),
 		gasPrice:    new(big.Int).SetUint64(config.PriceLimit),
 	}
@@ -433,6 +439,10 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	pool.currentState = statedb
 	pool.pendingState = state.ManageState(statedb)
 	pool.currentMaxGas = newHead.GasLimit
+	pool.currentHeight = newHead.Number.Uint64()
+
+	// Drop the storage contract transactions invalidated by the new head
+	pool.pruneStorageTxs()
 
 	// Inject any transactions discarded due to reorgs
 	log.Debug("Reinjecting stale transactions", "count", len(reinject))
@@ -625,7 +635,7 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if tx.Gas() < intrGas {
 		return ErrIntrinsicGas
 	}
-	return nil
+	return pool.validateStorageTx(tx, from)
 }
 
 // add validates a transaction and inserts it into the non-executable queue for
@@ -682,6 +692,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		}
 		pool.all.Add(tx)
 		pool.priced.Put(tx)
+		pool.indexStorageTx(tx, from)
 		pool.journalTx(from, tx)
 
 		log.Trace("Pooled new executable transaction", "hash", hash, "from", from, "to", tx.To())
@@ -703,6 +714,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 			pool.locals.add(from)
 		}
 	}
+	pool.indexStorageTx(tx, from)
 	pool.journalTx(from, tx)
 
 	log.Trace("Pooled new future transaction", "hash", hash, "from", from, "to", tx.To())
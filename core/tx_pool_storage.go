@@ -0,0 +1,229 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/rawdb"
+	"github.com/DxChainNetwork/godx/core/state"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage/coinchargemaintenance"
+)
+
+var (
+	// ErrRevisionSuperseded is returned if a storage contract revision has the revision number
+	// not higher than the revision of the same storage contract already in the pool.
+	ErrRevisionSuperseded = errors.New("storage contract revision superseded")
+
+	// ErrNoStorageContract is returned if the storage contract of the storage proof or the
+	// revision does not exist.
+	ErrNoStorageContract = errors.New("storage contract not exist")
+)
+
+// revisionTx is the storage contract revision transaction in the pool
+type revisionTx struct {
+	hash   common.Hash
+	from   common.Address
+	nonce  uint64
+	number uint64
+}
+
+// proofCheck is the storage contract state and the trigger block the storage proof in the pool
+// is verified against. The Merkle proof is only verified again if any of them changes, such as
+// by a revision or a chain reorganisation, while the proof window and the proof status are
+// checked at every new head.
+type proofCheck struct {
+	fork        *params.StorageFork
+	windowStart uint64
+	fileSize    uint64
+	root        common.Hash
+	trigger     common.Hash
+}
+
+// storageTxType returns the type of the storage contract transaction at the height, empty if the
+// transaction is not a storage contract transaction or the storage protocol is not active
+func storageTxType(config *params.ChainConfig, tx *types.Transaction, height uint64) string {
+	if tx.To() == nil || !config.IsStorage(new(big.Int).SetUint64(height)) {
+		return ""
+	}
+	return vm.PrecompiledEVMFileContracts[*tx.To()]
+}
+
+// validateStorageTx checks the storage proof and the storage contract revision against the
+// current state at the height of the next block, so that the invalid ones are rejected by the
+// pool instead of failing at the execution. The revision superseded by the revision of the same
+// storage contract in the pool is rejected as well.
+func (pool *TxPool) validateStorageTx(tx *types.Transaction, from common.Address) error {
+	switch storageTxType(pool.chainconfig, tx, pool.currentHeight+1) {
+	case vm.StorageProofTransaction:
+		var sp types.StorageProof
+		if err := rlp.DecodeBytes(tx.Data(), &sp); err != nil {
+			return err
+		}
+		return pool.checkStorageProof(tx.Hash(), sp)
+
+	case vm.CommitRevisionTransaction:
+		var scr types.StorageContractRevision
+		if err := rlp.DecodeBytes(tx.Data(), &scr); err != nil {
+			return err
+		}
+		if err := checkRevision(pool.currentState, scr, pool.currentHeight+1); err != nil {
+			return err
+		}
+		old := pool.revisions[scr.ParentID]
+		if old == nil || pool.all.Get(old.hash) == nil {
+			return nil
+		}
+		// the revision with the same number is allowed only to replace the transaction itself
+		if old.number > scr.NewRevisionNumber {
+			return ErrRevisionSuperseded
+		}
+		if old.number == scr.NewRevisionNumber && (old.from != from || old.nonce != tx.Nonce()) {
+			return ErrRevisionSuperseded
+		}
+	}
+	return nil
+}
+
+// indexStorageTx records the storage contract revision added to the pool, and removes the
+// revision of the same storage contract superseded by it. The superseded revision of the same
+// sender is kept, as removing it would leave a nonce gap; it is either replaced in place at
+// the same nonce, or fails harmlessly at the execution.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) indexStorageTx(tx *types.Transaction, from common.Address) {
+	if storageTxType(pool.chainconfig, tx, pool.currentHeight+1) != vm.CommitRevisionTransaction {
+		return
+	}
+	var scr types.StorageContractRevision
+	if err := rlp.DecodeBytes(tx.Data(), &scr); err != nil {
+		return
+	}
+	if old := pool.revisions[scr.ParentID]; old != nil && old.hash != tx.Hash() && old.from != from {
+		log.Trace("Removing superseded storage contract revision", "hash", old.hash, "number", old.number)
+		pool.removeTx(old.hash, true)
+	}
+	pool.revisions[scr.ParentID] = &revisionTx{
+		hash:   tx.Hash(),
+		from:   from,
+		nonce:  tx.Nonce(),
+		number: scr.NewRevisionNumber,
+	}
+}
+
+// pruneStorageTxs validates the storage contract transactions in the pool against the new head,
+// and removes the ones invalidated, such as the proof whose window is passed or the revision of
+// the storage contract already proved.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) pruneStorageTxs() {
+	var invalids []common.Hash
+	pool.all.Range(func(hash common.Hash, tx *types.Transaction) bool {
+		var err error
+		switch storageTxType(pool.chainconfig, tx, pool.currentHeight+1) {
+		case vm.StorageProofTransaction:
+			var sp types.StorageProof
+			if err = rlp.DecodeBytes(tx.Data(), &sp); err == nil {
+				err = pool.checkStorageProof(hash, sp)
+			}
+		case vm.CommitRevisionTransaction:
+			var scr types.StorageContractRevision
+			if err = rlp.DecodeBytes(tx.Data(), &scr); err == nil {
+				err = checkRevision(pool.currentState, scr, pool.currentHeight+1)
+			}
+		}
+		if err != nil {
+			log.Trace("Removing invalidated storage contract transaction", "hash", hash, "err", err)
+			invalids = append(invalids, hash)
+		}
+		return true
+	})
+	for _, hash := range invalids {
+		pool.removeTx(hash, true)
+	}
+	for id, rev := range pool.revisions {
+		if pool.all.Get(rev.hash) == nil {
+			delete(pool.revisions, id)
+		}
+	}
+	for hash := range pool.proofChecks {
+		if pool.all.Get(hash) == nil {
+			delete(pool.proofChecks, hash)
+		}
+	}
+}
+
+// checkStorageProof checks the storage proof transaction against the current state at the height
+// of the next block. The Merkle proof verified is not verified again until the state it is
+// verified against is changed, and only the proof window and the proof status are checked.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) checkStorageProof(hash common.Hash, sp types.StorageProof) error {
+	var (
+		statedb      = pool.currentState
+		height       = pool.currentHeight + 1
+		contractAddr = common.BytesToAddress(sp.ParentID[12:])
+	)
+	if !statedb.Exist(contractAddr) {
+		return ErrNoStorageContract
+	}
+	windowEnd := new(big.Int).SetBytes(statedb.GetState(contractAddr, coinchargemaintenance.KeyWindowEnd).Bytes()).Uint64()
+	statusAddr := common.BytesToAddress([]byte(coinchargemaintenance.StrPrefixExpSC + strconv.FormatUint(windowEnd, 10)))
+
+	fork := pool.chainconfig.StorageFork(new(big.Int).SetUint64(height))
+	check := proofCheck{
+		fork:        fork,
+		windowStart: new(big.Int).SetBytes(statedb.GetState(contractAddr, coinchargemaintenance.KeyWindowStart).Bytes()).Uint64(),
+		fileSize:    new(big.Int).SetBytes(statedb.GetState(contractAddr, coinchargemaintenance.KeyFileSize).Bytes()).Uint64(),
+		root:        statedb.GetState(contractAddr, coinchargemaintenance.KeyFileMerkleRoot),
+	}
+	if check.windowStart > 0 {
+		check.trigger = rawdb.ReadCanonicalHash(statedb.Database().TrieDB().DiskDB(), check.windowStart-1)
+	}
+	if verified, ok := pool.proofChecks[hash]; ok && verified == check {
+		return vm.CheckStorageProofWindow(statedb, sp, height, statusAddr, contractAddr)
+	}
+	if err := vm.CheckStorageProof(statedb, sp, height, statusAddr, contractAddr, fork); err != nil {
+		delete(pool.proofChecks, hash)
+		return err
+	}
+	pool.proofChecks[hash] = check
+	return nil
+}
+
+// checkRevision checks the storage contract revision against the state at the height
+func checkRevision(statedb vm.StateDB, scr types.StorageContractRevision, height uint64) error {
+	contractAddr := common.BytesToAddress(scr.ParentID[12:])
+	if !statedb.Exist(contractAddr) {
+		return ErrNoStorageContract
+	}
+	return vm.CheckRevisionContract(statedb, scr, height, contractAddr)
+}
+
+// IsClosingStorageProof returns whether the transaction is the storage proof whose window closes
+// within StorageProofClosingBlocks blocks from the height
+func IsClosingStorageProof(config *params.ChainConfig, statedb *state.StateDB, tx *types.Transaction, height uint64) bool {
+	if storageTxType(config, tx, height) != vm.StorageProofTransaction {
+		return false
+	}
+	var sp types.StorageProof
+	if err := rlp.DecodeBytes(tx.Data(), &sp); err != nil {
+		return false
+	}
+	contractAddr := common.BytesToAddress(sp.ParentID[12:])
+	if !statedb.Exist(contractAddr) {
+		return false
+	}
+	windowEnd := new(big.Int).SetBytes(statedb.GetState(contractAddr, coinchargemaintenance.KeyWindowEnd).Bytes()).Uint64()
+	return windowEnd >= height && windowEnd <= height+params.StorageProofClosingBlocks
+}
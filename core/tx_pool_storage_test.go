@@ -0,0 +1,324 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"strconv"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/rawdb"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage/coinchargemaintenance"
+)
+
+var (
+	storageContractID = common.HexToHash("0x51b3c1f2d2f6b8e2d44c1d0a4f7e6f3c1a3f2b4c5d6e7f8091a2b3c4d5e6f708")
+	revisionAddress   = common.BytesToAddress([]byte{11})
+	proofAddress      = common.BytesToAddress([]byte{12})
+)
+
+// setupStoragePool returns the pool with the storage contract formed by the client and the host
+// at the revision number 5, whose proof window is [100, 200]
+func setupStoragePool(t *testing.T) (*TxPool, *ecdsa.PrivateKey, *ecdsa.PrivateKey) {
+	pool, _ := setupTxPool()
+	clientKey, _ := crypto.GenerateKey()
+	hostKey, _ := crypto.GenerateKey()
+
+	contractAddr := common.BytesToAddress(storageContractID[12:])
+	statedb := pool.currentState
+	statedb.CreateAccount(contractAddr)
+	statedb.SetNonce(contractAddr, 1)
+	statedb.SetState(contractAddr, coinchargemaintenance.KeyUnlockHash, storageUnlockConditions(clientKey, hostKey).UnlockHash())
+	statedb.SetState(contractAddr, coinchargemaintenance.KeyRevisionNumber, common.BigToHash(big.NewInt(5)))
+	statedb.SetState(contractAddr, coinchargemaintenance.KeyWindowStart, common.BigToHash(big.NewInt(100)))
+	statedb.SetState(contractAddr, coinchargemaintenance.KeyWindowEnd, common.BigToHash(big.NewInt(200)))
+	for _, key := range []common.Hash{
+		coinchargemaintenance.KeyClientValidProofOutput,
+		coinchargemaintenance.KeyHostValidProofOutput,
+		coinchargemaintenance.KeyClientMissedProofOutput,
+		coinchargemaintenance.KeyHostMissedProofOutput,
+	} {
+		statedb.SetState(contractAddr, key, common.BigToHash(big.NewInt(1000)))
+	}
+	for _, key := range []*ecdsa.PrivateKey{clientKey, hostKey} {
+		statedb.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	}
+	return pool, clientKey, hostKey
+}
+
+func storageUnlockConditions(clientKey, hostKey *ecdsa.PrivateKey) types.UnlockConditions {
+	return types.UnlockConditions{
+		PaymentAddresses:   []common.Address{crypto.PubkeyToAddress(clientKey.PublicKey), crypto.PubkeyToAddress(hostKey.PublicKey)},
+		SignaturesRequired: 2,
+	}
+}
+
+// storageRevision returns the revision of the storage contract signed by the client and the host
+func storageRevision(t *testing.T, number uint64, clientKey, hostKey *ecdsa.PrivateKey) []byte {
+	uc := storageUnlockConditions(clientKey, hostKey)
+	outputs := []types.DxcoinCharge{
+		{Address: uc.PaymentAddresses[0], Value: big.NewInt(1000)},
+		{Address: uc.PaymentAddresses[1], Value: big.NewInt(1000)},
+	}
+	scr := types.StorageContractRevision{
+		ParentID:              storageContractID,
+		UnlockConditions:      uc,
+		NewRevisionNumber:     number,
+		NewWindowStart:        100,
+		NewWindowEnd:          200,
+		NewValidProofOutputs:  outputs,
+		NewMissedProofOutputs: outputs,
+		NewUnlockHash:         uc.UnlockHash(),
+	}
+	for _, key := range []*ecdsa.PrivateKey{clientKey, hostKey} {
+		sig, err := crypto.Sign(scr.RLPHash().Bytes(), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scr.Signatures = append(scr.Signatures, sig)
+	}
+	data, err := rlp.EncodeToBytes(scr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func storageTransaction(nonce uint64, to common.Address, data []byte, key *ecdsa.PrivateKey) *types.Transaction {
+	tx, _ := types.SignTx(types.NewTransaction(nonce, to, new(big.Int), 500000, big.NewInt(1), data), types.HomesteadSigner{}, key)
+	return tx
+}
+
+// Tests that the invalid storage proofs and revisions are rejected by the pool.
+func TestStorageTxValidation(t *testing.T) {
+	pool, clientKey, hostKey := setupStoragePool(t)
+	defer pool.Stop()
+
+	noContract, _ := rlp.EncodeToBytes(types.StorageProof{ParentID: common.HexToHash("0x01")})
+	earlyProof, _ := rlp.EncodeToBytes(types.StorageProof{ParentID: storageContractID})
+
+	tests := []struct {
+		to    common.Address
+		data  []byte
+		valid bool
+	}{
+		{proofAddress, []byte{0x01, 0x02}, false},
+		{proofAddress, noContract, false},
+		{proofAddress, earlyProof, false},
+		{revisionAddress, storageRevision(t, 3, clientKey, hostKey), false},
+		{revisionAddress, storageRevision(t, 6, hostKey, clientKey), false},
+		{revisionAddress, storageRevision(t, 6, clientKey, hostKey), true},
+	}
+	for i, test := range tests {
+		err := pool.AddRemote(storageTransaction(uint64(i), test.to, test.data, hostKey))
+		if test.valid && err != nil {
+			t.Errorf("test %d: failed to add the valid transaction: %v", i, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("test %d: invalid transaction added", i)
+		}
+	}
+}
+
+// Tests that the revisions in the pool are superseded by the revisions with the higher number,
+// and removed when the storage contract is proved.
+func TestStorageRevisionSupersede(t *testing.T) {
+	pool, clientKey, hostKey := setupStoragePool(t)
+	defer pool.Stop()
+
+	first := storageTransaction(0, revisionAddress, storageRevision(t, 6, clientKey, hostKey), hostKey)
+	if err := pool.AddRemote(first); err != nil {
+		t.Fatalf("failed to add revision: %v", err)
+	}
+	if err := pool.AddRemote(storageTransaction(0, revisionAddress, storageRevision(t, 6, clientKey, hostKey), clientKey)); err != ErrRevisionSuperseded {
+		t.Errorf("revision with the same number: expect %v, got %v", ErrRevisionSuperseded, err)
+	}
+	if err := pool.AddRemote(storageTransaction(0, revisionAddress, storageRevision(t, 5, clientKey, hostKey), clientKey)); err != ErrRevisionSuperseded {
+		t.Errorf("revision with the lower number: expect %v, got %v", ErrRevisionSuperseded, err)
+	}
+
+	second := storageTransaction(0, revisionAddress, storageRevision(t, 7, clientKey, hostKey), clientKey)
+	if err := pool.AddRemote(second); err != nil {
+		t.Fatalf("failed to add revision: %v", err)
+	}
+	if pool.Get(first.Hash()) != nil {
+		t.Errorf("superseded revision not removed")
+	}
+	if pool.Get(second.Hash()) == nil {
+		t.Errorf("revision not added")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+
+	// prove the storage contract, the revision is removed at the reset
+	contractAddr := common.BytesToAddress(storageContractID[12:])
+	statusAddr := common.BytesToAddress([]byte(coinchargemaintenance.StrPrefixExpSC + strconv.FormatUint(200, 10)))
+	pool.currentState.SetState(statusAddr, storageContractID, common.BytesToHash(append(coinchargemaintenance.ProofedStatus, contractAddr[:]...)))
+	pool.lockedReset(nil, nil)
+	if pool.Get(second.Hash()) != nil {
+		t.Errorf("revision of the proved storage contract not removed")
+	}
+	if len(pool.revisions) != 0 {
+		t.Errorf("revision index not pruned")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the revision superseded by the revision of the same sender is kept in the pool,
+// so that no nonce gap is left, and the revision at the same nonce is replaced in place.
+func TestStorageRevisionSupersedeSameSender(t *testing.T) {
+	pool, clientKey, hostKey := setupStoragePool(t)
+	defer pool.Stop()
+
+	first := storageTransaction(0, revisionAddress, storageRevision(t, 6, clientKey, hostKey), hostKey)
+	if err := pool.AddRemote(first); err != nil {
+		t.Fatalf("failed to add revision: %v", err)
+	}
+	second := storageTransaction(1, revisionAddress, storageRevision(t, 7, clientKey, hostKey), hostKey)
+	if err := pool.AddRemote(second); err != nil {
+		t.Fatalf("failed to add revision: %v", err)
+	}
+	if pool.Get(first.Hash()) == nil {
+		t.Errorf("superseded revision of the same sender removed")
+	}
+	if pending, _ := pool.Stats(); pending != 2 {
+		t.Errorf("pending transactions mismatched: have %d, want %d", pending, 2)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+
+	// replace the revision at the same nonce with the higher number
+	replaced, _ := types.SignTx(types.NewTransaction(1, revisionAddress, new(big.Int), 500000, big.NewInt(2), storageRevision(t, 8, clientKey, hostKey)), types.HomesteadSigner{}, hostKey)
+	if err := pool.AddRemote(replaced); err != nil {
+		t.Fatalf("failed to replace revision: %v", err)
+	}
+	if pool.Get(second.Hash()) != nil || pool.Get(replaced.Hash()) == nil {
+		t.Errorf("revision not replaced in place")
+	}
+	if pool.Get(first.Hash()) == nil {
+		t.Errorf("revision at the lower nonce removed")
+	}
+	if rev := pool.revisions[storageContractID]; rev == nil || rev.hash != replaced.Hash() {
+		t.Errorf("revision index mismatched: %+v", rev)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// addStorageProof opens the proof window of the empty file of the storage contract, triggered by
+// the block 0, and adds the storage proof of the host to the pool
+func addStorageProof(t *testing.T, pool *TxPool, hostKey *ecdsa.PrivateKey) *types.Transaction {
+	contractAddr := common.BytesToAddress(storageContractID[12:])
+	statedb := pool.currentState
+	statedb.SetState(contractAddr, coinchargemaintenance.KeyWindowStart, common.BigToHash(big.NewInt(1)))
+	rawdb.WriteCanonicalHash(statedb.Database().TrieDB().DiskDB().(ethdb.Database), common.HexToHash("0x01"), 0)
+
+	sp := types.StorageProof{ParentID: storageContractID}
+	sig, err := crypto.Sign(sp.RLPHash().Bytes(), hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp.Signature = sig
+	data, _ := rlp.EncodeToBytes(sp)
+	proof := storageTransaction(0, proofAddress, data, hostKey)
+	if err := pool.AddRemote(proof); err != nil {
+		t.Fatalf("failed to add storage proof: %v", err)
+	}
+	return proof
+}
+
+// Tests that the storage proofs verified are not verified again at the reset unless the state
+// they are verified against is changed, while the proof window is checked at every reset.
+func TestStorageProofVerificationCache(t *testing.T) {
+	pool, _, hostKey := setupStoragePool(t)
+	defer pool.Stop()
+
+	proof := addStorageProof(t, pool, hostKey)
+	contractAddr := common.BytesToAddress(storageContractID[12:])
+	statedb := pool.currentState
+	if _, ok := pool.proofChecks[proof.Hash()]; !ok {
+		t.Fatal("storage proof verified not cached")
+	}
+
+	// the proof verified against the file is kept without verifying again
+	statedb.SetState(contractAddr, coinchargemaintenance.KeyFileSize, common.BigToHash(big.NewInt(64)))
+	check := pool.proofChecks[proof.Hash()]
+	check.fileSize = 64
+	pool.proofChecks[proof.Hash()] = check
+	pool.lockedReset(nil, nil)
+	if pool.Get(proof.Hash()) == nil {
+		t.Fatal("storage proof verified removed")
+	}
+
+	// the proof is verified again once the file is changed
+	statedb.SetState(contractAddr, coinchargemaintenance.KeyFileMerkleRoot, common.HexToHash("0x02"))
+	pool.lockedReset(nil, nil)
+	if pool.Get(proof.Hash()) != nil {
+		t.Error("invalid storage proof not removed")
+	}
+	if len(pool.proofChecks) != 0 {
+		t.Errorf("storage proof verifications not pruned")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the proof window of the storage proof verified is checked at every reset.
+func TestStorageProofVerificationCacheWindow(t *testing.T) {
+	pool, _, hostKey := setupStoragePool(t)
+	defer pool.Stop()
+
+	proof := addStorageProof(t, pool, hostKey)
+	contractAddr := common.BytesToAddress(storageContractID[12:])
+	statedb := pool.currentState
+
+	// the storage contract proved by another proof
+	statusAddr := common.BytesToAddress([]byte(coinchargemaintenance.StrPrefixExpSC + strconv.FormatUint(200, 10)))
+	statedb.SetState(statusAddr, storageContractID, common.BytesToHash(append(coinchargemaintenance.ProofedStatus, contractAddr[:]...)))
+	pool.lockedReset(nil, nil)
+	if pool.Get(proof.Hash()) != nil {
+		t.Error("storage proof of the proved storage contract not removed")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests the storage proofs whose window is closing.
+func TestIsClosingStorageProof(t *testing.T) {
+	pool, _, hostKey := setupStoragePool(t)
+	defer pool.Stop()
+
+	data, _ := rlp.EncodeToBytes(types.StorageProof{ParentID: storageContractID})
+	proof := storageTransaction(0, proofAddress, data, hostKey)
+	tests := []struct {
+		tx      *types.Transaction
+		height  uint64
+		closing bool
+	}{
+		{proof, 150, false},
+		{proof, 180, true},
+		{proof, 200, true},
+		{proof, 201, false},
+		{storageTransaction(0, revisionAddress, data, hostKey), 190, false},
+	}
+	for i, test := range tests {
+		if closing := IsClosingStorageProof(pool.chainconfig, pool.currentState, test.tx, test.height); closing != test.closing {
+			t.Errorf("test %d: expect closing %v, got %v", i, test.closing, closing)
+		}
+	}
+}
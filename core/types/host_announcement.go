@@ -0,0 +1,98 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package types
+
+import (
+	"errors"
+	"io"
+
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+// legacyHostAnnouncement is the rlp format of the announcement with only one address
+// signed by the node key
+type legacyHostAnnouncement struct {
+	NetAddress string
+	Signature  []byte
+}
+
+// extendedHostAnnouncement is the rlp format of the announcement with alternative
+// addresses or signed by the announcement key
+type extendedHostAnnouncement struct {
+	NetAddress   string
+	Signature    []byte
+	AltAddresses []string
+	KeyCert      []AnnouncementKeyCert
+}
+
+// Extended returns whether the announcement has the fields introduced after the legacy format,
+// which are only accepted since the storage fork extending the host announcements
+func (ha HostAnnouncement) Extended() bool {
+	return len(ha.AltAddresses) != 0 || ha.KeyCert != nil
+}
+
+// ExtendedFormat returns whether the announcement is in the extended rlp format, which fails
+// to decode by the nodes before the storage fork extending the host announcements. It is the
+// case if the announcement is extended, or decoded from the extended format
+func (ha HostAnnouncement) ExtendedFormat() bool {
+	return ha.extendedFormat || ha.Extended()
+}
+
+// EncodeRLP implements rlp.Encoder. The announcement without alternative addresses and key
+// cert is encoded in the legacy format, so that it is accepted by the nodes not upgraded,
+// unless it is decoded from the extended format
+func (ha HostAnnouncement) EncodeRLP(w io.Writer) error {
+	if !ha.ExtendedFormat() {
+		return rlp.Encode(w, legacyHostAnnouncement{
+			NetAddress: ha.NetAddress,
+			Signature:  ha.Signature,
+		})
+	}
+	ext := extendedHostAnnouncement{
+		NetAddress:   ha.NetAddress,
+		Signature:    ha.Signature,
+		AltAddresses: ha.AltAddresses,
+	}
+	if ha.KeyCert != nil {
+		ext.KeyCert = []AnnouncementKeyCert{*ha.KeyCert}
+	}
+	return rlp.Encode(w, ext)
+}
+
+// DecodeRLP implements rlp.Decoder, decoding both the legacy and the extended format. The
+// format decoded is recorded, as the extended format is only accepted since the storage fork
+func (ha *HostAnnouncement) DecodeRLP(s *rlp.Stream) error {
+	raw, err := s.Raw()
+	if err != nil {
+		return err
+	}
+	var legacy legacyHostAnnouncement
+	if err := rlp.DecodeBytes(raw, &legacy); err == nil {
+		*ha = HostAnnouncement{
+			NetAddress: legacy.NetAddress,
+			Signature:  legacy.Signature,
+		}
+		return nil
+	}
+	var ext extendedHostAnnouncement
+	if err := rlp.DecodeBytes(raw, &ext); err != nil {
+		return err
+	}
+	if len(ext.KeyCert) > 1 {
+		return errors.New("host announcement has more than one key cert")
+	}
+	*ha = HostAnnouncement{
+		NetAddress:     ext.NetAddress,
+		Signature:      ext.Signature,
+		extendedFormat: true,
+	}
+	if len(ext.AltAddresses) != 0 {
+		ha.AltAddresses = ext.AltAddresses
+	}
+	if len(ext.KeyCert) == 1 {
+		ha.KeyCert = &ext.KeyCert[0]
+	}
+	return nil
+}
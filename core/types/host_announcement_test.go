@@ -0,0 +1,102 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+func TestHostAnnouncement_RLP(t *testing.T) {
+	tests := []HostAnnouncement{
+		{NetAddress: "enode://a", Signature: []byte{1, 2, 3}},
+		{NetAddress: "enode://a", Signature: []byte{1}, AltAddresses: []string{"enode://b", "enode://c"}, extendedFormat: true},
+		{
+			NetAddress:     "enode://a",
+			Signature:      []byte{1},
+			KeyCert:        &AnnouncementKeyCert{KeyAddress: common.HexToAddress("0x1"), Expiry: 100, Signature: []byte{2}},
+			extendedFormat: true,
+		},
+	}
+	for i, ha := range tests {
+		b, err := rlp.EncodeToBytes(ha)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded HostAnnouncement
+		if err = rlp.DecodeBytes(b, &decoded); err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(ha, decoded) {
+			t.Errorf("test %d: expect %+v, got %+v", i, ha, decoded)
+		}
+	}
+}
+
+func TestHostAnnouncement_LegacyFormat(t *testing.T) {
+	ha := HostAnnouncement{NetAddress: "enode://a", Signature: []byte{1, 2, 3}}
+
+	// the announcement without the new fields is encoded and hashed the same as before
+	b, err := rlp.EncodeToBytes(ha)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy, err := rlp.EncodeToBytes(legacyHostAnnouncement{NetAddress: ha.NetAddress, Signature: ha.Signature})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(b, legacy) {
+		t.Errorf("legacy announcement encoded differently: %x != %x", b, legacy)
+	}
+	if ha.RLPHash() != rlpHash([]interface{}{ha.NetAddress}) {
+		t.Error("legacy announcement hashed differently")
+	}
+
+	// the alternative addresses are covered by the signed hash
+	ext := ha
+	ext.AltAddresses = []string{"enode://b"}
+	if ext.RLPHash() == ha.RLPHash() {
+		t.Error("alternative addresses not covered by the hash")
+	}
+}
+
+func TestHostAnnouncement_ExtendedFormat(t *testing.T) {
+	// the extended format without the new fields
+	b, err := rlp.EncodeToBytes(extendedHostAnnouncement{NetAddress: "enode://a", Signature: []byte{1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ha HostAnnouncement
+	if err := rlp.DecodeBytes(b, &ha); err != nil {
+		t.Fatal(err)
+	}
+	if ha.Extended() || !ha.ExtendedFormat() {
+		t.Errorf("expect extended format without new fields, got extended %v, extended format %v", ha.Extended(), ha.ExtendedFormat())
+	}
+	// the format decoded is kept when encoded again
+	enc, err := rlp.EncodeToBytes(ha)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(b, enc) {
+		t.Errorf("extended format encoded differently: %x != %x", enc, b)
+	}
+
+	// the legacy format
+	b, err = rlp.EncodeToBytes(legacyHostAnnouncement{NetAddress: "enode://a", Signature: []byte{1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ha = HostAnnouncement{}
+	if err := rlp.DecodeBytes(b, &ha); err != nil {
+		t.Fatal(err)
+	}
+	if ha.ExtendedFormat() {
+		t.Error("legacy format decoded as the extended format")
+	}
+}
@@ -5,9 +5,11 @@
 package types
 
 import (
+	"errors"
 	"math/big"
 
 	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/rlp"
 )
 
 type StorageContractRLPHash interface {
@@ -18,6 +20,26 @@ type HostAnnouncement struct {
 	// host enode url
 	NetAddress string
 	Signature  []byte
+
+	// AltAddresses are the additional enode urls of the host, such as the IPv6 address.
+	// They must have the same node ID as NetAddress
+	AltAddresses []string
+
+	// KeyCert authorizes the announcement key which signs the announcement. If nil, the
+	// announcement is signed by the node key
+	KeyCert *AnnouncementKeyCert
+
+	// extendedFormat is set if the announcement is decoded from the extended format, even
+	// if the alternative addresses and the key cert are empty
+	extendedFormat bool
+}
+
+// AnnouncementKeyCert is signed by the node key of the host to authorize an announcement
+// key to sign the host announcements until the Expiry block
+type AnnouncementKeyCert struct {
+	KeyAddress common.Address
+	Expiry     uint64
+	Signature  []byte
 }
 
 type UnlockConditions struct {
@@ -70,17 +92,93 @@ type StorageContractRevision struct {
 	Signatures            [][]byte
 }
 
+// StorageProof is the proof of the segments stored by the host. The multi segment proof carries
+// the segments following Segment in Segments, which is empty in the single segment proof, so
+// that the single segment proof is encoded as before.
 type StorageProof struct {
 	ParentID  common.Hash   `json:"parentid"`
 	Segment   [64]byte      `json:"segment"`
 	HashSet   []common.Hash `json:"hashset"`
 	Signature []byte
+	Segments  [][64]byte `json:"segments" rlp:"tail"`
+}
+
+// singleSegmentProof is the rlp format of the storage proof before the multi segment proofs,
+// without the segments following Segment
+type singleSegmentProof struct {
+	ParentID  common.Hash
+	Segment   [64]byte
+	HashSet   []common.Hash
+	Signature []byte
 }
 
-// RLPHash calculate the hash of HostAnnouncement
+// DecodeSingleSegmentProof decodes the single segment storage proof into val, which must be a
+// *StorageProof. The proof carrying the segments following Segment fails to decode, the same
+// as decoded by the nodes before the multi segment proofs.
+func DecodeSingleSegmentProof(b []byte, val interface{}) error {
+	sp, ok := val.(*StorageProof)
+	if !ok {
+		return errors.New("single segment proof decoded into non storage proof")
+	}
+	var single singleSegmentProof
+	if err := rlp.DecodeBytes(b, &single); err != nil {
+		return err
+	}
+	*sp = StorageProof{
+		ParentID:  single.ParentID,
+		Segment:   single.Segment,
+		HashSet:   single.HashSet,
+		Signature: single.Signature,
+	}
+	return nil
+}
+
+// ExpiredStorageContract is the state of the storage contract settled by the storage proof or
+// by the end of the proof window. Once the state is cleared from the state trie, the expired
+// storage contract is archived in the local database
+type ExpiredStorageContract struct {
+	ID                      common.Hash    `json:"id"`
+	ClientAddress           common.Address `json:"clientaddress"`
+	HostAddress             common.Address `json:"hostaddress"`
+	ClientCollateral        *big.Int       `json:"clientcollateral"`
+	HostCollateral          *big.Int       `json:"hostcollateral"`
+	FileSize                uint64         `json:"filesize"`
+	FileMerkleRoot          common.Hash    `json:"filemerkleroot"`
+	UnlockHash              common.Hash    `json:"unlockhash"`
+	RevisionNumber          uint64         `json:"revisionnumber"`
+	WindowStart             uint64         `json:"windowstart"`
+	WindowEnd               uint64         `json:"windowend"`
+	ClientValidProofOutput  *big.Int       `json:"clientvalidproofoutput"`
+	HostValidProofOutput    *big.Int       `json:"hostvalidproofoutput"`
+	ClientMissedProofOutput *big.Int       `json:"clientmissedproofoutput"`
+	HostMissedProofOutput   *big.Int       `json:"hostmissedproofoutput"`
+	Proved                  bool           `json:"proved"`
+}
+
+// RLPHash calculate the hash of HostAnnouncement. The hash of the announcement without the
+// alternative addresses and key cert is the same as the legacy announcement
 func (ha HostAnnouncement) RLPHash() common.Hash {
+	if !ha.Extended() {
+		return rlpHash([]interface{}{
+			ha.NetAddress,
+		})
+	}
+	var keyAddress common.Address
+	if ha.KeyCert != nil {
+		keyAddress = ha.KeyCert.KeyAddress
+	}
 	return rlpHash([]interface{}{
 		ha.NetAddress,
+		ha.AltAddresses,
+		keyAddress,
+	})
+}
+
+// RLPHash calculate the hash of AnnouncementKeyCert
+func (cert AnnouncementKeyCert) RLPHash() common.Hash {
+	return rlpHash([]interface{}{
+		cert.KeyAddress,
+		cert.Expiry,
 	})
 }
 
@@ -129,6 +227,14 @@ func (scr StorageContractRevision) RLPHash() common.Hash {
 
 // RLPHash calculate the hash of StorageProof
 func (sp StorageProof) RLPHash() common.Hash {
+	if len(sp.Segments) != 0 {
+		return rlpHash([]interface{}{
+			sp.ParentID,
+			sp.Segment,
+			sp.HashSet,
+			sp.Segments,
+		})
+	}
 	return rlpHash([]interface{}{
 		sp.ParentID,
 		sp.Segment,
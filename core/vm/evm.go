@@ -61,6 +61,9 @@ func run(evm *EVM, contract *Contract, input []byte, readOnly bool) ([]byte, err
 		if p := precompiles[*contract.CodeAddr]; p != nil {
 			return RunPrecompiledContract(p, input, contract)
 		}
+		if p := newStoragePrecompile(evm, contract, *contract.CodeAddr, readOnly); p != nil {
+			return RunPrecompiledContract(p, input, contract)
+		}
 	}
 	for _, interpreter := range evm.interpreters {
 		if interpreter.CanRun(contract.Code) {
@@ -212,7 +215,7 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 		if evm.ChainConfig().IsByzantium(evm.BlockNumber) {
 			precompiles = PrecompiledContractsByzantium
 		}
-		if precompiles[addr] == nil && evm.ChainConfig().IsEIP158(evm.BlockNumber) && value.Sign() == 0 {
+		if precompiles[addr] == nil && !evm.isStoragePrecompile(addr) && evm.ChainConfig().IsEIP158(evm.BlockNumber) && value.Sign() == 0 {
 			// Calling a non existing account, don't do anything, but ping the tracer
 			if evm.vmConfig.Debug && evm.depth == 0 {
 				evm.vmConfig.Tracer.CaptureStart(caller.Address(), addr, false, input, gas, value)
@@ -505,6 +508,13 @@ func (evm *EVM) HostAnnounceTx(caller ContractRef, data []byte, gas uint64) ([]b
 		return nil, gasDecode, errDec
 	}
 
+	// the extended format is only accepted since the storage fork extending the host
+	// announcements, before which it fails to decode as the legacy format even if the
+	// alternative addresses and the key cert are empty
+	if fork := evm.ChainConfig().StorageFork(evm.BlockNumber); ha.ExtendedFormat() && (fork == nil || !fork.ExtendedAnnouncements) {
+		return nil, gasDecode, errExtendedAnnouncementNotActive
+	}
+
 	gasCheck, resultCheck := RemainGas(gasDecode, CheckMultiSignatures, ha, [][]byte{ha.Signature})
 	errCheck, _ := resultCheck[0].(error)
 	if errCheck != nil {
@@ -512,6 +522,11 @@ func (evm *EVM) HostAnnounceTx(caller ContractRef, data []byte, gas uint64) ([]b
 		return nil, gasCheck, errCheck
 	}
 
+	// the announcement key is only valid before the cert expires
+	if ha.KeyCert != nil && evm.BlockNumber.Uint64() > ha.KeyCert.Expiry {
+		return nil, gasCheck, errAnnounceKeyExpired
+	}
+
 	log.Info("host announce tx execution done", "remain_gas", gasCheck, "host_address", ha.NetAddress)
 
 	// return remain gas if everything is ok
@@ -665,6 +680,16 @@ func (evm *EVM) CommitRevisionTx(caller ContractRef, data []byte, gas uint64) ([
 	return nil, gasRemainCheck, nil
 }
 
+// storageProofDecoder returns the decoder of the storage proof under the storage fork. The segments
+// following Segment are only decoded since the multi segment proofs, before which the proof
+// carrying them fails to decode with the decode gas charged only
+func storageProofDecoder(fork *params.StorageFork) func([]byte, interface{}) error {
+	if fork == nil || fork.ProofVersion == params.StorageProofV0 {
+		return types.DecodeSingleSegmentProof
+	}
+	return rlp.DecodeBytes
+}
+
 // StorageProofTx host send storage certificate transaction
 func (evm *EVM) StorageProofTx(caller ContractRef, data []byte, gas uint64) ([]byte, uint64, error) {
 	log.Info("enter storage proof tx executing ... ")
@@ -672,8 +697,9 @@ func (evm *EVM) StorageProofTx(caller ContractRef, data []byte, gas uint64) ([]b
 		state = evm.StateDB
 	)
 
+	fork := evm.ChainConfig().StorageFork(evm.BlockNumber)
 	sp := types.StorageProof{}
-	gasRemainDec, resultDec := RemainGas(gas, rlp.DecodeBytes, data, &sp)
+	gasRemainDec, resultDec := RemainGas(gas, storageProofDecoder(fork), data, &sp)
 	errDec, _ := resultDec[0].(error)
 	if errDec != nil {
 		return nil, gasRemainDec, errDec
@@ -698,7 +724,7 @@ func (evm *EVM) StorageProofTx(caller ContractRef, data []byte, gas uint64) ([]b
 	windowEndStr := strconv.FormatUint(windowEnd, 10)
 	statusAddr := common.BytesToAddress([]byte(coinchargemaintenance.StrPrefixExpSC + windowEndStr))
 
-	gasRemainCheck, resultCheck := RemainGas(gasRemainDec, CheckStorageProof, state, sp, uint64(currentHeight), statusAddr, contractAddr)
+	gasRemainCheck, resultCheck := RemainGas(gasRemainDec, CheckStorageProof, state, sp, uint64(currentHeight), statusAddr, contractAddr, fork)
 	errCheck, _ := resultCheck[0].(error)
 	if errCheck != nil {
 		return nil, gasRemainCheck, errCheck
@@ -724,6 +750,11 @@ func (evm *EVM) StorageProofTx(caller ContractRef, data []byte, gas uint64) ([]b
 	// this contract is finished, so mark it empty account that will be deleted by stateDB
 	state.SetNonce(contractAddr, 0)
 
+	// clear the state of the storage contract settled, if pruned since the storage fork
+	if fork.PruneContractState {
+		coinchargemaintenance.PruneStorageContract(state, sp.ParentID, contractAddr, true)
+	}
+
 	log.Info("storage proof tx execution done", "storage_contract_id", sp.ParentID.Hex())
 	return nil, gasRemainCheck, nil
 }
@@ -7,6 +7,7 @@ package vm
 import (
 	"bytes"
 	"crypto/ecdsa"
+	"crypto/sha256"
 	"fmt"
 	"math/big"
 	"net"
@@ -18,6 +19,7 @@ import (
 	"github.com/DxChainNetwork/godx/core/state"
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/crypto/merkle"
 	"github.com/DxChainNetwork/godx/ethdb"
 	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/params"
@@ -90,6 +92,94 @@ func TestEVM_HostAnnounceTx(t *testing.T) {
 	}
 }
 
+// TestEVM_ExtendedHostAnnounceTx tests that the host announcement with the alternative addresses
+// is only accepted since the storage fork extending the host announcements
+func TestEVM_ExtendedHostAnnounceTx(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ha := types.HostAnnouncement{
+		NetAddress:   enode.NewV4(&privateKey.PublicKey, net.IP{127, 0, 0, 1}, 8888, 8888).String(),
+		AltAddresses: []string{enode.NewV4(&privateKey.PublicKey, net.IP{10, 0, 0, 1}, 8888, 8888).String()},
+	}
+	if ha.Signature, err = crypto.Sign(ha.RLPHash().Bytes(), privateKey); err != nil {
+		t.Fatal(err)
+	}
+	rlpBytes, err := rlp.EncodeToBytes(ha)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		config  *params.ChainConfig
+		err     error
+		gasLeft uint64
+	}{
+		{params.MainnetChainConfig, errExtendedAnnouncementNotActive, gasOrigin - params.DecodeGas},
+		{params.TestChainConfig, nil, gasOrigin - params.DecodeGas - params.CheckMultiSignaturesGas},
+	}
+	for i, test := range tests {
+		stateDB := mockState(ethdb.NewMemDatabase(), mockAccountAlloc(nil))
+		evm := NewEVM(Context{BlockNumber: big.NewInt(1)}, stateDB, test.config, Config{})
+		_, gasLeft, err := evm.HostAnnounceTx(AccountRef{}, rlpBytes, gasOrigin)
+		if err != test.err {
+			t.Errorf("test %d: expect error %v, got %v", i, test.err, err)
+		}
+		if gasLeft != test.gasLeft {
+			t.Errorf("test %d: expect gas left %d, got %d", i, test.gasLeft, gasLeft)
+		}
+	}
+}
+
+// TestEVM_ExtendedFormatHostAnnounceTx tests that the host announcement in the extended format
+// with empty alternative addresses and key cert is rejected before the storage fork extending
+// the host announcements, charging the same gas as the legacy announcement failing to decode
+func TestEVM_ExtendedFormatHostAnnounceTx(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ha := types.HostAnnouncement{NetAddress: enode.NewV4(&privateKey.PublicKey, net.IP{127, 0, 0, 1}, 8888, 8888).String()}
+	if ha.Signature, err = crypto.Sign(ha.RLPHash().Bytes(), privateKey); err != nil {
+		t.Fatal(err)
+	}
+	rlpBytes, err := rlp.EncodeToBytes([]interface{}{ha.NetAddress, ha.Signature, []string{}, []interface{}{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the legacy announcement fails to decode the extended format
+	var legacy struct {
+		NetAddress string
+		Signature  []byte
+	}
+	gasLegacy, resultLegacy := RemainGas(gasOrigin, rlp.DecodeBytes, rlpBytes, &legacy)
+	if err, _ := resultLegacy[0].(error); err == nil {
+		t.Fatal("extended format decoded as the legacy announcement")
+	}
+
+	tests := []struct {
+		config  *params.ChainConfig
+		err     error
+		gasLeft uint64
+	}{
+		{params.MainnetChainConfig, errExtendedAnnouncementNotActive, gasLegacy},
+		{params.TestChainConfig, nil, gasOrigin - params.DecodeGas - params.CheckMultiSignaturesGas},
+	}
+	for i, test := range tests {
+		stateDB := mockState(ethdb.NewMemDatabase(), mockAccountAlloc(nil))
+		evm := NewEVM(Context{BlockNumber: big.NewInt(1)}, stateDB, test.config, Config{})
+		_, gasLeft, err := evm.HostAnnounceTx(AccountRef{}, rlpBytes, gasOrigin)
+		if err != test.err {
+			t.Errorf("test %d: expect error %v, got %v", i, test.err, err)
+		}
+		if gasLeft != test.gasLeft {
+			t.Errorf("test %d: expect gas left %d, got %d", i, test.gasLeft, gasLeft)
+		}
+	}
+}
+
 func TestEVM_CreateContractTx(t *testing.T) {
 
 	// mock evm, state, client and host address ...
@@ -401,6 +491,175 @@ func TestEVM_StorageProofTx(t *testing.T) {
 
 }
 
+func TestEVM_MultiSegmentStorageProofTx(t *testing.T) {
+	evm, stateDB, prvAndAddresses, err := mockEvmAndState(1101)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := *params.MainnetChainConfig
+	config.Storage = &params.StorageConfig{
+		Forks: []params.StorageFork{
+			{Block: big.NewInt(0), SectorSize: 1 << 22, SegmentSize: 64, ProofVersion: params.StorageProofV1, ContractCalls: true},
+		},
+	}
+	evm.chainConfig = &config
+	evm.chainRules = config.Rules(evm.BlockNumber)
+	fork := config.StorageFork(evm.BlockNumber)
+
+	db := stateDB.Database().TrieDB().DiskDB().(ethdb.Database)
+	mockBlockHash := common.HexToHash("0x877c3a381d5ad88ca76a7b3e33ab1611939de59c56c0506efb9021593618f6ab")
+	rawdb.WriteCanonicalHash(db, mockBlockHash, uint64(1000))
+
+	// the file of 100 segments with the partial final segment
+	data := make([]byte, 100*merkle.LeafSize-10)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	sc, err := mockStorageContract(prvAndAddresses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc.FileSize = uint64(len(data))
+	sc.FileMerkleRoot = merkle.Sha256MerkleTreeRoot(data)
+	mockWriteStorageContractIntoState(*sc, stateDB)
+
+	indices, err := StorageProofSegments(params.StorageProofV1, mockBlockHash, sc.ID(), sc.FileSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uint64(len(indices)) != params.StorageProofSegments {
+		t.Fatalf("expect %d segments selected, got %d", params.StorageProofSegments, len(indices))
+	}
+
+	// build the multi segment proof from the leaf roots of the file
+	leaves := CalculateLeaves(sc.FileSize)
+	leafRoots := make([][]byte, 0, leaves)
+	segments := make([][64]byte, leaves)
+	for i := uint64(0); i < leaves; i++ {
+		copy(segments[i][:], data[i*merkle.LeafSize:])
+		leafRoots = append(leafRoots, leafHash(sha256.New(), proofSegment(segments[i], i, leaves, sc.FileSize)))
+	}
+	hashSet, err := merkle.Sha256MultiSegmentProof(indices, merkle.NewCachedSubtreeRoot(leafRoots, sha256.New()), leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockProof := func(tamper bool) []byte {
+		sp := types.StorageProof{ParentID: sc.ID(), Segment: segments[indices[0]], HashSet: hashSet}
+		for _, index := range indices[1:] {
+			sp.Segments = append(sp.Segments, segments[index])
+		}
+		if tamper {
+			sp.Segments[0][0] ^= 0xff
+		}
+		sp.Signature, err = crypto.Sign(sp.RLPHash().Bytes(), prvAndAddresses[1].Privkey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rlpBytes, err := rlp.EncodeToBytes(sp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rlpBytes
+	}
+
+	if _, _, err := evm.StorageProofTx(AccountRef{}, mockProof(true), gasOrigin); err != errInvalidStorageProof {
+		t.Errorf("tampered storage proof: expect error %v, got %v", errInvalidStorageProof, err)
+	}
+
+	var sp types.StorageProof
+	rlpBytes := mockProof(false)
+	if err := rlp.DecodeBytes(rlpBytes, &sp); err != nil {
+		t.Fatal(err)
+	}
+	snapshot := stateDB.Snapshot()
+	_, gasLeft, err := evm.StorageProofTx(AccountRef{}, rlpBytes, gasOrigin)
+	if err != nil {
+		t.Fatalf("failed to execute multi segment storage proof tx: %v", err)
+	}
+	if wanted := gasOrigin - params.DecodeGas - StorageProofGas(sp, fork); gasLeft != wanted {
+		t.Errorf("gas left is not right after executing storage proof tx, wanted %d, got %d", wanted, gasLeft)
+	}
+
+	// the same proof submitted through the storage proof precompile by a contract
+	stateDB.RevertToSnapshot(snapshot)
+	proofAddr := common.BytesToAddress([]byte{12})
+	contract := NewContract(AccountRef(prvAndAddresses[1].Address), AccountRef(proofAddr), new(big.Int), gasOrigin)
+	contract.CodeAddr = &proofAddr
+	if _, err := RunPrecompiledContract(newStoragePrecompile(evm, contract, proofAddr, false), rlpBytes, contract); err != nil {
+		t.Fatalf("failed to execute multi segment storage proof through the precompile: %v", err)
+	}
+	if wanted := gasOrigin - params.DecodeGas - StorageProofGas(sp, fork); contract.Gas != wanted {
+		t.Errorf("gas left is not right after calling the storage proof precompile, wanted %d, got %d", wanted, contract.Gas)
+	}
+	contractAddr := common.BytesToAddress(sp.ParentID[12:])
+	if stateDB.GetNonce(contractAddr) != 0 {
+		t.Errorf("storage contract not settled by the storage proof precompile")
+	}
+}
+
+// TestEVM_StorageProofTxV0Segments tests that the storage proof carrying the segments following
+// Segment fails to decode before the multi segment proofs, charging the same gas as the nodes
+// decoding the storage proof without the segments
+func TestEVM_StorageProofTxV0Segments(t *testing.T) {
+	evm, stateDB, prvAndAddresses, err := mockEvmAndState(1101)
+	if err != nil {
+		t.Fatal(err)
+	}
+	evm.chainConfig = params.MainnetChainConfig
+	evm.chainRules = params.MainnetChainConfig.Rules(evm.BlockNumber)
+	if fork := params.MainnetChainConfig.StorageFork(evm.BlockNumber); fork == nil || fork.ProofVersion != params.StorageProofV0 {
+		t.Fatalf("expect the storage proof V0 active, got %+v", fork)
+	}
+	sc, err := mockStorageContract(prvAndAddresses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockWriteStorageContractIntoState(*sc, stateDB)
+
+	sp := types.StorageProof{
+		ParentID: sc.ID(),
+		HashSet:  []common.Hash{common.HexToHash("0x02")},
+		Segments: [][64]byte{{1}, {2}},
+	}
+	rlpBytes, err := rlp.EncodeToBytes(sp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the storage proof decoded before the multi segment proofs
+	var baseline struct {
+		ParentID  common.Hash
+		Segment   [64]byte
+		HashSet   []common.Hash
+		Signature []byte
+	}
+	gasBaseline, resultBaseline := RemainGas(gasOrigin, rlp.DecodeBytes, rlpBytes, &baseline)
+	if err, _ := resultBaseline[0].(error); err == nil {
+		t.Fatal("storage proof with segments decoded by the baseline")
+	}
+
+	_, gasLeft, err := evm.StorageProofTx(AccountRef{}, rlpBytes, gasOrigin)
+	if err == nil {
+		t.Error("storage proof with segments decoded before the multi segment proofs")
+	}
+	if gasLeft != gasBaseline {
+		t.Errorf("expect gas left %d as the baseline, got %d", gasBaseline, gasLeft)
+	}
+
+	// the storage proof without the segments is decoded the same as the baseline
+	sp.Segments = nil
+	if rlpBytes, err = rlp.EncodeToBytes(sp); err != nil {
+		t.Fatal(err)
+	}
+	var single types.StorageProof
+	if err := types.DecodeSingleSegmentProof(rlpBytes, &single); err != nil {
+		t.Fatalf("failed to decode the single segment proof: %v", err)
+	}
+	if single.ParentID != sp.ParentID || len(single.HashSet) != 1 || single.HashSet[0] != sp.HashSet[0] || len(single.Segments) != 0 {
+		t.Errorf("unexpected single segment proof: %+v", single)
+	}
+}
+
 func mockAccountAlloc(addrs []common.Address) AccountAlloc {
 	accounts := make(AccountAlloc)
 	for _, addr := range addrs {
@@ -147,13 +147,8 @@ func RemainGas(args ...interface{}) (uint64, []interface{}) {
 		return gas, result
 
 		//CheckStorageProof
-	case func(StateDB, types.StorageProof, uint64, common.Address, common.Address) error:
-		if gas < params.CheckFileGas {
-			result = append(result, errGasCalculationInsufficient)
-			return gas, result
-		}
-
-		if len(args) != 7 {
+	case func(StateDB, types.StorageProof, uint64, common.Address, common.Address, *params.StorageFork) error:
+		if len(args) != 8 {
 			result = append(result, errGasCalculationParamsNumberWrong)
 			return gas, result
 		}
@@ -162,8 +157,14 @@ func RemainGas(args ...interface{}) (uint64, []interface{}) {
 		bl, _ := args[4].(uint64)
 		statusAddr, _ := args[5].(common.Address)
 		contractAddr, _ := args[6].(common.Address)
-		gas -= params.CheckFileGas
-		err := i(state, sp, bl, statusAddr, contractAddr)
+		fork, _ := args[7].(*params.StorageFork)
+		proofGas := StorageProofGas(sp, fork)
+		if gas < proofGas {
+			result = append(result, errGasCalculationInsufficient)
+			return gas, result
+		}
+		gas -= proofGas
+		err := i(state, sp, bl, statusAddr, contractAddr, fork)
 		if err != nil {
 			result = append(result, err)
 			return gas, result
@@ -63,6 +63,7 @@ type StateDB interface {
 
 	AddLog(*types.Log)
 	AddPreimage(common.Hash, []byte)
+	ArchiveStorageContract(*types.ExpiredStorageContract)
 
 	ForEachStorage(common.Address, func(common.Hash, common.Hash) bool)
 
@@ -0,0 +1,284 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package runtime
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/state"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage/coinchargemaintenance"
+)
+
+var (
+	contractAddress  = common.BytesToAddress([]byte("contract"))
+	createPrecompile = common.BytesToAddress([]byte{10})
+	statusPrecompile = common.BytesToAddress([]byte{13})
+
+	clientCollateral = big.NewInt(1000)
+	hostCollateral   = big.NewInt(2000)
+)
+
+// proxyCode returns the code calling the precompile with the call data, either by CALL or by
+// STATICCALL. The code returns the success flag of the call in the first word, followed by the
+// return data of the precompile
+func proxyCode(precompile common.Address, static bool) []byte {
+	code := []byte{
+		// copy the call data to the memory
+		byte(vm.CALLDATASIZE), byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.CALLDATACOPY),
+		// call the precompile with the call data
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.CALLDATASIZE), byte(vm.PUSH1), 0,
+	}
+	if static {
+		code = append(code, byte(vm.PUSH1), precompile[common.AddressLength-1], byte(vm.GAS), byte(vm.STATICCALL))
+	} else {
+		code = append(code, byte(vm.PUSH1), 0, byte(vm.PUSH1), precompile[common.AddressLength-1], byte(vm.GAS), byte(vm.CALL))
+	}
+	return append(code,
+		// store the success flag, followed by the return data
+		byte(vm.PUSH1), 0, byte(vm.MSTORE),
+		byte(vm.RETURNDATASIZE), byte(vm.PUSH1), 0, byte(vm.PUSH1), 0x20, byte(vm.RETURNDATACOPY),
+		byte(vm.RETURNDATASIZE), byte(vm.PUSH1), 0x20, byte(vm.ADD), byte(vm.PUSH1), 0, byte(vm.RETURN),
+	)
+}
+
+// newStorageConfig returns the config with the state funding the contract and the host
+func newStorageConfig(host common.Address) *Config {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	statedb.AddBalance(contractAddress, clientCollateral)
+	statedb.AddBalance(host, hostCollateral)
+	return &Config{
+		ChainConfig: &params.ChainConfig{
+			ChainID:        big.NewInt(1),
+			HomesteadBlock: new(big.Int),
+			EIP150Block:    new(big.Int),
+			EIP155Block:    new(big.Int),
+			EIP158Block:    new(big.Int),
+			ByzantiumBlock: new(big.Int),
+			Storage:        params.DevStorageConfig,
+		},
+		State: statedb,
+	}
+}
+
+// mockStorageContract returns the storage contract signed by the client and the host, where the
+// client collateral is paid by the payer
+func mockStorageContract(t *testing.T, payer common.Address, clientKey, hostKey *ecdsa.PrivateKey) *types.StorageContract {
+	clientAddress := crypto.PubkeyToAddress(clientKey.PublicKey)
+	hostAddress := crypto.PubkeyToAddress(hostKey.PublicKey)
+	clientCharge := types.DxcoinCharge{Value: clientCollateral, Address: payer}
+	hostCharge := types.DxcoinCharge{Value: hostCollateral, Address: hostAddress}
+	uc := types.UnlockConditions{
+		PaymentAddresses:   []common.Address{clientAddress, hostAddress},
+		SignaturesRequired: 2,
+	}
+
+	sc := &types.StorageContract{
+		WindowStart:        uint64(1001),
+		WindowEnd:          uint64(1101),
+		ClientCollateral:   types.DxcoinCollateral{DxcoinCharge: clientCharge},
+		HostCollateral:     types.DxcoinCollateral{DxcoinCharge: hostCharge},
+		UnlockHash:         uc.UnlockHash(),
+		ValidProofOutputs:  []types.DxcoinCharge{clientCharge, hostCharge},
+		MissedProofOutputs: []types.DxcoinCharge{clientCharge, hostCharge},
+	}
+	for _, key := range []*ecdsa.PrivateKey{clientKey, hostKey} {
+		sig, err := crypto.Sign(sc.RLPHash().Bytes(), key)
+		if err != nil {
+			t.Fatalf("failed to sign storage contract: %v", err)
+		}
+		sc.Signatures = append(sc.Signatures, sig)
+	}
+	return sc
+}
+
+func generateKeys(t *testing.T) (*ecdsa.PrivateKey, *ecdsa.PrivateKey) {
+	clientKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return clientKey, hostKey
+}
+
+// TestStoragePrecompile_CreateContract tests the storage contract created by the contract, where
+// the client collateral is paid by the contract
+func TestStoragePrecompile_CreateContract(t *testing.T) {
+	clientKey, hostKey := generateKeys(t)
+	hostAddress := crypto.PubkeyToAddress(hostKey.PublicKey)
+	sc := mockStorageContract(t, contractAddress, clientKey, hostKey)
+	input, err := rlp.EncodeToBytes(sc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := newStorageConfig(hostAddress)
+	ret, statedb, err := Execute(proxyCode(createPrecompile, false), input, cfg)
+	if err != nil {
+		t.Fatalf("failed to execute: %v", err)
+	}
+	if new(big.Int).SetBytes(ret[:32]).Uint64() != 1 {
+		t.Fatalf("storage contract creation failed: %x", ret[32:])
+	}
+	if statedb.GetBalance(contractAddress).Sign() != 0 {
+		t.Errorf("client collateral not paid by the contract: %v", statedb.GetBalance(contractAddress))
+	}
+	if statedb.GetBalance(hostAddress).Sign() != 0 {
+		t.Errorf("host collateral not paid by the host: %v", statedb.GetBalance(hostAddress))
+	}
+
+	id := sc.ID()
+	scAddress := common.BytesToAddress(id[12:])
+	total := new(big.Int).Add(clientCollateral, hostCollateral)
+	if statedb.GetBalance(scAddress).Cmp(total) != 0 {
+		t.Errorf("storage contract balance: expect %v, got %v", total, statedb.GetBalance(scAddress))
+	}
+	if statedb.GetState(scAddress, coinchargemaintenance.KeyClientAddress) != common.BytesToHash(contractAddress.Bytes()) {
+		t.Errorf("storage contract client not the contract")
+	}
+}
+
+// TestStoragePrecompile_Revert tests that the failed storage contract creation reverts with the
+// reason, and leaves the state untouched
+func TestStoragePrecompile_Revert(t *testing.T) {
+	clientKey, hostKey := generateKeys(t)
+	hostAddress := crypto.PubkeyToAddress(hostKey.PublicKey)
+	other := crypto.PubkeyToAddress(clientKey.PublicKey)
+	staticInput, err := rlp.EncodeToBytes(mockStorageContract(t, contractAddress, clientKey, hostKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherInput, err := rlp.EncodeToBytes(mockStorageContract(t, other, clientKey, hostKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		code   []byte
+		input  []byte
+		reason string
+	}{
+		{proxyCode(createPrecompile, false), otherInput, "client collateral must be paid by the caller"},
+		{proxyCode(createPrecompile, true), staticInput, "evm: write protection"},
+		{proxyCode(createPrecompile, false), []byte{0x01}, ""},
+		{proxyCode(statusPrecompile, false), []byte{0x01}, "invalid storage contract id"},
+	}
+	for i, test := range tests {
+		cfg := newStorageConfig(hostAddress)
+		cfg.State.AddBalance(other, clientCollateral)
+		ret, statedb, err := Execute(test.code, test.input, cfg)
+		if err != nil {
+			t.Fatalf("test %d: failed to execute: %v", i, err)
+		}
+		if new(big.Int).SetBytes(ret[:32]).Sign() != 0 {
+			t.Errorf("test %d: expect the call failed", i)
+		}
+		if !bytes.Equal(ret[32:36], []byte{0x08, 0xc3, 0x79, 0xa0}) {
+			t.Errorf("test %d: expect the revert reason, got %x", i, ret[32:])
+		}
+		if test.reason != "" && !bytes.Contains(ret, []byte(test.reason)) {
+			t.Errorf("test %d: expect the reason %s, got %x", i, test.reason, ret[32:])
+		}
+		if statedb.GetBalance(contractAddress).Cmp(clientCollateral) != 0 ||
+			statedb.GetBalance(other).Cmp(clientCollateral) != 0 ||
+			statedb.GetBalance(hostAddress).Cmp(hostCollateral) != 0 {
+			t.Errorf("test %d: balance changed by the failed call", i)
+		}
+	}
+}
+
+// TestStoragePrecompile_Status tests reading the status of the storage contract from the contract
+func TestStoragePrecompile_Status(t *testing.T) {
+	clientKey, hostKey := generateKeys(t)
+	hostAddress := crypto.PubkeyToAddress(hostKey.PublicKey)
+	sc := mockStorageContract(t, contractAddress, clientKey, hostKey)
+	input, err := rlp.EncodeToBytes(sc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := newStorageConfig(hostAddress)
+	if _, _, err := Execute(proxyCode(createPrecompile, false), input, cfg); err != nil {
+		t.Fatalf("failed to execute: %v", err)
+	}
+
+	id := sc.ID()
+	ret, _, err := Execute(proxyCode(statusPrecompile, true), id[:], cfg)
+	if err != nil {
+		t.Fatalf("failed to execute: %v", err)
+	}
+	expects := []*big.Int{
+		big.NewInt(1),
+		big.NewInt(1),
+		new(big.Int).SetBytes(contractAddress.Bytes()),
+		new(big.Int).SetBytes(hostAddress.Bytes()),
+		new(big.Int),
+		new(big.Int),
+		new(big.Int),
+		new(big.Int).SetUint64(sc.WindowStart),
+		new(big.Int).SetUint64(sc.WindowEnd),
+		clientCollateral,
+		hostCollateral,
+	}
+	if len(ret) != len(expects)*32 {
+		t.Fatalf("status size: expect %v, got %v", len(expects)*32, len(ret))
+	}
+	for i, expect := range expects {
+		if got := new(big.Int).SetBytes(ret[i*32 : (i+1)*32]); got.Cmp(expect) != 0 {
+			t.Errorf("word %d: expect %v, got %v", i, expect, got)
+		}
+	}
+
+	// the status of the storage contract not existing is inactive
+	ret, _, err = Execute(proxyCode(statusPrecompile, true), common.HexToHash("0x01").Bytes(), cfg)
+	if err != nil {
+		t.Fatalf("failed to execute: %v", err)
+	}
+	if new(big.Int).SetBytes(ret[:32]).Uint64() != 1 || new(big.Int).SetBytes(ret[32:64]).Sign() != 0 {
+		t.Errorf("expect the storage contract inactive")
+	}
+}
+
+// TestStoragePrecompile_NotActive tests that the storage contract precompiles are not available
+// before the storage fork, nor on the storage protocol not making them callable from the contracts
+func TestStoragePrecompile_NotActive(t *testing.T) {
+	clientKey, hostKey := generateKeys(t)
+	hostAddress := crypto.PubkeyToAddress(hostKey.PublicKey)
+	sc := mockStorageContract(t, contractAddress, clientKey, hostKey)
+	input, err := rlp.EncodeToBytes(sc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, storageConfig := range []*params.StorageConfig{
+		{Forks: []params.StorageFork{{Block: big.NewInt(100), SectorSize: 1 << 22, SegmentSize: 64, ContractCalls: true}}},
+		params.DefaultStorageConfig,
+	} {
+		cfg := newStorageConfig(hostAddress)
+		cfg.ChainConfig.Storage = storageConfig
+		ret, statedb, err := Execute(proxyCode(createPrecompile, false), input, cfg)
+		if err != nil {
+			t.Fatalf("test %d: failed to execute: %v", i, err)
+		}
+		if len(ret) != 32 {
+			t.Errorf("test %d: expect no return data from the inactive precompile, got %x", i, ret[32:])
+		}
+		id := sc.ID()
+		if statedb.Exist(common.BytesToAddress(id[12:])) || statedb.GetBalance(contractAddress).Cmp(clientCollateral) != 0 {
+			t.Errorf("test %d: storage contract created by the inactive precompile", i)
+		}
+	}
+}
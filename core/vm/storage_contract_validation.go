@@ -13,6 +13,7 @@ import (
 	"hash"
 	"math/big"
 	"reflect"
+	"sort"
 	"strconv"
 
 	"github.com/DxChainNetwork/godx/common"
@@ -23,6 +24,7 @@ import (
 	"github.com/DxChainNetwork/godx/ethdb"
 	"github.com/DxChainNetwork/godx/log"
 	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/params"
 	"github.com/DxChainNetwork/godx/storage/coinchargemaintenance"
 )
 
@@ -42,6 +44,14 @@ var (
 	errNoStorageContractType                   = errors.New("no this storage contract type")
 	errInvalidStorageProof                     = errors.New("invalid storage proof")
 	errUnfinishedStorageContract               = errors.New("storage contract has not yet opened")
+	errAnnounceAltAddressMismatch              = errors.New("host announce alternative address belongs to another node")
+	errAnnounceKeyCertSigner                   = errors.New("host announce key cert is not signed by the host node")
+	errAnnounceKeyMismatch                     = errors.New("host announce is not signed by the certified announcement key")
+	errAnnounceKeyExpired                      = errors.New("host announce key cert has expired")
+	errExtendedAnnouncementNotActive           = errors.New("extended host announcement is not active")
+	errStorageNotActive                        = errors.New("storage protocol is not active")
+	errUnsupportedSegmentSize                  = errors.New("storage fork segment size is not supported")
+	errUnsupportedProofVersion                 = errors.New("storage fork proof version is not supported")
 )
 
 // CheckCreateContract checks whether a new StorageContract is valid
@@ -242,15 +252,7 @@ func CheckMultiSignatures(originalData types.StorageContractRLPHash, signatures
 
 		// if it's a host announce, we must check the node public key is equal to the recover key
 		if ha, ok := originalData.(types.HostAnnouncement); ok {
-			hostNode, err := enode.ParseV4(ha.NetAddress)
-			if err != nil {
-				return fmt.Errorf("invalid host announce address: %v", err)
-			}
-
-			urlKey := hostNode.Pubkey()
-			if !crypto.IsEqualPublicKey(recoverKey, urlKey) {
-				return fmt.Errorf("announced host net address is not generated by self hostnode")
-			}
+			return checkHostAnnouncement(ha, recoverKey)
 		}
 	} else if len(signatures) == 2 {
 		clientSig = signatures[0]
@@ -287,9 +289,51 @@ func CheckMultiSignatures(originalData types.StorageContractRLPHash, signatures
 	return nil
 }
 
-// CheckStorageProof checks whether a new StorageProof is valid
-func CheckStorageProof(state StateDB, sp types.StorageProof, currentHeight uint64, statusAddr common.Address, contractAddr common.Address) error {
+// checkHostAnnouncement checks whether the host announcement is signed by the host. If the
+// announcement has a key cert, the announcement must be signed by the announcement key
+// authorized by the node key, otherwise it must be signed by the node key directly. All the
+// alternative addresses must belong to the same node
+func checkHostAnnouncement(ha types.HostAnnouncement, recoverKey *ecdsa.PublicKey) error {
+	hostNode, err := enode.ParseV4(ha.NetAddress)
+	if err != nil {
+		return fmt.Errorf("invalid host announce address: %v", err)
+	}
+
+	for _, addr := range ha.AltAddresses {
+		altNode, err := enode.ParseV4(addr)
+		if err != nil {
+			return fmt.Errorf("invalid host announce alternative address: %v", err)
+		}
+		if altNode.ID() != hostNode.ID() {
+			return errAnnounceAltAddressMismatch
+		}
+	}
+
+	urlKey := hostNode.Pubkey()
+	if ha.KeyCert == nil {
+		if !crypto.IsEqualPublicKey(recoverKey, urlKey) {
+			return fmt.Errorf("announced host net address is not generated by self hostnode")
+		}
+		return nil
+	}
+
+	// the key cert must be signed by the node key, and the announcement by the certified key
+	certKey, err := crypto.SigToPub(ha.KeyCert.RLPHash().Bytes(), ha.KeyCert.Signature)
+	if err != nil {
+		return err
+	}
+	if !crypto.IsEqualPublicKey(certKey, urlKey) {
+		return errAnnounceKeyCertSigner
+	}
+	if crypto.PubkeyToAddress(*recoverKey) != ha.KeyCert.KeyAddress {
+		return errAnnounceKeyMismatch
+	}
+	return nil
+}
 
+// CheckStorageProofWindow checks whether the StorageProof is submitted within the proof window of the
+// storage contract, which is not proved yet
+func CheckStorageProofWindow(state StateDB, sp types.StorageProof, currentHeight uint64, statusAddr common.Address, contractAddr common.Address) error {
 	// check whether it proofed repeatedly
 	statusContent := state.GetState(statusAddr, sp.ParentID)
 	flag := statusContent.Bytes()[11:12]
@@ -297,18 +341,12 @@ func CheckStorageProof(state StateDB, sp types.StorageProof, currentHeight uint6
 		return errors.New("can not submit storage proof repeatedly")
 	}
 
-	// retrieve the storage contract info
 	windowStartHash := state.GetState(contractAddr, coinchargemaintenance.KeyWindowStart)
 	windowStart := new(big.Int).SetBytes(windowStartHash.Bytes()).Uint64()
 
 	windowEndHash := state.GetState(contractAddr, coinchargemaintenance.KeyWindowEnd)
 	windowEnd := new(big.Int).SetBytes(windowEndHash.Bytes()).Uint64()
 
-	fileMerkleRoot := state.GetState(contractAddr, coinchargemaintenance.KeyFileMerkleRoot)
-
-	fileSizeHash := state.GetState(contractAddr, coinchargemaintenance.KeyFileSize)
-	fileSize := new(big.Int).SetBytes(fileSizeHash.Bytes()).Uint64()
-
 	if windowStart > currentHeight {
 		return errors.New("too early to submit storage proof")
 	}
@@ -316,6 +354,30 @@ func CheckStorageProof(state StateDB, sp types.StorageProof, currentHeight uint6
 	if windowEnd < currentHeight {
 		return errors.New("too late to submit storage proof")
 	}
+	return nil
+}
+
+// CheckStorageProof checks whether a new StorageProof is valid under the storage fork active at currentHeight
+func CheckStorageProof(state StateDB, sp types.StorageProof, currentHeight uint64, statusAddr common.Address, contractAddr common.Address, fork *params.StorageFork) error {
+	if fork == nil {
+		return errStorageNotActive
+	}
+	if fork.SegmentSize != merkle.LeafSize {
+		return errUnsupportedSegmentSize
+	}
+
+	if err := CheckStorageProofWindow(state, sp, currentHeight, statusAddr, contractAddr); err != nil {
+		return err
+	}
+
+	// retrieve the storage contract info
+	windowStartHash := state.GetState(contractAddr, coinchargemaintenance.KeyWindowStart)
+	windowStart := new(big.Int).SetBytes(windowStartHash.Bytes()).Uint64()
+
+	fileMerkleRoot := state.GetState(contractAddr, coinchargemaintenance.KeyFileMerkleRoot)
+
+	fileSizeHash := state.GetState(contractAddr, coinchargemaintenance.KeyFileSize)
+	fileSize := new(big.Int).SetBytes(fileSizeHash.Bytes()).Uint64()
 
 	// check signature
 	err := CheckMultiSignatures(sp, [][]byte{sp.Signature})
@@ -325,40 +387,68 @@ func CheckStorageProof(state StateDB, sp types.StorageProof, currentHeight uint6
 	}
 
 	// check that the storage proof itself is valid.
-
-	segmentIndex, err := storageProofSegment(state, windowStart, fileSize, sp.ParentID, currentHeight)
+	segmentIndices, err := storageProofSegments(state, fork, windowStart, fileSize, sp.ParentID, currentHeight)
 	if err != nil {
 		return err
 	}
 
 	leaves := CalculateLeaves(fileSize)
-
-	segmentLen := uint64(merkle.LeafSize)
-
-	// if this segment chosen is the final segment, it should only be as
-	// long as necessary to complete the file size.
-	if segmentIndex == leaves-1 {
-		segmentLen = fileSize % merkle.LeafSize
+	if fork.ProofVersion == params.StorageProofV0 {
+		if len(sp.Segments) != 0 {
+			return errInvalidStorageProof
+		}
+		segmentIndex := segmentIndices[0]
+		verified := VerifySegment(
+			proofSegment(sp.Segment, segmentIndex, leaves, fileSize),
+			sp.HashSet,
+			leaves,
+			segmentIndex,
+			fileMerkleRoot,
+		)
+		if !verified && fileSize > 0 {
+			return errInvalidStorageProof
+		}
+		return nil
 	}
 
-	if segmentLen == 0 {
-		segmentLen = uint64(merkle.LeafSize)
+	// the multi segment proof proves all the segments selected with one shared proof
+	if fileSize == 0 {
+		return nil
 	}
-
-	verified := VerifySegment(
-		sp.Segment[:segmentLen],
-		sp.HashSet,
-		leaves,
-		segmentIndex,
-		fileMerkleRoot,
-	)
-	if !verified && fileSize > 0 {
+	proved := append([][64]byte{sp.Segment}, sp.Segments...)
+	if len(proved) != len(segmentIndices) {
+		return errInvalidStorageProof
+	}
+	segments := make([][]byte, 0, len(proved))
+	for i, segmentIndex := range segmentIndices {
+		segments = append(segments, proofSegment(proved[i], segmentIndex, leaves, fileSize))
+	}
+	if err := merkle.Sha256VerifyMultiSegmentProof(segments, segmentIndices, leaves, sp.HashSet, fileMerkleRoot); err != nil {
 		return errInvalidStorageProof
 	}
-
 	return nil
 }
 
+// proofSegment returns the data of the segment at the index. If the segment is the final segment,
+// it is only as long as necessary to complete the file size.
+func proofSegment(segment [64]byte, segmentIndex, leaves, fileSize uint64) []byte {
+	segmentLen := uint64(merkle.LeafSize)
+	if segmentIndex == leaves-1 && fileSize%merkle.LeafSize != 0 {
+		segmentLen = fileSize % merkle.LeafSize
+	}
+	return segment[:segmentLen]
+}
+
+// StorageProofGas returns the gas for checking the storage proof under the storage fork. The
+// multi segment proof is charged for each segment and hash in addition.
+func StorageProofGas(sp types.StorageProof, fork *params.StorageFork) uint64 {
+	gas := params.CheckFileGas
+	if fork != nil && fork.ProofVersion != params.StorageProofV0 {
+		gas += uint64(1+len(sp.Segments))*params.StorageProofSegmentGas + uint64(len(sp.HashSet))*params.StorageProofHashGas
+	}
+	return gas
+}
+
 // VerifySegment checks whether host has really stored the file
 func VerifySegment(segment []byte, hashSet []common.Hash, leaves, segmentIndex uint64, merkleRoot common.Hash) bool {
 
@@ -371,28 +461,62 @@ func VerifySegment(segment []byte, hashSet []common.Hash, leaves, segmentIndex u
 	return VerifyProof(merkleRoot[:], proofSet, segmentIndex, leaves)
 }
 
-// get segment index by random
-func storageProofSegment(state StateDB, windowStart, fileSize uint64, scID common.Hash, currentHeight uint64) (uint64, error) {
-
+// storageProofSegments returns the indices of the segments to prove, selected by the hash of the
+// block before the proof window start
+func storageProofSegments(state StateDB, fork *params.StorageFork, windowStart, fileSize uint64, scID common.Hash, currentHeight uint64) ([]uint64, error) {
 	// Get the trigger block id that parent of windowStart.
 	triggerHeight := windowStart - 1
 	if triggerHeight > currentHeight {
-		return 0, errUnfinishedStorageContract
+		return nil, errUnfinishedStorageContract
 	}
 
 	db := state.Database().TrieDB().DiskDB().(ethdb.Database)
 	blockHash := rawdb.ReadCanonicalHash(db, uint64(triggerHeight))
 	if reflect.DeepEqual(blockHash, common.Hash{}) {
-		return 0, errors.New("can not read block hash of the trigger height for storage proof seed")
+		return nil, errors.New("can not read block hash of the trigger height for storage proof seed")
 	}
+	return StorageProofSegments(fork.ProofVersion, blockHash, scID, fileSize)
+}
 
-	seed := crypto.Keccak256Hash(blockHash[:], scID[:])
-	numSegments := int64(CalculateLeaves(fileSize))
+// StorageProofSegments returns the ascending indices of the segments of the file to prove in
+// the storage proof of the proof version, selected by the hash of the trigger block and the
+// storage contract id
+func StorageProofSegments(version uint64, triggerHash common.Hash, scID common.Hash, fileSize uint64) ([]uint64, error) {
+	numSegments := CalculateLeaves(fileSize)
+
+	switch version {
+	case params.StorageProofV0:
+		// index = seedInt % numSegments，index in [0，numSegments]
+		seed := crypto.Keccak256Hash(triggerHash[:], scID[:])
+		seedInt := new(big.Int).SetBytes(seed[:])
+		index := seedInt.Mod(seedInt, new(big.Int).SetUint64(numSegments)).Uint64()
+		return []uint64{index}, nil
+
+	case params.StorageProofV1:
+		indices := make([]uint64, 0, params.StorageProofSegments)
+		if numSegments <= params.StorageProofSegments {
+			for i := uint64(0); i < numSegments; i++ {
+				indices = append(indices, i)
+			}
+			return indices, nil
+		}
+		// the segments are selected without replacement by the seed of the counter
+		selected := make(map[uint64]bool)
+		for counter := uint64(0); uint64(len(indices)) < params.StorageProofSegments; counter++ {
+			seed := crypto.Keccak256Hash(triggerHash[:], scID[:], Uint64ToBytes(counter))
+			seedInt := new(big.Int).SetBytes(seed[:])
+			index := seedInt.Mod(seedInt, new(big.Int).SetUint64(numSegments)).Uint64()
+			if !selected[index] {
+				selected[index] = true
+				indices = append(indices, index)
+			}
+		}
+		sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+		return indices, nil
 
-	// index = seedInt % numSegments，index in [0，numSegments]
-	seedInt := new(big.Int).SetBytes(seed[:])
-	index := seedInt.Mod(seedInt, big.NewInt(numSegments)).Uint64()
-	return index, nil
+	default:
+		return nil, errUnsupportedProofVersion
+	}
 }
 
 // CalculateLeaves calculates the num of leaves formed by the given file
@@ -1,6 +1,7 @@
 package vm
 
 import (
+	"crypto/ecdsa"
 	"math/big"
 	"net"
 	"testing"
@@ -116,6 +117,51 @@ func mockMerkleProof(leaveContent []string) (common.Hash, []common.Hash) {
 	return common.BytesToHash(p0), hashSet
 }
 
+func TestCheckHostAnnouncementKeyCert(t *testing.T) {
+	nodeKey, _ := crypto.GenerateKey()
+	announceKey, _ := crypto.GenerateKey()
+	otherKey, _ := crypto.GenerateKey()
+	hostNode := enode.NewV4(&nodeKey.PublicKey, net.IP{127, 0, 0, 1}, 8888, 8888)
+	altNode := enode.NewV4(&nodeKey.PublicKey, net.IP{10, 0, 0, 1}, 8888, 8888)
+	otherNode := enode.NewV4(&otherKey.PublicKey, net.IP{10, 0, 0, 2}, 8888, 8888)
+
+	newCert := func(signer *ecdsa.PrivateKey) *types.AnnouncementKeyCert {
+		cert := &types.AnnouncementKeyCert{
+			KeyAddress: crypto.PubkeyToAddress(announceKey.PublicKey),
+			Expiry:     100,
+		}
+		cert.Signature, _ = crypto.Sign(cert.RLPHash().Bytes(), signer)
+		return cert
+	}
+
+	tests := []struct {
+		altAddresses []string
+		cert         *types.AnnouncementKeyCert
+		signer       *ecdsa.PrivateKey
+		err          error
+	}{
+		{[]string{altNode.String()}, nil, nodeKey, nil},
+		{[]string{altNode.String()}, newCert(nodeKey), announceKey, nil},
+		{[]string{otherNode.String()}, nil, nodeKey, errAnnounceAltAddressMismatch},
+		{nil, newCert(otherKey), announceKey, errAnnounceKeyCertSigner},
+		{nil, newCert(nodeKey), otherKey, errAnnounceKeyMismatch},
+	}
+	for i, test := range tests {
+		ha := types.HostAnnouncement{
+			NetAddress:   hostNode.String(),
+			AltAddresses: test.altAddresses,
+			KeyCert:      test.cert,
+		}
+		sig, err := crypto.Sign(ha.RLPHash().Bytes(), test.signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = CheckMultiSignatures(ha, [][]byte{sig}); err != test.err {
+			t.Errorf("test %d: expect error %v, got %v", i, test.err, err)
+		}
+	}
+}
+
 func TestVerifySegment(t *testing.T) {
 	root, hashSet := mockMerkleProof(leaveContent)
 
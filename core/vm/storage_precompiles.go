@@ -0,0 +1,200 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package vm
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage/coinchargemaintenance"
+)
+
+// StorageContractStatus is the tag of the precompiled contract reading the storage contract status
+const StorageContractStatus = "StorageContractStatus"
+
+// PrecompiledStorageContracts contains the storage contract precompiles callable from the smart
+// contracts, which are the storage contract transactions and the storage contract status query
+var PrecompiledStorageContracts = map[common.Address]string{
+	common.BytesToAddress([]byte{9}):  HostAnnounceTransaction,
+	common.BytesToAddress([]byte{10}): ContractCreateTransaction,
+	common.BytesToAddress([]byte{11}): CommitRevisionTransaction,
+	common.BytesToAddress([]byte{12}): StorageProofTransaction,
+	common.BytesToAddress([]byte{13}): StorageContractStatus,
+}
+
+var (
+	errStoragePrecompileValue    = errors.New("storage contract precompile does not accept value")
+	errStoragePrecompileDelegate = errors.New("storage contract precompile must be called by CALL or STATICCALL")
+	errStorageContractCaller     = errors.New("client collateral must be paid by the caller")
+	errInvalidStorageContractID  = errors.New("invalid storage contract id")
+)
+
+// revertSelector is the selector of Error(string), which is used to encode the revert reason
+var revertSelector = []byte{0x08, 0xc3, 0x79, 0xa0}
+
+// storagePrecompile is the storage contract precompile bound to the EVM and the contract calling
+// it. The storage contract transactions are executed against the state of the EVM, and the client
+// collateral of the storage contract created must be paid by the caller. The errors are returned
+// as the reverts with the reason, so that the remaining gas is returned to the caller.
+type storagePrecompile struct {
+	evm      *EVM
+	contract *Contract
+	txType   string
+	readOnly bool
+}
+
+// isStoragePrecompile returns whether the address is a storage contract precompile, which is
+// available only after the storage fork making the precompiles callable from the contracts
+func (evm *EVM) isStoragePrecompile(addr common.Address) bool {
+	_, ok := PrecompiledStorageContracts[addr]
+	return ok && evm.chainRules.IsStorage && evm.chainRules.IsStorageContractCalls
+}
+
+// newStoragePrecompile returns the storage contract precompile of the address, nil if the address
+// is not a storage contract precompile
+func newStoragePrecompile(evm *EVM, contract *Contract, addr common.Address, readOnly bool) PrecompiledContract {
+	if !evm.isStoragePrecompile(addr) {
+		return nil
+	}
+	txType := PrecompiledStorageContracts[addr]
+	if in, ok := evm.interpreter.(*EVMInterpreter); ok && in.readOnly {
+		readOnly = true
+	}
+	return &storagePrecompile{evm: evm, contract: contract, txType: txType, readOnly: readOnly}
+}
+
+// RequiredGas returns the gas of decoding and checking the storage contract transaction, which
+// is the same as the storage contract transaction sent directly
+func (c *storagePrecompile) RequiredGas(input []byte) uint64 {
+	switch c.txType {
+	case HostAnnounceTransaction:
+		return params.DecodeGas + params.CheckMultiSignaturesGas
+	case StorageContractStatus:
+		return params.ReadStorageContractGas
+	case StorageProofTransaction:
+		var sp types.StorageProof
+		fork := c.evm.ChainConfig().StorageFork(c.evm.BlockNumber)
+		if err := storageProofDecoder(fork)(input, &sp); err != nil {
+			return params.DecodeGas + params.CheckFileGas
+		}
+		return params.DecodeGas + StorageProofGas(sp, fork)
+	default:
+		return params.DecodeGas + params.CheckFileGas
+	}
+}
+
+// Run executes the storage contract precompile
+func (c *storagePrecompile) Run(input []byte) ([]byte, error) {
+	ret, err := c.run(input)
+	if err != nil {
+		return revertReason(err), errExecutionReverted
+	}
+	return ret, nil
+}
+
+func (c *storagePrecompile) run(input []byte) ([]byte, error) {
+	if c.contract.value != nil && c.contract.value.Sign() != 0 {
+		return nil, errStoragePrecompileValue
+	}
+	if c.contract.CodeAddr == nil || *c.contract.CodeAddr != c.contract.Address() {
+		return nil, errStoragePrecompileDelegate
+	}
+	if c.txType == StorageContractStatus {
+		return c.status(input)
+	}
+	if c.readOnly {
+		return nil, errWriteProtection
+	}
+
+	caller := AccountRef(c.contract.Caller())
+	if c.txType == ContractCreateTransaction {
+		sc := types.StorageContract{}
+		if err := rlp.DecodeBytes(input, &sc); err != nil {
+			return nil, err
+		}
+		if sc.ClientCollateral.Address != caller.Address() {
+			return nil, errStorageContractCaller
+		}
+	}
+	ret, _, err := c.evm.ApplyStorageContractTransaction(caller, c.txType, input, c.RequiredGas(input))
+	if err != nil {
+		return nil, err
+	}
+
+	// record the storage contract transaction in the receipt, so that the transactions called
+	// from the contracts are indexed like the ones sent to the precompiled addresses directly.
+	// The log is reverted along with the call
+	c.evm.StateDB.AddLog(&types.Log{
+		Address:     c.contract.Address(),
+		Topics:      []common.Hash{common.BytesToHash(caller.Address().Bytes())},
+		Data:        common.CopyBytes(input),
+		BlockNumber: c.evm.BlockNumber.Uint64(),
+	})
+	return ret, nil
+}
+
+// StorageCallLog returns the type, the caller and the input of the storage contract transaction
+// called from the contract, which is recorded by the log of the storage contract precompile.
+// False is returned if the log is not emitted by a storage contract precompile
+func StorageCallLog(l *types.Log) (txType string, caller common.Address, input []byte, ok bool) {
+	txType, ok = PrecompiledEVMFileContracts[l.Address]
+	if !ok || len(l.Topics) != 1 {
+		return "", common.Address{}, nil, false
+	}
+	return txType, common.BytesToAddress(l.Topics[0].Bytes()), l.Data, true
+}
+
+// status returns the status of the storage contract with the id as the input. The output is
+// the 32 bytes words of whether the storage contract is active, the client address, the host
+// address, the file size, the file merkle root, the revision number, the window start, the
+// window end, the client valid proof output and the host valid proof output
+func (c *storagePrecompile) status(input []byte) ([]byte, error) {
+	if len(input) != common.HashLength {
+		return nil, errInvalidStorageContractID
+	}
+	contractAddr := common.BytesToAddress(input[12:])
+	state := c.evm.StateDB
+
+	var active common.Hash
+	if state.Exist(contractAddr) {
+		active[common.HashLength-1] = 1
+	}
+	words := []common.Hash{active}
+	for _, key := range []common.Hash{
+		coinchargemaintenance.KeyClientAddress,
+		coinchargemaintenance.KeyHostAddress,
+		coinchargemaintenance.KeyFileSize,
+		coinchargemaintenance.KeyFileMerkleRoot,
+		coinchargemaintenance.KeyRevisionNumber,
+		coinchargemaintenance.KeyWindowStart,
+		coinchargemaintenance.KeyWindowEnd,
+		coinchargemaintenance.KeyClientValidProofOutput,
+		coinchargemaintenance.KeyHostValidProofOutput,
+	} {
+		words = append(words, state.GetState(contractAddr, key))
+	}
+
+	ret := make([]byte, 0, len(words)*common.HashLength)
+	for _, word := range words {
+		ret = append(ret, word.Bytes()...)
+	}
+	return ret, nil
+}
+
+// revertReason encodes the error as the revert reason in the form of Error(string)
+func revertReason(err error) []byte {
+	reason := []byte(err.Error())
+	ret := append([]byte{}, revertSelector...)
+	ret = append(ret, common.LeftPadBytes([]byte{0x20}, 32)...)
+
+	var length [32]byte
+	binary.BigEndian.PutUint64(length[24:], uint64(len(reason)))
+	ret = append(ret, length[:]...)
+	return append(ret, common.RightPadBytes(reason, (len(reason)+31)/32*32)...)
+}
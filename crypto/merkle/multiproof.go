@@ -0,0 +1,94 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package merkle
+
+import (
+	"crypto/sha256"
+	"errors"
+
+	"github.com/DxChainNetwork/godx/common"
+)
+
+// SegmentLimits returns the ranges of the consecutive segments at the indices, which must be
+// ascending without duplicates
+func SegmentLimits(indices []uint64) ([]SubTreeLimit, error) {
+	var limits []SubTreeLimit
+	for i, index := range indices {
+		if i > 0 && indices[i-1] >= index {
+			return nil, errors.New("segment indices are not ascending")
+		}
+		if len(limits) > 0 && limits[len(limits)-1].Right == index {
+			limits[len(limits)-1].Right++
+			continue
+		}
+		limits = append(limits, SubTreeLimit{Left: index, Right: index + 1})
+	}
+	return limits, nil
+}
+
+// DiffProofSize returns the number of the subtree roots in the diff proof of the ranges of the
+// tree with leavesCount leaves
+func DiffProofSize(rangeSet []SubTreeLimit, leavesCount uint64) int {
+	var size int
+	var leafIndex uint64
+	consumeUntil := func(end uint64) {
+		for leafIndex < end {
+			leafIndex += uint64(adjacentSubtreeSize(leafIndex, end))
+			size++
+		}
+	}
+	for _, r := range rangeSet {
+		consumeUntil(r.Left)
+		leafIndex = r.Right
+	}
+	consumeUntil(leavesCount)
+	return size
+}
+
+// Sha256MultiSegmentProof returns the proof of the segments at the ascending indices of the
+// tree with leavesCount leaves, which shares the subtree roots among the segments. The subtree
+// roots between the segments are retrieved from sr.
+func Sha256MultiSegmentProof(indices []uint64, sr SubtreeRoot, leavesCount uint64) (hashProofSet []common.Hash, err error) {
+	rangeSet, err := SegmentLimits(indices)
+	if err != nil {
+		return nil, err
+	}
+	if len(rangeSet) == 0 || rangeSet[len(rangeSet)-1].Right > leavesCount {
+		return nil, errors.New("segment indices out of range")
+	}
+	proofSet, err := GetDiffStorageProof(rangeSet, sr, leavesCount)
+	if err != nil {
+		return nil, err
+	}
+	for _, proof := range proofSet {
+		hashProofSet = append(hashProofSet, common.BytesToHash(proof))
+	}
+	return hashProofSet, nil
+}
+
+// Sha256VerifyMultiSegmentProof verifies the segments at the ascending indices of the tree with
+// leavesCount leaves against the merkle root with the proof from Sha256MultiSegmentProof
+func Sha256VerifyMultiSegmentProof(segments [][]byte, indices []uint64, leavesCount uint64, hashProofSet []common.Hash, merkleRoot common.Hash) error {
+	if len(segments) != len(indices) {
+		return errors.New("number of segments does not match the indices")
+	}
+	rangeSet, err := SegmentLimits(indices)
+	if err != nil {
+		return err
+	}
+	if len(rangeSet) == 0 || rangeSet[len(rangeSet)-1].Right > leavesCount {
+		return errors.New("segment indices out of range")
+	}
+	if len(hashProofSet) != DiffProofSize(rangeSet, leavesCount) {
+		return errors.New("invalid size of the multi segment proof")
+	}
+
+	h := sha256.New()
+	leafRoots := make([][]byte, 0, len(segments))
+	for _, segment := range segments {
+		leafRoots = append(leafRoots, leafTotal(h, segment))
+	}
+	return CheckDiffStorageProof(NewLeafRootCached(leafRoots), leavesCount, h, rangeSet, hashSliceToByteSlices(hashProofSet), merkleRoot[:])
+}
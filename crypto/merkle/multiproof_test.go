@@ -0,0 +1,88 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package merkle
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"reflect"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+)
+
+func TestSegmentLimits(t *testing.T) {
+	tables := []struct {
+		indices []uint64
+		limits  []SubTreeLimit
+		valid   bool
+	}{
+		{[]uint64{3}, []SubTreeLimit{{3, 4}}, true},
+		{[]uint64{0, 1, 2, 7, 9, 10}, []SubTreeLimit{{0, 3}, {7, 8}, {9, 11}}, true},
+		{[]uint64{2, 1}, nil, false},
+		{[]uint64{2, 2}, nil, false},
+	}
+	for i, table := range tables {
+		limits, err := SegmentLimits(table.indices)
+		if table.valid != (err == nil) {
+			t.Fatalf("test %d: expect valid %v, got error %v", i, table.valid, err)
+		}
+		if table.valid && !reflect.DeepEqual(limits, table.limits) {
+			t.Errorf("test %d: expect limits %v, got %v", i, table.limits, limits)
+		}
+	}
+}
+
+// Tests that the segments proved by the multi segment proof are verified against the merkle root
+// of the data, including the partial final segment.
+func TestMultiSegmentProof(t *testing.T) {
+	data := make([]byte, 100*LeafSize-10)
+	rand.Read(data)
+	leavesCount := LeavesCount(uint64(len(data)))
+	root := Sha256MerkleTreeRoot(data)
+
+	segment := func(index uint64) []byte {
+		end := (index + 1) * LeafSize
+		if end > uint64(len(data)) {
+			end = uint64(len(data))
+		}
+		return data[index*LeafSize : end]
+	}
+
+	for i, indices := range [][]uint64{
+		{0},
+		{99},
+		{0, 1, 2, 3},
+		{5, 17, 18, 63, 64, 98, 99},
+	} {
+		leafRoots := make([][]byte, 0, leavesCount)
+		for index := uint64(0); index < leavesCount; index++ {
+			leafRoots = append(leafRoots, leafTotal(sha256.New(), segment(index)))
+		}
+		hashProofSet, err := Sha256MultiSegmentProof(indices, NewCachedSubtreeRoot(leafRoots, sha256.New()), leavesCount)
+		if err != nil {
+			t.Fatalf("test %d: failed to create the multi segment proof: %v", i, err)
+		}
+		segments := make([][]byte, 0, len(indices))
+		for _, index := range indices {
+			segments = append(segments, segment(index))
+		}
+		if err := Sha256VerifyMultiSegmentProof(segments, indices, leavesCount, hashProofSet, root); err != nil {
+			t.Errorf("test %d: failed to verify the multi segment proof: %v", i, err)
+		}
+
+		// the tampered segment fails the verification
+		tampered := append([]byte{}, segments[0]...)
+		tampered[0] ^= 0xff
+		if err := Sha256VerifyMultiSegmentProof(append([][]byte{tampered}, segments[1:]...), indices, leavesCount, hashProofSet, root); err == nil {
+			t.Errorf("test %d: tampered segment verified", i)
+		}
+
+		// the proof with the extra hash fails the verification
+		if err := Sha256VerifyMultiSegmentProof(segments, indices, leavesCount, append(hashProofSet, common.Hash{}), root); err == nil {
+			t.Errorf("test %d: proof with extra hash verified", i)
+		}
+	}
+}
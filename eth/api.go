@@ -81,6 +81,80 @@ func (api *PublicEthereumAPI) Ethereum() *Ethereum {
 	return api.e
 }
 
+// HostReputationResult is the track record of the storage host derived from the chain
+type HostReputationResult struct {
+	Address       common.Address       `json:"address"`
+	IndexedBlocks uint64               `json:"indexedBlocks"`
+	Reputation    rawdb.HostReputation `json:"reputation"`
+}
+
+// HostReputation returns the contracts formed, the storage proofs submitted and the proofs
+// missed by the storage host with the payment address, over all the blocks indexed
+func (api *PublicEthereumAPI) HostReputation(address common.Address) HostReputationResult {
+	reputation, exist, indexed := api.e.hostReputations.get(address)
+	if !exist {
+		reputation = *newHostReputation()
+	}
+	return HostReputationResult{
+		Address:       address,
+		IndexedBlocks: indexed,
+		Reputation:    reputation,
+	}
+}
+
+// ExpiredStorageContract returns the storage contract archived in the storage contract freezer
+// once settled in the canonical chain, after which the contract state is cleared from the state.
+// The storage contracts settled by the blocks imported by fast sync are not archived
+func (api *PublicEthereumAPI) ExpiredStorageContract(id common.Hash) (*rawdb.FrozenStorageContract, error) {
+	frozen := rawdb.ReadFrozenStorageContract(api.e.chainDb, id)
+	if frozen == nil {
+		if tail := rawdb.ReadContractFreezerTail(api.e.chainDb); tail > 0 {
+			return nil, fmt.Errorf("expired storage contract %x not found, the storage contracts settled before block %d are not archived by fast sync", id, tail)
+		}
+		return nil, fmt.Errorf("expired storage contract %x not found", id)
+	}
+	return frozen, nil
+}
+
+// HostReputationHistory returns the track records of the storage host with the payment
+// address in each section of blocks indexed
+func (api *PublicEthereumAPI) HostReputationHistory(address common.Address) []HostReputationSection {
+	return api.e.hostReputations.history(address)
+}
+
+// StorageContractCreation returns the position of the transaction creating the storage
+// contract in the canonical chain
+func (api *PublicEthereumAPI) StorageContractCreation(id common.Hash) (*rawdb.StorageTxLookupEntry, error) {
+	entry := rawdb.ReadContractCreationLookup(api.e.chainDb, id)
+	if entry == nil {
+		return nil, fmt.Errorf("creation of storage contract %x not found", id)
+	}
+	return entry, nil
+}
+
+// StorageContractRevisions returns the positions of the transactions committing the revisions
+// of the storage contract in the canonical chain, in the order of the revision number
+func (api *PublicEthereumAPI) StorageContractRevisions(id common.Hash) []rawdb.ContractRevisionLookupEntry {
+	return rawdb.ReadContractRevisionLookups(api.e.chainDb, id)
+}
+
+// StorageContractProof returns the settlement of the storage contract in the canonical chain,
+// which is the position of the storage proof transaction, or the block at the window end of
+// the contract with an empty transaction hash if the storage proof is missed
+func (api *PublicEthereumAPI) StorageContractProof(id common.Hash) (*rawdb.StorageTxLookupEntry, error) {
+	entry := rawdb.ReadStorageProofLookup(api.e.chainDb, id)
+	if entry == nil {
+		return nil, fmt.Errorf("storage proof of storage contract %x not found", id)
+	}
+	return entry, nil
+}
+
+// HostAnnouncements returns the positions of the host announcement transactions sent by the
+// storage host in the canonical chain, in the order of the block number
+func (api *PublicEthereumAPI) HostAnnouncements(address common.Address) []rawdb.StorageTxLookupEntry {
+	return rawdb.ReadHostAnnouncementLookups(api.e.chainDb, address)
+}
+
 // PublicMinerAPI provides an API to control the miner.
 // It offers only methods that operate on data that pose no security risk when it is publicly accessible.
 type PublicMinerAPI struct {
@@ -32,6 +32,7 @@ import (
 	"github.com/DxChainNetwork/godx/eth/gasprice"
 	"github.com/DxChainNetwork/godx/ethdb"
 	"github.com/DxChainNetwork/godx/event"
+	"github.com/DxChainNetwork/godx/internal/ethapi"
 	"github.com/DxChainNetwork/godx/params"
 	"github.com/DxChainNetwork/godx/rpc"
 )
@@ -235,3 +236,7 @@ func (b *EthAPIBackend) SignByNode(hash []byte) ([]byte, error) {
 func (b *EthAPIBackend) GetHostEnodeURL() string {
 	return b.eth.GetHostEnodeURL()
 }
+
+func (b *EthAPIBackend) OfflineTxs() *ethapi.OfflineTxQueue {
+	return b.eth.offlineTxs
+}
@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"net"
 	"runtime"
 	"sync"
 	"sync/atomic"
@@ -92,6 +93,9 @@ type Ethereum struct {
 	bloomRequests chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
 	bloomIndexer  *core.ChainIndexer             // Bloom indexer operating during block imports
 
+	hostReputationIndexer *core.ChainIndexer // Host reputation indexer operating during block imports
+	hostReputations       *hostReputations   // Track records of the storage hosts aggregated from the indexer
+
 	APIBackend *EthAPIBackend
 
 	miner     *miner.Miner
@@ -101,6 +105,7 @@ type Ethereum struct {
 	apisOnce       sync.Once
 	registeredAPIs []rpc.API
 	storageClient  *storageclient.StorageClient
+	offlineTxs     *ethapi.OfflineTxQueue // Storage contract txs to be signed offline
 
 	networkID     uint64
 	netRPCService *ethapi.PublicNetAPI
@@ -154,7 +159,10 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		etherbase:      config.Etherbase,
 		bloomRequests:  make(chan chan *bloombits.Retrieval),
 		bloomIndexer:   NewBloomIndexer(chainDb, params.BloomBitsBlocks, params.BloomConfirms),
+
+		hostReputationIndexer: NewHostReputationIndexer(chainDb, params.HostReputationBlocks, params.HostReputationConfirms),
 	}
+	eth.hostReputations = newHostReputations(chainDb, eth.hostReputationIndexer, params.HostReputationBlocks)
 
 	log.Info("Initialising Ethereum protocol", "versions", ProtocolVersions, "network", config.NetworkId)
 
@@ -186,6 +194,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		rawdb.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
 	eth.bloomIndexer.Start(eth.blockchain)
+	eth.hostReputationIndexer.Start(eth.blockchain)
 
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
@@ -206,6 +215,10 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	}
 	eth.APIBackend.gpo = gasprice.NewOracle(eth.APIBackend, gpoParams)
 
+	if config.StorageOfflineSigning {
+		eth.offlineTxs = ethapi.NewOfflineTxQueue()
+	}
+
 	// Initialize StorageClient based on the configuration
 	if config.StorageClient {
 		clientPath := ctx.ResolvePath(config.StorageClientDir)
@@ -554,6 +567,11 @@ func (s *Ethereum) Downloader() *downloader.Downloader { return s.protocolManage
 func (s *Ethereum) GetCurrentBlockHeight() uint64      { return s.blockchain.CurrentHeader().Number.Uint64() }
 func (s *Ethereum) GetBlockChain() *core.BlockChain    { return s.blockchain }
 
+// StorageHost returns the storage host, nil if the node does not run the storage host
+func (s *Ethereum) StorageHost() *storagehost.StorageHost {
+	return s.storageHost
+}
+
 // Sign data with node private key. Now it is used to imply host identity
 func (s *Ethereum) SignWithNodeSk(hash []byte) ([]byte, error) {
 	return crypto.Sign(hash, s.server.Config.PrivateKey)
@@ -624,6 +642,9 @@ func (s *Ethereum) Stop() error {
 	err := s.bloomIndexer.Close()
 	fullErr = common.ErrCompose(fullErr, err)
 
+	err = s.hostReputationIndexer.Close()
+	fullErr = common.ErrCompose(fullErr, err)
+
 	s.blockchain.Stop()
 
 	err = s.engine.Close()
@@ -775,6 +796,49 @@ func (s *Ethereum) GetStorageHostSetting(enodeID enode.ID, enodeURL string, conf
 	return nil
 }
 
+// ProbeStorageHost will send the probe request to the storage host, and return the time
+// elapsed until the probe response is received
+func (s *Ethereum) ProbeStorageHost(enodeURL string, req storage.HostProbeRequest) (time.Duration, error) {
+	// set up the connection to the storage host node
+	sp, err := s.SetupConnection(enodeURL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to probe the storage host: %s", err.Error())
+	}
+
+	// only one probe is allowed at a time to the storage host, otherwise
+	// the measurement is not accurate
+	if err := sp.TryRequestHostProbe(); err != nil {
+		return 0, err
+	}
+	defer sp.RequestHostProbeDone()
+
+	start := time.Now()
+	if err := sp.RequestHostProbe(req); err != nil {
+		return 0, fmt.Errorf("failed to send the probe request: %s", err)
+	}
+
+	// wait until the response is given back
+	msg, err := sp.WaitProbeResp()
+	if err != nil {
+		return 0, fmt.Errorf("received error while waiting for the probe response: %s", err.Error())
+	}
+	elapsed := time.Since(start)
+
+	var resp storage.HostProbeResponse
+	if err := msg.Decode(&resp); err != nil {
+		return 0, fmt.Errorf("error decoding the probe response: %s", err.Error())
+	}
+	if err := resp.Validate(req); err != nil {
+		return 0, err
+	}
+
+	// check the connection and update the connection
+	// type if necessary
+	s.CheckAndUpdateConnection(sp.PeerNode())
+
+	return elapsed, nil
+}
+
 // CheckAndUpdateConnection will regularly check the connection between two nodes
 // local and remote. If there are no contracts between two nodes and the node
 // is not added by the user, then the connection will be deleted from the static
@@ -851,3 +915,12 @@ func (s *Ethereum) GetBlockByNumber(number uint64) (*types.Block, error) {
 func (s *Ethereum) SelfEnodeURL() string {
 	return s.server.NodeInfo().Enode
 }
+
+// ExternalIP returns the external IP address of the local node discovered by the NAT port
+// mapper. If no NAT port mapper is configured, the IP address of the local node is returned
+func (s *Ethereum) ExternalIP() (net.IP, error) {
+	if s.server.NAT != nil {
+		return s.server.NAT.ExternalIP()
+	}
+	return s.server.Self().IP(), nil
+}
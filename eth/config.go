@@ -148,6 +148,10 @@ type Config struct {
 	// Role, can only be one of the two roles
 	StorageClient bool
 	StorageHost   bool
+
+	// StorageOfflineSigning queues the storage contract txs unsigned to be signed offline,
+	// instead of signing them with the unlocked account
+	StorageOfflineSigning bool
 }
 
 type configMarshaling struct {
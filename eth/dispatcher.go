@@ -67,6 +67,16 @@ func (pm *ProtocolManager) clientMsgSchedule(msg p2p.Msg, p *peer) error {
 		}
 	}
 
+	// similarly, the probe response which is not waited for is discarded
+	if msg.Code == storage.HostProbeRespMsg {
+		select {
+		case p.clientProbeMsg <- msg:
+			return nil
+		default:
+			return msg.Discard()
+		}
+	}
+
 	// otherwise, push the message into clientContractMsg channel
 	// similarly, if the channel is full, meaning the previous message
 	// handling was not complete, trigger the error directly because the
@@ -88,6 +98,11 @@ func (pm *ProtocolManager) hostMsgSchedule(msg p2p.Msg, p *peer) error {
 		return pm.hostConfigMsgHandler(p, msg)
 	}
 
+	// the probe request is handled explicitly as well
+	if msg.Code == storage.HostProbeReqMsg {
+		return pm.hostProbeMsgHandler(p, msg)
+	}
+
 	// gets the handler based on the message code,
 	// if the handler does not exists, meaning it is not request message
 	// handle it as a dialogue message
@@ -0,0 +1,313 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package eth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core"
+	"github.com/DxChainNetwork/godx/core/rawdb"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+const (
+	// hostReputationThrottling is the time to wait between processing two consecutive
+	// host reputation sections.
+	hostReputationThrottling = 100 * time.Millisecond
+)
+
+// HostReputationIndexer implements a core.ChainIndexer, deriving the track records of the
+// storage hosts from the storage contracts formed, the storage proofs submitted and the
+// proofs missed when the proof window of the contracts ends. The storage contracts settled
+// are located by the storage contract transaction lookups of the canonical chain, so that
+// no state other than the track records of each section is kept by the indexer. The storage
+// contract transactions called from the contracts are applied like the ones sent directly.
+type HostReputationIndexer struct {
+	db      ethdb.Database                           // database instance to write index data into
+	section uint64                                   // section number being processed currently
+	head    common.Hash                              // hash of the last header processed
+	hosts   map[common.Address]*rawdb.HostReputation // track records of the section being processed
+}
+
+// contractPayouts is the host and the host outputs of a storage contract
+type contractPayouts struct {
+	host         common.Address
+	validPayout  *big.Int // host output if the storage proof is submitted
+	missedPayout *big.Int // host output if the storage proof is missed
+}
+
+// NewHostReputationIndexer returns a chain indexer that generates the track records of the
+// storage hosts for the canonical chain.
+func NewHostReputationIndexer(db ethdb.Database, size, confirms uint64) *core.ChainIndexer {
+	backend := &HostReputationIndexer{
+		db: db,
+	}
+	table := ethdb.NewTable(db, string(rawdb.HostReputationIndexPrefix))
+
+	return core.NewChainIndexer(db, table, backend, size, confirms, hostReputationThrottling, "reputation")
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new host reputation section.
+func (h *HostReputationIndexer) Reset(ctx context.Context, section uint64, lastSectionHead common.Hash) error {
+	h.section, h.head = section, common.Hash{}
+	h.hosts = make(map[common.Address]*rawdb.HostReputation)
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, applying the storage contract transactions
+// of the block and settling the contracts whose proof window ends at the block, in the same
+// order as the state processor does.
+func (h *HostReputationIndexer) Process(ctx context.Context, header *types.Header) error {
+	number, hash := header.Number.Uint64(), header.Hash()
+	body := rawdb.ReadBody(h.db, hash, number)
+	if body == nil {
+		return fmt.Errorf("block body #%d [%x] missing", number, hash[:4])
+	}
+	receipts := rawdb.ReadReceipts(h.db, hash, number)
+	if len(receipts) != len(body.Transactions) {
+		return fmt.Errorf("block receipts #%d [%x] missing", number, hash[:4])
+	}
+
+	for i, tx := range body.Transactions {
+		for _, call := range core.StorageCalls(tx, receipts[i]) {
+			h.processStorageTx(call.TxType, call.Data, number)
+		}
+	}
+	h.settleMissedProofs(number, hash)
+	h.head = hash
+	return nil
+}
+
+// Commit implements core.ChainIndexerBackend, writing the track records of the storage
+// hosts within the section into the database.
+func (h *HostReputationIndexer) Commit() error {
+	entries := make([]rawdb.HostReputationEntry, 0, len(h.hosts))
+	for host, reputation := range h.hosts {
+		entries = append(entries, rawdb.HostReputationEntry{Host: host, Reputation: *reputation})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].Host[:], entries[j].Host[:]) < 0
+	})
+	rawdb.WriteHostReputations(h.db, h.section, h.head, entries)
+	return nil
+}
+
+// processStorageTx applies the successful storage contract transaction of the block
+func (h *HostReputationIndexer) processStorageTx(txType string, data []byte, number uint64) {
+	switch txType {
+	case vm.ContractCreateTransaction:
+		var sc types.StorageContract
+		if err := rlp.DecodeBytes(data, &sc); err != nil {
+			return
+		}
+		h.reputation(sc.HostCollateral.Address).ContractsFormed++
+
+	case vm.StorageProofTransaction:
+		var sp types.StorageProof
+		if err := rlp.DecodeBytes(data, &sp); err != nil {
+			return
+		}
+		payouts := h.contractPayouts(sp.ParentID, number)
+		if payouts == nil {
+			return
+		}
+		reputation := h.reputation(payouts.host)
+		reputation.ProofsSubmitted++
+		reputation.ProofPayout.Add(reputation.ProofPayout, payouts.validPayout)
+	}
+}
+
+// settleMissedProofs counts the contracts whose proof window ends at the block without the
+// storage proof submitted, which are settled by MaintenanceMissedProof
+func (h *HostReputationIndexer) settleMissedProofs(number uint64, hash common.Hash) {
+	for _, id := range rawdb.ReadContractExpiry(h.db, number) {
+		entry := rawdb.ReadStorageProofLookup(h.db, id)
+		if entry == nil || entry.TxHash != (common.Hash{}) || entry.BlockHash != hash {
+			continue
+		}
+		payouts := h.contractPayouts(id, number)
+		if payouts == nil {
+			continue
+		}
+		reputation := h.reputation(payouts.host)
+		reputation.ProofsMissed++
+		reputation.MissedPayout.Add(reputation.MissedPayout, payouts.missedPayout)
+	}
+}
+
+// contractPayouts returns the host outputs of the storage contract in effect at the block,
+// which are the ones of the latest revision committed before the block, or the ones of the
+// contract formed if the contract has never been revised
+func (h *HostReputationIndexer) contractPayouts(id common.Hash, number uint64) *contractPayouts {
+	var sc types.StorageContract
+	created := h.findStorageCall(rawdb.ReadContractCreationLookup(h.db, id), vm.ContractCreateTransaction, func(data []byte) bool {
+		return rlp.DecodeBytes(data, &sc) == nil && sc.ID() == id
+	})
+	if !created || len(sc.ValidProofOutputs) < 2 || len(sc.MissedProofOutputs) < 2 {
+		return nil
+	}
+	payouts := &contractPayouts{
+		host:         sc.HostCollateral.Address,
+		validPayout:  sc.ValidProofOutputs[1].Value,
+		missedPayout: sc.MissedProofOutputs[1].Value,
+	}
+	revisions := rawdb.ReadContractRevisionLookups(h.db, id)
+	for i := len(revisions) - 1; i >= 0; i-- {
+		if revisions[i].BlockNumber > number {
+			continue
+		}
+		var scr types.StorageContractRevision
+		revised := h.findStorageCall(&revisions[i].StorageTxLookupEntry, vm.CommitRevisionTransaction, func(data []byte) bool {
+			return rlp.DecodeBytes(data, &scr) == nil && scr.ParentID == id && scr.NewRevisionNumber == revisions[i].RevisionNumber
+		})
+		if revised && len(scr.NewValidProofOutputs) >= 2 && len(scr.NewMissedProofOutputs) >= 2 {
+			payouts.validPayout = scr.NewValidProofOutputs[1].Value
+			payouts.missedPayout = scr.NewMissedProofOutputs[1].Value
+		}
+		break
+	}
+	return payouts
+}
+
+// findStorageCall finds the storage contract transaction of the type accepted by the match,
+// which is executed by the transaction the lookup entry points to. The transaction may execute
+// several storage contract transactions by the calls from the contracts
+func (h *HostReputationIndexer) findStorageCall(entry *rawdb.StorageTxLookupEntry, txType string, match func(data []byte) bool) bool {
+	if entry == nil {
+		return false
+	}
+	body := rawdb.ReadBody(h.db, entry.BlockHash, entry.BlockNumber)
+	receipts := rawdb.ReadReceipts(h.db, entry.BlockHash, entry.BlockNumber)
+	if body == nil || entry.Index >= uint64(len(body.Transactions)) || entry.Index >= uint64(len(receipts)) {
+		return false
+	}
+	for _, call := range core.StorageCalls(body.Transactions[entry.Index], receipts[entry.Index]) {
+		if call.TxType == txType && match(call.Data) {
+			return true
+		}
+	}
+	return false
+}
+
+// reputation returns the track record of the storage host within the section being processed
+func (h *HostReputationIndexer) reputation(host common.Address) *rawdb.HostReputation {
+	reputation, exist := h.hosts[host]
+	if !exist {
+		reputation = newHostReputation()
+		h.hosts[host] = reputation
+	}
+	return reputation
+}
+
+// HostReputationSection is the track record of a storage host within a section of blocks
+type HostReputationSection struct {
+	FromBlock  uint64               `json:"fromBlock"`
+	ToBlock    uint64               `json:"toBlock"`
+	Reputation rawdb.HostReputation `json:"reputation"`
+}
+
+// hostReputations caches the track records of the storage hosts aggregated over all the
+// sections indexed, which is refreshed once a new section is indexed
+type hostReputations struct {
+	db      ethdb.Database
+	indexer *core.ChainIndexer
+	size    uint64
+
+	sections uint64
+	head     common.Hash
+	hosts    map[common.Address]rawdb.HostReputation
+	lock     sync.Mutex
+}
+
+// newHostReputations returns the cache of the track records indexed by the indexer
+func newHostReputations(db ethdb.Database, indexer *core.ChainIndexer, size uint64) *hostReputations {
+	return &hostReputations{
+		db:      db,
+		indexer: indexer,
+		size:    size,
+		hosts:   make(map[common.Address]rawdb.HostReputation),
+	}
+}
+
+// get returns the track record of the storage host aggregated over all the sections indexed,
+// along with the number of blocks indexed
+func (r *hostReputations) get(host common.Address) (rawdb.HostReputation, bool, uint64) {
+	sections, _, head := r.indexer.Sections()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if sections != r.sections || head != r.head {
+		hosts := make(map[common.Address]rawdb.HostReputation)
+		for section := uint64(0); section < sections; section++ {
+			for _, entry := range rawdb.ReadHostReputations(r.db, section, r.indexer.SectionHead(section)) {
+				aggregate, exist := hosts[entry.Host]
+				if !exist {
+					aggregate = *newHostReputation()
+				}
+				addHostReputation(&aggregate, entry.Reputation)
+				hosts[entry.Host] = aggregate
+			}
+		}
+		r.sections, r.head, r.hosts = sections, head, hosts
+	}
+	reputation, exist := r.hosts[host]
+	return reputation, exist, r.sections * r.size
+}
+
+// history returns the track records of the storage host in each section indexed in which the
+// storage host has any contract formed or settled
+func (r *hostReputations) history(host common.Address) []HostReputationSection {
+	sections, _, _ := r.indexer.Sections()
+
+	var history []HostReputationSection
+	for section := uint64(0); section < sections; section++ {
+		entries := rawdb.ReadHostReputations(r.db, section, r.indexer.SectionHead(section))
+		i := sort.Search(len(entries), func(i int) bool {
+			return bytes.Compare(entries[i].Host[:], host[:]) >= 0
+		})
+		if i < len(entries) && entries[i].Host == host {
+			history = append(history, HostReputationSection{
+				FromBlock:  section * r.size,
+				ToBlock:    (section+1)*r.size - 1,
+				Reputation: entries[i].Reputation,
+			})
+		}
+	}
+	return history
+}
+
+// newHostReputation returns an empty track record
+func newHostReputation() *rawdb.HostReputation {
+	return &rawdb.HostReputation{
+		ProofPayout:  new(big.Int),
+		MissedPayout: new(big.Int),
+	}
+}
+
+// addHostReputation adds the track record to the aggregated track record
+func addHostReputation(aggregate *rawdb.HostReputation, reputation rawdb.HostReputation) {
+	aggregate.ContractsFormed += reputation.ContractsFormed
+	aggregate.ProofsSubmitted += reputation.ProofsSubmitted
+	aggregate.ProofsMissed += reputation.ProofsMissed
+	aggregate.ProofPayout = new(big.Int).Add(aggregate.ProofPayout, reputation.ProofPayout)
+	aggregate.MissedPayout = new(big.Int).Add(aggregate.MissedPayout, reputation.MissedPayout)
+}
+
+// HostReputation returns the track record of the storage host with the payment address,
+// derived from the chain. False is returned if the storage host has no contract indexed
+func (s *Ethereum) HostReputation(address common.Address) (rawdb.HostReputation, bool) {
+	reputation, exist, _ := s.hostReputations.get(address)
+	return reputation, exist
+}
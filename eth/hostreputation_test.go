@@ -0,0 +1,209 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/rawdb"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+// storageTx is a storage contract transaction to be included in the test blocks
+type storageTx struct {
+	txType string
+	data   interface{}
+	failed bool
+}
+
+// writeStorageBlock writes the block with the storage contract transactions and their
+// receipts into the database, and indexes the successful transactions and the proofs missed
+// at the block as the canonical chain does. The fork distinguishes the blocks of the same
+// number in different chains
+func writeStorageBlock(t *testing.T, db ethdb.Database, number uint64, fork byte, txs []storageTx) *types.Header {
+	header := &types.Header{Number: new(big.Int).SetUint64(number), Extra: []byte{fork}}
+	body := &types.Body{}
+	var receipts types.Receipts
+	for i, stx := range txs {
+		var to common.Address
+		for addr, txType := range vm.PrecompiledEVMFileContracts {
+			if txType == stx.txType {
+				to = addr
+			}
+		}
+		data, err := rlp.EncodeToBytes(stx.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx := types.NewTransaction(uint64(i), to, new(big.Int), 0, new(big.Int), data)
+		body.Transactions = append(body.Transactions, tx)
+		receipts = append(receipts, types.NewReceipt(nil, stx.failed, 0))
+		if stx.failed {
+			continue
+		}
+
+		entry := rawdb.StorageTxLookupEntry{TxHash: tx.Hash(), BlockHash: header.Hash(), BlockNumber: number, Index: uint64(i)}
+		switch data := stx.data.(type) {
+		case types.StorageContract:
+			rawdb.WriteContractCreationLookup(db, data.ID(), entry)
+			rawdb.WriteContractExpiry(db, data.WindowEnd, append(rawdb.ReadContractExpiry(db, data.WindowEnd), data.ID()))
+		case types.StorageContractRevision:
+			revisions := rawdb.ReadContractRevisionLookups(db, data.ParentID)
+			rawdb.WriteContractRevisionLookups(db, data.ParentID, append(revisions, rawdb.ContractRevisionLookupEntry{RevisionNumber: data.NewRevisionNumber, StorageTxLookupEntry: entry}))
+		case types.StorageProof:
+			rawdb.WriteStorageProofLookup(db, data.ParentID, entry)
+		}
+	}
+	for _, id := range rawdb.ReadContractExpiry(db, number) {
+		if rawdb.ReadStorageProofLookup(db, id) == nil {
+			rawdb.WriteStorageProofLookup(db, id, rawdb.StorageTxLookupEntry{BlockHash: header.Hash(), BlockNumber: number})
+		}
+	}
+	rawdb.WriteBody(db, header.Hash(), number, body)
+	rawdb.WriteReceipts(db, header.Hash(), number, receipts)
+	return header
+}
+
+// processSection indexes the host reputation section of the headers, and returns the track
+// records of the storage hosts within the section
+func processSection(t *testing.T, db ethdb.Database, headers []*types.Header) []rawdb.HostReputationEntry {
+	indexer := &HostReputationIndexer{db: db}
+	if err := indexer.Reset(context.Background(), 0, common.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, header := range headers {
+		if err := indexer.Process(context.Background(), header); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := indexer.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	return rawdb.ReadHostReputations(db, 0, headers[len(headers)-1].Hash())
+}
+
+// newTestStorageContract returns the storage contract with the host and window end
+func newTestStorageContract(host common.Address, windowEnd uint64, validPayout, missedPayout int64) types.StorageContract {
+	return types.StorageContract{
+		FileSize:       windowEnd,
+		WindowStart:    1,
+		WindowEnd:      windowEnd,
+		HostCollateral: types.DxcoinCollateral{DxcoinCharge: types.DxcoinCharge{Address: host, Value: big.NewInt(1)}},
+		ValidProofOutputs: []types.DxcoinCharge{
+			{Value: big.NewInt(0)}, {Address: host, Value: big.NewInt(validPayout)},
+		},
+		MissedProofOutputs: []types.DxcoinCharge{
+			{Value: big.NewInt(0)}, {Address: host, Value: big.NewInt(missedPayout)},
+		},
+	}
+}
+
+func TestHostReputationIndexer(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	hostA, hostB := common.Address{0x0a}, common.Address{0x0b}
+
+	// the contract of host A is proved, while the contract of host B is missed. The contract
+	// in the failed transaction is not counted
+	scA := newTestStorageContract(hostA, 3, 10, 5)
+	scB := newTestStorageContract(hostB, 3, 20, 7)
+	scFailed := newTestStorageContract(hostA, 2, 30, 9)
+	revision := types.StorageContractRevision{
+		ParentID:              scA.ID(),
+		NewValidProofOutputs:  []types.DxcoinCharge{{Value: big.NewInt(0)}, {Address: hostA, Value: big.NewInt(15)}},
+		NewMissedProofOutputs: []types.DxcoinCharge{{Value: big.NewInt(0)}, {Address: hostA, Value: big.NewInt(5)}},
+	}
+	headers := []*types.Header{
+		writeStorageBlock(t, db, 0, 0, nil),
+		writeStorageBlock(t, db, 1, 0, []storageTx{
+			{txType: vm.ContractCreateTransaction, data: scA},
+			{txType: vm.ContractCreateTransaction, data: scB},
+			{txType: vm.ContractCreateTransaction, data: scFailed, failed: true},
+		}),
+		writeStorageBlock(t, db, 2, 0, []storageTx{
+			{txType: vm.CommitRevisionTransaction, data: revision},
+			{txType: vm.StorageProofTransaction, data: types.StorageProof{ParentID: scA.ID()}},
+		}),
+		writeStorageBlock(t, db, 3, 0, nil),
+	}
+
+	entries := processSection(t, db, headers)
+	if len(entries) != 2 || entries[0].Host != hostA || entries[1].Host != hostB {
+		t.Fatalf("expect the track records of host A and B, got %+v", entries)
+	}
+	a, b := entries[0].Reputation, entries[1].Reputation
+	if a.ContractsFormed != 1 || a.ProofsSubmitted != 1 || a.ProofsMissed != 0 || a.ProofPayout.Int64() != 15 || a.MissedPayout.Sign() != 0 {
+		t.Errorf("unexpected track record of host A: %+v", a)
+	}
+	if b.ContractsFormed != 1 || b.ProofsSubmitted != 0 || b.ProofsMissed != 1 || b.ProofPayout.Sign() != 0 || b.MissedPayout.Int64() != 7 {
+		t.Errorf("unexpected track record of host B: %+v", b)
+	}
+
+	// aggregate the track records of two sections
+	aggregate := *newHostReputation()
+	addHostReputation(&aggregate, a)
+	addHostReputation(&aggregate, b)
+	if aggregate.ContractsFormed != 2 || aggregate.ProofsSubmitted != 1 || aggregate.ProofsMissed != 1 || aggregate.ProofPayout.Int64() != 15 || aggregate.MissedPayout.Int64() != 7 {
+		t.Errorf("unexpected aggregated track record: %+v", aggregate)
+	}
+}
+
+// Tests that the track records of a section re-indexed after a chain reorganisation only
+// reflect the storage contracts settled in the new chain.
+func TestHostReputationIndexerReorg(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	hostA, hostB := common.Address{0x0a}, common.Address{0x0b}
+	scA := newTestStorageContract(hostA, 3, 10, 5)
+	scB := newTestStorageContract(hostB, 3, 20, 7)
+
+	// the contract of host A is proved in the old chain, and the contract of host B is missed
+	ancestors := []*types.Header{
+		writeStorageBlock(t, db, 0, 0, nil),
+		writeStorageBlock(t, db, 1, 0, []storageTx{
+			{txType: vm.ContractCreateTransaction, data: scA},
+			{txType: vm.ContractCreateTransaction, data: scB},
+		}),
+	}
+	oldChain := []*types.Header{
+		ancestors[0], ancestors[1],
+		writeStorageBlock(t, db, 2, 0xa, []storageTx{{txType: vm.StorageProofTransaction, data: types.StorageProof{ParentID: scA.ID()}}}),
+		writeStorageBlock(t, db, 3, 0xa, nil),
+	}
+	old := processSection(t, db, oldChain)
+
+	// revert the settlements of the old chain, after which the contract of host B is proved
+	// in the new chain, and the contract of host A is missed
+	rawdb.DeleteStorageProofLookup(db, scA.ID())
+	rawdb.DeleteStorageProofLookup(db, scB.ID())
+	newChain := []*types.Header{
+		ancestors[0], ancestors[1],
+		writeStorageBlock(t, db, 2, 0xb, []storageTx{{txType: vm.StorageProofTransaction, data: types.StorageProof{ParentID: scB.ID()}}}),
+		writeStorageBlock(t, db, 3, 0xb, nil),
+	}
+	entries := processSection(t, db, newChain)
+	if len(entries) != 2 {
+		t.Fatalf("expect the track records of host A and B, got %+v", entries)
+	}
+	a, b := entries[0].Reputation, entries[1].Reputation
+	if a.ContractsFormed != 1 || a.ProofsSubmitted != 0 || a.ProofsMissed != 1 || a.ProofPayout.Sign() != 0 || a.MissedPayout.Int64() != 5 {
+		t.Errorf("unexpected track record of host A in the new chain: %+v", a)
+	}
+	if b.ContractsFormed != 1 || b.ProofsSubmitted != 1 || b.ProofsMissed != 0 || b.ProofPayout.Int64() != 20 || b.MissedPayout.Sign() != 0 {
+		t.Errorf("unexpected track record of host B in the new chain: %+v", b)
+	}
+
+	// the section of the old chain is kept under its own head
+	if len(old) != 2 || old[0].Reputation.ProofsSubmitted != 1 || old[1].Reputation.ProofsMissed != 1 {
+		t.Errorf("unexpected track records of the old chain: %+v", old)
+	}
+	if stored := rawdb.ReadHostReputations(db, 0, oldChain[3].Hash()); len(stored) != 2 || stored[0].Reputation.ProofsSubmitted != 1 || stored[1].Reputation.ProofsMissed != 1 {
+		t.Errorf("unexpected track records of the old chain: %+v", stored)
+	}
+}
@@ -95,6 +95,7 @@ type peer struct {
 
 	// eth and storage message channel
 	clientConfigMsg   chan p2p.Msg
+	clientProbeMsg    chan p2p.Msg
 	clientContractMsg chan p2p.Msg
 	hostContractMsg   chan p2p.Msg
 
@@ -103,10 +104,12 @@ type peer struct {
 	bufferLock        sync.RWMutex
 
 	hostConfigProcessing   chan struct{}
+	hostProbeProcessing    chan struct{}
 	hostContractProcessing chan struct{}
 
 	contractRevisingOrRenewing chan struct{}
 	hostConfigRequesting       chan struct{}
+	hostProbeRequesting        chan struct{}
 
 	// error channel
 	errMsg chan error
@@ -127,14 +130,17 @@ func newPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
 		queuedAnns:                 make(chan *types.Block, maxQueuedAnns),
 		term:                       make(chan struct{}),
 		clientConfigMsg:            make(chan p2p.Msg, 1),
+		clientProbeMsg:             make(chan p2p.Msg, 1),
 		clientContractMsg:          make(chan p2p.Msg, 1),
 		hostContractMsg:            make(chan p2p.Msg, 1),
 		ethStartIndicator:          make(chan struct{}, 1),
 		hostConfigProcessing:       make(chan struct{}, 1),
+		hostProbeProcessing:        make(chan struct{}, 1),
 		hostContractProcessing:     make(chan struct{}, 1),
 		errMsg:                     make(chan error, 1),
 		contractRevisingOrRenewing: make(chan struct{}, 1),
 		hostConfigRequesting:       make(chan struct{}, 1),
+		hostProbeRequesting:        make(chan struct{}, 1),
 		checkPeerStopHook:          checkPeerStop,
 	}
 }
@@ -30,6 +30,36 @@ func (pm *ProtocolManager) hostConfigMsgHandler(p *peer, configMsg p2p.Msg) erro
 	return nil
 }
 
+func (pm *ProtocolManager) hostProbeMsgHandler(p *peer, probeMsg p2p.Msg) error {
+	// similar to the host config request, only one probe request can be
+	// handled at a time
+	if err := p.HostProbeProcessing(); err != nil {
+		return err
+	}
+
+	var req storage.HostProbeRequest
+	if err := probeMsg.Decode(&req); err != nil {
+		p.HostProbeProcessingDone()
+		return err
+	}
+	resp, err := storage.NewHostProbeResponse(req)
+	if err != nil {
+		p.HostProbeProcessingDone()
+		return err
+	}
+
+	pm.wg.Add(1)
+	go func() {
+		defer pm.wg.Done()
+		defer p.HostProbeProcessingDone()
+		if err := p.SendHostProbeResponse(resp); err != nil {
+			p.TriggerError(err)
+		}
+	}()
+
+	return nil
+}
+
 func (pm *ProtocolManager) contractMsgHandler(p *peer, msg p2p.Msg) error {
 	// send the message to the hostContractMsg channel if the handler
 	// does not exist
@@ -0,0 +1,89 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package eth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/eth/downloader"
+	"github.com/DxChainNetwork/godx/p2p"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// TestHostProbeMsg tests the storage host answers the probe request with the requested
+// size, and the storage client receives the probe response through the message pipe
+func TestHostProbeMsg(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+	p, _ := newTestPeer("peer", eth63, pm, true)
+	defer p.close()
+
+	// the remote side probes the local storage host
+	req := storage.HostProbeRequest{Payload: make([]byte, 1024), RespSize: 2048}
+	if err := p2p.Send(p.app, storage.HostProbeReqMsg, req); err != nil {
+		t.Fatal(err)
+	}
+	if err := p2p.ExpectMsg(p.app, storage.HostProbeRespMsg, storage.HostProbeResponse{Payload: make([]byte, 2048)}); err != nil {
+		t.Fatalf("probe response: %v", err)
+	}
+
+	// the local storage client probes the remote side
+	if err := p.peer.TryRequestHostProbe(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.peer.TryRequestHostProbe(); err != storage.ErrRequestingHostProbe {
+		t.Errorf("expect error %v, got %v", storage.ErrRequestingHostProbe, err)
+	}
+	defer p.peer.RequestHostProbeDone()
+
+	req = storage.HostProbeRequest{RespSize: 16}
+	go p.peer.RequestHostProbe(req)
+	if err := p2p.ExpectMsg(p.app, storage.HostProbeReqMsg, req); err != nil {
+		t.Fatalf("probe request: %v", err)
+	}
+	go p2p.Send(p.app, storage.HostProbeRespMsg, storage.HostProbeResponse{Payload: make([]byte, 16)})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		msg, err := p.peer.WaitProbeResp()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		var resp storage.HostProbeResponse
+		if err = msg.Decode(&resp); err != nil {
+			t.Error(err)
+			return
+		}
+		if err = resp.Validate(req); err != nil {
+			t.Error(err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("probe response not received within 2 seconds")
+	}
+}
+
+// TestHostProbeMsgTooLarge tests the peer is disconnected with the probe exceeding the size limit
+func TestHostProbeMsgTooLarge(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+	p, errc := newTestPeer("peer", eth63, pm, true)
+	defer p.close()
+
+	go p2p.Send(p.app, storage.HostProbeReqMsg, storage.HostProbeRequest{RespSize: storage.MaxProbeSize + 1})
+	select {
+	case err := <-errc:
+		if err == nil {
+			t.Error("protocol returned nil error")
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("protocol did not shut down within 2 seconds")
+	}
+}
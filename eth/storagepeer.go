@@ -43,6 +43,26 @@ func (p *peer) RequestStorageHostConfig() error {
 	return err
 }
 
+// RequestHostProbe is used by the storage client to measure the round trip time and
+// throughput of the storage host. The HostProbeReqMsg will be sent to the storage host
+func (p *peer) RequestHostProbe(req storage.HostProbeRequest) error {
+	var err error
+	if err = p.checkPeerStopHook(p); err == nil {
+		return p2p.Send(p.rw, storage.HostProbeReqMsg, req)
+	}
+	return err
+}
+
+// SendHostProbeResponse will send the probe response back to the storage client
+// once the host got the probe request
+func (p *peer) SendHostProbeResponse(resp storage.HostProbeResponse) error {
+	var err error
+	if err = p.checkPeerStopHook(p); err == nil {
+		return p2p.Send(p.rw, storage.HostProbeRespMsg, resp)
+	}
+	return err
+}
+
 // RequestContractCreate will be used when the storage client is trying to create
 // the contract with desired storage host. ContractCreateReqMsg will be sent to the
 // storage host
@@ -220,6 +240,15 @@ func (p *peer) SendHostNegotiateErrorMsg() error {
 	return err
 }
 
+// SendHostSectorNotFoundMsg will send host sector not found msg
+func (p *peer) SendHostSectorNotFoundMsg() error {
+	var err error
+	if err = p.checkPeerStopHook(p); err == nil {
+		return p2p.Send(p.rw, storage.HostSectorNotFoundMsg, storage.ErrHostSectorNotFound.Error())
+	}
+	return err
+}
+
 // WaitConfigResp is used by the storage client, waiting from the configuration
 // response from the storage host
 func (p *peer) WaitConfigResp() (msg p2p.Msg, err error) {
@@ -236,6 +265,22 @@ func (p *peer) WaitConfigResp() (msg p2p.Msg, err error) {
 	}
 }
 
+// WaitProbeResp is used by the storage client, waiting for the probe response
+// from the storage host
+func (p *peer) WaitProbeResp() (msg p2p.Msg, err error) {
+	timeout := time.After(1 * time.Minute)
+	select {
+	case msg = <-p.clientProbeMsg:
+		return
+	case <-timeout:
+		err = errors.New("timeout -> client waits too long for probe response from the host")
+		return
+	case <-p.StopChan():
+		err = coinchargemaintenance.ErrProgramExit
+		return
+	}
+}
+
 // ClientWaitContractResp is used by the storage client. The method will block the current
 // process until the response was sent back from the storage host
 func (p *peer) ClientWaitContractResp() (msg p2p.Msg, err error) {
@@ -291,6 +336,29 @@ func (p *peer) HostConfigProcessingDone() {
 	}
 }
 
+// HostProbeProcessing is used to indicate that the host is currently processing
+// the probe request sent from the storage client, which will deny another probe
+// request sent by the storage client
+func (p *peer) HostProbeProcessing() error {
+	select {
+	case p.hostProbeProcessing <- struct{}{}:
+		return nil
+	default:
+		return errors.New("host probe request is currently processing, please wait until it finished first")
+	}
+}
+
+// HostProbeProcessingDone is used to indicate that storage host finished processing
+// the probe request
+func (p *peer) HostProbeProcessingDone() {
+	select {
+	case <-p.hostProbeProcessing:
+		return
+	default:
+		p.Log().Warn("host probe processing finished before it is actually done")
+	}
+}
+
 // HostContractProcessing is used to indicate that the host is currently processing
 // the contract related request sent from the storage client. It will include data upload,
 // data download, contract creation, and contract revision
@@ -354,6 +422,27 @@ func (p *peer) RequestHostConfigDone() {
 	}
 }
 
+// TryRequestHostProbe is used to check if the client is currently probing the
+// storage host, meaning the client should not send another probe before the
+// previous probe has finished
+func (p *peer) TryRequestHostProbe() error {
+	select {
+	case p.hostProbeRequesting <- struct{}{}:
+		return nil
+	default:
+		return storage.ErrRequestingHostProbe
+	}
+}
+
+// RequestHostProbeDone is used to indicate the storage client
+// that the probe is finished
+func (p *peer) RequestHostProbeDone() {
+	select {
+	case <-p.hostProbeRequesting:
+	default:
+	}
+}
+
 // IsStaticConn checks if the connection is static connection
 func (p *peer) IsStaticConn() bool {
 	return p.Peer.Info().Network.Static
@@ -73,6 +73,10 @@ type Backend interface {
 	// host announce
 	SignByNode(hash []byte) ([]byte, error)
 	GetHostEnodeURL() string
+
+	// OfflineTxs returns the queue of the storage contract txs to be signed offline, nil if
+	// the offline signing is not enabled
+	OfflineTxs() *OfflineTxQueue
 }
 
 func GetAPIs(apiBackend Backend) []rpc.API {
@@ -0,0 +1,160 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package ethapi
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/common/hexutil"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+var (
+	// ErrUnsignedTxNotFound is returned if the signed storage contract tx submitted does not
+	// match any unsigned tx in the offline queue
+	ErrUnsignedTxNotFound = errors.New("no unsigned storage contract tx matches the signed tx")
+
+	// ErrStorageTxQueued is returned if the storage contract tx is queued to be signed offline
+	// instead of sent to the txpool. The tx has no final hash until it is signed and submitted
+	ErrStorageTxQueued = errors.New("storage contract tx queued for offline signing")
+)
+
+// OfflineTxQueue holds the unsigned storage contract txs waiting to be signed offline. The txs
+// are keyed by the sender and the nonce, so that the tx replacing the unsigned tx of the same
+// nonce, such as the storage proof with a higher gas price, overwrites it.
+type OfflineTxQueue struct {
+	txs  map[common.Address]map[uint64]*UnsignedStorageTx
+	lock sync.RWMutex
+}
+
+// UnsignedStorageTx is the unsigned storage contract tx waiting to be signed offline
+type UnsignedStorageTx struct {
+	From        common.Address `json:"from"`
+	Nonce       hexutil.Uint64 `json:"nonce"`
+	ChainID     *hexutil.Big   `json:"chainId"`
+	SigningHash common.Hash    `json:"signingHash"`
+	Raw         hexutil.Bytes  `json:"raw"`
+}
+
+// NewOfflineTxQueue creates the empty offline tx queue
+func NewOfflineTxQueue() *OfflineTxQueue {
+	return &OfflineTxQueue{
+		txs: make(map[common.Address]map[uint64]*UnsignedStorageTx),
+	}
+}
+
+// Add adds the unsigned tx sent from the address to the queue, replacing the unsigned tx of
+// the same nonce
+func (q *OfflineTxQueue) Add(from common.Address, tx *types.Transaction, chainID *big.Int) error {
+	raw, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return err
+	}
+	var signer types.Signer = types.HomesteadSigner{}
+	if chainID != nil {
+		signer = types.NewEIP155Signer(chainID)
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.txs[from] == nil {
+		q.txs[from] = make(map[uint64]*UnsignedStorageTx)
+	}
+	q.txs[from][tx.Nonce()] = &UnsignedStorageTx{
+		From:        from,
+		Nonce:       hexutil.Uint64(tx.Nonce()),
+		ChainID:     (*hexutil.Big)(chainID),
+		SigningHash: signer.Hash(tx),
+		Raw:         raw,
+	}
+	return nil
+}
+
+// NextNonce returns the nonce next to the highest nonce of the unsigned txs sent from the
+// address, and false if there is none
+func (q *OfflineTxQueue) NextNonce(from common.Address) (uint64, bool) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	var next uint64
+	for nonce := range q.txs[from] {
+		if nonce+1 > next {
+			next = nonce + 1
+		}
+	}
+	return next, len(q.txs[from]) != 0
+}
+
+// Unsigned returns the unsigned txs in the queue, sorted by the sender and the nonce
+func (q *OfflineTxQueue) Unsigned() []*UnsignedStorageTx {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	var txs []*UnsignedStorageTx
+	for _, byNonce := range q.txs {
+		for _, utx := range byNonce {
+			txs = append(txs, utx)
+		}
+	}
+	sort.Slice(txs, func(i, j int) bool {
+		if txs[i].From != txs[j].From {
+			return txs[i].From.Hex() < txs[j].From.Hex()
+		}
+		return txs[i].Nonce < txs[j].Nonce
+	})
+	return txs
+}
+
+// Match returns the unsigned tx in the queue which the signed tx is signed from
+func (q *OfflineTxQueue) Match(signed *types.Transaction) (*UnsignedStorageTx, error) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	for from, byNonce := range q.txs {
+		utx, exist := byNonce[signed.Nonce()]
+		if !exist {
+			continue
+		}
+		if err := checkOfflineSignedTx(utx, signed, from); err == nil {
+			return utx, nil
+		}
+	}
+	return nil, ErrUnsignedTxNotFound
+}
+
+// Remove removes the unsigned tx of the nonce sent from the address
+func (q *OfflineTxQueue) Remove(from common.Address, nonce uint64) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if _, exist := q.txs[from][nonce]; !exist {
+		return false
+	}
+	delete(q.txs[from], nonce)
+	if len(q.txs[from]) == 0 {
+		delete(q.txs, from)
+	}
+	return true
+}
+
+// checkOfflineSignedTx checks whether the signed tx is the unsigned tx signed by the sender
+func checkOfflineSignedTx(utx *UnsignedStorageTx, signed *types.Transaction, from common.Address) error {
+	var signer types.Signer = types.HomesteadSigner{}
+	if utx.ChainID != nil {
+		signer = types.NewEIP155Signer(utx.ChainID.ToInt())
+	}
+	if signer.Hash(signed) != utx.SigningHash {
+		return ErrUnsignedTxNotFound
+	}
+	sender, err := types.Sender(signer, signed)
+	if err != nil {
+		return err
+	}
+	if sender != from {
+		return ErrUnsignedTxNotFound
+	}
+	return nil
+}
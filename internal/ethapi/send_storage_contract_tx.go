@@ -10,11 +10,18 @@ import (
 	"github.com/DxChainNetwork/godx/accounts"
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/common/hexutil"
+	"github.com/DxChainNetwork/godx/core"
+	"github.com/DxChainNetwork/godx/core/rawdb"
 	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/params"
 	"github.com/DxChainNetwork/godx/rlp"
 	"math/big"
 )
 
+var errOfflineSigningDisabled = errors.New("offline signing of storage contract tx is not enabled")
+
 // PrivateStorageContractTxAPI exposes the SendHostAnnounceTx methods for the RPC interface
 type PrivateStorageContractTxAPI struct {
 	b         Backend
@@ -56,6 +63,19 @@ func (psc *PrivateStorageContractTxAPI) SendHostAnnounceTX(from common.Address)
 	return txHash, nil
 }
 
+// SendSignedHostAnnounceTX send the host announce tx with the announcement already signed by
+// the host, used for announcing multiple addresses or signing with the announcement key
+func (psc *PrivateStorageContractTxAPI) SendSignedHostAnnounceTX(from common.Address, input []byte) (common.Hash, error) {
+	to := common.Address{}
+	to.SetBytes([]byte{9})
+	ctx := context.Background()
+	txHash, err := sendStorageContractTX(ctx, psc.b, psc.nonceLock, from, to, input)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return txHash, nil
+}
+
 // send form contract tx, generally triggered in ContractCreate, not for outer request
 func (psc *PrivateStorageContractTxAPI) SendContractCreateTX(from common.Address, input []byte) (common.Hash, error) {
 	to := common.Address{}
@@ -92,6 +112,32 @@ func (psc *PrivateStorageContractTxAPI) SendStorageProofTX(from common.Address,
 	return txHash, nil
 }
 
+// SendStorageProofTXWithArgs send storage proof tx with the nonce and gas price specified. The
+// nil nonce or gas price will be filled with the default value. The signed tx is returned, so that
+// the caller is able to track the tx and replace it with a higher gas price. If the tx is queued
+// to be signed offline, the unsigned tx is returned with ErrStorageTxQueued
+func (psc *PrivateStorageContractTxAPI) SendStorageProofTXWithArgs(from common.Address, input []byte, nonce *uint64, gasPrice *big.Int) (*types.Transaction, error) {
+	to := common.Address{}
+	to.SetBytes([]byte{12})
+	args := SendStorageContractTxArgs{
+		From:     from,
+		To:       to,
+		Nonce:    (*hexutil.Uint64)(nonce),
+		GasPrice: (*hexutil.Big)(gasPrice),
+	}
+	args.Input = (*hexutil.Bytes)(&input)
+	return signAndSendStorageContractTX(context.Background(), psc.b, psc.nonceLock, args)
+}
+
+// StorageContractTxKnown returns whether the storage contract tx is included in the canonical
+// chain or pending in the tx pool
+func (psc *PrivateStorageContractTxAPI) StorageContractTxKnown(hash common.Hash) bool {
+	if tx, _, _, _ := rawdb.ReadTransaction(psc.b.ChainDb(), hash); tx != nil {
+		return true
+	}
+	return psc.b.GetPoolTransaction(hash) != nil
+}
+
 // send storage contract tx，only need from、to、input（rlp encoded）
 //
 // NOTE: this is general func, you can construct different args to send 4 type txs, like host announce、form contract、contract revision、storage proof.
@@ -105,11 +151,30 @@ func sendStorageContractTX(ctx context.Context, b Backend, nonceLock *AddrLocker
 	}
 	args.Input = (*hexutil.Bytes)(&input)
 
+	signed, err := signAndSendStorageContractTX(ctx, b, nonceLock, args)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return signed.Hash(), nil
+}
+
+// signAndSendStorageContractTX sign the storage contract tx constructed with args by the wallet
+// of args.From, and send the signed tx to txpool. If the offline signing is enabled, the unsigned
+// tx is queued to be signed offline, and returned with ErrStorageTxQueued instead.
+func signAndSendStorageContractTX(ctx context.Context, b Backend, nonceLock *AddrLocker, args SendStorageContractTxArgs) (*types.Transaction, error) {
+	if offline := b.OfflineTxs(); offline != nil {
+		tx, err := queueStorageContractTX(ctx, b, offline, nonceLock, args)
+		if err != nil {
+			return nil, err
+		}
+		return tx, ErrStorageTxQueued
+	}
+
 	// find the account of the address from
 	account := accounts.Account{Address: args.From}
 	wallet, err := b.AccountManager().Find(account)
 	if err != nil {
-		return common.Hash{}, err
+		return nil, err
 	}
 
 	nonceLock.LockAddr(args.From)
@@ -118,29 +183,100 @@ func sendStorageContractTX(ctx context.Context, b Backend, nonceLock *AddrLocker
 	// construct tx
 	tx, err := args.setDefaultsTX(ctx, b)
 	if err != nil {
-		return common.Hash{}, err
-	}
-
-	// get chain ID
-	var chainID *big.Int
-	if config := b.ChainConfig(); config.IsEIP155(b.CurrentBlock().Number()) {
-		chainID = config.ChainID
+		return nil, err
 	}
 
 	// sign the tx by using from's wallet
-	signed, err := wallet.SignTx(account, tx, chainID)
+	signed, err := wallet.SignTx(account, tx, storageTxChainID(b))
 	if err != nil {
-		return common.Hash{}, err
+		return nil, err
 	}
 
 	// send signed tx to txpool
 	if err := b.SendTx(ctx, signed); err != nil {
-		return common.Hash{}, err
+		return nil, err
 	}
 
+	return signed, nil
+}
+
+// queueStorageContractTX constructs the storage contract tx with args and adds the unsigned tx
+// to the offline queue. The default nonce follows the unsigned txs already queued, which are not
+// in the txpool yet.
+func queueStorageContractTX(ctx context.Context, b Backend, offline *OfflineTxQueue, nonceLock *AddrLocker, args SendStorageContractTxArgs) (*types.Transaction, error) {
+	nonceLock.LockAddr(args.From)
+	defer nonceLock.UnlockAddr(args.From)
+
+	if args.Nonce == nil {
+		nonce, err := b.GetPoolNonce(ctx, args.From)
+		if err != nil {
+			return nil, err
+		}
+		if next, exist := offline.NextNonce(args.From); exist && next > nonce {
+			nonce = next
+		}
+		args.Nonce = (*hexutil.Uint64)(&nonce)
+	}
+	tx, err := args.setDefaultsTX(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	if err := offline.Add(args.From, tx, storageTxChainID(b)); err != nil {
+		return nil, err
+	}
+	log.Info("Storage contract tx queued for offline signing", "from", args.From, "nonce", tx.Nonce(), "to", args.To)
+	return tx, nil
+}
+
+// storageTxChainID returns the chain ID to sign the storage contract tx with, nil before EIP155
+func storageTxChainID(b Backend) *big.Int {
+	if config := b.ChainConfig(); config.IsEIP155(b.CurrentBlock().Number()) {
+		return config.ChainID
+	}
+	return nil
+}
+
+// UnsignedStorageContractTXs returns the storage contract txs waiting to be signed offline
+func (psc *PrivateStorageContractTxAPI) UnsignedStorageContractTXs() ([]*UnsignedStorageTx, error) {
+	offline := psc.b.OfflineTxs()
+	if offline == nil {
+		return nil, errOfflineSigningDisabled
+	}
+	return offline.Unsigned(), nil
+}
+
+// SubmitSignedStorageContractTX submits the RLP encoded storage contract tx signed offline. The
+// signed tx must be one of the unsigned txs queued, which is removed from the queue once sent.
+func (psc *PrivateStorageContractTxAPI) SubmitSignedStorageContractTX(ctx context.Context, raw hexutil.Bytes) (common.Hash, error) {
+	offline := psc.b.OfflineTxs()
+	if offline == nil {
+		return common.Hash{}, errOfflineSigningDisabled
+	}
+	signed := new(types.Transaction)
+	if err := rlp.DecodeBytes(raw, signed); err != nil {
+		return common.Hash{}, err
+	}
+	utx, err := offline.Match(signed)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := psc.b.SendTx(ctx, signed); err != nil {
+		return common.Hash{}, err
+	}
+	offline.Remove(utx.From, uint64(utx.Nonce))
 	return signed.Hash(), nil
 }
 
+// DiscardUnsignedStorageContractTX removes the unsigned storage contract tx of the nonce sent
+// from the address from the offline queue
+func (psc *PrivateStorageContractTxAPI) DiscardUnsignedStorageContractTX(from common.Address, nonce hexutil.Uint64) (bool, error) {
+	offline := psc.b.OfflineTxs()
+	if offline == nil {
+		return false, errOfflineSigningDisabled
+	}
+	return offline.Remove(from, uint64(nonce)), nil
+}
+
 // SendTxArgs represents the arguments to submit a new transaction into the transaction pool.
 type SendStorageContractTxArgs struct {
 	From     common.Address  `json:"from"`
@@ -154,19 +290,23 @@ type SendStorageContractTxArgs struct {
 // construct tx with args
 func (args *SendStorageContractTxArgs) setDefaultsTX(ctx context.Context, b Backend) (*types.Transaction, error) {
 	args.Gas = new(hexutil.Uint64)
-	*(*uint64)(args.Gas) = 90000
+	*(*uint64)(args.Gas) = storageContractTxGas(args.To, args.Input)
 
-	price, err := b.SuggestPrice(ctx)
-	if err != nil {
-		return nil, err
+	if args.GasPrice == nil {
+		price, err := b.SuggestPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
+		args.GasPrice = (*hexutil.Big)(price)
 	}
-	args.GasPrice = (*hexutil.Big)(price)
 
-	nonce, err := b.GetPoolNonce(ctx, args.From)
-	if err != nil {
-		return nil, err
+	if args.Nonce == nil {
+		nonce, err := b.GetPoolNonce(ctx, args.From)
+		if err != nil {
+			return nil, err
+		}
+		args.Nonce = (*hexutil.Uint64)(&nonce)
 	}
-	args.Nonce = (*hexutil.Uint64)(&nonce)
 
 	if args.To == (common.Address{}) || args.Input == nil {
 		return nil, errors.New(`storage contract tx without to or input`)
@@ -174,3 +314,25 @@ func (args *SendStorageContractTxArgs) setDefaultsTX(ctx context.Context, b Back
 
 	return types.NewTransaction(uint64(*args.Nonce), args.To, nil, uint64(*args.Gas), (*big.Int)(args.GasPrice), *args.Input), nil
 }
+
+// storageContractTxGas returns the gas limit of the storage contract tx. The multi segment storage
+// proof is given the gas for its input and verification, which is beyond the default gas limit.
+func storageContractTxGas(to common.Address, input *hexutil.Bytes) uint64 {
+	gas := uint64(90000)
+	if input == nil || vm.PrecompiledEVMFileContracts[to] != vm.StorageProofTransaction {
+		return gas
+	}
+	var sp types.StorageProof
+	if err := rlp.DecodeBytes(*input, &sp); err != nil || len(sp.Segments) == 0 {
+		return gas
+	}
+	intrinsic, err := core.IntrinsicGas(*input, false, true)
+	if err != nil {
+		return gas
+	}
+	fork := &params.StorageFork{ProofVersion: params.StorageProofV1}
+	if proofGas := intrinsic + params.DecodeGas + vm.StorageProofGas(sp, fork); proofGas > gas {
+		return proofGas
+	}
+	return gas
+}
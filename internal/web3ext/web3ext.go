@@ -500,6 +500,44 @@ web3._extend({
 			params: 3,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputBlockNumberFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'hostReputation',
+			call: 'eth_hostReputation',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'expiredStorageContract',
+			call: 'eth_expiredStorageContract',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'hostReputationHistory',
+			call: 'eth_hostReputationHistory',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'storageContractCreation',
+			call: 'eth_storageContractCreation',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'storageContractRevisions',
+			call: 'eth_storageContractRevisions',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'storageContractProof',
+			call: 'eth_storageContractProof',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'hostAnnouncements',
+			call: 'eth_hostAnnouncements',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -34,6 +34,7 @@ import (
 	"github.com/DxChainNetwork/godx/eth/gasprice"
 	"github.com/DxChainNetwork/godx/ethdb"
 	"github.com/DxChainNetwork/godx/event"
+	"github.com/DxChainNetwork/godx/internal/ethapi"
 	"github.com/DxChainNetwork/godx/light"
 	"github.com/DxChainNetwork/godx/params"
 	"github.com/DxChainNetwork/godx/rpc"
@@ -113,6 +114,15 @@ func (b *LesApiBackend) GetEVM(ctx context.Context, msg core.Message, state *sta
 }
 
 func (b *LesApiBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
+	// relay the storage contract transaction to a server first, so that the transaction
+	// rejected by the storage contract validation is reported. The transaction relayed is
+	// only tracked by the pool, without being relayed again
+	if to := signedTx.To(); to != nil && isStorageContractTx(*to) {
+		if err := b.eth.odr.RelayStorageTx(ctx, signedTx); err != nil {
+			return err
+		}
+		return b.eth.txPool.AddRelayed(ctx, signedTx)
+	}
 	return b.eth.txPool.Add(ctx, signedTx)
 }
 
@@ -212,3 +222,8 @@ func (b *LesApiBackend) SignByNode(hash []byte) ([]byte, error) {
 func (b *LesApiBackend) GetHostEnodeURL() string {
 	return ""
 }
+
+// Offline signing of storage contract txs is not supported in light mode
+func (b *LesApiBackend) OfflineTxs() *ethapi.OfflineTxQueue {
+	return nil
+}
@@ -43,6 +43,8 @@ import (
 	"github.com/DxChainNetwork/godx/p2p/discv5"
 	"github.com/DxChainNetwork/godx/params"
 	"github.com/DxChainNetwork/godx/rpc"
+	"github.com/DxChainNetwork/godx/storage/storageclient"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem"
 	"github.com/DxChainNetwork/godx/storage/storagehost"
 )
 
@@ -50,6 +52,7 @@ type LightEthereum struct {
 	apionce        sync.Once
 	registeredAPIs []rpc.API
 	storageHost    *storagehost.StorageHost
+	storageClient  *storageclient.StorageClient
 
 	lesCommons
 
@@ -75,6 +78,7 @@ type LightEthereum struct {
 	eventMux       *event.TypeMux
 	engine         consensus.Engine
 	accountManager *accounts.Manager
+	server         *p2p.Server
 
 	networkId     uint64
 	netRPCService *ethapi.PublicNetAPI
@@ -151,6 +155,15 @@ func New(ctx *node.ServiceContext, config *eth.Config) (*LightEthereum, error) {
 	}
 	leth.ApiBackend.gpo = gasprice.NewOracle(leth.ApiBackend, gpoParams)
 
+	// Initialize StorageClient based on the configuration
+	if config.StorageClient {
+		clientPath := ctx.ResolvePath(config.StorageClientDir)
+		leth.storageClient, err = storageclient.New(clientPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	path := ctx.ResolvePath(storagehost.PersistHostDir)
 	leth.storageHost, err = storagehost.New(path)
 
@@ -170,6 +183,8 @@ func lesTopic(genesisHash common.Hash, protocolVersion uint) discv5.Topic {
 		name = "LES"
 	case lpv2:
 		name = "LES2"
+	case lpv3:
+		name = "LES3"
 	default:
 		panic(nil)
 	}
@@ -218,6 +233,11 @@ func (s *LightEthereum) APIs() []rpc.API {
 				Version:   "1.0",
 				Service:   filters.NewPublicFilterAPI(s.ApiBackend, true),
 				Public:    true,
+			}, {
+				Namespace: "les",
+				Version:   "1.0",
+				Service:   NewPublicLightStorageAPI(s),
+				Public:    true,
 			}, {
 				Namespace: "net",
 				Version:   "1.0",
@@ -225,6 +245,27 @@ func (s *LightEthereum) APIs() []rpc.API {
 				Public:    true,
 			},
 		}...)
+
+		if s.config.StorageClient {
+			s.registeredAPIs = append(s.registeredAPIs, []rpc.API{
+				{
+					Namespace: "sclient",
+					Version:   "1.0",
+					Service:   storageclient.NewPublicStorageClientAPI(s.storageClient),
+					Public:    true,
+				}, {
+					Namespace: "sclient",
+					Version:   "1.0",
+					Service:   storageclient.NewPrivateStorageClientAPI(s.storageClient),
+					Public:    false,
+				}, {
+					Namespace: "clientfiles",
+					Version:   "1.0",
+					Service:   filesystem.NewPublicFileSystemAPI(s.storageClient.GetFileSystem()),
+					Public:    true,
+				},
+			}...)
+		}
 	}
 
 	s.apionce.Do(getAPI)
@@ -254,6 +295,10 @@ func (s *LightEthereum) Protocols() []p2p.Protocol {
 // Ethereum protocol implementation.
 func (s *LightEthereum) Start(srvr *p2p.Server) error {
 	log.Warn("Light client mode is an experimental feature")
+	if s.config.StorageHost {
+		log.Warn("Storage host is not supported in light client mode")
+	}
+	s.server = srvr
 	s.startBloomHandlers(params.BloomBitsBlocksClient)
 	s.netRPCService = ethapi.NewPublicNetAPI(srvr, s.networkId)
 	// clients are searching for the first advertised protocol in the list
@@ -261,6 +306,13 @@ func (s *LightEthereum) Start(srvr *p2p.Server) error {
 	s.serverPool.start(srvr, lesTopic(s.blockchain.Genesis().Hash(), protocolVersion))
 	s.protocolManager.Start(s.config.LightPeers)
 
+	// Start Storage Client, which negotiates with the storage hosts through LPV3
+	if s.config.StorageClient {
+		if err := s.storageClient.Start(s, s.ApiBackend); err != nil {
+			return err
+		}
+	}
+
 	// s.storageHost.Start(s)
 
 	return nil
@@ -282,6 +334,9 @@ func (s *LightEthereum) Stop() error {
 	time.Sleep(time.Millisecond * 200)
 	s.chainDb.Close()
 	s.storageHost.Close()
+	if s.config.StorageClient {
+		s.storageClient.Close()
+	}
 	close(s.shutdownChan)
 
 	return nil
@@ -42,6 +42,7 @@ import (
 	"github.com/DxChainNetwork/godx/p2p/discv5"
 	"github.com/DxChainNetwork/godx/params"
 	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage/storagehost"
 	"github.com/DxChainNetwork/godx/trie"
 )
 
@@ -59,6 +60,8 @@ const (
 	MaxHelperTrieProofsFetch = 64  // Amount of merkle proofs to be fetched per retrieval request
 	MaxTxSend                = 64  // Amount of transactions to be send per request
 	MaxTxStatus              = 256 // Amount of transactions to queried per request
+	MaxHostAnnouncementFetch = 128 // Amount of blocks to be scanned for host announcements per request
+	MaxStorageContractFetch  = 64  // Amount of storage contract proofs to be fetched per retrieval request
 
 	disableClientRemovePeer = false
 )
@@ -99,6 +102,7 @@ type ProtocolManager struct {
 	chainDb     ethdb.Database
 	odr         *LesOdr
 	server      *LesServer
+	storageHost *storagehost.StorageHost // nil if the server does not run the storage host
 	serverPool  *serverPool
 	clientPool  *freeClientPool
 	lesTopic    discv5.Topic
@@ -321,10 +325,16 @@ func (pm *ProtocolManager) handle(p *peer) error {
 			p.Log().Debug("Light Ethereum message handling failed", "err", err)
 			return err
 		}
+		// check for the error triggered by the storage negotiation
+		select {
+		case err := <-p.errMsg:
+			return err
+		default:
+		}
 	}
 }
 
-var reqList = []uint64{GetBlockHeadersMsg, GetBlockBodiesMsg, GetCodeMsg, GetReceiptsMsg, GetProofsV1Msg, SendTxMsg, SendTxV2Msg, GetTxStatusMsg, GetHeaderProofsMsg, GetProofsV2Msg, GetHelperTrieProofsMsg}
+var reqList = []uint64{GetBlockHeadersMsg, GetBlockBodiesMsg, GetCodeMsg, GetReceiptsMsg, GetProofsV1Msg, SendTxMsg, SendTxV2Msg, GetTxStatusMsg, GetHeaderProofsMsg, GetProofsV2Msg, GetHelperTrieProofsMsg, GetHostAnnouncementsMsg, GetStorageContractProofsMsg, SendStorageTxMsg}
 
 // handleMsg is invoked whenever an inbound message is received from a remote
 // peer. The remote connection is torn down upon returning any error.
@@ -357,6 +367,10 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 	if msg.Size > ProtocolMaxMsgSize {
 		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
 	}
+	// the storage negotiation messages are consumed by the storage client and host
+	if isStorageMsg(p, msg.Code) {
+		return pm.handleStorageMsg(p, msg)
+	}
 	defer msg.Discard()
 
 	var deliverMsg *Msg
@@ -1100,6 +1114,171 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 
 		p.fcServer.GotReply(resp.ReqID, resp.BV)
 
+	case GetHostAnnouncementsMsg:
+		p.Log().Trace("Received host announcements request")
+		// Decode the retrieval message
+		var req struct {
+			ReqID uint64
+			Query getHostAnnouncementsData
+		}
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if req.Query.To < req.Query.From {
+			return errResp(ErrDecode, "invalid block range %d - %d", req.Query.From, req.Query.To)
+		}
+		reqCnt := req.Query.To - req.Query.From + 1
+		if reject(reqCnt, MaxHostAnnouncementFetch) {
+			return errResp(ErrRequestRejected, "")
+		}
+		announcements := pm.hostAnnouncements(req.Query.From, req.Query.To)
+		bv, rcost := p.fcClient.RequestProcessed(costs.baseCost + reqCnt*costs.reqCost)
+		pm.server.fcCostStats.update(msg.Code, reqCnt, rcost)
+		return p.SendHostAnnouncements(req.ReqID, bv, announcements)
+
+	case HostAnnouncementsMsg:
+		if pm.odr == nil {
+			return errResp(ErrUnexpectedResponse, "")
+		}
+
+		p.Log().Trace("Received host announcements response")
+		var resp struct {
+			ReqID, BV uint64
+			Data      []hostAnnouncementsData
+		}
+		if err := msg.Decode(&resp); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		p.fcServer.GotReply(resp.ReqID, resp.BV)
+		deliverMsg = &Msg{
+			MsgType: MsgHostAnnouncements,
+			ReqID:   resp.ReqID,
+			Obj:     resp.Data,
+		}
+
+	case GetStorageContractProofsMsg:
+		p.Log().Trace("Received storage contract proofs request")
+		// Decode the retrieval message
+		var req struct {
+			ReqID uint64
+			Reqs  []StorageContractReq
+		}
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		reqCnt := len(req.Reqs)
+		if reject(uint64(reqCnt), MaxStorageContractFetch) {
+			return errResp(ErrRequestRejected, "")
+		}
+		nodes := light.NewNodeSet()
+		for _, req := range req.Reqs {
+			// Look up the state belonging to the request
+			number := rawdb.ReadHeaderNumber(pm.chainDb, req.BHash)
+			if number == nil {
+				continue
+			}
+			header := rawdb.ReadHeader(pm.chainDb, req.BHash, *number)
+			if header == nil {
+				continue
+			}
+			statedb, err := pm.blockchain.State()
+			if err != nil {
+				continue
+			}
+			// Prove the storage contract from the account and storage trie
+			if err := pm.proveStorageContract(statedb.Database(), header.Root, req.ContractID, nodes); err != nil {
+				continue
+			}
+			if nodes.DataSize() >= softResponseLimit {
+				break
+			}
+		}
+		bv, rcost := p.fcClient.RequestProcessed(costs.baseCost + uint64(reqCnt)*costs.reqCost)
+		pm.server.fcCostStats.update(msg.Code, uint64(reqCnt), rcost)
+		return p.SendStorageContractProofs(req.ReqID, bv, nodes.NodeList())
+
+	case StorageContractProofsMsg:
+		if pm.odr == nil {
+			return errResp(ErrUnexpectedResponse, "")
+		}
+
+		p.Log().Trace("Received storage contract proofs response")
+		var resp struct {
+			ReqID, BV uint64
+			Data      light.NodeList
+		}
+		if err := msg.Decode(&resp); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		p.fcServer.GotReply(resp.ReqID, resp.BV)
+		deliverMsg = &Msg{
+			MsgType: MsgStorageContractProofs,
+			ReqID:   resp.ReqID,
+			Obj:     resp.Data,
+		}
+
+	case SendStorageTxMsg:
+		if pm.txpool == nil {
+			return errResp(ErrRequestRejected, "")
+		}
+		// Storage contract transactions arrived, deliver them to the pool
+		var req struct {
+			ReqID uint64
+			Txs   []*types.Transaction
+		}
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		reqCnt := len(req.Txs)
+		if reject(uint64(reqCnt), MaxTxSend) {
+			return errResp(ErrRequestRejected, "")
+		}
+
+		hashes := make([]common.Hash, len(req.Txs))
+		for i, tx := range req.Txs {
+			hashes[i] = tx.Hash()
+		}
+		stats := pm.txStatus(hashes)
+		for i, stat := range stats {
+			if stat.Status != core.TxStatusUnknown {
+				continue
+			}
+			if to := req.Txs[i].To(); to == nil || !isStorageContractTx(*to) {
+				stats[i].Error = errNotStorageContractTx.Error()
+				continue
+			}
+			if errs := pm.txpool.AddRemotes([]*types.Transaction{req.Txs[i]}); errs[0] != nil {
+				stats[i].Error = errs[0].Error()
+				continue
+			}
+			stats[i] = pm.txStatus([]common.Hash{hashes[i]})[0]
+		}
+
+		bv, rcost := p.fcClient.RequestProcessed(costs.baseCost + uint64(reqCnt)*costs.reqCost)
+		pm.server.fcCostStats.update(msg.Code, uint64(reqCnt), rcost)
+
+		return p.SendStorageTxStatus(req.ReqID, bv, stats)
+
+	case StorageTxStatusMsg:
+		if pm.odr == nil {
+			return errResp(ErrUnexpectedResponse, "")
+		}
+
+		p.Log().Trace("Received storage tx status response")
+		var resp struct {
+			ReqID, BV uint64
+			Status    []txStatus
+		}
+		if err := msg.Decode(&resp); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		p.fcServer.GotReply(resp.ReqID, resp.BV)
+		deliverMsg = &Msg{
+			MsgType: MsgStorageTxStatus,
+			ReqID:   resp.ReqID,
+			Obj:     resp.Status,
+		}
+
 	default:
 		p.Log().Trace("Received unknown message", "code", msg.Code)
 		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
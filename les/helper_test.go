@@ -0,0 +1,196 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// This file contains some shares testing functionality, common to  multiple
+// different files and modules being tested.
+
+package les
+
+import (
+	"crypto/rand"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/consensus/ethash"
+	"github.com/DxChainNetwork/godx/core"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/event"
+	"github.com/DxChainNetwork/godx/les/flowcontrol"
+	"github.com/DxChainNetwork/godx/light"
+	"github.com/DxChainNetwork/godx/p2p"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/params"
+)
+
+var (
+	testBankKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testBankAddress = crypto.PubkeyToAddress(testBankKey.PublicKey)
+	testBankFunds   = big.NewInt(1000000000000000000)
+)
+
+const testBufLimit = 100000000
+
+// newTestProtocolManager creates a new protocol manager for testing purposes,
+// with the given number of blocks already known, potential notification
+// channels for different events and relative chain indexers array. The
+// server chain is generated with the generator, and the genesis funds the
+// test bank and the given accounts.
+func newTestProtocolManager(lightSync bool, blocks int, generator func(int, *core.BlockGen), odr *LesOdr, peers *peerSet, db ethdb.Database, txpool txPool, alloc core.GenesisAlloc) (*ProtocolManager, error) {
+	var (
+		evmux  = new(event.TypeMux)
+		engine = ethash.NewFaker()
+		gspec  = core.Genesis{
+			Config: params.AllEthashProtocolChanges,
+			Alloc:  core.GenesisAlloc{testBankAddress: {Balance: testBankFunds}},
+		}
+		chain BlockChain
+	)
+	for addr, account := range alloc {
+		gspec.Alloc[addr] = account
+	}
+	genesis := gspec.MustCommit(db)
+	if peers == nil {
+		peers = newPeerSet()
+	}
+
+	if lightSync {
+		chain, _ = light.NewLightChain(odr, gspec.Config, engine)
+	} else {
+		blockchain, _ := core.NewBlockChain(db, nil, gspec.Config, engine, vm.Config{}, nil)
+		gchain, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, blocks, generator)
+		if _, err := blockchain.InsertChain(gchain); err != nil {
+			return nil, err
+		}
+		chain = blockchain
+	}
+
+	indexConfig := light.TestServerIndexerConfig
+	if lightSync {
+		indexConfig = light.TestClientIndexerConfig
+	}
+	pm, err := NewProtocolManager(gspec.Config, indexConfig, lightSync, NetworkId, evmux, engine, peers, chain, txpool, db, odr, nil, nil, make(chan struct{}), new(sync.WaitGroup))
+	if err != nil {
+		return nil, err
+	}
+	if !lightSync {
+		srv := &LesServer{lesCommons: lesCommons{protocolManager: pm}}
+		pm.server = srv
+
+		srv.defParams = &flowcontrol.ServerParams{
+			BufLimit:    testBufLimit,
+			MinRecharge: 1,
+		}
+
+		srv.fcManager = flowcontrol.NewClientManager(50, 10, 1000000000)
+		srv.fcCostStats = newCostStats(nil)
+	}
+	pm.Start(1000)
+	return pm, nil
+}
+
+// newTestProtocolManagerMust creates a new protocol manager for testing purposes,
+// with the given number of blocks already known, potential notification
+// channels for different events and relative chain indexers array. In case of an error, the constructor force-
+// fails the test.
+func newTestProtocolManagerMust(t *testing.T, lightSync bool, blocks int, generator func(int, *core.BlockGen), odr *LesOdr, peers *peerSet, db ethdb.Database, txpool txPool, alloc core.GenesisAlloc) *ProtocolManager {
+	pm, err := newTestProtocolManager(lightSync, blocks, generator, odr, peers, db, txpool, alloc)
+	if err != nil {
+		t.Fatalf("Failed to create protocol manager: %v", err)
+	}
+	return pm
+}
+
+// newTestPeerPair creates a pair of connected peers of the given protocol
+// managers, and runs the les protocol on both sides.
+func newTestPeerPair(name string, version int, pm, pm2 *ProtocolManager) (*peer, <-chan error, *peer, <-chan error) {
+	// Create a message pipe to communicate through
+	app, net := p2p.MsgPipe()
+
+	// Generate a random id and create the peer
+	var id enode.ID
+	rand.Read(id[:])
+
+	peer := pm.newPeer(version, NetworkId, p2p.NewPeer(id, name, nil), net)
+	peer2 := pm2.newPeer(version, NetworkId, p2p.NewPeer(id, name, nil), app)
+
+	// Start the peer on a new thread
+	errc := make(chan error, 1)
+	errc2 := make(chan error, 1)
+	go func() {
+		select {
+		case pm.newPeerCh <- peer:
+			errc <- pm.handle(peer)
+		case <-pm.quitSync:
+			errc <- p2p.DiscQuitting
+		}
+	}()
+	go func() {
+		select {
+		case pm2.newPeerCh <- peer2:
+			errc2 <- pm2.handle(peer2)
+		case <-pm2.quitSync:
+			errc2 <- p2p.DiscQuitting
+		}
+	}()
+	return peer, errc, peer2, errc2
+}
+
+// odrTestEnv is a server and a light client connected through the les protocol
+type odrTestEnv struct {
+	pm, lpm *ProtocolManager
+	odr     *LesOdr
+}
+
+// newOdrTestEnv creates a server with the generated chain, and a light client synced to it,
+// connected by the given protocol version
+func newOdrTestEnv(t *testing.T, protocol int, blocks int, generator func(int, *core.BlockGen), txpool txPool, alloc core.GenesisAlloc) *odrTestEnv {
+	// Assemble the test environment
+	peers := newPeerSet()
+	dist := newRequestDistributor(peers, make(chan struct{}))
+	rm := newRetrieveManager(peers, dist, nil)
+	db := ethdb.NewMemDatabase()
+	ldb := ethdb.NewMemDatabase()
+	odr := NewLesOdr(ldb, light.TestClientIndexerConfig, rm)
+	pm := newTestProtocolManagerMust(t, false, blocks, generator, nil, nil, db, txpool, alloc)
+	lpm := newTestProtocolManagerMust(t, true, 0, nil, odr, peers, ldb, nil, alloc)
+	_, err1, lpeer, err2 := newTestPeerPair("peer", protocol, pm, lpm)
+	select {
+	case <-time.After(time.Millisecond * 100):
+	case err := <-err1:
+		t.Fatalf("peer 1 handshake error: %v", err)
+	case err := <-err2:
+		t.Fatalf("peer 2 handshake error: %v", err)
+	}
+
+	lpm.synchronise(lpeer)
+	return &odrTestEnv{pm: pm, lpm: lpm, odr: odr}
+}
+
+// close stops the protocol managers of the test environment
+func (env *odrTestEnv) close() {
+	env.odr.Stop()
+	env.lpm.Stop()
+
+	env.pm.server.fcManager.Stop()
+	go func() {
+		<-env.pm.noMorePeers
+	}()
+	env.pm.Stop()
+}
@@ -18,8 +18,10 @@ package les
 
 import (
 	"context"
+	"errors"
 
 	"github.com/DxChainNetwork/godx/core"
+	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/ethdb"
 	"github.com/DxChainNetwork/godx/light"
 	"github.com/DxChainNetwork/godx/log"
@@ -88,6 +90,9 @@ const (
 	MsgProofsV2
 	MsgHeaderProofs
 	MsgHelperTrieProofs
+	MsgHostAnnouncements
+	MsgStorageContractProofs
+	MsgStorageTxStatus
 )
 
 // Msg encodes a LES message that delivers reply data for a request
@@ -100,8 +105,31 @@ type Msg struct {
 // Retrieve tries to fetch an object from the LES network.
 // If the network retrieval was successful, it stores the object in local db.
 func (odr *LesOdr) Retrieve(ctx context.Context, req light.OdrRequest) (err error) {
-	lreq := LesRequest(req)
+	if err = odr.retrieve(ctx, LesRequest(req)); err == nil {
+		// retrieved from network, store in db
+		req.StoreResult(odr.db)
+	} else {
+		log.Debug("Failed to retrieve data from network", "err", err)
+	}
+	return
+}
+
+// RelayStorageTx relays the storage contract transaction to a LES server, and returns the
+// error if the transaction is rejected by the transaction pool of the server
+func (odr *LesOdr) RelayStorageTx(ctx context.Context, tx *types.Transaction) error {
+	req := &StorageTxRequest{Tx: tx}
+	if err := odr.retrieve(ctx, req); err != nil {
+		log.Debug("Failed to relay storage contract transaction", "hash", tx.Hash(), "err", err)
+		return err
+	}
+	if req.Status.Error != "" {
+		return errors.New(req.Status.Error)
+	}
+	return nil
+}
 
+// retrieve sends the request to the LES network, and waits for a valid reply
+func (odr *LesOdr) retrieve(ctx context.Context, lreq LesOdrRequest) error {
 	reqID := genReqID()
 	rq := &distReq{
 		getCost: func(dp distPeer) uint64 {
@@ -118,12 +146,5 @@ func (odr *LesOdr) Retrieve(ctx context.Context, req light.OdrRequest) (err erro
 			return func() { lreq.Request(reqID, p) }
 		},
 	}
-
-	if err = odr.retriever.retrieve(ctx, reqID, rq, func(p distPeer, msg *Msg) error { return lreq.Validate(odr.db, msg) }, odr.stop); err == nil {
-		// retrieved from network, store in db
-		req.StoreResult(odr.db)
-	} else {
-		log.Debug("Failed to retrieve data from network", "err", err)
-	}
-	return
+	return odr.retriever.retrieve(ctx, reqID, rq, func(p distPeer, msg *Msg) error { return lreq.Validate(odr.db, msg) }, odr.stop)
 }
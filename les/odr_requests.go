@@ -19,18 +19,22 @@
 package les
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
 
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/core/rawdb"
+	"github.com/DxChainNetwork/godx/core/state"
 	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
 	"github.com/DxChainNetwork/godx/crypto"
 	"github.com/DxChainNetwork/godx/ethdb"
 	"github.com/DxChainNetwork/godx/light"
 	"github.com/DxChainNetwork/godx/log"
 	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage/coinchargemaintenance"
 	"github.com/DxChainNetwork/godx/trie"
 )
 
@@ -45,6 +49,9 @@ var (
 	errCHTHashMismatch     = errors.New("cht hash mismatch")
 	errCHTNumberMismatch   = errors.New("cht number mismatch")
 	errUselessNodes        = errors.New("useless nodes in merkle proof nodeset")
+	errCanonicalMismatch   = errors.New("block not canonical")
+	errBlockOutOfRange     = errors.New("block out of requested range")
+	errNotHostAnnouncement = errors.New("transaction is not host announcement")
 )
 
 type LesOdrRequest interface {
@@ -68,6 +75,10 @@ func LesRequest(req light.OdrRequest) LesOdrRequest {
 		return (*ChtRequest)(r)
 	case *light.BloomRequest:
 		return (*BloomRequest)(r)
+	case *light.HostAnnouncementsRequest:
+		return (*HostAnnouncementsRequest)(r)
+	case *light.StorageContractRequest:
+		return (*StorageContractRequest)(r)
 	default:
 		return nil
 	}
@@ -193,7 +204,7 @@ func (r *TrieRequest) GetCost(peer *peer) uint64 {
 	switch peer.version {
 	case lpv1:
 		return peer.GetRequestCost(GetProofsV1Msg, 1)
-	case lpv2:
+	case lpv2, lpv3:
 		return peer.GetRequestCost(GetProofsV2Msg, 1)
 	default:
 		panic(nil)
@@ -353,7 +364,7 @@ func (r *ChtRequest) GetCost(peer *peer) uint64 {
 	switch peer.version {
 	case lpv1:
 		return peer.GetRequestCost(GetHeaderProofsMsg, 1)
-	case lpv2:
+	case lpv2, lpv3:
 		return peer.GetRequestCost(GetHelperTrieProofsMsg, 1)
 	default:
 		panic(nil)
@@ -389,7 +400,7 @@ func (r *ChtRequest) Request(reqID uint64, peer *peer) error {
 		// convert HelperTrie request to old CHT request
 		reqsV1 = ChtReq{ChtNum: (req.TrieIdx + 1) * (r.Config.ChtSize / r.Config.PairChtSize), BlockNum: blockNum, FromLevel: req.FromLevel}
 		return peer.RequestHelperTrieProofs(reqID, r.GetCost(peer), []ChtReq{reqsV1})
-	case lpv2:
+	case lpv2, lpv3:
 		return peer.RequestHelperTrieProofs(reqID, r.GetCost(peer), []HelperTrieReq{req})
 	default:
 		panic(nil)
@@ -557,6 +568,237 @@ func (r *BloomRequest) Validate(db ethdb.Database, msg *Msg) error {
 	return nil
 }
 
+// ODR request type for the host announcements of a range of canonical blocks, see LesOdrRequest
+// interface. As the server proves the inclusion of the host announcements returned, but not
+// the absence of the others, a block without host announcement returned is not proved to
+// contain none
+type HostAnnouncementsRequest light.HostAnnouncementsRequest
+
+// GetCost returns the cost of the given ODR request according to the serving
+// peer's cost table (implementation of LesOdrRequest)
+func (r *HostAnnouncementsRequest) GetCost(peer *peer) uint64 {
+	return peer.GetRequestCost(GetHostAnnouncementsMsg, int(r.To-r.From+1))
+}
+
+// CanSend tells if a certain peer is suitable for serving the given request
+func (r *HostAnnouncementsRequest) CanSend(peer *peer) bool {
+	peer.lock.RLock()
+	defer peer.lock.RUnlock()
+
+	if peer.version < lpv3 {
+		return false
+	}
+	return peer.headInfo.Number >= r.To
+}
+
+// Request sends an ODR request to the LES network (implementation of LesOdrRequest)
+func (r *HostAnnouncementsRequest) Request(reqID uint64, peer *peer) error {
+	peer.Log().Debug("Requesting host announcements", "from", r.From, "to", r.To)
+	return peer.RequestHostAnnouncements(reqID, r.GetCost(peer), r.From, r.To)
+}
+
+// Valid processes an ODR request reply message from the LES network
+// returns true and stores results in memory if the message was a valid reply
+// to the request (implementation of LesOdrRequest)
+func (r *HostAnnouncementsRequest) Validate(db ethdb.Database, msg *Msg) error {
+	log.Debug("Validating host announcements", "from", r.From, "to", r.To)
+
+	if msg.MsgType != MsgHostAnnouncements {
+		return errInvalidMessageType
+	}
+	var (
+		resps         = msg.Obj.([]hostAnnouncementsData)
+		announcements = make([]light.HostAnnouncements, 0, len(resps))
+		next          = r.From
+		keybuf        = new(bytes.Buffer)
+	)
+	for _, resp := range resps {
+		// Ensure the blocks are canonical ones within the range, in ascending order
+		number := rawdb.ReadHeaderNumber(db, resp.Hash)
+		if number == nil {
+			return errHeaderUnavailable
+		}
+		if *number < next || *number > r.To {
+			return errBlockOutOfRange
+		}
+		if rawdb.ReadCanonicalHash(db, *number) != resp.Hash {
+			return errCanonicalMismatch
+		}
+		next = *number + 1
+		header := rawdb.ReadHeader(db, resp.Hash, *number)
+		if header == nil {
+			return errHeaderUnavailable
+		}
+		if len(resp.Txs) == 0 {
+			return errInvalidEntryCount
+		}
+
+		// Verify the host announcement transactions against the transaction root
+		nodeSet := resp.Proof.NodeSet()
+		reads := &readTraceDB{db: nodeSet}
+		entry := light.HostAnnouncements{BlockHash: resp.Hash, BlockNumber: *number}
+		for _, atx := range resp.Txs {
+			keybuf.Reset()
+			rlp.Encode(keybuf, uint(atx.Index))
+			value, _, err := trie.VerifyProof(header.TxHash, keybuf.Bytes(), reads)
+			if err != nil {
+				return fmt.Errorf("merkle proof verification failed: %v", err)
+			}
+			data, err := rlp.EncodeToBytes(atx.Tx)
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(value, data) {
+				return errTxHashMismatch
+			}
+			if atx.Tx.To() == nil || vm.PrecompiledEVMFileContracts[*atx.Tx.To()] != vm.HostAnnounceTransaction {
+				return errNotHostAnnouncement
+			}
+			var announcement types.HostAnnouncement
+			if err := rlp.DecodeBytes(atx.Tx.Data(), &announcement); err != nil {
+				return err
+			}
+			entry.Announcements = append(entry.Announcements, announcement)
+		}
+		// check if all nodes have been read by VerifyProof
+		if len(reads.reads) != nodeSet.KeyCount() {
+			return errUselessNodes
+		}
+		announcements = append(announcements, entry)
+	}
+	r.Announcements = announcements
+	return nil
+}
+
+// StorageContractReq is the request of the state proof of a storage contract in the state
+// of the block
+type StorageContractReq struct {
+	BHash      common.Hash
+	ContractID common.Hash
+}
+
+// ODR request type for the storage contract state, see LesOdrRequest interface
+type StorageContractRequest light.StorageContractRequest
+
+// GetCost returns the cost of the given ODR request according to the serving
+// peer's cost table (implementation of LesOdrRequest)
+func (r *StorageContractRequest) GetCost(peer *peer) uint64 {
+	return peer.GetRequestCost(GetStorageContractProofsMsg, 1)
+}
+
+// CanSend tells if a certain peer is suitable for serving the given request
+func (r *StorageContractRequest) CanSend(peer *peer) bool {
+	if peer.version < lpv3 {
+		return false
+	}
+	return peer.HasBlock(r.Id.BlockHash, r.Id.BlockNumber, true)
+}
+
+// Request sends an ODR request to the LES network (implementation of LesOdrRequest)
+func (r *StorageContractRequest) Request(reqID uint64, peer *peer) error {
+	peer.Log().Debug("Requesting storage contract proof", "root", r.Id.Root, "id", r.ContractID)
+	req := StorageContractReq{
+		BHash:      r.Id.BlockHash,
+		ContractID: r.ContractID,
+	}
+	return peer.RequestStorageContractProofs(reqID, r.GetCost(peer), []StorageContractReq{req})
+}
+
+// Valid processes an ODR request reply message from the LES network
+// returns true and stores results in memory if the message was a valid reply
+// to the request (implementation of LesOdrRequest)
+func (r *StorageContractRequest) Validate(db ethdb.Database, msg *Msg) error {
+	log.Debug("Validating storage contract proof", "root", r.Id.Root, "id", r.ContractID)
+
+	if msg.MsgType != MsgStorageContractProofs {
+		return errInvalidMessageType
+	}
+	proofs := msg.Obj.(light.NodeList)
+	nodeSet := proofs.NodeSet()
+	reads := &readTraceDB{db: nodeSet}
+
+	// Verify the account of the storage contract against the state root
+	contractAddr := common.BytesToAddress(r.ContractID[12:])
+	blob, _, err := trie.VerifyProof(r.Id.Root, crypto.Keccak256(contractAddr[:]), reads)
+	if err != nil {
+		return fmt.Errorf("merkle proof verification failed: %v", err)
+	}
+	var contract *types.ExpiredStorageContract
+	if blob != nil {
+		var account state.Account
+		if err := rlp.DecodeBytes(blob, &account); err != nil {
+			return err
+		}
+		// Verify the storage contract state against the storage root of the account
+		values := make(map[common.Hash]common.Hash)
+		for _, key := range coinchargemaintenance.ContractStateKeys {
+			enc, _, err := trie.VerifyProof(account.Root, crypto.Keccak256(key[:]), reads)
+			if err != nil {
+				return fmt.Errorf("merkle proof verification failed: %v", err)
+			}
+			if len(enc) > 0 {
+				_, content, _, err := rlp.Split(enc)
+				if err != nil {
+					return err
+				}
+				values[key] = common.BytesToHash(content)
+			}
+		}
+		contract = coinchargemaintenance.StorageContractFromState(r.ContractID, func(key common.Hash) common.Hash {
+			return values[key]
+		})
+	}
+	// check if all nodes have been read by VerifyProof
+	if len(reads.reads) != nodeSet.KeyCount() {
+		return errUselessNodes
+	}
+	r.Contract = contract
+	r.Proof = nodeSet
+	return nil
+}
+
+// StorageTxRequest is the request type for relaying a storage contract transaction to a LES
+// server, which replies the status of the transaction in its transaction pool. The transaction
+// rejected by the server is a valid reply, with the reason returned in the status
+type StorageTxRequest struct {
+	Tx     *types.Transaction
+	Status txStatus
+}
+
+// GetCost returns the cost of the given request according to the serving peer's cost
+// table (implementation of LesOdrRequest)
+func (r *StorageTxRequest) GetCost(peer *peer) uint64 {
+	return peer.GetRequestCost(SendStorageTxMsg, 1)
+}
+
+// CanSend tells if a certain peer is suitable for serving the given request
+func (r *StorageTxRequest) CanSend(peer *peer) bool {
+	return peer.version >= lpv3
+}
+
+// Request sends the request to the LES network (implementation of LesOdrRequest)
+func (r *StorageTxRequest) Request(reqID uint64, peer *peer) error {
+	peer.Log().Debug("Relaying storage contract transaction", "hash", r.Tx.Hash())
+	return peer.SendStorageTxs(reqID, r.GetCost(peer), types.Transactions{r.Tx})
+}
+
+// Valid processes a reply message from the LES network, returns true and stores the
+// status of the transaction if the message was a valid reply to the request
+// (implementation of LesOdrRequest)
+func (r *StorageTxRequest) Validate(db ethdb.Database, msg *Msg) error {
+	log.Debug("Validating storage contract transaction status", "hash", r.Tx.Hash())
+
+	if msg.MsgType != MsgStorageTxStatus {
+		return errInvalidMessageType
+	}
+	stats := msg.Obj.([]txStatus)
+	if len(stats) != 1 {
+		return errInvalidEntryCount
+	}
+	r.Status = stats[0]
+	return nil
+}
+
 // readTraceDB stores the keys of database reads. We use this to check that received node
 // sets contain only the trie nodes necessary to make proofs pass.
 type readTraceDB struct {
@@ -74,6 +74,23 @@ type peer struct {
 	fcServer       *flowcontrol.ServerNode // nil if the peer is client only
 	fcServerParams *flowcontrol.ServerParams
 	fcCosts        requestCostTable
+
+	// storage negotiation message channels
+	clientConfigMsg   chan p2p.Msg
+	clientProbeMsg    chan p2p.Msg
+	clientContractMsg chan p2p.Msg
+	hostContractMsg   chan p2p.Msg
+
+	hostConfigProcessing   chan struct{}
+	hostProbeProcessing    chan struct{}
+	hostContractProcessing chan struct{}
+
+	contractRevisingOrRenewing chan struct{}
+	hostConfigRequesting       chan struct{}
+	hostProbeRequesting        chan struct{}
+
+	// error channel of the storage negotiation
+	errMsg chan error
 }
 
 func newPeer(version int, network uint64, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
@@ -86,6 +103,18 @@ func newPeer(version int, network uint64, p *p2p.Peer, rw p2p.MsgReadWriter) *pe
 		network:     network,
 		id:          fmt.Sprintf("%x", id[:8]),
 		announceChn: make(chan announceData, 20),
+
+		clientConfigMsg:            make(chan p2p.Msg, 1),
+		clientProbeMsg:             make(chan p2p.Msg, 1),
+		clientContractMsg:          make(chan p2p.Msg, 1),
+		hostContractMsg:            make(chan p2p.Msg, 1),
+		hostConfigProcessing:       make(chan struct{}, 1),
+		hostProbeProcessing:        make(chan struct{}, 1),
+		hostContractProcessing:     make(chan struct{}, 1),
+		contractRevisingOrRenewing: make(chan struct{}, 1),
+		hostConfigRequesting:       make(chan struct{}, 1),
+		hostProbeRequesting:        make(chan struct{}, 1),
+		errMsg:                     make(chan error, 1),
 	}
 }
 
@@ -232,6 +261,24 @@ func (p *peer) SendTxStatus(reqID, bv uint64, stats []txStatus) error {
 	return sendResponse(p.rw, TxStatusMsg, reqID, bv, stats)
 }
 
+// SendHostAnnouncements sends the host announcements of a range of blocks, corresponding
+// to the ones requested.
+func (p *peer) SendHostAnnouncements(reqID, bv uint64, announcements []hostAnnouncementsData) error {
+	return sendResponse(p.rw, HostAnnouncementsMsg, reqID, bv, announcements)
+}
+
+// SendStorageContractProofs sends a batch of storage contract state proofs, corresponding
+// to the ones requested.
+func (p *peer) SendStorageContractProofs(reqID, bv uint64, proofs light.NodeList) error {
+	return sendResponse(p.rw, StorageContractProofsMsg, reqID, bv, proofs)
+}
+
+// SendStorageTxStatus sends a batch of storage contract transaction status records,
+// corresponding to the storage contract transactions relayed.
+func (p *peer) SendStorageTxStatus(reqID, bv uint64, stats []txStatus) error {
+	return sendResponse(p.rw, StorageTxStatusMsg, reqID, bv, stats)
+}
+
 // RequestHeadersByHash fetches a batch of blocks' headers corresponding to the
 // specified header query, based on the hash of an origin block.
 func (p *peer) RequestHeadersByHash(reqID, cost uint64, origin common.Hash, amount int, skip int, reverse bool) error {
@@ -272,7 +319,7 @@ func (p *peer) RequestProofs(reqID, cost uint64, reqs []ProofReq) error {
 	switch p.version {
 	case lpv1:
 		return sendRequest(p.rw, GetProofsV1Msg, reqID, cost, reqs)
-	case lpv2:
+	case lpv2, lpv3:
 		return sendRequest(p.rw, GetProofsV2Msg, reqID, cost, reqs)
 	default:
 		panic(nil)
@@ -289,7 +336,7 @@ func (p *peer) RequestHelperTrieProofs(reqID, cost uint64, data interface{}) err
 		}
 		p.Log().Debug("Fetching batch of header proofs", "count", len(reqs))
 		return sendRequest(p.rw, GetHeaderProofsMsg, reqID, cost, reqs)
-	case lpv2:
+	case lpv2, lpv3:
 		reqs, ok := data.([]HelperTrieReq)
 		if !ok {
 			return errInvalidHelpTrieReq
@@ -307,13 +354,34 @@ func (p *peer) RequestTxStatus(reqID, cost uint64, txHashes []common.Hash) error
 	return sendRequest(p.rw, GetTxStatusMsg, reqID, cost, txHashes)
 }
 
+// RequestHostAnnouncements fetches the host announcements of the canonical blocks within
+// the given range from a remote node.
+func (p *peer) RequestHostAnnouncements(reqID, cost, from, to uint64) error {
+	p.Log().Debug("Fetching host announcements", "from", from, "to", to)
+	return sendRequest(p.rw, GetHostAnnouncementsMsg, reqID, cost, &getHostAnnouncementsData{From: from, To: to})
+}
+
+// RequestStorageContractProofs fetches a batch of storage contract state proofs from a
+// remote node.
+func (p *peer) RequestStorageContractProofs(reqID, cost uint64, reqs []StorageContractReq) error {
+	p.Log().Debug("Fetching batch of storage contract proofs", "count", len(reqs))
+	return sendRequest(p.rw, GetStorageContractProofsMsg, reqID, cost, reqs)
+}
+
+// SendStorageTxs relays a batch of storage contract transactions to be added to the
+// remote transaction pool, and requests the status of the transactions.
+func (p *peer) SendStorageTxs(reqID, cost uint64, txs types.Transactions) error {
+	p.Log().Debug("Relaying batch of storage contract transactions", "count", len(txs))
+	return sendRequest(p.rw, SendStorageTxMsg, reqID, cost, txs)
+}
+
 // SendTxStatus sends a batch of transactions to be added to the remote transaction pool.
 func (p *peer) SendTxs(reqID, cost uint64, txs types.Transactions) error {
 	p.Log().Debug("Fetching batch of transactions", "count", len(txs))
 	switch p.version {
 	case lpv1:
 		return p2p.Send(p.rw, SendTxMsg, txs) // old message format does not include reqID
-	case lpv2:
+	case lpv2, lpv3:
 		return sendRequest(p.rw, SendTxV2Msg, reqID, cost, txs)
 	default:
 		panic(nil)
@@ -27,26 +27,32 @@ import (
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/core"
 	"github.com/DxChainNetwork/godx/core/rawdb"
+	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/light"
 	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage"
 )
 
 // Constants to match up protocol versions and messages
 const (
 	lpv1 = 1
 	lpv2 = 2
+	lpv3 = 3
 )
 
 // Supported versions of the les protocol (first is primary)
 var (
-	ClientProtocolVersions    = []uint{lpv2, lpv1}
-	ServerProtocolVersions    = []uint{lpv2, lpv1}
-	AdvertiseProtocolVersions = []uint{lpv2} // clients are searching for the first advertised protocol in the list
+	ClientProtocolVersions    = []uint{lpv3, lpv2, lpv1}
+	ServerProtocolVersions    = []uint{lpv3, lpv2, lpv1}
+	AdvertiseProtocolVersions = []uint{lpv3, lpv2} // clients are searching for the first advertised protocol in the list
 )
 
-// Number of implemented message corresponding to different protocol versions.
-var ProtocolLengths = map[uint]uint64{lpv1: 15, lpv2: 22}
+// Number of implemented message corresponding to different protocol versions. The storage
+// negotiation messages belonging to LPV3 share the message codes of the storage package,
+// from storage.HostConfigRespMsg to storage.HostProbeReqMsg
+var ProtocolLengths = map[uint]uint64{lpv1: 15, lpv2: 22, lpv3: storage.HostProbeReqMsg + 1}
 
 const (
 	NetworkId          = 1
@@ -79,6 +85,13 @@ const (
 	SendTxV2Msg            = 0x13
 	GetTxStatusMsg         = 0x14
 	TxStatusMsg            = 0x15
+	// Protocol messages belonging to LPV3
+	GetHostAnnouncementsMsg     = 0x16
+	HostAnnouncementsMsg        = 0x17
+	GetStorageContractProofsMsg = 0x18
+	StorageContractProofsMsg    = 0x19
+	SendStorageTxMsg            = 0x1a
+	StorageTxStatusMsg          = 0x1b
 )
 
 type errCode int
@@ -219,6 +232,26 @@ type CodeData []struct {
 
 type proofsData [][]rlp.RawValue
 
+// getHostAnnouncementsData represents a host announcement query of the canonical blocks
+// within the range [From, To]
+type getHostAnnouncementsData struct {
+	From, To uint64
+}
+
+// announceTx is the host announcement transaction along with its index in the block
+type announceTx struct {
+	Index uint64
+	Tx    *types.Transaction
+}
+
+// hostAnnouncementsData is the network packet of the host announcements included in a block,
+// along with the merkle proof of the transactions against the transaction root of the block
+type hostAnnouncementsData struct {
+	Hash  common.Hash
+	Txs   []announceTx
+	Proof light.NodeList
+}
+
 type txStatus struct {
 	Status core.TxStatus
 	Lookup *rawdb.TxLookupEntry `rlp:"nil"`
@@ -97,6 +97,7 @@ func NewLesServer(eth *eth.Ethereum, config *eth.Config) (*LesServer, error) {
 
 	srv.chtIndexer.Start(eth.BlockChain())
 	pm.server = srv
+	pm.storageHost = eth.StorageHost()
 
 	srv.defParams = &flowcontrol.ServerParams{
 		BufLimit:    300000000,
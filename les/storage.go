@@ -0,0 +1,189 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package les
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/rawdb"
+	"github.com/DxChainNetwork/godx/core/state"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/light"
+	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage/coinchargemaintenance"
+	"github.com/DxChainNetwork/godx/trie"
+)
+
+var errNotStorageContractTx = errors.New("not a storage contract transaction")
+
+// isStorageContractTx checks if the transaction sent to the address is a storage contract
+// transaction
+func isStorageContractTx(to common.Address) bool {
+	_, ok := vm.PrecompiledEVMFileContracts[to]
+	return ok
+}
+
+// hostAnnouncements collects the host announcements included in the canonical blocks within
+// the range [from, to], along with the merkle proofs of the transactions
+func (pm *ProtocolManager) hostAnnouncements(from, to uint64) []hostAnnouncementsData {
+	var (
+		announcements []hostAnnouncementsData
+		keybuf        = new(bytes.Buffer)
+	)
+	for number := from; number <= to; number++ {
+		hash := rawdb.ReadCanonicalHash(pm.chainDb, number)
+		if hash == (common.Hash{}) {
+			break
+		}
+		body := rawdb.ReadBody(pm.chainDb, hash, number)
+		if body == nil {
+			continue
+		}
+		var txs []announceTx
+		for i, tx := range body.Transactions {
+			if tx.To() != nil && vm.PrecompiledEVMFileContracts[*tx.To()] == vm.HostAnnounceTransaction {
+				txs = append(txs, announceTx{Index: uint64(i), Tx: tx})
+			}
+		}
+		if len(txs) == 0 {
+			continue
+		}
+
+		// Rebuild the transaction trie of the block, and prove the host announcements
+		txTrie := new(trie.Trie)
+		for i, tx := range body.Transactions {
+			data, err := rlp.EncodeToBytes(tx)
+			if err != nil {
+				return announcements
+			}
+			keybuf.Reset()
+			rlp.Encode(keybuf, uint(i))
+			txTrie.Update(common.CopyBytes(keybuf.Bytes()), data)
+		}
+		nodes := light.NewNodeSet()
+		for _, atx := range txs {
+			keybuf.Reset()
+			rlp.Encode(keybuf, uint(atx.Index))
+			txTrie.Prove(keybuf.Bytes(), 0, nodes)
+		}
+		announcements = append(announcements, hostAnnouncementsData{Hash: hash, Txs: txs, Proof: nodes.NodeList()})
+	}
+	return announcements
+}
+
+// proveStorageContract proves the account and the state of the storage contract in the state
+// of the root. The absence of the account is proved if the storage contract does not exist
+func (pm *ProtocolManager) proveStorageContract(db state.Database, root, id common.Hash, nodes *light.NodeSet) error {
+	contractAddr := common.BytesToAddress(id[12:])
+	accountTrie, err := db.OpenTrie(root)
+	if err != nil {
+		return err
+	}
+	if err := accountTrie.Prove(crypto.Keccak256(contractAddr[:]), 0, nodes); err != nil {
+		return err
+	}
+	blob, err := accountTrie.TryGet(contractAddr[:])
+	if err != nil || blob == nil {
+		return err
+	}
+	var account state.Account
+	if err := rlp.DecodeBytes(blob, &account); err != nil {
+		return err
+	}
+	storageTrie, err := db.OpenStorageTrie(crypto.Keccak256Hash(contractAddr[:]), account.Root)
+	if err != nil {
+		return err
+	}
+	for _, key := range coinchargemaintenance.ContractStateKeys {
+		if err := storageTrie.Prove(crypto.Keccak256(key[:]), 0, nodes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetHostAnnouncements retrieves the host announcements included in the canonical blocks
+// within the range [from, to] from the LES servers
+func (s *LightEthereum) GetHostAnnouncements(ctx context.Context, from, to uint64) ([]light.HostAnnouncements, error) {
+	var announcements []light.HostAnnouncements
+	for start := from; start <= to; start += MaxHostAnnouncementFetch {
+		end := start + MaxHostAnnouncementFetch - 1
+		if end > to {
+			end = to
+		}
+		entries, err := light.GetHostAnnouncements(ctx, s.odr, start, end)
+		if err != nil {
+			return nil, err
+		}
+		announcements = append(announcements, entries...)
+	}
+	return announcements, nil
+}
+
+// GetHostAnnouncementWithBlockHash retrieves the host announcements included in the block
+// from the LES servers, along with the block number
+func (s *LightEthereum) GetHostAnnouncementWithBlockHash(blockHash common.Hash) (hostAnnouncements []types.HostAnnouncement, number uint64, errGet error) {
+	header := s.blockchain.GetHeaderByHash(blockHash)
+	if header == nil {
+		return nil, 0, light.ErrNoHeader
+	}
+	number = header.Number.Uint64()
+	entries, err := light.GetHostAnnouncements(context.Background(), s.odr, number, number)
+	if err != nil {
+		return nil, number, err
+	}
+	for _, entry := range entries {
+		if entry.BlockHash == blockHash {
+			hostAnnouncements = append(hostAnnouncements, entry.Announcements...)
+		}
+	}
+	return hostAnnouncements, number, nil
+}
+
+// GetStorageContract retrieves the state of the storage contract at the current head from
+// the LES servers. Nil is returned if the storage contract does not exist
+func (s *LightEthereum) GetStorageContract(ctx context.Context, id common.Hash) (*types.ExpiredStorageContract, error) {
+	return light.GetStorageContract(ctx, s.odr, s.blockchain.CurrentHeader(), id)
+}
+
+// PublicLightStorageAPI provides the storage data retrieved from the LES servers, with which
+// a light node discovers the storage hosts and reads the state of the storage contracts. The
+// storage client of the light node negotiates with the storage hosts through LPV3, while the
+// storage host is not run by a light node
+type PublicLightStorageAPI struct {
+	les *LightEthereum
+}
+
+// NewPublicLightStorageAPI creates the API serving the storage data of the light node
+func NewPublicLightStorageAPI(les *LightEthereum) *PublicLightStorageAPI {
+	return &PublicLightStorageAPI{les: les}
+}
+
+// HostAnnouncements returns the host announcements included in the canonical blocks within
+// the range [from, to]
+func (api *PublicLightStorageAPI) HostAnnouncements(ctx context.Context, from, to uint64) ([]light.HostAnnouncements, error) {
+	if to < from {
+		return nil, fmt.Errorf("invalid block range %d - %d", from, to)
+	}
+	return api.les.GetHostAnnouncements(ctx, from, to)
+}
+
+// StorageContract returns the state of the storage contract at the current head
+func (api *PublicLightStorageAPI) StorageContract(ctx context.Context, id common.Hash) (*types.ExpiredStorageContract, error) {
+	contract, err := api.les.GetStorageContract(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if contract == nil {
+		return nil, fmt.Errorf("storage contract %x not found", id)
+	}
+	return contract, nil
+}
@@ -0,0 +1,440 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package les
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core"
+	"github.com/DxChainNetwork/godx/core/rawdb"
+	"github.com/DxChainNetwork/godx/core/state"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/light"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage/coinchargemaintenance"
+)
+
+// storageTxTo returns the precompiled address of the storage contract transaction type
+func storageTxTo(txType string) common.Address {
+	for addr, typ := range vm.PrecompiledEVMFileContracts {
+		if typ == txType {
+			return addr
+		}
+	}
+	return common.Address{}
+}
+
+// writeCanonicalBlock writes the canonical block with the transactions into the database
+func writeCanonicalBlock(db ethdb.Database, number uint64, txs types.Transactions) common.Hash {
+	header := &types.Header{Number: new(big.Int).SetUint64(number), TxHash: types.DeriveSha(txs)}
+	rawdb.WriteHeader(db, header)
+	rawdb.WriteBody(db, header.Hash(), number, &types.Body{Transactions: txs})
+	rawdb.WriteCanonicalHash(db, header.Hash(), number)
+	return header.Hash()
+}
+
+func TestHostAnnouncementsRequest(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	announcement := types.HostAnnouncement{NetAddress: "enode://host"}
+	data, err := rlp.EncodeToBytes(announcement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	announceTo, createTo := storageTxTo(vm.HostAnnounceTransaction), storageTxTo(vm.ContractCreateTransaction)
+
+	writeCanonicalBlock(db, 0, nil)
+	writeCanonicalBlock(db, 1, types.Transactions{types.NewTransaction(0, createTo, new(big.Int), 0, new(big.Int), nil)})
+	hash := writeCanonicalBlock(db, 2, types.Transactions{
+		types.NewTransaction(1, common.Address{1}, new(big.Int), 0, new(big.Int), nil),
+		types.NewTransaction(2, announceTo, new(big.Int), 0, new(big.Int), data),
+	})
+	writeCanonicalBlock(db, 3, nil)
+
+	// pass the host announcements served through the network encoding
+	pm := &ProtocolManager{chainDb: db}
+	served := func(from, to uint64) []hostAnnouncementsData {
+		enc, err := rlp.EncodeToBytes(pm.hostAnnouncements(from, to))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var resps []hostAnnouncementsData
+		if err := rlp.DecodeBytes(enc, &resps); err != nil {
+			t.Fatal(err)
+		}
+		return resps
+	}
+
+	req := &HostAnnouncementsRequest{From: 0, To: 3}
+	if err := req.Validate(db, &Msg{MsgType: MsgHostAnnouncements, Obj: served(0, 3)}); err != nil {
+		t.Fatalf("failed to validate host announcements: %v", err)
+	}
+	if len(req.Announcements) != 1 {
+		t.Fatalf("expect host announcements of 1 block, got %d", len(req.Announcements))
+	}
+	entry := req.Announcements[0]
+	if entry.BlockHash != hash || entry.BlockNumber != 2 || len(entry.Announcements) != 1 || entry.Announcements[0].NetAddress != announcement.NetAddress {
+		t.Errorf("unexpected host announcements: %+v", entry)
+	}
+
+	// the blocks out of the range requested
+	req = &HostAnnouncementsRequest{From: 3, To: 3}
+	if err := req.Validate(db, &Msg{MsgType: MsgHostAnnouncements, Obj: served(0, 3)}); err != errBlockOutOfRange {
+		t.Errorf("expect error %v, got %v", errBlockOutOfRange, err)
+	}
+
+	// the transaction not matching the proof
+	resps := served(0, 3)
+	resps[0].Txs[0].Tx = types.NewTransaction(3, announceTo, new(big.Int), 0, new(big.Int), data)
+	req = &HostAnnouncementsRequest{From: 0, To: 3}
+	if err := req.Validate(db, &Msg{MsgType: MsgHostAnnouncements, Obj: resps}); err != errTxHashMismatch {
+		t.Errorf("expect error %v, got %v", errTxHashMismatch, err)
+	}
+}
+
+func TestStorageContractRequest(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+
+	id := common.HexToHash("0x0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20")
+	contractAddr := common.BytesToAddress(id[12:])
+	host := common.Address{0x0a}
+	statedb.SetNonce(contractAddr, 1)
+	statedb.SetState(contractAddr, coinchargemaintenance.KeyHostAddress, common.BytesToHash(host.Bytes()))
+	statedb.SetState(contractAddr, coinchargemaintenance.KeyFileSize, common.BigToHash(big.NewInt(100)))
+	statedb.SetState(contractAddr, coinchargemaintenance.KeyWindowEnd, common.BigToHash(big.NewInt(1000)))
+	statedb.SetState(contractAddr, coinchargemaintenance.KeyHostValidProofOutput, common.BigToHash(big.NewInt(20)))
+	root, err := statedb.Commit(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := statedb.Database().TrieDB().Commit(root, false); err != nil {
+		t.Fatal(err)
+	}
+
+	pm := &ProtocolManager{chainDb: db}
+	prove := func(ids ...common.Hash) light.NodeList {
+		nodes := light.NewNodeSet()
+		for _, id := range ids {
+			if err := pm.proveStorageContract(statedb.Database(), root, id, nodes); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return nodes.NodeList()
+	}
+
+	req := &StorageContractRequest{Id: &light.TrieID{Root: root}, ContractID: id}
+	if err := req.Validate(ethdb.NewMemDatabase(), &Msg{MsgType: MsgStorageContractProofs, Obj: prove(id)}); err != nil {
+		t.Fatalf("failed to validate storage contract proof: %v", err)
+	}
+	contract := req.Contract
+	if contract == nil {
+		t.Fatal("storage contract not retrieved")
+	}
+	if contract.ID != id || contract.HostAddress != host || contract.FileSize != 100 || contract.WindowEnd != 1000 || contract.HostValidProofOutput.Int64() != 20 {
+		t.Errorf("unexpected storage contract: %+v", contract)
+	}
+
+	// the absence of the storage contract is proved
+	missing := common.HexToHash("0x01")
+	req = &StorageContractRequest{Id: &light.TrieID{Root: root}, ContractID: missing}
+	if err := req.Validate(ethdb.NewMemDatabase(), &Msg{MsgType: MsgStorageContractProofs, Obj: prove(missing)}); err != nil {
+		t.Fatalf("failed to validate the absence of storage contract: %v", err)
+	}
+	if req.Contract != nil {
+		t.Errorf("expect no storage contract, got %+v", req.Contract)
+	}
+
+	// the nodes not needed by the proof
+	req = &StorageContractRequest{Id: &light.TrieID{Root: root}, ContractID: missing}
+	if err := req.Validate(ethdb.NewMemDatabase(), &Msg{MsgType: MsgStorageContractProofs, Obj: prove(missing, id)}); err != errUselessNodes {
+		t.Errorf("expect error %v, got %v", errUselessNodes, err)
+	}
+}
+
+// testStorageTxPool is the transaction pool of the server, which rejects the transactions
+// with the configured error
+type testStorageTxPool struct {
+	lock sync.Mutex
+	txs  map[common.Hash]*types.Transaction
+	err  error
+}
+
+func newTestStorageTxPool() *testStorageTxPool {
+	return &testStorageTxPool{txs: make(map[common.Hash]*types.Transaction)}
+}
+
+func (pool *testStorageTxPool) AddRemotes(txs []*types.Transaction) []error {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	errs := make([]error, len(txs))
+	for i, tx := range txs {
+		if pool.err != nil {
+			errs[i] = pool.err
+			continue
+		}
+		pool.txs[tx.Hash()] = tx
+	}
+	return errs
+}
+
+func (pool *testStorageTxPool) Status(hashes []common.Hash) []core.TxStatus {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	stats := make([]core.TxStatus, len(hashes))
+	for i, hash := range hashes {
+		if _, ok := pool.txs[hash]; ok {
+			stats[i] = core.TxStatusPending
+		}
+	}
+	return stats
+}
+
+func (pool *testStorageTxPool) setErr(err error) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	pool.err = err
+}
+
+func (pool *testStorageTxPool) contains(hash common.Hash) bool {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	_, ok := pool.txs[hash]
+	return ok
+}
+
+// testTxRelay records the transactions sent by the light transaction pool
+type testTxRelay struct {
+	sent types.Transactions
+}
+
+func (relay *testTxRelay) Send(txs types.Transactions)                             { relay.sent = append(relay.sent, txs...) }
+func (relay *testTxRelay) NewHead(head common.Hash, mined, rollback []common.Hash) {}
+func (relay *testTxRelay) Discard(hashes []common.Hash)                            {}
+
+// newTestHostAnnouncement creates the host announcement of the node key signed by the node key
+func newTestHostAnnouncement(t *testing.T, key *ecdsa.PrivateKey) []byte {
+	ha := types.HostAnnouncement{NetAddress: enode.NewV4(&key.PublicKey, net.ParseIP("127.0.0.1"), 30303, 30303).String()}
+	sig, err := crypto.Sign(ha.RLPHash().Bytes(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ha.Signature = sig
+	data, err := rlp.EncodeToBytes(ha)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestGetHostAnnouncementsLes3(t *testing.T) {
+	hostKey, _ := crypto.GenerateKey()
+	data := newTestHostAnnouncement(t, hostKey)
+	signer := types.NewEIP155Signer(params.AllEthashProtocolChanges.ChainID)
+	announce, err := types.SignTx(types.NewTransaction(0, storageTxTo(vm.HostAnnounceTransaction), new(big.Int), 1000000, new(big.Int), data), signer, testBankKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generator := func(i int, b *core.BlockGen) {
+		if i == 1 {
+			b.AddTx(announce)
+		}
+	}
+
+	env := newOdrTestEnv(t, lpv3, 4, generator, nil, nil)
+	defer env.close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	announcements, err := light.GetHostAnnouncements(ctx, env.odr, 0, 4)
+	if err != nil {
+		t.Fatalf("failed to retrieve host announcements: %v", err)
+	}
+	if len(announcements) != 1 {
+		t.Fatalf("expect host announcements of 1 block, got %d", len(announcements))
+	}
+	entry := announcements[0]
+	block := env.pm.blockchain.GetHeaderByNumber(2)
+	if entry.BlockHash != block.Hash() || entry.BlockNumber != 2 || len(entry.Announcements) != 1 {
+		t.Fatalf("unexpected host announcements: %+v", entry)
+	}
+	if id := enode.PubkeyToIDV4(&hostKey.PublicKey); enode.MustParseV4(entry.Announcements[0].NetAddress).ID() != id {
+		t.Errorf("expect the host announcement of node %x, got %s", id, entry.Announcements[0].NetAddress)
+	}
+}
+
+func TestGetHostAnnouncementsLes2(t *testing.T) {
+	env := newOdrTestEnv(t, lpv2, 1, nil, nil, nil)
+	defer env.close()
+
+	// the les/2 server is not asked for the host announcements
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err := light.GetHostAnnouncements(ctx, env.odr, 0, 1); err == nil {
+		t.Error("expect the host announcements not retrieved from the les/2 server")
+	}
+}
+
+func TestGetStorageContractLes3(t *testing.T) {
+	var (
+		clientKey, _ = crypto.GenerateKey()
+		hostKey, _   = crypto.GenerateKey()
+		client       = crypto.PubkeyToAddress(clientKey.PublicKey)
+		host         = crypto.PubkeyToAddress(hostKey.PublicKey)
+		funds        = big.NewInt(1000000000)
+		signer       = types.NewEIP155Signer(params.AllEthashProtocolChanges.ChainID)
+	)
+	sc := types.StorageContract{
+		FileSize:           1,
+		WindowStart:        10,
+		WindowEnd:          20,
+		ClientCollateral:   types.DxcoinCollateral{DxcoinCharge: types.DxcoinCharge{Address: client, Value: big.NewInt(10)}},
+		HostCollateral:     types.DxcoinCollateral{DxcoinCharge: types.DxcoinCharge{Address: host, Value: big.NewInt(20)}},
+		ValidProofOutputs:  []types.DxcoinCharge{{Address: client, Value: big.NewInt(10)}, {Address: host, Value: big.NewInt(20)}},
+		MissedProofOutputs: []types.DxcoinCharge{{Address: client, Value: big.NewInt(10)}, {Address: host, Value: big.NewInt(20)}},
+		UnlockHash:         types.UnlockConditions{PaymentAddresses: []common.Address{client, host}, SignaturesRequired: 2}.UnlockHash(),
+	}
+	for _, key := range []*ecdsa.PrivateKey{clientKey, hostKey} {
+		sig, err := crypto.Sign(sc.RLPHash().Bytes(), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sc.Signatures = append(sc.Signatures, sig)
+	}
+	data, err := rlp.EncodeToBytes(sc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	create, err := types.SignTx(types.NewTransaction(0, storageTxTo(vm.ContractCreateTransaction), new(big.Int), 1000000, new(big.Int), data), signer, clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generator := func(i int, b *core.BlockGen) {
+		if i == 0 {
+			b.AddTx(create)
+		}
+	}
+	alloc := core.GenesisAlloc{client: {Balance: funds}, host: {Balance: funds}}
+
+	env := newOdrTestEnv(t, lpv3, 2, generator, nil, alloc)
+	defer env.close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	header := env.lpm.blockchain.CurrentHeader()
+	if header.Number.Uint64() != 2 {
+		t.Fatalf("expect the light chain synced to block 2, got %d", header.Number.Uint64())
+	}
+	contract, err := light.GetStorageContract(ctx, env.odr, header, sc.ID())
+	if err != nil {
+		t.Fatalf("failed to retrieve storage contract: %v", err)
+	}
+	if contract == nil {
+		t.Fatal("storage contract not retrieved")
+	}
+	if contract.ID != sc.ID() || contract.ClientAddress != client || contract.HostAddress != host || contract.WindowEnd != sc.WindowEnd || contract.HostValidProofOutput.Int64() != 20 {
+		t.Errorf("unexpected storage contract: %+v", contract)
+	}
+
+	// the absence of the storage contract is proved
+	if contract, err := light.GetStorageContract(ctx, env.odr, header, common.HexToHash("0x01")); err != nil || contract != nil {
+		t.Errorf("expect no storage contract, got %+v, err %v", contract, err)
+	}
+}
+
+func TestSendStorageTxLes3(t *testing.T) {
+	pool := newTestStorageTxPool()
+	env := newOdrTestEnv(t, lpv3, 0, nil, pool, nil)
+	defer env.close()
+
+	signer := types.NewEIP155Signer(params.AllEthashProtocolChanges.ChainID)
+	sign := func(nonce uint64, to common.Address) *types.Transaction {
+		tx, err := types.SignTx(types.NewTransaction(nonce, to, new(big.Int), 1000000, new(big.Int), nil), signer, testBankKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// the storage contract transaction accepted by the server
+	tx := sign(0, storageTxTo(vm.HostAnnounceTransaction))
+	if err := env.odr.RelayStorageTx(ctx, tx); err != nil {
+		t.Fatalf("failed to relay storage contract transaction: %v", err)
+	}
+	if !pool.contains(tx.Hash()) {
+		t.Error("storage contract transaction not added to the server pool")
+	}
+	// the transaction known by the server is not added again
+	pool.setErr(errors.New("already known"))
+	if err := env.odr.RelayStorageTx(ctx, tx); err != nil {
+		t.Errorf("failed to relay known storage contract transaction: %v", err)
+	}
+
+	// the transaction rejected by the server pool
+	rejected := sign(1, storageTxTo(vm.HostAnnounceTransaction))
+	if err := env.odr.RelayStorageTx(ctx, rejected); err == nil || err.Error() != "already known" {
+		t.Errorf("expect error %q, got %v", "already known", err)
+	}
+	pool.setErr(nil)
+
+	// the transaction not sent to the storage contracts
+	plain := sign(1, common.Address{1})
+	if err := env.odr.RelayStorageTx(ctx, plain); err == nil || err.Error() != errNotStorageContractTx.Error() {
+		t.Errorf("expect error %v, got %v", errNotStorageContractTx, err)
+	}
+	if pool.contains(plain.Hash()) {
+		t.Error("non storage contract transaction added to the server pool")
+	}
+}
+
+func TestSendStorageTxBackendLes3(t *testing.T) {
+	pool := newTestStorageTxPool()
+	env := newOdrTestEnv(t, lpv3, 0, nil, pool, nil)
+	defer env.close()
+
+	relay := new(testTxRelay)
+	txPool := light.NewTxPool(params.AllEthashProtocolChanges, env.lpm.blockchain.(*light.LightChain), relay)
+	defer txPool.Stop()
+	backend := &LesApiBackend{eth: &LightEthereum{odr: env.odr, txPool: txPool}}
+
+	signer := types.NewEIP155Signer(params.AllEthashProtocolChanges.ChainID)
+	tx, err := types.SignTx(types.NewTransaction(0, storageTxTo(vm.HostAnnounceTransaction), new(big.Int), 1000000, new(big.Int), nil), signer, testBankKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := backend.SendTx(ctx, tx); err != nil {
+		t.Fatalf("failed to send storage contract transaction: %v", err)
+	}
+	// the storage contract transaction is relayed once through the storage tx message
+	if !pool.contains(tx.Hash()) {
+		t.Error("storage contract transaction not relayed to the server")
+	}
+	if len(relay.sent) != 0 {
+		t.Errorf("expect storage contract transaction not sent by the light pool, got %d sent", len(relay.sent))
+	}
+	if txPool.GetTransaction(tx.Hash()) == nil {
+		t.Error("storage contract transaction not tracked by the light pool")
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package les
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/event"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/rpc"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+var errStorageNotSupported = errors.New("storage negotiation is not supported by the LES server")
+
+// TryToRenewOrRevise is used to check if the contract is currently
+// revising
+func (s *LightEthereum) TryToRenewOrRevise(hostID enode.ID) bool {
+	peer := s.peers.Peer(fmt.Sprintf("%x", hostID.Bytes()[:8]))
+	// if the peer does not exist, meaning currently not revising
+	if peer == nil {
+		return false
+	}
+	return peer.TryToRenewOrRevise()
+}
+
+// RevisionOrRenewingDone indicates the renew finished
+func (s *LightEthereum) RevisionOrRenewingDone(hostID enode.ID) {
+	peer := s.peers.Peer(fmt.Sprintf("%x", hostID.Bytes()[:8]))
+	if peer == nil {
+		return
+	}
+	peer.RevisionOrRenewingDone()
+}
+
+// SetupConnection establishes the static LES connection to the storage host. The storage
+// host must run a LES server supporting LPV3, through which the storage negotiation messages
+// are exchanged
+func (s *LightEthereum) SetupConnection(enodeURL string) (storagePeer storage.Peer, err error) {
+	var destNode *enode.Node
+	if destNode, err = enode.ParseV4(enodeURL); err != nil {
+		err = fmt.Errorf("failed to parse the enodeURL: %s", err.Error())
+		return
+	}
+	peerID := fmt.Sprintf("%x", destNode.ID().Bytes()[:8])
+
+	// the connection is converted to the static connection if already established,
+	// otherwise wait until the LES handshake with the host is done
+	peer := s.peers.Peer(peerID)
+	if peer == nil {
+		s.server.AddPeer(destNode)
+		timeout := time.After(1 * time.Minute)
+		for peer == nil {
+			select {
+			case <-timeout:
+				err = errors.New("set up connection time out")
+				return
+			case <-time.After(500 * time.Millisecond):
+				peer = s.peers.Peer(peerID)
+			}
+		}
+	}
+	if !peer.IsStaticConn() {
+		s.server.SetStatic(destNode)
+	}
+
+	if peer.version < lpv3 {
+		err = errStorageNotSupported
+		return
+	}
+	storagePeer = peer
+	return
+}
+
+// SetStatic will convert the current connection into static connection
+func (s *LightEthereum) SetStatic(node *enode.Node) {
+	s.server.SetStatic(node)
+}
+
+// GetStorageHostSetting will send message to the peer with the corresponded peer ID
+func (s *LightEthereum) GetStorageHostSetting(enodeID enode.ID, enodeURL string, config *storage.HostExtConfig) error {
+	sp, err := s.SetupConnection(enodeURL)
+	if err != nil {
+		return fmt.Errorf("failed to get the storage host configuration: %s", err.Error())
+	}
+
+	// check if the client is currently requesting the host config
+	// once done, release the channel
+	if err := sp.TryRequestHostConfig(); err != nil {
+		return err
+	}
+	defer sp.RequestHostConfigDone()
+
+	if err := sp.RequestStorageHostConfig(); err != nil {
+		return fmt.Errorf("failed to request storage host configuration: %s", err)
+	}
+	msg, err := sp.WaitConfigResp()
+	if err != nil {
+		return fmt.Errorf("received error while waiting for retriving storage host config: %s", err.Error())
+	}
+	if err := msg.Decode(config); err != nil {
+		return fmt.Errorf("error decoding the storage configuration: %s", err.Error())
+	}
+
+	s.CheckAndUpdateConnection(sp.PeerNode())
+	return nil
+}
+
+// ProbeStorageHost will send the probe request to the storage host, and return the time
+// elapsed until the probe response is received
+func (s *LightEthereum) ProbeStorageHost(enodeURL string, req storage.HostProbeRequest) (time.Duration, error) {
+	sp, err := s.SetupConnection(enodeURL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to probe the storage host: %s", err.Error())
+	}
+
+	// only one probe is allowed at a time to the storage host, otherwise
+	// the measurement is not accurate
+	if err := sp.TryRequestHostProbe(); err != nil {
+		return 0, err
+	}
+	defer sp.RequestHostProbeDone()
+
+	start := time.Now()
+	if err := sp.RequestHostProbe(req); err != nil {
+		return 0, fmt.Errorf("failed to send the probe request: %s", err)
+	}
+	msg, err := sp.WaitProbeResp()
+	if err != nil {
+		return 0, fmt.Errorf("received error while waiting for the probe response: %s", err.Error())
+	}
+	elapsed := time.Since(start)
+
+	var resp storage.HostProbeResponse
+	if err := msg.Decode(&resp); err != nil {
+		return 0, fmt.Errorf("error decoding the probe response: %s", err.Error())
+	}
+	if err := resp.Validate(req); err != nil {
+		return 0, err
+	}
+
+	s.CheckAndUpdateConnection(sp.PeerNode())
+	return elapsed, nil
+}
+
+// CheckAndUpdateConnection resets the static connection to the storage host to the dynamic
+// connection, if no contract has been signed with the host and the host is not added by the user
+func (s *LightEthereum) CheckAndUpdateConnection(peerNode *enode.Node) {
+	if s.server.IsAddedByUser(peerNode.ID()) {
+		return
+	}
+	if s.storageClient != nil && s.storageClient.IsContractSignedWithHost(peerNode) {
+		return
+	}
+	_ = s.server.DeleteStatic(peerNode.String())
+}
+
+// SubscribeChainChangeEvent will report the changes of the canonical header chain
+func (s *LightEthereum) SubscribeChainChangeEvent(ch chan<- core.ChainChangeEvent) event.Subscription {
+	return s.blockchain.SubscribeChainChangeEvent(ch)
+}
+
+// GetBlockByHash retrieves the block by hash, the block body is retrieved from the LES servers
+func (s *LightEthereum) GetBlockByHash(blockHash common.Hash) (*types.Block, error) {
+	return s.ApiBackend.GetBlock(context.Background(), blockHash)
+}
+
+// GetBlockByNumber retrieves the block by number, the block body is retrieved from the LES servers
+func (s *LightEthereum) GetBlockByNumber(number uint64) (*types.Block, error) {
+	return s.ApiBackend.BlockByNumber(context.Background(), rpc.BlockNumber(number))
+}
+
+// GetBlockChain returns nil, the light node does not maintain the full block chain. It is
+// only used by the storage host, which is not run by the light node
+func (s *LightEthereum) GetBlockChain() *core.BlockChain {
+	return nil
+}
+
+// GetCurrentBlockHeight returns the number of the current header
+func (s *LightEthereum) GetCurrentBlockHeight() uint64 {
+	return s.blockchain.CurrentHeader().Number.Uint64()
+}
+
+// ChainConfig returns current chain config
+func (s *LightEthereum) ChainConfig() *params.ChainConfig {
+	return s.chainConfig
+}
+
+// CurrentBlock returns the block of the current header
+func (s *LightEthereum) CurrentBlock() *types.Block {
+	return s.ApiBackend.CurrentBlock()
+}
+
+// SendTx sends the transaction to the LES servers, the storage contract transactions are
+// relayed with SendStorageTxMsg
+func (s *LightEthereum) SendTx(ctx context.Context, signedTx *types.Transaction) error {
+	return s.ApiBackend.SendTx(ctx, signedTx)
+}
+
+// SuggestPrice returns the suggested gas price
+func (s *LightEthereum) SuggestPrice(ctx context.Context) (*big.Int, error) {
+	return s.ApiBackend.SuggestPrice(ctx)
+}
+
+// GetPoolNonce returns the nonce of given account
+func (s *LightEthereum) GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error) {
+	return s.ApiBackend.GetPoolNonce(ctx, addr)
+}
+
+// SelfEnodeURL returns the local node's url, used for storage host manager
+func (s *LightEthereum) SelfEnodeURL() string {
+	return s.server.NodeInfo().Enode
+}
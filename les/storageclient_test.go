@@ -0,0 +1,178 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package les
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/consensus/ethash"
+	"github.com/DxChainNetwork/godx/core"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/light"
+	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storagehost"
+)
+
+// newTestStorageHost creates the storage host served by the les server
+func newTestStorageHost(t *testing.T) (*storagehost.StorageHost, func()) {
+	dir, err := ioutil.TempDir("", "les-storagehost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := storagehost.New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.StorageManager.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return h, func() {
+		h.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestStorageHostNegotiationLes3(t *testing.T) {
+	env := newOdrTestEnv(t, lpv3, 1, nil, nil, nil)
+	defer env.close()
+	h, closeHost := newTestStorageHost(t)
+	defer closeHost()
+	env.pm.storageHost = h
+
+	peers := env.lpm.peers.AllPeers()
+	if len(peers) != 1 {
+		t.Fatalf("expect 1 les server, got %d", len(peers))
+	}
+	sp := peers[0]
+
+	// the host config is requested through the les connection
+	if err := sp.RequestStorageHostConfig(); err != nil {
+		t.Fatalf("failed to request the host config: %v", err)
+	}
+	msg, err := sp.WaitConfigResp()
+	if err != nil {
+		t.Fatalf("failed to wait for the host config: %v", err)
+	}
+	var config storage.HostExtConfig
+	if err := msg.Decode(&config); err != nil {
+		t.Fatalf("failed to decode the host config: %v", err)
+	}
+	if config.AcceptingContracts {
+		t.Error("expect the host without the payment address not accepting contracts")
+	}
+
+	// so is the probe
+	req := storage.HostProbeRequest{Payload: make([]byte, 64), RespSize: 128}
+	if err := sp.RequestHostProbe(req); err != nil {
+		t.Fatalf("failed to send the probe request: %v", err)
+	}
+	if msg, err = sp.WaitProbeResp(); err != nil {
+		t.Fatalf("failed to wait for the probe response: %v", err)
+	}
+	var resp storage.HostProbeResponse
+	if err := msg.Decode(&resp); err != nil {
+		t.Fatalf("failed to decode the probe response: %v", err)
+	}
+	if err := resp.Validate(req); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStorageHostNegotiationLes2(t *testing.T) {
+	env := newOdrTestEnv(t, lpv2, 1, nil, nil, nil)
+	defer env.close()
+
+	// the storage negotiation messages are not defined by les/2, the peer is dropped
+	sp := env.lpm.peers.AllPeers()[0]
+	if err := sp.RequestStorageHostConfig(); err != nil {
+		return
+	}
+	timeout := time.After(time.Second)
+	for env.pm.peers.Len() != 0 {
+		select {
+		case <-timeout:
+			t.Fatal("expect the les/2 peer requesting the host config dropped")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// Tests that the light chain reports the headers reverted and applied by the reorg, in the
+// ascending order of the block number
+func TestLightChainChangeEvent(t *testing.T) {
+	var (
+		db     = ethdb.NewMemDatabase()
+		gendb  = ethdb.NewMemDatabase()
+		gspec  = core.Genesis{Config: params.AllEthashProtocolChanges}
+		engine = ethash.NewFaker()
+	)
+	gspec.MustCommit(db)
+	genesis := gspec.MustCommit(gendb)
+	odr := NewLesOdr(db, light.TestClientIndexerConfig, nil)
+	lc, err := light.NewLightChain(odr, gspec.Config, engine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lc.Stop()
+
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, engine, gendb, 3, nil)
+	forks, _ := core.GenerateChain(gspec.Config, blocks[0], engine, gendb, 3, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(common.Address{1})
+	})
+
+	changes := make(chan core.ChainChangeEvent, 2)
+	sub := lc.SubscribeChainChangeEvent(changes)
+	defer sub.Unsubscribe()
+
+	for _, chain := range [][]*types.Block{blocks, forks} {
+		headers := make([]*types.Header, len(chain))
+		for i, block := range chain {
+			headers[i] = block.Header()
+		}
+		if _, err := lc.InsertHeaderChain(headers, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		reverted, applied []*types.Block
+	}{
+		{nil, blocks},
+		{blocks[1:], forks},
+	}
+	for i, test := range tests {
+		var change core.ChainChangeEvent
+		select {
+		case change = <-changes:
+		case <-time.After(time.Second):
+			t.Fatalf("test %d: chain change event not received", i)
+		}
+		if !equalBlockHashes(change.RevertedBlockHashes, test.reverted) {
+			t.Errorf("test %d: reverted blocks mismatch, got %x", i, change.RevertedBlockHashes)
+		}
+		if !equalBlockHashes(change.AppliedBlockHashes, test.applied) {
+			t.Errorf("test %d: applied blocks mismatch, got %x", i, change.AppliedBlockHashes)
+		}
+	}
+}
+
+// equalBlockHashes checks if the hashes are the hashes of the blocks in the same order
+func equalBlockHashes(hashes []common.Hash, blocks []*types.Block) bool {
+	if len(hashes) != len(blocks) {
+		return false
+	}
+	for i, block := range blocks {
+		if hashes[i] != block.Hash() {
+			return false
+		}
+	}
+	return true
+}
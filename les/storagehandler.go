@@ -0,0 +1,182 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package les
+
+import (
+	"errors"
+
+	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/p2p"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storagehost"
+)
+
+var hostHandlers = map[uint64]func(h *storagehost.StorageHost, sp storage.Peer, msg p2p.Msg){
+	storage.ContractCreateReqMsg:   storagehost.ContractCreateHandler,
+	storage.ContractUploadReqMsg:   storagehost.UploadHandler,
+	storage.ContractDownloadReqMsg: storagehost.DownloadHandler,
+}
+
+// isStorageMsg checks if the message is a storage negotiation message, which is exchanged
+// since LPV3
+func isStorageMsg(p *peer, code uint64) bool {
+	return p.version >= lpv3 && code >= storage.HostConfigRespMsg && code <= storage.HostProbeReqMsg
+}
+
+// handleStorageMsg dispatches the storage negotiation message. The responses of the storage host
+// are delivered to the storage client of the light node, and the requests of the storage client
+// are handled by the storage host of the server. The storage negotiation messages are not charged
+// by the flow control
+func (pm *ProtocolManager) handleStorageMsg(p *peer, msg p2p.Msg) error {
+	if msg.Code < storage.HostConfigReqMsg {
+		return pm.clientStorageMsgSchedule(p, msg)
+	}
+	return pm.hostStorageMsgSchedule(p, msg)
+}
+
+func (pm *ProtocolManager) clientStorageMsgSchedule(p *peer, msg p2p.Msg) error {
+	// only the light node acts as the storage client
+	if !pm.lightSync {
+		return errResp(ErrUnexpectedResponse, "storage host response %d", msg.Code)
+	}
+
+	// the config response which is not waited for is discarded, meaning the last
+	// config message handling is not finished yet
+	if msg.Code == storage.HostConfigRespMsg {
+		select {
+		case p.clientConfigMsg <- msg:
+			return nil
+		default:
+			return msg.Discard()
+		}
+	}
+
+	// similarly, the probe response which is not waited for is discarded
+	if msg.Code == storage.HostProbeRespMsg {
+		select {
+		case p.clientProbeMsg <- msg:
+			return nil
+		default:
+			return msg.Discard()
+		}
+	}
+
+	// otherwise, the client should not receive the message before the handling
+	// of the previous message finished
+	select {
+	case p.clientContractMsg <- msg:
+		return nil
+	default:
+		err := errors.New("clientStorageMsgSchedule error: message received before finishing the previous message handling")
+		log.Error("error handling clientContractMsg", "err", err.Error())
+		return err
+	}
+}
+
+func (pm *ProtocolManager) hostStorageMsgSchedule(p *peer, msg p2p.Msg) error {
+	// the storage requests are ignored if the server does not run the storage host,
+	// and the storage client waits until timeout
+	if pm.storageHost == nil {
+		p.Log().Debug("Storage request received without storage host", "code", msg.Code)
+		return msg.Discard()
+	}
+
+	if msg.Code == storage.HostConfigReqMsg {
+		return pm.hostConfigMsgHandler(p, msg)
+	}
+	if msg.Code == storage.HostProbeReqMsg {
+		return pm.hostProbeMsgHandler(p, msg)
+	}
+
+	// the message without the handler is a dialogue message of the ongoing negotiation
+	handler, exists := hostHandlers[msg.Code]
+	if !exists {
+		return pm.contractMsgHandler(p, msg)
+	}
+	return pm.contractReqHandler(handler, p, msg)
+}
+
+func (pm *ProtocolManager) hostConfigMsgHandler(p *peer, configMsg p2p.Msg) error {
+	// only one config request is handled at a time
+	if err := p.HostConfigProcessing(); err != nil {
+		return err
+	}
+
+	// the config request carries no payload
+	if err := configMsg.Discard(); err != nil {
+		p.HostConfigProcessingDone()
+		return err
+	}
+
+	pm.wg.Add(1)
+	go func() {
+		defer pm.wg.Done()
+		defer p.HostConfigProcessingDone()
+		config := pm.storageHost.RetrieveExternalConfig()
+		if err := p.SendStorageHostConfig(config); err != nil {
+			p.TriggerError(err)
+		}
+	}()
+
+	return nil
+}
+
+func (pm *ProtocolManager) hostProbeMsgHandler(p *peer, probeMsg p2p.Msg) error {
+	// similar to the host config request, only one probe request is handled at a time
+	if err := p.HostProbeProcessing(); err != nil {
+		return err
+	}
+
+	var req storage.HostProbeRequest
+	if err := probeMsg.Decode(&req); err != nil {
+		p.HostProbeProcessingDone()
+		return err
+	}
+	resp, err := storage.NewHostProbeResponse(req)
+	if err != nil {
+		p.HostProbeProcessingDone()
+		return err
+	}
+
+	pm.wg.Add(1)
+	go func() {
+		defer pm.wg.Done()
+		defer p.HostProbeProcessingDone()
+		if err := p.SendHostProbeResponse(resp); err != nil {
+			p.TriggerError(err)
+		}
+	}()
+
+	return nil
+}
+
+func (pm *ProtocolManager) contractMsgHandler(p *peer, msg p2p.Msg) error {
+	select {
+	case p.hostContractMsg <- msg:
+	default:
+		err := errors.New("hostStorageMsgSchedule error: message received before finishing the previous message handling")
+		log.Error("error handling hostContractMsg", "err", err.Error())
+		return err
+	}
+	return nil
+}
+
+func (pm *ProtocolManager) contractReqHandler(handler func(h *storagehost.StorageHost, sp storage.Peer, msg p2p.Msg), p *peer, msg p2p.Msg) error {
+	// only one contract request is handled at a time, the client must wait
+	// until timeout if the host is busy
+	if err := p.HostContractProcessing(); err != nil {
+		_ = p.SendHostBusyHandleRequestErr()
+		return err
+	}
+
+	pm.wg.Add(1)
+	go func() {
+		defer pm.wg.Done()
+		defer p.HostContractProcessingDone()
+		handler(pm.storageHost, p, msg)
+	}()
+
+	return nil
+}
@@ -54,8 +54,10 @@ type LightChain struct {
 	chainFeed     event.Feed
 	chainSideFeed event.Feed
 	chainHeadFeed event.Feed
-	scope         event.SubscriptionScope
-	genesisBlock  *types.Block
+	// chainChangeFeed reports the blocks reverted and applied by the change of the canonical head
+	chainChangeFeed event.Feed
+	scope           event.SubscriptionScope
+	genesisBlock    *types.Block
 
 	mu      sync.RWMutex
 	chainmu sync.RWMutex
@@ -318,6 +320,7 @@ func (self *LightChain) Rollback(chain []common.Hash) {
 	self.mu.Lock()
 	defer self.mu.Unlock()
 
+	oldHead := self.hc.CurrentHeader()
 	for i := len(chain) - 1; i >= 0; i-- {
 		hash := chain[i]
 
@@ -325,6 +328,25 @@ func (self *LightChain) Rollback(chain []common.Hash) {
 			self.hc.SetCurrentHeader(self.GetHeader(head.ParentHash, head.Number.Uint64()-1))
 		}
 	}
+	self.postChainChange(oldHead, self.hc.CurrentHeader())
+}
+
+// postChainChange posts the blocks reverted and applied by switching the canonical head from
+// the old head to the new head, in the ascending order of the block number
+func (self *LightChain) postChainChange(oldHead, newHead *types.Header) {
+	var change core.ChainChangeEvent
+	for oldHead != nil && newHead != nil && oldHead.Hash() != newHead.Hash() {
+		if oldHead.Number.Uint64() >= newHead.Number.Uint64() {
+			change.RevertedBlockHashes = append([]common.Hash{oldHead.Hash()}, change.RevertedBlockHashes...)
+			oldHead = self.GetHeader(oldHead.ParentHash, oldHead.Number.Uint64()-1)
+		} else {
+			change.AppliedBlockHashes = append([]common.Hash{newHead.Hash()}, change.AppliedBlockHashes...)
+			newHead = self.GetHeader(newHead.ParentHash, newHead.Number.Uint64()-1)
+		}
+	}
+	if len(change.RevertedBlockHashes) > 0 || len(change.AppliedBlockHashes) > 0 {
+		self.chainChangeFeed.Send(change)
+	}
 }
 
 // postChainEvents iterates over the events generated by a chain insertion and
@@ -371,6 +393,7 @@ func (self *LightChain) InsertHeaderChain(chain []*types.Header, checkFreq int)
 	defer self.wg.Done()
 
 	var events []interface{}
+	oldHead := self.CurrentHeader()
 	whFunc := func(header *types.Header) error {
 		self.mu.Lock()
 		defer self.mu.Unlock()
@@ -390,6 +413,7 @@ func (self *LightChain) InsertHeaderChain(chain []*types.Header, checkFreq int)
 	}
 	i, err := self.hc.InsertHeaderChain(chain, whFunc, start)
 	self.postChainEvents(events)
+	self.postChainChange(oldHead, self.CurrentHeader())
 	return i, err
 }
 
@@ -512,6 +536,12 @@ func (self *LightChain) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Sub
 	return self.scope.Track(self.chainFeed.Subscribe(ch))
 }
 
+// SubscribeChainChangeEvent registers a subscription of ChainChangeEvent, which reports the
+// blocks reverted and applied by the change of the canonical head
+func (self *LightChain) SubscribeChainChangeEvent(ch chan<- core.ChainChangeEvent) event.Subscription {
+	return self.scope.Track(self.chainChangeFeed.Subscribe(ch))
+}
+
 // SubscribeChainHeadEvent registers a subscription of ChainHeadEvent.
 func (self *LightChain) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
 	return self.scope.Track(self.chainHeadFeed.Subscribe(ch))
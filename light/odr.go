@@ -177,3 +177,35 @@ func (req *BloomRequest) StoreResult(db ethdb.Database) {
 		rawdb.WriteBloomBits(db, req.BitIdx, sectionIdx, sectionHead, req.BloomBits[i])
 	}
 }
+
+// HostAnnouncements are the host announcements included in a canonical block
+type HostAnnouncements struct {
+	BlockHash     common.Hash              `json:"blockHash"`
+	BlockNumber   uint64                   `json:"blockNumber"`
+	Announcements []types.HostAnnouncement `json:"announcements"`
+}
+
+// HostAnnouncementsRequest is the ODR request type for retrieving the host announcements
+// included in the canonical blocks within the range [From, To]
+type HostAnnouncementsRequest struct {
+	OdrRequest
+	From, To      uint64
+	Announcements []HostAnnouncements
+}
+
+// StoreResult does nothing, as the host announcements are not stored in local database
+func (req *HostAnnouncementsRequest) StoreResult(db ethdb.Database) {}
+
+// StorageContractRequest is the ODR request type for retrieving the state of a storage contract
+type StorageContractRequest struct {
+	OdrRequest
+	Id         *TrieID // references the state trie the storage contract belongs to
+	ContractID common.Hash
+	Contract   *types.ExpiredStorageContract // nil if the storage contract does not exist
+	Proof      *NodeSet
+}
+
+// StoreResult stores the retrieved data in local database
+func (req *StorageContractRequest) StoreResult(db ethdb.Database) {
+	req.Proof.Store(db)
+}
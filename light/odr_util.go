@@ -232,3 +232,23 @@ func GetBloomBits(ctx context.Context, odr OdrBackend, bitIdx uint, sectionIdxLi
 		return result, nil
 	}
 }
+
+// GetHostAnnouncements retrieves the host announcements included in the canonical blocks
+// within the range [from, to].
+func GetHostAnnouncements(ctx context.Context, odr OdrBackend, from, to uint64) ([]HostAnnouncements, error) {
+	r := &HostAnnouncementsRequest{From: from, To: to}
+	if err := odr.Retrieve(ctx, r); err != nil {
+		return nil, err
+	}
+	return r.Announcements, nil
+}
+
+// GetStorageContract retrieves the state of the storage contract in the state of the given
+// block header. Nil is returned if the storage contract does not exist.
+func GetStorageContract(ctx context.Context, odr OdrBackend, header *types.Header, id common.Hash) (*types.ExpiredStorageContract, error) {
+	r := &StorageContractRequest{Id: StateTrieID(header), ContractID: id}
+	if err := odr.Retrieve(ctx, r); err != nil {
+		return nil, err
+	}
+	return r.Contract, nil
+}
@@ -425,6 +425,18 @@ func (self *TxPool) add(ctx context.Context, tx *types.Transaction) error {
 // Add adds a transaction to the pool if valid and passes it to the tx relay
 // backend
 func (self *TxPool) Add(ctx context.Context, tx *types.Transaction) error {
+	return self.addTx(ctx, tx, true)
+}
+
+// AddRelayed adds a transaction already relayed to the servers by the caller to
+// the pool if valid, without passing it to the tx relay backend again
+func (self *TxPool) AddRelayed(ctx context.Context, tx *types.Transaction) error {
+	return self.addTx(ctx, tx, false)
+}
+
+// addTx adds a transaction to the pool if valid, and passes it to the tx relay
+// backend if requested
+func (self *TxPool) addTx(ctx context.Context, tx *types.Transaction, relay bool) error {
 	self.mu.Lock()
 	defer self.mu.Unlock()
 
@@ -437,7 +449,9 @@ func (self *TxPool) Add(ctx context.Context, tx *types.Transaction) error {
 		return err
 	}
 	//fmt.Println("Send", tx.Hash())
-	self.relay.Send(types.Transactions{tx})
+	if relay {
+		self.relay.Send(types.Transactions{tx})
+	}
 
 	self.chainDb.Put(tx.Hash().Bytes(), data)
 	return nil
@@ -920,6 +920,10 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 		w.updateSnapshot()
 		return
 	}
+	// Commit the storage proofs whose window is closing first
+	if w.commitStorageProofs(pending, interrupt) {
+		return
+	}
 	// Split the pending transactions into locals and remotes
 	localTxs, remoteTxs := make(map[common.Address]types.Transactions), pending
 	for _, account := range w.eth.TxPool().Locals() {
@@ -943,6 +947,50 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 	w.commit(uncles, w.fullTaskHook, true, tstart)
 }
 
+// commitStorageProofs commits the storage proofs whose window is closing, within the share of the
+// block gas reserved for them. Only the proofs and the transactions of lower nonces they depend
+// on are committed within the reserved gas, the transactions of these accounts left are
+// committed along with the other pending transactions afterwards.
+func (w *worker) commitStorageProofs(pending map[common.Address]types.Transactions, interrupt *int32) bool {
+	proofTxs := closingStorageProofs(w.config, w.current.state, pending, w.current.header.Number.Uint64())
+	if len(proofTxs) == 0 {
+		return false
+	}
+
+	if w.current.gasPool == nil {
+		w.current.gasPool = new(core.GasPool).AddGas(w.current.header.GasLimit)
+	}
+	reserved := w.current.header.GasLimit * params.StorageProofReservedGasRate / 100
+	if available := w.current.gasPool.Gas(); reserved > available {
+		reserved = available
+	}
+	remaining := w.current.gasPool.Gas() - reserved
+
+	// commit within the reserved gas, and return the gas left to the block afterwards
+	w.current.gasPool = new(core.GasPool).AddGas(reserved)
+	txs := types.NewTransactionsByPriceAndNonce(w.current.signer, proofTxs)
+	interrupted := w.commitTransactions(txs, w.coinbase, interrupt)
+	w.current.gasPool.AddGas(remaining)
+	return interrupted
+}
+
+// closingStorageProofs returns the pending transactions of each account up to its last storage
+// proof whose window is closing at the height, which are the proofs and the transactions of lower
+// nonces they depend on. The accounts without closing proofs are left out.
+func closingStorageProofs(config *params.ChainConfig, statedb *state.StateDB, pending map[common.Address]types.Transactions, height uint64) map[common.Address]types.Transactions {
+	proofTxs := make(map[common.Address]types.Transactions)
+	for account, txs := range pending {
+		// the pending transactions are sorted by nonce, so the last closing proof bounds them
+		for i := len(txs) - 1; i >= 0; i-- {
+			if core.IsClosingStorageProof(config, statedb, txs[i], height) {
+				proofTxs[account] = txs[:i+1]
+				break
+			}
+		}
+	}
+	return proofTxs
+}
+
 // commit runs any post-transaction state modifications, assembles the final block
 // and commits new work if consensus engine is running.
 func (w *worker) commit(uncles []*types.Header, interval func(), update bool, start time.Time) error {
@@ -956,7 +1004,7 @@ func (w *worker) commit(uncles []*types.Header, interval func(), update bool, st
 
 	// maintenance missed storage proof
 	height := w.current.header.Number.Uint64()
-	coinchargemaintenance.MaintenanceMissedProof(height, s)
+	coinchargemaintenance.MaintenanceMissedProof(height, s, w.config.StorageFork(w.current.header.Number))
 
 	block, err := w.engine.Finalize(w.chain, w.current.header, s, w.current.txs, uncles, w.current.receipts)
 	if err != nil {
@@ -17,15 +17,19 @@
 package miner
 
 import (
+	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/consensus"
 	"github.com/DxChainNetwork/godx/consensus/ethash"
 	"github.com/DxChainNetwork/godx/core"
+	"github.com/DxChainNetwork/godx/core/state"
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/core/vm"
 	"github.com/DxChainNetwork/godx/crypto"
 	"github.com/DxChainNetwork/godx/ethdb"
 	"github.com/DxChainNetwork/godx/event"
 	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage/coinchargemaintenance"
 	"math/big"
 	"testing"
 	"time"
@@ -427,3 +431,53 @@ func testAdjustInterval(t *testing.T, chainConfig *params.ChainConfig, engine co
 		t.Error("interval reset timeout")
 	}
 }
+
+func TestClosingStorageProofs(t *testing.T) {
+	var proofAddr common.Address
+	for addr, typ := range vm.PrecompiledEVMFileContracts {
+		if typ == vm.StorageProofTransaction {
+			proofAddr = addr
+		}
+	}
+	closing, open := common.HexToHash("0x01"), common.HexToHash("0x02")
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	for id, windowEnd := range map[common.Hash]int64{closing: 105, open: 1000} {
+		contractAddr := common.BytesToAddress(id[12:])
+		statedb.CreateAccount(contractAddr)
+		statedb.SetNonce(contractAddr, 1)
+		statedb.SetState(contractAddr, coinchargemaintenance.KeyWindowEnd, common.BigToHash(big.NewInt(windowEnd)))
+	}
+	proof := func(nonce uint64, id common.Hash) *types.Transaction {
+		data, _ := rlp.EncodeToBytes(types.StorageProof{ParentID: id})
+		return types.NewTransaction(nonce, proofAddr, new(big.Int), 100000, new(big.Int), data)
+	}
+	transfer := func(nonce uint64) *types.Transaction {
+		return types.NewTransaction(nonce, common.Address{1}, new(big.Int), 21000, new(big.Int), nil)
+	}
+
+	hostA, hostB, hostC := common.Address{0xa}, common.Address{0xb}, common.Address{0xc}
+	pending := map[common.Address]types.Transactions{
+		hostA: {transfer(0), proof(1, closing), transfer(2), transfer(3)},
+		hostB: {proof(0, open), transfer(1)},
+		hostC: {proof(0, closing)},
+	}
+	proofTxs := closingStorageProofs(params.TestChainConfig, statedb, pending, 100)
+
+	// only the closing proofs and the transactions of lower nonces are committed first
+	expect := map[common.Address]int{hostA: 2, hostC: 1}
+	if len(proofTxs) != len(expect) {
+		t.Fatalf("expect closing proofs of %d accounts, got %d", len(expect), len(proofTxs))
+	}
+	for account, n := range expect {
+		txs := proofTxs[account]
+		if len(txs) != n {
+			t.Errorf("account %x: expect %d transactions, got %d", account, n, len(txs))
+			continue
+		}
+		for i, tx := range txs {
+			if tx != pending[account][i] {
+				t.Errorf("account %x: transaction %d mismatched", account, i)
+			}
+		}
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"sync"
 
 	"github.com/DxChainNetwork/godx/accounts"
+	"github.com/DxChainNetwork/godx/accounts/external"
 	"github.com/DxChainNetwork/godx/accounts/keystore"
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/crypto"
@@ -88,6 +89,10 @@ type Config struct {
 	// NoUSB disables hardware wallet monitoring and connectivity.
 	NoUSB bool `toml:",omitempty"`
 
+	// ExternalSigner is the IPC endpoint of the external signer process. If set, the accounts
+	// held by the external signer are available to sign, without any key unlocked in the node.
+	ExternalSigner string `toml:",omitempty"`
+
 	// IPCPath is the requested location to place the IPC endpoint. If the path is
 	// a simple file name, it is placed inside the data directory (or on the root
 	// pipe path on Windows), whereas if it's a resolvable path name (absolute or
@@ -436,6 +441,13 @@ func makeAccountManager(conf *Config) (*accounts.Manager, string, error) {
 	}
 	if !conf.NoUSB {
 	}
+	if conf.ExternalSigner != "" {
+		signer, err := external.NewExternalBackend(conf.ExternalSigner)
+		if err != nil {
+			return nil, "", err
+		}
+		backends = append(backends, signer)
+	}
 	return accounts.NewManager(backends...), ephemeral, nil
 }
 
@@ -43,6 +43,7 @@ var (
 		EIP158Block:         big.NewInt(0),
 		ByzantiumBlock:      big.NewInt(0),
 		ConstantinopleBlock: nil,
+		Storage:             DefaultStorageConfig,
 		Ethash:              new(EthashConfig),
 	}
 
@@ -67,6 +68,7 @@ var (
 		EIP158Block:         big.NewInt(10),
 		ByzantiumBlock:      big.NewInt(1700000),
 		ConstantinopleBlock: big.NewInt(4230000),
+		Storage:             DefaultStorageConfig,
 		Ethash:              new(EthashConfig),
 	}
 
@@ -111,17 +113,56 @@ var (
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil}
+	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, DevStorageConfig, new(EthashConfig), nil}
 
 	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
 	// and accepted by the Ethereum core developers into the Clique consensus.
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}}
+	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, DevStorageConfig, nil, &CliqueConfig{Period: 0, Epoch: 30000}}
 
-	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil}
+	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, DevStorageConfig, new(EthashConfig), nil}
 	TestRules       = TestChainConfig.Rules(new(big.Int))
+
+	// DefaultStorageConfig is the original storage protocol active from genesis, which is used
+	// by the main networks and the chains not specifying the storage config. None of the storage
+	// protocol upgrades is scheduled, which must be activated at a real block height.
+	DefaultStorageConfig = &StorageConfig{
+		Forks: []StorageFork{
+			{
+				Block:        big.NewInt(0),
+				SectorSize:   1 << 22,
+				SegmentSize:  64,
+				ProofVersion: StorageProofV0,
+			},
+		},
+	}
+
+	// DevStorageConfig is the storage protocol used by the development and test chains, which
+	// activates all the storage protocol upgrades from genesis.
+	DevStorageConfig = &StorageConfig{
+		Forks: []StorageFork{
+			{
+				Block:                 big.NewInt(0),
+				SectorSize:            1 << 22,
+				SegmentSize:           64,
+				ProofVersion:          StorageProofV1,
+				ContractCalls:         true,
+				ExtendedAnnouncements: true,
+			},
+		},
+	}
+)
+
+const (
+	// StorageProofV0 is the storage proof version selecting the single segment to prove by the
+	// hash of the block before the proof window start and the storage contract id
+	StorageProofV0 uint64 = 0
+
+	// StorageProofV1 is the storage proof version selecting StorageProofSegments segments to
+	// prove with one shared merkle multiproof
+	StorageProofV1 uint64 = 1
 )
 
 // TrustedCheckpoint represents a set of post-processed trie roots (CHT and
@@ -160,6 +201,9 @@ type ChainConfig struct {
 	ConstantinopleBlock *big.Int `json:"constantinopleBlock,omitempty"` // Constantinople switch block (nil = no fork, 0 = already activated)
 	EWASMBlock          *big.Int `json:"ewasmBlock,omitempty"`          // EWASM switch block (nil = no fork, 0 = already activated)
 
+	// Storage protocol forks (nil = DefaultStorageConfig, no storage protocol upgrades)
+	Storage *StorageConfig `json:"storage,omitempty"`
+
 	// Various consensus engines
 	Ethash *EthashConfig `json:"ethash,omitempty"`
 	Clique *CliqueConfig `json:"clique,omitempty"`
@@ -184,6 +228,48 @@ func (c *CliqueConfig) String() string {
 	return "clique"
 }
 
+// StorageConfig is the storage protocol configs, which consists of the storage forks ordered by
+// the activation block. The storage protocol is not active before the first fork.
+type StorageConfig struct {
+	Forks []StorageFork `json:"forks"`
+}
+
+// StorageFork is the storage protocol parameters activated from the fork block
+type StorageFork struct {
+	Block        *big.Int `json:"block"`        // Fork activation block
+	SectorSize   uint64   `json:"sectorSize"`   // Size of the sector stored by the storage hosts
+	SegmentSize  uint64   `json:"segmentSize"`  // Size of the segment proved in the storage proof
+	ProofVersion uint64   `json:"proofVersion"` // Version of the storage proof segment selection
+
+	// PruneContractState clears the state of the storage contracts once settled, the storage
+	// contracts are archived in the local database instead
+	PruneContractState bool `json:"pruneContractState,omitempty"`
+
+	// ContractCalls makes the storage contract precompiles callable from the smart contracts,
+	// which must be activated by a dedicated fork on the existing chains
+	ContractCalls bool `json:"contractCalls,omitempty"`
+
+	// ExtendedAnnouncements accepts the host announcements with the alternative addresses, and
+	// the ones signed by the announcement key certified by the node key
+	ExtendedAnnouncements bool `json:"extendedAnnouncements,omitempty"`
+}
+
+// String implements the stringer interface, returning the storage fork blocks.
+func (c *StorageConfig) String() string {
+	blocks := make([]string, 0, len(c.Forks))
+	for _, fork := range c.Forks {
+		blocks = append(blocks, fork.Block.String())
+	}
+	return fmt.Sprintf("storage%v", blocks)
+}
+
+// equalParams returns whether the two forks have the same parameters, regardless of the blocks
+func (f *StorageFork) equalParams(other *StorageFork) bool {
+	return f.SectorSize == other.SectorSize && f.SegmentSize == other.SegmentSize && f.ProofVersion == other.ProofVersion &&
+		f.PruneContractState == other.PruneContractState && f.ContractCalls == other.ContractCalls &&
+		f.ExtendedAnnouncements == other.ExtendedAnnouncements
+}
+
 // String implements the fmt.Stringer interface.
 func (c *ChainConfig) String() string {
 	var engine interface{}
@@ -195,7 +281,7 @@ func (c *ChainConfig) String() string {
 	default:
 		engine = "unknown"
 	}
-	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v Engine: %v}",
+	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v Storage: %v Engine: %v}",
 		c.ChainID,
 		c.HomesteadBlock,
 		c.DAOForkBlock,
@@ -205,6 +291,7 @@ func (c *ChainConfig) String() string {
 		c.EIP158Block,
 		c.ByzantiumBlock,
 		c.ConstantinopleBlock,
+		c.storageConfig(),
 		engine,
 	)
 }
@@ -249,6 +336,43 @@ func (c *ChainConfig) IsEWASM(num *big.Int) bool {
 	return isForked(c.EWASMBlock, num)
 }
 
+// IsStorage returns whether num is either equal to the first storage fork block or greater.
+func (c *ChainConfig) IsStorage(num *big.Int) bool {
+	return c.StorageFork(num) != nil
+}
+
+// IsStorageContractCalls returns whether the storage contract precompiles are callable from the
+// smart contracts at num.
+func (c *ChainConfig) IsStorageContractCalls(num *big.Int) bool {
+	fork := c.StorageFork(num)
+	return fork != nil && fork.ContractCalls
+}
+
+// StorageFork returns the storage protocol parameters active at num, nil if the storage protocol
+// is not active.
+//
+// The returned StorageFork's fields shouldn't, under any circumstances, be changed.
+func (c *ChainConfig) StorageFork(num *big.Int) *StorageFork {
+	var active *StorageFork
+	forks := c.storageConfig().Forks
+	for i := range forks {
+		if !isForked(forks[i].Block, num) {
+			break
+		}
+		active = &forks[i]
+	}
+	return active
+}
+
+// storageConfig returns the storage config of the chain, which is DefaultStorageConfig if the
+// storage config is not specified
+func (c *ChainConfig) storageConfig() *StorageConfig {
+	if c.Storage == nil {
+		return DefaultStorageConfig
+	}
+	return c.Storage
+}
+
 // GasTable returns the gas table corresponding to the current phase (homestead or homestead reprice).
 //
 // The returned GasTable's fields shouldn't, under any circumstances, be changed.
@@ -317,6 +441,37 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.EWASMBlock, newcfg.EWASMBlock, head) {
 		return newCompatError("ewasm fork block", c.EWASMBlock, newcfg.EWASMBlock)
 	}
+	return checkStorageCompatible(c.storageConfig(), newcfg.storageConfig(), head)
+}
+
+// checkStorageCompatible checks whether the storage forks already activated at head are
+// rescheduled or have the parameters changed
+func checkStorageCompatible(stored, newcfg *StorageConfig, head *big.Int) *ConfigCompatError {
+	forks := len(stored.Forks)
+	if len(newcfg.Forks) > forks {
+		forks = len(newcfg.Forks)
+	}
+	for i := 0; i < forks; i++ {
+		var s1, s2 *StorageFork
+		if i < len(stored.Forks) {
+			s1 = &stored.Forks[i]
+		}
+		if i < len(newcfg.Forks) {
+			s2 = &newcfg.Forks[i]
+		}
+		switch {
+		case s1 == nil && isForked(s2.Block, head):
+			return newCompatError(fmt.Sprintf("storage fork %d block", i), nil, s2.Block)
+		case s2 == nil && isForked(s1.Block, head):
+			return newCompatError(fmt.Sprintf("storage fork %d block", i), s1.Block, nil)
+		case s1 == nil || s2 == nil:
+			continue
+		case isForkIncompatible(s1.Block, s2.Block, head):
+			return newCompatError(fmt.Sprintf("storage fork %d block", i), s1.Block, s2.Block)
+		case isForked(s1.Block, head) && !s1.equalParams(s2):
+			return newCompatError(fmt.Sprintf("storage fork %d parameters", i), s1.Block, s2.Block)
+		}
+	}
 	return nil
 }
 
@@ -384,6 +539,7 @@ type Rules struct {
 	ChainID                                   *big.Int
 	IsHomestead, IsEIP150, IsEIP155, IsEIP158 bool
 	IsByzantium, IsConstantinople             bool
+	IsStorage, IsStorageContractCalls         bool
 }
 
 // Rules ensures c's ChainID is not nil.
@@ -393,12 +549,14 @@ func (c *ChainConfig) Rules(num *big.Int) Rules {
 		chainID = new(big.Int)
 	}
 	return Rules{
-		ChainID:          new(big.Int).Set(chainID),
-		IsHomestead:      c.IsHomestead(num),
-		IsEIP150:         c.IsEIP150(num),
-		IsEIP155:         c.IsEIP155(num),
-		IsEIP158:         c.IsEIP158(num),
-		IsByzantium:      c.IsByzantium(num),
-		IsConstantinople: c.IsConstantinople(num),
+		ChainID:                new(big.Int).Set(chainID),
+		IsHomestead:            c.IsHomestead(num),
+		IsEIP150:               c.IsEIP150(num),
+		IsEIP155:               c.IsEIP155(num),
+		IsEIP158:               c.IsEIP158(num),
+		IsByzantium:            c.IsByzantium(num),
+		IsConstantinople:       c.IsConstantinople(num),
+		IsStorage:              c.IsStorage(num),
+		IsStorageContractCalls: c.IsStorageContractCalls(num),
 	}
 }
@@ -32,6 +32,13 @@ const (
 	// considered probably final and its rotated bits are calculated.
 	BloomConfirms = 256
 
+	// HostReputationBlocks is the number of blocks a single host reputation section contains
+	HostReputationBlocks uint64 = 1024
+
+	// HostReputationConfirms is the number of confirmation blocks before a host reputation
+	// section is considered probably final and indexed.
+	HostReputationConfirms = 64
+
 	// CHTFrequencyClient is the block frequency for creating CHTs on the client side.
 	CHTFrequencyClient = 32768
 
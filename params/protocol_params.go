@@ -90,6 +90,16 @@ const (
 	CheckFileGas            uint64 = 10000 // the gas for checking storage contract content
 	CheckMultiSignaturesGas uint64 = 3000  // the gas for verifying multi-signature
 	DecodeGas               uint64 = 1000  // the gas for rlp decoding
+	ReadStorageContractGas  uint64 = 2000  // the gas for reading the storage contract status
+	StorageProofSegmentGas  uint64 = 600   // the gas for verifying each segment of the multi segment storage proof
+	StorageProofHashGas     uint64 = 100   // the gas for each hash of the multi segment storage proof
+
+	// StorageProofSegments is the number of segments proved by the multi segment storage proof
+	StorageProofSegments uint64 = 16
+
+	// storage proof priority in the block
+	StorageProofClosingBlocks   uint64 = 20 // the number of blocks before the window end that the proof window is closing
+	StorageProofReservedGasRate uint64 = 10 // the percentage of the block gas reserved for the proofs whose window is closing
 )
 
 var (
@@ -11,6 +11,8 @@ import (
 
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/core/state"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/params"
 )
 
 var (
@@ -67,13 +69,34 @@ var (
 	KeyHostMissedProofOutput = common.BytesToHash([]byte("HostMissedProofOutput"))
 )
 
-// MaintenanceMissedProof maintains missed storage proof
-func MaintenanceMissedProof(height uint64, state *state.StateDB) {
+// ContractStateKeys are the keys of the storage contract state stored in the contract account
+var ContractStateKeys = []common.Hash{
+	KeyClientCollateral, KeyHostCollateral, KeyFileSize, KeyUnlockHash, KeyFileMerkleRoot,
+	KeyRevisionNumber, KeyWindowStart, KeyWindowEnd, KeyClientAddress, KeyHostAddress,
+	KeyClientValidProofOutput, KeyClientMissedProofOutput, KeyHostValidProofOutput, KeyHostMissedProofOutput,
+}
+
+// ContractStateDB is the state database in which the storage contracts are settled
+type ContractStateDB interface {
+	GetState(common.Address, common.Hash) common.Hash
+	SetState(common.Address, common.Hash, common.Hash)
+	GetBalance(common.Address) *big.Int
+	SubBalance(common.Address, *big.Int)
+	SetNonce(common.Address, uint64)
+	ArchiveStorageContract(*types.ExpiredStorageContract)
+}
+
+// MaintenanceMissedProof maintains missed storage proof. If the storage fork prunes the contract
+// state, the state of the storage contracts expired and the status account are cleared
+func MaintenanceMissedProof(height uint64, state *state.StateDB, fork *params.StorageFork) {
 	windowEndStr := strconv.FormatUint(height, 10)
 	statusAddr := common.BytesToAddress([]byte(StrPrefixExpSC + windowEndStr))
+	prune := fork != nil && fork.PruneContractState
 
 	if state.Exist(statusAddr) {
+		var statusKeys []common.Hash
 		state.ForEachStorage(statusAddr, func(key, value common.Hash) bool {
+			statusKeys = append(statusKeys, key)
 			flag := value.Bytes()[11:12]
 			if bytes.Equal(flag, NotProofedStatus) {
 				contractAddr := common.BytesToAddress(value[12:])
@@ -93,11 +116,73 @@ func MaintenanceMissedProof(height uint64, state *state.StateDB) {
 				// deduct the sum missed output from contract account
 				totalValue := new(big.Int).Add(clientMpo, hostMpo)
 				state.SubBalance(contractAddr, totalValue)
+
+				if prune {
+					PruneStorageContract(state, key, contractAddr, false)
+				}
 			}
 			return true
 		})
 
+		if prune {
+			for _, key := range statusKeys {
+				state.SetState(statusAddr, key, common.Hash{})
+			}
+		}
+
 		// mark the statusAddr as empty account, that will be deleted by stateDB
 		state.SetNonce(statusAddr, 0)
 	}
 }
+
+// PruneStorageContract clears the state and the balance of the settled storage contract from the
+// contract account, so that the contract account is removed from the state trie. The state of the
+// storage contract is archived by the state database.
+//
+// The balance left in the contract account after the proof outputs are paid out is burnt. It is
+// the collateral and the payment forfeited by a missed proof, which neither the client nor the
+// host is entitled to: paying it to the client would reward the client for the host failure, and
+// paying it to the host would reward the failure itself. Without pruning, the balance is locked in
+// the contract account which no key controls, thus burning it does not change any spendable balance
+func PruneStorageContract(state ContractStateDB, id common.Hash, contractAddr common.Address, proved bool) {
+	contract := StorageContractFromState(id, func(key common.Hash) common.Hash {
+		return state.GetState(contractAddr, key)
+	})
+	contract.Proved = proved
+
+	for _, key := range ContractStateKeys {
+		state.SetState(contractAddr, key, common.Hash{})
+	}
+	state.SubBalance(contractAddr, state.GetBalance(contractAddr))
+	state.SetNonce(contractAddr, 0)
+	state.ArchiveStorageContract(contract)
+}
+
+// StorageContractFromState assembles the storage contract of the id from the contract state,
+// which is read by the getter of the state keys
+func StorageContractFromState(id common.Hash, get func(key common.Hash) common.Hash) *types.ExpiredStorageContract {
+	getBig := func(key common.Hash) *big.Int {
+		return new(big.Int).SetBytes(get(key).Bytes())
+	}
+	getUint64 := func(key common.Hash) uint64 {
+		return getBig(key).Uint64()
+	}
+
+	return &types.ExpiredStorageContract{
+		ID:                      id,
+		ClientAddress:           common.BytesToAddress(get(KeyClientAddress).Bytes()),
+		HostAddress:             common.BytesToAddress(get(KeyHostAddress).Bytes()),
+		ClientCollateral:        getBig(KeyClientCollateral),
+		HostCollateral:          getBig(KeyHostCollateral),
+		FileSize:                getUint64(KeyFileSize),
+		FileMerkleRoot:          get(KeyFileMerkleRoot),
+		UnlockHash:              get(KeyUnlockHash),
+		RevisionNumber:          getUint64(KeyRevisionNumber),
+		WindowStart:             getUint64(KeyWindowStart),
+		WindowEnd:               getUint64(KeyWindowEnd),
+		ClientValidProofOutput:  getBig(KeyClientValidProofOutput),
+		HostValidProofOutput:    getBig(KeyHostValidProofOutput),
+		ClientMissedProofOutput: getBig(KeyClientMissedProofOutput),
+		HostMissedProofOutput:   getBig(KeyHostMissedProofOutput),
+	}
+}
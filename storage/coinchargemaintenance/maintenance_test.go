@@ -15,6 +15,7 @@ import (
 	"github.com/DxChainNetwork/godx/core/state"
 	"github.com/DxChainNetwork/godx/crypto"
 	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/params"
 )
 
 var (
@@ -55,7 +56,7 @@ func TestMaintenanceMissedProof(t *testing.T) {
 	// mock write missed storage proof
 	contractAddr := mockMissedStorageProof(1000, stateDB, prvAndAddresses)
 
-	MaintenanceMissedProof(1000, stateDB)
+	MaintenanceMissedProof(1000, stateDB, nil)
 
 	// check balance
 	afterContractBal := stateDB.GetBalance(contractAddr)
@@ -74,6 +75,79 @@ func TestMaintenanceMissedProof(t *testing.T) {
 	}
 }
 
+func TestMaintenanceMissedProof_PruneContractState(t *testing.T) {
+	prvAndAddresses, err := mockClientAndHostAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientAddress := prvAndAddresses[0].Address
+	hostAddress := prvAndAddresses[1].Address
+
+	accounts := mockAccountAlloc([]common.Address{clientAddress, hostAddress})
+	stateDB := mockState(ethdb.NewMemDatabase(), accounts)
+	contractAddr := mockMissedStorageProof(1000, stateDB, prvAndAddresses)
+	statusAddr := common.BytesToAddress([]byte(StrPrefixExpSC + "1000"))
+
+	MaintenanceMissedProof(1000, stateDB, &params.StorageFork{PruneContractState: true})
+
+	// the missed proof outputs are still paid out
+	if bal := stateDB.GetBalance(hostAddress); bal.Int64() != clientAndHostOriginBal.Int64()+hostMpo.Int64() {
+		t.Errorf("failed to effect host missed proof, wanted %d, getted %d", clientAndHostOriginBal.Int64()+hostMpo.Int64(), bal.Int64())
+	}
+
+	// the contract state is archived and cleared
+	expired := stateDB.ExpiredStorageContracts()
+	if len(expired) != 1 {
+		t.Fatalf("expect 1 storage contract archived, got %d", len(expired))
+	}
+	if contract := expired[0]; common.BytesToAddress(contract.ID[12:]) != contractAddr || contract.Proved ||
+		contract.HostAddress != hostAddress || contract.HostMissedProofOutput.Cmp(hostMpo) != 0 {
+		t.Errorf("unexpected storage contract archived: %+v", contract)
+	}
+	if value := stateDB.GetState(contractAddr, KeyHostMissedProofOutput); value != (common.Hash{}) {
+		t.Errorf("contract state not cleared: %x", value)
+	}
+	stateDB.Finalise(true)
+	if stateDB.Exist(contractAddr) || stateDB.Exist(statusAddr) {
+		t.Errorf("the contract account and the status account shall be removed")
+	}
+}
+
+// Tests that pruning the missed storage contract pays out the same outputs as the settlement without
+// pruning, and burns only the forfeited balance which is otherwise locked in the contract account.
+func TestMaintenanceMissedProof_PruneForfeitedBalance(t *testing.T) {
+	prvAndAddresses, err := mockClientAndHostAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientAddress := prvAndAddresses[0].Address
+	hostAddress := prvAndAddresses[1].Address
+	accounts := mockAccountAlloc([]common.Address{clientAddress, hostAddress})
+
+	settled := mockState(ethdb.NewMemDatabase(), accounts)
+	contractAddr := mockMissedStorageProof(1000, settled, prvAndAddresses)
+	MaintenanceMissedProof(1000, settled, nil)
+
+	pruned := mockState(ethdb.NewMemDatabase(), accounts)
+	mockMissedStorageProof(1000, pruned, prvAndAddresses)
+	MaintenanceMissedProof(1000, pruned, &params.StorageFork{PruneContractState: true})
+
+	for _, addr := range []common.Address{clientAddress, hostAddress} {
+		if settled.GetBalance(addr).Cmp(pruned.GetBalance(addr)) != 0 {
+			t.Errorf("balance of %x differs: settled %v, pruned %v", addr, settled.GetBalance(addr), pruned.GetBalance(addr))
+		}
+	}
+
+	// the forfeited balance is locked in the contract account without pruning, and burnt with pruning
+	forfeited := new(big.Int).Sub(contractOriginbal, new(big.Int).Add(clientMpo, hostMpo))
+	if bal := settled.GetBalance(contractAddr); bal.Cmp(forfeited) != 0 {
+		t.Errorf("expect %v locked in the contract account, got %v", forfeited, bal)
+	}
+	if bal := pruned.GetBalance(contractAddr); bal.Sign() != 0 {
+		t.Errorf("expect the contract balance burnt, got %v", bal)
+	}
+}
+
 // mock that have a missed proof at the given height
 func mockMissedStorageProof(height uint64, state *state.StateDB, prvAndAddresses []PrivkeyAddress) common.Address {
 	windowEndStr := strconv.FormatUint(height, 10)
@@ -28,6 +28,9 @@ var (
 
 	// ErrHostCommit defines that host occurs error while commit(finalize)
 	ErrHostCommit = errors.New("host commit error")
+
+	// ErrHostSectorNotFound defines that the host no longer has the requested sector data
+	ErrHostSectorNotFound = errors.New("host sector not found")
 )
 
 // Negotiation related messages
@@ -43,6 +46,8 @@ const (
 	HostCommitFailedMsg          = 0x27
 	HostAckMsg                   = 0x28
 	HostNegotiateErrorMsg        = 0x29
+	HostProbeRespMsg             = 0x2a
+	HostSectorNotFoundMsg        = 0x2b
 
 	// Host Handle Message Set
 	HostConfigReqMsg                 = 0x30
@@ -55,6 +60,7 @@ const (
 	ClientCommitFailedMsg            = 0x37
 	ClientAckMsg                     = 0x38
 	ClientNegotiateErrorMsg          = 0x39
+	HostProbeReqMsg                  = 0x3a
 )
 
 // The block generation rate for Ethereum is 15s/block. Therefore, 240 blocks
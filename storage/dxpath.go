@@ -21,9 +21,15 @@ var (
 
 	reservedNames = []string{
 		".dxdir",
+		packDirName,
 	}
 )
 
+// packDirName is the name of the reserved directory for the pack files, which hold
+// the data of the small files packed together
+const packDirName = ".packs"
+
+
 type (
 	// DxPath is the file Path or directory Path relates to the root directory of the DxFiles.
 	// It is used in storage client and storage client's file system
@@ -114,6 +120,22 @@ func RootDxPath() DxPath {
 	return DxPath{""}
 }
 
+// PackDxPath returns the DxPath of the pack file with the name. The pack files locate in
+// the reserved directory that could not be created by the user
+func PackDxPath(name string) DxPath {
+	return DxPath{packDirName + "/" + name}
+}
+
+// PackDirDxPath returns the DxPath of the reserved directory for the pack files
+func PackDirDxPath() DxPath {
+	return DxPath{packDirName}
+}
+
+// IsPack checks whether the DxPath is in the reserved directory of the pack files
+func (dp DxPath) IsPack() bool {
+	return strings.HasPrefix(dp.Path, packDirName+"/")
+}
+
 // Equals check whether the two DxPath are equal
 func (dp DxPath) Equals(dp2 DxPath) bool {
 	return dp.Path == dp2.Path
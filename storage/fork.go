@@ -0,0 +1,31 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/DxChainNetwork/godx/params"
+)
+
+// ErrStorageNotActive is the error returned when the storage protocol is not active at the height
+var ErrStorageNotActive = errors.New("storage protocol is not active")
+
+// CheckStorageFork checks whether the storage fork active at the height is supported by this node,
+// which stores the data in the sectors of SectorSize and proves the segments of SegmentSize
+func CheckStorageFork(config *params.ChainConfig, height uint64) error {
+	fork := config.StorageFork(new(big.Int).SetUint64(height))
+	if fork == nil {
+		return ErrStorageNotActive
+	}
+	if fork.SectorSize != SectorSize || fork.SegmentSize != SegmentSize ||
+		(fork.ProofVersion != params.StorageProofV0 && fork.ProofVersion != params.StorageProofV1) {
+		return fmt.Errorf("storage fork at block %v is not supported: sector size %v, segment size %v, proof version %v",
+			fork.Block, fork.SectorSize, fork.SegmentSize, fork.ProofVersion)
+	}
+	return nil
+}
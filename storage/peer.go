@@ -16,6 +16,10 @@ import (
 // should not be deducted.
 var ErrRequestingHostConfig = errors.New("host configuration should only be requested one at a time")
 
+// ErrRequestingHostProbe is the error returned when the client tries to probe the host before the
+// previous probe is finished
+var ErrRequestingHostProbe = errors.New("host should only be probed one at a time")
+
 // Peer is the interface returned by the SetupConnection. The use of it is to allow eth.peer object
 // to be used in the storage model. All the methods provided in the Peer interface is used for negotiation
 // during the contract create, contract revision, contract renew, and configuration request
@@ -41,7 +45,13 @@ type Peer interface {
 	SendClientAckMsg() error
 	SendHostAckMsg() error
 	SendHostNegotiateErrorMsg() error
+	SendHostSectorNotFoundMsg() error
 	WaitConfigResp() (p2p.Msg, error)
+	RequestHostProbe(req HostProbeRequest) error
+	SendHostProbeResponse(resp HostProbeResponse) error
+	WaitProbeResp() (p2p.Msg, error)
+	TryRequestHostProbe() error
+	RequestHostProbeDone()
 	ClientWaitContractResp() (msg p2p.Msg, err error)
 	HostWaitContractResp() (msg p2p.Msg, err error)
 	TryToRenewOrRevise() bool
@@ -0,0 +1,101 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxProbeSize is the max size of the payload of the probe request and response
+const MaxProbeSize = 1 << 20
+
+type (
+	// HostProbeRequest is the probe sent by the storage client to measure the round trip time
+	// and throughput of the storage host. Payload is the data uploaded to the host, and RespSize
+	// is the size of the data the host shall send back
+	HostProbeRequest struct {
+		Payload  []byte
+		RespSize uint64
+	}
+
+	// HostProbeResponse is the response of the probe sent by the storage host
+	HostProbeResponse struct {
+		Payload []byte
+	}
+
+	// HostProbes stores a time series of host probe records
+	HostProbes []HostProbe
+
+	// HostProbe is the result of probing the storage host. RTT is the round trip time of an
+	// empty probe. UploadSpeed and DownloadSpeed are in bytes per second
+	HostProbe struct {
+		Timestamp     time.Time     `json:"timestamp"`
+		Success       bool          `json:"success"`
+		RTT           time.Duration `json:"rtt"`
+		UploadSpeed   uint64        `json:"uploadspeed"`
+		DownloadSpeed uint64        `json:"downloadspeed"`
+	}
+)
+
+// NewHostProbeResponse validates the probe request, and returns the response of the probe
+func NewHostProbeResponse(req HostProbeRequest) (HostProbeResponse, error) {
+	if len(req.Payload) > MaxProbeSize || req.RespSize > MaxProbeSize {
+		return HostProbeResponse{}, fmt.Errorf("probe size exceeds the limit %v", MaxProbeSize)
+	}
+	return HostProbeResponse{Payload: make([]byte, req.RespSize)}, nil
+}
+
+// Validate checks whether the response matches the probe request
+func (resp HostProbeResponse) Validate(req HostProbeRequest) error {
+	if uint64(len(resp.Payload)) != req.RespSize {
+		return fmt.Errorf("probe response size %v not equal to the requested size %v", len(resp.Payload), req.RespSize)
+	}
+	return nil
+}
+
+// Recent returns the successful probes within the duration before now
+func (probes HostProbes) Recent(d time.Duration) HostProbes {
+	var recent HostProbes
+	for _, probe := range probes {
+		if probe.Success && time.Since(probe.Timestamp) <= d {
+			recent = append(recent, probe)
+		}
+	}
+	return recent
+}
+
+// AverageRTT returns the average round trip time of the successful probes, and false if
+// there is no successful probe
+func (probes HostProbes) AverageRTT() (time.Duration, bool) {
+	var total time.Duration
+	var count int64
+	for _, probe := range probes {
+		if probe.Success {
+			total += probe.RTT
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, false
+	}
+	return total / time.Duration(count), true
+}
+
+// AverageSpeed returns the average of the upload and download speeds of the successful probes,
+// and false if there is no successful probe
+func (probes HostProbes) AverageSpeed() (uint64, bool) {
+	var total, count uint64
+	for _, probe := range probes {
+		if probe.Success {
+			total += (probe.UploadSpeed + probe.DownloadSpeed) / 2
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, false
+	}
+	return total / count, true
+}
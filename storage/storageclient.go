@@ -7,10 +7,12 @@ package storage
 import (
 	"context"
 	"math/big"
+	"time"
 
 	"github.com/DxChainNetwork/godx/accounts"
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/core"
+	"github.com/DxChainNetwork/godx/core/rawdb"
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/event"
 	"github.com/DxChainNetwork/godx/p2p/enode"
@@ -22,6 +24,7 @@ import (
 type EthBackend interface {
 	APIs() []rpc.API
 	GetStorageHostSetting(hostEnodeID enode.ID, hostEnodeURL string, config *HostExtConfig) error
+	ProbeStorageHost(hostEnodeURL string, req HostProbeRequest) (time.Duration, error)
 	SubscribeChainChangeEvent(ch chan<- core.ChainChangeEvent) event.Subscription
 	GetBlockByHash(blockHash common.Hash) (*types.Block, error)
 	GetBlockChain() *core.BlockChain
@@ -47,6 +50,7 @@ type ClientBackend interface {
 	Online() bool
 	Syncing() bool
 	GetStorageHostSetting(hostEnodeID enode.ID, hostEnodeURL string, config *HostExtConfig) error
+	ProbeStorageHost(hostEnodeURL string, req HostProbeRequest) (time.Duration, error)
 	SubscribeChainChangeEvent(ch chan<- core.ChainChangeEvent) event.Subscription
 	GetTxByBlockHash(blockHash common.Hash) (types.Transactions, error)
 	SetupConnection(enodeURL string) (Peer, error)
@@ -65,6 +69,18 @@ type ClientBackend interface {
 	SelfEnodeURL() string
 }
 
+// HostReputationBackend is implemented by the backend indexing the track records of the
+// storage hosts from the chain
+type HostReputationBackend interface {
+	HostReputation(address common.Address) (rawdb.HostReputation, bool)
+}
+
+// HostAnnouncementBackend is implemented by the backend retrieving the host announcements
+// without the full block, such as the light node
+type HostAnnouncementBackend interface {
+	GetHostAnnouncementWithBlockHash(blockHash common.Hash) ([]types.HostAnnouncement, uint64, error)
+}
+
 // DownloadParameters is the parameters to download from outer request
 type DownloadParameters struct {
 	RemoteFilePath   string
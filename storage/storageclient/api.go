@@ -13,7 +13,9 @@ import (
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/contractmanager"
 	"github.com/DxChainNetwork/godx/storage/storageclient/storagehostmanager"
+	"github.com/DxChainNetwork/godx/storage/storageclient/storagehosttree"
 )
 
 // ActiveContractsAPIDisplay is used to re-format the contract information that is going to
@@ -75,6 +77,67 @@ func (api *PublicStorageClientAPI) HostRank() (evaluation []storagehostmanager.S
 	return api.sc.storageHostManager.StorageHostRanks()
 }
 
+// EvaluationConfig will retrieve the storage host evaluation config, including the weights
+// of the evaluation factors and the curve parameters
+func (api *PublicStorageClientAPI) EvaluationConfig() storagehostmanager.EvaluationConfig {
+	return api.sc.storageHostManager.RetrieveEvaluationConfig()
+}
+
+// Placement will retrieve the placement constraints of the storage hosts selected
+func (api *PublicStorageClientAPI) Placement() []storagehosttree.PlacementConstraint {
+	return api.sc.storageHostManager.RetrievePlacementConstraints()
+}
+
+// HostLabels will retrieve the labels of the storage host used by the placement constraints
+func (api *PublicStorageClientAPI) HostLabels(id string) (map[string]string, error) {
+	info, err := api.Host(id)
+	if err != nil {
+		return nil, err
+	}
+	return api.sc.storageHostManager.HostLabels(info), nil
+}
+
+// PinnedHosts will retrieve the storage hosts pinned
+func (api *PublicStorageClientAPI) PinnedHosts() (hostIDs []string) {
+	for _, id := range api.sc.contractManager.PinnedHosts() {
+		hostIDs = append(hostIDs, id.String())
+	}
+	return
+}
+
+// SpendingForecast will retrieve the spending of the current period, and the forecast spending
+// at the end of the period
+func (api *PublicStorageClientAPI) SpendingForecast() contractmanager.SpendingForecast {
+	return api.sc.contractManager.RetrieveSpendingForecast()
+}
+
+// BudgetAlerts will retrieve the budget alerts raised recently
+func (api *PublicStorageClientAPI) BudgetAlerts() []contractmanager.BudgetAlert {
+	return api.sc.contractManager.RetrieveBudgetAlerts()
+}
+
+// Budget will retrieve the budget config, including the alert thresholds and the hard cap
+func (api *PublicStorageClientAPI) Budget() contractmanager.BudgetConfig {
+	return api.sc.contractManager.RetrieveBudget()
+}
+
+// Profiles will retrieve the storage profiles, along with the DxPaths bound to them
+func (api *PublicStorageClientAPI) Profiles() []contractmanager.Profile {
+	return api.sc.contractManager.Profiles()
+}
+
+// DownloadStats will retrieve the download latency and throughput of the storage hosts observed,
+// fastest first
+func (api *PublicStorageClientAPI) DownloadStats() []WorkerDownloadStats {
+	return api.sc.DownloadStats()
+}
+
+// Audits will retrieve the audit budget and spending of the current period, the audit results of
+// the storage hosts, and the recent audit failures
+func (api *PublicStorageClientAPI) Audits() AuditReport {
+	return api.sc.AuditReport()
+}
+
 // Contracts will retrieve all active contracts and display their general information
 func (api *PublicStorageClientAPI) Contracts() (activeContracts []ActiveContractsAPIDisplay) {
 	activeContracts = api.sc.ActiveContracts()
@@ -125,8 +188,9 @@ func (api *PublicStorageClientAPI) DownloadSync(remoteFilePath, localPath string
 	return "File downloaded successfully", nil
 }
 
-// Upload their local files to hosts made contract with
-func (api *PublicStorageClientAPI) Upload(source string, dxPath string) (string, error) {
+// Upload their local files to hosts made contract with. If dedup is set, the segments
+// identical to the ones uploaded in dedup mode share the uploaded sectors
+func (api *PublicStorageClientAPI) Upload(source string, dxPath string, dedup *bool) (string, error) {
 	path, err := storage.NewDxPath(dxPath)
 	if err != nil {
 		return "", err
@@ -135,6 +199,7 @@ func (api *PublicStorageClientAPI) Upload(source string, dxPath string) (string,
 		Source: source,
 		DxPath: path,
 		Mode:   storage.Override,
+		Dedup:  dedup != nil && *dedup,
 	}
 	if err := api.sc.Upload(param); err != nil {
 		return "", err
@@ -156,10 +221,36 @@ func NewPrivateStorageClientAPI(sc *StorageClient) *PrivateStorageClientAPI {
 
 // SetConfig will configure the client setting based on the user input data
 func (api *PrivateStorageClientAPI) SetConfig(settings map[string]string) (resp string, err error) {
+	// the storage host evaluation config is set to the storage host manager separately
+	clientSettings, evalSettings := make(map[string]string), make(map[string]string)
+	for key, value := range settings {
+		if storagehostmanager.IsEvaluationConfigKey(key) {
+			evalSettings[key] = value
+		} else {
+			clientSettings[key] = value
+		}
+	}
+	if len(evalSettings) != 0 {
+		var evalConfig storagehostmanager.EvaluationConfig
+		prevEvalConfig := api.sc.storageHostManager.RetrieveEvaluationConfig()
+		if evalConfig, err = storagehostmanager.ParseEvaluationConfig(evalSettings, prevEvalConfig); err != nil {
+			err = fmt.Errorf("failed to parse the host evaluation config: %s", err.Error())
+			return
+		}
+		if err = api.sc.storageHostManager.SetEvaluationConfig(evalConfig); err != nil {
+			err = fmt.Errorf("failed to set the host evaluation config: %s", err.Error())
+			return
+		}
+		if len(clientSettings) == 0 {
+			resp = fmt.Sprintf("Successfully set the storage host evaluation config")
+			return
+		}
+	}
+
 	prevClientSetting := api.sc.RetrieveClientSetting()
 	var currentSetting storage.ClientSetting
 
-	if currentSetting, err = parseClientSetting(settings, prevClientSetting); err != nil {
+	if currentSetting, err = parseClientSetting(clientSettings, prevClientSetting); err != nil {
 		err = fmt.Errorf("form contract failed, failed to parse the client settings: %s", err.Error())
 		return
 	}
@@ -202,6 +293,230 @@ func (api *PrivateStorageClientAPI) PeriodCost() storage.PeriodCost {
 	return api.sc.contractManager.RetrievePeriodCost()
 }
 
+// FlushPack uploads the pack file currently holding the small files, without waiting
+// for the pack file to be full
+func (api *PrivateStorageClientAPI) FlushPack() (string, error) {
+	if err := api.sc.FlushPack(); err != nil {
+		return "", err
+	}
+	return "pack file flushed", nil
+}
+
+// SetPlacement sets the placement constraints of the storage hosts selected for the contracts
+// and the sectors of each segment. Each constraint is in the form of "label:max=K,min=N"
+func (api *PrivateStorageClientAPI) SetPlacement(constraints []string) (string, error) {
+	var placement []storagehosttree.PlacementConstraint
+	for _, str := range constraints {
+		c, err := storagehosttree.ParsePlacementConstraint(str)
+		if err != nil {
+			return "", err
+		}
+		placement = append(placement, c)
+	}
+	if err := api.sc.storageHostManager.SetPlacementConstraints(placement); err != nil {
+		return "", fmt.Errorf("failed to set the placement constraints: %s", err.Error())
+	}
+	return "Successfully set the placement constraints", nil
+}
+
+// SetHostLabel sets the custom label of the storage host, such as datacenter=A. The label
+// is removed if the value is empty
+func (api *PrivateStorageClientAPI) SetHostLabel(id string, label string, value string) (string, error) {
+	hostID, err := parseHostID(id)
+	if err != nil {
+		return "", err
+	}
+	if err = api.sc.storageHostManager.SetHostLabel(hostID, label, value); err != nil {
+		return "", fmt.Errorf("failed to set the host label: %s", err.Error())
+	}
+	return "Successfully set the host label", nil
+}
+
+// LoadGeoDB loads the offline geo database providing the asn and region labels of the storage
+// hosts. The database file contains the lines in the form of "cidr,asn,region"
+func (api *PrivateStorageClientAPI) LoadGeoDB(path string) (string, error) {
+	if err := api.sc.storageHostManager.LoadGeoDB(path); err != nil {
+		return "", err
+	}
+	return "Successfully loaded the geo database", nil
+}
+
+// CreateContract creates the contract with the storage host specified. The fund and period
+// are optional, and are derived from the client settings if empty
+func (api *PrivateStorageClientAPI) CreateContract(hostID string, fund string, period string) (detail ContractMetaDataAPIDisplay, err error) {
+	id, err := parseHostID(hostID)
+	if err != nil {
+		return
+	}
+	contractFund, contractPeriod, err := parseContractFundPeriod(fund, period)
+	if err != nil {
+		return
+	}
+	contract, err := api.sc.contractManager.ManualCreateContract(id, contractFund, contractPeriod)
+	if err != nil {
+		return
+	}
+	return formatContractMetaData(contract), nil
+}
+
+// RenewContract renews the contract before it is about to expire. The period is the number of
+// blocks extended. The fund and period are optional, and are derived from the client settings if empty
+func (api *PrivateStorageClientAPI) RenewContract(contractID string, fund string, period string) (detail ContractMetaDataAPIDisplay, err error) {
+	id, err := storage.StringToContractID(contractID)
+	if err != nil {
+		err = fmt.Errorf("the contract id provided is invalid: %s", err.Error())
+		return
+	}
+	contractFund, contractPeriod, err := parseContractFundPeriod(fund, period)
+	if err != nil {
+		return
+	}
+	contract, err := api.sc.contractManager.ManualRenewContract(id, contractFund, contractPeriod)
+	if err != nil {
+		return
+	}
+	return formatContractMetaData(contract), nil
+}
+
+// CancelContract cancels the contract, which will no longer be used for uploading and renewed
+func (api *PrivateStorageClientAPI) CancelContract(contractID string) (string, error) {
+	id, err := storage.StringToContractID(contractID)
+	if err != nil {
+		return "", fmt.Errorf("the contract id provided is invalid: %s", err.Error())
+	}
+	if err = api.sc.contractManager.ManualCancelContract(id); err != nil {
+		return "", fmt.Errorf("failed to cancel the contract: %s", err.Error())
+	}
+	return fmt.Sprintf("Successfully canceled the contract %v", contractID), nil
+}
+
+// PinHost pins the storage host, so that the contract with the storage host is never dropped
+// by the contract maintenance
+func (api *PrivateStorageClientAPI) PinHost(hostID string) (string, error) {
+	id, err := parseHostID(hostID)
+	if err != nil {
+		return "", err
+	}
+	if err = api.sc.contractManager.PinHost(id); err != nil {
+		return "", fmt.Errorf("failed to pin the storage host: %s", err.Error())
+	}
+	return fmt.Sprintf("Successfully pinned the storage host %v", hostID), nil
+}
+
+// ProfileCosts will get the period cost of each storage profile, including the default profile
+func (api *PrivateStorageClientAPI) ProfileCosts() (map[string]storage.PeriodCost, error) {
+	costs := make(map[string]storage.PeriodCost)
+	names := []string{contractmanager.DefaultProfile}
+	for _, p := range api.sc.contractManager.Profiles() {
+		names = append(names, p.Name)
+	}
+	for _, name := range names {
+		cost, err := api.sc.contractManager.ProfilePeriodCost(name)
+		if err != nil {
+			return nil, err
+		}
+		costs[name] = cost
+	}
+	return costs, nil
+}
+
+// SetProfile creates or updates the storage profile. The settings are the rent payment settings in
+// the same form as setConfig, the address is the payment address of the profile, and the hosts are
+// the storage hosts the contracts of the profile can be formed with, empty means any storage host
+func (api *PrivateStorageClientAPI) SetProfile(name string, settings map[string]string, address string, hosts []string) (string, error) {
+	prevSetting := storage.ClientSetting{RentPayment: storage.DefaultRentPayment}
+	profile := contractmanager.Profile{Name: name}
+	for _, p := range api.sc.contractManager.Profiles() {
+		if p.Name == name {
+			prevSetting.RentPayment = p.RentPayment
+			profile.PaymentAddress = p.PaymentAddress
+		}
+	}
+
+	setting, err := parseClientSetting(settings, prevSetting)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse the profile settings: %s", err.Error())
+	}
+	profile.RentPayment = setting.RentPayment
+
+	if address != "" {
+		if !common.IsHexAddress(address) {
+			return "", fmt.Errorf("invalid payment address: %s", address)
+		}
+		profile.PaymentAddress = common.HexToAddress(address)
+	}
+	for _, host := range hosts {
+		id, err := parseHostID(host)
+		if err != nil {
+			return "", err
+		}
+		profile.Hosts = append(profile.Hosts, id)
+	}
+
+	if err = api.sc.contractManager.SetProfile(profile); err != nil {
+		return "", fmt.Errorf("failed to set the profile: %s", err.Error())
+	}
+	return fmt.Sprintf("Successfully set the profile %s", name), nil
+}
+
+// RemoveProfile removes the storage profile, whose contracts must be canceled first
+func (api *PrivateStorageClientAPI) RemoveProfile(name string) (string, error) {
+	if err := api.sc.contractManager.RemoveProfile(name); err != nil {
+		return "", fmt.Errorf("failed to remove the profile: %s", err.Error())
+	}
+	return fmt.Sprintf("Successfully removed the profile %s", name), nil
+}
+
+// BindPath binds the DxPath and its subtree to the storage profile, the files under the path are
+// only uploaded to the contracts of the profile
+func (api *PrivateStorageClientAPI) BindPath(path string, name string) (string, error) {
+	dxPath, err := storage.NewDxPath(path)
+	if err != nil {
+		return "", err
+	}
+	if err = api.sc.contractManager.BindPath(dxPath, name); err != nil {
+		return "", fmt.Errorf("failed to bind the path: %s", err.Error())
+	}
+	return fmt.Sprintf("Successfully bound the path %s to the profile %s", path, name), nil
+}
+
+// UnbindPath removes the binding of the DxPath to the storage profile
+func (api *PrivateStorageClientAPI) UnbindPath(path string) (string, error) {
+	dxPath, err := storage.NewDxPath(path)
+	if err != nil {
+		return "", err
+	}
+	if err = api.sc.contractManager.UnbindPath(dxPath); err != nil {
+		return "", fmt.Errorf("failed to unbind the path: %s", err.Error())
+	}
+	return fmt.Sprintf("Successfully unbound the path %s", path), nil
+}
+
+// SetBudget sets the alert thresholds as comma separated percentages of the fund, and the hard
+// cap at which the uploads and downloads are paused. Empty hard cap means no hard cap
+func (api *PrivateStorageClientAPI) SetBudget(alerts string, hardCap string) (string, error) {
+	budget, err := parseBudget(alerts, hardCap)
+	if err != nil {
+		return "", err
+	}
+	if err = api.sc.contractManager.SetBudget(budget); err != nil {
+		return "", fmt.Errorf("failed to set the budget: %s", err.Error())
+	}
+	return "Successfully set the budget", nil
+}
+
+// UnpinHost unpins the storage host
+func (api *PrivateStorageClientAPI) UnpinHost(hostID string) (string, error) {
+	id, err := parseHostID(hostID)
+	if err != nil {
+		return "", err
+	}
+	if err = api.sc.contractManager.UnpinHost(id); err != nil {
+		return "", fmt.Errorf("failed to unpin the storage host: %s", err.Error())
+	}
+	return fmt.Sprintf("Successfully unpinned the storage host %v", hostID), nil
+}
+
 // CancelAllContracts will cancel all contracts signed with storage client by
 // marking all active contracts as canceled, not good for uploading, and not good
 // for renewing
@@ -0,0 +1,315 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/crypto/merkle"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+var (
+	// errInvalidSectorData is returned when the sector data or the merkle proof sent by the
+	// storage host does not match the merkle root of the sector
+	errInvalidSectorData = errors.New("host provided incorrect sector data or Merkle proof")
+
+	// errInsufficientSectorData is returned when the storage host sent less data than requested
+	errInsufficientSectorData = errors.New("host did not send enough sector data")
+)
+
+type (
+	// HostAuditResult is the accumulated result of the audits of a storage host
+	HostAuditResult struct {
+		HostID      enode.ID      `json:"hostid"`
+		Audits      uint64        `json:"audits"`
+		Failures    uint64        `json:"failures"`
+		Spent       common.BigInt `json:"spent"`
+		LastAudit   time.Time     `json:"lastaudit"`
+		LastFailure time.Time     `json:"lastfailure"`
+	}
+
+	// AuditFailure records the sector which the storage host failed to prove it is storing
+	AuditFailure struct {
+		HostID       enode.ID    `json:"hostid"`
+		DxPath       string      `json:"dxpath"`
+		SegmentIndex uint64      `json:"segmentindex"`
+		MerkleRoot   common.Hash `json:"merkleroot"`
+		Time         time.Time   `json:"time"`
+		Error        string      `json:"error"`
+	}
+
+	// AuditReport is the report of the audits of the storage hosts within the current period
+	AuditReport struct {
+		Budget      common.BigInt     `json:"budget"`
+		Spent       common.BigInt     `json:"spent"`
+		PeriodStart uint64            `json:"periodstart"`
+		Hosts       []HostAuditResult `json:"hosts"`
+		Failures    []AuditFailure    `json:"failures"`
+	}
+
+	// auditTarget is a sector of the file stored by the storage host to be audited
+	auditTarget struct {
+		dxPath       storage.DxPath
+		segmentIndex int
+		root         common.Hash
+	}
+
+	// auditState keeps the results of the audits in memory
+	auditState struct {
+		hosts    map[enode.ID]*HostAuditResult
+		failures []AuditFailure
+		mu       sync.Mutex
+	}
+)
+
+// auditLoop periodically audits the storage hosts the client has contracts with, by requesting
+// random segments of the sectors stored along with the merkle proofs
+func (client *StorageClient) auditLoop() {
+	if err := client.tm.Add(); err != nil {
+		return
+	}
+	defer client.tm.Done()
+
+	for {
+		select {
+		case <-client.tm.StopChan():
+			return
+		case <-time.After(auditInterval):
+		}
+		client.auditHosts()
+	}
+}
+
+// auditHosts audits a random segment of the random sectors stored by each storage host, until
+// the audit budget of the period runs out. The sectors the storage host failed to prove are
+// removed from the dxfile and queued for repair immediately.
+func (client *StorageClient) auditHosts() {
+	if client.auditBudget().Sign() <= 0 || client.contractManager.SpendingPaused() {
+		return
+	}
+	targets, err := client.auditTargets()
+	if err != nil {
+		client.log.Warn("failed to select the sectors to audit", "err", err)
+		return
+	}
+
+	client.lock.Lock()
+	workers := make([]*worker, 0, len(client.workerPool))
+	for _, w := range client.workerPool {
+		workers = append(workers, w)
+	}
+	client.lock.Unlock()
+
+	for _, w := range workers {
+		for _, target := range targets[w.hostID] {
+			select {
+			case <-client.tm.StopChan():
+				return
+			default:
+			}
+			if !client.auditSector(w, target) {
+				return
+			}
+		}
+	}
+}
+
+// auditSector requests a random segment of the sector from the storage host of the worker with
+// the merkle proof. False is returned if the audit budget of the period runs out.
+func (client *StorageClient) auditSector(w *worker, target auditTarget) bool {
+	sp, hostInfo, err := w.checkConnection()
+	if sp != nil {
+		defer sp.RevisionOrRenewingDone()
+	}
+	if err != nil {
+		client.log.Debug("failed to connect to the storage host for audit", "host", w.hostID, "err", err)
+		return true
+	}
+
+	cost := downloadPrice(hostInfo, merkle.LeafSize, true).MultFloat64(1 + extraRatio)
+	if !client.auditAffordable(cost) {
+		client.log.Info("audit budget of the period is used up")
+		return false
+	}
+
+	// the failed and successful interactions with the host are recorded by the download
+	offset := uint32(rand.Intn(int(storage.SectorSize/merkle.LeafSize))) * merkle.LeafSize
+	_, err = client.Download(sp, target.root, offset, merkle.LeafSize, nil, hostInfo)
+	switch {
+	case err == nil:
+		client.recordAudit(w.hostID, cost, nil)
+	case isAuditFailure(err):
+		client.recordAudit(w.hostID, common.BigInt0, &AuditFailure{
+			HostID:       w.hostID,
+			DxPath:       target.dxPath.Path,
+			SegmentIndex: uint64(target.segmentIndex),
+			MerkleRoot:   target.root,
+			Time:         time.Now(),
+			Error:        err.Error(),
+		})
+		client.log.Warn("storage host failed the audit", "host", w.hostID, "dxpath", target.dxPath.Path, "segment", target.segmentIndex, "err", err)
+		if err := client.repairLostSector(w.hostID, target); err != nil {
+			client.log.Warn("failed to repair the sector lost", "dxpath", target.dxPath.Path, "segment", target.segmentIndex, "err", err)
+		}
+	default:
+		// the audit is inconclusive, such as the network errors or the busy host
+		client.log.Debug("audit inconclusive", "host", w.hostID, "err", err)
+	}
+	return true
+}
+
+// isAuditFailure checks whether the download error shows that the storage host is not able to
+// provide the sector data. The negotiation errors are transient and not counted as failures
+func isAuditFailure(err error) bool {
+	return err == errInvalidSectorData || err == errInsufficientSectorData || err == storage.ErrHostSectorNotFound
+}
+
+// auditTargets selects at most auditSectorsPerHost random sectors stored by each storage host
+// from the dxfiles by reservoir sampling
+func (client *StorageClient) auditTargets() (map[enode.ID][]auditTarget, error) {
+	paths, err := client.fileSystem.DxFilePaths()
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make(map[enode.ID][]auditTarget)
+	seen := make(map[enode.ID]int)
+	for _, path := range paths {
+		entry, err := client.fileSystem.OpenDxFile(path)
+		if err != nil {
+			continue
+		}
+		for segmentIndex := 0; segmentIndex < entry.NumSegments(); segmentIndex++ {
+			sectors, err := entry.Sectors(segmentIndex)
+			if err != nil {
+				break
+			}
+			for _, sectorSet := range sectors {
+				for _, sector := range sectorSet {
+					target := auditTarget{dxPath: path, segmentIndex: segmentIndex, root: sector.MerkleRoot}
+					seen[sector.HostID]++
+					if len(targets[sector.HostID]) < auditSectorsPerHost {
+						targets[sector.HostID] = append(targets[sector.HostID], target)
+					} else if i := rand.Intn(seen[sector.HostID]); i < auditSectorsPerHost {
+						targets[sector.HostID][i] = target
+					}
+				}
+			}
+		}
+		entry.Close()
+	}
+	return targets, nil
+}
+
+// repairLostSector removes the sector lost by the storage host from the dxfile, and pushes the
+// segments of the file to the upload heap to be repaired
+func (client *StorageClient) repairLostSector(hostID enode.ID, target auditTarget) error {
+	entry, err := client.fileSystem.OpenDxFile(target.dxPath)
+	if err != nil {
+		return err
+	}
+	err = entry.RemoveSector(hostID, target.root, target.segmentIndex)
+	if err == nil {
+		err = entry.SetStuckByIndex(target.segmentIndex, false)
+	}
+	if closeErr := entry.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	client.pushDirOrFileToSegmentHeap(target.dxPath, false, client.refreshHostsAndWorkers(), targetUnstuckSegments)
+	select {
+	case client.uploadHeap.segmentComing <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// auditAffordable checks whether the cost of the audit is within the audit budget of the current
+// period. The spending is reset when a new period starts.
+func (client *StorageClient) auditAffordable(cost common.BigInt) bool {
+	periodStart := client.contractManager.RetrieveSpendingForecast().PeriodStart
+
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	if client.persist.AuditPeriod != periodStart {
+		client.persist.AuditPeriod = periodStart
+		client.persist.AuditSpent = common.BigInt0
+	}
+	return client.persist.AuditSpent.Add(cost).Cmp(client.persist.AuditBudget) <= 0
+}
+
+// recordAudit records the result of the audit of the storage host. The failure is nil if the
+// storage host passed the audit
+func (client *StorageClient) recordAudit(hostID enode.ID, cost common.BigInt, failure *AuditFailure) {
+	client.lock.Lock()
+	client.persist.AuditSpent = client.persist.AuditSpent.Add(cost)
+	if err := client.saveSettings(); err != nil {
+		client.log.Warn("failed to save the audit spending", "err", err)
+	}
+	client.lock.Unlock()
+
+	client.audits.mu.Lock()
+	defer client.audits.mu.Unlock()
+	if client.audits.hosts == nil {
+		client.audits.hosts = make(map[enode.ID]*HostAuditResult)
+	}
+	result, exist := client.audits.hosts[hostID]
+	if !exist {
+		result = &HostAuditResult{HostID: hostID, Spent: common.BigInt0}
+		client.audits.hosts[hostID] = result
+	}
+	result.Audits++
+	result.Spent = result.Spent.Add(cost)
+	result.LastAudit = time.Now()
+	if failure == nil {
+		return
+	}
+	result.Failures++
+	result.LastFailure = failure.Time
+	client.audits.failures = append(client.audits.failures, *failure)
+	if len(client.audits.failures) > maxAuditFailures {
+		client.audits.failures = client.audits.failures[len(client.audits.failures)-maxAuditFailures:]
+	}
+}
+
+// auditBudget returns the maximum amount spent auditing the storage hosts in each period
+func (client *StorageClient) auditBudget() common.BigInt {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	return client.persist.AuditBudget
+}
+
+// AuditReport returns the audit budget and spending of the current period, along with the audit
+// results of the storage hosts and the recent audit failures
+func (client *StorageClient) AuditReport() AuditReport {
+	client.lock.Lock()
+	report := AuditReport{
+		Budget:      client.persist.AuditBudget,
+		Spent:       client.persist.AuditSpent,
+		PeriodStart: client.persist.AuditPeriod,
+	}
+	client.lock.Unlock()
+
+	client.audits.mu.Lock()
+	defer client.audits.mu.Unlock()
+	for _, result := range client.audits.hosts {
+		report.Hosts = append(report.Hosts, *result)
+	}
+	sort.Slice(report.Hosts, func(i, j int) bool {
+		return report.Hosts[i].HostID.String() < report.Hosts[j].HostID.String()
+	})
+	report.Failures = append(report.Failures, client.audits.failures...)
+	return report
+}
@@ -0,0 +1,113 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package storageclient
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+func TestStorageClient_AuditTargets(t *testing.T) {
+	sct := newStorageClientTester(t)
+	sc := sct.Client
+	defer sc.Close()
+
+	entry := newFileEntry(t, sc)
+	defer func() {
+		os.Remove(string(entry.LocalPath()))
+		entry.Close()
+		sc.DeleteFile(entry.DxPath())
+	}()
+
+	// the first host stores all the sectors, while the second host stores only one
+	hostA, hostB := enode.RandomID(enode.ID{}, 1), enode.RandomID(enode.ID{}, 2)
+	rootsA := make(map[common.Hash]bool)
+	for segmentIndex := 0; segmentIndex < entry.NumSegments(); segmentIndex++ {
+		for sectorIndex := 0; sectorIndex < 2; sectorIndex++ {
+			root := common.BytesToHash([]byte{byte(segmentIndex), byte(sectorIndex), 1})
+			rootsA[root] = true
+			if err := entry.AddSector(hostA, root, segmentIndex, sectorIndex); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	rootB := common.BytesToHash([]byte{2})
+	if err := entry.AddSector(hostB, rootB, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	targets, err := sc.auditTargets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets[hostA]) != auditSectorsPerHost {
+		t.Fatalf("expect %d sectors of host A selected, got %d", auditSectorsPerHost, len(targets[hostA]))
+	}
+	for _, target := range targets[hostA] {
+		if !rootsA[target.root] || target.dxPath != entry.DxPath() {
+			t.Errorf("sector %x not stored by host A selected", target.root)
+		}
+	}
+	if len(targets[hostB]) != 1 || targets[hostB][0].root != rootB || targets[hostB][0].segmentIndex != 0 {
+		t.Errorf("expect the only sector of host B selected, got %v", targets[hostB])
+	}
+}
+
+func TestStorageClient_AuditBudget(t *testing.T) {
+	sct := newStorageClientTester(t)
+	sc := sct.Client
+	defer sc.Close()
+
+	host := enode.RandomID(enode.ID{}, 1)
+	periodStart := sc.contractManager.RetrieveSpendingForecast().PeriodStart
+	sc.persist.AuditBudget = common.NewBigInt(100)
+	sc.persist.AuditSpent = common.NewBigInt(70)
+	sc.persist.AuditPeriod = periodStart + 1
+
+	// the spending of the previous period is reset
+	if !sc.auditAffordable(common.NewBigInt(60)) {
+		t.Fatalf("audit within the budget of the new period is not affordable")
+	}
+	sc.recordAudit(host, common.NewBigInt(60), nil)
+	if sc.auditAffordable(common.NewBigInt(60)) {
+		t.Errorf("audit exceeding the budget is affordable")
+	}
+	sc.recordAudit(host, common.BigInt0, &AuditFailure{HostID: host, Error: errInvalidSectorData.Error()})
+
+	report := sc.AuditReport()
+	if report.PeriodStart != periodStart || !report.Spent.IsEqual(common.NewBigInt(60)) {
+		t.Errorf("expect spent 60 in period %d, got %v in period %d", periodStart, report.Spent, report.PeriodStart)
+	}
+	if len(report.Hosts) != 1 || report.Hosts[0].Audits != 2 || report.Hosts[0].Failures != 1 {
+		t.Fatalf("expect 2 audits and 1 failure of the host, got %+v", report.Hosts)
+	}
+	if len(report.Failures) != 1 || report.Failures[0].HostID != host {
+		t.Errorf("expect the failure of the host reported, got %+v", report.Failures)
+	}
+}
+
+func TestIsAuditFailure(t *testing.T) {
+	tests := []struct {
+		err     error
+		failure bool
+	}{
+		{errInvalidSectorData, true},
+		{errInsufficientSectorData, true},
+		{storage.ErrHostSectorNotFound, true},
+		{storage.ErrHostNegotiate, false},
+		{storage.ErrHostBusyHandleReq, false},
+		{errors.New("connection closed"), false},
+	}
+	for i, test := range tests {
+		if failure := isAuditFailure(test.err); failure != test.failure {
+			t.Errorf("test %d: expect audit failure %v, got %v", i, test.failure, failure)
+		}
+	}
+}
@@ -5,11 +5,17 @@
 package storageclient
 
 import (
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/common/unit"
+	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/storage"
 	"strconv"
+	"strings"
+
+	"github.com/DxChainNetwork/godx/storage/storageclient/contractmanager"
 )
 
 // parseClientSetting will take client settings in a map format, where both key and value are strings. Then, those value will be parsed
@@ -120,6 +126,33 @@ func parseClientSetting(settings map[string]string, prevSetting storage.ClientSe
 			}
 			clientSetting.MaxDownloadSpeed = downloadSpeed
 
+		case key == "hedge":
+			var hedge uint64
+			hedge, err = unit.ParseUint64(value, 1, "")
+			if err != nil {
+				err = fmt.Errorf("failed to parse the download hedge: %s", err.Error())
+				break
+			}
+			clientSetting.DownloadHedge = hedge
+
+		case key == "cachesize":
+			var cacheSize uint64
+			cacheSize, err = unit.ParseStorage(value)
+			if err != nil {
+				err = fmt.Errorf("failed to parse the segment cache size: %s", err.Error())
+				break
+			}
+			clientSetting.SegmentCacheSize = cacheSize
+
+		case key == "auditbudget":
+			var budget common.BigInt
+			budget, err = unit.ParseCurrency(value)
+			if err != nil {
+				err = fmt.Errorf("failed to parse the audit budget: %s", err.Error())
+				break
+			}
+			clientSetting.AuditBudget = budget
+
 		default:
 			err = fmt.Errorf("the key entered: %s is not valid. Here is a list of available keys: %+v",
 				key, keys)
@@ -209,3 +242,56 @@ func clientSettingGetDefault(setting storage.ClientSetting) (newSetting storage.
 
 	return setting
 }
+
+// parseHostID converts the hex string to the storage host enode ID
+func parseHostID(id string) (hostID enode.ID, err error) {
+	idSlice, err := hex.DecodeString(id)
+	if err != nil || len(idSlice) != len(hostID) {
+		return enode.ID{}, errors.New("the hostID provided is not valid")
+	}
+	copy(hostID[:], idSlice)
+	return
+}
+
+// parseContractFundPeriod parses the fund and period of the contract created or renewed manually,
+// where the empty string is parsed to zero
+func parseContractFundPeriod(fund string, period string) (contractFund common.BigInt, contractPeriod uint64, err error) {
+	contractFund = common.BigInt0
+	if fund != "" {
+		if contractFund, err = unit.ParseCurrency(fund); err != nil {
+			err = fmt.Errorf("failed to parse the contract fund: %s", err.Error())
+			return
+		}
+	}
+	if period != "" {
+		if contractPeriod, err = unit.ParseTime(period); err != nil {
+			err = fmt.Errorf("failed to parse the contract period: %s", err.Error())
+			return
+		}
+	}
+	return
+}
+
+// parseBudget parses the alert thresholds in the form of comma separated percentages such as
+// "50,80,100", and the hard cap in the currency format. Empty hard cap means no hard cap
+func parseBudget(alerts string, hardCap string) (budget contractmanager.BudgetConfig, err error) {
+	budget.HardCap = common.BigInt0
+	for _, str := range strings.Split(alerts, ",") {
+		if str = strings.TrimSuffix(strings.TrimSpace(str), "%"); str == "" {
+			continue
+		}
+		var threshold float64
+		if threshold, err = strconv.ParseFloat(str, 64); err != nil {
+			err = fmt.Errorf("failed to parse the alert threshold %s: %s", str, err.Error())
+			return
+		}
+		budget.AlertThresholds = append(budget.AlertThresholds, threshold)
+	}
+	if hardCap != "" {
+		if budget.HardCap, err = unit.ParseCurrency(hardCap); err != nil {
+			err = fmt.Errorf("failed to parse the hard cap: %s", err.Error())
+			return
+		}
+	}
+	return
+}
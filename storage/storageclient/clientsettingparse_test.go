@@ -172,7 +172,7 @@ func randomValue(selectedKeys map[string]string) (settings map[string]string, er
 
 	for key := range selectedKeys {
 		switch {
-		case key == "fund":
+		case key == "fund" || key == "auditbudget":
 			value = common.RandomBigInt()
 			granularity = unit.CurrencyUnit[rand.Intn(len(unit.CurrencyUnit))]
 			break
@@ -180,7 +180,7 @@ func randomValue(selectedKeys map[string]string) (settings map[string]string, er
 			value = rand.Uint64()
 			granularity = unit.TimeUnit[rand.Intn(len(unit.TimeUnit))]
 			break
-		case key == "storage" || key == "upload" || key == "download":
+		case key == "storage" || key == "upload" || key == "download" || key == "cachesize":
 			value = rand.Uint64()
 			granularity = unit.DataSizeUnit[rand.Intn(len(unit.DataSizeUnit))]
 			break
@@ -192,7 +192,7 @@ func randomValue(selectedKeys map[string]string) (settings map[string]string, er
 			value = rand.Intn(2) == 0
 			granularity = ""
 			break
-		case key == "hosts":
+		case key == "hosts" || key == "hedge":
 			value = rand.Int63()
 			granularity = ""
 			break
@@ -245,6 +245,15 @@ func clientSettingValidation(key string, prevSetting storage.ClientSetting, curr
 	case "downloadspeed":
 		valid = currentSetting.MaxDownloadSpeed == prevSetting.MaxDownloadSpeed
 		return
+	case "hedge":
+		valid = currentSetting.DownloadHedge == prevSetting.DownloadHedge
+		return
+	case "cachesize":
+		valid = currentSetting.SegmentCacheSize == prevSetting.SegmentCacheSize
+		return
+	case "auditbudget":
+		valid = currentSetting.AuditBudget.IsEqual(prevSetting.AuditBudget)
+		return
 	default:
 		err = fmt.Errorf("the provided key is invalid: %s", key)
 		return
@@ -0,0 +1,314 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package contractmanager
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// Kinds of the budget alerts
+const (
+	// AlertSpent is raised when the spending reaches a threshold of the fund
+	AlertSpent = "spent"
+
+	// AlertForecast is raised when the forecast spending of the period exceeds the fund
+	AlertForecast = "forecast"
+
+	// AlertHardCap is raised when the spending reaches the hard cap, and the uploads
+	// and downloads are paused
+	AlertHardCap = "hardcap"
+)
+
+type (
+	// BudgetConfig is the spending budget of the storage client within a period. AlertThresholds
+	// are the percentages of the fund at which the budget alerts are raised. HardCap is the amount
+	// of spending at which the uploads and downloads are paused, and zero means no hard cap
+	BudgetConfig struct {
+		AlertThresholds []float64     `json:"alertThresholds"`
+		HardCap         common.BigInt `json:"hardCap"`
+	}
+
+	// SpendingForecast is the spending of the current period, along with the forecast spending
+	// at the end of the period based on the spending rates of the recent blocks
+	SpendingForecast struct {
+		BlockHeight uint64        `json:"blockHeight"`
+		PeriodStart uint64        `json:"periodStart"`
+		PeriodEnd   uint64        `json:"periodEnd"`
+		Fund        common.BigInt `json:"fund"`
+		HardCap     common.BigInt `json:"hardCap"`
+		Paused      bool          `json:"paused"`
+
+		// spending of the current period
+		ContractFees common.BigInt `json:"contractFees"`
+		UploadCost   common.BigInt `json:"uploadCost"`
+		DownloadCost common.BigInt `json:"downloadCost"`
+		StorageCost  common.BigInt `json:"storageCost"`
+		Spent        common.BigInt `json:"spent"`
+
+		// spending rates per block
+		UploadRate   common.BigInt `json:"uploadRate"`
+		DownloadRate common.BigInt `json:"downloadRate"`
+		StorageRate  common.BigInt `json:"storageRate"`
+
+		// Forecast is the spending forecast at the end of the period, and ExhaustHeight is the
+		// block height at which the fund is forecast to run out, zero if the fund lasts the period
+		Forecast      common.BigInt `json:"forecast"`
+		ExhaustHeight uint64        `json:"exhaustHeight"`
+	}
+
+	// BudgetAlert is the alert raised when the spending reaches the budget thresholds
+	BudgetAlert struct {
+		Kind        string        `json:"kind"`
+		Threshold   float64       `json:"threshold"`
+		BlockHeight uint64        `json:"blockHeight"`
+		Spent       common.BigInt `json:"spent"`
+		Forecast    common.BigInt `json:"forecast"`
+		Fund        common.BigInt `json:"fund"`
+	}
+
+	// spendingSample is the snapshot of the spending of the current period at a block height
+	spendingSample struct {
+		blockHeight  uint64
+		uploadCost   common.BigInt
+		downloadCost common.BigInt
+		storageCost  common.BigInt
+	}
+)
+
+// defaultBudgetConfig returns the default budget config, which raises alerts at 50%, 80%
+// and 100% of the fund, without the hard cap
+func defaultBudgetConfig() BudgetConfig {
+	return BudgetConfig{
+		AlertThresholds: []float64{50, 80, 100},
+		HardCap:         common.BigInt0,
+	}
+}
+
+// String returns the description of the budget alert
+func (alert BudgetAlert) String() string {
+	switch alert.Kind {
+	case AlertForecast:
+		return fmt.Sprintf("the spending of the period is forecast to be %v camel, exceeding the fund %v camel", alert.Forecast, alert.Fund)
+	case AlertHardCap:
+		return fmt.Sprintf("the spending %v camel reached the hard cap, uploads and downloads are paused", alert.Spent)
+	default:
+		return fmt.Sprintf("the spending %v camel reached %v%% of the fund %v camel", alert.Spent, alert.Threshold, alert.Fund)
+	}
+}
+
+// SetBudget sets the budget config, and checks the spending against the new budget
+func (cm *ContractManager) SetBudget(config BudgetConfig) (err error) {
+	for _, threshold := range config.AlertThresholds {
+		if threshold <= 0 {
+			return fmt.Errorf("alert threshold %v shall be positive", threshold)
+		}
+	}
+	if config.HardCap.IsNeg() {
+		return fmt.Errorf("hard cap cannot be negative")
+	}
+	thresholds := append([]float64{}, config.AlertThresholds...)
+	sort.Float64s(thresholds)
+
+	cm.lock.Lock()
+	cm.budget = BudgetConfig{AlertThresholds: thresholds, HardCap: config.HardCap}
+	cm.firedAlerts = make(map[string]struct{})
+	cm.lock.Unlock()
+
+	if err = cm.saveSettings(); err != nil {
+		return
+	}
+	cm.checkBudget()
+	return
+}
+
+// RetrieveBudget returns the budget config
+func (cm *ContractManager) RetrieveBudget() BudgetConfig {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+	return BudgetConfig{
+		AlertThresholds: append([]float64{}, cm.budget.AlertThresholds...),
+		HardCap:         cm.budget.HardCap,
+	}
+}
+
+// RetrieveBudgetAlerts returns the budget alerts raised recently
+func (cm *ContractManager) RetrieveBudgetAlerts() []BudgetAlert {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+	return append([]BudgetAlert{}, cm.budgetAlerts...)
+}
+
+// SpendingPaused returns whether the spending reached the hard cap, in which case the uploads
+// and downloads shall be paused
+func (cm *ContractManager) SpendingPaused() bool {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+	return cm.spendingPaused
+}
+
+// RetrieveSpendingForecast returns the spending of the current period and the forecast spending
+// at the end of the period
+func (cm *ContractManager) RetrieveSpendingForecast() SpendingForecast {
+	cm.lock.RLock()
+	rentPayment := cm.rentPayment
+	cm.lock.RUnlock()
+
+	periodCost := cm.CalculatePeriodCost(rentPayment)
+
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+	return cm.spendingForecast(periodCost)
+}
+
+// checkBudget records the spending of the current period, raises the budget alerts, and pauses
+// the spending if the hard cap is reached
+func (cm *ContractManager) checkBudget() {
+	cm.lock.RLock()
+	rentPayment := cm.rentPayment
+	cm.lock.RUnlock()
+
+	periodCost := cm.CalculatePeriodCost(rentPayment)
+
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+
+	cm.recordSpendingSample(periodCost)
+	forecast := cm.spendingForecast(periodCost)
+
+	// the alerts are raised once per period
+	if cm.alertPeriod != cm.currentPeriod {
+		cm.alertPeriod = cm.currentPeriod
+		cm.firedAlerts = make(map[string]struct{})
+	}
+	percentage := forecast.Spent.DivWithFloatResult(forecast.Fund) * 100
+	for _, threshold := range cm.budget.AlertThresholds {
+		if forecast.Fund.Sign() > 0 && percentage >= threshold {
+			cm.raiseBudgetAlert(AlertSpent, threshold, forecast)
+		}
+	}
+	if forecast.Fund.Sign() > 0 && forecast.Forecast.Cmp(forecast.Fund) > 0 {
+		cm.raiseBudgetAlert(AlertForecast, 0, forecast)
+	}
+
+	paused := forecast.Paused
+	if paused && !cm.spendingPaused {
+		cm.raiseBudgetAlert(AlertHardCap, 0, forecast)
+	}
+	if !paused && cm.spendingPaused {
+		cm.log.Info("the spending is below the hard cap, uploads and downloads are resumed")
+	}
+	cm.spendingPaused = paused
+}
+
+// raiseBudgetAlert raises the budget alert if the alert has not been raised within the period.
+// Require: lock cm.lock by caller
+func (cm *ContractManager) raiseBudgetAlert(kind string, threshold float64, forecast SpendingForecast) {
+	key := fmt.Sprintf("%s:%v", kind, threshold)
+	if _, fired := cm.firedAlerts[key]; fired && kind != AlertHardCap {
+		return
+	}
+	cm.firedAlerts[key] = struct{}{}
+
+	alert := BudgetAlert{
+		Kind:        kind,
+		Threshold:   threshold,
+		BlockHeight: forecast.BlockHeight,
+		Spent:       forecast.Spent,
+		Forecast:    forecast.Forecast,
+		Fund:        forecast.Fund,
+	}
+	cm.log.Warn("storage client budget alert", "alert", alert.String())
+
+	cm.budgetAlerts = append(cm.budgetAlerts, alert)
+	if len(cm.budgetAlerts) > maxBudgetAlerts {
+		cm.budgetAlerts = cm.budgetAlerts[len(cm.budgetAlerts)-maxBudgetAlerts:]
+	}
+}
+
+// recordSpendingSample records the spending of the current period. The samples outside of the
+// trend window or the current period are removed.
+// Require: lock cm.lock by caller
+func (cm *ContractManager) recordSpendingSample(periodCost storage.PeriodCost) {
+	if n := len(cm.spendingSamples); n > 0 && cm.spendingSamples[n-1].blockHeight+spendingSampleInterval > cm.blockHeight &&
+		cm.spendingSamples[n-1].blockHeight <= cm.blockHeight {
+		return
+	}
+
+	var samples []spendingSample
+	for _, sample := range cm.spendingSamples {
+		if sample.blockHeight >= cm.currentPeriod && sample.blockHeight < cm.blockHeight &&
+			sample.blockHeight+spendingTrendWindow >= cm.blockHeight {
+			samples = append(samples, sample)
+		}
+	}
+	cm.spendingSamples = append(samples, spendingSample{
+		blockHeight:  cm.blockHeight,
+		uploadCost:   periodCost.UploadCost,
+		downloadCost: periodCost.DownloadCost,
+		storageCost:  periodCost.StorageCost,
+	})
+}
+
+// spendingForecast calculates the spending forecast of the current period. The spending rates
+// are derived from the oldest spending sample within the trend window.
+// Require: lock cm.lock by caller
+func (cm *ContractManager) spendingForecast(periodCost storage.PeriodCost) (forecast SpendingForecast) {
+	forecast = SpendingForecast{
+		BlockHeight:  cm.blockHeight,
+		PeriodStart:  cm.currentPeriod,
+		PeriodEnd:    cm.currentPeriod + cm.rentPayment.Period,
+		Fund:         cm.rentPayment.Fund,
+		HardCap:      cm.budget.HardCap,
+		ContractFees: periodCost.ContractFees,
+		UploadCost:   periodCost.UploadCost,
+		DownloadCost: periodCost.DownloadCost,
+		StorageCost:  periodCost.StorageCost,
+		UploadRate:   common.BigInt0,
+		DownloadRate: common.BigInt0,
+		StorageRate:  common.BigInt0,
+	}
+	forecast.Spent = periodCost.ContractFees.Add(periodCost.UploadCost).Add(periodCost.DownloadCost).Add(periodCost.StorageCost)
+	forecast.Paused = forecast.HardCap.Sign() > 0 && forecast.Spent.Cmp(forecast.HardCap) >= 0
+
+	// calculate the spending rates from the oldest sample
+	if len(cm.spendingSamples) > 0 && cm.spendingSamples[0].blockHeight < cm.blockHeight {
+		oldest := cm.spendingSamples[0]
+		blocks := cm.blockHeight - oldest.blockHeight
+		forecast.UploadRate = spendingRate(oldest.uploadCost, periodCost.UploadCost, blocks)
+		forecast.DownloadRate = spendingRate(oldest.downloadCost, periodCost.DownloadCost, blocks)
+		forecast.StorageRate = spendingRate(oldest.storageCost, periodCost.StorageCost, blocks)
+	}
+
+	// forecast the spending at the end of the period
+	rate := forecast.UploadRate.Add(forecast.DownloadRate).Add(forecast.StorageRate)
+	var remainingBlocks uint64
+	if forecast.PeriodEnd > cm.blockHeight {
+		remainingBlocks = forecast.PeriodEnd - cm.blockHeight
+	}
+	forecast.Forecast = forecast.Spent.Add(rate.MultUint64(remainingBlocks))
+
+	// calculate the block height at which the fund runs out
+	if forecast.Forecast.Cmp(forecast.Fund) > 0 {
+		if forecast.Spent.Cmp(forecast.Fund) >= 0 {
+			forecast.ExhaustHeight = cm.blockHeight
+		} else {
+			blocks := forecast.Fund.Sub(forecast.Spent).Div(rate)
+			forecast.ExhaustHeight = cm.blockHeight + uint64(blocks.Float64())
+		}
+	}
+	return
+}
+
+// spendingRate calculates the spending per block from the previous cost to the current cost
+func spendingRate(prev, current common.BigInt, blocks uint64) common.BigInt {
+	if current.Cmp(prev) <= 0 || blocks == 0 {
+		return common.BigInt0
+	}
+	return current.Sub(prev).DivUint64(blocks)
+}
@@ -0,0 +1,151 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package contractmanager
+
+import (
+	"os"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+func TestContractManager_SpendingForecast(t *testing.T) {
+	cm, err := createNewContractManager()
+	if err != nil {
+		t.Fatalf("failed to create contract manager: %s", err.Error())
+	}
+	defer os.RemoveAll("test")
+	defer cm.activeContracts.Close()
+	defer cm.activeContracts.EmptyDB()
+
+	cm.rentPayment.Fund = common.NewBigIntUint64(1000)
+	cm.rentPayment.Period = 1000
+	cm.currentPeriod = 0
+
+	tests := []struct {
+		blockHeight  uint64
+		uploadCost   uint64
+		spent        uint64
+		uploadRate   uint64
+		forecast     uint64
+		exhaust      uint64
+		alertsRaised int
+	}{
+		// the first sample, no spending rate yet
+		{100, 100, 100, 0, 100, 0, 0},
+		// 5 camel per block, the 50% alert and the forecast alert are raised
+		{200, 600, 600, 5, 4600, 280, 2},
+		// the alerts are not raised again within the period
+		{205, 650, 650, 5, 4625, 275, 0},
+		// the 80% alert is raised
+		{300, 850, 850, 3, 2950, 350, 1},
+	}
+
+	id := storage.ContractID{1}
+	for i, test := range tests {
+		cm.lock.Lock()
+		cm.blockHeight = test.blockHeight
+		cm.expiredContracts[id] = storage.ContractMetaData{
+			ID:           id,
+			UploadCost:   common.NewBigIntUint64(test.uploadCost),
+			DownloadCost: common.BigInt0,
+			StorageCost:  common.BigInt0,
+			ContractFee:  common.BigInt0,
+			GasCost:      common.BigInt0,
+			TotalCost:    common.NewBigIntUint64(1000),
+		}
+		cm.lock.Unlock()
+
+		prevAlerts := len(cm.RetrieveBudgetAlerts())
+		cm.checkBudget()
+		forecast := cm.RetrieveSpendingForecast()
+
+		if forecast.Spent.Cmp(common.NewBigIntUint64(test.spent)) != 0 {
+			t.Errorf("test %d: spent expected %v, got %v", i, test.spent, forecast.Spent)
+		}
+		if forecast.UploadRate.Cmp(common.NewBigIntUint64(test.uploadRate)) != 0 {
+			t.Errorf("test %d: upload rate expected %v, got %v", i, test.uploadRate, forecast.UploadRate)
+		}
+		if forecast.Forecast.Cmp(common.NewBigIntUint64(test.forecast)) != 0 {
+			t.Errorf("test %d: forecast expected %v, got %v", i, test.forecast, forecast.Forecast)
+		}
+		if forecast.ExhaustHeight != test.exhaust {
+			t.Errorf("test %d: exhaust height expected %v, got %v", i, test.exhaust, forecast.ExhaustHeight)
+		}
+		if raised := len(cm.RetrieveBudgetAlerts()) - prevAlerts; raised != test.alertsRaised {
+			t.Errorf("test %d: expected %v alerts raised, got %v", i, test.alertsRaised, raised)
+		}
+	}
+}
+
+func TestContractManager_SpendingHardCap(t *testing.T) {
+	cm, err := createNewContractManager()
+	if err != nil {
+		t.Fatalf("failed to create contract manager: %s", err.Error())
+	}
+	defer os.RemoveAll("test")
+	defer cm.activeContracts.Close()
+	defer cm.activeContracts.EmptyDB()
+
+	cm.rentPayment.Fund = common.NewBigIntUint64(1000)
+	cm.rentPayment.Period = 1000
+	cm.currentPeriod = 0
+	cm.blockHeight = 100
+
+	id := storage.ContractID{1}
+	cm.expiredContracts[id] = storage.ContractMetaData{
+		ID:           id,
+		UploadCost:   common.NewBigIntUint64(300),
+		DownloadCost: common.NewBigIntUint64(200),
+		StorageCost:  common.BigInt0,
+		ContractFee:  common.BigInt0,
+		GasCost:      common.BigInt0,
+		TotalCost:    common.NewBigIntUint64(1000),
+	}
+
+	if err = cm.SetBudget(BudgetConfig{AlertThresholds: []float64{-1}}); err == nil {
+		t.Errorf("negative alert threshold shall not be accepted")
+	}
+
+	// the spending 500 is below the hard cap
+	if err = cm.SetBudget(BudgetConfig{HardCap: common.NewBigIntUint64(600)}); err != nil {
+		t.Fatalf("failed to set the budget: %s", err.Error())
+	}
+	if cm.SpendingPaused() {
+		t.Errorf("spending below the hard cap shall not be paused")
+	}
+
+	// the spending reaches the hard cap
+	if err = cm.SetBudget(BudgetConfig{HardCap: common.NewBigIntUint64(500)}); err != nil {
+		t.Fatalf("failed to set the budget: %s", err.Error())
+	}
+	if !cm.SpendingPaused() {
+		t.Errorf("spending reaching the hard cap shall be paused")
+	}
+	alerts := cm.RetrieveBudgetAlerts()
+	if len(alerts) != 1 || alerts[0].Kind != AlertHardCap {
+		t.Errorf("expected the hard cap alert, got %v", alerts)
+	}
+
+	// the budget shall be persisted
+	cm.lock.Lock()
+	cm.budget = defaultBudgetConfig()
+	cm.lock.Unlock()
+	if err = cm.loadSettings(); err != nil {
+		t.Fatalf("failed to load the settings: %s", err.Error())
+	}
+	if budget := cm.RetrieveBudget(); budget.HardCap.Cmp(common.NewBigIntUint64(500)) != 0 || len(budget.AlertThresholds) != 0 {
+		t.Errorf("the budget is not persisted: %v", budget)
+	}
+
+	// removing the hard cap resumes the spending
+	if err = cm.SetBudget(defaultBudgetConfig()); err != nil {
+		t.Fatalf("failed to set the budget: %s", err.Error())
+	}
+	if cm.SpendingPaused() {
+		t.Errorf("spending without the hard cap shall not be paused")
+	}
+}
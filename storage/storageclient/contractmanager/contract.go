@@ -292,6 +292,11 @@ func (cm *ContractManager) removeHostWithDuplicateNetworkAddress() {
 			continue
 		}
 
+		// the contract with the pinned storage host is never dropped
+		if cm.isHostPinned(hid) {
+			continue
+		}
+
 		// cancel the contract if exists
 		if err := cm.markContractCancel(contractID); err != nil {
 			cm.log.Error("failed to mark the contract's status as canceled", "err", err.Error())
@@ -488,11 +493,13 @@ func (cm *ContractManager) checkContractStatus(contract storage.ContractMetaData
 	}
 
 	// check the storage host's evaluation, if the evaluation is smaller than baseline, mark
-	// the upload and renew ability to be false
+	// the upload and renew ability to be false. The contract with the pinned storage host
+	// is never dropped
 	eval := cm.hostManager.Evaluation(host)
+	pinned := cm.isHostPinned(contract.EnodeID)
 
 	// if the baseline is bigger than 0 and the host evaluation is smaller than the baseline
-	if eval.Cmp(evalBaseline) < 0 && evalBaseline.Cmp(common.BigInt0) > 0 {
+	if !pinned && eval.Cmp(evalBaseline) < 0 && evalBaseline.Cmp(common.BigInt0) > 0 {
 		stats.UploadAbility = false
 		stats.RenewAbility = false
 		return
@@ -501,15 +508,16 @@ func (cm *ContractManager) checkContractStatus(contract storage.ContractMetaData
 	// check if the storage host if offline, if so, mark the upload and renew ability to be false
 	if isOffline(host) {
 		stats.UploadAbility = false
-		stats.RenewAbility = false
+		stats.RenewAbility = pinned && stats.RenewAbility
 		return
 	}
 
 	// check if the contract should be renewed, if so, mark the contract upload ability to be false
 	cm.lock.RLock()
 	blockHeight := cm.blockHeight
-	renewWindow := cm.rentPayment.RenewWindow
-	period := cm.rentPayment.Period
+	rentPayment := cm.profileRentPayment(cm.contractProfiles[contract.ID])
+	renewWindow := rentPayment.RenewWindow
+	period := rentPayment.Period
 	cm.lock.RUnlock()
 
 	// if the contract is expected to be renewed already
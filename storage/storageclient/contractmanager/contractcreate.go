@@ -12,6 +12,7 @@ import (
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/internal/ethapi"
 	"github.com/DxChainNetwork/godx/log"
 	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/rlp"
@@ -20,17 +21,17 @@ import (
 	"github.com/DxChainNetwork/godx/storage/storagehost"
 )
 
-// prepareCreateContract refers that client will sign some contracts with hosts, which satisfies the upload/download demand
-func (cm *ContractManager) prepareCreateContract(neededContracts int, clientRemainingFund common.BigInt, rentPayment storage.RentPayment) (terminated bool, err error) {
+// prepareCreateContract refers that client will sign some contracts of the profile with hosts, which satisfies the upload/download demand
+func (cm *ContractManager) prepareCreateContract(profile string, neededContracts int, clientRemainingFund common.BigInt, rentPayment storage.RentPayment) (terminated bool, err error) {
 	// get some random hosts for contract formation
-	randomHosts, err := cm.randomHostsForContractForm(neededContracts)
+	randomHosts, err := cm.randomHostsForContractForm(profile, neededContracts)
 	if err != nil {
 		return
 	}
 
 	cm.lock.RLock()
 	contractFund := rentPayment.Fund.DivUint64(rentPayment.StorageHosts).DivUint64(3)
-	contractEndHeight := cm.currentPeriod + rentPayment.Period + rentPayment.RenewWindow
+	contractEndHeight := cm.profilePeriod(profile) + rentPayment.Period + rentPayment.RenewWindow
 	cm.lock.RUnlock()
 
 	// loop through each host and try to form contract with them
@@ -43,7 +44,7 @@ func (cm *ContractManager) prepareCreateContract(neededContracts int, clientRema
 		}
 
 		// start to form contract
-		formCost, contract, errFormContract := cm.createContract(host, contractFund, contractEndHeight, rentPayment)
+		formCost, contract, errFormContract := cm.createContract(profile, host, contractFund, contractEndHeight, rentPayment)
 		// if contract formation failed, the error do not need to be returned, just try to form the
 		// contract with another storage host
 		if errFormContract != nil {
@@ -82,7 +83,7 @@ func (cm *ContractManager) prepareCreateContract(neededContracts int, clientRema
 // 		2. form the contract create parameters
 // 		3. start to create the contract
 // 		4. update the contract manager fields
-func (cm *ContractManager) createContract(host storage.HostInfo, contractFund common.BigInt, contractEndHeight uint64, rentPayment storage.RentPayment) (formCost common.BigInt, newlyCreatedContract storage.ContractMetaData, err error) {
+func (cm *ContractManager) createContract(profile string, host storage.HostInfo, contractFund common.BigInt, contractEndHeight uint64, rentPayment storage.RentPayment) (formCost common.BigInt, newlyCreatedContract storage.ContractMetaData, err error) {
 	// 1. storage host validation
 	// validate the storage price
 	if host.StoragePrice.Cmp(maxHostStoragePrice) > 0 {
@@ -110,10 +111,10 @@ func (cm *ContractManager) createContract(host storage.HostInfo, contractFund co
 	startHeight := cm.blockHeight
 	cm.lock.RUnlock()
 
-	// try to get the clientPaymentAddress. If failed, return error directly and set the contract creation cost
-	// to be zero
+	// try to get the clientPaymentAddress of the profile. If failed, return error directly and set the
+	// contract creation cost to be zero
 	var clientPaymentAddress common.Address
-	if clientPaymentAddress, err = cm.b.GetPaymentAddress(); err != nil {
+	if clientPaymentAddress, err = cm.paymentAddress(profile); err != nil {
 		formCost = common.BigInt0
 		err = fmt.Errorf("failed to create the contract with host: %v, failed to get the clientPayment address: %s", host.EnodeID, err.Error())
 		return
@@ -147,16 +148,20 @@ func (cm *ContractManager) createContract(host storage.HostInfo, contractFund co
 		return
 	}
 
-	// if not exists, update the host to contract mapping
+	// if not exists, update the host to contract mapping and the profile of the contract
 	cm.hostToContract[newlyCreatedContract.EnodeID] = newlyCreatedContract.ID
+	if profile != "" {
+		cm.contractProfiles[newlyCreatedContract.ID] = profile
+	}
 	cm.lock.Unlock()
 
 	formCost = contractFund
 	return
 }
 
-// randomHostsForContractForm will randomly retrieve some storage hosts from the storage host pool
-func (cm *ContractManager) randomHostsForContractForm(neededContracts int) (randomHosts []storage.HostInfo, err error) {
+// randomHostsForContractForm will randomly retrieve some storage hosts from the storage host pool. If the
+// storage hosts of the profile are specified, the hosts are retrieved from them instead
+func (cm *ContractManager) randomHostsForContractForm(profile string, neededContracts int) (randomHosts []storage.HostInfo, err error) {
 	// for all active contracts, the storage host will be added to be blacklist
 	// for all active contracts which are not canceled, good for uploading, and renewing
 	// the storage host will be added to the addressBlackList
@@ -175,6 +180,11 @@ func (cm *ContractManager) randomHostsForContractForm(neededContracts int) (rand
 	}
 	cm.lock.RUnlock()
 
+	// the storage hosts of the profile without contracts
+	if hosts := cm.profileHosts(profile); len(hosts) != 0 {
+		return cm.availableProfileHosts(hosts, blackList), nil
+	}
+
 	// randomly retrieve some hosts
 	return cm.hostManager.RetrieveRandomHosts(neededContracts*randomStorageHostsFactor+randomStorageHostsBackup, blackList, addressBlackList)
 }
@@ -184,6 +194,13 @@ func (cm *ContractManager) randomHostsForContractForm(neededContracts int) (rand
 func (cm *ContractManager) ContractCreate(params storage.ContractParams) (md storage.ContractMetaData, err error) {
 	rentPayment, funding, clientPaymentAddress, startHeight, endHeight, host := params.RentPayment, params.Funding, params.ClientPaymentAddress, params.StartHeight, params.EndHeight, params.Host
 
+	// the storage forks active now and at the proof window must both be supported
+	for _, height := range []uint64{startHeight, endHeight} {
+		if err = storage.CheckStorageFork(cm.b.ChainConfig(), height); err != nil {
+			return storage.ContractMetaData{}, err
+		}
+	}
+
 	// Calculate the payouts for the client, host, and whole contract
 	period := endHeight - startHeight
 	expectedStorage := rentPayment.ExpectedStorage / rentPayment.StorageHosts
@@ -353,7 +370,7 @@ func (cm *ContractManager) ContractCreate(params storage.ContractParams) (md sto
 		return storage.ContractMetaData{}, clientNegotiateErr
 	}
 
-	if _, err := cm.b.SendStorageContractCreateTx(clientPaymentAddress, scBytes); err != nil {
+	if _, err := cm.b.SendStorageContractCreateTx(clientPaymentAddress, scBytes); err != nil && err != ethapi.ErrStorageTxQueued {
 		clientNegotiateErr = storagehost.ExtendErr("Send storage contract creation transaction error", err)
 		return storage.ContractMetaData{}, clientNegotiateErr
 	}
@@ -49,6 +49,23 @@ type ContractManager struct {
 	renewedTo        map[storage.ContractID]storage.ContractID
 	failedRenewCount map[storage.ContractID]uint64
 
+	// contracts with the pinned storage hosts are never dropped by the contract maintenance
+	pinnedHosts map[enode.ID]struct{}
+
+	// spending budget related, alerts are raised once per period, and the uploads and
+	// downloads are paused once the spending reaches the hard cap
+	budget          BudgetConfig
+	firedAlerts     map[string]struct{}
+	alertPeriod     uint64
+	budgetAlerts    []BudgetAlert
+	spendingSamples []spendingSample
+	spendingPaused  bool
+
+	// named storage profiles, and the profile of the contracts formed for the profiles. The
+	// contracts not in contractProfiles belong to the default profile
+	profiles         map[string]*Profile
+	contractProfiles map[storage.ContractID]string
+
 	// used to acquire storage contract
 	blockHeight   uint64
 	currentPeriod uint64
@@ -75,6 +92,11 @@ func New(persistDir string, hm *storagehostmanager.StorageHostManager) (cm *Cont
 		renewedTo:        make(map[storage.ContractID]storage.ContractID),
 		failedRenewCount: make(map[storage.ContractID]uint64),
 		hostToContract:   make(map[enode.ID]storage.ContractID),
+		pinnedHosts:      make(map[enode.ID]struct{}),
+		budget:           defaultBudgetConfig(),
+		firedAlerts:      make(map[string]struct{}),
+		profiles:         make(map[string]*Profile),
+		contractProfiles: make(map[storage.ContractID]string),
 		quit:             make(chan struct{}),
 	}
 
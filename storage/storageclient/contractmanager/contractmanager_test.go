@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/DxChainNetwork/godx/accounts"
 	"github.com/DxChainNetwork/godx/common"
@@ -84,6 +85,11 @@ func newContractManagerTest(hm *storagehostmanager.StorageHostManager) (cm *Cont
 		renewedTo:        make(map[storage.ContractID]storage.ContractID),
 		failedRenewCount: make(map[storage.ContractID]uint64),
 		hostToContract:   make(map[enode.ID]storage.ContractID),
+		pinnedHosts:      make(map[enode.ID]struct{}),
+		budget:           defaultBudgetConfig(),
+		firedAlerts:      make(map[string]struct{}),
+		profiles:         make(map[string]*Profile),
+		contractProfiles: make(map[storage.ContractID]string),
 		quit:             make(chan struct{}),
 		log:              log.New(),
 	}
@@ -138,6 +144,10 @@ func (st *storageClientBackendContractManager) GetStorageHostSetting(hostEnodeID
 	return nil
 }
 
+func (st *storageClientBackendContractManager) ProbeStorageHost(hostEnodeURL string, req storage.HostProbeRequest) (time.Duration, error) {
+	return 0, nil
+}
+
 func (st *storageClientBackendContractManager) SubscribeChainChangeEvent(ch chan<- core.ChainChangeEvent) event.Subscription {
 	return nil
 }
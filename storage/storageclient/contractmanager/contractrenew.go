@@ -14,6 +14,7 @@ import (
 	"github.com/DxChainNetwork/godx/common/math"
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/internal/ethapi"
 	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/rlp"
 	"github.com/DxChainNetwork/godx/storage"
@@ -22,12 +23,12 @@ import (
 	dberrors "github.com/syndtr/goleveldb/leveldb/errors"
 )
 
-// checkForContractRenew will loop through all active contracts and filter out those needs to be renewed.
+// checkForContractRenew will loop through all active contracts of the profile and filter out those needs to be renewed.
 // There are two types of contract needs to be renewed
 // 		1. contracts that are about to expired. they need to be renewed
 // 		2. contracts that have insufficient amount of funding, meaning the contract is about to be
 // 		   marked as not good for data uploading
-func (cm *ContractManager) checkForContractRenew(rentPayment storage.RentPayment) (closeToExpireRenews []contractRenewRecord, insufficientFundingRenews []contractRenewRecord) {
+func (cm *ContractManager) checkForContractRenew(profile string, rentPayment storage.RentPayment) (closeToExpireRenews []contractRenewRecord, insufficientFundingRenews []contractRenewRecord) {
 
 	cm.lock.RLock()
 	currentBlockHeight := cm.blockHeight
@@ -35,6 +36,11 @@ func (cm *ContractManager) checkForContractRenew(rentPayment storage.RentPayment
 
 	// loop through all active contracts, get the closeToExpireRenews and insufficientFundingRenews
 	for _, contract := range cm.activeContracts.RetrieveAllContractsMetaData() {
+		// the contracts of other profiles are renewed based on their own rent payment
+		if cm.ContractProfile(contract.ID) != profile {
+			continue
+		}
+
 		// validate the storage host for the contract, check if the host exists or get filtered
 		host, exists := cm.hostManager.RetrieveHostInfo(contract.EnodeID)
 		if !exists || host.Filtered {
@@ -77,13 +83,20 @@ func (cm *ContractManager) checkForContractRenew(rentPayment storage.RentPayment
 }
 
 // resetFailedRenews will update the failedRenewCount list, which only includes the failedRenewCount
-// in the current renew lists
-func (cm *ContractManager) resetFailedRenews(closeToExpireRenews []contractRenewRecord, insufficientFundingRenews []contractRenewRecord) {
+// in the current renew lists of the profile, and the failedRenewCount of the other profiles
+func (cm *ContractManager) resetFailedRenews(profile string, closeToExpireRenews []contractRenewRecord, insufficientFundingRenews []contractRenewRecord) {
 	cm.lock.Lock()
 	defer cm.lock.Unlock()
 
 	filteredFailedRenews := make(map[storage.ContractID]uint64)
 
+	// keep the failedRenewCount of the contracts of the other profiles
+	for contractID, count := range cm.failedRenewCount {
+		if cm.contractProfiles[contractID] != profile {
+			filteredFailedRenews[contractID] = count
+		}
+	}
+
 	// loop through the closeToExpireRenews, get the failedRenewCount
 	for _, renewRecord := range closeToExpireRenews {
 		contractID := renewRecord.id
@@ -105,15 +118,15 @@ func (cm *ContractManager) resetFailedRenews(closeToExpireRenews []contractRenew
 }
 
 // prepareContractRenew will loop through all record in the renewRecords and start to renew
-// each contract. Before contract renewing get started, the fund will be validated first.
-func (cm *ContractManager) prepareContractRenew(renewRecords []contractRenewRecord, clientRemainingFund common.BigInt, rentPayment storage.RentPayment) (remainingFund common.BigInt, terminate bool) {
+// each contract of the profile. Before contract renewing get started, the fund will be validated first.
+func (cm *ContractManager) prepareContractRenew(profile string, renewRecords []contractRenewRecord, clientRemainingFund common.BigInt, rentPayment storage.RentPayment) (remainingFund common.BigInt, terminate bool) {
 
 	cm.log.Debug("Prepare to renew the contract")
 
 	// get the data needed
 	cm.lock.RLock()
-	currentPeriod := cm.currentPeriod
-	contractEndHeight := cm.currentPeriod + rentPayment.Period + rentPayment.RenewWindow
+	currentPeriod := cm.profilePeriod(profile)
+	contractEndHeight := currentPeriod + rentPayment.Period + rentPayment.RenewWindow
 	cm.lock.RUnlock()
 
 	// initialize remaining fund first
@@ -163,9 +176,15 @@ func (cm *ContractManager) contractRenewStart(record contractRenewRecord, curren
 	}
 
 	// if the contract is revising, return error directly
-	if cm.b.TryToRenewOrRevise(contractMeta.EnodeID) {
+	host, exists := cm.hostManager.RetrieveHostInfo(contractMeta.EnodeID)
+	if !exists {
+		renewCost = common.BigInt0
+		err = fmt.Errorf("the storage host of the contract that is trying to be renewed cannot be found")
+		return
+	}
+	if err = cm.lockHostOperation(host); err != nil {
 		renewCost = common.BigInt0
-		err = fmt.Errorf("the contract is revising, cannot be renewed")
+		err = fmt.Errorf("the contract cannot be renewed: %s", err.Error())
 		return
 	}
 
@@ -301,10 +320,11 @@ func (cm *ContractManager) renew(renewContract *contractset.Contract, rentPaymen
 	startHeight := cm.blockHeight
 	cm.lock.RUnlock()
 
-	// try to get the clientPaymentAddress. If failed, return error directly and set the contract creation cost
-	// to be zero
+	// try to get the clientPaymentAddress of the profile. If failed, return error directly and set the
+	// contract creation cost to be zero
+	profile := cm.ContractProfile(contractMeta.ID)
 	var clientPaymentAddress common.Address
-	if clientPaymentAddress, err = cm.b.GetPaymentAddress(); err != nil {
+	if clientPaymentAddress, err = cm.paymentAddress(profile); err != nil {
 		err = fmt.Errorf("failed to create the contract with host: %v, failed to get the clientPayment address: %s", host.EnodeID, err.Error())
 		return
 	}
@@ -325,9 +345,12 @@ func (cm *ContractManager) renew(renewContract *contractset.Contract, rentPaymen
 		return
 	}
 
-	// 5. update the storage host to contract id mapping
+	// 5. update the storage host to contract id mapping, the renewed contract belongs to the same profile
 	cm.lock.Lock()
 	cm.hostToContract[renewedContract.EnodeID] = renewedContract.ID
+	if profile != "" {
+		cm.contractProfiles[renewedContract.ID] = profile
+	}
 	cm.lock.Unlock()
 
 	return
@@ -354,7 +377,7 @@ func (cm *ContractManager) handleRenewFailed(failedContract *contractset.Contrac
 	cm.lock.RUnlock()
 
 	secondHalfRenewWindow := blockHeight+rentPayment.RenewWindow/2 >= failedContract.Metadata().EndHeight
-	contractReplace := numFailed >= consecutiveRenewFailsBeforeReplacement
+	contractReplace := numFailed >= consecutiveRenewFailsBeforeReplacement && !cm.isHostPinned(failedContract.Metadata().EnodeID)
 
 	// if the contract has been failed before, passed the second half renew window, and need replacement
 	// mark the contract that is trying to be renewed as canceled
@@ -555,7 +578,7 @@ func (cm *ContractManager) ContractRenew(oldContract *contractset.Contract, para
 		return storage.ContractMetaData{}, err
 	}
 
-	if _, err := cm.b.SendStorageContractCreateTx(clientAddr, scBytes); err != nil {
+	if _, err := cm.b.SendStorageContractCreateTx(clientAddr, scBytes); err != nil && err != ethapi.ErrStorageTxQueued {
 		clientNegotiateErr = storagehost.ExtendErr("Send storage contract creation transaction error", err)
 		return storage.ContractMetaData{}, clientNegotiateErr
 	}
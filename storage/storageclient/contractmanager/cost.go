@@ -95,28 +95,40 @@ func (cm *ContractManager) renewCostEstimation(host storage.HostInfo, contract s
 	return
 }
 
-// CalculatePeriodCost will calculate the storage client's cost for one period (including all contracts)
+// CalculatePeriodCost will calculate the storage client's cost for one period (including all contracts
+// of the default profile)
 func (cm *ContractManager) CalculatePeriodCost(rentPayment storage.RentPayment) (periodCost storage.PeriodCost) {
+	return cm.calculatePeriodCost("", rentPayment)
+}
+
+// calculatePeriodCost will calculate the storage client's cost for one period of the profile
+func (cm *ContractManager) calculatePeriodCost(profile string, rentPayment storage.RentPayment) (periodCost storage.PeriodCost) {
 	// get all activeContracts
 	activeContracts := cm.activeContracts.RetrieveAllContractsMetaData()
 
 	cm.lock.RLock()
 	defer cm.lock.RUnlock()
+	currentPeriod := cm.profilePeriod(profile)
 
-	// loop through all signed contract, get the total cost spent
+	// loop through all signed contract of the profile, get the total cost spent
 	// by the storage client
 	for _, contract := range activeContracts {
-		updatePrevContractCost(&periodCost, contract)
+		if cm.contractProfiles[contract.ID] == profile {
+			updatePrevContractCost(&periodCost, contract)
+		}
 	}
 
 	// loop through expired contracts list, update the money spent by the storage client
 	// for expiredContracts, only contract within the current period will be used to do the
 	// PeriodCost calculation
 	for _, contract := range cm.expiredContracts {
+		if cm.contractProfiles[contract.ID] != profile {
+			continue
+		}
 		host, exists := cm.hostManager.RetrieveHostInfo(contract.EnodeID)
 		// it is possible that the expiredContract (got renewed but still not expired, old contract)
 		// started within the current period. Therefore, add cost for that contract as well
-		if contract.StartHeight >= cm.currentPeriod {
+		if contract.StartHeight >= currentPeriod {
 			updatePrevContractCost(&periodCost, contract)
 		} else if exists && contract.EndHeight+host.WindowSize+maturityDelay > cm.blockHeight {
 			// if the host exists, and the contract is still waiting for the storage proof
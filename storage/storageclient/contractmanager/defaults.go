@@ -9,6 +9,7 @@ import (
 	"math/big"
 
 	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
 )
 
 // persistent related constants
@@ -37,6 +38,19 @@ const (
 	consecutiveRenewFailsBeforeReplacement = 12
 )
 
+// budget related constants
+const (
+	// spendingSampleInterval is the minimum number of blocks between two spending samples
+	spendingSampleInterval = uint64(10)
+
+	// maxBudgetAlerts is the max number of budget alerts kept
+	maxBudgetAlerts = 100
+)
+
+// spendingTrendWindow is the number of blocks of the spending samples used to calculate
+// the spending rates
+var spendingTrendWindow = storage.BlocksPerDay
+
 // variables below are used to calculate the maxHostStoragePrice and maxHostDeposit, which set
 // a limitation to storage host's configuration
 var (
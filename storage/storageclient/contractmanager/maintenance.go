@@ -56,35 +56,60 @@ func (cm *ContractManager) contractMaintenance() {
 	// contract renew and contract create
 	cm.lock.RLock()
 	rentPayment := cm.rentPayment
+	storageHosts := rentPayment.StorageHosts
+	var profiles []Profile
+	for _, p := range cm.profiles {
+		profiles = append(profiles, p.copy())
+		storageHosts += p.RentPayment.StorageHosts
+	}
 	cm.lock.RUnlock()
 
-	// when RentPayment is empty, meaning that the storage client does
-	// not want to sign contract with anyone
-	if reflect.DeepEqual(rentPayment, storage.RentPayment{}) {
+	// when RentPayment is empty and there is no profile, meaning that the storage
+	// client does not want to sign contract with anyone
+	emptyRentPayment := reflect.DeepEqual(rentPayment, storage.RentPayment{})
+	if emptyRentPayment && len(profiles) == 0 {
 		return
 	}
 
-	if err := cm.maintainContractStatus(int(rentPayment.StorageHosts)); err != nil {
+	if err := cm.maintainContractStatus(int(storageHosts)); err != nil {
 		log.Error("failed to maintain contract status, contractMaintenance terminating", "err", err.Error())
 		return
 	}
 
+	// maintain the contracts of the default profile, and then the contracts of each profile
+	if !emptyRentPayment {
+		if terminated := cm.maintainProfileContracts("", rentPayment); terminated {
+			return
+		}
+	}
+	for _, p := range profiles {
+		if terminated := cm.maintainProfileContracts(p.Name, p.RentPayment); terminated {
+			return
+		}
+	}
+}
+
+// maintainProfileContracts renews the contracts of the profile, and creates more contracts if needed
+// based on the rent payment of the profile. Empty profile refers to the default profile
+func (cm *ContractManager) maintainProfileContracts(profile string, rentPayment storage.RentPayment) (terminated bool) {
 	// get the contract renew list
-	closeToExpireRenews, insufficientFundingRenews := cm.checkForContractRenew(rentPayment)
+	closeToExpireRenews, insufficientFundingRenews := cm.checkForContractRenew(profile, rentPayment)
 
 	// reset the failed renew set, making sure it only keep track of
 	// current renew list
-	cm.resetFailedRenews(closeToExpireRenews, insufficientFundingRenews)
+	cm.resetFailedRenews(profile, closeToExpireRenews, insufficientFundingRenews)
 
 	// calculate amount of money the storage client need to spend within
-	// one period cycle. It includes cost for all contracts
+	// one period cycle. It includes cost for all contracts of the profile
 	var clientRemainingFund common.BigInt
-	periodCost := cm.CalculatePeriodCost(rentPayment)
+	periodCost := cm.calculatePeriodCost(profile, rentPayment)
 
 	// update the periodCost in the contract manager
-	cm.lock.Lock()
-	cm.periodCost = periodCost
-	cm.lock.Unlock()
+	if profile == "" {
+		cm.lock.Lock()
+		cm.periodCost = periodCost
+		cm.lock.Unlock()
+	}
 
 	// calculate the clientRemainingFund, in case the remaining fund is negative
 	// set it to 0
@@ -94,15 +119,15 @@ func (cm *ContractManager) contractMaintenance() {
 	}
 
 	// start to renew the contracts in the closeToExpireRenews list, which has higher priority
-	clientRemainingFund, terminate := cm.prepareContractRenew(closeToExpireRenews, clientRemainingFund, rentPayment)
-	if terminate {
+	clientRemainingFund, terminated = cm.prepareContractRenew(profile, closeToExpireRenews, clientRemainingFund, rentPayment)
+	if terminated {
 		log.Debug("[closeToExpireRenews]prepareContractRenew terminate", "clientRemainingFund", clientRemainingFund)
 		return
 	}
 
 	// start to renew contract in the insufficientFundingRenews list, lower priority
-	clientRemainingFund, terminate = cm.prepareContractRenew(insufficientFundingRenews, clientRemainingFund, rentPayment)
-	if terminate {
+	clientRemainingFund, terminated = cm.prepareContractRenew(profile, insufficientFundingRenews, clientRemainingFund, rentPayment)
+	if terminated {
 		log.Debug("[insufficientFundingRenews]prepareContractRenew terminate", "clientRemainingFund", clientRemainingFund)
 		return
 	}
@@ -111,7 +136,7 @@ func (cm *ContractManager) contractMaintenance() {
 	// calculate how many extra contracts are needed
 	var uploadableContracts uint64
 	for _, contract := range cm.activeContracts.RetrieveAllContractsMetaData() {
-		if contract.Status.UploadAbility {
+		if contract.Status.UploadAbility && cm.ContractProfile(contract.ID) == profile {
 			uploadableContracts++
 		}
 	}
@@ -122,18 +147,21 @@ func (cm *ContractManager) contractMaintenance() {
 		return
 	}
 
-	// prepare to for forming contract based on the number of extract contracts needed
-	terminated, err := cm.prepareCreateContract(neededContracts, clientRemainingFund, rentPayment)
-	if err != nil {
-		cm.log.Error("failed to create the contract", "err", err.Error())
+	// no new contract will be formed once the spending reached the hard cap, while
+	// the contracts are still renewed to keep the data stored
+	if profile == "" && cm.SpendingPaused() {
+		cm.log.Debug("the spending reached the hard cap, stop forming new contracts")
 		return
 	}
 
-	// why terminated is checked explicitly?
-	// in case more codes need to be added in the future after this function
-	if terminated {
+	// prepare to for forming contract based on the number of extract contracts needed
+	terminated, err := cm.prepareCreateContract(profile, neededContracts, clientRemainingFund, rentPayment)
+	if err != nil {
+		cm.log.Error("failed to create the contract", "profile", profile, "err", err.Error())
 		return
 	}
+
+	return
 }
 
 // checkMaintenanceTermination will check if the maintenanceStop signal has been sent
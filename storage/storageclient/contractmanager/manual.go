@@ -0,0 +1,234 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package contractmanager
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// errHostOperating indicates the contract with the storage host is currently being revised or renewed
+var errHostOperating = errors.New("the contract with the storage host is currently revising or renewing, please try again later")
+
+// ManualCreateContract creates the contract with the storage host specified by the user. The contract
+// is funded with fund, and lasts for period blocks. Zero fund or zero period means the value derived
+// from the rent payment, the same as the contracts created automatically
+func (cm *ContractManager) ManualCreateContract(hostID enode.ID, fund common.BigInt, period uint64) (md storage.ContractMetaData, err error) {
+	host, exists := cm.hostManager.RetrieveHostInfo(hostID)
+	if !exists {
+		return storage.ContractMetaData{}, fmt.Errorf("the storage host %v cannot be found", hostID)
+	}
+	if host.Filtered {
+		return storage.ContractMetaData{}, fmt.Errorf("the storage host %v has been filtered", hostID)
+	}
+
+	// lock the operation with the storage host, so that the maintenance loop cannot
+	// create or renew the contract with the same storage host at the same time
+	if err = cm.lockHostOperation(host); err != nil {
+		return
+	}
+	defer cm.b.RevisionOrRenewingDone(host.EnodeID)
+
+	if id, exists := cm.activeContractWithHost(hostID); exists {
+		return storage.ContractMetaData{}, fmt.Errorf("active contract %v with the storage host already exists", id)
+	}
+
+	rentPayment, startHeight := cm.manualRentPayment()
+	if fund.IsEqual(common.BigInt0) {
+		fund = rentPayment.Fund.DivUint64(rentPayment.StorageHosts).DivUint64(3)
+	}
+	if period == 0 {
+		period = rentPayment.Period + rentPayment.RenewWindow
+	}
+	rentPayment.Period = period
+
+	// validate the storage host, and form the contract create parameters
+	if host.StoragePrice.Cmp(maxHostStoragePrice) > 0 {
+		return storage.ContractMetaData{}, fmt.Errorf("the storage price of the host %v is too high", hostID)
+	}
+	if host.MaxDeposit.Cmp(maxHostDeposit) > 0 {
+		host.MaxDeposit = maxHostDeposit
+	}
+	if host.MaxDuration < period {
+		return storage.ContractMetaData{}, fmt.Errorf("the max duration of the host %v is smaller than period", hostID)
+	}
+
+	clientPaymentAddress, err := cm.b.GetPaymentAddress()
+	if err != nil {
+		return storage.ContractMetaData{}, fmt.Errorf("failed to get the client payment address: %s", err.Error())
+	}
+
+	params := storage.ContractParams{
+		RentPayment:          rentPayment,
+		HostEnodeURL:         host.EnodeURL,
+		Funding:              fund,
+		StartHeight:          startHeight,
+		EndHeight:            startHeight + period,
+		ClientPaymentAddress: clientPaymentAddress,
+		Host:                 host,
+	}
+	if md, err = cm.ContractCreate(params); err != nil {
+		return storage.ContractMetaData{}, fmt.Errorf("failed to create the contract: %s", err.Error())
+	}
+
+	// update the contract status and the host to contract mapping
+	if err = cm.markNewlyFormedContractStats(md.ID); err != nil {
+		return
+	}
+	cm.lock.Lock()
+	cm.hostToContract[md.EnodeID] = md.ID
+	cm.lock.Unlock()
+
+	if err = cm.saveSettings(); err != nil {
+		cm.log.Warn("after created the contract manually, failed to save the contract manager settings", "err", err.Error())
+	}
+	md, _ = cm.RetrieveActiveContract(md.ID)
+	return md, nil
+}
+
+// ManualRenewContract renews the contract before it is about to expire. The renewed contract
+// is funded with fund, and extends the end height of the contract by period blocks. Zero fund
+// or zero period means the value derived from the rent payment
+func (cm *ContractManager) ManualRenewContract(id storage.ContractID, fund common.BigInt, period uint64) (md storage.ContractMetaData, err error) {
+	contract, exists := cm.RetrieveActiveContract(id)
+	if !exists {
+		return storage.ContractMetaData{}, fmt.Errorf("the contract %v cannot be found", id)
+	}
+	host, exists := cm.hostManager.RetrieveHostInfo(contract.EnodeID)
+	if !exists {
+		return storage.ContractMetaData{}, fmt.Errorf("the storage host %v cannot be found", contract.EnodeID)
+	}
+
+	rentPayment, blockHeight := cm.manualRentPayment()
+	if period != 0 {
+		rentPayment.Period = period
+	}
+	if fund.IsEqual(common.BigInt0) {
+		fund = cm.renewCostEstimation(host, contract, blockHeight, rentPayment)
+	}
+
+	cm.lock.RLock()
+	currentPeriod := cm.currentPeriod
+	cm.lock.RUnlock()
+
+	record := contractRenewRecord{id: id, cost: fund}
+	if _, err = cm.contractRenewStart(record, currentPeriod, rentPayment, contract.EndHeight+rentPayment.Period); err != nil {
+		return
+	}
+
+	cm.lock.RLock()
+	renewedID, renewed := cm.renewedTo[id]
+	cm.lock.RUnlock()
+	if !renewed {
+		return storage.ContractMetaData{}, fmt.Errorf("the contract %v is not renewed", id)
+	}
+	md, _ = cm.RetrieveActiveContract(renewedID)
+	return md, nil
+}
+
+// ManualCancelContract marks the contract as canceled, which will no longer be used for the data
+// uploading, and will not be renewed
+func (cm *ContractManager) ManualCancelContract(id storage.ContractID) (err error) {
+	contract, exists := cm.RetrieveActiveContract(id)
+	if !exists {
+		return fmt.Errorf("the contract %v cannot be found", id)
+	}
+
+	// the contract cannot be canceled while it is being revised or renewed
+	if host, exists := cm.hostManager.RetrieveHostInfo(contract.EnodeID); exists {
+		if err = cm.lockHostOperation(host); err == nil {
+			defer cm.b.RevisionOrRenewingDone(host.EnodeID)
+		} else if err == errHostOperating {
+			return
+		}
+	}
+	return cm.markContractCancel(id)
+}
+
+// PinHost pins the storage host, so that the contract with the storage host will not be
+// dropped by the contract maintenance due to the low evaluation, the ip violation, being
+// offline, or the failed renews
+func (cm *ContractManager) PinHost(hostID enode.ID) (err error) {
+	if _, exists := cm.hostManager.RetrieveHostInfo(hostID); !exists {
+		return fmt.Errorf("the storage host %v cannot be found", hostID)
+	}
+	cm.lock.Lock()
+	cm.pinnedHosts[hostID] = struct{}{}
+	cm.lock.Unlock()
+	return cm.saveSettings()
+}
+
+// UnpinHost unpins the storage host pinned before
+func (cm *ContractManager) UnpinHost(hostID enode.ID) (err error) {
+	cm.lock.Lock()
+	if _, exists := cm.pinnedHosts[hostID]; !exists {
+		cm.lock.Unlock()
+		return fmt.Errorf("the storage host %v is not pinned", hostID)
+	}
+	delete(cm.pinnedHosts, hostID)
+	cm.lock.Unlock()
+	return cm.saveSettings()
+}
+
+// PinnedHosts returns the storage hosts pinned
+func (cm *ContractManager) PinnedHosts() (hostIDs []enode.ID) {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+	for id := range cm.pinnedHosts {
+		hostIDs = append(hostIDs, id)
+	}
+	return
+}
+
+// isHostPinned checks if the storage host is pinned
+func (cm *ContractManager) isHostPinned(hostID enode.ID) bool {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+	_, pinned := cm.pinnedHosts[hostID]
+	return pinned
+}
+
+// lockHostOperation sets up the connection with the storage host, and then locks the contract
+// revision and renew with the storage host through TryToRenewOrRevise. The lock must be released
+// by RevisionOrRenewingDone once the operation is finished
+func (cm *ContractManager) lockHostOperation(host storage.HostInfo) (err error) {
+	// the connection must be set up first, otherwise TryToRenewOrRevise cannot tell if
+	// the contract is revising or the storage host is not connected
+	if _, err = cm.b.SetupConnection(host.EnodeURL); err != nil {
+		return fmt.Errorf("failed to set up the connection with the storage host: %s", err.Error())
+	}
+	if !cm.b.TryToRenewOrRevise(host.EnodeID) {
+		return errHostOperating
+	}
+	return nil
+}
+
+// activeContractWithHost returns the active contract with the storage host which is not canceled
+func (cm *ContractManager) activeContractWithHost(hostID enode.ID) (storage.ContractID, bool) {
+	for _, contract := range cm.activeContracts.RetrieveAllContractsMetaData() {
+		if contract.EnodeID == hostID && !contract.Status.Canceled {
+			return contract.ID, true
+		}
+	}
+	return storage.ContractID{}, false
+}
+
+// manualRentPayment returns the rent payment used for the contracts created or renewed manually,
+// and the current block height. The default rent payment is used if the rent payment is not set
+func (cm *ContractManager) manualRentPayment() (rentPayment storage.RentPayment, blockHeight uint64) {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+
+	rentPayment = cm.rentPayment
+	if reflect.DeepEqual(rentPayment, storage.RentPayment{}) {
+		rentPayment = storage.DefaultRentPayment
+	}
+	return rentPayment, cm.blockHeight
+}
@@ -0,0 +1,137 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package contractmanager
+
+import (
+	"os"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// manualTestBackend is the storage client backend whose contract revision and renew lock
+// can be set to be busy
+type manualTestBackend struct {
+	storageClientBackendContractManager
+	busy bool
+}
+
+func (b *manualTestBackend) TryToRenewOrRevise(hostID enode.ID) bool {
+	return !b.busy
+}
+
+func TestContractManager_PinHost(t *testing.T) {
+	cm, err := createNewContractManager()
+	if err != nil {
+		t.Fatalf("failed to create contract manager: %s", err.Error())
+	}
+	defer os.RemoveAll("test")
+	defer cm.activeContracts.Close()
+	defer cm.activeContracts.EmptyDB()
+
+	cm.blockHeight = 100
+	baseline := common.NewBigIntFloat64(10)
+
+	lowEvalContracts, err := insertLowEvalContract(cm, 1)
+	if err != nil {
+		t.Fatalf("failed to insert contract with lower host evaluation: %s", err.Error())
+	}
+	contract := lowEvalContracts[0]
+
+	if err = cm.PinHost(randomEnodeIDGenerator()); err == nil {
+		t.Errorf("storage host not exist shall not be pinned")
+	}
+	if err = cm.PinHost(contract.EnodeID); err != nil {
+		t.Fatalf("failed to pin the storage host: %s", err.Error())
+	}
+
+	// the contract with the pinned storage host shall not be dropped
+	if stats := cm.checkContractStatus(contract, baseline); !stats.RenewAbility || stats.Canceled {
+		t.Errorf("contract with the pinned storage host shall be able to renew")
+	}
+
+	// the pinned storage hosts shall be persisted
+	cm.lock.Lock()
+	cm.pinnedHosts = make(map[enode.ID]struct{})
+	cm.lock.Unlock()
+	if err = cm.loadSettings(); err != nil {
+		t.Fatalf("failed to load the settings: %s", err.Error())
+	}
+	if pinned := cm.PinnedHosts(); len(pinned) != 1 || pinned[0] != contract.EnodeID {
+		t.Errorf("pinned storage hosts not expected: %v", pinned)
+	}
+
+	if err = cm.UnpinHost(contract.EnodeID); err != nil {
+		t.Fatalf("failed to unpin the storage host: %s", err.Error())
+	}
+	if err = cm.UnpinHost(contract.EnodeID); err == nil {
+		t.Errorf("storage host not pinned shall not be unpinned")
+	}
+	if stats := cm.checkContractStatus(contract, baseline); stats.RenewAbility {
+		t.Errorf("contract with the low evaluation storage host shall not be able to renew after unpinned")
+	}
+}
+
+func TestContractManager_ManualCancelContract(t *testing.T) {
+	cm, err := createNewContractManager()
+	if err != nil {
+		t.Fatalf("failed to create contract manager: %s", err.Error())
+	}
+	defer os.RemoveAll("test")
+	defer cm.activeContracts.Close()
+	defer cm.activeContracts.EmptyDB()
+
+	highEvalContracts, err := insertHighEvalContract(cm, 1)
+	if err != nil {
+		t.Fatalf("failed to insert contract with high host evaluation: %s", err.Error())
+	}
+	contract := highEvalContracts[0]
+
+	// the contract cannot be canceled while revising or renewing
+	b := &manualTestBackend{busy: true}
+	cm.b = b
+	if err = cm.ManualCancelContract(contract.ID); err != errHostOperating {
+		t.Errorf("expect error %v, got %v", errHostOperating, err)
+	}
+
+	b.busy = false
+	if err = cm.ManualCancelContract(contract.ID); err != nil {
+		t.Fatalf("failed to cancel the contract: %s", err.Error())
+	}
+	meta, _ := cm.RetrieveActiveContract(contract.ID)
+	if !meta.Status.Canceled || meta.Status.UploadAbility || meta.Status.RenewAbility {
+		t.Errorf("contract status not expected after canceled: %+v", meta.Status)
+	}
+
+	if err = cm.ManualCancelContract(storage.ContractID{}); err == nil {
+		t.Errorf("contract not exist shall not be canceled")
+	}
+}
+
+func TestContractManager_ManualCreateContract(t *testing.T) {
+	cm, err := createNewContractManager()
+	if err != nil {
+		t.Fatalf("failed to create contract manager: %s", err.Error())
+	}
+	defer os.RemoveAll("test")
+	defer cm.activeContracts.Close()
+	defer cm.activeContracts.EmptyDB()
+
+	highEvalContracts, err := insertHighEvalContract(cm, 1)
+	if err != nil {
+		t.Fatalf("failed to insert contract with high host evaluation: %s", err.Error())
+	}
+	contract := highEvalContracts[0]
+	cm.b = &manualTestBackend{}
+
+	if _, err = cm.ManualCreateContract(randomEnodeIDGenerator(), common.BigInt0, 0); err == nil {
+		t.Errorf("contract shall not be created with the storage host not exist")
+	}
+	if _, err = cm.ManualCreateContract(contract.EnodeID, common.BigInt0, 0); err == nil {
+		t.Errorf("contract shall not be created with the storage host which has an active contract")
+	}
+}
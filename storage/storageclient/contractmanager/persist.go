@@ -6,6 +6,7 @@ package contractmanager
 
 import (
 	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/storage"
 	"os"
 	"path/filepath"
@@ -23,15 +24,21 @@ type persistence struct {
 	ExpiredContracts []storage.ContractMetaData    `json:"expiredcontracts"`
 	RenewedFrom      map[string]storage.ContractID `json:"renewedfrom"`
 	RenewedTo        map[string]storage.ContractID `json:"renewedto"`
+	PinnedHosts      []enode.ID                    `json:"pinnedhosts"`
+	Budget           *BudgetConfig                 `json:"budget,omitempty"`
+	Profiles         []Profile                     `json:"profiles"`
+	ContractProfiles map[string]string             `json:"contractprofiles"`
 }
 
 func (cm *ContractManager) persistUpdate() (persist persistence) {
 	persist = persistence{
-		Rent:          cm.rentPayment,
-		BlockHeight:   cm.blockHeight,
-		CurrentPeriod: cm.currentPeriod,
-		RenewedFrom:   make(map[string]storage.ContractID),
-		RenewedTo:     make(map[string]storage.ContractID),
+		Rent:             cm.rentPayment,
+		BlockHeight:      cm.blockHeight,
+		CurrentPeriod:    cm.currentPeriod,
+		RenewedFrom:      make(map[string]storage.ContractID),
+		RenewedTo:        make(map[string]storage.ContractID),
+		Budget:           &cm.budget,
+		ContractProfiles: make(map[string]string),
 	}
 
 	// update the renewedFrom
@@ -49,6 +56,19 @@ func (cm *ContractManager) persistUpdate() (persist persistence) {
 		persist.ExpiredContracts = append(persist.ExpiredContracts, ec)
 	}
 
+	// update the pinnedHosts
+	for id := range cm.pinnedHosts {
+		persist.PinnedHosts = append(persist.PinnedHosts, id)
+	}
+
+	// update the profiles and the profile of the contracts
+	for _, p := range cm.profiles {
+		persist.Profiles = append(persist.Profiles, p.copy())
+	}
+	for id, profile := range cm.contractProfiles {
+		persist.ContractProfiles[id.String()] = profile
+	}
+
 	return
 }
 
@@ -113,6 +133,30 @@ func (cm *ContractManager) loadSettings() (err error) {
 		cm.expiredContracts[ec.ID] = ec
 		cm.hostToContract[ec.EnodeID] = ec.ID
 	}
+
+	// update the pinnedHosts
+	for _, id := range data.PinnedHosts {
+		cm.pinnedHosts[id] = struct{}{}
+	}
+
+	// update the profiles and the profile of the contracts
+	for _, p := range data.Profiles {
+		profile := p
+		cm.profiles[p.Name] = &profile
+	}
+	for key, profile := range data.ContractProfiles {
+		id, err := storage.StringToContractID(key)
+		if err != nil {
+			cm.log.Warn("contractmanager loadsettings contractProfiles", "err", err.Error())
+			continue
+		}
+		cm.contractProfiles[id] = profile
+	}
+
+	// the default budget is kept if the budget is not saved before
+	if data.Budget != nil {
+		cm.budget = *data.Budget
+	}
 	cm.lock.Unlock()
 
 	return
@@ -0,0 +1,282 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package contractmanager
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// DefaultProfile is the name of the default storage profile, which uses the rent payment and the
+// payment address of the storage client. The files not bound to any profile belong to it
+const DefaultProfile = "default"
+
+// Profile is a named storage profile of the storage client, with its own rent payment, payment
+// address and storage hosts. The contracts formed for the profile are only used to store the files
+// under the DxPaths bound to the profile, and the cost of the contracts is reported separately
+type Profile struct {
+	Name           string              `json:"name"`
+	RentPayment    storage.RentPayment `json:"rentPayment"`
+	PaymentAddress common.Address      `json:"paymentAddress"`
+
+	// Hosts are the storage hosts the contracts can be formed with, empty means any storage host
+	Hosts []enode.ID `json:"hosts"`
+
+	// Paths are the DxPaths bound to the profile, including their subtrees
+	Paths []string `json:"paths"`
+
+	// CurrentPeriod is the start block height of the current period of the profile
+	CurrentPeriod uint64 `json:"currentPeriod"`
+}
+
+// copy returns the deep copy of the profile
+func (p *Profile) copy() Profile {
+	cp := *p
+	cp.Hosts = append([]enode.ID{}, p.Hosts...)
+	cp.Paths = append([]string{}, p.Paths...)
+	return cp
+}
+
+// SetProfile creates the storage profile, or updates the rent payment, the payment address and
+// the storage hosts of the existing profile
+func (cm *ContractManager) SetProfile(profile Profile) (err error) {
+	if profile.Name == "" || profile.Name == DefaultProfile {
+		return fmt.Errorf("invalid profile name: %s", profile.Name)
+	}
+	if err = RentPaymentValidation(profile.RentPayment); err != nil {
+		return
+	}
+	if profile.PaymentAddress == (common.Address{}) {
+		return errors.New("the payment address of the profile must be specified")
+	}
+
+	cm.lock.Lock()
+	if prev, exists := cm.profiles[profile.Name]; exists {
+		prev.RentPayment = profile.RentPayment
+		prev.PaymentAddress = profile.PaymentAddress
+		prev.Hosts = append([]enode.ID{}, profile.Hosts...)
+	} else {
+		p := &Profile{
+			Name:           profile.Name,
+			RentPayment:    profile.RentPayment,
+			PaymentAddress: profile.PaymentAddress,
+			Hosts:          append([]enode.ID{}, profile.Hosts...),
+		}
+		if cm.blockHeight > p.RentPayment.RenewWindow {
+			p.CurrentPeriod = cm.blockHeight - p.RentPayment.RenewWindow
+		}
+		cm.profiles[profile.Name] = p
+	}
+	cm.lock.Unlock()
+
+	if err = cm.saveSettings(); err != nil {
+		return fmt.Errorf("failed to save settings persistently: %s", err.Error())
+	}
+
+	// form the contracts for the profile
+	if !cm.b.Syncing() {
+		go cm.contractMaintenance()
+	}
+	return
+}
+
+// RemoveProfile removes the storage profile. The profile cannot be removed while it still has the
+// active contracts, which shall be canceled first
+func (cm *ContractManager) RemoveProfile(name string) (err error) {
+	for _, contract := range cm.activeContracts.RetrieveAllContractsMetaData() {
+		if !contract.Status.Canceled && cm.ContractProfile(contract.ID) == name {
+			return fmt.Errorf("the profile %s still has the active contract %v", name, contract.ID)
+		}
+	}
+
+	cm.lock.Lock()
+	if _, exists := cm.profiles[name]; !exists {
+		cm.lock.Unlock()
+		return fmt.Errorf("the profile %s cannot be found", name)
+	}
+	delete(cm.profiles, name)
+	cm.lock.Unlock()
+
+	return cm.saveSettings()
+}
+
+// Profiles returns all storage profiles sorted by name
+func (cm *ContractManager) Profiles() (profiles []Profile) {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+
+	for _, p := range cm.profiles {
+		profiles = append(profiles, p.copy())
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].Name < profiles[j].Name
+	})
+	return
+}
+
+// BindPath binds the DxPath and its subtree to the profile. The path bound to another profile
+// before is moved to the profile
+func (cm *ContractManager) BindPath(dxPath storage.DxPath, name string) (err error) {
+	cm.lock.Lock()
+	p, exists := cm.profiles[name]
+	if !exists {
+		cm.lock.Unlock()
+		return fmt.Errorf("the profile %s cannot be found", name)
+	}
+	cm.unbindPath(dxPath.Path)
+	p.Paths = append(p.Paths, dxPath.Path)
+	cm.lock.Unlock()
+
+	return cm.saveSettings()
+}
+
+// UnbindPath removes the binding of the DxPath, the subtree of the path belongs to the profile
+// of the parent paths afterwards
+func (cm *ContractManager) UnbindPath(dxPath storage.DxPath) (err error) {
+	cm.lock.Lock()
+	if !cm.unbindPath(dxPath.Path) {
+		cm.lock.Unlock()
+		return fmt.Errorf("the path %s is not bound to any profile", dxPath.Path)
+	}
+	cm.lock.Unlock()
+
+	return cm.saveSettings()
+}
+
+// PathProfile returns the profile the DxPath belongs to, which is the profile bound to the nearest
+// ancestor of the path. Empty string is returned if the path belongs to the default profile
+func (cm *ContractManager) PathProfile(dxPath storage.DxPath) string {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+
+	var profile, matched string
+	for name, p := range cm.profiles {
+		for _, path := range p.Paths {
+			if isSubPath(dxPath.Path, path) && (profile == "" || len(path) > len(matched)) {
+				profile, matched = name, path
+			}
+		}
+	}
+	return profile
+}
+
+// ContractProfile returns the profile of the contract, empty string if the contract belongs to the
+// default profile
+func (cm *ContractManager) ContractProfile(id storage.ContractID) string {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+	return cm.contractProfiles[id]
+}
+
+// ProfilePeriodCost returns the cost of the current period of the profile
+func (cm *ContractManager) ProfilePeriodCost(name string) (periodCost storage.PeriodCost, err error) {
+	if name == DefaultProfile {
+		return cm.CalculatePeriodCost(cm.AcquireRentPayment()), nil
+	}
+
+	cm.lock.RLock()
+	p, exists := cm.profiles[name]
+	var rentPayment storage.RentPayment
+	if exists {
+		rentPayment = p.RentPayment
+	}
+	cm.lock.RUnlock()
+
+	if !exists {
+		return storage.PeriodCost{}, fmt.Errorf("the profile %s cannot be found", name)
+	}
+	return cm.calculatePeriodCost(name, rentPayment), nil
+}
+
+// unbindPath removes the path from the profile it is bound to, and returns whether the path is
+// bound before.
+// Require: lock cm.lock by caller
+func (cm *ContractManager) unbindPath(path string) (bound bool) {
+	for _, p := range cm.profiles {
+		for i, bp := range p.Paths {
+			if bp == path {
+				p.Paths = append(p.Paths[:i], p.Paths[i+1:]...)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// profileRentPayment returns the rent payment of the profile.
+// Require: lock cm.lock by caller
+func (cm *ContractManager) profileRentPayment(profile string) storage.RentPayment {
+	if p, exists := cm.profiles[profile]; exists {
+		return p.RentPayment
+	}
+	return cm.rentPayment
+}
+
+// profilePeriod returns the start block height of the current period of the profile.
+// Require: lock cm.lock by caller
+func (cm *ContractManager) profilePeriod(profile string) uint64 {
+	if p, exists := cm.profiles[profile]; exists {
+		return p.CurrentPeriod
+	}
+	return cm.currentPeriod
+}
+
+// paymentAddress returns the address paying for the contracts of the profile
+func (cm *ContractManager) paymentAddress(profile string) (common.Address, error) {
+	if profile == "" {
+		return cm.b.GetPaymentAddress()
+	}
+
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+	p, exists := cm.profiles[profile]
+	if !exists {
+		return common.Address{}, fmt.Errorf("the profile %s cannot be found", profile)
+	}
+	return p.PaymentAddress, nil
+}
+
+// profileHosts returns the storage hosts the contracts of the profile can be formed with, nil if
+// the profile can use any storage host
+func (cm *ContractManager) profileHosts(profile string) []enode.ID {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+	if p, exists := cm.profiles[profile]; exists {
+		return append([]enode.ID{}, p.Hosts...)
+	}
+	return nil
+}
+
+// availableProfileHosts returns the storage hosts of the profile which are not filtered, and have
+// no contract with the storage client
+func (cm *ContractManager) availableProfileHosts(hosts []enode.ID, blackList []enode.ID) (infos []storage.HostInfo) {
+	contracted := make(map[enode.ID]struct{})
+	for _, id := range blackList {
+		contracted[id] = struct{}{}
+	}
+	for _, id := range hosts {
+		if _, exists := contracted[id]; exists {
+			continue
+		}
+		if info, exists := cm.hostManager.RetrieveHostInfo(id); exists && !info.Filtered {
+			infos = append(infos, info)
+		}
+	}
+	return
+}
+
+// isSubPath checks if the path is the ancestor path itself or within the subtree of the ancestor
+func isSubPath(path, ancestor string) bool {
+	if ancestor == "" || path == ancestor {
+		return true
+	}
+	return strings.HasPrefix(path, ancestor+"/")
+}
@@ -0,0 +1,211 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package contractmanager
+
+import (
+	"os"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// profileTestBackend is the storage client backend which is syncing, so that the contract
+// maintenance is not started while setting the profiles
+type profileTestBackend struct {
+	storageClientBackendContractManager
+}
+
+func (b *profileTestBackend) Syncing() bool {
+	return true
+}
+
+func newProfileTestContractManager(t *testing.T) *ContractManager {
+	cm, err := createNewContractManager()
+	if err != nil {
+		t.Fatalf("failed to create contract manager: %s", err.Error())
+	}
+	cm.b = &profileTestBackend{}
+	return cm
+}
+
+func TestContractManager_SetProfile(t *testing.T) {
+	cm := newProfileTestContractManager(t)
+	defer os.RemoveAll("test")
+	defer cm.activeContracts.Close()
+	defer cm.activeContracts.EmptyDB()
+
+	cm.blockHeight = 100
+	address := randomAddressGenerator()
+
+	tests := []struct {
+		profile Profile
+		valid   bool
+	}{
+		{Profile{Name: "", RentPayment: testRentPayment, PaymentAddress: address}, false},
+		{Profile{Name: DefaultProfile, RentPayment: testRentPayment, PaymentAddress: address}, false},
+		{Profile{Name: "project", RentPayment: storage.RentPayment{}, PaymentAddress: address}, false},
+		{Profile{Name: "project", RentPayment: testRentPayment}, false},
+		{Profile{Name: "project", RentPayment: testRentPayment, PaymentAddress: address}, true},
+	}
+	for i, test := range tests {
+		err := cm.SetProfile(test.profile)
+		if (err == nil) != test.valid {
+			t.Errorf("test %d: expected valid %v, got error %v", i, test.valid, err)
+		}
+	}
+
+	profiles := cm.Profiles()
+	if len(profiles) != 1 || profiles[0].Name != "project" || profiles[0].PaymentAddress != address {
+		t.Fatalf("unexpected profiles: %v", profiles)
+	}
+	if expected := cm.blockHeight - testRentPayment.RenewWindow; cm.blockHeight > testRentPayment.RenewWindow &&
+		profiles[0].CurrentPeriod != expected {
+		t.Errorf("current period expected %v, got %v", expected, profiles[0].CurrentPeriod)
+	}
+
+	// the payment address of the profile shall be used for the contracts of the profile
+	if addr, err := cm.paymentAddress("project"); err != nil || addr != address {
+		t.Errorf("payment address expected %v, got %v, %v", address, addr, err)
+	}
+
+	// the profiles shall be persisted
+	cm.lock.Lock()
+	cm.profiles = make(map[string]*Profile)
+	cm.lock.Unlock()
+	if err := cm.loadSettings(); err != nil {
+		t.Fatalf("failed to load the settings: %s", err.Error())
+	}
+	if profiles := cm.Profiles(); len(profiles) != 1 || profiles[0].Name != "project" {
+		t.Errorf("the profiles are not persisted: %v", profiles)
+	}
+
+	if err := cm.RemoveProfile("project"); err != nil {
+		t.Fatalf("failed to remove the profile: %s", err.Error())
+	}
+	if err := cm.RemoveProfile("project"); err == nil {
+		t.Errorf("the profile removed shall not be removed again")
+	}
+}
+
+func TestContractManager_PathProfile(t *testing.T) {
+	cm := newProfileTestContractManager(t)
+	defer os.RemoveAll("test")
+	defer cm.activeContracts.Close()
+	defer cm.activeContracts.EmptyDB()
+
+	for _, name := range []string{"a", "b"} {
+		profile := Profile{Name: name, RentPayment: testRentPayment, PaymentAddress: randomAddressGenerator()}
+		if err := cm.SetProfile(profile); err != nil {
+			t.Fatalf("failed to set the profile: %s", err.Error())
+		}
+	}
+
+	bindings := []struct {
+		path    string
+		profile string
+	}{
+		{"projects", "a"},
+		{"projects/b", "b"},
+		{"others", "b"},
+		{"others", "a"},
+	}
+	for _, binding := range bindings {
+		dxPath, _ := storage.NewDxPath(binding.path)
+		if err := cm.BindPath(dxPath, binding.profile); err != nil {
+			t.Fatalf("failed to bind the path %s: %s", binding.path, err.Error())
+		}
+	}
+	if err := cm.BindPath(storage.DxPath{Path: "unknown"}, "c"); err == nil {
+		t.Errorf("path shall not be bound to the profile not exist")
+	}
+
+	tests := []struct {
+		path    string
+		profile string
+	}{
+		{"projects", "a"},
+		{"projects/file", "a"},
+		{"projects/b", "b"},
+		{"projects/b/file", "b"},
+		{"projects/bb", "a"},
+		{"others/file", "a"},
+		{"file", ""},
+	}
+	for i, test := range tests {
+		if profile := cm.PathProfile(storage.DxPath{Path: test.path}); profile != test.profile {
+			t.Errorf("test %d: profile of %s expected %q, got %q", i, test.path, test.profile, profile)
+		}
+	}
+
+	if err := cm.UnbindPath(storage.DxPath{Path: "projects/b"}); err != nil {
+		t.Fatalf("failed to unbind the path: %s", err.Error())
+	}
+	if profile := cm.PathProfile(storage.DxPath{Path: "projects/b/file"}); profile != "a" {
+		t.Errorf("after unbinding, profile expected a, got %q", profile)
+	}
+	if err := cm.UnbindPath(storage.DxPath{Path: "projects/b"}); err == nil {
+		t.Errorf("path not bound shall not be unbound")
+	}
+}
+
+func TestContractManager_ProfileContracts(t *testing.T) {
+	cm := newProfileTestContractManager(t)
+	defer os.RemoveAll("test")
+	defer cm.activeContracts.Close()
+	defer cm.activeContracts.EmptyDB()
+
+	profile := Profile{Name: "project", RentPayment: testRentPayment, PaymentAddress: randomAddressGenerator()}
+	if err := cm.SetProfile(profile); err != nil {
+		t.Fatalf("failed to set the profile: %s", err.Error())
+	}
+
+	contracts, err := insertHighEvalContract(cm, 4)
+	if err != nil {
+		t.Fatalf("failed to insert contracts: %s", err.Error())
+	}
+
+	// the first two contracts belong to the profile
+	cm.lock.Lock()
+	for _, contract := range contracts[:2] {
+		cm.contractProfiles[contract.ID] = "project"
+		cm.failedRenewCount[contract.ID] = 1
+	}
+	cm.lock.Unlock()
+
+	// the period cost is split by the profiles
+	expected := map[string]common.BigInt{"": common.BigInt0, "project": common.BigInt0}
+	for i, contract := range contracts {
+		name := ""
+		if i < 2 {
+			name = "project"
+		}
+		expected[name] = expected[name].Add(contract.UploadCost)
+	}
+	for name, uploadCost := range expected {
+		cost := cm.calculatePeriodCost(name, testRentPayment)
+		if cost.UploadCost.Cmp(uploadCost) != 0 {
+			t.Errorf("upload cost of profile %q expected %v, got %v", name, uploadCost, cost.UploadCost)
+		}
+	}
+	if _, err = cm.ProfilePeriodCost("unknown"); err == nil {
+		t.Errorf("the period cost of the profile not exist shall not be calculated")
+	}
+
+	// resetting the failed renews of the default profile keeps the ones of the profile
+	cm.resetFailedRenews("", nil, nil)
+	if len(cm.failedRenewCount) != 2 {
+		t.Errorf("the failed renews of the profile shall be kept, got %v", cm.failedRenewCount)
+	}
+	cm.resetFailedRenews("project", nil, nil)
+	if len(cm.failedRenewCount) != 0 {
+		t.Errorf("the failed renews of the profile shall be reset, got %v", cm.failedRenewCount)
+	}
+
+	// the profile cannot be removed with active contracts
+	if err = cm.RemoveProfile("project"); err == nil {
+		t.Errorf("the profile with active contracts shall not be removed")
+	}
+}
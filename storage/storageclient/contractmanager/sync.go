@@ -50,6 +50,11 @@ func (cm *ContractManager) analyzeChainEventChange(change core.ChainChangeEvent)
 	if cm.blockHeight >= cm.currentPeriod+cm.rentPayment.Period {
 		cm.currentPeriod += cm.rentPayment.Period
 	}
+	for _, p := range cm.profiles {
+		if cm.blockHeight >= p.CurrentPeriod+p.RentPayment.Period {
+			p.CurrentPeriod += p.RentPayment.Period
+		}
+	}
 	cm.lock.Unlock()
 
 	// save the newest settings (blockHeight) persistently
@@ -57,6 +62,9 @@ func (cm *ContractManager) analyzeChainEventChange(change core.ChainChangeEvent)
 		cm.log.Warn("failed to save the current contract manager settings while analyzing the chain change event", "err", err.Error())
 	}
 
+	// check the spending against the budget
+	cm.checkBudget()
+
 	// if the block chain finished syncing, start the contract maintenance routine
 	if !cm.b.Syncing() {
 		go cm.contractMaintenance()
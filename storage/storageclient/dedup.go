@@ -0,0 +1,146 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+)
+
+// dedupKey is the per-client secret of the dedup mode. All files uploaded in dedup mode are
+// encrypted with the same cipher key, so that the sectors of identical segments can be
+// shared among files. The segments are identified by the hash keyed with HashKey, so that
+// the segment hashes reveal nothing about the content without the key
+type dedupKey struct {
+	CipherKeyCode uint8
+	CipherKey     []byte
+	HashKey       []byte
+}
+
+// dedupKeys returns the cipher key and hash key of the dedup mode, creating them if not exist
+func (client *StorageClient) dedupKeys() (crypto.CipherKey, []byte, error) {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+
+	if client.persist.DedupKey == nil {
+		cipherKey, err := crypto.GenerateCipherKey(crypto.GCMCipherCode)
+		if err != nil {
+			return nil, nil, fmt.Errorf("generate cipher key error: %v", err)
+		}
+		hashKey := make([]byte, 32)
+		if _, err = rand.Read(hashKey); err != nil {
+			return nil, nil, err
+		}
+		client.persist.DedupKey = &dedupKey{
+			CipherKeyCode: crypto.CipherCodeByName(cipherKey.CodeName()),
+			CipherKey:     cipherKey.Key(),
+			HashKey:       hashKey,
+		}
+		if err = client.saveSettings(); err != nil {
+			return nil, nil, err
+		}
+	}
+	dk := client.persist.DedupKey
+	cipherKey, err := crypto.NewCipherKey(dk.CipherKeyCode, dk.CipherKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cipherKey, dk.HashKey, nil
+}
+
+// segmentHashes computes the keyed hashes of the segments of the source file. The erasure
+// code params are hashed along with the data, since segments encoded differently cannot
+// share the sectors
+func segmentHashes(source string, segmentSize uint64, ec erasurecode.ErasureCoder, hashKey []byte) ([]common.Hash, error) {
+	f, err := os.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ecParams := make([]byte, 9)
+	ecParams[0] = ec.Type()
+	binary.BigEndian.PutUint32(ecParams[1:], ec.MinSectors())
+	binary.BigEndian.PutUint32(ecParams[5:], ec.NumSectors())
+	ecParams = append(ecParams, fmt.Sprint(ec.Extra()...)...)
+
+	var hashes []common.Hash
+	buf := make([]byte, segmentSize)
+	for {
+		n, err := io.ReadFull(f, buf)
+		if n > 0 {
+			hashes = append(hashes, crypto.Keccak256Hash(hashKey, ecParams, buf[:n]))
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return hashes, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// dedupSegments adds the segments of the file uploaded in dedup mode to the dedup index. For
+// the segments identical to the ones already referenced, the sectors are copied from the
+// existing segment, so that only the missing sectors are uploaded
+func (client *StorageClient) dedupSegments(entry *dxfile.FileSetEntryWithID, source string, hashKey []byte) error {
+	ec, err := entry.ErasureCode()
+	if err != nil {
+		return err
+	}
+	hashes, err := segmentHashes(source, entry.SegmentSize(), ec, hashKey)
+	if err != nil {
+		return fmt.Errorf("cannot hash the segments: %v", err)
+	}
+	if len(hashes) != entry.NumSegments() {
+		return fmt.Errorf("segment hashes %d not equal to number of segments %d", len(hashes), entry.NumSegments())
+	}
+	refs, err := client.fileSystem.AddDedupRefs(entry.DxPath(), hashes)
+	if err != nil {
+		return err
+	}
+
+	var deduped int
+	for i, ref := range refs {
+		if ref == nil {
+			continue
+		}
+		src := entry
+		if !ref.DxPath.Equals(entry.DxPath()) {
+			if src, err = client.fileSystem.OpenDxFile(ref.DxPath); err != nil {
+				client.log.Warn("Failed to open the dedup source file", "path", ref.DxPath.Path, "err", err)
+				continue
+			}
+		}
+		sectors, err := src.Sectors(int(ref.Segment))
+		if src != entry {
+			src.Close()
+		}
+		if err != nil {
+			client.log.Warn("Failed to get the sectors of the dedup source", "path", ref.DxPath.Path, "err", err)
+			continue
+		}
+		for sectorIndex, sectorSet := range sectors {
+			for _, sector := range sectorSet {
+				if err = entry.AddSector(sector.HostID, sector.MerkleRoot, i, sectorIndex); err != nil {
+					return err
+				}
+			}
+		}
+		deduped++
+	}
+	if deduped != 0 {
+		client.log.Info("Deduplicated segments", "path", entry.DxPath().Path, "deduped", deduped, "segments", len(hashes))
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
+)
+
+func TestSegmentHashes(t *testing.T) {
+	dir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	segment := bytes.Repeat([]byte{1}, 100)
+	files := map[string][]byte{
+		"a": append(append(append([]byte{}, segment...), bytes.Repeat([]byte{2}, 100)...), 3),
+		"b": append(append([]byte{}, segment...), bytes.Repeat([]byte{2}, 50)...),
+	}
+	for name, data := range files {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ec, err := erasurecode.New(erasurecode.ECTypeStandard, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := []byte("hash key")
+	hashesA, err := segmentHashes(filepath.Join(dir, "a"), 100, ec, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashesB, err := segmentHashes(filepath.Join(dir, "b"), 100, ec, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hashesA) != 3 || len(hashesB) != 2 {
+		t.Fatalf("number of segment hashes not expected: %v, %v", len(hashesA), len(hashesB))
+	}
+	if hashesA[0] != hashesB[0] {
+		t.Error("identical segments shall have the same hash")
+	}
+	if hashesA[1] == hashesB[1] {
+		t.Error("different segments shall have different hashes")
+	}
+
+	// the hashes depend on the hash key and the erasure code
+	hashes, err := segmentHashes(filepath.Join(dir, "a"), 100, ec, []byte("another key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hashes[0] == hashesA[0] {
+		t.Error("segment hash shall depend on the hash key")
+	}
+	ec2, err := erasurecode.New(erasurecode.ECTypeStandard, 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hashes, err = segmentHashes(filepath.Join(dir, "a"), 100, ec2, key); err != nil {
+		t.Fatal(err)
+	}
+	if hashes[0] == hashesA[0] {
+		t.Error("segment hash shall depend on the erasure code")
+	}
+}
@@ -12,6 +12,7 @@ import (
 const (
 	PersistDirectory            = "storageclient"
 	PersistFilename             = "storageclient.json"
+	SegmentCacheDir             = "segmentcache"
 	PersistStorageClientVersion = "1.0"
 	DxPathRoot                  = "dxfiles"
 )
@@ -22,6 +23,10 @@ const (
 	DefaultMaxUploadSpeed   = 0
 	DefaultPacketSize       = 4 * 4096
 
+	// DefaultDownloadHedge is the default maximum number of extra sectors of a segment
+	// requested speculatively from the fastest storage hosts while downloading
+	DefaultDownloadHedge = 3
+
 	// frequency to check whether storage client is online
 	OnlineCheckFrequency = time.Second * 10
 
@@ -69,7 +74,67 @@ var (
 	// UploadFailureCoolDown is the initial time of punishment while upload consecutive fails
 	// the punishment time shows exponential growth
 	UploadFailureCoolDown = 3 * time.Second
+
+	// SpendingPausedCheckInterval is how long the upload loop sleeps before checking again
+	// whether the spending is still paused by the hard cap
+	SpendingPausedCheckInterval = time.Minute
 )
 
 var keys = []string{"fund", "hosts", "period", "renew", "storage", "upload", "download",
-	"redundancy", "violation", "uploadspeed", "downloadspeed"}
+	"redundancy", "violation", "uploadspeed", "downloadspeed", "hedge", "cachesize", "auditbudget"}
+
+// Hedged download related params
+const (
+	// downloadStatsDecay is the weight of the previous observations in the moving average of
+	// the download latency and throughput of the worker
+	downloadStatsDecay = 0.8
+
+	// downloadRequestInterval is how long the worker waits after a sector download before
+	// sending the next download request to the storage host
+	downloadRequestInterval = time.Second
+
+	// downloadHedgeDelay is how long the sectors of a segment are reserved for the fastest
+	// workers before the standby workers are allowed to download them
+	downloadHedgeDelay = 3 * time.Second
+)
+
+// Small file packing related params
+const (
+	// packStagingDir is the directory in the persist directory to stage the data of pack files
+	packStagingDir = "packs"
+
+	// packFileSizeThreshold is the size under which a file is packed into a pack file
+	packFileSizeThreshold = 1 << 20
+
+	// packMaxSegments is the number of segments of a pack file when it is full
+	packMaxSegments = 4
+
+	// packCompactThreshold is the ratio of the data belonging to the deleted files in a
+	// pack file to start compacting the pack file
+	packCompactThreshold = 0.5
+)
+
+var (
+	// packFlushInterval is the maximum amount of time the small files wait in the open pack
+	// file before the pack file is uploaded
+	packFlushInterval = time.Hour
+
+	// packCheckInterval is the interval to check whether the open pack file shall be uploaded,
+	// and whether any pack files shall be compacted
+	packCheckInterval = 10 * time.Minute
+)
+
+// Storage host audit related params
+const (
+	// auditSectorsPerHost is the maximum number of the sectors stored by each storage host
+	// audited in each round, where a random segment of each sector is requested
+	auditSectorsPerHost = 4
+
+	// maxAuditFailures is the maximum number of the recent audit failures kept
+	maxAuditFailures = 100
+)
+
+var (
+	// auditInterval is the interval between the audits of the storage hosts
+	auditInterval = time.Hour
+)
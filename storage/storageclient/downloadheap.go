@@ -6,6 +6,7 @@ package storageclient
 
 import (
 	"container/heap"
+	"sort"
 	"time"
 
 	"github.com/DxChainNetwork/godx/log"
@@ -140,6 +141,22 @@ func (client *StorageClient) acquireMemoryForDownloadSegment(uds *unfinishedDown
 	return client.memoryManager.Request(memoryRequired, true)
 }
 
+// workersByLatency returns the workers sorted by the estimated sector download time, so that
+// the segment is queued to the fastest workers first.
+// Require: lock client.lock by caller
+func (client *StorageClient) workersByLatency() []*worker {
+	workers := make([]*worker, 0, len(client.workerPool))
+	estimates := make(map[*worker]time.Duration, len(client.workerPool))
+	for _, w := range client.workerPool {
+		estimates[w] = w.estimatedSectorTime()
+		workers = append(workers, w)
+	}
+	sort.SliceStable(workers, func(i, j int) bool {
+		return estimates[workers[i]] < estimates[workers[j]]
+	})
+	return workers
+}
+
 // Pass a segment out to all of the workers.
 func (client *StorageClient) distributeDownloadSegmentToWorkers(uds *unfinishedDownloadSegment) {
 
@@ -148,11 +165,16 @@ func (client *StorageClient) distributeDownloadSegmentToWorkers(uds *unfinishedD
 	uds.mu.Lock()
 	uds.workersRemaining = uint32(len(client.workerPool))
 	uds.mu.Unlock()
-	for _, worker := range client.workerPool {
+	workers := client.workersByLatency()
+	uds.preferWorkers(workers)
+	for _, worker := range workers {
 		worker.queueDownloadSegment(uds)
 	}
 	client.lock.Unlock()
 
+	// request the sectors from the standby workers if the fastest workers are not done in time
+	time.AfterFunc(downloadHedgeDelay, uds.releasePreferredWorkers)
+
 	// if there are no workers, there will be no workers to attempt to clean up
 	// the segment, so we must make sure that cleanUp is called at least once on the segment.
 	uds.cleanUp()
@@ -0,0 +1,139 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package storageclient
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
+)
+
+// errDownloadCanceled is used when the sector download is canceled before the request is sent
+// to the storage host, because the segment can already be recovered from the other sectors
+var errDownloadCanceled = errors.New("download canceled, the segment is already recoverable")
+
+// WorkerDownloadStats is the download latency and throughput of the storage host observed by
+// the worker, which is used to request the sectors from the fastest storage hosts first
+type WorkerDownloadStats struct {
+	HostID     string        `json:"hostID"`
+	Latency    time.Duration `json:"latency"`
+	Throughput float64       `json:"throughput"`
+	Samples    uint64        `json:"samples"`
+}
+
+// DownloadStats returns the download stats observed by all workers, fastest first
+func (client *StorageClient) DownloadStats() (stats []WorkerDownloadStats) {
+	client.lock.Lock()
+	workers := client.workersByLatency()
+	client.lock.Unlock()
+
+	for _, w := range workers {
+		w.mu.Lock()
+		stats = append(stats, WorkerDownloadStats{
+			HostID:     w.hostID.String(),
+			Latency:    w.downloadLatency,
+			Throughput: w.downloadThroughput,
+			Samples:    w.downloadSamples,
+		})
+		w.mu.Unlock()
+	}
+	return
+}
+
+// downloadHedge returns the maximum number of extra sectors of a segment requested while
+// downloading
+func (client *StorageClient) downloadHedge() uint64 {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	if client.persist.DownloadHedge == nil {
+		return DefaultDownloadHedge
+	}
+	return *client.persist.DownloadHedge
+}
+
+// hedgeOverdrive returns the number of extra sectors requested for the segment, which is bounded
+// by both the download hedge and the number of the redundant sectors of the segment
+func hedgeOverdrive(ec erasurecode.ErasureCoder, hedge uint64) int {
+	redundant := uint64(ec.NumSectors() - ec.MinSectors())
+	if hedge > redundant {
+		hedge = redundant
+	}
+	return int(hedge)
+}
+
+// recordDownload updates the moving average of the download latency and throughput of the worker
+// with the sector of the size downloaded within the elapsed time
+func (w *worker) recordDownload(size uint64, elapsed time.Duration) {
+	if elapsed <= 0 {
+		return
+	}
+	throughput := float64(size) / elapsed.Seconds()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.downloadSamples == 0 {
+		w.downloadLatency, w.downloadThroughput = elapsed, throughput
+	} else {
+		w.downloadLatency = time.Duration(downloadStatsDecay*float64(w.downloadLatency) + (1-downloadStatsDecay)*float64(elapsed))
+		w.downloadThroughput = downloadStatsDecay*w.downloadThroughput + (1-downloadStatsDecay)*throughput
+	}
+	w.downloadSamples++
+}
+
+// estimatedSectorTime returns the estimated time for the worker to download a sector. The
+// observed download latency is used if the worker has downloaded any sector, otherwise the
+// round trip time measured by the probes. The workers with neither of them are the slowest
+func (w *worker) estimatedSectorTime() time.Duration {
+	w.mu.Lock()
+	latency, samples := w.downloadLatency, w.downloadSamples
+	w.mu.Unlock()
+	if samples != 0 {
+		return latency
+	}
+	if rtt, probed := w.client.storageHostManager.HostRTT(w.hostID); probed {
+		return rtt
+	}
+	return math.MaxInt64
+}
+
+// preferWorkers reserves the sectors of the segment for the fastest workers holding the sectors,
+// the other workers are put on standby until the reserved workers fail, or the hedge delay passes.
+// The workers shall be sorted by latency
+func (uds *unfinishedDownloadSegment) preferWorkers(workers []*worker) {
+	uds.mu.Lock()
+	defer uds.mu.Unlock()
+
+	uds.preferredWorkers = make(map[*worker]struct{})
+	desired := int(uds.erasureCode.MinSectors() + uds.overdrive)
+	for _, w := range workers {
+		if len(uds.preferredWorkers) >= desired {
+			break
+		}
+		if _, exists := uds.segmentMap[w.hostID.String()]; exists {
+			uds.preferredWorkers[w] = struct{}{}
+		}
+	}
+}
+
+// releasePreferredWorkers drops the reservation of the sectors for the fastest workers which have
+// not started downloading yet, once the hedge delay passes, so that the standby workers can
+// download the sectors instead
+func (uds *unfinishedDownloadSegment) releasePreferredWorkers() {
+	uds.mu.Lock()
+	uds.preferredWorkers = nil
+	uds.mu.Unlock()
+	uds.cleanUp()
+}
+
+// cancelRequests cancels the sector download requests not sent to the storage hosts yet.
+// Require: lock uds.mu by caller
+func (uds *unfinishedDownloadSegment) cancelRequests() {
+	if !uds.requestsCanceled {
+		uds.requestsCanceled = true
+		close(uds.cancel)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package storageclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
+)
+
+func TestHedgeOverdrive(t *testing.T) {
+	tests := []struct {
+		minSectors uint32
+		numSectors uint32
+		hedge      uint64
+		overdrive  int
+	}{
+		{1, 2, 0, 0},
+		{1, 2, 3, 1},
+		{10, 30, 3, 3},
+		{10, 30, 100, 20},
+		{10, 11, 3, 1},
+	}
+	for i, test := range tests {
+		ec, err := erasurecode.New(erasurecode.ECTypeStandard, test.minSectors, test.numSectors)
+		if err != nil {
+			t.Fatalf("test %d: failed to create erasure code: %s", i, err.Error())
+		}
+		if overdrive := hedgeOverdrive(ec, test.hedge); overdrive != test.overdrive {
+			t.Errorf("test %d: overdrive expected %v, got %v", i, test.overdrive, overdrive)
+		}
+	}
+}
+
+func TestWorker_RecordDownload(t *testing.T) {
+	w := &worker{}
+	w.recordDownload(1000, 0)
+	if w.downloadSamples != 0 {
+		t.Fatalf("the download without elapsed time shall not be recorded")
+	}
+
+	w.recordDownload(1000, time.Second)
+	if w.downloadLatency != time.Second || w.downloadThroughput != 1000 {
+		t.Errorf("the first sample expected latency 1s and throughput 1000, got %v, %v", w.downloadLatency, w.downloadThroughput)
+	}
+	w.recordDownload(1000, 2*time.Second)
+	if expected := 1200 * time.Millisecond; w.downloadLatency != expected {
+		t.Errorf("latency expected %v, got %v", expected, w.downloadLatency)
+	}
+	if expected := 900.0; w.downloadThroughput != expected {
+		t.Errorf("throughput expected %v, got %v", expected, w.downloadThroughput)
+	}
+	if w.downloadSamples != 2 {
+		t.Errorf("samples expected 2, got %v", w.downloadSamples)
+	}
+}
+
+func TestUnfinishedDownloadSegment_PreferWorkers(t *testing.T) {
+	ec, err := erasurecode.New(erasurecode.ECTypeStandard, 2, 6)
+	if err != nil {
+		t.Fatalf("failed to create erasure code: %s", err.Error())
+	}
+	uds := &unfinishedDownloadSegment{
+		erasureCode:      ec,
+		segmentMap:       make(map[string]downloadSectorInfo),
+		overdrive:        1,
+		completedSectors: make([]bool, ec.NumSectors()),
+		sectorUsage:      make([]bool, ec.NumSectors()),
+		cancel:           make(chan struct{}),
+		download:         &download{completeChan: make(chan struct{})},
+	}
+
+	// the workers are sorted by latency, the last one holds no sector of the segment
+	var workers []*worker
+	for i := 0; i < 6; i++ {
+		w := &worker{hostID: enode.ID{byte(i + 1)}, downloadChan: make(chan struct{}, 1)}
+		if i < 5 {
+			uds.segmentMap[w.hostID.String()] = downloadSectorInfo{index: uint64(i)}
+		}
+		workers = append(workers, w)
+	}
+	uds.workersRemaining = uint32(len(workers))
+	uds.preferWorkers(workers)
+	if len(uds.preferredWorkers) != 3 {
+		t.Fatalf("expected the fastest 3 workers preferred, got %v", len(uds.preferredWorkers))
+	}
+
+	// the slower worker processing the segment first is put on standby
+	if w := workers[3].processDownloadSegment(uds); w != nil {
+		t.Errorf("the slower worker shall not download the sector reserved for the fastest workers")
+	}
+	if len(uds.workersStandby) != 1 {
+		t.Errorf("the slower worker shall be put on standby")
+	}
+	if w := workers[0].processDownloadSegment(uds); w == nil {
+		t.Errorf("the fastest worker shall download the sector")
+	}
+
+	// the standby worker takes over once the fastest workers miss the latency target
+	uds.releasePreferredWorkers()
+	if len(uds.workersStandby) != 0 || len(workers[3].downloadSegments) != 1 {
+		t.Fatalf("the standby worker shall be queued the segment again")
+	}
+	if w := workers[3].processDownloadSegment(uds); w == nil {
+		t.Errorf("the standby worker shall download the sector after the reservation is released")
+	}
+
+	// the outstanding requests are canceled once the segment is recoverable
+	uds.mu.Lock()
+	uds.markSectorCompleted(0)
+	uds.markSectorCompleted(3)
+	uds.mu.Unlock()
+	select {
+	case <-uds.cancel:
+	default:
+		t.Errorf("the requests shall be canceled once the segment is recoverable")
+	}
+}
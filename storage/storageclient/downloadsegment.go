@@ -83,6 +83,13 @@ type unfinishedDownloadSegment struct {
 	// backup workers that can be used to download when other workers fail
 	workersStandby []*worker
 
+	// the fastest workers the sectors are reserved for, which have not processed the segment yet
+	preferredWorkers map[*worker]struct{}
+
+	// closed once the segment is recoverable or failed, to cancel the requests not sent yet
+	cancel           chan struct{}
+	requestsCanceled bool
+
 	// record how much memory allocated
 	memoryAllocated uint64
 
@@ -122,10 +129,12 @@ func (uds *unfinishedDownloadSegment) cleanUp() {
 		return
 	}
 
-	// check whether standby workers are required.
+	// check whether standby workers are required, the sectors reserved for the fastest
+	// workers are counted as registered.
 	segmentComplete := uds.sectorsCompleted >= uds.erasureCode.MinSectors()
 	desiredSectorsRegistered := uds.erasureCode.MinSectors() + uds.overdrive - uds.sectorsCompleted
-	standbyWorkersRequired := !segmentComplete && uds.sectorsRegistered < desiredSectorsRegistered
+	sectorsReserved := uds.sectorsRegistered + uint32(len(uds.preferredWorkers))
+	standbyWorkersRequired := !segmentComplete && sectorsReserved < desiredSectorsRegistered
 	if !standbyWorkersRequired {
 		uds.mu.Unlock()
 		return
@@ -146,6 +155,7 @@ func (uds *unfinishedDownloadSegment) cleanUp() {
 func (uds *unfinishedDownloadSegment) fail(err error) {
 	uds.failed = true
 	uds.recoveryComplete = true
+	uds.cancelRequests()
 	for i := range uds.physicalSegmentData {
 		uds.physicalSegmentData[i] = nil
 	}
@@ -185,6 +195,11 @@ func (uds *unfinishedDownloadSegment) returnMemory() {
 func (uds *unfinishedDownloadSegment) markSectorCompleted(sectorIndex uint64) {
 	uds.completedSectors[sectorIndex] = true
 	uds.sectorsCompleted++
+
+	// the other sectors are no longer needed once the segment is recoverable
+	if uds.sectorsCompleted >= uds.erasureCode.MinSectors() {
+		uds.cancelRequests()
+	}
 	completed := uint32(0)
 	for _, b := range uds.completedSectors {
 		if b {
@@ -234,17 +249,40 @@ func (uds *unfinishedDownloadSegment) recoverLogicalData() error {
 	}
 	recoverWriter = nil
 
+	// cache the recovered segment for the later downloads
+	if cache := uds.download.segmentCache; cache != nil {
+		if err := cache.put(uds.clientFile.UID(), uds.segmentIndex, recoveredData); err != nil {
+			log.Warn("failed to cache the segment", "segment", uds.segmentIndex, "err", err)
+		}
+	}
+
 	uds.mu.Lock()
 	uds.recoveryComplete = true
 	uds.mu.Unlock()
 
-	// update the download and signal completion of this segment.
+	uds.completeSegment()
+	return nil
+}
+
+// writeCachedSegment writes the segment data cached locally to the requested output, without
+// downloading the segment from the storage hosts
+func (uds *unfinishedDownloadSegment) writeCachedSegment(data []byte) error {
+	start := uds.fetchOffset
+	end := start + uds.fetchLength
+	if _, err := uds.destination.WriteAt(data[start:end], uds.writeOffset); err != nil {
+		uds.download.fail(fmt.Errorf("unable to write the cached segment %v: %v", uds.segmentIndex, err))
+		return err
+	}
+	uds.completeSegment()
+	return nil
+}
+
+// completeSegment updates the download and signal completion of this segment.
+func (uds *unfinishedDownloadSegment) completeSegment() {
 	uds.download.mu.Lock()
 	defer uds.download.mu.Unlock()
 	uds.download.segmentsRemaining--
 	if uds.download.segmentsRemaining == 0 {
 		uds.download.markComplete()
-		return err
 	}
-	return nil
 }
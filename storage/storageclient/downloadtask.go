@@ -67,6 +67,9 @@ type (
 		// higher priority will complete first.
 		priority uint64
 
+		// the local cache the recovered segments are written to, nil if not available
+		segmentCache *segmentCache
+
 		// Utilities.
 		log           log.Logger
 		memoryManager *memorymanager.MemoryManager
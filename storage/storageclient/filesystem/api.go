@@ -82,6 +82,12 @@ func (api *PublicFileSystemAPI) FileList() []storage.FileBriefInfo {
 	return fileList
 }
 
+// DedupStats is the API function that returns the statistics of the segments uploaded in
+// dedup mode
+func (api *PublicFileSystemAPI) DedupStats() DedupStats {
+	return api.fs.DedupStats()
+}
+
 // Uploads is the API function that return all files currently uploading in progress
 func (api *PublicFileSystemAPI) Uploads() []storage.FileBriefInfo {
 	rawFileList, err := api.fs.fileList()
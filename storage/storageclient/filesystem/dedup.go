@@ -0,0 +1,177 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+var dedupIndexMetadata = common.Metadata{
+	Header:  "storage client dedup index",
+	Version: "1.0",
+}
+
+type (
+	// DedupRef is the location of a segment in a dxfile uploaded in dedup mode
+	DedupRef struct {
+		DxPath  storage.DxPath `json:"dxPath"`
+		Segment uint64         `json:"segment"`
+	}
+
+	// DedupStats is the statistics of the dedup index. Segments is the number of distinct
+	// segments, and References is the number of segments referring to them
+	DedupStats struct {
+		Segments   uint64 `json:"segments"`
+		References uint64 `json:"references"`
+	}
+
+	// dedupEntry is the reference count of a distinct segment, along with the segment
+	// whose sectors are shared by the other references
+	dedupEntry struct {
+		RefCount uint64   `json:"refCount"`
+		Source   DedupRef `json:"source"`
+	}
+
+	// dedupIndex is the persisted index from the segment hash to the dedup entry. Files
+	// records the segment hashes of each dxfile uploaded in dedup mode
+	dedupIndex struct {
+		Segments map[common.Hash]*dedupEntry `json:"segments"`
+		Files    map[string][]common.Hash    `json:"files"`
+	}
+)
+
+// AddDedupRefs adds the references of the segments of the dxfile to the dedup index. For
+// each segment, the location of an identical segment already referenced is returned, or
+// nil if the segment is new
+func (fs *fileSystem) AddDedupRefs(dxPath storage.DxPath, hashes []common.Hash) ([]*DedupRef, error) {
+	fs.dedupLock.Lock()
+	defer fs.dedupLock.Unlock()
+
+	fs.removeDedupRefsLocked(dxPath)
+	sources := make([]*DedupRef, len(hashes))
+	for i, hash := range hashes {
+		entry, exist := fs.dedup.Segments[hash]
+		if !exist {
+			entry = &dedupEntry{Source: DedupRef{DxPath: dxPath, Segment: uint64(i)}}
+			fs.dedup.Segments[hash] = entry
+		} else {
+			source := entry.Source
+			sources[i] = &source
+		}
+		entry.RefCount++
+	}
+	fs.dedup.Files[dxPath.Path] = hashes
+	return sources, fs.saveDedupIndex()
+}
+
+// DedupStats returns the statistics of the dedup index
+func (fs *fileSystem) DedupStats() DedupStats {
+	fs.dedupLock.Lock()
+	defer fs.dedupLock.Unlock()
+
+	stats := DedupStats{Segments: uint64(len(fs.dedup.Segments))}
+	for _, entry := range fs.dedup.Segments {
+		stats.References += entry.RefCount
+	}
+	return stats
+}
+
+// removeDedupRefs decrements the reference counts of the segments of the dxfile
+func (fs *fileSystem) removeDedupRefs(dxPath storage.DxPath) error {
+	fs.dedupLock.Lock()
+	defer fs.dedupLock.Unlock()
+
+	if !fs.removeDedupRefsLocked(dxPath) {
+		return nil
+	}
+	return fs.saveDedupIndex()
+}
+
+// removeDedupRefsLocked decrements the reference counts of the segments of the dxfile, and
+// returns whether the file is in the dedup index. The segments no longer referenced are
+// removed, and the source of the segments still referenced is moved to another reference.
+// Require: lock fs.dedupLock by caller
+func (fs *fileSystem) removeDedupRefsLocked(dxPath storage.DxPath) bool {
+	hashes, exist := fs.dedup.Files[dxPath.Path]
+	if !exist {
+		return false
+	}
+	delete(fs.dedup.Files, dxPath.Path)
+	for _, hash := range hashes {
+		entry, exist := fs.dedup.Segments[hash]
+		if !exist {
+			continue
+		}
+		if entry.RefCount--; entry.RefCount == 0 {
+			delete(fs.dedup.Segments, hash)
+			continue
+		}
+		if entry.Source.DxPath.Equals(dxPath) {
+			entry.Source = fs.findDedupRef(hash)
+		}
+	}
+	return true
+}
+
+// findDedupRef finds a segment referring to the segment hash.
+// Require: lock fs.dedupLock by caller
+func (fs *fileSystem) findDedupRef(hash common.Hash) DedupRef {
+	for path, hashes := range fs.dedup.Files {
+		for i, h := range hashes {
+			if h == hash {
+				return DedupRef{DxPath: storage.DxPath{Path: path}, Segment: uint64(i)}
+			}
+		}
+	}
+	return DedupRef{}
+}
+
+// renameDedupRefs updates the dedup index after the dxfile is renamed
+func (fs *fileSystem) renameDedupRefs(prevPath, curPath storage.DxPath) error {
+	fs.dedupLock.Lock()
+	defer fs.dedupLock.Unlock()
+
+	hashes, exist := fs.dedup.Files[prevPath.Path]
+	if !exist {
+		return nil
+	}
+	delete(fs.dedup.Files, prevPath.Path)
+	fs.dedup.Files[curPath.Path] = hashes
+	for _, hash := range hashes {
+		if entry, exist := fs.dedup.Segments[hash]; exist && entry.Source.DxPath.Equals(prevPath) {
+			entry.Source.DxPath = curPath
+		}
+	}
+	return fs.saveDedupIndex()
+}
+
+// loadDedupIndex loads the dedup index from the persist directory
+func (fs *fileSystem) loadDedupIndex() error {
+	fs.dedupLock.Lock()
+	defer fs.dedupLock.Unlock()
+
+	fs.dedup = dedupIndex{}
+	err := common.LoadDxJSON(dedupIndexMetadata, filepath.Join(string(fs.persistDir), dedupIndexFileName), &fs.dedup)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if fs.dedup.Segments == nil {
+		fs.dedup.Segments = make(map[common.Hash]*dedupEntry)
+	}
+	if fs.dedup.Files == nil {
+		fs.dedup.Files = make(map[string][]common.Hash)
+	}
+	return nil
+}
+
+// saveDedupIndex saves the dedup index to the persist directory.
+// Require: lock fs.dedupLock by caller
+func (fs *fileSystem) saveDedupIndex() error {
+	return common.SaveDxJSON(dedupIndexMetadata, filepath.Join(string(fs.persistDir), dedupIndexFileName), fs.dedup)
+}
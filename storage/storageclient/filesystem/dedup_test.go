@@ -0,0 +1,85 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package filesystem
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+)
+
+// TestFileSystem_DedupIndex test the reference counts of the dedup index along with the
+// files being added, renamed and removed
+func TestFileSystem_DedupIndex(t *testing.T) {
+	fs := newEmptyTestFileSystem(t, "", &AlwaysSuccessContractManager{}, newStandardDisrupter())
+	h1, h2, h3 := common.HexToHash("0x1"), common.HexToHash("0x2"), common.HexToHash("0x3")
+	path1, path2 := randomDxPath(t, 2), randomDxPath(t, 2)
+
+	refs, err := fs.AddDedupRefs(path1, []common.Hash{h1, h2, h1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect1 := []*DedupRef{nil, nil, {DxPath: path1, Segment: 0}}
+	if err = checkDedupRefs(refs, expect1); err != nil {
+		t.Fatal(err)
+	}
+	refs, err = fs.AddDedupRefs(path2, []common.Hash{h2, h3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = checkDedupRefs(refs, []*DedupRef{{DxPath: path1, Segment: 1}, nil}); err != nil {
+		t.Fatal(err)
+	}
+	if stats := fs.DedupStats(); stats.Segments != 3 || stats.References != 5 {
+		t.Fatalf("dedup stats not expected: %+v", stats)
+	}
+
+	// removing the source file moves the source to the other reference
+	if err = fs.removeDedupRefs(path1); err != nil {
+		t.Fatal(err)
+	}
+	if stats := fs.DedupStats(); stats.Segments != 2 || stats.References != 2 {
+		t.Fatalf("dedup stats not expected after remove: %+v", stats)
+	}
+	if source := fs.dedup.Segments[h2].Source; !source.DxPath.Equals(path2) || source.Segment != 0 {
+		t.Fatalf("dedup source not expected after remove: %+v", source)
+	}
+
+	// rename shall update the source
+	renamed := randomDxPath(t, 2)
+	if err = fs.renameDedupRefs(path2, renamed); err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.loadDedupIndex(); err != nil {
+		t.Fatal(err)
+	}
+	refs, err = fs.AddDedupRefs(path1, []common.Hash{h3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = checkDedupRefs(refs, []*DedupRef{{DxPath: renamed, Segment: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if hashes := fs.dedup.Files[renamed.Path]; len(hashes) != 2 {
+		t.Fatalf("renamed file hashes not expected: %v", hashes)
+	}
+}
+
+// checkDedupRefs checks whether the dedup refs are expected
+func checkDedupRefs(got, expect []*DedupRef) error {
+	if len(got) != len(expect) {
+		return fmt.Errorf("expect %d refs, got %d", len(expect), len(got))
+	}
+	for i := range got {
+		if (got[i] == nil) != (expect[i] == nil) {
+			return fmt.Errorf("ref %d: expect %+v, got %+v", i, expect[i], got[i])
+		}
+		if got[i] != nil && *got[i] != *expect[i] {
+			return fmt.Errorf("ref %d: expect %+v, got %+v", i, *expect[i], *got[i])
+		}
+	}
+	return nil
+}
@@ -35,6 +35,12 @@ const (
 
 	// updateWalName is the fileName for the updateWal
 	updateWalName = "update.wal"
+
+	// packIndexFileName is the fileName for the index of the pack files
+	packIndexFileName = "packs.json"
+
+	// dedupIndexFileName is the fileName for the index of the deduplicated segments
+	dedupIndexFileName = "dedup.json"
 )
 
 const (
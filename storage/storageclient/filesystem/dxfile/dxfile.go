@@ -85,6 +85,21 @@ type (
 // erasureCode is the erasure coder for encoding. cipherKey is the key for encryption.
 // fileSize is the size of the original data file. fileMode is the file privilege mode (e.g. 0777)
 func New(filePath storage.SysPath, dxPath storage.DxPath, sourcePath storage.SysPath, wal *writeaheadlog.Wal, erasureCode erasurecode.ErasureCoder, cipherKey crypto.CipherKey, fileSize uint64, fileMode os.FileMode) (*DxFile, error) {
+	return newDxFile(filePath, dxPath, sourcePath, wal, erasureCode, cipherKey, fileSize, fileMode, nil)
+}
+
+// NewPacked creates a new dxfile whose data is packed into the pack file at loc. The packed
+// dxfile has no segments of its own, and the file data is read from the pack file.
+func NewPacked(filePath storage.SysPath, dxPath storage.DxPath, sourcePath storage.SysPath, wal *writeaheadlog.Wal, erasureCode erasurecode.ErasureCoder, cipherKey crypto.CipherKey, loc PackLocation, fileMode os.FileMode) (*DxFile, error) {
+	if loc.Length == 0 {
+		return nil, fmt.Errorf("packed file size is 0")
+	}
+	return newDxFile(filePath, dxPath, sourcePath, wal, erasureCode, cipherKey, loc.Length, fileMode, []PackLocation{loc})
+}
+
+// newDxFile creates a new dxfile. pack is the location of the file data in the pack file,
+// which is empty if the file is not packed
+func newDxFile(filePath storage.SysPath, dxPath storage.DxPath, sourcePath storage.SysPath, wal *writeaheadlog.Wal, erasureCode erasurecode.ErasureCoder, cipherKey crypto.CipherKey, fileSize uint64, fileMode os.FileMode, pack []PackLocation) (*DxFile, error) {
 	currentTime := uint64(time.Now().Unix())
 	// create the params for erasureCode and cipherKey
 	minSectors, numSectors, extra, err := erasureCodeToParams(erasureCode)
@@ -117,6 +132,7 @@ func New(filePath storage.SysPath, dxPath storage.DxPath, sourcePath storage.Sys
 		MinSectors:      minSectors,
 		NumSectors:      numSectors,
 		ECExtra:         extra,
+		Pack:            pack,
 	}
 	if err := md.validate(); err != nil {
 		return nil, err
@@ -206,6 +222,39 @@ func (df *DxFile) AddSector(address enode.ID, merkleRoot common.Hash, segmentInd
 	return df.saveSegments([]int{int(segmentIndex)})
 }
 
+// RemoveSector removes the sectors of the merkle root stored by the host from the segment, so that
+// the sector no longer counts toward the health of the segment. It is used when the host is found
+// to have lost the sector.
+func (df *DxFile) RemoveSector(address enode.ID, merkleRoot common.Hash, segmentIndex int) error {
+	df.lock.Lock()
+	defer df.lock.Unlock()
+	if df.deleted {
+		return fmt.Errorf("file already deleted")
+	}
+	if segmentIndex >= len(df.segments) {
+		return fmt.Errorf("segment Index %d out of bound %d", segmentIndex, len(df.segments))
+	}
+	var removed bool
+	for i, sectors := range df.segments[segmentIndex].Sectors {
+		kept := sectors[:0]
+		for _, sector := range sectors {
+			if sector.HostID == address && sector.MerkleRoot == merkleRoot {
+				removed = true
+				continue
+			}
+			kept = append(kept, sector)
+		}
+		df.segments[segmentIndex].Sectors[i] = kept
+	}
+	if !removed {
+		return fmt.Errorf("sector %x of host %v not found in segment %d", merkleRoot, address, segmentIndex)
+	}
+	df.metadata.TimeModify = unixNow()
+	df.metadata.TimeUpdate = df.metadata.TimeModify
+
+	return df.saveSegments([]int{segmentIndex})
+}
+
 // Delete delete the DxFile. The function delete the DxFile on disk, and also mark
 // df.deleted as true
 func (df *DxFile) Delete() error {
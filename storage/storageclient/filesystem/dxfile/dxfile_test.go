@@ -117,6 +117,49 @@ func TestAddSector(t *testing.T) {
 	}
 }
 
+// TestRemoveSector test DxFile.RemoveSector
+func TestRemoveSector(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	df, err := newTestDxFileWithSegments(t, SectorSize*64, 10, 30, erasurecode.ECTypeStandard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	segmentIndex := rand.Intn(int(df.metadata.numSegments()))
+	sectorIndex := rand.Intn(int(df.metadata.NumSectors))
+	newAddr, newHash := randomAddress(), randomHash()
+	if err = df.AddSector(newAddr, newHash, segmentIndex, sectorIndex); err != nil {
+		t.Fatal(err)
+	}
+	numSectors := len(df.segments[segmentIndex].Sectors[sectorIndex])
+
+	if err = df.RemoveSector(newAddr, newHash, segmentIndex); err != nil {
+		t.Fatal(err)
+	}
+	if err = df.RemoveSector(newAddr, newHash, segmentIndex); err == nil {
+		t.Errorf("removed sector removed again")
+	}
+	path, err := storage.NewDxPath(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	recoveredDF, err := readDxFile(testDir.Join(path), df.wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = checkDxFileEqual(df, recoveredDF); err != nil {
+		t.Error(err)
+	}
+	sectors := recoveredDF.segments[segmentIndex].Sectors[sectorIndex]
+	if len(sectors) != numSectors-1 {
+		t.Fatalf("expect %d sectors after removal, got %d", numSectors-1, len(sectors))
+	}
+	for _, sector := range sectors {
+		if sector.HostID == newAddr && sector.MerkleRoot == newHash {
+			t.Errorf("removed sector still exists")
+		}
+	}
+}
+
 // TestDelete test DxFile.Delete function
 func TestDelete(t *testing.T) {
 	df, err := newTestDxFile(t, sectorSize*64, 10, 30, erasurecode.ECTypeStandard)
@@ -91,6 +91,30 @@ func (fs *FileSet) NewDxFile(dxPath storage.DxPath, sourcePath storage.SysPath,
 	if err != nil {
 		return nil, err
 	}
+	return fs.register(dxPath, df), nil
+}
+
+// NewPackedDxFile create a DxFile whose data is packed into the pack file at loc. Return a
+// FileSetEntryWithID that has been registered with threadID in FileSetEntry
+func (fs *FileSet) NewPackedDxFile(dxPath storage.DxPath, sourcePath storage.SysPath, force bool, erasureCode erasurecode.ErasureCoder, cipherKey crypto.CipherKey, loc PackLocation, fileMode os.FileMode) (*FileSetEntryWithID, error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	exists := fs.exists(dxPath)
+	if exists && !force {
+		return nil, ErrFileExist
+	}
+	// Create a new packed DxFile
+	df, err := NewPacked(fs.filepath(dxPath), dxPath, sourcePath, fs.wal, erasureCode, cipherKey, loc, fileMode)
+	if err != nil {
+		return nil, err
+	}
+	return fs.register(dxPath, df), nil
+}
+
+// register registers the newly created DxFile to the file set, and returns the entry with
+// a new threadID.
+// Require: lock the file set by caller
+func (fs *FileSet) register(dxPath storage.DxPath, df *DxFile) *FileSetEntryWithID {
 	// Assign a threadID to the new DxFile. Register the threadID to the entry.
 	entry := fs.newFileSetEntry(df)
 	threadID := randomThreadID()
@@ -99,7 +123,7 @@ func (fs *FileSet) NewDxFile(dxPath storage.DxPath, sourcePath storage.SysPath,
 	return &FileSetEntryWithID{
 		fileSetEntry: entry,
 		threadID:     threadID,
-	}, nil
+	}
 }
 
 // CopyEntry copy the FileSetEntry. A new thread is created and registered in entry.threadMap
@@ -123,6 +123,45 @@ func TestFileSet_NewDxFile(t *testing.T) {
 	}
 }
 
+// TestFileSet_NewPackedDxFile test creating a packed DxFile, and its pack location is persisted
+func TestFileSet_NewPackedDxFile(t *testing.T) {
+	_, fs := newTestFileSet(t)
+	ec, err := erasurecode.New(erasurecode.ECTypeStandard, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ck, err := crypto.GenerateCipherKey(crypto.GCMCipherCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loc := PackLocation{PackPath: storage.PackDxPath("pack"), Segment: 1, Offset: 100, Length: 1000}
+	dxPath := randomDxPath()
+	entry, err := fs.NewPackedDxFile(dxPath, "", false, ec, ck, loc, 0777)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.FileSize() != loc.Length || entry.NumSegments() != 0 {
+		t.Errorf("packed file size %v, segments %v", entry.FileSize(), entry.NumSegments())
+	}
+	if err = entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+	recovered, err := fs.Open(dxPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recovered.Close()
+	if got, packed := recovered.PackLocation(); !packed || got != loc {
+		t.Errorf("pack location not expected: %+v", got)
+	}
+	if err = recovered.SetPackLocation(PackLocation{PackPath: loc.PackPath, Length: 1}); err == nil {
+		t.Error("pack location with wrong length shall give error")
+	}
+	if _, err = fs.NewPackedDxFile(randomDxPath(), "", false, ec, ck, PackLocation{PackPath: loc.PackPath}, 0777); err == nil {
+		t.Error("packed file of zero length shall give error")
+	}
+}
+
 // TestFileSet_CloseOpen test the FileSet Close and Open process.
 // First close the file, and then open the file. The process should not give error.
 func TestFileSet_CloseOpen(t *testing.T) {
@@ -61,6 +61,19 @@ type (
 
 		// Version control for fork
 		Version string
+
+		// Pack is the location of the file data in the pack file. It is empty if the file
+		// is not packed, and has exactly one element otherwise
+		Pack []PackLocation `rlp:"tail"`
+	}
+
+	// PackLocation is the location of a small file packed into a pack file, which is a
+	// DxFile whose segments are shared by multiple small files
+	PackLocation struct {
+		PackPath storage.DxPath // DxPath of the pack file
+		Segment  uint64         // index of the segment in the pack file
+		Offset   uint64         // offset of the file data within the segment
+		Length   uint64         // length of the file data
 	}
 
 	// UpdateMetaData is the Metadata to be updated
@@ -279,3 +292,36 @@ func (df *DxFile) SectorSize() uint64 {
 
 	return df.metadata.SectorSize
 }
+
+// PackLocation returns the location of the file data in the pack file, and whether the
+// file is packed
+func (df *DxFile) PackLocation() (PackLocation, bool) {
+	df.lock.RLock()
+	defer df.lock.RUnlock()
+
+	if len(df.metadata.Pack) == 0 {
+		return PackLocation{}, false
+	}
+	return df.metadata.Pack[0], true
+}
+
+// SetPackLocation change the location of the packed file data and save to disk. It is used
+// when the file data is moved to another pack file during compaction
+func (df *DxFile) SetPackLocation(loc PackLocation) error {
+	df.lock.Lock()
+	defer df.lock.Unlock()
+
+	if len(df.metadata.Pack) == 0 {
+		return fmt.Errorf("file %v is not packed", df.metadata.DxPath)
+	}
+	if loc.Length != df.metadata.FileSize {
+		return fmt.Errorf("pack location length %d not equal to file size %d", loc.Length, df.metadata.FileSize)
+	}
+	df.metadata.Pack = []PackLocation{loc}
+	return df.saveMetadata()
+}
+
+// PackOffset returns the offset of the file data within the pack file with the segment size
+func (loc PackLocation) PackOffset(segmentSize uint64) uint64 {
+	return loc.Segment*segmentSize + loc.Offset
+}
@@ -111,6 +111,19 @@ func (s *Segment) EncodeRLP(w io.Writer) error {
 	})
 }
 
+// DecodeRLP of Metadata implements rlp decode rule. The metadata of a file not packed
+// is decoded with nil Pack field, same as the metadata encoded before packing is supported
+func (md *Metadata) DecodeRLP(st *rlp.Stream) error {
+	type persistMetadata Metadata
+	if err := st.Decode((*persistMetadata)(md)); err != nil {
+		return err
+	}
+	if len(md.Pack) == 0 {
+		md.Pack = nil
+	}
+	return nil
+}
+
 // DecodeRLP of Segment implements rlp decode rule to decode the Sectors field
 func (s *Segment) DecodeRLP(st *rlp.Stream) error {
 	var ps persistSegment
@@ -201,6 +214,10 @@ func (md Metadata) segmentSize() uint64 {
 
 // numSegments is the number of segments of a dxfile based on metadata info
 func (md Metadata) numSegments() uint64 {
+	// packed file data is stored in the segments of the pack file
+	if len(md.Pack) != 0 {
+		return 0
+	}
 	num := md.FileSize / md.segmentSize()
 	if md.FileSize%md.segmentSize() != 0 || num == 0 {
 		num++
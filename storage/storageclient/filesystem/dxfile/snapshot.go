@@ -26,6 +26,7 @@ type Snapshot struct {
 	segments    []Segment
 	hostTable   map[enode.ID]bool
 	dxPath      storage.DxPath
+	uid         FileID
 }
 
 // SnapshotReader is the structure that allow reading the raw DxFile content
@@ -102,6 +103,7 @@ func (df *DxFile) Snapshot() (*Snapshot, error) {
 		segments:    segments,
 		hostTable:   hostTable,
 		dxPath:      df.metadata.DxPath,
+		uid:         df.ID,
 	}, nil
 }
 
@@ -152,6 +154,11 @@ func (s *Snapshot) DxPath() storage.DxPath {
 	return s.dxPath
 }
 
+// UID return the id of the DxFile the snapshot is taken from
+func (s *Snapshot) UID() FileID {
+	return s.uid
+}
+
 // FileSize return the file size
 func (s *Snapshot) FileSize() uint64 {
 	return uint64(s.fileSize)
@@ -77,6 +77,16 @@ type fileSystem struct {
 
 	// stuckFound is the channel to signal a stuck segment is found
 	stuckFound chan struct{}
+
+	// packs is the index of the pack files holding the data of the small files, and
+	// packLock is the lock protecting it
+	packs    packIndex
+	packLock sync.Mutex
+
+	// dedup is the reference counted index of the segments uploaded in dedup mode, and
+	// dedupLock is the lock protecting it
+	dedup     dedupIndex
+	dedupLock sync.Mutex
 }
 
 // newFileSystem creates a new file system with the standardDisrupter
@@ -108,6 +118,14 @@ func (fs *fileSystem) Start() error {
 		return fmt.Errorf("cannot start the file system dirSet: %v", err)
 	}
 	fs.fileSet = dxfile.NewFileSet(fs.fileRootDir, fs.fileWal)
+	// load the index of the pack files
+	if err := fs.loadPackIndex(); err != nil {
+		return fmt.Errorf("cannot start the file system pack index: %v", err)
+	}
+	// load the index of the deduplicated segments
+	if err := fs.loadDedupIndex(); err != nil {
+		return fmt.Errorf("cannot start the file system dedup index: %v", err)
+	}
 	// open the updateWal
 	if err := fs.loadUpdateWal(); err != nil {
 		return fmt.Errorf("cannot start the file system: %v", err)
@@ -146,7 +164,19 @@ func (fs *fileSystem) PersistDir() storage.SysPath {
 
 // NewDxFile creates a new dxfile in the file system
 func (fs *fileSystem) NewDxFile(dxPath storage.DxPath, sourcePath storage.SysPath, force bool, erasureCode erasurecode.ErasureCoder, cipherKey crypto.CipherKey, fileSize uint64, fileMode os.FileMode) (*dxfile.FileSetEntryWithID, error) {
-	return fs.fileSet.NewDxFile(dxPath, sourcePath, force, erasureCode, cipherKey, fileSize, fileMode)
+	entry, err := fs.fileSet.NewDxFile(dxPath, sourcePath, force, erasureCode, cipherKey, fileSize, fileMode)
+	if err != nil {
+		return nil, err
+	}
+	// the overridden file might be packed
+	if err = fs.onDxFileOverridden(dxPath); err != nil {
+		fs.logger.Warn("failed to update the pack index", "path", dxPath, "err", err)
+	}
+	// the overridden file might be uploaded in dedup mode
+	if err = fs.removeDedupRefs(dxPath); err != nil {
+		fs.logger.Warn("failed to update the dedup index", "path", dxPath, "err", err)
+	}
+	return entry, nil
 }
 
 // OpenDxFile opens the DxFile specified by the path
@@ -156,12 +186,24 @@ func (fs *fileSystem) OpenDxFile(path storage.DxPath) (*dxfile.FileSetEntryWithI
 
 // Delete delete the dxfile from the file system
 func (fs *fileSystem) DeleteDxFile(dxPath storage.DxPath) error {
-	return fs.fileSet.Delete(dxPath)
+	if err := fs.fileSet.Delete(dxPath); err != nil {
+		return err
+	}
+	if err := fs.onDxFileDeleted(dxPath); err != nil {
+		return err
+	}
+	return fs.removeDedupRefs(dxPath)
 }
 
 // RenameDxFile rename the dxfile from prevPath to newPath
 func (fs *fileSystem) RenameDxFile(prevPath, newPath storage.DxPath) error {
-	return fs.fileSet.Rename(prevPath, newPath)
+	if err := fs.fileSet.Rename(prevPath, newPath); err != nil {
+		return err
+	}
+	if err := fs.onDxFileRenamed(prevPath, newPath); err != nil {
+		return err
+	}
+	return fs.renameDedupRefs(prevPath, newPath)
 }
 
 // NewDxDir creates a new dxdir specified by path
@@ -542,6 +584,35 @@ func (fs *fileSystem) fileList() ([]storage.FileBriefInfo, error) {
 	return fileList, err
 }
 
+// DxFilePaths returns the DxPaths of all the dxfiles in the file system
+func (fs *fileSystem) DxFilePaths() ([]storage.DxPath, error) {
+	if err := fs.tm.Add(); err != nil {
+		return nil, err
+	}
+	defer fs.tm.Done()
+
+	var paths []storage.DxPath
+	err := filepath.Walk(string(fs.fileRootDir), func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != storage.DxFileExt {
+			return nil
+		}
+		str := strings.TrimSuffix(strings.TrimPrefix(path, string(fs.fileRootDir)), storage.DxFileExt)
+		dxPath, err := storage.NewDxPath(str)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, dxPath)
+		return nil
+	})
+	return paths, err
+}
+
 // fileDetailedInfo returns detailed information for a file specified by the path
 // If the input table is empty, the code the query the contractManager for health info
 func (fs *fileSystem) fileDetailedInfo(path storage.DxPath, table storage.HostHealthInfoTable) (storage.FileInfo, error) {
@@ -10,6 +10,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/crypto"
 	"github.com/DxChainNetwork/godx/log"
 	"github.com/DxChainNetwork/godx/p2p/enode"
@@ -34,6 +35,17 @@ type FileSystem interface {
 	OpenDxFile(path storage.DxPath) (*dxfile.FileSetEntryWithID, error)
 	RenameDxFile(prevDxPath, curDxPath storage.DxPath) error
 	DeleteDxFile(dxPath storage.DxPath) error
+	DxFilePaths() ([]storage.DxPath, error)
+
+	// Small file packing related methods
+	NewPackedDxFile(dxPath storage.DxPath, sourcePath storage.SysPath, force bool, erasureCode erasurecode.ErasureCoder, cipherKey crypto.CipherKey, loc dxfile.PackLocation, fileMode os.FileMode) (*dxfile.FileSetEntryWithID, error)
+	UpdatePackLocation(dxPath storage.DxPath, loc dxfile.PackLocation) error
+	PackInfo(packPath storage.DxPath) (PackInfo, bool)
+	PacksToCompact(threshold float64) []storage.DxPath
+
+	// Segment deduplication related methods
+	AddDedupRefs(dxPath storage.DxPath, hashes []common.Hash) ([]*DedupRef, error)
+	DedupStats() DedupStats
 
 	// DxDir related methods, including New and open
 	NewDxDir(path storage.DxPath) (*dxdir.DirSetEntryWithID, error)
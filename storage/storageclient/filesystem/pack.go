@@ -0,0 +1,231 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+)
+
+var packIndexMetadata = common.Metadata{
+	Header:  "storage client pack index",
+	Version: "1.0",
+}
+
+type (
+	// PackInfo is the usage information of a pack file, which holds the data of multiple
+	// small files. PackedBytes is the total size of the files ever packed into the pack
+	// file, and LiveBytes is the size of the files that are not deleted
+	PackInfo struct {
+		PackedBytes uint64                         `json:"packedBytes"`
+		LiveBytes   uint64                         `json:"liveBytes"`
+		Members     map[string]dxfile.PackLocation `json:"members"`
+	}
+
+	// packIndex is the persisted index from the pack file DxPath to the pack info
+	packIndex struct {
+		Packs map[string]*PackInfo `json:"packs"`
+	}
+)
+
+// deadRatio returns the ratio of the packed bytes that belong to the deleted files
+func (pi *PackInfo) deadRatio() float64 {
+	if pi.PackedBytes == 0 {
+		return 0
+	}
+	return float64(pi.PackedBytes-pi.LiveBytes) / float64(pi.PackedBytes)
+}
+
+// copy returns a deep copy of the pack info
+func (pi *PackInfo) copy() PackInfo {
+	cpy := PackInfo{
+		PackedBytes: pi.PackedBytes,
+		LiveBytes:   pi.LiveBytes,
+		Members:     make(map[string]dxfile.PackLocation, len(pi.Members)),
+	}
+	for path, loc := range pi.Members {
+		cpy.Members[path] = loc
+	}
+	return cpy
+}
+
+// NewPackedDxFile creates a new dxfile whose data is packed into the pack file at loc, and
+// registers the file as a member of the pack file
+func (fs *fileSystem) NewPackedDxFile(dxPath storage.DxPath, sourcePath storage.SysPath, force bool, erasureCode erasurecode.ErasureCoder, cipherKey crypto.CipherKey, loc dxfile.PackLocation, fileMode os.FileMode) (*dxfile.FileSetEntryWithID, error) {
+	entry, err := fs.fileSet.NewPackedDxFile(dxPath, sourcePath, force, erasureCode, cipherKey, loc, fileMode)
+	if err != nil {
+		return nil, err
+	}
+	fs.packLock.Lock()
+	defer fs.packLock.Unlock()
+	// the overridden file might be packed or uploaded in dedup mode
+	if err = fs.removeDedupRefs(dxPath); err != nil {
+		fs.logger.Warn("failed to update the dedup index", "path", dxPath, "err", err)
+	}
+	fs.removePackMember(dxPath)
+	pi := fs.packInfo(loc.PackPath)
+	pi.Members[dxPath.Path] = loc
+	pi.PackedBytes += loc.Length
+	pi.LiveBytes += loc.Length
+	return entry, fs.savePackIndex()
+}
+
+// UpdatePackLocation moves the data of the packed dxfile to a new location. It is used when
+// the pack file is compacted, and the live files are packed into another pack file
+func (fs *fileSystem) UpdatePackLocation(dxPath storage.DxPath, loc dxfile.PackLocation) error {
+	entry, err := fs.fileSet.Open(dxPath)
+	if err != nil {
+		return err
+	}
+	defer entry.Close()
+	if err = entry.SetPackLocation(loc); err != nil {
+		return err
+	}
+
+	fs.packLock.Lock()
+	defer fs.packLock.Unlock()
+	fs.removePackMember(dxPath)
+	pi := fs.packInfo(loc.PackPath)
+	pi.Members[dxPath.Path] = loc
+	pi.PackedBytes += loc.Length
+	pi.LiveBytes += loc.Length
+	return fs.savePackIndex()
+}
+
+// PackInfo returns the usage information of the pack file
+func (fs *fileSystem) PackInfo(packPath storage.DxPath) (PackInfo, bool) {
+	fs.packLock.Lock()
+	defer fs.packLock.Unlock()
+
+	pi, exist := fs.packs.Packs[packPath.Path]
+	if !exist {
+		return PackInfo{}, false
+	}
+	return pi.copy(), true
+}
+
+// PacksToCompact returns the pack files of which the ratio of data belonging to the deleted
+// files reaches the threshold. The result is sorted by the dead ratio in descending order
+func (fs *fileSystem) PacksToCompact(threshold float64) []storage.DxPath {
+	fs.packLock.Lock()
+	defer fs.packLock.Unlock()
+
+	var paths []storage.DxPath
+	for path, pi := range fs.packs.Packs {
+		if pi.deadRatio() >= threshold {
+			paths = append(paths, storage.DxPath{Path: path})
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return fs.packs.Packs[paths[i].Path].deadRatio() > fs.packs.Packs[paths[j].Path].deadRatio()
+	})
+	return paths
+}
+
+// packInfo returns the pack info of the pack file, creating one if not exist.
+// Require: lock fs.packLock by caller
+func (fs *fileSystem) packInfo(packPath storage.DxPath) *PackInfo {
+	pi, exist := fs.packs.Packs[packPath.Path]
+	if !exist {
+		pi = &PackInfo{Members: make(map[string]dxfile.PackLocation)}
+		fs.packs.Packs[packPath.Path] = pi
+	}
+	return pi
+}
+
+// removePackMember removes the file from the members of its pack file, and returns whether
+// the file is a member of any pack file.
+// Require: lock fs.packLock by caller
+func (fs *fileSystem) removePackMember(dxPath storage.DxPath) bool {
+	for _, pi := range fs.packs.Packs {
+		loc, exist := pi.Members[dxPath.Path]
+		if !exist {
+			continue
+		}
+		delete(pi.Members, dxPath.Path)
+		pi.LiveBytes -= loc.Length
+		return true
+	}
+	return false
+}
+
+// onDxFileDeleted updates the pack index after the dxfile is deleted. If the file is packed,
+// it is removed from the members of the pack file. If the file is a pack file, the pack
+// info is removed
+func (fs *fileSystem) onDxFileDeleted(dxPath storage.DxPath) error {
+	fs.packLock.Lock()
+	defer fs.packLock.Unlock()
+
+	if _, exist := fs.packs.Packs[dxPath.Path]; exist && dxPath.IsPack() {
+		delete(fs.packs.Packs, dxPath.Path)
+		return fs.savePackIndex()
+	}
+	if fs.removePackMember(dxPath) {
+		return fs.savePackIndex()
+	}
+	return nil
+}
+
+// onDxFileOverridden updates the pack index after the dxfile is overridden by a file not packed
+func (fs *fileSystem) onDxFileOverridden(dxPath storage.DxPath) error {
+	fs.packLock.Lock()
+	defer fs.packLock.Unlock()
+
+	if fs.removePackMember(dxPath) {
+		return fs.savePackIndex()
+	}
+	return nil
+}
+
+// onDxFileRenamed updates the pack index after the dxfile is renamed
+func (fs *fileSystem) onDxFileRenamed(prevPath, curPath storage.DxPath) error {
+	fs.packLock.Lock()
+	defer fs.packLock.Unlock()
+
+	for _, pi := range fs.packs.Packs {
+		loc, exist := pi.Members[prevPath.Path]
+		if !exist {
+			continue
+		}
+		delete(pi.Members, prevPath.Path)
+		pi.Members[curPath.Path] = loc
+		return fs.savePackIndex()
+	}
+	return nil
+}
+
+// loadPackIndex loads the pack index from the persist directory
+func (fs *fileSystem) loadPackIndex() error {
+	fs.packLock.Lock()
+	defer fs.packLock.Unlock()
+
+	fs.packs = packIndex{}
+	err := common.LoadDxJSON(packIndexMetadata, filepath.Join(string(fs.persistDir), packIndexFileName), &fs.packs)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if fs.packs.Packs == nil {
+		fs.packs.Packs = make(map[string]*PackInfo)
+	}
+	for _, pi := range fs.packs.Packs {
+		if pi.Members == nil {
+			pi.Members = make(map[string]dxfile.PackLocation)
+		}
+	}
+	return nil
+}
+
+// savePackIndex saves the pack index to the persist directory.
+// Require: lock fs.packLock by caller
+func (fs *fileSystem) savePackIndex() error {
+	return common.SaveDxJSON(packIndexMetadata, filepath.Join(string(fs.persistDir), packIndexFileName), fs.packs)
+}
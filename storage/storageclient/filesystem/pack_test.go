@@ -0,0 +1,97 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package filesystem
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+)
+
+// TestFileSystem_PackIndex test the pack index is updated along with the packed files being
+// created, renamed, moved and deleted
+func TestFileSystem_PackIndex(t *testing.T) {
+	fs := newEmptyTestFileSystem(t, "", &AlwaysSuccessContractManager{}, newStandardDisrupter())
+	ec, err := erasurecode.New(erasurecode.ECTypeStandard, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ck, err := crypto.GenerateCipherKey(crypto.GCMCipherCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pack1, pack2 := storage.PackDxPath("pack1"), storage.PackDxPath("pack2")
+	paths := []storage.DxPath{randomDxPath(t, 2), randomDxPath(t, 2), randomDxPath(t, 2)}
+	for i, path := range paths {
+		loc := dxfile.PackLocation{PackPath: pack1, Offset: uint64(i) * 100, Length: 100}
+		entry, err := fs.NewPackedDxFile(path, "", false, ec, ck, loc, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = entry.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if info, exist := fs.PackInfo(pack1); !exist || info.PackedBytes != 300 || info.LiveBytes != 300 || len(info.Members) != 3 {
+		t.Fatalf("pack info not expected: %+v", info)
+	}
+
+	// delete two of the packed files
+	for _, path := range paths[:2] {
+		if err = fs.DeleteDxFile(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = fs.waitForUpdatesComplete(1 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := fs.PackInfo(pack1); info.LiveBytes != 100 || len(info.Members) != 1 {
+		t.Fatalf("pack info not expected after delete: %+v", info)
+	}
+	if toCompact := fs.PacksToCompact(0.5); len(toCompact) != 1 || !toCompact[0].Equals(pack1) {
+		t.Fatalf("packs to compact not expected: %v", toCompact)
+	}
+	if toCompact := fs.PacksToCompact(0.7); len(toCompact) != 0 {
+		t.Fatalf("packs to compact not expected: %v", toCompact)
+	}
+
+	// rename the live file, and move it to another pack
+	renamed := randomDxPath(t, 2)
+	if err = fs.RenameDxFile(paths[2], renamed); err != nil {
+		t.Fatal(err)
+	}
+	newLoc := dxfile.PackLocation{PackPath: pack2, Segment: 1, Length: 100}
+	if err = fs.UpdatePackLocation(renamed, newLoc); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := fs.PackInfo(pack1); info.LiveBytes != 0 || len(info.Members) != 0 {
+		t.Fatalf("pack info not expected after move: %+v", info)
+	}
+	if info, _ := fs.PackInfo(pack2); info.Members[renamed.Path] != newLoc {
+		t.Fatalf("pack info not expected after move: %+v", info)
+	}
+	entry, err := fs.OpenDxFile(renamed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc, packed := entry.PackLocation(); !packed || loc != newLoc {
+		t.Errorf("pack location not expected: %+v", loc)
+	}
+	if err = entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the pack index shall be persisted
+	if err = fs.loadPackIndex(); err != nil {
+		t.Fatal(err)
+	}
+	if info, exist := fs.PackInfo(pack2); !exist || info.LiveBytes != 100 || info.Members[renamed.Path] != newLoc {
+		t.Errorf("pack info not expected after reload: %+v", info)
+	}
+}
@@ -6,6 +6,7 @@ package storageclient
 
 import (
 	"fmt"
+	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/common/unit"
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/p2p/enode"
@@ -84,10 +85,37 @@ func formatClientSetting(setting storage.ClientSetting) (formatted storage.Clien
 	formatted.EnableIPViolation = formatIPViolation(setting.EnableIPViolation)
 	formatted.MaxUploadSpeed = unit.FormatSpeed(setting.MaxUploadSpeed)
 	formatted.MaxDownloadSpeed = unit.FormatSpeed(setting.MaxDownloadSpeed)
+	formatted.DownloadHedge = formatDownloadHedge(setting.DownloadHedge)
+	formatted.SegmentCacheSize = formatSegmentCacheSize(setting.SegmentCacheSize)
+	formatted.AuditBudget = formatAuditBudget(setting.AuditBudget)
 	formatted.RentPayment = formatRentPayment(setting.RentPayment)
 	return
 }
 
+// formatDownloadHedge is used to format storage.ClientSetting.DownloadHedge field
+func formatDownloadHedge(hedge uint64) (formatted string) {
+	if hedge == 0 {
+		return "Disabled: only the minimum sectors of a segment are requested"
+	}
+	return fmt.Sprintf("up to %v extra sectors per segment", hedge)
+}
+
+// formatSegmentCacheSize is used to format storage.ClientSetting.SegmentCacheSize field
+func formatSegmentCacheSize(size uint64) (formatted string) {
+	if size == 0 {
+		return "Disabled: the downloaded segments are not cached locally"
+	}
+	return unit.FormatStorage(size, true)
+}
+
+// formatAuditBudget is used to format storage.ClientSetting.AuditBudget field
+func formatAuditBudget(budget common.BigInt) (formatted string) {
+	if budget.Sign() <= 0 {
+		return "Disabled: the storage hosts are not audited"
+	}
+	return fmt.Sprintf("%s per period", unit.FormatCurrency(budget))
+}
+
 // formatIPViolation is used to format storage.ClientSetting.IPViolation field
 func formatIPViolation(enabled bool) (formatted string) {
 	if enabled {
@@ -0,0 +1,415 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+)
+
+// openPack is the pack file currently accepting small files. The data of the packed files
+// is staged in a local file, and the pack file is uploaded as a whole once it is full or
+// has been open for packFlushInterval
+type openPack struct {
+	DxPath        storage.DxPath
+	LocalPath     storage.SysPath
+	CipherKeyCode uint8
+	CipherKey     []byte
+	SegmentSize   uint64
+	Size          uint64
+	TimeCreate    time.Time
+}
+
+// shouldPack returns whether the uploaded file shall be packed into a pack file. Only the
+// small files uploaded with the default erasure code, and not in dedup mode are packed
+func shouldPack(up storage.FileUploadParams, size int64) bool {
+	return up.ErasureCode == nil && !up.Dedup && size > 0 && size < packFileSizeThreshold
+}
+
+// packErasureCode returns the erasure code of the pack files
+func packErasureCode() (erasurecode.ErasureCoder, error) {
+	return erasurecode.New(erasurecode.ECTypeStandard, storage.DefaultMinSectors, storage.DefaultNumSectors)
+}
+
+// cipherKey returns the cipher key of the open pack
+func (op *openPack) cipherKey() (crypto.CipherKey, error) {
+	return crypto.NewCipherKey(op.CipherKeyCode, op.CipherKey)
+}
+
+// full returns whether the open pack might not be able to hold another small file
+func (op *openPack) full() bool {
+	return op.Size+packFileSizeThreshold > packMaxSegments*op.SegmentSize
+}
+
+// nextLocation returns the location in the open pack for data of the length. The data of
+// a small file never crosses the segment boundary, so that it can be downloaded from a
+// single segment
+func (op *openPack) nextLocation(length uint64) dxfile.PackLocation {
+	segment, offset := op.Size/op.SegmentSize, op.Size%op.SegmentSize
+	if offset != 0 && offset+length > op.SegmentSize {
+		segment, offset = segment+1, 0
+	}
+	return dxfile.PackLocation{
+		PackPath: op.DxPath,
+		Segment:  segment,
+		Offset:   offset,
+		Length:   length,
+	}
+}
+
+// uploadPacked packs the small file into the open pack file. The file is uploaded along
+// with the pack file once the pack file is flushed
+func (client *StorageClient) uploadPacked(up storage.FileUploadParams, cipherKey crypto.CipherKey, sourceInfo os.FileInfo) error {
+	data, err := ioutil.ReadFile(up.Source)
+	if err != nil {
+		return fmt.Errorf("unable to read the source file, error: %v", err)
+	}
+
+	client.packLock.Lock()
+	defer client.packLock.Unlock()
+
+	pack, err := client.currentPack()
+	if err != nil {
+		return fmt.Errorf("unable to open a pack file, error: %v", err)
+	}
+	loc, err := client.appendToPack(pack, data)
+	if err != nil {
+		return fmt.Errorf("unable to pack the file, error: %v", err)
+	}
+	entry, err := client.fileSystem.NewPackedDxFile(up.DxPath, storage.SysPath(up.Source), false, up.ErasureCode, cipherKey, loc, sourceInfo.Mode())
+	if err != nil {
+		return fmt.Errorf("could not create a new dx file, error: %v", err)
+	}
+	if err = entry.Close(); err != nil {
+		return err
+	}
+
+	// Update the health of the DxFile directory recursively to ensure the health is updated with the new file
+	go client.fileSystem.InitAndUpdateDirMetadata(up.DxPath)
+
+	if pack.full() {
+		return client.flushPack()
+	}
+	return nil
+}
+
+// currentPack returns the open pack file, creating a new one if there is none.
+// Require: lock client.packLock by caller
+func (client *StorageClient) currentPack() (*openPack, error) {
+	if client.persist.OpenPack != nil {
+		return client.persist.OpenPack, nil
+	}
+	ec, err := packErasureCode()
+	if err != nil {
+		return nil, err
+	}
+	cipherKey, err := crypto.GenerateCipherKey(crypto.GCMCipherCode)
+	if err != nil {
+		return nil, fmt.Errorf("generate cipher key error: %v", err)
+	}
+	id := make([]byte, 16)
+	if _, err = rand.Read(id); err != nil {
+		return nil, err
+	}
+	name := hex.EncodeToString(id)
+	stagingDir := filepath.Join(client.persistDir, packStagingDir)
+	if err = os.MkdirAll(stagingDir, 0700); err != nil {
+		return nil, err
+	}
+	pack := &openPack{
+		DxPath:        storage.PackDxPath(name),
+		LocalPath:     storage.SysPath(filepath.Join(stagingDir, name)),
+		CipherKeyCode: crypto.CipherCodeByName(cipherKey.CodeName()),
+		CipherKey:     cipherKey.Key(),
+		SegmentSize:   (dxfile.SectorSize - uint64(cipherKey.Overhead())) * uint64(ec.MinSectors()),
+		TimeCreate:    time.Now(),
+	}
+	return pack, client.setOpenPack(pack)
+}
+
+// setOpenPack sets the open pack file and saves the settings.
+// Require: lock client.packLock by caller
+func (client *StorageClient) setOpenPack(pack *openPack) error {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	client.persist.OpenPack = pack
+	return client.saveSettings()
+}
+
+// appendToPack writes the data to the local staging file of the open pack, and returns the
+// location of the data in the pack file.
+// Require: lock client.packLock by caller
+func (client *StorageClient) appendToPack(pack *openPack, data []byte) (dxfile.PackLocation, error) {
+	loc := pack.nextLocation(uint64(len(data)))
+	f, err := os.OpenFile(string(pack.LocalPath), os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return dxfile.PackLocation{}, err
+	}
+	if _, err = f.WriteAt(data, int64(loc.PackOffset(pack.SegmentSize))); err != nil {
+		f.Close()
+		return dxfile.PackLocation{}, err
+	}
+	if err = f.Close(); err != nil {
+		return dxfile.PackLocation{}, err
+	}
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	pack.Size = loc.PackOffset(pack.SegmentSize) + loc.Length
+	return loc, client.saveSettings()
+}
+
+// flushPack closes the open pack file, and uploads it as a regular dxfile.
+// Require: lock client.packLock by caller
+func (client *StorageClient) flushPack() error {
+	pack := client.persist.OpenPack
+	if pack == nil || pack.Size == 0 {
+		return nil
+	}
+	ec, err := packErasureCode()
+	if err != nil {
+		return err
+	}
+	cipherKey, err := pack.cipherKey()
+	if err != nil {
+		return err
+	}
+	dirEntry, err := client.fileSystem.NewDxDir(storage.PackDirDxPath())
+	if err != os.ErrExist && err != nil {
+		return fmt.Errorf("unable to create dx directory for pack files, error: %v", err)
+	} else if err == nil {
+		if err = dirEntry.Close(); err != nil {
+			return err
+		}
+	}
+	entry, err := client.fileSystem.NewDxFile(pack.DxPath, pack.LocalPath, false, ec, cipherKey, pack.Size, 0600)
+	if err != nil {
+		return fmt.Errorf("could not create the pack file, error: %v", err)
+	}
+	if err = client.setOpenPack(nil); err != nil {
+		return err
+	}
+
+	// Send the upload to the repair loop
+	hosts := client.refreshHostsAndWorkers()
+	if err = client.createAndPushSegments([]*dxfile.FileSetEntryWithID{entry}, hosts, targetUnstuckSegments, make(storage.HostHealthInfoTable)); err != nil {
+		return err
+	}
+	select {
+	case client.uploadHeap.segmentComing <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// FlushPack uploads the open pack file without waiting for it to be full
+func (client *StorageClient) FlushPack() error {
+	if err := client.tm.Add(); err != nil {
+		return err
+	}
+	defer client.tm.Done()
+
+	client.packLock.Lock()
+	defer client.packLock.Unlock()
+	return client.flushPack()
+}
+
+// packedDownloadRange returns the file and range to download for the packed file. If the
+// pack file is still open, the data is copied from the local staging file to the destination
+// directly, and there is nothing left to download
+func (client *StorageClient) packedDownloadRange(entry *dxfile.FileSetEntryWithID, loc dxfile.PackLocation, dw writeDestination) (*dxfile.Snapshot, uint64, uint64, error) {
+	client.packLock.Lock()
+	pack := client.persist.OpenPack
+	if pack != nil && pack.DxPath.Equals(loc.PackPath) {
+		data, err := readStaged(pack.LocalPath, loc.PackOffset(pack.SegmentSize), loc.Length)
+		client.packLock.Unlock()
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		if _, err = dw.WriteAt(data, 0); err != nil {
+			return nil, 0, 0, err
+		}
+		snap, err := entry.Snapshot()
+		return snap, 0, 0, err
+	}
+	client.packLock.Unlock()
+
+	packEntry, err := client.fileSystem.OpenDxFile(loc.PackPath)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("cannot open the pack file: %v", err)
+	}
+	defer packEntry.Close()
+	snap, err := packEntry.Snapshot()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	return snap, loc.PackOffset(snap.SegmentSize()), loc.Length, nil
+}
+
+// readPacked reads the data of the packed file. The data is read from the local staging
+// file if available, and downloaded from the hosts otherwise.
+// Require: lock client.packLock by caller
+func (client *StorageClient) readPacked(loc dxfile.PackLocation) ([]byte, error) {
+	if pack := client.persist.OpenPack; pack != nil && pack.DxPath.Equals(loc.PackPath) {
+		return readStaged(pack.LocalPath, loc.PackOffset(pack.SegmentSize), loc.Length)
+	}
+	packEntry, err := client.fileSystem.OpenDxFile(loc.PackPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open the pack file: %v", err)
+	}
+	defer packEntry.Close()
+	snap, err := packEntry.Snapshot()
+	if err != nil {
+		return nil, fmt.Errorf("cannot create the snapshot: %v", err)
+	}
+	offset := loc.PackOffset(snap.SegmentSize())
+	if data, err := readStaged(packEntry.LocalPath(), offset, loc.Length); err == nil {
+		return data, nil
+	}
+
+	buf := newDownloadBuffer(loc.Length, snap.SectorSize())
+	d, err := client.newDownload(downloadParams{
+		destination:     buf,
+		destinationType: "buffer",
+		file:            snap,
+		latencyTarget:   200e3, // No need to rush latency on compaction downloads.
+		length:          loc.Length,
+		needsMemory:     true,
+		offset:          offset,
+		overdrive:       0,
+		priority:        0,
+	})
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case <-d.completeChan:
+	case <-client.tm.StopChan():
+		return nil, errors.New("pack download interrupted by stop call")
+	}
+	if d.Err() != nil {
+		return nil, d.Err()
+	}
+	data := make([]byte, 0, loc.Length)
+	for _, sector := range buf.buf {
+		data = append(data, sector...)
+	}
+	return data[:loc.Length], nil
+}
+
+// readStaged reads the data of the length at the offset of the local staging file
+func readStaged(path storage.SysPath, offset, length uint64) ([]byte, error) {
+	f, err := os.Open(string(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data := make([]byte, length)
+	if _, err = f.ReadAt(data, int64(offset)); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// compactPacks compacts the pack files of which enough packed files have been deleted
+func (client *StorageClient) compactPacks() {
+	for _, packPath := range client.fileSystem.PacksToCompact(packCompactThreshold) {
+		if err := client.compactPack(packPath); err != nil {
+			client.log.Warn("Failed to compact the pack file", "pack", packPath.Path, "err", err)
+		}
+	}
+}
+
+// compactPack moves the data of the live files in the pack file to the open pack file,
+// and deletes the pack file along with the local staging data. The sectors of the deleted
+// pack file are no longer renewed with the contracts
+func (client *StorageClient) compactPack(packPath storage.DxPath) error {
+	client.packLock.Lock()
+	defer client.packLock.Unlock()
+
+	// the open pack file is not compacted until flushed
+	if pack := client.persist.OpenPack; pack != nil && pack.DxPath.Equals(packPath) {
+		return nil
+	}
+	info, exist := client.fileSystem.PackInfo(packPath)
+	if !exist {
+		return nil
+	}
+	for path, loc := range info.Members {
+		data, err := client.readPacked(loc)
+		if err != nil {
+			return fmt.Errorf("cannot read packed file %v: %v", path, err)
+		}
+		pack, err := client.currentPack()
+		if err != nil {
+			return err
+		}
+		newLoc, err := client.appendToPack(pack, data)
+		if err != nil {
+			return err
+		}
+		if err = client.fileSystem.UpdatePackLocation(storage.DxPath{Path: path}, newLoc); err != nil {
+			// the file might have been deleted in the meanwhile
+			client.log.Warn("Failed to move the packed file", "path", path, "err", err)
+		}
+		if pack.full() {
+			if err = client.flushPack(); err != nil {
+				return err
+			}
+		}
+	}
+
+	// all live files are moved, remove the pack file
+	packEntry, err := client.fileSystem.OpenDxFile(packPath)
+	if err != nil {
+		return err
+	}
+	localPath := packEntry.LocalPath()
+	if err = packEntry.Close(); err != nil {
+		return err
+	}
+	if err = client.fileSystem.DeleteDxFile(packPath); err != nil {
+		return err
+	}
+	if err = os.Remove(string(localPath)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// packLoop periodically uploads the open pack file that has been waiting for too long, and
+// compacts the pack files of which enough packed files have been deleted
+func (client *StorageClient) packLoop() {
+	if err := client.tm.Add(); err != nil {
+		return
+	}
+	defer client.tm.Done()
+
+	for {
+		select {
+		case <-client.tm.StopChan():
+			return
+		case <-time.After(packCheckInterval):
+		}
+		client.packLock.Lock()
+		if pack := client.persist.OpenPack; pack != nil && time.Since(pack.TimeCreate) > packFlushInterval {
+			if err := client.flushPack(); err != nil {
+				client.log.Warn("Failed to flush the pack file", "err", err)
+			}
+		}
+		client.packLock.Unlock()
+		client.compactPacks()
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"testing"
+
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+)
+
+func TestOpenPack_NextLocation(t *testing.T) {
+	segSize := uint64(1 << 22)
+	tests := []struct {
+		size, length uint64
+		expect       dxfile.PackLocation
+	}{
+		{0, 100, dxfile.PackLocation{Segment: 0, Offset: 0, Length: 100}},
+		{segSize - 100, 100, dxfile.PackLocation{Segment: 0, Offset: segSize - 100, Length: 100}},
+		{segSize - 50, 100, dxfile.PackLocation{Segment: 1, Offset: 0, Length: 100}},
+		{segSize, 1000, dxfile.PackLocation{Segment: 1, Offset: 0, Length: 1000}},
+		{segSize + 10, 1000, dxfile.PackLocation{Segment: 1, Offset: 10, Length: 1000}},
+	}
+	for i, test := range tests {
+		pack := &openPack{DxPath: storage.PackDxPath("pack"), SegmentSize: segSize, Size: test.size}
+		loc := pack.nextLocation(test.length)
+		test.expect.PackPath = pack.DxPath
+		if loc != test.expect {
+			t.Errorf("test %d: expect location %+v, got %+v", i, test.expect, loc)
+		}
+	}
+
+	// the pack is full once it might not hold another small file
+	pack := &openPack{SegmentSize: segSize, Size: packMaxSegments*segSize - packFileSizeThreshold}
+	if pack.full() {
+		t.Error("pack shall not be full")
+	}
+	pack.Size++
+	if !pack.full() {
+		t.Error("pack shall be full")
+	}
+}
+
+func TestShouldPack(t *testing.T) {
+	tests := []struct {
+		size   int64
+		expect bool
+	}{
+		{0, false},
+		{1, true},
+		{packFileSizeThreshold - 1, true},
+		{packFileSizeThreshold, false},
+	}
+	for i, test := range tests {
+		if res := shouldPack(storage.FileUploadParams{}, test.size); res != test.expect {
+			t.Errorf("test %d: expect %v, got %v", i, test.expect, res)
+		}
+	}
+}
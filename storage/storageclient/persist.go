@@ -20,6 +20,21 @@ var settingsMetadata = common.Metadata{
 type persistence struct {
 	MaxDownloadSpeed int64
 	MaxUploadSpeed   int64
+	OpenPack         *openPack
+	DedupKey         *dedupKey
+
+	// SegmentCacheSize is the size limit of the local segment cache, zero disables the cache
+	SegmentCacheSize uint64
+
+	// AuditBudget is the maximum amount spent auditing the storage hosts in each period, and
+	// AuditSpent is the amount spent in the period starting at AuditPeriod
+	AuditBudget common.BigInt
+	AuditSpent  common.BigInt
+	AuditPeriod uint64
+
+	// DownloadHedge is nil for the settings saved before the download hedge is introduced,
+	// where the default download hedge is used
+	DownloadHedge *uint64
 }
 
 func (client *StorageClient) loadPersist() error {
@@ -32,7 +47,11 @@ func (client *StorageClient) loadPersist() error {
 	// initialize logger
 	client.log = log.New()
 
-	return client.loadSettings()
+	if err = client.loadSettings(); err != nil {
+		return err
+	}
+	client.segmentCache, err = newSegmentCache(filepath.Join(client.persistDir, SegmentCacheDir), client.persist.SegmentCacheSize)
+	return err
 }
 
 // save StorageClient settings into storageclient.json file
@@ -47,6 +66,8 @@ func (client *StorageClient) loadSettings() error {
 	if os.IsNotExist(err) {
 		client.persist.MaxDownloadSpeed = DefaultMaxDownloadSpeed
 		client.persist.MaxUploadSpeed = DefaultMaxUploadSpeed
+		hedge := uint64(DefaultDownloadHedge)
+		client.persist.DownloadHedge = &hedge
 		err = client.saveSettings()
 		if err != nil {
 			return err
@@ -0,0 +1,221 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package storageclient
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+)
+
+// segmentCacheKey is the key of the segment cached, which is the UID of the DxFile and the
+// index of the segment
+type segmentCacheKey struct {
+	uid   dxfile.FileID
+	index uint64
+}
+
+// fileName returns the name of the file storing the segment in the cache directory
+func (key segmentCacheKey) fileName() string {
+	return fmt.Sprintf("%s_%d", hex.EncodeToString(key.uid[:]), key.index)
+}
+
+// parseSegmentCacheKey parses the segment cache key from the name of the cache file
+func parseSegmentCacheKey(name string) (key segmentCacheKey, err error) {
+	strs := strings.Split(name, "_")
+	if len(strs) != 2 {
+		return segmentCacheKey{}, fmt.Errorf("invalid segment cache file name: %s", name)
+	}
+	uid, err := hex.DecodeString(strs[0])
+	if err != nil || len(uid) != len(key.uid) {
+		return segmentCacheKey{}, fmt.Errorf("invalid segment cache file name: %s", name)
+	}
+	copy(key.uid[:], uid)
+	if key.index, err = strconv.ParseUint(strs[1], 10, 64); err != nil {
+		return segmentCacheKey{}, fmt.Errorf("invalid segment cache file name: %s", name)
+	}
+	return
+}
+
+// segmentCacheEntry is the entry of the segment cached, where size is the size of the segment data
+type segmentCacheEntry struct {
+	key  segmentCacheKey
+	size uint64
+}
+
+// segmentCache is the on-disk read-through cache of the decrypted logical segment data downloaded,
+// so that the segments downloaded repeatedly are not fetched and paid for again. The cache is
+// bounded by the size limit, and the least recently used segments are evicted first. Each cache
+// file starts with the checksum of the segment data, which is verified on every read
+type segmentCache struct {
+	dir   string
+	limit uint64
+	size  uint64
+
+	// the most recently used segment is at the front of the list
+	lru     *list.List
+	entries map[segmentCacheKey]*list.Element
+
+	lock sync.Mutex
+}
+
+// newSegmentCache creates the segment cache in the directory with the size limit, where zero
+// limit disables the cache. The segments cached before are loaded, and the files accessed more
+// recently are regarded as more recently used
+func newSegmentCache(dir string, limit uint64) (*segmentCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	sc := &segmentCache{
+		dir:     dir,
+		limit:   limit,
+		lru:     list.New(),
+		entries: make(map[segmentCacheKey]*list.Element),
+	}
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ModTime().After(infos[j].ModTime())
+	})
+	for _, info := range infos {
+		key, err := parseSegmentCacheKey(info.Name())
+		if err != nil || info.Size() < sha256.Size {
+			_ = os.Remove(filepath.Join(dir, info.Name()))
+			continue
+		}
+		entry := &segmentCacheEntry{key: key, size: uint64(info.Size()) - sha256.Size}
+		sc.entries[key] = sc.lru.PushBack(entry)
+		sc.size += entry.size
+	}
+
+	sc.lock.Lock()
+	sc.evict()
+	sc.lock.Unlock()
+	return sc, nil
+}
+
+// get returns the segment data cached. The segment failing the integrity check is removed from
+// the cache and regarded as not cached
+func (sc *segmentCache) get(uid dxfile.FileID, index uint64, size uint64) ([]byte, bool) {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	key := segmentCacheKey{uid: uid, index: index}
+	elem, exists := sc.entries[key]
+	if !exists {
+		return nil, false
+	}
+
+	path := filepath.Join(sc.dir, key.fileName())
+	data, err := ioutil.ReadFile(path)
+	if err != nil || len(data) != sha256.Size+int(size) {
+		sc.remove(elem)
+		return nil, false
+	}
+	checksum := sha256.Sum256(data[sha256.Size:])
+	if !bytes.Equal(checksum[:], data[:sha256.Size]) {
+		sc.remove(elem)
+		return nil, false
+	}
+
+	// the modification time of the file keeps the order of the recent use across restarts
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	sc.lru.MoveToFront(elem)
+	return data[sha256.Size:], true
+}
+
+// put adds the segment data to the cache, and evicts the least recently used segments if the
+// size limit is exceeded. The segment larger than the size limit is not cached
+func (sc *segmentCache) put(uid dxfile.FileID, index uint64, data []byte) error {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	if uint64(len(data)) > sc.limit {
+		return nil
+	}
+	key := segmentCacheKey{uid: uid, index: index}
+	if elem, exists := sc.entries[key]; exists {
+		sc.remove(elem)
+	}
+
+	checksum := sha256.Sum256(data)
+	if err := ioutil.WriteFile(filepath.Join(sc.dir, key.fileName()), append(checksum[:], data...), 0600); err != nil {
+		return err
+	}
+	sc.entries[key] = sc.lru.PushFront(&segmentCacheEntry{key: key, size: uint64(len(data))})
+	sc.size += uint64(len(data))
+	sc.evict()
+	return nil
+}
+
+// removeFile removes all segments of the DxFile from the cache
+func (sc *segmentCache) removeFile(uid dxfile.FileID) {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	for key, elem := range sc.entries {
+		if key.uid == uid {
+			sc.remove(elem)
+		}
+	}
+}
+
+// setLimit updates the size limit of the cache, and evicts the least recently used segments
+// exceeding the new limit
+func (sc *segmentCache) setLimit(limit uint64) {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	sc.limit = limit
+	sc.evict()
+}
+
+// evict removes the least recently used segments until the size limit is satisfied.
+// Require: lock sc.lock by caller
+func (sc *segmentCache) evict() {
+	for sc.size > sc.limit {
+		sc.remove(sc.lru.Back())
+	}
+}
+
+// remove removes the segment from the cache, along with the cache file.
+// Require: lock sc.lock by caller
+func (sc *segmentCache) remove(elem *list.Element) {
+	entry := sc.lru.Remove(elem).(*segmentCacheEntry)
+	delete(sc.entries, entry.key)
+	sc.size -= entry.size
+	_ = os.Remove(filepath.Join(sc.dir, entry.key.fileName()))
+}
+
+// segmentCacheSize returns the size limit of the local segment cache
+func (client *StorageClient) segmentCacheSize() uint64 {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	return client.persist.SegmentCacheSize
+}
+
+// cachedSegment returns the logical segment data of the file cached locally
+func (client *StorageClient) cachedSegment(file *dxfile.Snapshot, index uint64) ([]byte, bool) {
+	if client.segmentCache == nil {
+		return nil, false
+	}
+	return client.segmentCache.get(file.UID(), index, file.SegmentSize())
+}
@@ -0,0 +1,122 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package storageclient
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+)
+
+func newTestSegmentCache(t *testing.T, limit uint64) (*segmentCache, string) {
+	dir, err := ioutil.TempDir("", "segmentcache")
+	if err != nil {
+		t.Fatalf("failed to create the temp dir: %s", err.Error())
+	}
+	sc, err := newSegmentCache(dir, limit)
+	if err != nil {
+		t.Fatalf("failed to create the segment cache: %s", err.Error())
+	}
+	return sc, dir
+}
+
+func TestSegmentCache_LRU(t *testing.T) {
+	sc, dir := newTestSegmentCache(t, 30)
+	defer os.RemoveAll(dir)
+
+	uid := dxfile.FileID{1}
+	for i := uint64(0); i < 3; i++ {
+		if err := sc.put(uid, i, bytes.Repeat([]byte{byte(i)}, 10)); err != nil {
+			t.Fatalf("failed to put the segment %d: %s", i, err.Error())
+		}
+	}
+
+	// the segment 0 is used recently, the segment 1 is evicted instead
+	if data, cached := sc.get(uid, 0, 10); !cached || !bytes.Equal(data, bytes.Repeat([]byte{0}, 10)) {
+		t.Fatalf("the segment 0 shall be cached, got %v, %v", data, cached)
+	}
+	if err := sc.put(uid, 3, bytes.Repeat([]byte{3}, 10)); err != nil {
+		t.Fatalf("failed to put the segment 3: %s", err.Error())
+	}
+
+	tests := []struct {
+		index  uint64
+		cached bool
+	}{
+		{0, true},
+		{1, false},
+		{2, true},
+		{3, true},
+	}
+	for i, test := range tests {
+		if _, cached := sc.get(uid, test.index, 10); cached != test.cached {
+			t.Errorf("test %d: segment %d expected cached %v, got %v", i, test.index, test.cached, cached)
+		}
+	}
+	if sc.size != 30 {
+		t.Errorf("cache size expected 30, got %v", sc.size)
+	}
+
+	// the segment larger than the limit is not cached
+	if err := sc.put(uid, 4, make([]byte, 31)); err != nil {
+		t.Fatalf("failed to put the segment 4: %s", err.Error())
+	}
+	if _, cached := sc.get(uid, 4, 31); cached {
+		t.Errorf("the segment larger than the limit shall not be cached")
+	}
+
+	// shrinking the limit evicts the least recently used segments
+	sc.setLimit(10)
+	if len(sc.entries) != 1 || sc.size != 10 {
+		t.Errorf("expected 1 segment left after shrinking the limit, got %v", len(sc.entries))
+	}
+}
+
+func TestSegmentCache_Integrity(t *testing.T) {
+	sc, dir := newTestSegmentCache(t, 100)
+	defer os.RemoveAll(dir)
+
+	uid := dxfile.FileID{2}
+	data := []byte("segment data")
+	if err := sc.put(uid, 0, data); err != nil {
+		t.Fatalf("failed to put the segment: %s", err.Error())
+	}
+	if err := sc.put(uid, 1, data); err != nil {
+		t.Fatalf("failed to put the segment: %s", err.Error())
+	}
+
+	// the segments are loaded from the disk
+	reloaded, err := newSegmentCache(dir, 100)
+	if err != nil {
+		t.Fatalf("failed to reload the segment cache: %s", err.Error())
+	}
+	if cached, exists := reloaded.get(uid, 1, uint64(len(data))); !exists || !bytes.Equal(cached, data) {
+		t.Fatalf("the segment shall be reloaded, got %v, %v", cached, exists)
+	}
+
+	// the corrupted segment is removed
+	path := filepath.Join(dir, segmentCacheKey{uid: uid, index: 0}.fileName())
+	content, _ := ioutil.ReadFile(path)
+	content[len(content)-1] ^= 0xff
+	if err = ioutil.WriteFile(path, content, 0600); err != nil {
+		t.Fatalf("failed to corrupt the segment: %s", err.Error())
+	}
+	if _, cached := reloaded.get(uid, 0, uint64(len(data))); cached {
+		t.Errorf("the corrupted segment shall not be returned")
+	}
+	if _, err = os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("the corrupted segment shall be removed from the disk")
+	}
+
+	// the segments of the file deleted are removed
+	reloaded.removeFile(uid)
+	if len(reloaded.entries) != 0 || reloaded.size != 0 {
+		t.Errorf("the segments of the file shall be removed, got %v", len(reloaded.entries))
+	}
+}
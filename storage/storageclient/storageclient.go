@@ -31,6 +31,7 @@ import (
 	"github.com/DxChainNetwork/godx/storage"
 	"github.com/DxChainNetwork/godx/storage/storageclient/contractmanager"
 	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
 	"github.com/DxChainNetwork/godx/storage/storageclient/memorymanager"
 	"github.com/DxChainNetwork/godx/storage/storageclient/storagehostmanager"
 )
@@ -50,10 +51,17 @@ type StorageClient struct {
 	downloadHeapMu sync.Mutex
 	downloadHeap   *downloadSegmentHeap
 	newDownloads   chan struct{}
+	segmentCache   *segmentCache
 
 	// Upload management
 	uploadHeap uploadHeap
 
+	// packLock protects the open pack file of the small files
+	packLock sync.Mutex
+
+	// results of the audits of the storage hosts
+	audits auditState
+
 	// List of workers that can be used for uploading and/or downloading.
 	workerPool map[storage.ContractID]*worker
 
@@ -126,6 +134,14 @@ func (client *StorageClient) Start(b storage.EthBackend, apiBackend ethapi.Backe
 		return
 	}
 
+	// evaluate the storage hosts with their track records, if indexed by the backend
+	if rb, ok := b.(storage.HostReputationBackend); ok {
+		factor := storagehostmanager.ReputationFactor(rb)
+		if err = client.storageHostManager.RegisterEvaluationFactor(storagehostmanager.FactorReputation, factor); err != nil {
+			return
+		}
+	}
+
 	// start contractManager
 	if err = client.contractManager.Start(client); err != nil {
 		err = fmt.Errorf("error starting contract manager: %s", err.Error())
@@ -150,6 +166,8 @@ func (client *StorageClient) Start(b storage.EthBackend, apiBackend ethapi.Backe
 	go client.stuckLoop()
 	go client.uploadOrRepair()
 	go client.healthCheckLoop()
+	go client.packLoop()
+	go client.auditLoop()
 
 	// kill workers on shutdown.
 	client.tm.OnStop(func() error {
@@ -197,6 +215,14 @@ func (client *StorageClient) DeleteFile(path storage.DxPath) error {
 		return err
 	}
 	defer client.tm.Done()
+
+	// the segments of the file cached are no longer needed
+	if entry, err := client.fileSystem.OpenDxFile(path); err == nil && client.segmentCache != nil {
+		client.segmentCache.removeFile(entry.UID())
+		entry.Close()
+	} else if err == nil {
+		entry.Close()
+	}
 	return client.fileSystem.DeleteDxFile(path)
 }
 
@@ -259,6 +285,13 @@ func (client *StorageClient) SetClientSetting(setting storage.ClientSetting) (er
 	client.lock.Lock()
 	client.persist.MaxDownloadSpeed = setting.MaxDownloadSpeed
 	client.persist.MaxUploadSpeed = setting.MaxUploadSpeed
+	hedge := setting.DownloadHedge
+	client.persist.DownloadHedge = &hedge
+	client.persist.SegmentCacheSize = setting.SegmentCacheSize
+	client.persist.AuditBudget = setting.AuditBudget
+	if client.segmentCache != nil {
+		client.segmentCache.setLimit(setting.SegmentCacheSize)
+	}
 	if err = client.saveSettings(); err != nil {
 		err = fmt.Errorf("failed to save the storage client settings: %s", err.Error())
 		client.lock.Unlock()
@@ -280,6 +313,9 @@ func (client *StorageClient) RetrieveClientSetting() (setting storage.ClientSett
 		EnableIPViolation: client.storageHostManager.RetrieveIPViolationCheckSetting(),
 		MaxUploadSpeed:    maxUploadSpeed,
 		MaxDownloadSpeed:  maxDownloadSpeed,
+		DownloadHedge:     client.downloadHedge(),
+		SegmentCacheSize:  client.segmentCacheSize(),
+		AuditBudget:       client.auditBudget(),
 	}
 	return
 }
@@ -308,6 +344,10 @@ func (client *StorageClient) Append(sp storage.Peer, data []byte, hostInfo *stor
 }
 
 func (client *StorageClient) Write(sp storage.Peer, actions []storage.UploadAction, hostInfo *storage.HostInfo) (err error) {
+	if client.contractManager.SpendingPaused() {
+		return ErrSpendingCapReached
+	}
+
 	// Retrieve the last contract revision
 	scs := client.contractManager.GetStorageContractSet()
 
@@ -532,6 +572,10 @@ func (client *StorageClient) Write(sp storage.Peer, actions []storage.UploadActi
 // Download calls the Read RPC, writing the requested data to w
 // NOTE: The RPC can be cancelled (with a granularity of one section) via the cancel channel.
 func (client *StorageClient) Read(sp storage.Peer, w io.Writer, req storage.DownloadRequest, cancel <-chan struct{}, hostInfo *storage.HostInfo) (err error) {
+	if client.contractManager.SpendingPaused() {
+		return ErrSpendingCapReached
+	}
+
 	// sanity check the request.
 	sector := req.Sector
 	if uint64(sector.Offset)+uint64(sector.Length) > storage.SectorSize {
@@ -543,19 +587,6 @@ func (client *StorageClient) Read(sp storage.Peer, w io.Writer, req storage.Down
 		}
 	}
 
-	// calculate estimated bandwidth
-	var totalLength uint64
-	totalLength += uint64(sector.Length)
-
-	var estProofHashes uint64
-	if req.MerkleProof {
-		// use the worst-case proof size of 2*tree depth,
-		// which occurs when proving across the two leaves in the center of the tree
-		estHashesPerProof := 2 * bits.Len64(storage.SectorSize/storage.SegmentSize)
-		estProofHashes = uint64(estHashesPerProof)
-	}
-	estBandwidth := totalLength + estProofHashes*uint64(storage.HashSize)
-
 	// retrieve the last contract revision
 	scs := client.contractManager.GetStorageContractSet()
 
@@ -572,10 +603,7 @@ func (client *StorageClient) Read(sp storage.Peer, w io.Writer, req storage.Down
 	lastRevision := contractHeader.LatestContractRevision
 
 	// calculate price
-	bandwidthPrice := hostInfo.DownloadBandwidthPrice.MultUint64(estBandwidth)
-	sectorAccessPrice := hostInfo.SectorAccessPrice
-
-	price := hostInfo.BaseRPCPrice.Add(bandwidthPrice).Add(sectorAccessPrice)
+	price := downloadPrice(hostInfo, sector.Length, req.MerkleProof)
 	if lastRevision.NewValidProofOutputs[0].Value.Cmp(price.BigIntPtr()) < 0 {
 		return errors.New("client funds not enough to support download")
 	}
@@ -635,6 +663,13 @@ func (client *StorageClient) Read(sp storage.Peer, w io.Writer, req storage.Down
 		}
 	}()
 
+	// the request is not sent if it is canceled, no cost is incurred
+	select {
+	case <-cancel:
+		return errDownloadCanceled
+	default:
+	}
+
 	// send download request
 	err = sp.RequestContractDownload(req)
 	if err != nil {
@@ -662,6 +697,12 @@ func (client *StorageClient) Read(sp storage.Peer, w io.Writer, req storage.Down
 		return hostNegotiateErr
 	}
 
+	// the host no longer has the requested sector
+	if msg.Code == storage.HostSectorNotFoundMsg {
+		hostNegotiateErr = storage.ErrHostSectorNotFound
+		return hostNegotiateErr
+	}
+
 	err = msg.Decode(&resp)
 	if err != nil {
 		hostNegotiateErr = err
@@ -671,7 +712,7 @@ func (client *StorageClient) Read(sp storage.Peer, w io.Writer, req storage.Down
 	// if host sent data, should validate it
 	if len(resp.Data) > 0 {
 		if len(resp.Data) != int(sector.Length) {
-			err = errors.New("host did not send enough sector data")
+			err = errInsufficientSectorData
 			hostNegotiateErr = err
 			return err
 		}
@@ -681,7 +722,7 @@ func (client *StorageClient) Read(sp storage.Peer, w io.Writer, req storage.Down
 			proofEnd := int(sector.Offset+sector.Length) / merkle.LeafSize
 			verified, err := merkle.Sha256VerifyRangeProof(resp.Data, resp.MerkleProof, proofStart, proofEnd, sector.MerkleRoot)
 			if !verified || err != nil {
-				err = errors.New("host provided incorrect sector data or Merkle proof")
+				err = errInvalidSectorData
 				hostNegotiateErr = err
 				return err
 			}
@@ -745,11 +786,26 @@ func (client *StorageClient) Read(sp storage.Peer, w io.Writer, req storage.Down
 	}
 }
 
-// Download requests for a single section and returns the requested data. A Merkle proof is always requested.
-func (client *StorageClient) Download(sp storage.Peer, root common.Hash, offset, length uint32, hostInfo *storage.HostInfo) ([]byte, error) {
-	client.lock.Lock()
-	defer client.lock.Unlock()
+// downloadPrice returns the price of downloading the data of the length from the sector stored
+// by the host, including the bandwidth of the merkle proof if requested
+func downloadPrice(hostInfo *storage.HostInfo, length uint32, merkleProof bool) common.BigInt {
+	// calculate estimated bandwidth
+	var estProofHashes uint64
+	if merkleProof {
+		// use the worst-case proof size of 2*tree depth,
+		// which occurs when proving across the two leaves in the center of the tree
+		estHashesPerProof := 2 * bits.Len64(storage.SectorSize/storage.SegmentSize)
+		estProofHashes = uint64(estHashesPerProof)
+	}
+	estBandwidth := uint64(length) + estProofHashes*uint64(storage.HashSize)
 
+	bandwidthPrice := hostInfo.DownloadBandwidthPrice.MultUint64(estBandwidth)
+	return hostInfo.BaseRPCPrice.Add(bandwidthPrice).Add(hostInfo.SectorAccessPrice)
+}
+
+// Download requests for a single section and returns the requested data. A Merkle proof is always requested.
+// The request is not sent if the cancel channel is closed before
+func (client *StorageClient) Download(sp storage.Peer, root common.Hash, offset, length uint32, cancel <-chan struct{}, hostInfo *storage.HostInfo) ([]byte, error) {
 	req := storage.DownloadRequest{
 		Sector: storage.DownloadRequestSector{
 			MerkleRoot: root,
@@ -759,9 +815,7 @@ func (client *StorageClient) Download(sp storage.Peer, root common.Hash, offset,
 		MerkleProof: true,
 	}
 	var buf bytes.Buffer
-	err := client.Read(sp, &buf, req, nil, hostInfo)
-	time.Sleep(1 * time.Second)
-
+	err := client.Read(sp, &buf, req, cancel, hostInfo)
 	return buf.Bytes(), err
 }
 
@@ -797,6 +851,7 @@ func (client *StorageClient) newDownload(params downloadParams) (*download, erro
 		priority:          params.priority,
 		log:               client.log,
 		memoryManager:     client.memoryManager,
+		segmentCache:      client.segmentCache,
 	}
 
 	// record the end time when it's done.
@@ -866,6 +921,7 @@ func (client *StorageClient) newDownload(params downloadParams) (*download, erro
 			completedSectors:    make([]bool, params.file.ErasureCode().NumSectors()),
 			physicalSegmentData: make([][]byte, params.file.ErasureCode().NumSectors()),
 			sectorUsage:         make([]bool, params.file.ErasureCode().NumSectors()),
+			cancel:              make(chan struct{}),
 			download:            d,
 			clientFile:          params.file,
 		}
@@ -888,6 +944,14 @@ func (client *StorageClient) newDownload(params downloadParams) (*download, erro
 		uds.writeOffset = writeOffset
 		writeOffset += int64(uds.fetchLength)
 
+		// the segment cached locally is written to the destination directly
+		if data, cached := client.cachedSegment(params.file, i); cached {
+			if err := uds.writeCachedSegment(data); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
 		uds.overdrive = uint32(params.overdrive)
 
 		// add this segment to the segment heap, and notify the download loop a new task
@@ -902,6 +966,10 @@ func (client *StorageClient) newDownload(params downloadParams) (*download, erro
 
 // createDownload performs a file download and returns the download object
 func (client *StorageClient) createDownload(p storage.DownloadParameters) (*download, error) {
+	if client.contractManager.SpendingPaused() {
+		return nil, ErrSpendingCapReached
+	}
+
 	dxPath, err := storage.NewDxPath(p.RemoteFilePath)
 	if err != nil {
 		return nil, err
@@ -946,9 +1014,17 @@ func (client *StorageClient) createDownload(p storage.DownloadParameters) (*down
 	dw = osFile
 	destinationType = "file"
 
-	// create the download object.
-	snap, err := entry.Snapshot()
+	// create the download object. The packed file is downloaded as a range of the pack file
+	var snap *dxfile.Snapshot
+	var offset, length uint64
+	if loc, packed := entry.PackLocation(); packed {
+		snap, offset, length, err = client.packedDownloadRange(entry, loc, dw)
+	} else {
+		length = entry.FileSize()
+		snap, err = entry.Snapshot()
+	}
 	if err != nil {
+		osFile.Close()
 		return nil, fmt.Errorf("cannot create snapshot: %v", err)
 	}
 	d, err := client.newDownload(downloadParams{
@@ -959,12 +1035,12 @@ func (client *StorageClient) createDownload(p storage.DownloadParameters) (*down
 		latencyTarget:     25e3 * time.Millisecond,
 
 		// always download the whole file
-		length:      entry.FileSize(),
+		length:      length,
 		needsMemory: true,
 
-		// always download from 0
-		offset:    0,
-		overdrive: 3,
+		// always download from the start of the file
+		offset:    offset,
+		overdrive: hedgeOverdrive(snap.ErasureCode(), client.downloadHedge()),
 		priority:  5,
 	})
 	if closer, ok := dw.(io.Closer); err != nil && ok {
@@ -1038,6 +1114,11 @@ func (client *StorageClient) DownloadAsync(p storage.DownloadParameters) error {
 
 // GetHostAnnouncementWithBlockHash will get the HostAnnouncements and block height through the hash of the block
 func (client *StorageClient) GetHostAnnouncementWithBlockHash(blockHash common.Hash) (hostAnnouncements []types.HostAnnouncement, number uint64, errGet error) {
+	// the backend retrieving the announcements without the full block is preferred
+	if ab, ok := client.ethBackend.(storage.HostAnnouncementBackend); ok {
+		return ab.GetHostAnnouncementWithBlockHash(blockHash)
+	}
+
 	precompiled := vm.PrecompiledEVMFileContracts
 	block, err := client.ethBackend.GetBlockByHash(blockHash)
 
@@ -16,6 +16,7 @@ import (
 	"github.com/DxChainNetwork/godx/eth/downloader"
 	"github.com/DxChainNetwork/godx/ethdb"
 	"github.com/DxChainNetwork/godx/event"
+	"github.com/DxChainNetwork/godx/internal/ethapi"
 	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/params"
 	"github.com/DxChainNetwork/godx/rpc"
@@ -133,6 +134,10 @@ func (b *BackendTest) GetStorageHostSetting(hostEnodeID enode.ID, hostEnodeURL s
 	return nil
 }
 
+func (b *BackendTest) ProbeStorageHost(hostEnodeURL string, req storage.HostProbeRequest) (time.Duration, error) {
+	return 0, nil
+}
+
 func (b *BackendTest) IsRevising(hostID enode.ID) bool {
 	return false
 }
@@ -268,6 +273,10 @@ func (b *BackendTest) GetHostEnodeURL() string {
 	return ""
 }
 
+func (b *BackendTest) OfflineTxs() *ethapi.OfflineTxQueue {
+	return nil
+}
+
 func (b *BackendTest) TryToRenewOrRevise(hostID enode.ID) bool { return false }
 
 func (b *BackendTest) RevisionOrRenewingDone(hostID enode.ID) {}
@@ -16,6 +16,13 @@ const (
 	priceExponentiationSmall  = 0.75
 	priceExponentiationLarge  = 5
 	minStorage                = uint64(20e9)
+	presenceMaturity          = uint64(12000)
+)
+
+// Evaluation config related constants
+const (
+	weightKeyPrefix = "weight."
+	maxFactorWeight = float64(10)
 )
 
 // StorageHostManager related constant
@@ -40,6 +47,32 @@ const (
 	maxDowntime = 10 * 24 * time.Hour
 )
 
+// Probe related constants
+const (
+	probeCheckInterval = 10 * time.Minute
+	probeInterval      = time.Hour
+	probeQuantity      = 50
+	probeSize          = 64 << 10
+	maxProbeRecords    = 24
+	probeHistoryWindow = 24 * time.Hour
+
+	// the host with RTT within the target or speed above the target gets the full factor
+	probeRTTTarget   = 200 * time.Millisecond
+	probeSpeedTarget = 1 << 20
+
+	// probeUnknownFactor is the factor of the host not probed yet
+	probeUnknownFactor = 0.5
+	probeFactorFloor   = 0.01
+)
+
+// Reputation related constants
+const (
+	// reputationBaseProofs is the number of storage proofs every host is assumed to have
+	// submitted, so that a single missed proof of a new host is not punished heavily
+	reputationBaseProofs = 10
+	reputationExponent   = 4
+)
+
 // historical interaction with host related constants
 const (
 	historicInteractionDecay      = 0.9995
@@ -0,0 +1,221 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/common/unit"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/storagehosttree"
+)
+
+type (
+	// EvaluationConfig is the user tunable configuration of the storage host evaluation.
+	// Weights are the weights of the evaluation factors by name, where a factor not specified
+	// has the weight of 1, and a factor of weight 0 is ignored. The other fields are the
+	// parameters of the curves used to calculate the built-in factors
+	EvaluationConfig struct {
+		Weights map[string]float64 `json:"weights"`
+
+		// PresenceMaturity is the number of blocks after which a host gets the full presence factor
+		PresenceMaturity uint64 `json:"presenceMaturity"`
+
+		// MinStorage is the remaining storage under which a host gets the lowest storage factor
+		MinStorage uint64 `json:"minStorage"`
+
+		// InteractionExponent is the exponent applied to the interaction success ratio
+		InteractionExponent float64 `json:"interactionExponent"`
+
+		// PriceExponent is the exponent applied to the ratio of the host price to the cutoff
+		PriceExponent float64 `json:"priceExponent"`
+
+		// DepositExponent is the exponent applied to the ratio of the host deposit to the cutoff
+		DepositExponent float64 `json:"depositExponent"`
+	}
+
+	// FactorFunc calculates an additional evaluation factor of the storage host. Additional
+	// factors, such as the measured latency or the region, are registered to the storage host
+	// manager with RegisterEvaluationFactor
+	FactorFunc func(info storage.HostInfo) float64
+)
+
+// evaluationConfigKeys are the keys of the curve parameters accepted by ParseEvaluationConfig
+var evaluationConfigKeys = []string{"presencematurity", "minstorage", "interactionexp", "priceexp", "depositexp"}
+
+// defaultEvaluationConfig returns the default evaluation config, with which all factors
+// have the weight of 1
+func defaultEvaluationConfig() EvaluationConfig {
+	return EvaluationConfig{
+		Weights:             make(map[string]float64),
+		PresenceMaturity:    presenceMaturity,
+		MinStorage:          minStorage,
+		InteractionExponent: interactionExponentiation,
+		PriceExponent:       priceExponentiationLarge,
+		DepositExponent:     depositExponentialLarge,
+	}
+}
+
+// fillDefaults fills the zero fields of the evaluation config with the default values
+func (config *EvaluationConfig) fillDefaults() {
+	def := defaultEvaluationConfig()
+	if config.Weights == nil {
+		config.Weights = def.Weights
+	}
+	if config.PresenceMaturity == 0 {
+		config.PresenceMaturity = def.PresenceMaturity
+	}
+	if config.MinStorage == 0 {
+		config.MinStorage = def.MinStorage
+	}
+	if config.InteractionExponent == 0 {
+		config.InteractionExponent = def.InteractionExponent
+	}
+	if config.PriceExponent == 0 {
+		config.PriceExponent = def.PriceExponent
+	}
+	if config.DepositExponent == 0 {
+		config.DepositExponent = def.DepositExponent
+	}
+}
+
+// copy returns a deep copy of the evaluation config
+func (config EvaluationConfig) copy() EvaluationConfig {
+	weights := make(map[string]float64, len(config.Weights))
+	for name, weight := range config.Weights {
+		weights[name] = weight
+	}
+	config.Weights = weights
+	return config
+}
+
+// IsEvaluationConfigKey returns whether the setConfig key is a key of the evaluation config
+func IsEvaluationConfigKey(key string) bool {
+	if strings.HasPrefix(key, weightKeyPrefix) {
+		return true
+	}
+	for _, k := range evaluationConfigKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseEvaluationConfig parses the settings into the evaluation config on top of the previous
+// config. The weight of a factor is specified with the key "weight.<factor>"
+func ParseEvaluationConfig(settings map[string]string, prev EvaluationConfig) (EvaluationConfig, error) {
+	config := prev.copy()
+	for key, value := range settings {
+		var err error
+		switch key {
+		case "presencematurity":
+			config.PresenceMaturity, err = unit.ParseTime(value)
+		case "minstorage":
+			config.MinStorage, err = unit.ParseStorage(value)
+		case "interactionexp":
+			config.InteractionExponent, err = parsePositiveFloat(value)
+		case "priceexp":
+			config.PriceExponent, err = parsePositiveFloat(value)
+		case "depositexp":
+			config.DepositExponent, err = parsePositiveFloat(value)
+		default:
+			if !strings.HasPrefix(key, weightKeyPrefix) || len(key) == len(weightKeyPrefix) {
+				return EvaluationConfig{}, fmt.Errorf("the key entered: %s is not a valid evaluation config key", key)
+			}
+			var weight float64
+			if weight, err = strconv.ParseFloat(value, 64); err == nil && (weight < 0 || weight > maxFactorWeight) {
+				err = fmt.Errorf("weight shall be between 0 and %v", maxFactorWeight)
+			}
+			config.Weights[strings.TrimPrefix(key, weightKeyPrefix)] = weight
+		}
+		if err != nil {
+			return EvaluationConfig{}, fmt.Errorf("failed to parse %s: %v", key, err)
+		}
+	}
+	if config.PresenceMaturity == 0 || config.MinStorage == 0 {
+		return EvaluationConfig{}, fmt.Errorf("presence maturity and min storage cannot be zero")
+	}
+	return config, nil
+}
+
+// parsePositiveFloat parses the string to a positive float
+func parsePositiveFloat(value string) (float64, error) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	if f <= 0 {
+		return 0, fmt.Errorf("value %v shall be positive", f)
+	}
+	return f, nil
+}
+
+// RetrieveEvaluationConfig returns the evaluation config of the storage host manager
+func (shm *StorageHostManager) RetrieveEvaluationConfig() EvaluationConfig {
+	shm.lock.RLock()
+	defer shm.lock.RUnlock()
+	return shm.evalConfig.copy()
+}
+
+// SetEvaluationConfig sets the evaluation config, and re-evaluates all storage hosts
+func (shm *StorageHostManager) SetEvaluationConfig(config EvaluationConfig) error {
+	shm.lock.Lock()
+	defer shm.lock.Unlock()
+
+	config.fillDefaults()
+	shm.evalConfig = config.copy()
+	if err := shm.updateEvaluationFunc(); err != nil {
+		return err
+	}
+	return shm.saveSettings()
+}
+
+// RegisterEvaluationFactor registers an additional evaluation factor, and re-evaluates all
+// storage hosts. The weight of the factor is configured by the name of the factor
+func (shm *StorageHostManager) RegisterEvaluationFactor(name string, f FactorFunc) error {
+	shm.lock.Lock()
+	defer shm.lock.Unlock()
+
+	if shm.extraFactors == nil {
+		shm.extraFactors = make(map[string]FactorFunc)
+	}
+	shm.extraFactors[name] = f
+	return shm.updateEvaluationFunc()
+}
+
+// EvaluationFactors returns the names of all evaluation factors, including the additional
+// factors registered
+func (shm *StorageHostManager) EvaluationFactors() []string {
+	shm.lock.RLock()
+	defer shm.lock.RUnlock()
+
+	names := []string{storagehosttree.FactorPresence, storagehosttree.FactorDeposit, storagehosttree.FactorInteraction,
+		storagehosttree.FactorContractPrice, storagehosttree.FactorStorageRemaining, storagehosttree.FactorUptime}
+	var extra []string
+	for name := range shm.extraFactors {
+		extra = append(extra, name)
+	}
+	sort.Strings(extra)
+	return append(names, extra...)
+}
+
+// updateEvaluationFunc recalculates the evaluation function with the current rent payment,
+// evaluation config and additional factors, and updates the storage host trees.
+// Require: lock shm.lock by caller
+func (shm *StorageHostManager) updateEvaluationFunc() error {
+	evalFunc := shm.calculateEvaluationFunc(shm.rent)
+	shm.evalFunc = evalFunc
+
+	err := shm.storageHostTree.SetEvaluationFunc(evalFunc)
+	if shm.filteredTree != shm.storageHostTree {
+		err = common.ErrCompose(err, shm.filteredTree.SetEvaluationFunc(evalFunc))
+	}
+	return err
+}
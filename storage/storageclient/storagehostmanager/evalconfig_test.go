@@ -0,0 +1,102 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common/unit"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/storagehosttree"
+)
+
+func TestParseEvaluationConfig(t *testing.T) {
+	tests := []struct {
+		settings map[string]string
+		err      bool
+		check    func(config EvaluationConfig) bool
+	}{
+		{
+			settings: map[string]string{"weight.price": "2", "weight.latency": "0"},
+			check: func(config EvaluationConfig) bool {
+				return config.Weights["price"] == 2 && config.Weights["latency"] == 0
+			},
+		},
+		{
+			settings: map[string]string{"presencematurity": "10d", "minstorage": "10gb", "priceexp": "3"},
+			check: func(config EvaluationConfig) bool {
+				return config.PresenceMaturity == 10*unit.BlocksPerDay && config.MinStorage == 10e9 && config.PriceExponent == 3
+			},
+		},
+		{settings: map[string]string{"weight.price": "11"}, err: true},
+		{settings: map[string]string{"weight.price": "-1"}, err: true},
+		{settings: map[string]string{"weight.": "1"}, err: true},
+		{settings: map[string]string{"depositexp": "0"}, err: true},
+		{settings: map[string]string{"unknown": "1"}, err: true},
+	}
+	for i, test := range tests {
+		prev := defaultEvaluationConfig()
+		config, err := ParseEvaluationConfig(test.settings, prev)
+		if (err != nil) != test.err {
+			t.Errorf("test %d: expect error %v, got %v", i, test.err, err)
+			continue
+		}
+		if len(prev.Weights) != 0 {
+			t.Errorf("test %d: the previous config shall not be modified", i)
+		}
+		if err == nil && !test.check(config) {
+			t.Errorf("test %d: config not expected: %+v", i, config)
+		}
+	}
+}
+
+func TestStorageHostManager_EvaluationConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "evalconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	shm := New(dir)
+	host := hostInfoGenerator()
+	if err = shm.insert(host); err != nil {
+		t.Fatal(err)
+	}
+	if err = shm.RegisterEvaluationFactor("latency", func(info storage.HostInfo) float64 { return 0.5 }); err != nil {
+		t.Fatal(err)
+	}
+	criteria := shm.evalFunc(host).(storagehosttree.EvaluationCriteria)
+	if criteria.ExtraFactors["latency"] != 0.5 {
+		t.Fatalf("additional factor not expected: %v", criteria.ExtraFactors)
+	}
+
+	// the presence factor shall be full with a small presence maturity
+	shm.blockHeight = host.FirstSeen + 100
+	config := shm.RetrieveEvaluationConfig()
+	config.PresenceMaturity = 100
+	config.Weights["latency"] = 2
+	if err = shm.SetEvaluationConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	criteria = shm.evalFunc(host).(storagehosttree.EvaluationCriteria)
+	if criteria.PresenceFactor != 1 {
+		t.Errorf("presence factor not expected: %v", criteria.PresenceFactor)
+	}
+	if criteria.Weights["latency"] != 2 {
+		t.Errorf("weights not expected: %v", criteria.Weights)
+	}
+
+	// the evaluation config shall be persisted
+	shm2 := New(dir)
+	shm2.b = &storageClientBackendTestData{}
+	if err = shm2.loadSettings(); err != nil {
+		t.Fatal(err)
+	}
+	if loaded := shm2.RetrieveEvaluationConfig(); loaded.PresenceMaturity != 100 || loaded.Weights["latency"] != 2 {
+		t.Errorf("loaded config not expected: %+v", loaded)
+	}
+}
@@ -13,43 +13,69 @@ import (
 )
 
 // calculateEvaluationFunc will generate the function that returns the storage host evaluation
-// for each factor
+// for each factor. The evaluation config and the additional factors are captured at the time
+// the function is generated
+// Require: lock shm.lock by caller
 func (shm *StorageHostManager) calculateEvaluationFunc(rent storage.RentPayment) storagehosttree.EvaluationFunc {
+	config := shm.evalConfig.copy()
+	config.fillDefaults()
+	extraFactors := make(map[string]FactorFunc, len(shm.extraFactors))
+	for name, f := range shm.extraFactors {
+		extraFactors[name] = f
+	}
+
 	return func(info storage.HostInfo) storagehosttree.HostEvaluation {
+		var extra map[string]float64
+		if len(extraFactors) != 0 {
+			extra = make(map[string]float64, len(extraFactors))
+			for name, f := range extraFactors {
+				extra[name] = f(info)
+			}
+		}
 		return storagehosttree.EvaluationCriteria{
-			PresenceFactor:         shm.presenceFactorCalc(info),
-			DepositFactor:          shm.depositFactorCalc(info, rent),
-			InteractionFactor:      shm.interactionFactorCalc(info),
-			ContractPriceFactor:    shm.contractPriceFactorCalc(info, rent),
-			StorageRemainingFactor: shm.storageRemainingFactorCalc(info),
+			PresenceFactor:         shm.presenceFactorCalc(info, config),
+			DepositFactor:          shm.depositFactorCalc(info, rent, config),
+			InteractionFactor:      shm.interactionFactorCalc(info, config),
+			ContractPriceFactor:    shm.contractPriceFactorCalc(info, rent, config),
+			StorageRemainingFactor: shm.storageRemainingFactorCalc(info, config),
 			UptimeFactor:           shm.uptimeFactorCalc(info),
+			ExtraFactors:           extra,
+			Weights:                config.Weights,
 		}
 	}
 }
 
 // presenceFactorCalc calculates the factor value based on the existence of the
-// storage host. The earlier it was discovered, the presence factor will be higher
-func (shm *StorageHostManager) presenceFactorCalc(info storage.HostInfo) float64 {
+// storage host. The earlier it was discovered, the presence factor will be higher.
+// The steps are scaled by the presence maturity in the evaluation config
+func (shm *StorageHostManager) presenceFactorCalc(info storage.HostInfo, config EvaluationConfig) float64 {
 	var base float64 = 1
 
-	switch presence := shm.blockHeight - info.FirstSeen; {
-	case presence < 0:
+	// step returns the number of blocks of the step, scaled by the presence maturity
+	step := func(blocks uint64) uint64 {
+		return blocks * config.PresenceMaturity / presenceMaturity
+	}
+
+	if shm.blockHeight < info.FirstSeen {
 		return base
-	case presence < 144:
+	}
+
+	switch presence := shm.blockHeight - info.FirstSeen; {
+	case presence < step(144):
 		return base / 972
-	case presence < 288:
+	case presence < step(288):
 		return base / 324
-	case presence < 576:
+	case presence < step(576):
 		return base / 108
-	case presence < 1000:
+	case presence < step(1000):
 		return base / 36
-	case presence < 2000:
+	case presence < step(2000):
 		return base / 12
-	case presence < 4000:
+	case presence < step(4000):
 		return base / 6
-	case presence < 6000:
+	case presence < step(6000):
 		return base / 3
-	case presence < 12000:
+	case presence < step(12000):
 		return base * 2 / 3
 	}
 
@@ -58,7 +84,7 @@ func (shm *StorageHostManager) presenceFactorCalc(info storage.HostInfo) float64
 
 // depositFactorCalc calculates the factor value based on the storage host's deposit setting. The higher
 // the deposit is, the higher evaluation it will get
-func (shm *StorageHostManager) depositFactorCalc(info storage.HostInfo, rent storage.RentPayment) float64 {
+func (shm *StorageHostManager) depositFactorCalc(info storage.HostInfo, rent storage.RentPayment, config EvaluationConfig) float64 {
 
 	// make sure RentPayment's fields are non zeros
 	rentPaymentValidation(storage.RentPayment{})
@@ -91,23 +117,23 @@ func (shm *StorageHostManager) depositFactorCalc(info storage.HostInfo, rent sto
 
 	ratio := hostDeposit.Div(cutoff)
 	smallWeight := math.Pow(cutoff.Float64(), depositExponentialSmall)
-	largeWeight := math.Pow(ratio.Float64(), depositExponentialLarge)
+	largeWeight := math.Pow(ratio.Float64(), config.DepositExponent)
 
 	return smallWeight * largeWeight
 }
 
 // interactionFactorCalc calculates the factor value based on the historical success interactions
 // and failed interactions. More success interactions will cause higher evaluation
-func (shm *StorageHostManager) interactionFactorCalc(info storage.HostInfo) float64 {
+func (shm *StorageHostManager) interactionFactorCalc(info storage.HostInfo, config EvaluationConfig) float64 {
 	hs := info.HistoricSuccessfulInteractions + 30
 	hf := info.HistoricFailedInteractions + 1
 	ratio := hs / (hs + hf)
-	return math.Pow(ratio, interactionExponentiation)
+	return math.Pow(ratio, config.InteractionExponent)
 }
 
 // contractPriceFactorCalc calculates the factor value based on the contract price that storage host requested
 // the lower the price is, the higher the storage host evaluation will be
-func (shm *StorageHostManager) contractPriceFactorCalc(info storage.HostInfo, rent storage.RentPayment) float64 {
+func (shm *StorageHostManager) contractPriceFactorCalc(info storage.HostInfo, rent storage.RentPayment, config EvaluationConfig) float64 {
 	// make sure the rent has non-zero fields
 	rentPaymentValidation(rent)
 
@@ -145,15 +171,16 @@ func (shm *StorageHostManager) contractPriceFactorCalc(info storage.HostInfo, re
 	ratio := hostContractPrice / cutoff
 
 	smallWeight := math.Pow(cutoff, priceExponentiationSmall)
-	largeWeight := math.Pow(ratio, priceExponentiationLarge)
+	largeWeight := math.Pow(ratio, config.PriceExponent)
 
 	return 1 / (smallWeight * largeWeight)
 }
 
 // storageRemainingFactorCalc calculates the factor value based on the storage remaining, the more storage
 // space the storage host remained, higher evaluation it will got
-func (shm *StorageHostManager) storageRemainingFactorCalc(info storage.HostInfo) float64 {
+func (shm *StorageHostManager) storageRemainingFactorCalc(info storage.HostInfo, config EvaluationConfig) float64 {
 	var base float64 = 1
+	minStorage := config.MinStorage
 
 	switch rs := info.RemainingStorage; {
 	case rs < minStorage:
@@ -0,0 +1,101 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/storagehosttree"
+)
+
+type (
+	// GeoDB is the offline database mapping the IP networks to the ASN and region. The
+	// database file is a CSV file with each line in the form of "cidr,asn,region". The
+	// empty lines and the lines starting with '#' are ignored
+	GeoDB struct {
+		entries []geoEntry
+	}
+
+	geoEntry struct {
+		network *net.IPNet
+		asn     string
+		region  string
+	}
+)
+
+// LoadGeoDB loads the geo database from the file
+func LoadGeoDB(path string) (*GeoDB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	db := &GeoDB{}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, ",")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expect 3 fields, got %d", line, len(fields))
+		}
+		_, network, err := net.ParseCIDR(strings.TrimSpace(fields[0]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		db.entries = append(db.entries, geoEntry{
+			network: network,
+			asn:     strings.TrimSpace(fields[1]),
+			region:  strings.TrimSpace(fields[2]),
+		})
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// Lookup returns the ASN and region of the IP address. The entry with the longest prefix
+// containing the IP address is used
+func (db *GeoDB) Lookup(ip string) (asn string, region string, found bool) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return "", "", false
+	}
+	bestPrefix := -1
+	for _, entry := range db.entries {
+		if !entry.network.Contains(addr) {
+			continue
+		}
+		if prefix, _ := entry.network.Mask.Size(); prefix > bestPrefix {
+			bestPrefix, asn, region = prefix, entry.asn, entry.region
+		}
+	}
+	return asn, region, bestPrefix >= 0
+}
+
+// Labeler returns the labeler providing the ASN and region labels of the storage host
+func (db *GeoDB) Labeler() storagehosttree.Labeler {
+	return func(info storage.HostInfo) map[string]string {
+		labels := make(map[string]string)
+		if asn, region, found := db.Lookup(info.IP); found {
+			if asn != "" {
+				labels[storagehosttree.LabelASN] = asn
+			}
+			if region != "" {
+				labels[storagehosttree.LabelRegion] = region
+			}
+		}
+		return labels
+	}
+}
@@ -26,6 +26,10 @@ func (shm *StorageHostManager) hostInfoUpdate(hi storage.HostInfo, err error) {
 		storedInfo.HostExtConfig = hi.HostExtConfig
 		storedInfo.IPNetwork = hi.IPNetwork
 		storedInfo.LastIPNetWorkChange = hi.LastIPNetWorkChange
+		// the host might be reached through an alternative address
+		if err == nil {
+			storedInfo.EnodeURL, storedInfo.IP, storedInfo.AltEnodeURLs = hi.EnodeURL, hi.IP, hi.AltEnodeURLs
+		}
 	} else {
 		storedInfo = hi
 	}
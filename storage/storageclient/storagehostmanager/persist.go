@@ -13,6 +13,7 @@ import (
 	"github.com/DxChainNetwork/godx/log"
 	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/storagehosttree"
 )
 
 // settingsMetadata contains the header and version of the JSON file
@@ -30,6 +31,11 @@ type persistence struct {
 	IPViolationCheck bool
 	FilteredHosts    map[enode.ID]struct{}
 	FilterMode       FilterMode
+	EvaluationConfig EvaluationConfig
+
+	PlacementConstraints []storagehosttree.PlacementConstraint
+	HostLabels           map[enode.ID]map[string]string
+	GeoDBPath            string
 }
 
 // saveSettings will save the storage host configurations into the JSON file
@@ -47,6 +53,11 @@ func (shm *StorageHostManager) persistUpdate() (persist persistence) {
 		IPViolationCheck: shm.ipViolationCheck,
 		FilteredHosts:    shm.filteredHosts,
 		FilterMode:       shm.filterMode,
+		EvaluationConfig: shm.evalConfig,
+
+		PlacementConstraints: shm.placementConstraints,
+		HostLabels:           shm.hostLabels,
+		GeoDBPath:            shm.geoDBPath,
 	}
 }
 
@@ -96,6 +107,25 @@ func (shm *StorageHostManager) loadSettings() error {
 	shm.ipViolationCheck = persist.IPViolationCheck
 	shm.filteredHosts = persist.FilteredHosts
 	shm.filterMode = persist.FilterMode
+	shm.placementConstraints = persist.PlacementConstraints
+	if persist.HostLabels != nil {
+		shm.hostLabels = persist.HostLabels
+	}
+
+	// the geo database is loaded again from the file, which might have been updated
+	shm.geoDBPath = persist.GeoDBPath
+	if shm.geoDBPath != "" {
+		if shm.geoDB, err = LoadGeoDB(shm.geoDBPath); err != nil {
+			shm.log.Warn("failed to load the geo database", "path", shm.geoDBPath, "err", err)
+		}
+	}
+
+	// apply the evaluation config before the storage hosts are inserted
+	persist.EvaluationConfig.fillDefaults()
+	shm.evalConfig = persist.EvaluationConfig
+	if err = shm.updateEvaluationFunc(); err != nil {
+		return err
+	}
 
 	// update the storage host tree
 	for _, info := range persist.StorageHostsInfo {
@@ -0,0 +1,138 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"fmt"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/storagehosttree"
+)
+
+// SetPlacementConstraints sets the placement constraints of the storage hosts selected
+func (shm *StorageHostManager) SetPlacementConstraints(constraints []storagehosttree.PlacementConstraint) error {
+	for _, c := range constraints {
+		if c.Label == "" || c.MaxPerValue < 0 || c.MinDistinct < 0 {
+			return fmt.Errorf("invalid placement constraint: %s", c.String())
+		}
+	}
+	shm.lock.Lock()
+	defer shm.lock.Unlock()
+
+	shm.placementConstraints = append([]storagehosttree.PlacementConstraint{}, constraints...)
+	return shm.saveSettings()
+}
+
+// RetrievePlacementConstraints returns the placement constraints of the storage hosts selected
+func (shm *StorageHostManager) RetrievePlacementConstraints() []storagehosttree.PlacementConstraint {
+	shm.lock.RLock()
+	defer shm.lock.RUnlock()
+	return append([]storagehosttree.PlacementConstraint{}, shm.placementConstraints...)
+}
+
+// SetHostLabel sets the custom label of the storage host, such as "datacenter=A". The
+// label is removed if the value is empty
+func (shm *StorageHostManager) SetHostLabel(id enode.ID, label, value string) error {
+	if label == "" {
+		return fmt.Errorf("empty label")
+	}
+	shm.lock.Lock()
+	defer shm.lock.Unlock()
+
+	labels := make(map[string]string)
+	for l, v := range shm.hostLabels[id] {
+		labels[l] = v
+	}
+	if value == "" {
+		delete(labels, label)
+	} else {
+		labels[label] = value
+	}
+	if len(labels) == 0 {
+		delete(shm.hostLabels, id)
+	} else {
+		shm.hostLabels[id] = labels
+	}
+	return shm.saveSettings()
+}
+
+// LoadGeoDB loads the offline geo database providing the ASN and region labels. The
+// geo database is loaded again after restart
+func (shm *StorageHostManager) LoadGeoDB(path string) error {
+	db, err := LoadGeoDB(path)
+	if err != nil {
+		return fmt.Errorf("failed to load the geo database: %v", err)
+	}
+	shm.lock.Lock()
+	defer shm.lock.Unlock()
+
+	shm.geoDB, shm.geoDBPath = db, path
+	return shm.saveSettings()
+}
+
+// RegisterLabeler registers an additional source of the storage host labels. The labels
+// provided by the labelers registered later override the former ones
+func (shm *StorageHostManager) RegisterLabeler(labeler storagehosttree.Labeler) {
+	shm.lock.Lock()
+	defer shm.lock.Unlock()
+	shm.labelers = append(shm.labelers, labeler)
+}
+
+// HostLabels returns the labels of the storage host
+func (shm *StorageHostManager) HostLabels(info storage.HostInfo) map[string]string {
+	shm.lock.RLock()
+	defer shm.lock.RUnlock()
+	return shm.labeler()(info)
+}
+
+// NewPlacement returns the placement tracking the storage hosts selected against the
+// placement constraints. Nil is returned if there is no placement constraint
+func (shm *StorageHostManager) NewPlacement() *storagehosttree.Placement {
+	shm.lock.RLock()
+	defer shm.lock.RUnlock()
+	return shm.newPlacement()
+}
+
+// newPlacement returns the placement with the current placement constraints.
+// Require: lock shm.lock by caller
+func (shm *StorageHostManager) newPlacement() *storagehosttree.Placement {
+	if len(shm.placementConstraints) == 0 {
+		return nil
+	}
+	constraints := append([]storagehosttree.PlacementConstraint{}, shm.placementConstraints...)
+	return storagehosttree.NewPlacement(constraints, shm.labeler())
+}
+
+// labeler returns the labeler combining all sources of the labels. The subnet label comes
+// first, then the geo database, the labelers registered, and the custom labels set by user.
+// Require: lock shm.lock by caller
+func (shm *StorageHostManager) labeler() storagehosttree.Labeler {
+	var labelers []storagehosttree.Labeler
+	if shm.geoDB != nil {
+		labelers = append(labelers, shm.geoDB.Labeler())
+	}
+	labelers = append(labelers, shm.labelers...)
+	custom := make(map[enode.ID]map[string]string, len(shm.hostLabels))
+	for id, labels := range shm.hostLabels {
+		custom[id] = labels
+	}
+
+	return func(info storage.HostInfo) map[string]string {
+		labels := make(map[string]string)
+		if ipnet, err := storagehosttree.IPNetwork(info.IP); err == nil {
+			labels[storagehosttree.LabelSubnet] = ipnet.String()
+		}
+		for _, labeler := range labelers {
+			for l, v := range labeler(info) {
+				labels[l] = v
+			}
+		}
+		for l, v := range custom[info.EnodeID] {
+			labels[l] = v
+		}
+		return labels
+	}
+}
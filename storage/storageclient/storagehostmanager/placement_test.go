@@ -0,0 +1,137 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/storagehosttree"
+)
+
+const testGeoDB = `# cidr,asn,region
+10.0.0.0/8,AS100,us-east
+10.1.0.0/16,AS200,us-west
+
+2001:db8::/32,AS300,eu
+`
+
+func TestGeoDB_Lookup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "geodb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "geo.csv")
+	if err = ioutil.WriteFile(path, []byte(testGeoDB), 0600); err != nil {
+		t.Fatal(err)
+	}
+	db, err := LoadGeoDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ip     string
+		asn    string
+		region string
+		found  bool
+	}{
+		{"10.2.0.1", "AS100", "us-east", true},
+		// the entry with the longest prefix is used
+		{"10.1.2.3", "AS200", "us-west", true},
+		{"2001:db8::1", "AS300", "eu", true},
+		{"192.168.0.1", "", "", false},
+		{"invalid", "", "", false},
+	}
+	for i, test := range tests {
+		asn, region, found := db.Lookup(test.ip)
+		if asn != test.asn || region != test.region || found != test.found {
+			t.Errorf("test %d: expect %v %v %v, got %v %v %v", i, test.asn, test.region, test.found, asn, region, found)
+		}
+	}
+
+	if err = ioutil.WriteFile(path, []byte("10.0.0.0/8,AS100\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = LoadGeoDB(path); err == nil {
+		t.Errorf("invalid geo database shall not be loaded")
+	}
+}
+
+func TestStorageHostManager_HostLabels(t *testing.T) {
+	dir, err := ioutil.TempDir("", "placement")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "geo.csv")
+	if err = ioutil.WriteFile(path, []byte(testGeoDB), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	shm := New(dir)
+	if err = shm.LoadGeoDB(path); err != nil {
+		t.Fatal(err)
+	}
+	shm.RegisterLabeler(func(info storage.HostInfo) map[string]string {
+		return map[string]string{"rack": "r1", storagehosttree.LabelRegion: "override"}
+	})
+	id := enode.ID{1}
+	if err = shm.SetHostLabel(id, "datacenter", "A"); err != nil {
+		t.Fatal(err)
+	}
+
+	labels := shm.HostLabels(storage.HostInfo{EnodeID: id, IP: "10.1.2.3"})
+	expect := map[string]string{
+		storagehosttree.LabelSubnet: "10.1.2.0/24",
+		storagehosttree.LabelASN:    "AS200",
+		storagehosttree.LabelRegion: "override",
+		"rack":                      "r1",
+		"datacenter":                "A",
+	}
+	if len(labels) != len(expect) {
+		t.Fatalf("labels expect %v, got %v", expect, labels)
+	}
+	for l, v := range expect {
+		if labels[l] != v {
+			t.Errorf("label %s expect %s, got %s", l, v, labels[l])
+		}
+	}
+
+	// the placement constraints, custom labels and the geo database shall be persisted
+	constraints := []storagehosttree.PlacementConstraint{{Label: "datacenter", MaxPerValue: 1}}
+	if err = shm.SetPlacementConstraints(constraints); err != nil {
+		t.Fatal(err)
+	}
+	shm2 := New(dir)
+	shm2.b = &storageClientBackendTestData{}
+	if err = shm2.loadSettings(); err != nil {
+		t.Fatal(err)
+	}
+	if loaded := shm2.RetrievePlacementConstraints(); len(loaded) != 1 || loaded[0] != constraints[0] {
+		t.Errorf("loaded placement constraints not expected: %v", loaded)
+	}
+	labels = shm2.HostLabels(storage.HostInfo{EnodeID: id, IP: "10.1.2.3"})
+	if labels["datacenter"] != "A" || labels[storagehosttree.LabelASN] != "AS200" {
+		t.Errorf("loaded labels not expected: %v", labels)
+	}
+
+	// the host in the same datacenter shall violate the placement
+	if err = shm2.SetHostLabel(enode.ID{2}, "datacenter", "A"); err != nil {
+		t.Fatal(err)
+	}
+	placement := shm2.NewPlacement()
+	placement.Add(storage.HostInfo{EnodeID: id})
+	if !placement.Violates(storage.HostInfo{EnodeID: enode.ID{2}}) {
+		t.Errorf("host in the same datacenter shall violate the placement")
+	}
+}
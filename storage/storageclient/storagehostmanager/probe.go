@@ -0,0 +1,175 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// Names of the evaluation factors calculated from the probe records
+const (
+	FactorLatency    = "latency"
+	FactorThroughput = "throughput"
+)
+
+// probeLoop will periodically measure the round trip time and throughput of the
+// storage hosts online
+func (shm *StorageHostManager) probeLoop() {
+	if err := shm.tm.Add(); err != nil {
+		return
+	}
+	defer shm.tm.Done()
+
+	for {
+		select {
+		case <-shm.tm.StopChan():
+			return
+		case <-time.After(probeCheckInterval):
+		}
+		if err := shm.waitOnline(); err != nil {
+			return
+		}
+		shm.probeHosts()
+	}
+}
+
+// probeHosts probes the storage hosts online which have not been probed within the
+// probe interval. The hosts probed earliest are probed first
+func (shm *StorageHostManager) probeHosts() {
+	var targets []storage.HostInfo
+	for _, host := range shm.storageHostTree.All() {
+		scans := len(host.ScanRecords)
+		if scans == 0 || !host.ScanRecords[scans-1].Success {
+			continue
+		}
+		if probes := len(host.ProbeRecords); probes != 0 && time.Since(host.ProbeRecords[probes-1].Timestamp) < probeInterval {
+			continue
+		}
+		targets = append(targets, host)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		return lastProbeTime(targets[i]).Before(lastProbeTime(targets[j]))
+	})
+	if len(targets) > probeQuantity {
+		targets = targets[:probeQuantity]
+	}
+
+	for _, host := range targets {
+		select {
+		case <-shm.tm.StopChan():
+			return
+		default:
+		}
+		probe := shm.probeHost(host)
+		shm.lock.Lock()
+		shm.probeRecordUpdate(host.EnodeID, probe)
+		shm.lock.Unlock()
+	}
+}
+
+// probeHost measures the round trip time with an empty probe, and the upload and download
+// speed with probes carrying data. The round trip time is deducted from the time elapsed
+// for the probes carrying data
+func (shm *StorageHostManager) probeHost(host storage.HostInfo) storage.HostProbe {
+	probe := storage.HostProbe{Timestamp: time.Now()}
+
+	rtt, err := shm.b.ProbeStorageHost(host.EnodeURL, storage.HostProbeRequest{})
+	if err != nil {
+		shm.log.Debug("Failed to probe the storage host", "hostID", host.EnodeID, "err", err)
+		return probe
+	}
+	upload, err := shm.b.ProbeStorageHost(host.EnodeURL, storage.HostProbeRequest{Payload: make([]byte, probeSize)})
+	if err != nil {
+		shm.log.Debug("Failed to probe the storage host upload", "hostID", host.EnodeID, "err", err)
+		return probe
+	}
+	download, err := shm.b.ProbeStorageHost(host.EnodeURL, storage.HostProbeRequest{RespSize: probeSize})
+	if err != nil {
+		shm.log.Debug("Failed to probe the storage host download", "hostID", host.EnodeID, "err", err)
+		return probe
+	}
+
+	probe.Success = true
+	probe.RTT = rtt
+	probe.UploadSpeed = probeSpeed(upload - rtt)
+	probe.DownloadSpeed = probeSpeed(download - rtt)
+	return probe
+}
+
+// probeRecordUpdate appends the probe record to the storage host, and re-evaluates the host.
+// Require: lock shm.lock by caller
+func (shm *StorageHostManager) probeRecordUpdate(id enode.ID, probe storage.HostProbe) {
+	info, exists := shm.storageHostTree.RetrieveHostInfo(id)
+	if !exists {
+		return
+	}
+	// the slice is shared with the stored host info, make a copy before modification
+	records := append(storage.HostProbes{}, info.ProbeRecords...)
+	records = append(records, probe)
+	if len(records) > maxProbeRecords {
+		records = records[len(records)-maxProbeRecords:]
+	}
+	info.ProbeRecords = records
+	if err := shm.modify(info); err != nil {
+		shm.log.Warn("failed to update the probe records", "hostID", id, "err", err)
+	}
+}
+
+// HostRTT returns the average round trip time of the storage host measured recently, and
+// false if the host has not been probed successfully
+func (shm *StorageHostManager) HostRTT(id enode.ID) (time.Duration, bool) {
+	info, exists := shm.storageHostTree.RetrieveHostInfo(id)
+	if !exists {
+		return 0, false
+	}
+	return info.ProbeRecords.Recent(probeHistoryWindow).AverageRTT()
+}
+
+// latencyFactorCalc calculates the factor value based on the round trip time measured
+// recently. The host with lower round trip time gets higher evaluation
+func latencyFactorCalc(info storage.HostInfo) float64 {
+	rtt, probed := info.ProbeRecords.Recent(probeHistoryWindow).AverageRTT()
+	if !probed {
+		return probeUnknownFactor
+	}
+	if rtt <= probeRTTTarget {
+		return 1
+	}
+	return math.Max(float64(probeRTTTarget)/float64(rtt), probeFactorFloor)
+}
+
+// throughputFactorCalc calculates the factor value based on the upload and download speed
+// measured recently. The host with higher speed gets higher evaluation
+func throughputFactorCalc(info storage.HostInfo) float64 {
+	speed, probed := info.ProbeRecords.Recent(probeHistoryWindow).AverageSpeed()
+	if !probed {
+		return probeUnknownFactor
+	}
+	if speed >= probeSpeedTarget {
+		return 1
+	}
+	return math.Max(float64(speed)/probeSpeedTarget, probeFactorFloor)
+}
+
+// probeSpeed returns the speed in bytes per second of transferring the probe data
+func probeSpeed(elapsed time.Duration) uint64 {
+	if elapsed < time.Millisecond {
+		elapsed = time.Millisecond
+	}
+	return uint64(float64(probeSize) / elapsed.Seconds())
+}
+
+// lastProbeTime returns the time of the last probe of the storage host
+func lastProbeTime(host storage.HostInfo) time.Time {
+	if len(host.ProbeRecords) == 0 {
+		return time.Time{}
+	}
+	return host.ProbeRecords[len(host.ProbeRecords)-1].Timestamp
+}
@@ -0,0 +1,72 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+func TestStorageHostManager_ProbeHost(t *testing.T) {
+	shm := newHostManagerTestData()
+	host := hostInfoGenerator()
+	if err := shm.insert(host); err != nil {
+		t.Fatal(err)
+	}
+
+	// the test backend takes 10ms for the empty probe, and 64ms more for the probe
+	// carrying 64 KiB data
+	probe := shm.probeHost(host)
+	speed := probeSpeed(64 * time.Millisecond)
+	if !probe.Success || probe.RTT != 10*time.Millisecond {
+		t.Fatalf("probe not expected: %+v", probe)
+	}
+	if probe.UploadSpeed != speed || probe.DownloadSpeed != speed {
+		t.Errorf("probe speed not expected: %+v", probe)
+	}
+
+	for i := 0; i < maxProbeRecords+1; i++ {
+		shm.probeRecordUpdate(host.EnodeID, probe)
+	}
+	info, _ := shm.RetrieveHostInfo(host.EnodeID)
+	if len(info.ProbeRecords) != maxProbeRecords {
+		t.Errorf("probe records not trimmed: %v", len(info.ProbeRecords))
+	}
+	if rtt, probed := shm.HostRTT(host.EnodeID); !probed || rtt != probe.RTT {
+		t.Errorf("host rtt not expected: %v", rtt)
+	}
+	if factor := latencyFactorCalc(info); factor != 1 {
+		t.Errorf("latency factor not expected: %v", factor)
+	}
+	if factor := throughputFactorCalc(info); factor != float64(speed)/probeSpeedTarget {
+		t.Errorf("throughput factor not expected: %v", factor)
+	}
+}
+
+func TestLatencyFactorCalc(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		probes storage.HostProbes
+		expect float64
+	}{
+		{nil, probeUnknownFactor},
+		{storage.HostProbes{{Timestamp: now, Success: false, RTT: time.Second}}, probeUnknownFactor},
+		{storage.HostProbes{{Timestamp: now.Add(-2 * probeHistoryWindow), Success: true, RTT: time.Second}}, probeUnknownFactor},
+		{storage.HostProbes{{Timestamp: now, Success: true, RTT: probeRTTTarget / 2}}, 1},
+		{storage.HostProbes{{Timestamp: now, Success: true, RTT: 2 * probeRTTTarget}}, 0.5},
+		{storage.HostProbes{{Timestamp: now, Success: true, RTT: 1000 * probeRTTTarget}}, probeFactorFloor},
+		{storage.HostProbes{
+			{Timestamp: now, Success: true, RTT: 3 * probeRTTTarget},
+			{Timestamp: now, Success: true, RTT: 5 * probeRTTTarget},
+		}, 0.25},
+	}
+	for i, test := range tests {
+		if factor := latencyFactorCalc(storage.HostInfo{ProbeRecords: test.probes}); factor != test.expect {
+			t.Errorf("test %d: expect factor %v, got %v", i, test.expect, factor)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"math"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// FactorReputation is the name of the evaluation factor based on the track record of the
+// storage host derived from the chain
+const FactorReputation = "reputation"
+
+// ReputationFactor returns the evaluation factor based on the storage proofs submitted and
+// missed by the storage host on chain. The storage host which never missed a proof gets the
+// full factor, so that the new storage hosts are not punished by the factor
+func ReputationFactor(b storage.HostReputationBackend) FactorFunc {
+	return func(info storage.HostInfo) float64 {
+		reputation, exist := b.HostReputation(info.PaymentAddress)
+		if !exist {
+			return 1
+		}
+		proofs := float64(reputation.ProofsSubmitted + reputationBaseProofs)
+		ratio := proofs / (proofs + float64(reputation.ProofsMissed))
+		return math.Pow(ratio, reputationExponent)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/rawdb"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// reputationBackendTestData returns the track records of the storage hosts by the payment address
+type reputationBackendTestData map[common.Address]rawdb.HostReputation
+
+func (b reputationBackendTestData) HostReputation(address common.Address) (rawdb.HostReputation, bool) {
+	reputation, exist := b[address]
+	return reputation, exist
+}
+
+func TestReputationFactor(t *testing.T) {
+	reliable, unreliable, unknown := common.Address{1}, common.Address{2}, common.Address{3}
+	factor := ReputationFactor(reputationBackendTestData{
+		reliable:   {ContractsFormed: 20, ProofsSubmitted: 20},
+		unreliable: {ContractsFormed: 20, ProofsSubmitted: 10, ProofsMissed: 10},
+	})
+
+	infoOf := func(address common.Address) storage.HostInfo {
+		var info storage.HostInfo
+		info.PaymentAddress = address
+		return info
+	}
+	if f := factor(infoOf(reliable)); f != 1 {
+		t.Errorf("expect the full factor of the host never missed a proof, got %v", f)
+	}
+	if f := factor(infoOf(unknown)); f != 1 {
+		t.Errorf("expect the full factor of the host without track record, got %v", f)
+	}
+	if f := factor(infoOf(unreliable)); f >= 0.5 {
+		t.Errorf("expect the host missed half of the proofs punished, got %v", f)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"math/rand"
 	"time"
 
+	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/storage"
 	"github.com/DxChainNetwork/godx/storage/storageclient/storagehosttree"
 )
@@ -202,17 +203,6 @@ func (shm *StorageHostManager) scanExecute(scanWorker <-chan storage.HostInfo) {
 func (shm *StorageHostManager) updateHostConfig(hi storage.HostInfo) {
 	shm.log.Info("Started updating the storage host", "Host ID", hi.EnodeURL)
 
-	// get the IP network and check if it is changed
-	// this is needed because the storage host can change its settings directly
-	ipnet, err := storagehosttree.IPNetwork(hi.IP)
-
-	if err == nil && ipnet.String() != hi.IPNetwork {
-		hi.IPNetwork = ipnet.String()
-		hi.LastIPNetWorkChange = time.Now()
-	} else if err != nil {
-		shm.log.Error("failed to get the IP network information", "err", err.Error())
-	}
-
 	// update the historical interactions
 	shm.lock.RLock()
 	info := &hi
@@ -222,7 +212,8 @@ func (shm *StorageHostManager) updateHostConfig(hi storage.HostInfo) {
 	hostHistoricInteractionsUpdate(info, blockHeight)
 
 	// retrieve storage host external settings
-	hostConfig, err := shm.retrieveHostConfig(hi)
+	knownInfo := hi
+	hostConfig, err := shm.retrieveHostConfig(&hi)
 	if err == storage.ErrRequestingHostConfig {
 		return
 	} else if err != nil {
@@ -231,6 +222,20 @@ func (shm *StorageHostManager) updateHostConfig(hi storage.HostInfo) {
 		hi.HostExtConfig = hostConfig
 	}
 
+	// get the IP network and check if it is changed
+	// this is needed because the storage host can change its settings directly,
+	// or the host is reached through one of its alternative addresses
+	ipnet, ipErr := storagehosttree.IPNetwork(hi.IP)
+
+	if ipErr == nil && ipnet.String() != hi.IPNetwork {
+		hi.IPNetwork = ipnet.String()
+		if !announcedNetwork(knownInfo, hi.IPNetwork) {
+			hi.LastIPNetWorkChange = time.Now()
+		}
+	} else if ipErr != nil {
+		shm.log.Error("failed to get the IP network information", "err", ipErr.Error())
+	}
+
 	shm.lock.Lock()
 	defer shm.lock.Unlock()
 
@@ -241,12 +246,32 @@ func (shm *StorageHostManager) updateHostConfig(hi storage.HostInfo) {
 }
 
 // retrieveHostSetting will establish connection to the corresponded storage host
-// and get its configurations
-func (shm *StorageHostManager) retrieveHostConfig(hi storage.HostInfo) (storage.HostExtConfig, error) {
+// and get its configurations. If the host cannot be reached through its enode URL,
+// the alternative addresses are tried, and the first working one is promoted to be
+// the enode URL of the host
+func (shm *StorageHostManager) retrieveHostConfig(hi *storage.HostInfo) (storage.HostExtConfig, error) {
 	var config storage.HostExtConfig
 
 	// send message, and get host setting
 	err := shm.b.GetStorageHostSetting(hi.EnodeID, hi.EnodeURL, &config)
+	if err == nil || err == storage.ErrRequestingHostConfig {
+		return config, err
+	}
+	for i, url := range hi.AltEnodeURLs {
+		node, parseErr := enode.ParseV4(url)
+		if parseErr != nil {
+			continue
+		}
+		if altErr := shm.b.GetStorageHostSetting(hi.EnodeID, url, &config); altErr != nil {
+			continue
+		}
+		shm.log.Info("Storage host reached through alternative address", "hostID", hi.EnodeID, "url", url)
+		// the slice is shared with the stored host info, make a copy before modification
+		altURLs := append([]string{}, hi.AltEnodeURLs...)
+		altURLs[i] = hi.EnodeURL
+		hi.AltEnodeURLs, hi.EnodeURL, hi.IP = altURLs, url, node.IP().String()
+		return config, nil
+	}
 	return config, err
 }
 
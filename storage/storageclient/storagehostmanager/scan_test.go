@@ -142,6 +142,12 @@ func (st *storageClientBackendTestData) GetStorageHostSetting(hostEnodeID enode.
 	return nil
 }
 
+func (st *storageClientBackendTestData) ProbeStorageHost(hostEnodeURL string, req storage.HostProbeRequest) (time.Duration, error) {
+	// the probe takes 10ms plus 1ms per KiB transferred
+	size := uint64(len(req.Payload)) + req.RespSize
+	return 10*time.Millisecond + time.Duration(size/1024)*time.Millisecond, nil
+}
+
 func (st *storageClientBackendTestData) SubscribeChainChangeEvent(ch chan<- core.ChainChangeEvent) event.Subscription {
 	return nil
 }
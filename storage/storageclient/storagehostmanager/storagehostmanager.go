@@ -30,6 +30,17 @@ type StorageHostManager struct {
 	evalFunc        storagehosttree.EvaluationFunc
 	storageHostTree *storagehosttree.StorageHostTree
 
+	// evaluation config and the additional evaluation factors
+	evalConfig   EvaluationConfig
+	extraFactors map[string]FactorFunc
+
+	// placement constraints and the label sources of the storage hosts
+	placementConstraints []storagehosttree.PlacementConstraint
+	hostLabels           map[enode.ID]map[string]string
+	labelers             []storagehosttree.Labeler
+	geoDB                *GeoDB
+	geoDBPath            string
+
 	// ip violation check
 	ipViolationCheck bool
 
@@ -65,6 +76,12 @@ func New(persistDir string) *StorageHostManager {
 		scanLookup:    make(map[enode.ID]struct{}),
 		filterMode:    DisableFilter,
 		filteredHosts: make(map[enode.ID]struct{}),
+		evalConfig:    defaultEvaluationConfig(),
+		hostLabels:    make(map[enode.ID]map[string]string),
+		extraFactors: map[string]FactorFunc{
+			FactorLatency:    latencyFactorCalc,
+			FactorThroughput: throughputFactorCalc,
+		},
 	}
 
 	shm.evalFunc = shm.calculateEvaluationFunc(shm.rent)
@@ -105,6 +122,9 @@ func (shm *StorageHostManager) Start(b storage.ClientBackend) error {
 	// started scan and update storage host information
 	go shm.scan()
 
+	// measure the round trip time and throughput of the storage hosts
+	go shm.probeLoop()
+
 	shm.log.Info("Storage Host Manager Started")
 
 	return nil
@@ -150,15 +170,9 @@ func (shm *StorageHostManager) SetRentPayment(rent storage.RentPayment) (err err
 	// update the rent
 	shm.rent = rent
 
-	// update the storage host evaluation function
-	evalFunc := shm.calculateEvaluationFunc(rent)
-	shm.evalFunc = evalFunc
-
-	// update the storage host tree and filtered tree evaluation func
-	err = shm.storageHostTree.SetEvaluationFunc(evalFunc)
-	err = common.ErrCompose(err, shm.filteredTree.SetEvaluationFunc(evalFunc))
-
-	return
+	// update the storage host evaluation function, along with the storage host tree
+	// and filtered tree evaluation func
+	return shm.updateEvaluationFunc()
 }
 
 // RetrieveRentPayment will return the current rent payment settings for storage host manager
@@ -261,6 +275,7 @@ func (shm *StorageHostManager) RetrieveRandomHosts(num int, blacklist, addrBlack
 	shm.lock.RLock()
 	initScan := shm.initialScan
 	ipCheck := shm.ipViolationCheck
+	placement := shm.newPlacement()
 	shm.lock.RUnlock()
 
 	// if the initialize scan is not complete
@@ -269,11 +284,19 @@ func (shm *StorageHostManager) RetrieveRandomHosts(num int, blacklist, addrBlack
 		return
 	}
 
+	// the storage hosts in the address blacklist are the hosts already selected, which
+	// count towards the placement constraints
+	for _, id := range addrBlacklist {
+		if info, exist := shm.storageHostTree.RetrieveHostInfo(id); exist {
+			placement.Add(info)
+		}
+	}
+
 	// select random
 	if ipCheck {
-		infos = shm.filteredTree.SelectRandom(num, blacklist, addrBlacklist)
+		infos = shm.filteredTree.SelectRandomWithPlacement(num, blacklist, addrBlacklist, placement)
 	} else {
-		infos = shm.filteredTree.SelectRandom(num, blacklist, nil)
+		infos = shm.filteredTree.SelectRandomWithPlacement(num, blacklist, nil, placement)
 	}
 
 	return
@@ -113,17 +113,22 @@ func (shm *StorageHostManager) insertStorageHostInformation(info storage.HostInf
 	}
 
 	// if the storage host information already existed, update the settings
+	knownInfo := oldInfo
 	oldInfo.EnodeURL = info.EnodeURL
 	oldInfo.IP = info.IP
+	oldInfo.AltEnodeURLs = info.AltEnodeURLs
 
 	// check if the ip address has been changed, if so, update the IP network field
-	// and update the LastIPNetWorkChange time
+	// and update the LastIPNetWorkChange time. Moving to an address the host announced
+	// before is not treated as a network change
 	networkAddr, err := storagehosttree.IPNetwork(oldInfo.IP)
 	if err != nil {
 		shm.log.Error("failed to extract the network address from the IP address", "err", err.Error())
 	} else if networkAddr.String() != oldInfo.IPNetwork {
 		oldInfo.IPNetwork = networkAddr.String()
-		oldInfo.LastIPNetWorkChange = time.Now()
+		if !announcedNetwork(knownInfo, oldInfo.IPNetwork) {
+			oldInfo.LastIPNetWorkChange = time.Now()
+		}
 	}
 
 	// modify the old storage host information
@@ -148,5 +153,31 @@ func parseHostAnnouncement(announcement types.HostAnnouncement) (hostInfo storag
 	hostInfo.IP = node.IP().String()
 	hostInfo.NodePubKey = crypto.FromECDSAPub(node.Pubkey())
 
+	// the alternative addresses must belong to the same node
+	for _, url := range announcement.AltAddresses {
+		altNode, altErr := enode.ParseV4(url)
+		if altErr != nil || altNode.ID() != hostInfo.EnodeID {
+			continue
+		}
+		hostInfo.AltEnodeURLs = append(hostInfo.AltEnodeURLs, url)
+	}
+
 	return
 }
+
+// announcedNetwork checks whether the IP network is the network of any address announced
+// by the storage host
+func announcedNetwork(info storage.HostInfo, network string) bool {
+	urls := append([]string{info.EnodeURL}, info.AltEnodeURLs...)
+	for _, url := range urls {
+		node, err := enode.ParseV4(url)
+		if err != nil {
+			continue
+		}
+		ipnet, err := storagehosttree.IPNetwork(node.IP().String())
+		if err == nil && ipnet.String() == network {
+			return true
+		}
+	}
+	return false
+}
@@ -5,6 +5,8 @@
 package storagehosttree
 
 import (
+	"math"
+
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/storage"
 )
@@ -19,6 +21,16 @@ type HostEvaluation interface {
 // EvaluationFunc is used to calculate storage host evaluation
 type EvaluationFunc func(storage.HostInfo) HostEvaluation
 
+// Names of the storage host evaluation factors, which are used as the keys of the weights
+const (
+	FactorPresence         = "presence"
+	FactorDeposit          = "deposit"
+	FactorInteraction      = "interaction"
+	FactorContractPrice    = "price"
+	FactorStorageRemaining = "storage"
+	FactorUptime           = "uptime"
+)
+
 // EvaluationDetail contains the detailed storage host evaluation factors
 type EvaluationDetail struct {
 	Evaluation     common.BigInt `json:"evaluation"`
@@ -30,9 +42,15 @@ type EvaluationDetail struct {
 	ContractPriceFactor    float64 `json:"contractpriceFactor"`
 	StorageRemainingFactor float64 `json:"storageremainingfactor"`
 	UptimeFactor           float64 `json:"uptimefactor"`
+
+	ExtraFactors map[string]float64 `json:"extrafactors"`
+	Weights      map[string]float64 `json:"weights"`
 }
 
-// EvaluationCriteria contains statistics that used to calculate the storage host evaluation
+// EvaluationCriteria contains statistics that used to calculate the storage host evaluation.
+// ExtraFactors are the factors other than the built-in ones, such as the measured latency.
+// The evaluation is the product of all factors, each raised to the power of its weight in
+// Weights. The factors not specified in Weights have the weight of 1
 type EvaluationCriteria struct {
 	PresenceFactor         float64
 	DepositFactor          float64
@@ -40,12 +58,22 @@ type EvaluationCriteria struct {
 	ContractPriceFactor    float64
 	StorageRemainingFactor float64
 	UptimeFactor           float64
+
+	ExtraFactors map[string]float64
+	Weights      map[string]float64
 }
 
 // Evaluation will be used to calculate the storage host evaluation
 func (ec EvaluationCriteria) Evaluation() common.BigInt {
-	total := ec.PresenceFactor * ec.DepositFactor * ec.InteractionFactor *
-		ec.ContractPriceFactor * ec.StorageRemainingFactor * ec.UptimeFactor
+	total := ec.weighted(FactorPresence, ec.PresenceFactor) *
+		ec.weighted(FactorDeposit, ec.DepositFactor) *
+		ec.weighted(FactorInteraction, ec.InteractionFactor) *
+		ec.weighted(FactorContractPrice, ec.ContractPriceFactor) *
+		ec.weighted(FactorStorageRemaining, ec.StorageRemainingFactor) *
+		ec.weighted(FactorUptime, ec.UptimeFactor)
+	for name, factor := range ec.ExtraFactors {
+		total *= ec.weighted(name, factor)
+	}
 
 	// making sure the total is at least 1
 	if total < 1 {
@@ -55,6 +83,15 @@ func (ec EvaluationCriteria) Evaluation() common.BigInt {
 	return common.NewBigInt(1).MultFloat64(total)
 }
 
+// weighted returns the factor raised to the power of its weight
+func (ec EvaluationCriteria) weighted(name string, factor float64) float64 {
+	weight, exist := ec.Weights[name]
+	if !exist || weight == 1 {
+		return factor
+	}
+	return math.Pow(factor, weight)
+}
+
 // EvaluationDetail will return storage host detailed evaluation, including evaluation criteria
 func (ec EvaluationCriteria) EvaluationDetail(evalAll common.BigInt, ignoreAge, ignoreUptime bool) EvaluationDetail {
 	if ignoreAge {
@@ -77,6 +114,8 @@ func (ec EvaluationCriteria) EvaluationDetail(evalAll common.BigInt, ignoreAge,
 		ContractPriceFactor:    ec.ContractPriceFactor,
 		StorageRemainingFactor: ec.StorageRemainingFactor,
 		UptimeFactor:           ec.UptimeFactor,
+		ExtraFactors:           ec.ExtraFactors,
+		Weights:                ec.Weights,
 	}
 
 }
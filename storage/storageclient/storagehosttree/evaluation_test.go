@@ -76,3 +76,30 @@ func partialRandomCriteria() EvaluationCriteria {
 func randFloat64() float64 {
 	return math.Abs(rand.Float64() * 5000)
 }
+
+func TestEvaluationCriteria_Weights(t *testing.T) {
+	ec := EvaluationCriteria{
+		PresenceFactor:         10,
+		DepositFactor:          10,
+		InteractionFactor:      10,
+		ContractPriceFactor:    10,
+		StorageRemainingFactor: 10,
+		UptimeFactor:           10,
+		ExtraFactors:           map[string]float64{"latency": 10},
+	}
+	tests := []struct {
+		weights map[string]float64
+		expect  int64
+	}{
+		{nil, 1e7},
+		{map[string]float64{FactorPresence: 0, "latency": 0}, 1e5},
+		{map[string]float64{FactorContractPrice: 2}, 1e8},
+		{map[string]float64{FactorUptime: 3, FactorDeposit: 0}, 1e8},
+	}
+	for i, test := range tests {
+		ec.Weights = test.weights
+		if eval := ec.Evaluation(); eval.Cmp(common.NewBigInt(test.expect)) != 0 {
+			t.Errorf("test %d: expect evaluation %v, got %v", i, test.expect, eval)
+		}
+	}
+}
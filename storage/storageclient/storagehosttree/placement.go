@@ -0,0 +1,159 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehosttree
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// Labels of the storage host provided by the built-in label sources
+const (
+	LabelSubnet = "subnet"
+	LabelASN    = "asn"
+	LabelRegion = "region"
+)
+
+type (
+	// Labeler returns the labels of the storage host, such as the ASN, the region or any
+	// custom label like "datacenter=A". The labels are the data source of the placement
+	// constraints
+	Labeler func(info storage.HostInfo) map[string]string
+
+	// PlacementConstraint constrains the storage hosts selected by the value of a label.
+	// At most MaxPerValue hosts can have the same value of the label, and the hosts with
+	// new values are preferred until MinDistinct distinct values are selected. Zero means
+	// no constraint. Hosts without the label are not constrained by MaxPerValue, and do
+	// not count as a distinct value
+	PlacementConstraint struct {
+		Label       string `json:"label"`
+		MaxPerValue int    `json:"maxPerValue"`
+		MinDistinct int    `json:"minDistinct"`
+	}
+
+	// Placement tracks the storage hosts selected against the placement constraints
+	Placement struct {
+		constraints []PlacementConstraint
+		labeler     Labeler
+
+		// counts is the number of hosts selected for each value of each label
+		counts map[string]map[string]int
+	}
+)
+
+// NewPlacement will create and initialize a Placement object
+func NewPlacement(constraints []PlacementConstraint, labeler Labeler) *Placement {
+	p := &Placement{
+		constraints: constraints,
+		labeler:     labeler,
+		counts:      make(map[string]map[string]int),
+	}
+	for _, c := range constraints {
+		p.counts[c.Label] = make(map[string]int)
+	}
+	return p
+}
+
+// Add will add the storage host to the selected hosts
+func (p *Placement) Add(info storage.HostInfo) {
+	if p == nil {
+		return
+	}
+	labels := p.labeler(info)
+	for _, c := range p.constraints {
+		if value := labels[c.Label]; value != "" {
+			p.counts[c.Label][value]++
+		}
+	}
+}
+
+// Remove will remove the storage host from the selected hosts
+func (p *Placement) Remove(info storage.HostInfo) {
+	if p == nil {
+		return
+	}
+	labels := p.labeler(info)
+	for _, c := range p.constraints {
+		value := labels[c.Label]
+		if value == "" || p.counts[c.Label][value] == 0 {
+			continue
+		}
+		if p.counts[c.Label][value]--; p.counts[c.Label][value] == 0 {
+			delete(p.counts[c.Label], value)
+		}
+	}
+}
+
+// Violates checks if selecting the storage host exceeds MaxPerValue of any constraint
+func (p *Placement) Violates(info storage.HostInfo) bool {
+	if p == nil {
+		return false
+	}
+	labels := p.labeler(info)
+	for _, c := range p.constraints {
+		value := labels[c.Label]
+		if c.MaxPerValue > 0 && value != "" && p.counts[c.Label][value] >= c.MaxPerValue {
+			return true
+		}
+	}
+	return false
+}
+
+// Preferred checks if the storage host shall be selected given the number of hosts remaining
+// to be selected. The host is not preferred if it brings no new value to a constraint whose
+// MinDistinct can only be reached with all the remaining hosts having new values
+func (p *Placement) Preferred(info storage.HostInfo, remaining int) bool {
+	if p == nil {
+		return true
+	}
+	labels := p.labeler(info)
+	for _, c := range p.constraints {
+		missing := c.MinDistinct - len(p.counts[c.Label])
+		if missing <= 0 || missing < remaining {
+			continue
+		}
+		if value := labels[c.Label]; value == "" || p.counts[c.Label][value] > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ParsePlacementConstraint parses the placement constraint in the form of
+// "label:max=K", "label:min=N" or "label:max=K,min=N"
+func ParsePlacementConstraint(str string) (c PlacementConstraint, err error) {
+	parts := strings.SplitN(strings.TrimSpace(str), ":", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return PlacementConstraint{}, fmt.Errorf("placement constraint %s is not in the form of label:max=K,min=N", str)
+	}
+	c.Label = parts[0]
+	for _, param := range strings.Split(parts[1], ",") {
+		kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+		if len(kv) != 2 {
+			return PlacementConstraint{}, fmt.Errorf("invalid placement constraint param: %s", param)
+		}
+		value, err := strconv.ParseUint(kv[1], 10, 32)
+		if err != nil {
+			return PlacementConstraint{}, fmt.Errorf("invalid placement constraint value %s: %v", kv[1], err)
+		}
+		switch kv[0] {
+		case "max":
+			c.MaxPerValue = int(value)
+		case "min":
+			c.MinDistinct = int(value)
+		default:
+			return PlacementConstraint{}, fmt.Errorf("invalid placement constraint param: %s", kv[0])
+		}
+	}
+	return c, nil
+}
+
+// String returns the placement constraint in the form of "label:max=K,min=N"
+func (c PlacementConstraint) String() string {
+	return fmt.Sprintf("%s:max=%d,min=%d", c.Label, c.MaxPerValue, c.MinDistinct)
+}
@@ -0,0 +1,143 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehosttree
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// regionLabeler labels the storage host with the region by the first byte of the enode ID
+func regionLabeler(regions map[byte]string) Labeler {
+	return func(info storage.HostInfo) map[string]string {
+		return map[string]string{LabelRegion: regions[info.EnodeID[0]]}
+	}
+}
+
+func TestPlacement_Violates(t *testing.T) {
+	regions := map[byte]string{1: "us", 2: "us", 3: "eu", 4: ""}
+	placement := NewPlacement([]PlacementConstraint{{Label: LabelRegion, MaxPerValue: 1}}, regionLabeler(regions))
+	placement.Add(storage.HostInfo{EnodeID: enode.ID{1}})
+
+	tests := []struct {
+		id       byte
+		violates bool
+	}{
+		{2, true},
+		{3, false},
+		// host without the label is not constrained
+		{4, false},
+	}
+	for i, test := range tests {
+		if violates := placement.Violates(storage.HostInfo{EnodeID: enode.ID{test.id}}); violates != test.violates {
+			t.Errorf("test %d: violates expect %v, got %v", i, test.violates, violates)
+		}
+	}
+
+	placement.Remove(storage.HostInfo{EnodeID: enode.ID{1}})
+	if placement.Violates(storage.HostInfo{EnodeID: enode.ID{2}}) {
+		t.Errorf("host shall not violate the placement after the host is removed")
+	}
+}
+
+func TestPlacement_Preferred(t *testing.T) {
+	regions := map[byte]string{1: "us", 2: "us", 3: "eu", 4: ""}
+	placement := NewPlacement([]PlacementConstraint{{Label: LabelRegion, MinDistinct: 2}}, regionLabeler(regions))
+	placement.Add(storage.HostInfo{EnodeID: enode.ID{1}})
+
+	tests := []struct {
+		id        byte
+		remaining int
+		preferred bool
+	}{
+		{2, 1, false},
+		{2, 2, true},
+		{3, 1, true},
+		{4, 1, false},
+	}
+	for i, test := range tests {
+		if preferred := placement.Preferred(storage.HostInfo{EnodeID: enode.ID{test.id}}, test.remaining); preferred != test.preferred {
+			t.Errorf("test %d: preferred expect %v, got %v", i, test.preferred, preferred)
+		}
+	}
+
+	// nil placement has no constraint
+	var nilPlacement *Placement
+	if !nilPlacement.Preferred(storage.HostInfo{}, 1) || nilPlacement.Violates(storage.HostInfo{}) {
+		t.Errorf("nil placement shall not constrain the storage hosts")
+	}
+}
+
+func TestParsePlacementConstraint(t *testing.T) {
+	tests := []struct {
+		str        string
+		constraint PlacementConstraint
+		err        bool
+	}{
+		{"asn:max=1", PlacementConstraint{Label: LabelASN, MaxPerValue: 1}, false},
+		{"region:min=3", PlacementConstraint{Label: LabelRegion, MinDistinct: 3}, false},
+		{"datacenter:max=2,min=2", PlacementConstraint{Label: "datacenter", MaxPerValue: 2, MinDistinct: 2}, false},
+		{"asn", PlacementConstraint{}, true},
+		{":max=1", PlacementConstraint{}, true},
+		{"asn:max=-1", PlacementConstraint{}, true},
+		{"asn:count=1", PlacementConstraint{}, true},
+	}
+	for i, test := range tests {
+		c, err := ParsePlacementConstraint(test.str)
+		if (err != nil) != test.err {
+			t.Errorf("test %d: error expect %v, got %v", i, test.err, err)
+			continue
+		}
+		if c != test.constraint {
+			t.Errorf("test %d: constraint expect %+v, got %+v", i, test.constraint, c)
+		}
+		if err == nil {
+			if parsed, _ := ParsePlacementConstraint(c.String()); parsed != c {
+				t.Errorf("test %d: constraint string %s cannot be parsed back", i, c.String())
+			}
+		}
+	}
+}
+
+func TestStorageHostTree_SelectRandomWithPlacement(t *testing.T) {
+	// hosts 1-6 in region us, host 7 in region eu, host 8 in region asia
+	regions := make(map[byte]string)
+	placementTree := New(evalFunc)
+	for i := byte(1); i <= 8; i++ {
+		regions[i] = "us"
+		scans := storage.HostPoolScans{{Timestamp: time.Now(), Success: true}}
+		info := createHostInfo(fmt.Sprintf("%d.0.0.1", i), enode.ID{i}, scans, true)
+		if err := placementTree.Insert(info); err != nil {
+			t.Fatal(err)
+		}
+	}
+	regions[7], regions[8] = "eu", "asia"
+
+	for i := 0; i < 20; i++ {
+		constraints := []PlacementConstraint{{Label: LabelRegion, MaxPerValue: 2, MinDistinct: 3}}
+		infos := placementTree.SelectRandomWithPlacement(4, nil, nil, NewPlacement(constraints, regionLabeler(regions)))
+		if len(infos) != 4 {
+			t.Fatalf("expect 4 hosts selected, got %d", len(infos))
+		}
+		counts := make(map[string]int)
+		for _, info := range infos {
+			counts[regions[info.EnodeID[0]]]++
+		}
+		if len(counts) != 3 || counts["us"] != 2 {
+			t.Fatalf("hosts selected do not honor the placement: %v", counts)
+		}
+	}
+
+	// not enough distinct values, the hosts not preferred are still selected
+	constraints := []PlacementConstraint{{Label: LabelRegion, MinDistinct: 5}}
+	infos := placementTree.SelectRandomWithPlacement(6, nil, nil, NewPlacement(constraints, regionLabeler(regions)))
+	if len(infos) != 6 {
+		t.Errorf("expect 6 hosts selected, got %d", len(infos))
+	}
+}
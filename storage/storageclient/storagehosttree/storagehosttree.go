@@ -188,10 +188,19 @@ func (t *StorageHostTree) SetEvaluationFunc(ef EvaluationFunc) error {
 //  	1. handle addrBlacklist
 // 		2. handle blacklist
 //      3. get needed storage hosts
-//      4. restore storage host tree structure
+//      4. select the deferred storage hosts
+//      5. restore storage host tree structure
 // NOTE: the number of storage hosts information got may not satisfy the number of storage host
 // information needed.
 func (t *StorageHostTree) SelectRandom(needed int, blacklist, addrBlacklist []enode.ID) []storage.HostInfo {
+	return t.SelectRandomWithPlacement(needed, blacklist, addrBlacklist, nil)
+}
+
+// SelectRandomWithPlacement works the same as SelectRandom, except that the storage hosts
+// selected also honor the placement constraints. The storage host violating the constraints
+// cannot be selected, and the storage host not preferred by the placement is deferred, which
+// will be selected only if there are not enough preferred storage hosts
+func (t *StorageHostTree) SelectRandomWithPlacement(needed int, blacklist, addrBlacklist []enode.ID, placement *Placement) []storage.HostInfo {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
@@ -221,7 +230,7 @@ func (t *StorageHostTree) SelectRandom(needed int, blacklist, addrBlacklist []en
 	}
 
 	// 3. get needed storage hosts information
-	var storageHosts []storage.HostInfo
+	var storageHosts, deferred []storage.HostInfo
 	for len(t.hostPool) > 0 && len(storageHosts) < needed {
 		// in case the evaluation is negative, the random will return error
 		// however, this should never happen
@@ -246,12 +255,19 @@ func (t *StorageHostTree) SelectRandom(needed int, blacklist, addrBlacklist []en
 		//   2. must be scanned at least once
 		//   3. the latest scan must be success
 		//   4. ip network should not be the same as once contained in the address blacklist
+		//   5. must not violate the placement constraints
 		if node.entry.AcceptingContracts &&
 			len(node.entry.ScanRecords) > 0 &&
 			node.entry.ScanRecords[len(node.entry.ScanRecords)-1].Success &&
-			!filter.Filtered(node.entry.IP) {
-			storageHosts = append(storageHosts, node.entry.HostInfo)
-			filter.Add(node.entry.IP)
+			!filter.Filtered(node.entry.IP) &&
+			!placement.Violates(node.entry.HostInfo) {
+			if placement.Preferred(node.entry.HostInfo, needed-len(storageHosts)) {
+				storageHosts = append(storageHosts, node.entry.HostInfo)
+				filter.Add(node.entry.IP)
+				placement.Add(node.entry.HostInfo)
+			} else {
+				deferred = append(deferred, node.entry.HostInfo)
+			}
 		}
 
 		// remove the node
@@ -260,7 +276,20 @@ func (t *StorageHostTree) SelectRandom(needed int, blacklist, addrBlacklist []en
 		removedNodeEntries = append(removedNodeEntries, node.entry)
 	}
 
-	// 4. restore storage host tree structure
+	// 4. select the deferred storage hosts if there are not enough storage hosts
+	for _, info := range deferred {
+		if len(storageHosts) >= needed {
+			break
+		}
+		if filter.Filtered(info.IP) || placement.Violates(info) {
+			continue
+		}
+		storageHosts = append(storageHosts, info)
+		filter.Add(info.IP)
+		placement.Add(info)
+	}
+
+	// 5. restore storage host tree structure
 	for _, entry := range removedNodeEntries {
 		_, node := t.root.nodeInsert(entry)
 		t.hostPool[node.entry.EnodeID] = node
@@ -24,6 +24,10 @@ func (client *StorageClient) Upload(up storage.FileUploadParams) error {
 	}
 	defer client.tm.Done()
 
+	if client.contractManager.SpendingPaused() {
+		return ErrSpendingCapReached
+	}
+
 	// Check whether file is a directory
 	sourceInfo, err := os.Stat(up.Source)
 	if err != nil {
@@ -49,6 +53,9 @@ func (client *StorageClient) Upload(up storage.FileUploadParams) error {
 	//	}
 	//}
 
+	// Small files with the default erasure code are packed into the shared pack files
+	packed := shouldPack(up, sourceInfo.Size())
+
 	// Setup ECTypeStandard's ErasureCode with default params
 	if up.ErasureCode == nil {
 		up.ErasureCode, _ = erasurecode.New(erasurecode.ECTypeStandard, storage.DefaultMinSectors, storage.DefaultNumSectors)
@@ -75,11 +82,22 @@ func (client *StorageClient) Upload(up storage.FileUploadParams) error {
 	}
 	//client.log.Error("test error for NewDxDir in upload", "error", err)
 
-	cipherKey, err := crypto.GenerateCipherKey(crypto.GCMCipherCode)
+	// Files uploaded in dedup mode share the cipher key, so that the sectors could be shared
+	var cipherKey crypto.CipherKey
+	var hashKey []byte
+	if up.Dedup {
+		cipherKey, hashKey, err = client.dedupKeys()
+	} else {
+		cipherKey, err = crypto.GenerateCipherKey(crypto.GCMCipherCode)
+	}
 	if err != nil {
 		return fmt.Errorf("generate cipher key error: %v", err)
 	}
 
+	if packed {
+		return client.uploadPacked(up, cipherKey, sourceInfo)
+	}
+
 	// Create the DxFile and add to client
 	entry, err := client.fileSystem.NewDxFile(up.DxPath, storage.SysPath(up.Source), false, up.ErasureCode, cipherKey, uint64(sourceInfo.Size()), sourceInfo.Mode())
 
@@ -90,6 +108,13 @@ func (client *StorageClient) Upload(up storage.FileUploadParams) error {
 		return fmt.Errorf("source file size is 0, fileName: %s", sourceInfo.Name())
 	}
 
+	// Share the sectors of the segments already uploaded in dedup mode
+	if up.Dedup {
+		if err = client.dedupSegments(entry, up.Source, hashKey); err != nil {
+			return fmt.Errorf("could not deduplicate the segments, error: %v", err)
+		}
+	}
+
 	// Update the health of the DxFile directory recursively to ensure the health is updated with the new file
 	go client.fileSystem.InitAndUpdateDirMetadata(dirDxPath)
 
@@ -109,6 +109,10 @@ func (client *StorageClient) createUnfinishedSegments(entry *dxfile.FileSetEntry
 	if err != nil {
 		return nil, err
 	}
+
+	// the file is only uploaded to the storage hosts of the profile it is bound to
+	hosts = client.profileHosts(entry.DxPath(), hosts)
+
 	if len(client.workerPool) < int(ec.MinSectors()) {
 		client.log.Info("cannot create any segment from file because there are not enough workers, so marked all unhealthy segments as stuck")
 
@@ -175,6 +179,7 @@ func (client *StorageClient) createUnfinishedSegments(entry *dxfile.FileSetEntry
 
 			sectorSlotsStatus: make([]bool, ec.NumSectors()),
 			unusedHosts:       make(map[string]struct{}),
+			placement:         client.storageHostManager.NewPlacement(),
 		}
 
 		// Every Segment can have a different set of unused hosts.
@@ -206,6 +211,9 @@ func (client *StorageClient) createUnfinishedSegments(entry *dxfile.FileSetEntry
 					newUnfinishedSegments[i].sectorSlotsStatus[sectorIndex] = true
 					newUnfinishedSegments[i].sectorsCompletedNum++
 					delete(newUnfinishedSegments[i].unusedHosts, sector.HostID.String())
+					if info, ok := client.storageHostManager.RetrieveHostInfo(sector.HostID); ok {
+						newUnfinishedSegments[i].placement.Add(info)
+					}
 				} else if exists {
 					delete(newUnfinishedSegments[i].unusedHosts, sector.HostID.String())
 				}
@@ -263,6 +271,26 @@ func (client *StorageClient) createUnfinishedSegments(entry *dxfile.FileSetEntry
 	return incompleteSegments, nil
 }
 
+// profileHosts filters the hosts with the contracts of the profile the DxPath belongs to. The
+// hosts are returned directly if there is no storage profile
+func (client *StorageClient) profileHosts(dxPath storage.DxPath, hosts map[string]struct{}) map[string]struct{} {
+	if len(client.contractManager.Profiles()) == 0 {
+		return hosts
+	}
+
+	profile := client.contractManager.PathProfile(dxPath)
+	filtered := make(map[string]struct{})
+	for _, contract := range client.contractManager.GetStorageContractSet().RetrieveAllContractsMetaData() {
+		if client.contractManager.ContractProfile(contract.ID) != profile {
+			continue
+		}
+		if _, exists := hosts[contract.EnodeID.String()]; exists {
+			filtered[contract.EnodeID.String()] = struct{}{}
+		}
+	}
+	return filtered
+}
+
 // Select a dxfile randomly and then grab one segment randomly in this file
 func (client *StorageClient) createAndPushRandomSegment(files []*dxfile.FileSetEntryWithID, hosts map[string]struct{}, target uploadTarget, hostHealthInfoTable storage.HostHealthInfoTable) {
 	// Sanity check that there are files
@@ -566,6 +594,16 @@ func (client *StorageClient) uploadLoop() {
 			return
 		}
 
+		// Wait until the spending is below the hard cap of the budget
+		if client.contractManager.SpendingPaused() {
+			select {
+			case <-time.After(SpendingPausedCheckInterval):
+			case <-client.tm.StopChan():
+				return
+			}
+			continue
+		}
+
 		// Check whether a repair is needed of root dir. If the root dir health is more than
 		// RepairHealthThreshold, it is not necessary to upload any sectors
 		rootMetadata, err := client.dirMetadata(storage.RootDxPath())
@@ -14,6 +14,7 @@ import (
 
 	"github.com/DxChainNetwork/godx/storage"
 	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+	"github.com/DxChainNetwork/godx/storage/storageclient/storagehosttree"
 )
 
 // uploadSegmentID is a unique identifier for each segment in the storage client
@@ -65,6 +66,10 @@ type unfinishedUploadSegment struct {
 	unusedHosts         map[string]struct{} // hosts that aren't yet storing any sectors or performing any work
 	workersRemain       int                 // number of inactive workers still able to upload a sector
 	workerBackups       []*worker           // workers that can be used if other workers fail
+
+	// placement tracks the hosts storing the sectors against the placement constraints,
+	// nil if there is no placement constraint
+	placement *storagehosttree.Placement
 }
 
 // notifyBackupWorkers is called when a worker fails to upload a sector, meaning
@@ -68,6 +68,12 @@ func (client *StorageClient) GetStorageHostSetting(hostEnodeID enode.ID, hostEno
 	return client.ethBackend.GetStorageHostSetting(hostEnodeID, hostEnodeURL, config)
 }
 
+// ProbeStorageHost will be used to measure the round trip time and throughput of the
+// storage host, returning the time elapsed for the probe
+func (client *StorageClient) ProbeStorageHost(hostEnodeURL string, req storage.HostProbeRequest) (time.Duration, error) {
+	return client.ethBackend.ProbeStorageHost(hostEnodeURL, req)
+}
+
 // SubscribeChainChangeEvent will be used to get block information every time a change happened
 // in the blockchain
 func (client *StorageClient) SubscribeChainChangeEvent(ch chan<- core.ChainChangeEvent) event.Subscription {
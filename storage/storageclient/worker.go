@@ -26,6 +26,10 @@ var (
 	// ErrContractRenewing is used when client and host is renewing contract
 	// the worker will return directly
 	ErrContractRenewing = errors.New("client and host is renewing contract")
+
+	// ErrSpendingCapReached is used when the spending reached the hard cap of the budget,
+	// the uploads and downloads are paused
+	ErrSpendingCapReached = errors.New("spending reached the hard cap of the budget, uploads and downloads are paused")
 )
 
 // Listen for a work on a certain host.
@@ -42,6 +46,11 @@ type worker struct {
 	// the time that last failure
 	ownedDownloadRecentFailure time.Time
 
+	// the moving average of the sector download latency and throughput observed
+	downloadLatency    time.Duration
+	downloadThroughput float64
+	downloadSamples    uint64
+
 	// Notifications of new download work. Takes priority over uploads.
 	downloadChan chan struct{}
 
@@ -261,13 +270,23 @@ func (w *worker) download(uds *unfinishedDownloadSegment) error {
 	fetchOffset, fetchLength := 0, storage.SectorSize
 	root := uds.segmentMap[w.hostID.String()].root
 
-	// call rpc request the data from host, if get error, unregister the worker.
-	sectorData, err := w.client.Download(sp, root, uint32(fetchOffset), uint32(fetchLength), hostInfo)
+	// call rpc request the data from host, if get error, unregister the worker. The canceled
+	// request is not a failure of the worker
+	start := time.Now()
+	sectorData, err := w.client.Download(sp, root, uint32(fetchOffset), uint32(fetchLength), uds.cancel, hostInfo)
+	if err == errDownloadCanceled {
+		uds.unregisterWorker(w)
+		return nil
+	}
 	if err != nil {
 		w.client.log.Error("worker failed to download sector", "error", err)
 		uds.unregisterWorker(w)
 		return err
 	}
+	w.recordDownload(uint64(len(sectorData)), time.Since(start))
+
+	// pace the download requests sent to the host
+	<-time.After(downloadRequestInterval)
 
 	// decrypt the sector
 	key := uds.clientFile.CipherKey()
@@ -311,6 +330,10 @@ func (w *worker) processDownloadSegment(uds *unfinishedDownloadSegment) *unfinis
 
 	sectorCompleted := uds.completedSectors[sectorData.index]
 
+	// the reservation for the worker ends once the worker processes the segment
+	_, preferred := uds.preferredWorkers[w]
+	delete(uds.preferredWorkers, w)
+
 	// if the given segment downloading complete/fail, or no sector associated with host for downloading,
 	// or the sector has completed, the worker should be removed.
 	if segmentComplete || segmentFailed || w.onDownloadCooldown() || !workerHasSector || sectorCompleted {
@@ -322,8 +345,12 @@ func (w *worker) processDownloadSegment(uds *unfinishedDownloadSegment) *unfinis
 
 	// if need more sector, and the sector has not been fetched yet,
 	// should register the worker and return the segment for downloading.
+	// The sectors reserved for the fastest workers are not taken by the other workers.
 	sectorTaken := uds.sectorUsage[sectorData.index]
 	sectorsInProgress := uds.sectorsRegistered + uds.sectorsCompleted
+	if !preferred {
+		sectorsInProgress += uint32(len(uds.preferredWorkers))
+	}
 	desiredSectorsInProgress := uds.erasureCode.MinSectors() + uds.overdrive
 	workersDesired := sectorsInProgress < desiredSectorsInProgress && !sectorTaken
 	if workersDesired {
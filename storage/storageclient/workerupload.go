@@ -168,6 +168,9 @@ func (w *worker) preProcessUploadSegment(uc *unfinishedUploadSegment) (*unfinish
 	onCoolDown := w.onUploadCoolDown()
 	w.mu.Unlock()
 
+	// retrieve the host info for the placement constraints before locking the segment
+	hostInfo, hostExist := w.client.storageHostManager.RetrieveHostInfo(w.contract.EnodeID)
+
 	// Determine what sort of help this segment needs
 	// uc.mu condition race, low performance
 	uc.mu.Lock()
@@ -175,12 +178,18 @@ func (w *worker) preProcessUploadSegment(uc *unfinishedUploadSegment) (*unfinish
 	isComplete := uc.sectorsAllNeedNum <= uc.sectorsCompletedNum
 	isNeedUpload := uc.sectorsAllNeedNum > uc.sectorsCompletedNum+uc.sectorsUploadingNum
 
+	// The host shall not violate the placement constraints of the segment. The host is
+	// also dropped if it is not preferred while the other unused hosts might be preferred
+	remaining := uc.sectorsAllNeedNum - uc.sectorsCompletedNum - uc.sectorsUploadingNum
+	placementAllowed := !hostExist || !uc.placement.Violates(hostInfo) &&
+		(uc.placement.Preferred(hostInfo, remaining) || uc.workersRemain <= remaining)
+
 	// If the segment does not need help from this worker, release the segment
-	if isComplete || !candidateHost || !uploadAbility || onCoolDown {
+	if isComplete || !candidateHost || !uploadAbility || onCoolDown || !placementAllowed {
 		// This worker no longer needs to track this segment
 		uc.mu.Unlock()
 		w.dropSegment(uc)
-		w.client.log.Info("Worker will drop a segment due to it's status: complete/notCandidate/uploadInAbility/onCoolDown")
+		w.client.log.Info("Worker will drop a segment due to it's status: complete/notCandidate/uploadInAbility/onCoolDown/placement")
 		return nil, 0
 	}
 
@@ -210,6 +219,9 @@ func (w *worker) preProcessUploadSegment(uc *unfinishedUploadSegment) (*unfinish
 	}
 
 	delete(uc.unusedHosts, w.contract.EnodeID.String())
+	if hostExist {
+		uc.placement.Add(hostInfo)
+	}
 	uc.sectorsUploadingNum++
 	uc.workersRemain--
 	uc.mu.Unlock()
@@ -228,10 +240,14 @@ func (w *worker) uploadFailed(uc *unfinishedUploadSegment, sectorIndex uint64) {
 	}
 
 	// Unregister the sector from the segment and hunt for a replacement
+	hostInfo, hostExist := w.client.storageHostManager.RetrieveHostInfo(w.contract.EnodeID)
 	uc.mu.Lock()
 	uc.workersRemain--
 	uc.sectorsUploadingNum--
 	uc.sectorSlotsStatus[sectorIndex] = false
+	if hostExist {
+		uc.placement.Remove(hostInfo)
+	}
 	uc.mu.Unlock()
 
 	// Clean up this segment, we may notify backup workers of segment to help upload
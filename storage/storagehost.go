@@ -5,6 +5,8 @@
 package storage
 
 import (
+	"net"
+
 	"github.com/DxChainNetwork/godx/accounts"
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/core"
@@ -24,6 +26,10 @@ type HostBackend interface {
 	AccountManager() *accounts.Manager
 	SetStatic(node *enode.Node)
 	CheckAndUpdateConnection(peerNode *enode.Node)
+	GetStorageHostSetting(hostEnodeID enode.ID, hostEnodeURL string, config *HostExtConfig) error
+	SelfEnodeURL() string
+	SignWithNodeSk(hash []byte) ([]byte, error)
+	ExternalIP() (net.IP, error)
 }
 
 // AccountManager is the interface for account.Manager to be used in storage host module
@@ -0,0 +1,350 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/DxChainNetwork/godx/accounts"
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/common/unit"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/internal/ethapi"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+type (
+	// AnnounceConfig is the configuration of the host announcement. AltAddresses are the
+	// additional addresses, in the format of "ip" or "ip:port", announced along with the node
+	// address. If AutoAnnounce is enabled, the host is re-announced once the external IP
+	// changes, at most once every AnnounceInterval blocks. If KeyLifetime is not zero, the
+	// announcement is signed by an announcement key certified by the node key. The
+	// announcement keys are the KeyAccounts held by the account manager, and the key is
+	// rotated to the next account every KeyLifetime blocks
+	AnnounceConfig struct {
+		AltAddresses     []string         `json:"altAddresses"`
+		AutoAnnounce     bool             `json:"autoAnnounce"`
+		AnnounceInterval uint64           `json:"announceInterval"`
+		KeyLifetime      uint64           `json:"keyLifetime"`
+		KeyAccounts      []common.Address `json:"keyAccounts"`
+	}
+
+	// AnnounceConfigForDisplay is the announce config for display
+	AnnounceConfigForDisplay struct {
+		AltAddresses       []string `json:"altAddresses"`
+		AutoAnnounce       bool     `json:"autoAnnounce"`
+		AnnounceInterval   string   `json:"announceInterval"`
+		KeyLifetime        string   `json:"keyLifetime"`
+		KeyAccounts        []string `json:"keyAccounts"`
+		AnnouncementKey    string   `json:"announcementKey"`
+		KeyExpiry          uint64   `json:"keyExpiry"`
+		LastAnnounceHeight uint64   `json:"lastAnnounceHeight"`
+		AnnouncedIP        string   `json:"announcedIP"`
+	}
+
+	// announceState is the persisted state of the host announcement
+	announceState struct {
+		LastAnnounceHeight uint64                     `json:"lastAnnounceHeight"`
+		AnnouncedIP        string                     `json:"announcedIP"`
+		KeyCert            *types.AnnouncementKeyCert `json:"keyCert"`
+	}
+)
+
+// validate check whether the announce config is valid
+func (config AnnounceConfig) validate() error {
+	if config.AutoAnnounce && config.AnnounceInterval == 0 {
+		return errZeroAnnounceInterval
+	}
+	if config.KeyLifetime != 0 && len(config.KeyAccounts) == 0 {
+		return errNoAnnounceKeyAccount
+	}
+	for _, addr := range config.AltAddresses {
+		if _, _, err := parseAltAddress(addr, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseAltAddress parses the alternative address in the format of "ip" or "ip:port". If the
+// port is not specified, the default port is returned
+func parseAltAddress(addr string, defaultPort int) (net.IP, int, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		// no port specified
+		host, portStr = strings.Trim(addr, "[]"), ""
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil, 0, fmt.Errorf("invalid alternative address: %v", addr)
+	}
+	if portStr == "" {
+		return ip, defaultPort, nil
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid port of alternative address %v: %v", addr, err)
+	}
+	return ip, int(port), nil
+}
+
+// buildAnnouncement builds the unsigned host announcement. The primary address uses the
+// external IP of the node, and the alternative addresses use the same node ID
+func (h *StorageHost) buildAnnouncement(externalIP net.IP, altAddresses []string) (types.HostAnnouncement, error) {
+	self, err := enode.ParseV4(h.ethBackend.SelfEnodeURL())
+	if err != nil {
+		return types.HostAnnouncement{}, fmt.Errorf("invalid local node url: %v", err)
+	}
+	ip := self.IP()
+	if externalIP != nil && !externalIP.IsUnspecified() {
+		ip = externalIP
+	}
+	ha := types.HostAnnouncement{
+		NetAddress: enode.NewV4(self.Pubkey(), ip, self.TCP(), self.UDP()).String(),
+	}
+	for _, addr := range altAddresses {
+		altIP, port, err := parseAltAddress(addr, self.TCP())
+		if err != nil {
+			return types.HostAnnouncement{}, err
+		}
+		ha.AltAddresses = append(ha.AltAddresses, enode.NewV4(self.Pubkey(), altIP, port, port).String())
+	}
+	return ha, nil
+}
+
+// signAnnouncement signs the host announcement. If the announcement is extended, and the key
+// lifetime is configured, the announcement is signed by the announcement key, which is rotated
+// if expiring. Otherwise the announcement is signed by the node key
+// Require: lock the storageHost by caller
+func (h *StorageHost) signAnnouncement(ha *types.HostAnnouncement, extended bool) error {
+	if !extended || h.announceConfig.KeyLifetime == 0 {
+		sig, err := h.ethBackend.SignWithNodeSk(ha.RLPHash().Bytes())
+		if err != nil {
+			return err
+		}
+		ha.Signature = sig
+		return nil
+	}
+	if h.announceKeyExpiring() || !h.isAnnounceKeyAccount(h.announceState.KeyCert.KeyAddress) {
+		if _, err := h.rotateAnnouncementKey(); err != nil {
+			return err
+		}
+	}
+	ha.KeyCert = h.announceState.KeyCert
+	sig, err := h.signWithAccount(ha.KeyCert.KeyAddress, ha.RLPHash().Bytes())
+	if err != nil {
+		return err
+	}
+	ha.Signature = sig
+	return nil
+}
+
+// announceKeyExpiring returns whether the announcement key cert is missing, or expires
+// before the next announcement could be made
+// Require: lock the storageHost by caller
+func (h *StorageHost) announceKeyExpiring() bool {
+	cert := h.announceState.KeyCert
+	return cert == nil || cert.Expiry <= h.blockHeight+h.announceConfig.AnnounceInterval
+}
+
+// isAnnounceKeyAccount returns whether the address is one of the announcement key accounts
+// Require: lock the storageHost by caller
+func (h *StorageHost) isAnnounceKeyAccount(address common.Address) bool {
+	for _, addr := range h.announceConfig.KeyAccounts {
+		if addr == address {
+			return true
+		}
+	}
+	return false
+}
+
+// nextAnnounceKeyAccount returns the announcement key account following the one currently
+// certified, in the order of the configured key accounts
+// Require: lock the storageHost by caller
+func (h *StorageHost) nextAnnounceKeyAccount() (common.Address, error) {
+	keyAccounts := h.announceConfig.KeyAccounts
+	if len(keyAccounts) == 0 {
+		return common.Address{}, errNoAnnounceKeyAccount
+	}
+	if cert := h.announceState.KeyCert; cert != nil {
+		for i, addr := range keyAccounts {
+			if addr == cert.KeyAddress {
+				return keyAccounts[(i+1)%len(keyAccounts)], nil
+			}
+		}
+	}
+	return keyAccounts[0], nil
+}
+
+// rotateAnnouncementKey certifies the next announcement key account with the node key. The
+// key is kept in the account manager, and never leaves it
+// Require: lock the storageHost by caller
+func (h *StorageHost) rotateAnnouncementKey() (common.Address, error) {
+	address, err := h.nextAnnounceKeyAccount()
+	if err != nil {
+		return common.Address{}, err
+	}
+	if _, err = h.am.Find(accounts.Account{Address: address}); err != nil {
+		return common.Address{}, fmt.Errorf("announcement key account %v not found: %v", address.Hex(), err)
+	}
+	lifetime := h.announceConfig.KeyLifetime
+	if lifetime == 0 {
+		lifetime = defaultAnnounceKeyLifetime
+	}
+	cert := &types.AnnouncementKeyCert{
+		KeyAddress: address,
+		Expiry:     h.blockHeight + lifetime,
+	}
+	if cert.Signature, err = h.ethBackend.SignWithNodeSk(cert.RLPHash().Bytes()); err != nil {
+		return common.Address{}, err
+	}
+	h.announceState.KeyCert = cert
+	h.log.Info("Announcement key rotated", "address", cert.KeyAddress, "expiry", cert.Expiry)
+	return address, h.syncConfig()
+}
+
+// signWithAccount signs the hash with the account held by the account manager
+func (h *StorageHost) signWithAccount(address common.Address, hash []byte) ([]byte, error) {
+	account := accounts.Account{Address: address}
+	wallet, err := h.am.Find(account)
+	if err != nil {
+		return nil, err
+	}
+	return wallet.SignHash(account, hash)
+}
+
+// announce sends the host announcement transaction, and records the currently announced
+// prices for the pricing engine
+func (h *StorageHost) announce() (common.Hash, error) {
+	address, err := h.getPaymentAddress()
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("cannot get the payment address: %v", err)
+	}
+	externalIP, err := h.ethBackend.ExternalIP()
+	if err != nil {
+		h.log.Warn("Failed to get the external IP, announce with the local node IP", "err", err)
+		externalIP = nil
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	// the alternative addresses and the announcement key are only accepted since the storage
+	// fork extending the host announcements
+	fork := h.ethBackend.GetBlockChain().Config().StorageFork(new(big.Int).SetUint64(h.blockHeight + 1))
+	extended := fork != nil && fork.ExtendedAnnouncements
+	altAddresses := h.announceConfig.AltAddresses
+	if !extended && (len(altAddresses) != 0 || h.announceConfig.KeyLifetime != 0) {
+		h.log.Warn("Extended host announcement is not active, announce with the node address and key only")
+		altAddresses = nil
+	}
+
+	ha, err := h.buildAnnouncement(externalIP, altAddresses)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err = h.signAnnouncement(&ha, extended); err != nil {
+		return common.Hash{}, fmt.Errorf("cannot sign the host announcement: %v", err)
+	}
+	payload, err := rlp.EncodeToBytes(ha)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	// the announcement queued for offline signing is recorded as announced, so that it is
+	// not queued again, and ErrStorageTxQueued is returned to report the status
+	hash, err := h.parseAPI.StorageTx.SendSignedHostAnnounceTX(address, payload)
+	if err != nil && err != ethapi.ErrStorageTxQueued {
+		return common.Hash{}, fmt.Errorf("cannot send the announce transaction: %v", err)
+	}
+	h.pricingState.AnnouncedPrices = pricesFromConfig(h.config)
+	h.announceState.LastAnnounceHeight = h.blockHeight
+	if externalIP != nil {
+		h.announceState.AnnouncedIP = externalIP.String()
+	}
+	if syncErr := h.syncConfig(); syncErr != nil {
+		return common.Hash{}, syncErr
+	}
+	return hash, err
+}
+
+// checkAutoAnnounce re-announces the host if the external IP has changed, or the announcement
+// key is expiring. The announcement is made at most once every AnnounceInterval blocks
+func (h *StorageHost) checkAutoAnnounce() {
+	h.lock.RLock()
+	config, state, blockHeight := h.announceConfig, h.announceState, h.blockHeight
+	keyExpiring := config.KeyLifetime != 0 && h.announceKeyExpiring()
+	h.lock.RUnlock()
+
+	if !config.AutoAnnounce || state.LastAnnounceHeight == 0 || blockHeight < state.LastAnnounceHeight+config.AnnounceInterval {
+		return
+	}
+	// the previous check is still in progress
+	if !h.announceLock.TryLock() {
+		return
+	}
+	go func() {
+		defer h.announceLock.Unlock()
+		if err := h.tm.Add(); err != nil {
+			return
+		}
+		defer h.tm.Done()
+
+		// querying the external IP through the NAT port mapper could take a while
+		ip, err := h.ethBackend.ExternalIP()
+		if err != nil {
+			h.log.Warn("Failed to get the external IP", "err", err)
+			return
+		}
+		if !keyExpiring && (ip == nil || ip.String() == state.AnnouncedIP) {
+			return
+		}
+		h.log.Info("Re-announce the host", "ip", ip, "announcedIP", state.AnnouncedIP, "keyExpiring", keyExpiring)
+		if _, err := h.announce(); err != nil {
+			h.log.Warn("Failed to re-announce the host", "err", err)
+		}
+	}()
+}
+
+// getAnnounceConfig returns the announce config and state of the host
+func (h *StorageHost) getAnnounceConfig() (AnnounceConfig, announceState) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.announceConfig, h.announceState
+}
+
+// formatAnnounceConfig format the announce config and state for display
+func formatAnnounceConfig(config AnnounceConfig, state announceState) AnnounceConfigForDisplay {
+	display := AnnounceConfigForDisplay{
+		AltAddresses:       config.AltAddresses,
+		AutoAnnounce:       config.AutoAnnounce,
+		AnnounceInterval:   unit.FormatTime(config.AnnounceInterval),
+		KeyLifetime:        "disabled",
+		LastAnnounceHeight: state.LastAnnounceHeight,
+		AnnouncedIP:        state.AnnouncedIP,
+	}
+	if config.KeyLifetime != 0 {
+		display.KeyLifetime = unit.FormatTime(config.KeyLifetime)
+	}
+	for _, addr := range config.KeyAccounts {
+		display.KeyAccounts = append(display.KeyAccounts, addr.Hex())
+	}
+	if state.KeyCert != nil {
+		display.AnnouncementKey = state.KeyCert.KeyAddress.Hex()
+		display.KeyExpiry = state.KeyCert.Expiry
+	}
+	return display
+}
+
+// clearAnnouncementKey clears the announcement key cert, used when the host switches back
+// to signing the announcement with the node key
+// Require: lock the storageHost by caller
+func (h *StorageHost) clearAnnouncementKey() {
+	h.announceState.KeyCert = nil
+}
@@ -0,0 +1,189 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"crypto/ecdsa"
+	"net"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/accounts"
+	"github.com/DxChainNetwork/godx/accounts/keystore"
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// announceTestBackend is the host backend signing with the node key
+type announceTestBackend struct {
+	storage.HostBackend
+	nodeKey *ecdsa.PrivateKey
+}
+
+func (b *announceTestBackend) SelfEnodeURL() string {
+	return enode.NewV4(&b.nodeKey.PublicKey, net.IP{127, 0, 0, 1}, 30303, 30303).String()
+}
+
+func (b *announceTestBackend) SignWithNodeSk(hash []byte) ([]byte, error) {
+	return crypto.Sign(hash, b.nodeKey)
+}
+
+func TestParseAltAddress(t *testing.T) {
+	tests := []struct {
+		addr  string
+		ip    net.IP
+		port  int
+		valid bool
+	}{
+		{"10.0.0.1", net.IP{10, 0, 0, 1}, 30303, true},
+		{"10.0.0.1:8888", net.IP{10, 0, 0, 1}, 8888, true},
+		{"[::1]:8888", net.ParseIP("::1"), 8888, true},
+		{"example.com:8888", nil, 0, false},
+		{"10.0.0.1:port", nil, 0, false},
+	}
+	for _, test := range tests {
+		ip, port, err := parseAltAddress(test.addr, 30303)
+		if (err == nil) != test.valid {
+			t.Errorf("%v: unexpected error %v", test.addr, err)
+			continue
+		}
+		if test.valid && (!ip.Equal(test.ip) || port != test.port) {
+			t.Errorf("%v: expect %v:%v, got %v:%v", test.addr, test.ip, test.port, ip, port)
+		}
+	}
+}
+
+func TestStorageHost_SignAnnouncement(t *testing.T) {
+	h := newTestStorageHost(t)
+	nodeKey, _ := crypto.GenerateKey()
+	h.ethBackend = &announceTestBackend{nodeKey: nodeKey}
+	h.blockHeight = 10
+
+	ha, err := h.buildAnnouncement(net.IP{1, 2, 3, 4}, []string{"10.0.0.1:8888"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node, _ := enode.ParseV4(ha.NetAddress); !node.IP().Equal(net.IP{1, 2, 3, 4}) {
+		t.Errorf("external ip not announced: %v", ha.NetAddress)
+	}
+	if len(ha.AltAddresses) != 1 {
+		t.Fatalf("alternative addresses not announced: %v", ha.AltAddresses)
+	}
+
+	// signed by the node key
+	if err = h.signAnnouncement(&ha, true); err != nil {
+		t.Fatal(err)
+	}
+	if err = vm.CheckMultiSignatures(ha, [][]byte{ha.Signature}); err != nil {
+		t.Errorf("announcement signed by node key not valid: %v", err)
+	}
+
+	// the announcement keys are the accounts of the account manager
+	ks := keystore.NewKeyStore(filepath.Join(h.persistDir, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+	var keyAccounts []common.Address
+	for i := 0; i < 2; i++ {
+		account, err := ks.NewAccount("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = ks.Unlock(account, ""); err != nil {
+			t.Fatal(err)
+		}
+		keyAccounts = append(keyAccounts, account.Address)
+	}
+	h.am = accounts.NewManager(ks)
+
+	// signed by the announcement key
+	h.announceConfig.KeyLifetime = 100
+	h.announceConfig.AnnounceInterval = 10
+	h.announceConfig.KeyAccounts = keyAccounts
+	ha.Signature = nil
+	if err = h.signAnnouncement(&ha, true); err != nil {
+		t.Fatal(err)
+	}
+	if ha.KeyCert == nil || ha.KeyCert.Expiry != 110 || ha.KeyCert.KeyAddress != keyAccounts[0] {
+		t.Fatalf("key cert not expected: %+v", ha.KeyCert)
+	}
+	if err = vm.CheckMultiSignatures(ha, [][]byte{ha.Signature}); err != nil {
+		t.Errorf("announcement signed by announcement key not valid: %v", err)
+	}
+
+	// the key is reused until it is expiring, then rotated to the next account
+	if err = h.signAnnouncement(&ha, true); err != nil {
+		t.Fatal(err)
+	}
+	if ha.KeyCert.KeyAddress != keyAccounts[0] {
+		t.Error("announcement key rotated before expiring")
+	}
+	h.blockHeight = 110 - h.announceConfig.AnnounceInterval
+	if err = h.signAnnouncement(&ha, true); err != nil {
+		t.Fatal(err)
+	}
+	if ha.KeyCert.KeyAddress != keyAccounts[1] {
+		t.Error("expiring announcement key not rotated to the next account")
+	}
+	if err = vm.CheckMultiSignatures(ha, [][]byte{ha.Signature}); err != nil {
+		t.Errorf("announcement signed by rotated announcement key not valid: %v", err)
+	}
+
+	// signed by the node key before the extended announcement is active
+	legacy, err := h.buildAnnouncement(net.IP{1, 2, 3, 4}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = h.signAnnouncement(&legacy, false); err != nil {
+		t.Fatal(err)
+	}
+	if legacy.Extended() {
+		t.Errorf("extended announcement signed before the storage fork: %+v", legacy)
+	}
+	if err = vm.CheckMultiSignatures(legacy, [][]byte{legacy.Signature}); err != nil {
+		t.Errorf("announcement signed by node key not valid: %v", err)
+	}
+}
+
+func TestHostPrivateAPI_SetAnnounceConfig(t *testing.T) {
+	h := newTestStorageHost(t)
+	api := NewHostPrivateAPI(h)
+
+	if _, err := api.SetAnnounceConfig(map[string]string{
+		"altAddresses":     "10.0.0.1, 10.0.0.2:8888",
+		"autoAnnounce":     "true",
+		"announceInterval": "2h",
+		"keyLifetime":      "7d",
+		"keyAccounts":      "0x0000000000000000000000000000000000000001",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	config, _ := h.getAnnounceConfig()
+	expect := AnnounceConfig{
+		AltAddresses:     []string{"10.0.0.1", "10.0.0.2:8888"},
+		AutoAnnounce:     true,
+		AnnounceInterval: 2 * storage.BlockPerHour,
+		KeyLifetime:      7 * storage.BlocksPerDay,
+		KeyAccounts:      []common.Address{common.HexToAddress("0x01")},
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("announce config not expected: %+v", config)
+	}
+
+	// invalid config shall not change the config
+	if _, err := api.SetAnnounceConfig(map[string]string{"altAddresses": "localhost"}); err == nil {
+		t.Error("invalid alternative address should give error")
+	}
+	if _, err := api.SetAnnounceConfig(map[string]string{"announceInterval": "0b"}); err != errZeroAnnounceInterval {
+		t.Errorf("expect error %v, got %v", errZeroAnnounceInterval, err)
+	}
+	if _, err := api.SetAnnounceConfig(map[string]string{"keyAccounts": ""}); err != errNoAnnounceKeyAccount {
+		t.Errorf("expect error %v, got %v", errNoAnnounceKeyAccount, err)
+	}
+	if config, _ = h.getAnnounceConfig(); !reflect.DeepEqual(config, expect) {
+		t.Errorf("announce config changed on error: %+v", config)
+	}
+}
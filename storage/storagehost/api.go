@@ -5,12 +5,18 @@
 package storagehost
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/DxChainNetwork/godx/accounts"
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/common/unit"
+	"github.com/DxChainNetwork/godx/rpc"
 	"github.com/DxChainNetwork/godx/storage"
 )
 
@@ -47,13 +53,9 @@ func (h *HostPrivateAPI) Announce() string {
 	if err := h.storageHost.setAcceptContracts(true); err != nil {
 		return fmt.Sprintf("cannot set AcceptingContracts: %v", err)
 	}
-	address, err := h.storageHost.getPaymentAddress()
+	hash, err := h.storageHost.announce()
 	if err != nil {
-		return fmt.Sprintf("cannot get the payment address: %v", err)
-	}
-	hash, err := h.storageHost.parseAPI.StorageTx.SendHostAnnounceTX(address)
-	if err != nil {
-		return fmt.Sprintf("cannot send the announce transaction: %v", err)
+		return err.Error()
 	}
 	return fmt.Sprintf("Announcement transaction: %v", hash.Hex())
 }
@@ -116,6 +118,40 @@ func (h *HostPrivateAPI) GetFinancialMetrics() HostFinancialMetricsForDisplay {
 	return display
 }
 
+// GetClientUsage get the usage of the host aggregated by the client payment address for
+// the contracts active in the block window [start, end]. Zero end means no upper bound
+func (h *HostPrivateAPI) GetClientUsage(start, end uint64) ([]ClientUsage, error) {
+	if end != 0 && start > end {
+		return nil, errInvalidUsageWindow
+	}
+	return h.storageHost.clientUsages(start, end)
+}
+
+// ExportClientUsage export the client usage report in the block window [start, end] in the
+// given format. The supported formats are json and csv
+func (h *HostPrivateAPI) ExportClientUsage(start, end uint64, format string) (string, error) {
+	usages, err := h.GetClientUsage(start, end)
+	if err != nil {
+		return "", err
+	}
+	switch strings.ToLower(format) {
+	case "json":
+		b, err := json.MarshalIndent(usages, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	case "csv":
+		var buf bytes.Buffer
+		if err := WriteClientUsageCSV(&buf, usages); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	default:
+		return "", fmt.Errorf("unsupported export format: %v", format)
+	}
+}
+
 //GetPaymentAddress get the account address used to sign the storage contract. If not configured, the first address in the local wallet will be used as the paymentAddress by default.
 func (h *HostPrivateAPI) GetPaymentAddress() string {
 	addr, err := h.storageHost.getPaymentAddress()
@@ -366,3 +402,496 @@ func (h *HostPrivateAPI) setUploadBandwidthPrice(str string) error {
 	h.storageHost.config.UploadBandwidthPrice = wei
 	return nil
 }
+
+// GetPricingConfig return the config and state of the automatic pricing engine
+func (h *HostPrivateAPI) GetPricingConfig() PricingConfigForDisplay {
+	config, state := h.storageHost.getPricingConfig()
+	return PricingConfigForDisplay{
+		AutoPricing:               unit.FormatBool(config.AutoPricing),
+		UpdateInterval:            unit.FormatTime(config.UpdateInterval),
+		AnnounceThreshold:         strconv.FormatFloat(config.AnnounceThreshold*100, 'f', 2, 64) + "%",
+		MinStoragePrice:           unit.FormatCurrency(config.MinStoragePrice, "/byte/block"),
+		MaxStoragePrice:           unit.FormatCurrency(config.MaxStoragePrice, "/byte/block"),
+		MinUploadBandwidthPrice:   unit.FormatCurrency(config.MinUploadBandwidthPrice, "/byte"),
+		MaxUploadBandwidthPrice:   unit.FormatCurrency(config.MaxUploadBandwidthPrice, "/byte"),
+		MinDownloadBandwidthPrice: unit.FormatCurrency(config.MinDownloadBandwidthPrice, "/byte"),
+		MaxDownloadBandwidthPrice: unit.FormatCurrency(config.MaxDownloadBandwidthPrice, "/byte"),
+		MinContractPrice:          unit.FormatCurrency(config.MinContractPrice, "/contract"),
+		MaxContractPrice:          unit.FormatCurrency(config.MaxContractPrice, "/contract"),
+		LastUpdateHeight:          state.LastUpdateHeight,
+		KnownHosts:                len(state.AnnouncedHosts),
+	}
+}
+
+// pricingSetterCallbacks is the mapping from the pricing field name to the setter function
+var pricingSetterCallbacks = map[string]func(*PricingConfig, string) error{
+	"autoPricing":               setAutoPricing,
+	"updateInterval":            setPricingUpdateInterval,
+	"announceThreshold":         setAnnounceThreshold,
+	"minStoragePrice":           pricingCurrencySetter(func(c *PricingConfig) *common.BigInt { return &c.MinStoragePrice }),
+	"maxStoragePrice":           pricingCurrencySetter(func(c *PricingConfig) *common.BigInt { return &c.MaxStoragePrice }),
+	"minUploadBandwidthPrice":   pricingCurrencySetter(func(c *PricingConfig) *common.BigInt { return &c.MinUploadBandwidthPrice }),
+	"maxUploadBandwidthPrice":   pricingCurrencySetter(func(c *PricingConfig) *common.BigInt { return &c.MaxUploadBandwidthPrice }),
+	"minDownloadBandwidthPrice": pricingCurrencySetter(func(c *PricingConfig) *common.BigInt { return &c.MinDownloadBandwidthPrice }),
+	"maxDownloadBandwidthPrice": pricingCurrencySetter(func(c *PricingConfig) *common.BigInt { return &c.MaxDownloadBandwidthPrice }),
+	"minContractPrice":          pricingCurrencySetter(func(c *PricingConfig) *common.BigInt { return &c.MinContractPrice }),
+	"maxContractPrice":          pricingCurrencySetter(func(c *PricingConfig) *common.BigInt { return &c.MaxContractPrice }),
+}
+
+// SetPricingConfig set the pricing config specified by a mapping of key value pair
+func (h *HostPrivateAPI) SetPricingConfig(config map[string]string) (string, error) {
+	h.storageHost.lock.Lock()
+	defer h.storageHost.lock.Unlock()
+
+	// apply the changes to a copy, so that the config is not changed on error
+	newConfig := h.storageHost.pricingConfig
+	for key, value := range config {
+		callback, exist := pricingSetterCallbacks[key]
+		if !exist {
+			return "", fmt.Errorf("unknown pricing config variable: %v", key)
+		}
+		if err := callback(&newConfig, value); err != nil {
+			return "", err
+		}
+	}
+	if err := newConfig.validate(); err != nil {
+		return "", err
+	}
+	h.storageHost.pricingConfig = newConfig
+	// sync the config
+	if err := h.storageHost.syncConfig(); err != nil {
+		return "", err
+	}
+	return "Successfully set the host pricing config", nil
+}
+
+// setAutoPricing set AutoPricing to val specified by valStr
+func setAutoPricing(config *PricingConfig, valStr string) error {
+	val, err := unit.ParseBool(valStr)
+	if err != nil {
+		return fmt.Errorf("invalid bool string: %v", err)
+	}
+	config.AutoPricing = val
+	return nil
+}
+
+// setPricingUpdateInterval set UpdateInterval to value
+func setPricingUpdateInterval(config *PricingConfig, str string) error {
+	val, err := unit.ParseTime(str)
+	if err != nil {
+		return fmt.Errorf("invalid time string: %v", err)
+	}
+	config.UpdateInterval = val
+	return nil
+}
+
+// setAnnounceThreshold set AnnounceThreshold to value. The value is a percentage
+// such as "10%", or a ratio such as "0.1"
+func setAnnounceThreshold(config *PricingConfig, str string) error {
+	str = strings.TrimSpace(str)
+	factor := 1.0
+	if strings.HasSuffix(str, "%") {
+		str = strings.TrimSuffix(str, "%")
+		factor = 0.01
+	}
+	val, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return fmt.Errorf("invalid threshold string: %v", err)
+	}
+	config.AnnounceThreshold = val * factor
+	return nil
+}
+
+// pricingCurrencySetter returns the setter of the price bound specified by field
+func pricingCurrencySetter(field func(*PricingConfig) *common.BigInt) func(*PricingConfig, string) error {
+	return func(config *PricingConfig, str string) error {
+		wei, err := unit.ParseCurrency(str)
+		if err != nil {
+			return fmt.Errorf("invalid currency expression: %v", err)
+		}
+		*field(config) = wei
+		return nil
+	}
+}
+
+// GetContractPolicy return the policy of the host for accepting storage contracts
+func (h *HostPrivateAPI) GetContractPolicy() ContractPolicyForDisplay {
+	policy := h.storageHost.getContractPolicy()
+	return ContractPolicyForDisplay{
+		ClientAllowlist:        policy.ClientAllowlist,
+		ClientDenylist:         policy.ClientDenylist,
+		MinContractDuration:    unit.FormatTime(policy.MinContractDuration),
+		MinContractFund:        unit.FormatCurrency(policy.MinContractFund),
+		MaxStoragePerClient:    unit.FormatStorage(policy.MaxStoragePerClient, false),
+		MaxConcurrentContracts: policy.MaxConcurrentContracts,
+	}
+}
+
+// policySetterCallbacks is the mapping from the policy field name to the setter function
+var policySetterCallbacks = map[string]func(*ContractPolicy, string) error{
+	"minContractDuration":    setMinContractDuration,
+	"minContractFund":        setMinContractFund,
+	"maxStoragePerClient":    setMaxStoragePerClient,
+	"maxConcurrentContracts": setMaxConcurrentContracts,
+}
+
+// SetContractPolicy set the contract policy specified by a mapping of key value pair
+func (h *HostPrivateAPI) SetContractPolicy(policy map[string]string) (string, error) {
+	h.storageHost.lock.Lock()
+	defer h.storageHost.lock.Unlock()
+
+	// apply the changes to a copy, so that the policy is not changed on error
+	newPolicy := h.storageHost.contractPolicy
+	for key, value := range policy {
+		callback, exist := policySetterCallbacks[key]
+		if !exist {
+			return "", fmt.Errorf("unknown contract policy variable: %v", key)
+		}
+		if err := callback(&newPolicy, value); err != nil {
+			return "", err
+		}
+	}
+	h.storageHost.contractPolicy = newPolicy
+	// sync the config
+	if err := h.storageHost.syncConfig(); err != nil {
+		return "", err
+	}
+	return "Successfully set the host contract policy", nil
+}
+
+// AddAllowlist add the client address or enode ID to the allowlist. Once the allowlist
+// is not empty, only clients in the allowlist are able to create contracts with the host
+func (h *HostPrivateAPI) AddAllowlist(entry string) (string, error) {
+	if err := h.storageHost.updatePolicyList(entry, false, false); err != nil {
+		return "", err
+	}
+	return "Successfully added the client to the allowlist", nil
+}
+
+// RemoveAllowlist remove the client address or enode ID from the allowlist
+func (h *HostPrivateAPI) RemoveAllowlist(entry string) (string, error) {
+	if err := h.storageHost.updatePolicyList(entry, false, true); err != nil {
+		return "", err
+	}
+	return "Successfully removed the client from the allowlist", nil
+}
+
+// AddDenylist add the client address or enode ID to the denylist
+func (h *HostPrivateAPI) AddDenylist(entry string) (string, error) {
+	if err := h.storageHost.updatePolicyList(entry, true, false); err != nil {
+		return "", err
+	}
+	return "Successfully added the client to the denylist", nil
+}
+
+// RemoveDenylist remove the client address or enode ID from the denylist
+func (h *HostPrivateAPI) RemoveDenylist(entry string) (string, error) {
+	if err := h.storageHost.updatePolicyList(entry, true, true); err != nil {
+		return "", err
+	}
+	return "Successfully removed the client from the denylist", nil
+}
+
+// setMinContractDuration set MinContractDuration to value
+func setMinContractDuration(policy *ContractPolicy, str string) error {
+	val, err := unit.ParseTime(str)
+	if err != nil {
+		return fmt.Errorf("invalid time string: %v", err)
+	}
+	policy.MinContractDuration = val
+	return nil
+}
+
+// setMinContractFund set MinContractFund to value
+func setMinContractFund(policy *ContractPolicy, str string) error {
+	wei, err := unit.ParseCurrency(str)
+	if err != nil {
+		return fmt.Errorf("invalid currency expression: %v", err)
+	}
+	policy.MinContractFund = wei
+	return nil
+}
+
+// setMaxStoragePerClient set MaxStoragePerClient to value
+func setMaxStoragePerClient(policy *ContractPolicy, str string) error {
+	val, err := unit.ParseStorage(str)
+	if err != nil {
+		return fmt.Errorf("invalid storage string: %v", err)
+	}
+	policy.MaxStoragePerClient = val
+	return nil
+}
+
+// setMaxConcurrentContracts set MaxConcurrentContracts to value
+func setMaxConcurrentContracts(policy *ContractPolicy, str string) error {
+	val, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid contract count: %v", err)
+	}
+	policy.MaxConcurrentContracts = val
+	return nil
+}
+
+// GetProofConfig return the configuration for submitting storage proofs
+func (h *HostPrivateAPI) GetProofConfig() ProofConfigForDisplay {
+	return formatProofConfig(h.storageHost.getProofConfig())
+}
+
+// proofSetterCallbacks is the mapping from the proof config field name to the setter function
+var proofSetterCallbacks = map[string]func(*ProofConfig, string) error{
+	"fallbackAddress": setProofFallbackAddress,
+	"retryInterval":   setProofRetryInterval,
+	"alertWindow":     setProofAlertWindow,
+	"gasPriceBump":    setGasPriceBump,
+	"maxGasPrice":     setMaxGasPrice,
+}
+
+// SetProofConfig set the proof config specified by a mapping of key value pair
+func (h *HostPrivateAPI) SetProofConfig(config map[string]string) (string, error) {
+	h.storageHost.lock.Lock()
+	defer h.storageHost.lock.Unlock()
+
+	// apply the changes to a copy, so that the config is not changed on error
+	newConfig := h.storageHost.proofConfig
+	for key, value := range config {
+		callback, exist := proofSetterCallbacks[key]
+		if !exist {
+			return "", fmt.Errorf("unknown proof config variable: %v", key)
+		}
+		if err := callback(&newConfig, value); err != nil {
+			return "", err
+		}
+	}
+	if err := newConfig.validate(); err != nil {
+		return "", err
+	}
+	// the fallback account must be available in the local wallet
+	if newConfig.FallbackAddress != (common.Address{}) {
+		if h.storageHost.am == nil {
+			return "", errors.New("storage host has no account manager")
+		}
+		if _, err := h.storageHost.am.Find(accounts.Account{Address: newConfig.FallbackAddress}); err != nil {
+			return "", errors.New("unknown fallback account")
+		}
+	}
+	h.storageHost.proofConfig = newConfig
+	// sync the config
+	if err := h.storageHost.syncConfig(); err != nil {
+		return "", err
+	}
+	return "Successfully set the host proof config", nil
+}
+
+// ProofAlerts create a subscription that is notified when a storage proof is close to
+// its deadline and still not confirmed
+func (h *HostPrivateAPI) ProofAlerts(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		alerts := make(chan ProofAlert, 16)
+		sub := h.storageHost.subscribeProofAlert(alerts)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case alert := <-alerts:
+				notifier.Notify(rpcSub.ID, alert)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// setProofFallbackAddress set FallbackAddress to value. Empty string clears the fallback address
+func setProofFallbackAddress(config *ProofConfig, str string) error {
+	str = strings.TrimSpace(str)
+	if str == "" {
+		config.FallbackAddress = common.Address{}
+		return nil
+	}
+	if !common.IsHexAddress(str) {
+		return fmt.Errorf("invalid address: %v", str)
+	}
+	config.FallbackAddress = common.HexToAddress(str)
+	return nil
+}
+
+// setProofRetryInterval set RetryInterval to value
+func setProofRetryInterval(config *ProofConfig, str string) error {
+	val, err := unit.ParseTime(str)
+	if err != nil {
+		return fmt.Errorf("invalid time string: %v", err)
+	}
+	config.RetryInterval = val
+	return nil
+}
+
+// setProofAlertWindow set AlertWindow to value
+func setProofAlertWindow(config *ProofConfig, str string) error {
+	val, err := unit.ParseTime(str)
+	if err != nil {
+		return fmt.Errorf("invalid time string: %v", err)
+	}
+	config.AlertWindow = val
+	return nil
+}
+
+// setGasPriceBump set GasPriceBump to value. The value is a percentage such as "20%" or "20"
+func setGasPriceBump(config *ProofConfig, str string) error {
+	str = strings.TrimSuffix(strings.TrimSpace(str), "%")
+	val, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid percentage string: %v", err)
+	}
+	config.GasPriceBump = val
+	return nil
+}
+
+// setMaxGasPrice set MaxGasPrice to value. Zero value means no limitation
+func setMaxGasPrice(config *ProofConfig, str string) error {
+	wei, err := unit.ParseCurrency(str)
+	if err != nil {
+		return fmt.Errorf("invalid currency expression: %v", err)
+	}
+	config.MaxGasPrice = wei
+	return nil
+}
+
+// GetAnnounceConfig return the configuration and state of the host announcement
+func (h *HostPrivateAPI) GetAnnounceConfig() AnnounceConfigForDisplay {
+	return formatAnnounceConfig(h.storageHost.getAnnounceConfig())
+}
+
+// announceSetterCallbacks is the mapping from the announce config field name to the setter function
+var announceSetterCallbacks = map[string]func(*AnnounceConfig, string) error{
+	"altAddresses":     setAltAddresses,
+	"autoAnnounce":     setAutoAnnounce,
+	"announceInterval": setAnnounceInterval,
+	"keyLifetime":      setKeyLifetime,
+	"keyAccounts":      setKeyAccounts,
+}
+
+// SetAnnounceConfig set the announce config specified by a mapping of key value pair.
+// The new config takes effect on the next announcement
+func (h *HostPrivateAPI) SetAnnounceConfig(config map[string]string) (string, error) {
+	h.storageHost.lock.Lock()
+	defer h.storageHost.lock.Unlock()
+
+	// apply the changes to a copy, so that the config is not changed on error
+	newConfig := h.storageHost.announceConfig
+	for key, value := range config {
+		callback, exist := announceSetterCallbacks[key]
+		if !exist {
+			return "", fmt.Errorf("unknown announce config variable: %v", key)
+		}
+		if err := callback(&newConfig, value); err != nil {
+			return "", err
+		}
+	}
+	if err := newConfig.validate(); err != nil {
+		return "", err
+	}
+	// switching back to the node key, the announcement key is no longer needed
+	if newConfig.KeyLifetime == 0 && h.storageHost.announceConfig.KeyLifetime != 0 {
+		h.storageHost.clearAnnouncementKey()
+	}
+	h.storageHost.announceConfig = newConfig
+	// sync the config
+	if err := h.storageHost.syncConfig(); err != nil {
+		return "", err
+	}
+	return "Successfully set the host announce config", nil
+}
+
+// RotateAnnouncementKey certifies the next announcement key account with the node key. The
+// new key is used from the next announcement
+func (h *HostPrivateAPI) RotateAnnouncementKey() (string, error) {
+	h.storageHost.lock.Lock()
+	defer h.storageHost.lock.Unlock()
+
+	if h.storageHost.announceConfig.KeyLifetime == 0 {
+		return "", errors.New("announcement key is disabled, set the key lifetime first")
+	}
+	address, err := h.storageHost.rotateAnnouncementKey()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("New announcement key: %v", address.Hex()), nil
+}
+
+// setAltAddresses set AltAddresses to value. The value is a comma separated list of
+// addresses in the format of "ip" or "ip:port". Empty string clears the addresses
+func setAltAddresses(config *AnnounceConfig, str string) error {
+	var addresses []string
+	for _, addr := range strings.Split(str, ",") {
+		if addr = strings.TrimSpace(addr); addr == "" {
+			continue
+		}
+		if _, _, err := parseAltAddress(addr, 0); err != nil {
+			return err
+		}
+		addresses = append(addresses, addr)
+	}
+	config.AltAddresses = addresses
+	return nil
+}
+
+// setAutoAnnounce set AutoAnnounce to value
+func setAutoAnnounce(config *AnnounceConfig, str string) error {
+	val, err := unit.ParseBool(str)
+	if err != nil {
+		return fmt.Errorf("invalid bool string: %v", err)
+	}
+	config.AutoAnnounce = val
+	return nil
+}
+
+// setAnnounceInterval set AnnounceInterval to value
+func setAnnounceInterval(config *AnnounceConfig, str string) error {
+	val, err := unit.ParseTime(str)
+	if err != nil {
+		return fmt.Errorf("invalid time string: %v", err)
+	}
+	config.AnnounceInterval = val
+	return nil
+}
+
+// setKeyLifetime set KeyLifetime to value. Zero value means the announcement is signed
+// by the node key
+func setKeyLifetime(config *AnnounceConfig, str string) error {
+	val, err := unit.ParseTime(str)
+	if err != nil {
+		return fmt.Errorf("invalid time string: %v", err)
+	}
+	config.KeyLifetime = val
+	return nil
+}
+
+// setKeyAccounts set KeyAccounts to value. The value is a comma separated list of the
+// accounts in the account manager used as the announcement keys
+func setKeyAccounts(config *AnnounceConfig, str string) error {
+	var keyAccounts []common.Address
+	for _, addr := range strings.Split(str, ",") {
+		if addr = strings.TrimSpace(addr); addr == "" {
+			continue
+		}
+		if !common.IsHexAddress(addr) {
+			return fmt.Errorf("invalid announcement key account: %v", addr)
+		}
+		keyAccounts = append(keyAccounts, common.HexToAddress(addr))
+	}
+	config.KeyAccounts = keyAccounts
+	return nil
+}
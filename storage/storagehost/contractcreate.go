@@ -14,6 +14,7 @@ import (
 	"github.com/DxChainNetwork/godx/crypto"
 	"github.com/DxChainNetwork/godx/log"
 	"github.com/DxChainNetwork/godx/p2p"
+	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/storage"
 )
 
@@ -27,6 +28,7 @@ func ContractCreateHandler(h *StorageHost, sp storage.Peer, contractCreateReqMsg
 			_ = sp.SendHostAckMsg()
 			h.ethBackend.CheckAndUpdateConnection(sp.PeerNode())
 		} else if hostNegotiateErr != nil {
+			h.log.Debug("storage host rejected the contract create request", "err", hostNegotiateErr)
 			_ = sp.SendHostNegotiateErrorMsg()
 		}
 	}()
@@ -91,13 +93,13 @@ func ContractCreateHandler(h *StorageHost, sp storage.Peer, contractCreateReqMsg
 	// Check an incoming storage contract matches the host's expectations for a valid contract
 	if req.Renew {
 		oldContractID := req.OldContractID
-		err = verifyRenewedContract(h, &sc, clientPK, hostPK, oldContractID)
+		err = verifyRenewedContract(h, &sc, clientPK, hostPK, sp.PeerNode(), oldContractID)
 		if err != nil {
 			hostNegotiateErr = fmt.Errorf("storage host failed to verify the renewed storage contract: %s", err.Error())
 			return
 		}
 	} else {
-		err = verifyStorageContract(h, &sc, clientPK, hostPK)
+		err = verifyStorageContract(h, &sc, clientPK, hostPK, sp.PeerNode())
 		if err != nil {
 			hostNegotiateErr = fmt.Errorf("storage host failed to verify the storage contract: %s", err.Error())
 			return
@@ -241,11 +243,17 @@ func ContractCreateHandler(h *StorageHost, sp storage.Peer, contractCreateReqMsg
 		log.Error("storage host failed to send host ack msg", "err", err)
 		_ = rollbackStorageResponsibility(h, so)
 		rollbackPeerStatic(h, sp)
+		return
+	}
+
+	// the renewed contract replaces the old one in the storage quota of the client
+	if req.Renew {
+		h.renewClientStorage(req.OldContractID, so.id())
 	}
 }
 
 // verifyStorageContract verify the validity of the storage contract. If discrepancy found, return error
-func verifyStorageContract(h *StorageHost, sc *types.StorageContract, clientPK *ecdsa.PublicKey, hostPK *ecdsa.PublicKey) error {
+func verifyStorageContract(h *StorageHost, sc *types.StorageContract, clientPK *ecdsa.PublicKey, hostPK *ecdsa.PublicKey, clientNode *enode.Node) error {
 	h.lock.RLock()
 	blockHeight := h.blockHeight
 	lockedStorageDeposit := h.financialMetrics.LockedStorageDeposit
@@ -255,6 +263,13 @@ func verifyStorageContract(h *StorageHost, sc *types.StorageContract, clientPK *
 
 	externalConfig := h.externalConfig()
 
+	// The storage forks active now and at the proof window must both be supported
+	for _, height := range []uint64{blockHeight + 1, sc.WindowStart} {
+		if err := storage.CheckStorageFork(h.ethBackend.GetBlockChain().Config(), height); err != nil {
+			return err
+		}
+	}
+
 	// A new file contract should have a file size of zero
 	if sc.FileSize != 0 {
 		return errBadFileSize
@@ -323,7 +338,9 @@ func verifyStorageContract(h *StorageHost, sc *types.StorageContract, clientPK *
 	if sc.UnlockHash != expectedUH {
 		return errBadUnlockHash
 	}
-	return nil
+	// The contract must meet the contract policy of the host
+	client := clientIdentity{address: crypto.PubkeyToAddress(*clientPK), node: clientNode}
+	return verifyContractPolicy(h, sc, client, false)
 }
 
 // finalizeStorageResponsibility insert storage responsibility
@@ -380,7 +397,7 @@ func renewBaseDeposit(so StorageResponsibility, settings storage.HostExtConfig,
 }
 
 // verifyRenewedContract checks whether the renewed contract matches the previous and appropriate payments.
-func verifyRenewedContract(h *StorageHost, sc *types.StorageContract, clientPK *ecdsa.PublicKey, hostPK *ecdsa.PublicKey, oldContractID common.Hash) error {
+func verifyRenewedContract(h *StorageHost, sc *types.StorageContract, clientPK *ecdsa.PublicKey, hostPK *ecdsa.PublicKey, clientNode *enode.Node, oldContractID common.Hash) error {
 	h.lock.RLock()
 	blockHeight := h.blockHeight
 	lockedStorageDeposit := h.financialMetrics.LockedStorageDeposit
@@ -467,7 +484,9 @@ func verifyRenewedContract(h *StorageHost, sc *types.StorageContract, clientPK *
 		return errBadUnlockHash
 	}
 
-	return nil
+	// The renewed contract must meet the contract policy of the host
+	client := clientIdentity{address: crypto.PubkeyToAddress(*clientPK), node: clientNode}
+	return verifyContractPolicy(h, sc, client, true)
 }
 
 func rollbackPeerStatic(h *StorageHost, sp storage.Peer) {
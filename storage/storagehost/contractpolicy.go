@@ -0,0 +1,277 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+)
+
+type (
+	// ContractPolicy is the host policy for accepting storage contracts. An entry of the
+	// allowlist or denylist is either a client address or a client enode ID. If the
+	// allowlist is not empty, only the clients in the allowlist can create contracts with
+	// the host. A zero value for the other fields means no limitation
+	ContractPolicy struct {
+		ClientAllowlist []string `json:"clientAllowlist"`
+		ClientDenylist  []string `json:"clientDenylist"`
+
+		MinContractDuration    uint64        `json:"minContractDuration"`
+		MinContractFund        common.BigInt `json:"minContractFund"`
+		MaxStoragePerClient    uint64        `json:"maxStoragePerClient"`
+		MaxConcurrentContracts uint64        `json:"maxConcurrentContracts"`
+	}
+
+	// ContractPolicyForDisplay is the contract policy for display
+	ContractPolicyForDisplay struct {
+		ClientAllowlist []string `json:"clientAllowlist"`
+		ClientDenylist  []string `json:"clientDenylist"`
+
+		MinContractDuration    string `json:"minContractDuration"`
+		MinContractFund        string `json:"minContractFund"`
+		MaxStoragePerClient    string `json:"maxStoragePerClient"`
+		MaxConcurrentContracts uint64 `json:"maxConcurrentContracts"`
+	}
+
+	// clientIdentity is the identity of the client requesting the contract
+	clientIdentity struct {
+		address common.Address
+		node    *enode.Node
+	}
+)
+
+// parsePolicyEntry parses the allowlist or denylist entry. The entry could be an address,
+// an enode ID or an enode URL. The returned entry is in the normalized format
+func parsePolicyEntry(entry string) (string, error) {
+	entry = strings.TrimSpace(entry)
+	if common.IsHexAddress(entry) {
+		return strings.ToLower(common.HexToAddress(entry).Hex()), nil
+	}
+	if strings.HasPrefix(entry, "enode://") {
+		node, err := enode.ParseV4(entry)
+		if err != nil {
+			return "", fmt.Errorf("invalid enode url: %v", err)
+		}
+		return node.ID().String(), nil
+	}
+	var id enode.ID
+	if err := id.UnmarshalText([]byte(entry)); err != nil {
+		return "", fmt.Errorf("entry is neither an address nor an enode ID: %v", entry)
+	}
+	return id.String(), nil
+}
+
+// matches check whether the client is specified by one of the entries
+func (client clientIdentity) matches(entries []string) bool {
+	address := strings.ToLower(client.address.Hex())
+	var id string
+	if client.node != nil {
+		id = client.node.ID().String()
+	}
+	for _, entry := range entries {
+		if entry == address || (id != "" && entry == id) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyClient check whether the client is permitted by the allowlist and denylist
+func (policy ContractPolicy) verifyClient(client clientIdentity) error {
+	if client.matches(policy.ClientDenylist) {
+		return errClientDenied
+	}
+	if len(policy.ClientAllowlist) != 0 && !client.matches(policy.ClientAllowlist) {
+		return errClientNotAllowed
+	}
+	return nil
+}
+
+// verifyTerms check whether the contract meets the minimum contract terms
+func (policy ContractPolicy) verifyTerms(sc *types.StorageContract, blockHeight uint64) error {
+	if policy.MinContractDuration != 0 && sc.WindowStart < blockHeight+policy.MinContractDuration {
+		return errShortContractDuration
+	}
+	if policy.MinContractFund.Sign() > 0 {
+		if sc.ClientCollateral.Value == nil || sc.ClientCollateral.Value.Cmp(policy.MinContractFund.BigIntPtr()) < 0 {
+			return errLowContractFund
+		}
+	}
+	return nil
+}
+
+// verifyClientStorage check whether adding the bytes to the client's storage exceeds
+// the maximum storage per client. No bytes added never exceeds the quota, so that the
+// client over the lowered quota is still able to renew or shrink its contracts
+func (policy ContractPolicy) verifyClientStorage(stored, added uint64) error {
+	if added == 0 {
+		return nil
+	}
+	if policy.MaxStoragePerClient != 0 && stored+added > policy.MaxStoragePerClient {
+		return errClientStorageQuota
+	}
+	return nil
+}
+
+// verifyContractPolicy verify the contract create or renew request against the contract
+// policy of the host. The concurrent contracts cap and the storage quota are not checked
+// for renew, since the renewed contract replaces the old one without adding data
+func verifyContractPolicy(h *StorageHost, sc *types.StorageContract, client clientIdentity, renew bool) error {
+	h.lock.RLock()
+	policy := h.contractPolicy
+	blockHeight := h.blockHeight
+	contractCount := h.financialMetrics.ContractCount
+	h.lock.RUnlock()
+
+	if err := policy.verifyClient(client); err != nil {
+		return err
+	}
+	if err := policy.verifyTerms(sc, blockHeight); err != nil {
+		return err
+	}
+	if !renew && policy.MaxConcurrentContracts != 0 && contractCount >= policy.MaxConcurrentContracts {
+		return errMaxContractsReached
+	}
+	return nil
+}
+
+// clientStorage returns the total size of data stored by the client in the unresolved
+// storage responsibilities. Only the latest contract of a renewal chain is counted
+func (h *StorageHost) clientStorage(client common.Address) uint64 {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.clientStored[client]
+}
+
+// reserveClientStorage checks the storage quota of the client for the added bytes, and
+// reserves them until released by releaseClientStorage. The bytes reserved by the uploads
+// in progress are counted as stored, so that concurrent uploads cannot exceed the quota
+func (h *StorageHost) reserveClientStorage(client common.Address, added uint64) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	stored := h.clientStored[client] + h.clientReserved[client]
+	if err := h.contractPolicy.verifyClientStorage(stored, added); err != nil {
+		return err
+	}
+	if added != 0 {
+		h.clientReserved[client] += added
+	}
+	return nil
+}
+
+// releaseClientStorage releases the bytes reserved for the client. It is called once the
+// upload is either committed, when the bytes are counted as stored, or failed
+func (h *StorageHost) releaseClientStorage(client common.Address, reserved uint64) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if reserved >= h.clientReserved[client] {
+		delete(h.clientReserved, client)
+	} else {
+		h.clientReserved[client] -= reserved
+	}
+}
+
+// updateClientStorage adds the added bytes to, and subtracts the removed bytes from the size
+// of data stored by the client of the storage responsibility. The renewed responsibility is
+// skipped, as its data is counted by the contract renewing it
+// Require: lock the storageHost by caller
+func (h *StorageHost) updateClientStorage(so StorageResponsibility, added, removed uint64) {
+	if _, renewed := h.renewedContracts[so.id()]; renewed {
+		return
+	}
+	client, ok := so.clientAddress()
+	if !ok {
+		return
+	}
+	stored := h.clientStored[client] + added
+	if removed > stored {
+		stored = 0
+	} else {
+		stored -= removed
+	}
+	if stored == 0 {
+		delete(h.clientStored, client)
+	} else {
+		h.clientStored[client] = stored
+	}
+}
+
+// renewClientStorage marks the storage responsibility renewed by the new contract, so that
+// the data of the renewal chain is only counted by the new contract
+func (h *StorageHost) renewClientStorage(oldID, newID common.Hash) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	so, err := getStorageResponsibility(h.db, oldID)
+	if err != nil {
+		h.log.Warn("failed to get the renewed storage responsibility", "id", oldID, "err", err)
+		return
+	}
+	if so.ResponsibilityStatus == responsibilityUnresolved {
+		h.updateClientStorage(so, 0, so.fileSize())
+		h.renewedContracts[oldID] = newID
+	}
+}
+
+// loadClientStorage counts the data stored by the clients from the unresolved storage
+// responsibilities persisted in the database
+func (h *StorageHost) loadClientStorage() error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.clientStored = make(map[common.Address]uint64)
+	return forEachStorageResponsibility(h.db, func(so StorageResponsibility) {
+		if so.ResponsibilityStatus == responsibilityUnresolved {
+			h.updateClientStorage(so, so.fileSize(), 0)
+		}
+	})
+}
+
+// getContractPolicy returns the contract policy of the host
+func (h *StorageHost) getContractPolicy() ContractPolicy {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.contractPolicy
+}
+
+// updatePolicyList add or remove the entry from the allowlist or denylist
+func (h *StorageHost) updatePolicyList(entry string, deny bool, remove bool) error {
+	normalized, err := parsePolicyEntry(entry)
+	if err != nil {
+		return err
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	list := &h.contractPolicy.ClientAllowlist
+	if deny {
+		list = &h.contractPolicy.ClientDenylist
+	}
+	index := -1
+	for i, e := range *list {
+		if e == normalized {
+			index = i
+			break
+		}
+	}
+	switch {
+	case remove && index == -1:
+		return fmt.Errorf("entry %v not found", normalized)
+	case remove:
+		*list = append((*list)[:index], (*list)[index+1:]...)
+	case index != -1:
+		return fmt.Errorf("entry %v already exists", normalized)
+	default:
+		*list = append(*list, normalized)
+	}
+	return h.syncConfig()
+}
@@ -0,0 +1,272 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+)
+
+const testEnodeID = "a979fb575495b8d6db44f750317d0f4622bf4c2aa3365d6af7c284339968eef29b69ad0dce72a4d8db5ebb4968de0e3bec910127f134779fbcb0cb6d3331163c"
+
+func TestParsePolicyEntry(t *testing.T) {
+	var id enode.ID
+	if err := id.UnmarshalText([]byte("0xa979fb575495b8d6db44f750317d0f4622bf4c2aa3365d6af7c284339968eef2")); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		entry  string
+		expect string
+		err    bool
+	}{
+		{"0x0000000000000000000000000000000000000aBc", "0x0000000000000000000000000000000000000abc", false},
+		{" " + id.String() + " ", id.String(), false},
+		{"invalid", "", true},
+	}
+	for i, test := range tests {
+		res, err := parsePolicyEntry(test.entry)
+		if (err != nil) != test.err {
+			t.Errorf("test %d: expect error %v, got %v", i, test.err, err)
+			continue
+		}
+		if res != test.expect {
+			t.Errorf("test %d: expect %v, got %v", i, test.expect, res)
+		}
+	}
+}
+
+func TestContractPolicy_VerifyClient(t *testing.T) {
+	alice := clientIdentity{address: common.HexToAddress("0x1")}
+	bob := clientIdentity{address: common.HexToAddress("0x2")}
+	aliceEntry, _ := parsePolicyEntry(alice.address.Hex())
+	bobEntry, _ := parsePolicyEntry(bob.address.Hex())
+
+	policy := ContractPolicy{}
+	if err := policy.verifyClient(alice); err != nil {
+		t.Errorf("empty policy should accept all clients: %v", err)
+	}
+	policy.ClientDenylist = []string{aliceEntry}
+	if err := policy.verifyClient(alice); err != errClientDenied {
+		t.Errorf("expect error %v, got %v", errClientDenied, err)
+	}
+	policy.ClientDenylist = nil
+	policy.ClientAllowlist = []string{bobEntry}
+	if err := policy.verifyClient(alice); err != errClientNotAllowed {
+		t.Errorf("expect error %v, got %v", errClientNotAllowed, err)
+	}
+	if err := policy.verifyClient(bob); err != nil {
+		t.Errorf("client in the allowlist should be accepted: %v", err)
+	}
+	// denylist takes precedence over the allowlist
+	policy.ClientDenylist = []string{bobEntry}
+	if err := policy.verifyClient(bob); err != errClientDenied {
+		t.Errorf("expect error %v, got %v", errClientDenied, err)
+	}
+}
+
+func TestContractPolicy_VerifyTerms(t *testing.T) {
+	policy := ContractPolicy{
+		MinContractDuration: 100,
+		MinContractFund:     common.NewBigInt(1000),
+	}
+	sc := &types.StorageContract{
+		WindowStart:      200,
+		ClientCollateral: types.DxcoinCollateral{DxcoinCharge: types.DxcoinCharge{Value: big.NewInt(1000)}},
+	}
+	if err := policy.verifyTerms(sc, 100); err != nil {
+		t.Errorf("contract meeting the terms should be accepted: %v", err)
+	}
+	if err := policy.verifyTerms(sc, 101); err != errShortContractDuration {
+		t.Errorf("expect error %v, got %v", errShortContractDuration, err)
+	}
+	sc.ClientCollateral.Value = big.NewInt(999)
+	if err := policy.verifyTerms(sc, 100); err != errLowContractFund {
+		t.Errorf("expect error %v, got %v", errLowContractFund, err)
+	}
+}
+
+func TestContractPolicy_VerifyClientStorage(t *testing.T) {
+	policy := ContractPolicy{}
+	if err := policy.verifyClientStorage(1<<40, 1<<40); err != nil {
+		t.Errorf("zero quota means no limitation: %v", err)
+	}
+	policy.MaxStoragePerClient = 100
+	if err := policy.verifyClientStorage(60, 40); err != nil {
+		t.Errorf("storage within quota should be accepted: %v", err)
+	}
+	if err := policy.verifyClientStorage(60, 41); err != errClientStorageQuota {
+		t.Errorf("expect error %v, got %v", errClientStorageQuota, err)
+	}
+	if err := policy.verifyClientStorage(200, 0); err != nil {
+		t.Errorf("no bytes added should be accepted over the quota: %v", err)
+	}
+}
+
+func TestStorageHost_ClientStorage(t *testing.T) {
+	h := newTestStorageHost(t)
+	alice, bob := common.HexToAddress("0x1"), common.HexToAddress("0x2")
+
+	sos := []StorageResponsibility{
+		newTestClientResponsibility(alice, 10, 100, responsibilityUnresolved),
+		newTestClientResponsibility(alice, 20, 120, responsibilitySucceeded),
+		newTestClientResponsibility(bob, 10, 100, responsibilityUnresolved),
+	}
+	for _, so := range sos {
+		if err := putStorageResponsibility(h.db, so.id(), so); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.loadClientStorage(); err != nil {
+		t.Fatal(err)
+	}
+	if stored := h.clientStorage(alice); stored != 1<<20 {
+		t.Errorf("alice storage: expect %v, got %v", 1<<20, stored)
+	}
+
+	// renew the contract of alice, only the renewed contract is counted
+	renewed := newTestClientResponsibility(alice, 90, 200, responsibilityUnresolved)
+	if err := putStorageResponsibility(h.db, renewed.id(), renewed); err != nil {
+		t.Fatal(err)
+	}
+	h.lock.Lock()
+	h.updateClientStorage(renewed, renewed.fileSize(), 0)
+	h.lock.Unlock()
+	h.renewClientStorage(sos[0].id(), renewed.id())
+	if stored := h.clientStorage(alice); stored != 1<<20 {
+		t.Errorf("alice storage after renew: expect %v, got %v", 1<<20, stored)
+	}
+
+	// the renewed contract is skipped when counted from the database
+	if err := h.loadClientStorage(); err != nil {
+		t.Fatal(err)
+	}
+	if stored := h.clientStorage(alice); stored != 1<<20 {
+		t.Errorf("alice storage after reload: expect %v, got %v", 1<<20, stored)
+	}
+	if stored := h.clientStorage(bob); stored != 1<<20 {
+		t.Errorf("bob storage: expect %v, got %v", 1<<20, stored)
+	}
+
+	// the data of the deleted responsibility is no longer counted
+	if err := h.deleteStorageResponsibilities([]common.Hash{sos[2].id()}); err != nil {
+		t.Fatal(err)
+	}
+	if stored := h.clientStorage(bob); stored != 0 {
+		t.Errorf("bob storage after delete: expect 0, got %v", stored)
+	}
+}
+
+func TestStorageHost_ReserveClientStorage(t *testing.T) {
+	h := newTestStorageHost(t)
+	alice := common.HexToAddress("0x1")
+	h.contractPolicy.MaxStoragePerClient = 100
+	h.clientStored[alice] = 40
+
+	// the concurrent uploads are not able to exceed the quota together
+	var (
+		wg       sync.WaitGroup
+		accepted int32
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := h.reserveClientStorage(alice, 30); err == nil {
+				atomic.AddInt32(&accepted, 1)
+			} else if err != errClientStorageQuota {
+				t.Errorf("expect error %v, got %v", errClientStorageQuota, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if accepted != 2 {
+		t.Fatalf("expect 2 uploads accepted, got %d", accepted)
+	}
+
+	// the reservation of the failed upload is released
+	h.releaseClientStorage(alice, 30)
+	if err := h.reserveClientStorage(alice, 30); err != nil {
+		t.Errorf("storage released should be reserved again: %v", err)
+	}
+	if err := h.reserveClientStorage(alice, 1); err != errClientStorageQuota {
+		t.Errorf("expect error %v, got %v", errClientStorageQuota, err)
+	}
+
+	// the committed upload is counted as stored once released
+	h.lock.Lock()
+	h.clientStored[alice] += 30
+	h.lock.Unlock()
+	h.releaseClientStorage(alice, 30)
+	h.releaseClientStorage(alice, 30)
+	if reserved := h.clientReserved[alice]; reserved != 0 {
+		t.Errorf("expect no storage reserved, got %v", reserved)
+	}
+	if err := h.reserveClientStorage(alice, 31); err != errClientStorageQuota {
+		t.Errorf("expect error %v, got %v", errClientStorageQuota, err)
+	}
+}
+
+func TestHostPrivateAPI_ContractPolicy(t *testing.T) {
+	h := newTestStorageHost(t)
+	api := NewHostPrivateAPI(h)
+
+	if _, err := api.SetContractPolicy(map[string]string{
+		"minContractDuration":    "1d",
+		"minContractFund":        "1dx",
+		"maxStoragePerClient":    "1gb",
+		"maxConcurrentContracts": "10",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	policy := h.getContractPolicy()
+	if policy.MaxConcurrentContracts != 10 {
+		t.Errorf("max concurrent contracts not expected: %v", policy.MaxConcurrentContracts)
+	}
+	if policy.MinContractFund.Cmp(mustParseCurrency("1dx")) != 0 {
+		t.Errorf("min contract fund not expected: %v", policy.MinContractFund)
+	}
+	// invalid value shall not change the policy
+	if _, err := api.SetContractPolicy(map[string]string{
+		"maxConcurrentContracts": "20",
+		"maxStoragePerClient":    "invalid",
+	}); err == nil {
+		t.Error("invalid storage should give error")
+	}
+	if policy = h.getContractPolicy(); policy.MaxConcurrentContracts != 10 {
+		t.Errorf("policy changed on error: %v", policy.MaxConcurrentContracts)
+	}
+
+	if _, err := api.AddDenylist("enode://" + testEnodeID + "@127.0.0.1:30303"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.AddAllowlist("0x1"); err == nil {
+		t.Error("invalid entry should give error")
+	}
+	if _, err := api.AddAllowlist(common.HexToAddress("0x1").Hex()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.AddAllowlist(common.HexToAddress("0x1").Hex()); err == nil {
+		t.Error("duplicate entry should give error")
+	}
+	display := api.GetContractPolicy()
+	if len(display.ClientAllowlist) != 1 || len(display.ClientDenylist) != 1 {
+		t.Fatalf("lists not expected: %+v", display)
+	}
+	if _, err := api.RemoveAllowlist(common.HexToAddress("0x1").Hex()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.RemoveAllowlist(common.HexToAddress("0x1").Hex()); err == nil {
+		t.Error("removing non-existing entry should give error")
+	}
+	if policy = h.getContractPolicy(); len(policy.ClientAllowlist) != 0 {
+		t.Errorf("allowlist not empty: %v", policy.ClientAllowlist)
+	}
+}
@@ -31,10 +31,16 @@ func (h *StorageHost) deleteStorageResponsibilities(soids []common.Hash) error {
 	h.lock.Lock()
 	defer h.lock.Unlock()
 	for _, soid := range soids {
+		if so, err := getStorageResponsibility(h.db, soid); err == nil && so.ResponsibilityStatus == responsibilityUnresolved {
+			h.updateClientStorage(so, 0, so.fileSize())
+		}
+		delete(h.renewedContracts, soid)
 		err := deleteStorageResponsibility(h.db, soid)
 		if err != nil {
 			return err
 		}
+		// the bandwidth usage might not exist, thus the error is ignored
+		_ = deleteBandwidthUsage(h.db, soid)
 	}
 	return nil
 }
@@ -68,6 +74,21 @@ func getStorageResponsibility(db ethdb.Database, storageContractID common.Hash)
 	return so, nil
 }
 
+//forEachStorageResponsibility calls fn with every storageResponsibility persisted in DB,
+//including the resolved ones no longer tracked in memory
+func forEachStorageResponsibility(db *ethdb.LDBDatabase, fn func(so StorageResponsibility)) error {
+	iter := db.NewIteratorWithPrefix([]byte(prefixStorageResponsibility))
+	defer iter.Release()
+	for iter.Next() {
+		var so StorageResponsibility
+		if err := rlp.DecodeBytes(iter.Value(), &so); err != nil {
+			return err
+		}
+		fn(so)
+	}
+	return iter.Error()
+}
+
 //storeHeight storage task by block height
 func storeHeight(db ethdb.Database, storageContractID common.Hash, height uint64) error {
 	scdb := ethdb.StorageContractDB{db}
@@ -98,3 +119,34 @@ func getHeight(db ethdb.Database, height uint64) ([]byte, error) {
 
 	return valueBytes, nil
 }
+
+//putBandwidthUsage store the bandwidth served for the storage contract into DB
+func putBandwidthUsage(db ethdb.Database, storageContractID common.Hash, usage bandwidthUsage) error {
+	scdb := ethdb.StorageContractDB{db}
+	data, err := rlp.EncodeToBytes(usage)
+	if err != nil {
+		return err
+	}
+	return scdb.StoreWithPrefix(storageContractID, data, prefixBandwidthUsage)
+}
+
+//getBandwidthUsage get the bandwidth served for the storage contract from DB. If not
+//found, an empty bandwidth usage is returned
+func getBandwidthUsage(db ethdb.Database, storageContractID common.Hash) bandwidthUsage {
+	scdb := ethdb.StorageContractDB{db}
+	valueBytes, err := scdb.GetWithPrefix(storageContractID, prefixBandwidthUsage)
+	if err != nil {
+		return bandwidthUsage{}
+	}
+	var usage bandwidthUsage
+	if err = rlp.DecodeBytes(valueBytes, &usage); err != nil {
+		return bandwidthUsage{}
+	}
+	return usage
+}
+
+//deleteBandwidthUsage delete the bandwidth usage of the storage contract from DB
+func deleteBandwidthUsage(db ethdb.Database, storageContractID common.Hash) error {
+	scdb := ethdb.StorageContractDB{db}
+	return scdb.DeleteWithPrefix(storageContractID, prefixBandwidthUsage)
+}
@@ -37,6 +37,8 @@ const (
 	prefixStorageResponsibility = "StorageResponsibility-"
 	//prefixHeight db prefix for task
 	prefixHeight = "height-"
+	//prefixBandwidthUsage db prefix for the bandwidth served for a storage contract
+	prefixBandwidthUsage = "BandwidthUsage-"
 )
 
 var (
@@ -67,6 +69,10 @@ var (
 	defaultStoragePrice           = common.PtrBigInt(math.BigPow(10, 3))                                    // Same as deposit
 	defaultUploadBandwidthPrice   = common.PtrBigInt(math.BigPow(10, 7))                                    // 10 DX per TB
 
+	// pricing engine defaults value
+	defaultPricingUpdateInterval = 6 * storage.BlockPerHour // 6 hours
+	defaultAnnounceThreshold     = 0.1                      // 10%
+
 	//Storage contract should not be empty
 	emptyStorageContract = types.StorageContract{}
 
@@ -110,6 +116,60 @@ func defaultConfig() storage.HostIntConfig {
 	}
 }
 
+const (
+	// pricing engine related constants
+	maxPriceChangePercent    = 20   // maximum change of a price in a single update in percent
+	demandPriceStep          = 0.05 // relative price change caused by the revenue trend
+	minUtilizationMultiplier = 0.5  // multiplier applied when the host storage is empty
+	maxUtilizationMultiplier = 1.5  // multiplier applied when the host storage is full
+	marketSampleSize         = 10   // number of announced hosts sampled for the market prices
+	maxTrackedHosts          = 256  // maximum number of announced hosts tracked by the host
+)
+
+// defaultPricingConfig loads the default pricing config. Auto pricing is disabled
+// by default, and the prices are not bounded
+func defaultPricingConfig() PricingConfig {
+	return PricingConfig{
+		UpdateInterval:    defaultPricingUpdateInterval,
+		AnnounceThreshold: defaultAnnounceThreshold,
+	}
+}
+
+var (
+	// storage proof submission related parameters
+	defaultProofRetryInterval = 10 * storage.BlockPerMin // resubmit the proof every 10 minutes
+	defaultProofAlertWindow   = storage.BlockPerHour     // alert 1 hour before the proof deadline
+)
+
+const (
+	defaultGasPriceBump = 20 // gas price bump in percent for resubmission
+	minGasPriceBump     = 10 // minimum gas price bump accepted by the txpool
+)
+
+// defaultProofConfig loads the default proof config. No fallback address and maximum
+// gas price are set by default
+func defaultProofConfig() ProofConfig {
+	return ProofConfig{
+		RetryInterval: defaultProofRetryInterval,
+		AlertWindow:   defaultProofAlertWindow,
+		GasPriceBump:  defaultGasPriceBump,
+	}
+}
+
+var (
+	// host announcement related parameters
+	defaultAnnounceInterval    = storage.BlockPerHour      // re-announce at most once per hour
+	defaultAnnounceKeyLifetime = 30 * storage.BlocksPerDay // rotate the announcement key every 30 days
+)
+
+// defaultAnnounceConfig loads the default announce config. Auto announcement is disabled
+// by default, and the announcement is signed by the node key
+func defaultAnnounceConfig() AnnounceConfig {
+	return AnnounceConfig{
+		AnnounceInterval: defaultAnnounceInterval,
+	}
+}
+
 const (
 	// responsibility status
 	responsibilityUnresolved storageResponsibilityStatus = iota //Storage responsibility is initialization, no meaning
@@ -18,16 +18,19 @@ import (
 	"github.com/DxChainNetwork/godx/log"
 	"github.com/DxChainNetwork/godx/p2p"
 	"github.com/DxChainNetwork/godx/storage"
+	sm "github.com/DxChainNetwork/godx/storage/storagehost/storagemanager"
 )
 
 // DownloadHandler handles the download negotiation
 func DownloadHandler(h *StorageHost, sp storage.Peer, downloadReqMsg p2p.Msg) {
-	var hostNegotiateErr, clientNegotiateErr, clientCommitErr error
+	var hostNegotiateErr, hostSectorErr, clientNegotiateErr, clientCommitErr error
 
 	defer func() {
 		if clientNegotiateErr != nil || clientCommitErr != nil {
 			_ = sp.SendHostAckMsg()
 			h.ethBackend.CheckAndUpdateConnection(sp.PeerNode())
+		} else if hostSectorErr != nil {
+			_ = sp.SendHostSectorNotFoundMsg()
 		} else if hostNegotiateErr != nil {
 			_ = sp.SendHostNegotiateErrorMsg()
 		}
@@ -81,6 +84,19 @@ func DownloadHandler(h *StorageHost, sp storage.Peer, downloadReqMsg p2p.Msg) {
 		return
 	}
 
+	// fetch the requested data from host local storage before accepting the payment.
+	// A sector lost by the host is reported distinctly so that the client could tell
+	// it apart from a transient negotiation failure
+	sectorData, err := h.ReadSector(sec.MerkleRoot)
+	if err == sm.ErrNotFound {
+		hostSectorErr = fmt.Errorf("host failed read sector: %s", err.Error())
+		return
+	} else if err != nil {
+		hostNegotiateErr = fmt.Errorf("host failed read sector: %s", err.Error())
+		return
+	}
+	data := sectorData[sec.Offset : sec.Offset+sec.Length]
+
 	// construct the new revision
 	newRevision := currentRevision
 	newRevision.NewRevisionNumber = req.NewRevisionNumber
@@ -139,14 +155,6 @@ func DownloadHandler(h *StorageHost, sp storage.Peer, downloadReqMsg p2p.Msg) {
 	so.PotentialDownloadRevenue = so.PotentialDownloadRevenue.Add(paymentTransfer)
 	so.StorageContractRevisions = append(so.StorageContractRevisions, newRevision)
 
-	// fetch the requested data from host local storage
-	sectorData, err := h.ReadSector(sec.MerkleRoot)
-	if err != nil {
-		hostNegotiateErr = fmt.Errorf("host failed read sector: %s", err.Error())
-		return
-	}
-	data := sectorData[sec.Offset : sec.Offset+sec.Length]
-
 	// construct the Merkle proof, if requested.
 	var proof []common.Hash
 	if req.MerkleProof {
@@ -218,7 +226,11 @@ func DownloadHandler(h *StorageHost, sp storage.Peer, downloadReqMsg p2p.Msg) {
 		log.Error("storage host failed to send host ack msg", "err", err)
 		_ = h.rollbackStorageResponsibility(snapshotSo, nil, nil, nil)
 		h.ethBackend.CheckAndUpdateConnection(sp.PeerNode())
+		return
 	}
+
+	// record the download bandwidth served for the client usage report
+	h.recordBandwidthUsage(so.id(), 0, uint64(sec.Length))
 }
 
 // verifyPaymentRevision verifies that the revision being provided to pay for
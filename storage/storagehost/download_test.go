@@ -0,0 +1,90 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/p2p"
+	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// downloadTestPeer records the negotiation error messages sent by the download handler
+type downloadTestPeer struct {
+	storage.Peer
+	negotiateErrSent      bool
+	sectorNotFoundErrSent bool
+}
+
+func (p *downloadTestPeer) SendHostNegotiateErrorMsg() error {
+	p.negotiateErrSent = true
+	return nil
+}
+
+func (p *downloadTestPeer) SendHostSectorNotFoundMsg() error {
+	p.sectorNotFoundErrSent = true
+	return nil
+}
+
+// Tests that a host missing the requested sector replies the sector not found message instead
+// of the negotiation error, and that the other failures are still reported as negotiation errors.
+func TestDownloadHandler_SectorNotFound(t *testing.T) {
+	h := newTestStorageHost(t)
+	if err := h.StorageManager.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer h.StorageManager.Close()
+	outputs := []types.DxcoinCharge{
+		{Address: common.HexToAddress("0x1"), Value: big.NewInt(1000)},
+		{Address: common.HexToAddress("0x2"), Value: big.NewInt(1000)},
+	}
+	so := StorageResponsibility{
+		OriginStorageContract: types.StorageContract{WindowStart: 1000, WindowEnd: 2000},
+		StorageContractRevisions: []types.StorageContractRevision{{
+			NewWindowStart:        1000,
+			NewValidProofOutputs:  outputs,
+			NewMissedProofOutputs: outputs,
+		}},
+	}
+	id := common.HexToHash("0x10")
+	if err := putStorageResponsibility(h.db, id, so); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		contractID        common.Hash
+		sectorNotFoundErr bool
+	}{
+		{id, true},
+		{common.HexToHash("0x20"), false},
+	}
+	for i, test := range tests {
+		req := storage.DownloadRequest{
+			StorageContractID:    test.contractID,
+			Sector:               storage.DownloadRequestSector{MerkleRoot: common.HexToHash("0x30"), Length: storage.SegmentSize},
+			NewValidProofValues:  []*big.Int{big.NewInt(900), big.NewInt(1100)},
+			NewMissedProofValues: []*big.Int{big.NewInt(1000), big.NewInt(1000)},
+		}
+		payload, err := rlp.EncodeToBytes(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := p2p.Msg{Code: storage.ContractDownloadReqMsg, Size: uint32(len(payload)), Payload: bytes.NewReader(payload)}
+
+		sp := &downloadTestPeer{}
+		DownloadHandler(h, sp, msg)
+		if sp.sectorNotFoundErrSent != test.sectorNotFoundErr {
+			t.Errorf("test %d: expect sector not found message sent %v, got %v", i, test.sectorNotFoundErr, sp.sectorNotFoundErrSent)
+		}
+		if sp.negotiateErrSent == test.sectorNotFoundErr {
+			t.Errorf("test %d: expect negotiate error message sent %v, got %v", i, !test.sectorNotFoundErr, sp.negotiateErrSent)
+		}
+	}
+}
@@ -48,6 +48,12 @@ func (h *StorageHost) hostBlockHeightChange(cce core.ChainChangeEvent) {
 	// update the contractToClientID
 	h.UpdateContractToClientNodeMappingAndConnection()
 
+	// record the announced hosts, update the prices if auto pricing is enabled, and
+	// re-announce the host if the external IP changed
+	h.recordAnnouncedHosts(cce.AppliedBlockHashes)
+	h.checkAutoPricing()
+	h.checkAutoAnnounce()
+
 	// sync the configuration
 	err := h.syncConfig()
 	if err != nil {
@@ -114,6 +120,7 @@ func (h *StorageHost) applyBlockHashesStorageResponsibility(blocks []common.Hash
 				continue
 			}
 			so.StorageProofConfirmed = true
+			delete(h.proofSubmissions, so.id())
 			errPut := putStorageResponsibility(h.db, so.id(), so)
 			if errPut != nil {
 				h.log.Error("Failed to put storage responsibility", "err", errPut)
@@ -204,6 +211,10 @@ func (h *StorageHost) revertedBlockHashesStorageResponsibility(blocks []common.H
 				h.log.Error("Failed to put storage responsibility", "err", errPut)
 				continue
 			}
+			//The reverted storage proof needs to be submitted again
+			if err := h.queueTaskItem(h.blockHeight+postponedExecution, so.id()); err != nil {
+				h.log.Warn("Error queuing task item", "err", err)
+			}
 		}
 
 		if number != 0 && h.blockHeight > 1 {
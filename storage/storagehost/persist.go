@@ -12,10 +12,18 @@ import (
 
 // the fields that need to write into the jason file
 type persistence struct {
-	BlockHeight      uint64                 `json:"blockHeight"`
-	FinancialMetrics HostFinancialMetrics   `json:"financialmetrics"`
-	Config           storage.HostIntConfig  `json:"config"`
-	Contracts        map[string]common.Hash `json:"contracts"`
+	BlockHeight      uint64                           `json:"blockHeight"`
+	FinancialMetrics HostFinancialMetrics             `json:"financialmetrics"`
+	Config           storage.HostIntConfig            `json:"config"`
+	Contracts        map[string]common.Hash           `json:"contracts"`
+	PricingConfig    PricingConfig                    `json:"pricingConfig"`
+	PricingState     pricingState                     `json:"pricingState"`
+	ContractPolicy   ContractPolicy                   `json:"contractPolicy"`
+	RenewedContracts map[common.Hash]common.Hash      `json:"renewedContracts"`
+	ProofConfig      ProofConfig                      `json:"proofConfig"`
+	ProofSubmissions map[common.Hash]*proofSubmission `json:"proofSubmissions"`
+	AnnounceConfig   AnnounceConfig                   `json:"announceConfig"`
+	AnnounceState    announceState                    `json:"announceState"`
 }
 
 // save the host config: the filed as persistence shown, to the json file
@@ -49,6 +57,14 @@ func (h *StorageHost) extractPersistence() *persistence {
 		FinancialMetrics: h.financialMetrics,
 		Config:           h.config,
 		Contracts:        h.clientToContract,
+		PricingConfig:    h.pricingConfig,
+		PricingState:     h.pricingState,
+		ContractPolicy:   h.contractPolicy,
+		RenewedContracts: h.renewedContracts,
+		ProofConfig:      h.proofConfig,
+		ProofSubmissions: h.proofSubmissions,
+		AnnounceConfig:   h.announceConfig,
+		AnnounceState:    h.announceState,
 	}
 }
 
@@ -59,4 +75,35 @@ func (h *StorageHost) loadPersistence(persist *persistence) {
 	h.financialMetrics = persist.FinancialMetrics
 	h.config = persist.Config
 	h.clientToContract = persist.Contracts
+	h.pricingConfig = persist.PricingConfig
+	h.pricingState = persist.PricingState
+	h.contractPolicy = persist.ContractPolicy
+	if persist.RenewedContracts != nil {
+		h.renewedContracts = persist.RenewedContracts
+	}
+	h.proofConfig = persist.ProofConfig
+	if persist.ProofSubmissions != nil {
+		h.proofSubmissions = persist.ProofSubmissions
+	}
+	h.announceConfig = persist.AnnounceConfig
+	h.announceState = persist.AnnounceState
+
+	// config files saved before the pricing engine was introduced have no pricing config
+	if h.pricingConfig.UpdateInterval == 0 {
+		h.pricingConfig.UpdateInterval = defaultPricingUpdateInterval
+	}
+	if h.pricingConfig.AnnounceThreshold == 0 {
+		h.pricingConfig.AnnounceThreshold = defaultAnnounceThreshold
+	}
+	// config files saved before the proof config was introduced have no proof config
+	if h.proofConfig.RetryInterval == 0 {
+		h.proofConfig.RetryInterval = defaultProofRetryInterval
+	}
+	if h.proofConfig.GasPriceBump == 0 {
+		h.proofConfig.GasPriceBump = defaultGasPriceBump
+	}
+	// config files saved before the announce config was introduced have no announce config
+	if h.announceConfig.AnnounceInterval == 0 {
+		h.announceConfig.AnnounceInterval = defaultAnnounceInterval
+	}
 }
@@ -0,0 +1,434 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+type (
+	// PricingConfig is the operator-set configuration of the automatic pricing engine.
+	// A zero value bound means the price is not bounded in that direction
+	PricingConfig struct {
+		AutoPricing       bool    `json:"autoPricing"`
+		UpdateInterval    uint64  `json:"updateInterval"`
+		AnnounceThreshold float64 `json:"announceThreshold"`
+
+		MinStoragePrice           common.BigInt `json:"minStoragePrice"`
+		MaxStoragePrice           common.BigInt `json:"maxStoragePrice"`
+		MinUploadBandwidthPrice   common.BigInt `json:"minUploadBandwidthPrice"`
+		MaxUploadBandwidthPrice   common.BigInt `json:"maxUploadBandwidthPrice"`
+		MinDownloadBandwidthPrice common.BigInt `json:"minDownloadBandwidthPrice"`
+		MaxDownloadBandwidthPrice common.BigInt `json:"maxDownloadBandwidthPrice"`
+		MinContractPrice          common.BigInt `json:"minContractPrice"`
+		MaxContractPrice          common.BigInt `json:"maxContractPrice"`
+	}
+
+	// PricingConfigForDisplay is the pricing config and state for display
+	PricingConfigForDisplay struct {
+		AutoPricing       string `json:"autoPricing"`
+		UpdateInterval    string `json:"updateInterval"`
+		AnnounceThreshold string `json:"announceThreshold"`
+
+		MinStoragePrice           string `json:"minStoragePrice"`
+		MaxStoragePrice           string `json:"maxStoragePrice"`
+		MinUploadBandwidthPrice   string `json:"minUploadBandwidthPrice"`
+		MaxUploadBandwidthPrice   string `json:"maxUploadBandwidthPrice"`
+		MinDownloadBandwidthPrice string `json:"minDownloadBandwidthPrice"`
+		MaxDownloadBandwidthPrice string `json:"maxDownloadBandwidthPrice"`
+		MinContractPrice          string `json:"minContractPrice"`
+		MaxContractPrice          string `json:"maxContractPrice"`
+
+		LastUpdateHeight uint64 `json:"lastUpdateHeight"`
+		KnownHosts       int    `json:"knownHosts"`
+	}
+
+	// pricingState is the runtime state of the pricing engine that is persisted
+	// together with the host config
+	pricingState struct {
+		LastUpdateHeight uint64        `json:"lastUpdateHeight"`
+		LastRevenue      common.BigInt `json:"lastRevenue"`
+		AnnouncedPrices  hostPrices    `json:"announcedPrices"`
+		AnnouncedHosts   []string      `json:"announcedHosts"`
+	}
+
+	// hostPrices is the set of prices adjusted by the pricing engine
+	hostPrices struct {
+		StoragePrice           common.BigInt `json:"storagePrice"`
+		UploadBandwidthPrice   common.BigInt `json:"uploadBandwidthPrice"`
+		DownloadBandwidthPrice common.BigInt `json:"downloadBandwidthPrice"`
+		ContractPrice          common.BigInt `json:"contractPrice"`
+	}
+
+	// pricingInput collects all data the pricing engine derives the new prices from
+	pricingInput struct {
+		current      hostPrices
+		market       []hostPrices
+		totalSectors uint64
+		freeSectors  uint64
+		revenue      common.BigInt
+		lastRevenue  common.BigInt
+	}
+)
+
+// validate check whether the pricing config is valid
+func (config PricingConfig) validate() error {
+	if config.UpdateInterval == 0 {
+		return errZeroPricingInterval
+	}
+	if config.AnnounceThreshold <= 0 {
+		return errInvalidAnnounceThreshold
+	}
+	bounds := [][2]common.BigInt{
+		{config.MinStoragePrice, config.MaxStoragePrice},
+		{config.MinUploadBandwidthPrice, config.MaxUploadBandwidthPrice},
+		{config.MinDownloadBandwidthPrice, config.MaxDownloadBandwidthPrice},
+		{config.MinContractPrice, config.MaxContractPrice},
+	}
+	for _, bound := range bounds {
+		if bound[1].Sign() > 0 && bound[0].Cmp(bound[1]) > 0 {
+			return errInvalidPriceBounds
+		}
+	}
+	return nil
+}
+
+// pricesFromConfig extract the prices adjusted by the pricing engine from the host config
+func pricesFromConfig(config storage.HostIntConfig) hostPrices {
+	return hostPrices{
+		StoragePrice:           config.StoragePrice,
+		UploadBandwidthPrice:   config.UploadBandwidthPrice,
+		DownloadBandwidthPrice: config.DownloadBandwidthPrice,
+		ContractPrice:          config.ContractPrice,
+	}
+}
+
+// pricesFromExtConfig extract the prices from the external config of another host
+func pricesFromExtConfig(config storage.HostExtConfig) hostPrices {
+	return hostPrices{
+		StoragePrice:           config.StoragePrice,
+		UploadBandwidthPrice:   config.UploadBandwidthPrice,
+		DownloadBandwidthPrice: config.DownloadBandwidthPrice,
+		ContractPrice:          config.ContractPrice,
+	}
+}
+
+// apply write the prices to the host config
+func (p hostPrices) apply(config *storage.HostIntConfig) {
+	config.StoragePrice = p.StoragePrice
+	config.UploadBandwidthPrice = p.UploadBandwidthPrice
+	config.DownloadBandwidthPrice = p.DownloadBandwidthPrice
+	config.ContractPrice = p.ContractPrice
+}
+
+// totalRevenue returns the realized and potential revenue recorded in the financial metrics.
+// The growth of the value between two pricing updates is used as the demand signal
+func (fm HostFinancialMetrics) totalRevenue() common.BigInt {
+	return fm.ContractCompensation.Add(fm.PotentialContractCompensation).
+		Add(fm.StorageRevenue).Add(fm.PotentialStorageRevenue).
+		Add(fm.UploadBandwidthRevenue).Add(fm.PotentialUploadBandwidthRevenue).
+		Add(fm.DownloadBandwidthRevenue).Add(fm.PotentialDownloadBandwidthRevenue)
+}
+
+// calculatePrices calculate the new prices of the host. The reference price is the median
+// of the market prices, or the current price if no market data is available. Storage and
+// contract prices are scaled by the storage utilization, and all prices are scaled by the
+// demand derived from the revenue history. The change of a single update is limited by
+// maxPriceChangePercent, and the result is clamped to the operator-set bounds.
+func calculatePrices(config PricingConfig, in pricingInput) hostPrices {
+	utilization := utilizationMultiplier(in.totalSectors, in.freeSectors)
+	demand := demandMultiplier(in.revenue, in.lastRevenue)
+
+	var market [4][]common.BigInt
+	for _, mp := range in.market {
+		market[0] = append(market[0], mp.StoragePrice)
+		market[1] = append(market[1], mp.UploadBandwidthPrice)
+		market[2] = append(market[2], mp.DownloadBandwidthPrice)
+		market[3] = append(market[3], mp.ContractPrice)
+	}
+
+	return hostPrices{
+		StoragePrice: calculatePrice(in.current.StoragePrice, market[0], utilization*demand,
+			config.MinStoragePrice, config.MaxStoragePrice),
+		UploadBandwidthPrice: calculatePrice(in.current.UploadBandwidthPrice, market[1], demand,
+			config.MinUploadBandwidthPrice, config.MaxUploadBandwidthPrice),
+		DownloadBandwidthPrice: calculatePrice(in.current.DownloadBandwidthPrice, market[2], demand,
+			config.MinDownloadBandwidthPrice, config.MaxDownloadBandwidthPrice),
+		ContractPrice: calculatePrice(in.current.ContractPrice, market[3], utilization*demand,
+			config.MinContractPrice, config.MaxContractPrice),
+	}
+}
+
+// calculatePrice calculate a single price based on the current price, the market prices,
+// the multiplier and the bounds
+func calculatePrice(current common.BigInt, market []common.BigInt, multiplier float64, min, max common.BigInt) common.BigInt {
+	reference := current
+	if len(market) != 0 {
+		reference = medianPrice(market)
+	}
+	target := reference.MultFloat64(multiplier)
+
+	// limit the change rate to prevent the price from oscillating
+	if current.Sign() > 0 {
+		lower := current.MultUint64(100 - maxPriceChangePercent).DivUint64(100)
+		upper := current.MultUint64(100 + maxPriceChangePercent).DivUint64(100)
+		if target.Cmp(lower) < 0 {
+			target = lower
+		}
+		if target.Cmp(upper) > 0 {
+			target = upper
+		}
+	}
+
+	// clamp to the operator-set bounds
+	if min.Sign() > 0 && target.Cmp(min) < 0 {
+		target = min
+	}
+	if max.Sign() > 0 && target.Cmp(max) > 0 {
+		target = max
+	}
+	return target
+}
+
+// medianPrice returns the median of the prices. The input must not be empty
+func medianPrice(prices []common.BigInt) common.BigInt {
+	sorted := make([]common.BigInt, len(prices))
+	copy(sorted, prices)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Cmp(sorted[j]) < 0
+	})
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return sorted[mid-1].Add(sorted[mid]).DivUint64(2)
+}
+
+// utilizationMultiplier returns the price multiplier based on the storage utilization.
+// An empty host lowers its price to attract clients, and a nearly full host raises its
+// price. If the host has no storage at all, the multiplier is 1
+func utilizationMultiplier(totalSectors, freeSectors uint64) float64 {
+	if totalSectors == 0 || freeSectors > totalSectors {
+		return 1
+	}
+	utilization := float64(totalSectors-freeSectors) / float64(totalSectors)
+	return minUtilizationMultiplier + (maxUtilizationMultiplier-minUtilizationMultiplier)*utilization
+}
+
+// demandMultiplier returns the price multiplier based on the revenue growth since the
+// last pricing update. Growing revenue raises the price, and stale revenue lowers it.
+// If there is no revenue history, the multiplier is 1
+func demandMultiplier(revenue, lastRevenue common.BigInt) float64 {
+	if lastRevenue.Sign() == 0 {
+		return 1
+	}
+	if revenue.Cmp(lastRevenue) > 0 {
+		return 1 + demandPriceStep
+	}
+	return 1 - demandPriceStep
+}
+
+// pricesChanged check whether any of the new prices moved past the threshold relative to
+// the prices that were announced
+func pricesChanged(announced, prices hostPrices, threshold float64) bool {
+	pairs := [][2]common.BigInt{
+		{announced.StoragePrice, prices.StoragePrice},
+		{announced.UploadBandwidthPrice, prices.UploadBandwidthPrice},
+		{announced.DownloadBandwidthPrice, prices.DownloadBandwidthPrice},
+		{announced.ContractPrice, prices.ContractPrice},
+	}
+	for _, pair := range pairs {
+		if pair[0].Sign() == 0 {
+			if pair[1].Sign() != 0 {
+				return true
+			}
+			continue
+		}
+		diff := pair[1].Sub(pair[0])
+		if diff.IsNeg() {
+			diff = common.BigInt0.Sub(diff)
+		}
+		if diff.DivWithFloatResult(pair[0]) >= threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// recordAnnouncedHosts analyze the applied blocks and record the enode url of the announced
+// storage hosts, which are sampled later for the market prices. The hosts are recorded even
+// if auto pricing is disabled, so that the market history is available once it is enabled
+func (h *StorageHost) recordAnnouncedHosts(blocks []common.Hash) {
+	var urls []string
+	for _, hash := range blocks {
+		block, err := h.ethBackend.GetBlockByHash(hash)
+		if err != nil {
+			continue
+		}
+		for _, tx := range block.Transactions() {
+			if tx.To() == nil {
+				continue
+			}
+			if p, ok := vm.PrecompiledEVMFileContracts[*tx.To()]; !ok || p != vm.HostAnnounceTransaction {
+				continue
+			}
+			var ha types.HostAnnouncement
+			if err := rlp.DecodeBytes(tx.Data(), &ha); err != nil {
+				continue
+			}
+			urls = append(urls, ha.NetAddress)
+		}
+	}
+	if len(urls) == 0 {
+		return
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for _, url := range urls {
+		h.addAnnouncedHost(url)
+	}
+}
+
+// addAnnouncedHost add the url to the announced hosts. If the url is already known, it is
+// moved to the end of the list. The list is capped by maxTrackedHosts, dropping the oldest.
+// Require: lock the storageHost by caller
+func (h *StorageHost) addAnnouncedHost(url string) {
+	hosts := h.pricingState.AnnouncedHosts
+	for i, known := range hosts {
+		if known == url {
+			hosts = append(hosts[:i], hosts[i+1:]...)
+			break
+		}
+	}
+	hosts = append(hosts, url)
+	if len(hosts) > maxTrackedHosts {
+		hosts = hosts[len(hosts)-maxTrackedHosts:]
+	}
+	h.pricingState.AnnouncedHosts = hosts
+}
+
+// checkAutoPricing starts a price update in the background if auto pricing is enabled and
+// the update interval has passed since the last update
+func (h *StorageHost) checkAutoPricing() {
+	h.lock.RLock()
+	enabled := h.pricingConfig.AutoPricing
+	interval := h.pricingConfig.UpdateInterval
+	due := h.blockHeight >= h.pricingState.LastUpdateHeight+interval
+	h.lock.RUnlock()
+
+	if !enabled || !due {
+		return
+	}
+	// only one update is allowed at a time
+	if !h.pricingLock.TryLock() {
+		return
+	}
+	go func() {
+		defer h.pricingLock.Unlock()
+		h.updatePrices()
+	}()
+}
+
+// updatePrices samples the market prices, calculates the new prices and applies them to the
+// host config. If the prices moved past the announce threshold, the host is re-announced
+func (h *StorageHost) updatePrices() {
+	if err := h.tm.Add(); err != nil {
+		return
+	}
+	defer h.tm.Done()
+
+	market := h.sampleMarketPrices()
+	space := h.StorageManager.AvailableSpace()
+
+	h.lock.Lock()
+	in := pricingInput{
+		current:      pricesFromConfig(h.config),
+		market:       market,
+		totalSectors: space.TotalSectors,
+		freeSectors:  space.FreeSectors,
+		revenue:      h.financialMetrics.totalRevenue(),
+		lastRevenue:  h.pricingState.LastRevenue,
+	}
+	prices := calculatePrices(h.pricingConfig, in)
+	prices.apply(&h.config)
+	h.pricingState.LastUpdateHeight = h.blockHeight
+	h.pricingState.LastRevenue = in.revenue
+
+	announce := h.config.AcceptingContracts && pricesChanged(h.pricingState.AnnouncedPrices, prices, h.pricingConfig.AnnounceThreshold)
+	if err := h.syncConfig(); err != nil {
+		h.log.Warn("failed to save the config after price update", "err", err)
+	}
+	h.lock.Unlock()
+
+	h.log.Info("Storage host prices updated", "storage", prices.StoragePrice, "upload", prices.UploadBandwidthPrice,
+		"download", prices.DownloadBandwidthPrice, "contract", prices.ContractPrice, "samples", len(market))
+
+	if !announce {
+		return
+	}
+	if _, err := h.announce(); err != nil {
+		h.log.Warn("failed to re-announce the storage host after price update", "err", err)
+	}
+}
+
+// sampleMarketPrices randomly picks some of the announced hosts, and retrieves their prices
+// through the storage protocol. Hosts that cannot be reached are skipped
+func (h *StorageHost) sampleMarketPrices() []hostPrices {
+	h.lock.RLock()
+	hosts := make([]string, len(h.pricingState.AnnouncedHosts))
+	copy(hosts, h.pricingState.AnnouncedHosts)
+	h.lock.RUnlock()
+
+	self := h.ethBackend.SelfEnodeURL()
+	rand.Shuffle(len(hosts), func(i, j int) {
+		hosts[i], hosts[j] = hosts[j], hosts[i]
+	})
+
+	var market []hostPrices
+	for _, url := range hosts {
+		if len(market) >= marketSampleSize {
+			break
+		}
+		if url == self {
+			continue
+		}
+		node, err := enode.ParseV4(url)
+		if err != nil {
+			continue
+		}
+		var config storage.HostExtConfig
+		if err := h.ethBackend.GetStorageHostSetting(node.ID(), url, &config); err != nil {
+			h.log.Debug("failed to sample the host price", "host", url, "err", err)
+			continue
+		}
+		if !config.AcceptingContracts {
+			continue
+		}
+		market = append(market, pricesFromExtConfig(config))
+
+		select {
+		case <-h.tm.StopChan():
+			return market
+		default:
+		}
+	}
+	return market
+}
+
+// getPricingConfig returns the pricing config of the host
+func (h *StorageHost) getPricingConfig() (PricingConfig, pricingState) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.pricingConfig, h.pricingState
+}
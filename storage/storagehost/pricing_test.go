@@ -0,0 +1,211 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// pricingTestBackend is the host backend returning the block with a host announcement
+type pricingTestBackend struct {
+	storage.HostBackend
+	block   *types.Block
+	queried int
+}
+
+func (b *pricingTestBackend) GetBlockByHash(hash common.Hash) (*types.Block, error) {
+	b.queried++
+	return b.block, nil
+}
+
+func TestCalculatePrice(t *testing.T) {
+	tests := []struct {
+		current    int64
+		market     []int64
+		multiplier float64
+		min, max   int64
+		expect     int64
+	}{
+		// no market data, no adjustment
+		{1000, nil, 1, 0, 0, 1000},
+		// follow the market median within the change rate
+		{1000, []int64{900, 1100, 1050}, 1, 0, 0, 1050},
+		// change is limited by maxPriceChangePercent
+		{1000, []int64{5000}, 1, 0, 0, 1200},
+		{1000, []int64{10}, 1, 0, 0, 800},
+		// multiplier applies to the reference price
+		{1000, nil, 1.1, 0, 0, 1100},
+		// clamped to the operator-set bounds
+		{1000, nil, 1.1, 0, 1050, 1050},
+		{1000, nil, 0.9, 950, 0, 950},
+		// zero current price is not limited by the change rate
+		{0, []int64{300, 500}, 1, 0, 0, 400},
+	}
+	for i, test := range tests {
+		var market []common.BigInt
+		for _, p := range test.market {
+			market = append(market, common.NewBigInt(p))
+		}
+		res := calculatePrice(common.NewBigInt(test.current), market, test.multiplier, common.NewBigInt(test.min), common.NewBigInt(test.max))
+		if res.Cmp(common.NewBigInt(test.expect)) != 0 {
+			t.Errorf("test %d: expect price %v, got %v", i, test.expect, res)
+		}
+	}
+}
+
+func TestCalculatePrices(t *testing.T) {
+	current := hostPrices{
+		StoragePrice:           common.NewBigInt(1000),
+		UploadBandwidthPrice:   common.NewBigInt(1000),
+		DownloadBandwidthPrice: common.NewBigInt(1000),
+		ContractPrice:          common.NewBigInt(1000),
+	}
+	// a full host with growing revenue raises storage prices more than bandwidth prices
+	in := pricingInput{
+		current:      current,
+		totalSectors: 100,
+		freeSectors:  0,
+		revenue:      common.NewBigInt(20),
+		lastRevenue:  common.NewBigInt(10),
+	}
+	prices := calculatePrices(defaultPricingConfig(), in)
+	if prices.StoragePrice.Cmp(common.NewBigInt(1200)) != 0 {
+		t.Errorf("storage price not expected: %v", prices.StoragePrice)
+	}
+	if prices.ContractPrice.Cmp(common.NewBigInt(1200)) != 0 {
+		t.Errorf("contract price not expected: %v", prices.ContractPrice)
+	}
+	if prices.UploadBandwidthPrice.Cmp(common.NewBigInt(1050)) != 0 {
+		t.Errorf("upload price not expected: %v", prices.UploadBandwidthPrice)
+	}
+	// a half used host without revenue history keeps its prices
+	in = pricingInput{
+		current:      current,
+		totalSectors: 100,
+		freeSectors:  50,
+	}
+	prices = calculatePrices(defaultPricingConfig(), in)
+	if pricesChanged(current, prices, 0.0001) {
+		t.Errorf("prices should not change: %+v", prices)
+	}
+}
+
+func TestMedianPrice(t *testing.T) {
+	tests := []struct {
+		prices []int64
+		expect int64
+	}{
+		{[]int64{1}, 1},
+		{[]int64{3, 1, 2}, 2},
+		{[]int64{4, 1, 3, 2}, 2},
+	}
+	for i, test := range tests {
+		var prices []common.BigInt
+		for _, p := range test.prices {
+			prices = append(prices, common.NewBigInt(p))
+		}
+		if res := medianPrice(prices); res.Cmp(common.NewBigInt(test.expect)) != 0 {
+			t.Errorf("test %d: expect median %v, got %v", i, test.expect, res)
+		}
+	}
+}
+
+func TestPricesChanged(t *testing.T) {
+	announced := hostPrices{
+		StoragePrice:           common.NewBigInt(100),
+		UploadBandwidthPrice:   common.NewBigInt(100),
+		DownloadBandwidthPrice: common.NewBigInt(100),
+		ContractPrice:          common.NewBigInt(100),
+	}
+	prices := announced
+	prices.DownloadBandwidthPrice = common.NewBigInt(95)
+	if pricesChanged(announced, prices, 0.1) {
+		t.Error("5% change should not pass the 10% threshold")
+	}
+	prices.DownloadBandwidthPrice = common.NewBigInt(90)
+	if !pricesChanged(announced, prices, 0.1) {
+		t.Error("10% change should pass the 10% threshold")
+	}
+	if !pricesChanged(hostPrices{}, announced, 0.1) {
+		t.Error("prices never announced should be treated as changed")
+	}
+}
+
+func TestHostPrivateAPI_SetPricingConfig(t *testing.T) {
+	h := newTestStorageHost(t)
+	api := NewHostPrivateAPI(h)
+
+	if _, err := api.SetPricingConfig(map[string]string{
+		"autoPricing":       "true",
+		"announceThreshold": "5%",
+		"minStoragePrice":   "1camel",
+		"maxStoragePrice":   "10camel",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	config, _ := h.getPricingConfig()
+	if !config.AutoPricing {
+		t.Error("auto pricing not enabled")
+	}
+	if config.AnnounceThreshold != 0.05 {
+		t.Errorf("announce threshold not expected: %v", config.AnnounceThreshold)
+	}
+	if config.MaxStoragePrice.Cmp(mustParseCurrency("10camel")) != 0 {
+		t.Errorf("max storage price not expected: %v", config.MaxStoragePrice)
+	}
+
+	// invalid bounds shall not change the config
+	if _, err := api.SetPricingConfig(map[string]string{
+		"minStoragePrice": "100camel",
+	}); err != errInvalidPriceBounds {
+		t.Fatalf("expect error %v, got %v", errInvalidPriceBounds, err)
+	}
+	config, _ = h.getPricingConfig()
+	if config.MinStoragePrice.Cmp(mustParseCurrency("1camel")) != 0 {
+		t.Errorf("min storage price changed on error: %v", config.MinStoragePrice)
+	}
+	if _, err := api.SetPricingConfig(map[string]string{"unknown": "1"}); err == nil {
+		t.Error("unknown key should give error")
+	}
+}
+
+func TestStorageHost_RecordAnnouncedHosts(t *testing.T) {
+	h := newTestStorageHost(t)
+
+	var to common.Address
+	for addr, typ := range vm.PrecompiledEVMFileContracts {
+		if typ == vm.HostAnnounceTransaction {
+			to = addr
+		}
+	}
+	data, err := rlp.EncodeToBytes(types.HostAnnouncement{NetAddress: "enode://host"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := types.NewTransaction(0, to, new(big.Int), 0, new(big.Int), data)
+	backend := &pricingTestBackend{block: types.NewBlock(&types.Header{}, types.Transactions{tx}, nil, nil)}
+	h.ethBackend = backend
+
+	// the announced hosts are recorded even if auto pricing is disabled
+	h.pricingConfig.AutoPricing = false
+	h.recordAnnouncedHosts([]common.Hash{{1}})
+	if backend.queried != 1 || len(h.pricingState.AnnouncedHosts) != 1 || h.pricingState.AnnouncedHosts[0] != "enode://host" {
+		t.Errorf("announced hosts not recorded with auto pricing disabled: queried %v, hosts %v", backend.queried, h.pricingState.AnnouncedHosts)
+	}
+
+	// the known host is not recorded twice
+	h.pricingConfig.AutoPricing = true
+	h.recordAnnouncedHosts([]common.Hash{{1}})
+	if len(h.pricingState.AnnouncedHosts) != 1 {
+		t.Errorf("announced host recorded twice: %v", h.pricingState.AnnouncedHosts)
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"math/big"
+	"strconv"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/common/unit"
+	"github.com/DxChainNetwork/godx/core"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/event"
+	"github.com/DxChainNetwork/godx/internal/ethapi"
+	"github.com/DxChainNetwork/godx/metrics"
+)
+
+var (
+	proofSubmitCounter   = metrics.NewRegisteredCounter("storage/host/proof/submit", nil)
+	proofRetryCounter    = metrics.NewRegisteredCounter("storage/host/proof/retry", nil)
+	proofFallbackCounter = metrics.NewRegisteredCounter("storage/host/proof/fallback", nil)
+	proofAlertCounter    = metrics.NewRegisteredCounter("storage/host/proof/alert", nil)
+	proofMissedCounter   = metrics.NewRegisteredCounter("storage/host/proof/missed", nil)
+)
+
+type (
+	// ProofConfig is the configuration for submitting storage proofs. The storage proof
+	// is resubmitted every RetryInterval blocks with the gas price bumped by GasPriceBump
+	// percent until it is included in a block. If the proof cannot be sent from the host
+	// payment address, it is sent from the FallbackAddress. An alert is raised once the
+	// proof is within AlertWindow blocks of its deadline and still not confirmed
+	ProofConfig struct {
+		FallbackAddress common.Address `json:"fallbackAddress"`
+		RetryInterval   uint64         `json:"retryInterval"`
+		AlertWindow     uint64         `json:"alertWindow"`
+		GasPriceBump    uint64         `json:"gasPriceBump"`
+		MaxGasPrice     common.BigInt  `json:"maxGasPrice"`
+	}
+
+	// ProofConfigForDisplay is the proof config for display
+	ProofConfigForDisplay struct {
+		FallbackAddress string `json:"fallbackAddress"`
+		RetryInterval   string `json:"retryInterval"`
+		AlertWindow     string `json:"alertWindow"`
+		GasPriceBump    string `json:"gasPriceBump"`
+		MaxGasPrice     string `json:"maxGasPrice"`
+	}
+
+	// ProofAlert is the event sent when the storage proof is close to its deadline and
+	// still not confirmed
+	ProofAlert struct {
+		ContractID  common.Hash `json:"contractID"`
+		TxHash      common.Hash `json:"txHash"`
+		BlockHeight uint64      `json:"blockHeight"`
+		Deadline    uint64      `json:"deadline"`
+		Attempts    uint64      `json:"attempts"`
+	}
+
+	// proofSubmission is the record of the latest storage proof tx sent for a contract
+	proofSubmission struct {
+		TxHash   common.Hash    `json:"txHash"`
+		From     common.Address `json:"from"`
+		Nonce    uint64         `json:"nonce"`
+		GasPrice *big.Int       `json:"gasPrice"`
+		Attempts uint64         `json:"attempts"`
+	}
+)
+
+// validate check whether the proof config is valid
+func (config ProofConfig) validate() error {
+	if config.RetryInterval == 0 {
+		return errZeroProofRetryInterval
+	}
+	if config.GasPriceBump < minGasPriceBump {
+		return errLowGasPriceBump
+	}
+	return nil
+}
+
+// bumpGasPrice increase the gas price by percent. The result is limited by the maximum
+// gas price if the maximum gas price is positive
+func bumpGasPrice(price *big.Int, percent uint64, max common.BigInt) *big.Int {
+	bumped := new(big.Int).Mul(price, new(big.Int).SetUint64(100+percent))
+	bumped.Div(bumped, big.NewInt(100))
+	if max.Sign() > 0 && bumped.Cmp(max.BigIntPtr()) > 0 {
+		bumped = max.BigIntPtr()
+	}
+	return bumped
+}
+
+// nextProofCheckHeight returns the block height to check the storage proof inclusion. If
+// the retry height passes the proof deadline, the proof is checked right after the deadline
+func nextProofCheckHeight(blockHeight, deadline, retryInterval uint64) uint64 {
+	next := blockHeight + retryInterval
+	if next > deadline {
+		next = deadline + 1
+	}
+	return next
+}
+
+// submitStorageProof send the storage proof tx. If a proof tx has been sent for the contract,
+// the tx is replaced by the one with the same nonce and bumped gas price. If the nonce has
+// been used by the proof tx itself, which is included in the chain or pending in the pool, the
+// proof is not resent. If the nonce has been used by another tx, or the proof cannot be sent from
+// the host payment address, a new tx is sent, falling back to the fallback address if configured.
+// Require: lock the storageHost by caller
+func (h *StorageHost) submitStorageProof(so StorageResponsibility, input []byte) error {
+	id := so.id()
+	from := so.OriginStorageContract.ValidProofOutputs[1].Address
+	config := h.proofConfig
+
+	var nonce *uint64
+	var gasPrice *big.Int
+	record, exist := h.proofSubmissions[id]
+	if exist {
+		from = record.From
+		nonce = &record.Nonce
+		gasPrice = bumpGasPrice(record.GasPrice, config.GasPriceBump, config.MaxGasPrice)
+		proofRetryCounter.Inc(1)
+	}
+
+	tx, err := h.sendStorageProofTx(from, input, nonce, gasPrice)
+	if err == core.ErrNonceTooLow {
+		if exist && h.storageTxKnown(record.TxHash) {
+			h.log.Debug("Storage proof tx already sent", "id", id, "tx", record.TxHash)
+			return nil
+		}
+		// the nonce has been used by another tx, resend with a new nonce
+		tx, err = h.sendStorageProofTx(from, input, nil, gasPrice)
+	}
+	if err != nil && err != core.ErrReplaceUnderpriced && err != ethapi.ErrStorageTxQueued && config.FallbackAddress != (common.Address{}) && from != config.FallbackAddress {
+		h.log.Warn("Failed to send storage proof, fall back to the alternate account", "id", id, "from", from, "err", err)
+		proofFallbackCounter.Inc(1)
+		from = config.FallbackAddress
+		tx, err = h.sendStorageProofTx(from, input, nil, gasPrice)
+	}
+	if err != nil && err != ethapi.ErrStorageTxQueued {
+		return err
+	}
+
+	// the queued proof is tracked by the nonce, so that the retry replaces the unsigned tx
+	// in the offline queue instead of queueing another one
+	proofSubmitCounter.Inc(1)
+	newRecord := &proofSubmission{
+		TxHash:   tx.Hash(),
+		From:     from,
+		Nonce:    tx.Nonce(),
+		GasPrice: tx.GasPrice(),
+		Attempts: 1,
+	}
+	if exist {
+		newRecord.Attempts = record.Attempts + 1
+	}
+	h.proofSubmissions[id] = newRecord
+	if err == ethapi.ErrStorageTxQueued {
+		h.log.Info("Storage proof queued for offline signing", "id", id, "nonce", tx.Nonce(), "gasPrice", tx.GasPrice(), "attempts", newRecord.Attempts)
+		return nil
+	}
+	h.log.Info("Storage proof submitted", "id", id, "tx", tx.Hash(), "gasPrice", tx.GasPrice(), "attempts", newRecord.Attempts)
+	return nil
+}
+
+// checkProofDeadline raise the alert if the storage proof is within the alert window of
+// its deadline and still not confirmed
+// Require: lock the storageHost by caller
+func (h *StorageHost) checkProofDeadline(so StorageResponsibility) {
+	deadline := so.proofDeadline()
+	if so.StorageProofConfirmed || h.blockHeight+h.proofConfig.AlertWindow < deadline {
+		return
+	}
+	alert := ProofAlert{
+		ContractID:  so.id(),
+		BlockHeight: h.blockHeight,
+		Deadline:    deadline,
+	}
+	if record, exist := h.proofSubmissions[alert.ContractID]; exist {
+		alert.TxHash = record.TxHash
+		alert.Attempts = record.Attempts
+	}
+	h.log.Warn("Storage proof is close to deadline and not confirmed", "id", alert.ContractID, "height", alert.BlockHeight, "deadline", alert.Deadline, "attempts", alert.Attempts)
+	proofAlertCounter.Inc(1)
+	h.proofAlertFeed.Send(alert)
+}
+
+// subscribeProofAlert subscribe the storage proof alerts
+func (h *StorageHost) subscribeProofAlert(ch chan<- ProofAlert) event.Subscription {
+	return h.proofAlertScope.Track(h.proofAlertFeed.Subscribe(ch))
+}
+
+// getProofConfig returns the proof config of the host
+func (h *StorageHost) getProofConfig() ProofConfig {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.proofConfig
+}
+
+// sendStorageProofTx send storage proof tx with the nonce and gas price. The nil value is
+// filled with the default one
+func (h *StorageHost) sendStorageProofTx(from common.Address, input []byte, nonce *uint64, gasPrice *big.Int) (*types.Transaction, error) {
+	return h.parseAPI.StorageTx.SendStorageProofTXWithArgs(from, input, nonce, gasPrice)
+}
+
+// storageTxKnown returns whether the storage contract tx is included in the chain or pending
+// in the tx pool
+func (h *StorageHost) storageTxKnown(hash common.Hash) bool {
+	return h.parseAPI.StorageTx.StorageContractTxKnown(hash)
+}
+
+// formatProofConfig format the proof config for display
+func formatProofConfig(config ProofConfig) ProofConfigForDisplay {
+	display := ProofConfigForDisplay{
+		RetryInterval: unit.FormatTime(config.RetryInterval),
+		AlertWindow:   unit.FormatTime(config.AlertWindow),
+		GasPriceBump:  strconv.FormatUint(config.GasPriceBump, 10) + "%",
+		MaxGasPrice:   "unlimited",
+	}
+	if config.FallbackAddress != (common.Address{}) {
+		display.FallbackAddress = config.FallbackAddress.Hex()
+	}
+	if config.MaxGasPrice.Sign() > 0 {
+		display.MaxGasPrice = unit.FormatCurrency(config.MaxGasPrice)
+	}
+	return display
+}
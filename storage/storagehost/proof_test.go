@@ -0,0 +1,154 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+)
+
+func TestBumpGasPrice(t *testing.T) {
+	tests := []struct {
+		price   int64
+		percent uint64
+		max     int64
+		expect  int64
+	}{
+		{100, 20, 0, 120},
+		{100, 10, 0, 110},
+		{1000, 20, 1100, 1100},
+		{1000, 20, 2000, 1200},
+	}
+	for i, test := range tests {
+		res := bumpGasPrice(big.NewInt(test.price), test.percent, common.NewBigInt(test.max))
+		if res.Cmp(big.NewInt(test.expect)) != 0 {
+			t.Errorf("test %d: expect gas price %v, got %v", i, test.expect, res)
+		}
+	}
+}
+
+func TestNextProofCheckHeight(t *testing.T) {
+	tests := []struct {
+		height, deadline, interval uint64
+		expect                     uint64
+	}{
+		{100, 200, 40, 140},
+		{180, 200, 40, 201},
+		{200, 200, 40, 201},
+	}
+	for i, test := range tests {
+		if res := nextProofCheckHeight(test.height, test.deadline, test.interval); res != test.expect {
+			t.Errorf("test %d: expect height %v, got %v", i, test.expect, res)
+		}
+	}
+}
+
+func TestStorageHost_CheckProofDeadline(t *testing.T) {
+	h := newTestStorageHost(t)
+	h.proofConfig.AlertWindow = 10
+
+	alerts := make(chan ProofAlert, 1)
+	sub := h.subscribeProofAlert(alerts)
+	defer sub.Unsubscribe()
+
+	so := StorageResponsibility{
+		OriginStorageContract: types.StorageContract{WindowStart: 50, WindowEnd: 100},
+	}
+	h.proofSubmissions[so.id()] = &proofSubmission{TxHash: common.HexToHash("0x1"), Attempts: 2}
+
+	// out of the alert window
+	h.blockHeight = 89
+	h.checkProofDeadline(so)
+	select {
+	case alert := <-alerts:
+		t.Fatalf("unexpected alert: %+v", alert)
+	default:
+	}
+
+	// within the alert window
+	h.blockHeight = 90
+	h.checkProofDeadline(so)
+	select {
+	case alert := <-alerts:
+		if alert.ContractID != so.id() || alert.Deadline != 100 || alert.Attempts != 2 || alert.TxHash != common.HexToHash("0x1") {
+			t.Errorf("alert not expected: %+v", alert)
+		}
+	default:
+		t.Fatal("alert not raised")
+	}
+
+	// confirmed proof raises no alert
+	so.StorageProofConfirmed = true
+	h.checkProofDeadline(so)
+	select {
+	case alert := <-alerts:
+		t.Fatalf("unexpected alert: %+v", alert)
+	default:
+	}
+}
+
+func TestHostPrivateAPI_SetProofConfig(t *testing.T) {
+	h := newTestStorageHost(t)
+	api := NewHostPrivateAPI(h)
+
+	if _, err := api.SetProofConfig(map[string]string{
+		"retryInterval": "10b",
+		"alertWindow":   "2h",
+		"gasPriceBump":  "50%",
+		"maxGasPrice":   "1gcamel",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	config := h.getProofConfig()
+	if config.RetryInterval != 10 || config.GasPriceBump != 50 {
+		t.Errorf("proof config not expected: %+v", config)
+	}
+	if config.MaxGasPrice.Cmp(mustParseCurrency("1gcamel")) != 0 {
+		t.Errorf("max gas price not expected: %v", config.MaxGasPrice)
+	}
+
+	// invalid config shall not change the config
+	if _, err := api.SetProofConfig(map[string]string{"gasPriceBump": "5"}); err != errLowGasPriceBump {
+		t.Errorf("expect error %v, got %v", errLowGasPriceBump, err)
+	}
+	if _, err := api.SetProofConfig(map[string]string{"retryInterval": "0b"}); err != errZeroProofRetryInterval {
+		t.Errorf("expect error %v, got %v", errZeroProofRetryInterval, err)
+	}
+	// the fallback account must be in the wallet
+	if _, err := api.SetProofConfig(map[string]string{"fallbackAddress": common.HexToAddress("0x1").Hex()}); err == nil {
+		t.Error("unknown fallback account should give error")
+	}
+	if config = h.getProofConfig(); config.GasPriceBump != 50 || config.FallbackAddress != (common.Address{}) {
+		t.Errorf("proof config changed on error: %+v", config)
+	}
+}
+
+func TestStorageHost_PersistProofSubmissions(t *testing.T) {
+	h := newTestStorageHost(t)
+	id := common.HexToHash("0x2")
+	record := &proofSubmission{
+		TxHash:   common.HexToHash("0x1"),
+		From:     common.HexToAddress("0x3"),
+		Nonce:    4,
+		GasPrice: big.NewInt(5),
+		Attempts: 2,
+	}
+	h.proofSubmissions[id] = record
+	if err := h.syncConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := &StorageHost{persistDir: h.persistDir, proofSubmissions: make(map[common.Hash]*proofSubmission)}
+	if err := loaded.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	got, exist := loaded.proofSubmissions[id]
+	if !exist || got.TxHash != record.TxHash || got.From != record.From || got.Nonce != record.Nonce || got.GasPrice.Cmp(record.GasPrice) != 0 || got.Attempts != record.Attempts {
+		t.Errorf("proof submission not persisted: %+v", got)
+	}
+}
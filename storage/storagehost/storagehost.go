@@ -15,6 +15,7 @@ import (
 	"github.com/DxChainNetwork/godx/common"
 	tm "github.com/DxChainNetwork/godx/common/threadmanager"
 	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/event"
 	"github.com/DxChainNetwork/godx/log"
 	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/storage"
@@ -35,6 +36,30 @@ type StorageHost struct {
 	config           storage.HostIntConfig
 	financialMetrics HostFinancialMetrics
 
+	// automatic pricing engine
+	pricingConfig PricingConfig
+	pricingState  pricingState
+	pricingLock   TryMutex
+
+	// policy for accepting storage contracts, the data stored and reserved by the uploads
+	// in progress of each client for the storage quota, and the contracts renewed mapped to
+	// the renewing ones
+	contractPolicy   ContractPolicy
+	clientStored     map[common.Address]uint64
+	clientReserved   map[common.Address]uint64
+	renewedContracts map[common.Hash]common.Hash
+
+	// storage proof submission tracking and alerts
+	proofConfig      ProofConfig
+	proofSubmissions map[common.Hash]*proofSubmission
+	proofAlertFeed   event.Feed
+	proofAlertScope  event.SubscriptionScope
+
+	// host announcement and automatic re-announcement
+	announceConfig AnnounceConfig
+	announceState  announceState
+	announceLock   TryMutex
+
 	// storage host manager for manipulating the file storage system
 	sm.StorageManager
 
@@ -109,6 +134,10 @@ func New(persistDir string) (*StorageHost, error) {
 		persistDir:                  persistDir,
 		lockedStorageResponsibility: make(map[common.Hash]*TryMutex),
 		clientToContract:            make(map[string]common.Hash),
+		proofSubmissions:            make(map[common.Hash]*proofSubmission),
+		clientStored:                make(map[common.Address]uint64),
+		clientReserved:              make(map[common.Address]uint64),
+		renewedContracts:            make(map[common.Hash]common.Hash),
 	}
 
 	var err error
@@ -139,6 +168,10 @@ func (h *StorageHost) Start(eth storage.HostBackend) (err error) {
 	if err = h.load(); err != nil {
 		return err
 	}
+	// count the data stored by the clients for the storage quota
+	if err = h.loadClientStorage(); err != nil {
+		return err
+	}
 	// start the storage manager
 	if err = h.StorageManager.Start(); err != nil {
 		return err
@@ -161,6 +194,7 @@ func (h *StorageHost) Start(eth storage.HostBackend) (err error) {
 // Close the storage host and persist the data
 func (h *StorageHost) Close() error {
 	err := h.tm.Stop()
+	h.proofAlertScope.Close()
 
 	newErr := h.StorageManager.Close()
 	err = common.ErrCompose(err, newErr)
@@ -207,6 +241,9 @@ func (h *StorageHost) load() error {
 	}
 	// load the default config
 	h.config = defaultConfig()
+	h.pricingConfig = defaultPricingConfig()
+	h.proofConfig = defaultProofConfig()
+	h.announceConfig = defaultAnnounceConfig()
 
 	// and get synchronization
 	if syncErr := h.syncConfig(); syncErr != nil {
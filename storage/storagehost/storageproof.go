@@ -0,0 +1,187 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/crypto/merkle"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// segmentsPerSector is the number of segments in a sector
+const segmentsPerSector = storage.SectorSize / merkle.LeafSize
+
+// storageProofSegments returns the proof version of the storage fork active in the next block,
+// and the indices of the segments of the storage contract to prove under it
+func (h *StorageHost) storageProofSegments(fc types.StorageContractRevision) (uint64, []uint64, error) {
+	// the proof is checked under the storage fork of the block it is included in
+	config := h.ethBackend.GetBlockChain().Config()
+	if err := storage.CheckStorageFork(config, h.blockHeight+1); err != nil {
+		return 0, nil, err
+	}
+	fork := config.StorageFork(new(big.Int).SetUint64(h.blockHeight + 1))
+
+	block, err := h.ethBackend.GetBlockByNumber(fc.NewWindowStart - 1)
+	if err != nil {
+		return 0, nil, err
+	}
+	indices, err := vm.StorageProofSegments(fork.ProofVersion, block.Hash(), fc.ParentID, fc.NewFileSize)
+	if err != nil {
+		return 0, nil, err
+	}
+	return fork.ProofVersion, indices, nil
+}
+
+// singleSegmentProof builds the storage proof of the segment at the index
+func (h *StorageHost) singleSegmentProof(so StorageResponsibility, segmentIndex uint64) (types.StorageProof, error) {
+	sectorIndex := segmentIndex / segmentsPerSector
+	if sectorIndex >= uint64(len(so.SectorRoots)) {
+		return types.StorageProof{}, errors.New("segment index out of the sectors stored")
+	}
+	sectorBytes, err := h.ReadSector(so.SectorRoots[sectorIndex])
+	if err != nil {
+		return types.StorageProof{}, err
+	}
+
+	//Build a storage certificate for this storage contract
+	sectorSegment := segmentIndex % segmentsPerSector
+	base, cachedHashSet := merkleProof(sectorBytes, sectorSegment)
+	// Using the sector, build a cached root.
+	log2SectorSize := uint64(0)
+	for 1<<log2SectorSize < segmentsPerSector {
+		log2SectorSize++
+	}
+	ct := merkle.NewSha256CachedTree(log2SectorSize)
+	err = ct.SetStorageProofIndex(segmentIndex)
+	if err != nil {
+		h.log.Warn("cannot call SetIndex on Tree ", "err", err)
+	}
+	for _, root := range so.SectorRoots {
+		ct.Push(root)
+	}
+
+	sp := types.StorageProof{
+		HashSet: ct.Prove(base, cachedHashSet),
+	}
+	copy(sp.Segment[:], base)
+	return sp, nil
+}
+
+// multiSegmentProof builds the storage proof of the segments at the ascending indices of the
+// sectors, proved by one merkle multiproof shared among the segments
+func multiSegmentProof(roots []common.Hash, read func(common.Hash) ([]byte, error), fileSize uint64, segmentIndices []uint64) (types.StorageProof, error) {
+	sr := newSectorSubtreeRoot(roots, read)
+	hashSet, err := merkle.Sha256MultiSegmentProof(segmentIndices, sr, calculateLeaves(fileSize))
+	if err != nil {
+		return types.StorageProof{}, err
+	}
+
+	segments := make([][64]byte, len(segmentIndices))
+	for i, segmentIndex := range segmentIndices {
+		sector, err := sr.readSector(segmentIndex / segmentsPerSector)
+		if err != nil {
+			return types.StorageProof{}, err
+		}
+		offset := (segmentIndex % segmentsPerSector) * merkle.LeafSize
+		copy(segments[i][:], sector[offset:offset+merkle.LeafSize])
+	}
+
+	sp := types.StorageProof{
+		Segment: segments[0],
+		HashSet: hashSet,
+	}
+	if len(segments) > 1 {
+		sp.Segments = segments[1:]
+	}
+	return sp, nil
+}
+
+// sectorSubtreeRoot implements merkle.SubtreeRoot over the sectors stored by the host. The
+// subtrees not smaller than a sector are combined from the sector roots, so that only the
+// sectors containing the segments proved are read.
+type sectorSubtreeRoot struct {
+	roots     []common.Hash
+	read      func(common.Hash) ([]byte, error)
+	leafIndex uint64
+
+	// the sectors read, which are reused to retrieve the segments proved
+	sectors map[uint64][]byte
+}
+
+func newSectorSubtreeRoot(roots []common.Hash, read func(common.Hash) ([]byte, error)) *sectorSubtreeRoot {
+	return &sectorSubtreeRoot{
+		roots:   roots,
+		read:    read,
+		sectors: make(map[uint64][]byte),
+	}
+}
+
+// GetSubtreeRoot implements merkle.SubtreeRoot, returning the root of the next n segments
+func (sr *sectorSubtreeRoot) GetSubtreeRoot(n int) ([]byte, error) {
+	leaves := uint64(len(sr.roots)) * segmentsPerSector
+	if sr.leafIndex >= leaves {
+		return nil, io.EOF
+	}
+	end := sr.leafIndex + uint64(n)
+	if end > leaves {
+		end = leaves
+	}
+
+	tree := merkle.NewTree(sha256.New())
+	if uint64(n) >= segmentsPerSector {
+		height := 0
+		for 1<<uint(height) < segmentsPerSector {
+			height++
+		}
+		for i := sr.leafIndex / segmentsPerSector; i < end/segmentsPerSector; i++ {
+			if err := tree.PushSubTree(height, sr.roots[i][:]); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		sector, err := sr.readSector(sr.leafIndex / segmentsPerSector)
+		if err != nil {
+			return nil, err
+		}
+		for i := sr.leafIndex; i < end; i++ {
+			offset := (i % segmentsPerSector) * merkle.LeafSize
+			tree.PushLeaf(sector[offset : offset+merkle.LeafSize])
+		}
+	}
+	sr.leafIndex = end
+	return tree.Root(), nil
+}
+
+// Skip implements merkle.SubtreeRoot, skipping the next n segments
+func (sr *sectorSubtreeRoot) Skip(n int) error {
+	sr.leafIndex += uint64(n)
+	return nil
+}
+
+// readSector returns the sector at the index, reading it from the storage manager only once
+func (sr *sectorSubtreeRoot) readSector(index uint64) ([]byte, error) {
+	if sector, exist := sr.sectors[index]; exist {
+		return sector, nil
+	}
+	if index >= uint64(len(sr.roots)) {
+		return nil, errors.New("segment index out of the sectors stored")
+	}
+	sector, err := sr.read(sr.roots[index])
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(sector)) != storage.SectorSize {
+		return nil, errors.New("invalid size of the sector read")
+	}
+	sr.sectors[index] = sector
+	return sector, nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/crypto/merkle"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// Tests that the multi segment proof built from the sectors stored verifies against the merkle
+// root of the file, and that only the sectors containing the segments proved are read.
+func TestMultiSegmentProof(t *testing.T) {
+	sectors := make(map[common.Hash][]byte)
+	var roots []common.Hash
+	for i := 0; i < 3; i++ {
+		sector := make([]byte, storage.SectorSize)
+		rand.Read(sector)
+		root := merkle.Sha256MerkleTreeRoot(sector)
+		sectors[root] = sector
+		roots = append(roots, root)
+	}
+	fileSize := uint64(len(roots)) * storage.SectorSize
+	// the file merkle root is the root of the sector roots pushed at the sector height
+	height := uint64(0)
+	for 1<<height < segmentsPerSector {
+		height++
+	}
+	fileRoot := merkle.Sha256CachedTreeRoot(roots, height)
+
+	for i, indices := range [][]uint64{
+		{0},
+		{3*segmentsPerSector - 1},
+		{1, 2, 3, segmentsPerSector + 7},
+		{5, 2*segmentsPerSector + 100, 2*segmentsPerSector + 101},
+	} {
+		read := make(map[common.Hash]bool)
+		readSector := func(root common.Hash) ([]byte, error) {
+			sector, exist := sectors[root]
+			if !exist {
+				return nil, errors.New("sector not found")
+			}
+			read[root] = true
+			return sector, nil
+		}
+		sp, err := multiSegmentProof(roots, readSector, fileSize, indices)
+		if err != nil {
+			t.Fatalf("test %d: failed to build the multi segment proof: %v", i, err)
+		}
+
+		expectRead := make(map[common.Hash]bool)
+		segments := make([][]byte, 0, len(indices))
+		proved := append([][64]byte{sp.Segment}, sp.Segments...)
+		if len(proved) != len(indices) {
+			t.Fatalf("test %d: expect %d segments, got %d", i, len(indices), len(proved))
+		}
+		for j := range indices {
+			expectRead[roots[indices[j]/segmentsPerSector]] = true
+			segments = append(segments, proved[j][:])
+		}
+		if len(read) != len(expectRead) {
+			t.Errorf("test %d: expect %d sectors read, got %d", i, len(expectRead), len(read))
+		}
+		if err := merkle.Sha256VerifyMultiSegmentProof(segments, indices, calculateLeaves(fileSize), sp.HashSet, fileRoot); err != nil {
+			t.Errorf("test %d: failed to verify the multi segment proof: %v", i, err)
+		}
+	}
+}
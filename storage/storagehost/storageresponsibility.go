@@ -6,14 +6,14 @@ package storagehost
 
 import (
 	"bytes"
-	"math/big"
 	"reflect"
 
 	"github.com/DxChainNetwork/godx/accounts"
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/core/types"
-	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/internal/ethapi"
 	"github.com/DxChainNetwork/godx/crypto/merkle"
+	"github.com/DxChainNetwork/godx/params"
 	"github.com/DxChainNetwork/godx/rlp"
 	"github.com/DxChainNetwork/godx/storage"
 )
@@ -191,6 +191,7 @@ func (h *StorageHost) insertStorageResponsibility(so StorageResponsibility) erro
 		}
 
 		// Update the host financial metrics with regards to this storage responsibility.
+		h.updateClientStorage(so, so.fileSize(), 0)
 		h.financialMetrics.ContractCount++
 		h.financialMetrics.PotentialContractCompensation = h.financialMetrics.PotentialContractCompensation.Add(so.ContractCost)
 		h.financialMetrics.LockedStorageDeposit = h.financialMetrics.LockedStorageDeposit.Add(so.LockedStorageDeposit)
@@ -301,6 +302,8 @@ func (h *StorageHost) modifyStorageResponsibility(so StorageResponsibility, sect
 		h.DeleteSector(sectorsRemoved[k])
 	}
 
+	h.updateClientStorage(so, so.fileSize(), oldso.fileSize())
+
 	// Update the financial information for the storage responsibility - apply the cost
 	h.financialMetrics.PotentialContractCompensation = h.financialMetrics.PotentialContractCompensation.Add(so.ContractCost)
 	h.financialMetrics.LockedStorageDeposit = h.financialMetrics.LockedStorageDeposit.Add(so.LockedStorageDeposit)
@@ -377,6 +380,8 @@ func (h *StorageHost) rollbackStorageResponsibility(oldSo StorageResponsibility,
 		return errDB
 	}
 
+	h.updateClientStorage(oldSo, oldSo.fileSize(), newSo.fileSize())
+
 	// revert oldSo financialMetrics
 	h.financialMetrics.PotentialContractCompensation = h.financialMetrics.PotentialContractCompensation.Add(oldSo.ContractCost)
 	h.financialMetrics.LockedStorageDeposit = h.financialMetrics.LockedStorageDeposit.Add(oldSo.LockedStorageDeposit)
@@ -485,7 +490,12 @@ func (h *StorageHost) removeStorageResponsibility(so StorageResponsibility, sos
 
 	}
 
+	if so.ResponsibilityStatus == responsibilityUnresolved {
+		h.updateClientStorage(so, 0, so.fileSize())
+	}
+	delete(h.renewedContracts, so.id())
 	h.financialMetrics.ContractCount--
+	delete(h.proofSubmissions, so.id())
 	so.ResponsibilityStatus = sos
 	so.SectorRoots = []common.Hash{}
 	return putStorageResponsibility(h.db, so.id(), so)
@@ -593,7 +603,7 @@ func (h *StorageHost) handleTaskItem(soid common.Hash) {
 		}
 
 		//The host sends a revision transaction to the transaction pool.
-		if _, err := h.sendStorageContractRevisionTx(scrv.NewValidProofOutputs[1].Address, scBytes); err != nil {
+		if _, err := h.sendStorageContractRevisionTx(scrv.NewValidProofOutputs[1].Address, scBytes); err != nil && err != ethapi.ErrStorageTxQueued {
 			h.log.Warn("Error sending a revision transaction", "err", err)
 			return
 		}
@@ -612,6 +622,7 @@ func (h *StorageHost) handleTaskItem(soid common.Hash) {
 
 		if so.proofDeadline() < h.blockHeight {
 			h.log.Info("If the storage contract has expired and the proof transaction has not been confirmed, delete the storage responsibility", "id", so.id().String())
+			proofMissedCounter.Inc(1)
 			err := h.removeStorageResponsibility(so, responsibilityFailed)
 			if err != nil {
 				h.log.Warn("Error removing storage Responsibility", "err", err)
@@ -619,45 +630,35 @@ func (h *StorageHost) handleTaskItem(soid common.Hash) {
 			return
 		}
 
-		//The storage host side gets the index of the data containing the segment
+		//Raise the alert if the proof is close to the deadline, and schedule the next check so
+		//that the proof is resubmitted until it is included in a block
+		h.checkProofDeadline(so)
+		err := h.queueTaskItem(nextProofCheckHeight(h.blockHeight, so.proofDeadline(), h.proofConfig.RetryInterval), so.id())
+		if err != nil {
+			h.log.Warn("Error queuing task item", "err", err)
+		}
+
+		//The storage host side gets the indices of the segments to prove, and builds the storage
+		//proof of the proof version active in the next block
 		scrv := so.StorageContractRevisions[len(so.StorageContractRevisions)-1]
-		segmentIndex, err := h.storageProofSegment(scrv)
+		proofVersion, segmentIndices, err := h.storageProofSegments(scrv)
 		if err != nil {
 			h.log.Warn("An error occurred while getting the storage certificate from the storage host", "err", err)
 			return
 		}
 
-		sectorIndex := segmentIndex / (storage.SectorSize / merkle.LeafSize)
-		sectorRoot := so.SectorRoots[sectorIndex]
-		sectorBytes, err := h.ReadSector(sectorRoot)
+		var sp types.StorageProof
+		if proofVersion == params.StorageProofV0 {
+			sp, err = h.singleSegmentProof(so, segmentIndices[0])
+		} else {
+			sp, err = multiSegmentProof(so.SectorRoots, h.ReadSector, scrv.NewFileSize, segmentIndices)
+		}
 		//No content can be read from the memory, indicating that the storage host is not storing.
 		if err != nil {
 			h.log.Warn("the storage host is not storing", "err", err)
 			return
 		}
-
-		//Build a storage certificate for this storage contract
-		sectorSegment := segmentIndex % (storage.SectorSize / merkle.LeafSize)
-		base, cachedHashSet := merkleProof(sectorBytes, sectorSegment)
-		// Using the sector, build a cached root.
-		log2SectorSize := uint64(0)
-		for 1<<log2SectorSize < (storage.SectorSize / merkle.LeafSize) {
-			log2SectorSize++
-		}
-		ct := merkle.NewSha256CachedTree(log2SectorSize)
-		err = ct.SetStorageProofIndex(segmentIndex)
-		if err != nil {
-			h.log.Warn("cannot call SetIndex on Tree ", "err", err)
-		}
-		for _, root := range so.SectorRoots {
-			ct.Push(root)
-		}
-		hashSet := ct.Prove(base, cachedHashSet)
-		sp := types.StorageProof{
-			ParentID: so.id(),
-			HashSet:  hashSet,
-		}
-		copy(sp.Segment[:], base)
+		sp.ParentID = so.id()
 
 		//Here take the address of the storage host in the storage contract book
 		fromAddress := so.OriginStorageContract.ValidProofOutputs[1].Address
@@ -680,16 +681,19 @@ func (h *StorageHost) handleTaskItem(soid common.Hash) {
 			return
 		}
 
-		//The host sends a storage proof transaction to the transaction pool.
-		if _, err := h.sendStorageProofTx(fromAddress, spBytes); err != nil {
+		//The host sends a storage proof transaction to the transaction pool, or replaces the
+		//pending one with a higher gas price.
+		if err := h.submitStorageProof(so, spBytes); err != nil {
 			h.log.Warn("Error sending a storage proof transaction", "err", err)
 			return
 		}
+	}
 
-		//Insert the check proof task in the task queue.
-		err = h.queueTaskItem(so.proofDeadline(), so.id())
+	//The storage proof is confirmed before the deadline, check the responsibility at the deadline
+	if so.StorageProofConfirmed && h.blockHeight < so.proofDeadline() {
+		err := h.queueTaskItem(so.proofDeadline(), so.id())
 		if err != nil {
-			h.log.Warn("Error queuing task item", err)
+			h.log.Warn("Error queuing task item", "err", err)
 		}
 	}
 
@@ -736,25 +740,6 @@ func merkleProof(b []byte, proofIndex uint64) (base []byte, hashSet []common.Has
 	return base, hashSet
 }
 
-//If it exists, return the index of the segment in the storage contract that needs to be proved
-func (h *StorageHost) storageProofSegment(fc types.StorageContractRevision) (uint64, error) {
-	fcid := fc.ParentID
-	triggerHeight := fc.NewWindowStart - 1
-
-	block, errGetHeight := h.ethBackend.GetBlockByNumber(triggerHeight)
-	if errGetHeight != nil {
-		return 0, errGetHeight
-	}
-
-	triggerID := block.Hash()
-	seed := crypto.Keccak256Hash(triggerID[:], fcid[:])
-	numSegments := int64(calculateLeaves(fc.NewFileSize))
-	seedInt := new(big.Int).SetBytes(seed[:])
-	index := seedInt.Mod(seedInt, big.NewInt(numSegments)).Uint64()
-
-	return index, nil
-}
-
 func calculateLeaves(dataSize uint64) uint64 {
 	numSegments := dataSize / merkle.LeafSize
 	if dataSize == 0 || dataSize%merkle.LeafSize != 0 {
@@ -767,8 +752,3 @@ func calculateLeaves(dataSize uint64) uint64 {
 func (h *StorageHost) sendStorageContractRevisionTx(from common.Address, input []byte) (common.Hash, error) {
 	return h.parseAPI.StorageTx.SendContractRevisionTX(from, input)
 }
-
-// SendStorageProofTx send storage proof tx
-func (h *StorageHost) sendStorageProofTx(from common.Address, input []byte) (common.Hash, error) {
-	return h.parseAPI.StorageTx.SendStorageProofTX(from, input)
-}
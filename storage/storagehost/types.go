@@ -112,6 +112,55 @@ var (
 	// per file contract.
 	errMaxCollateralReached = errors.New("file contract proposal expects the host to pay more than the maximum allowed collateral")
 
+	// errClientDenied is returned if the client requesting the contract is in the
+	// denylist of the host
+	errClientDenied = ErrorCreateContract("client is in the denylist of the host")
+
+	// errClientNotAllowed is returned if the host has an allowlist and the client
+	// requesting the contract is not in it
+	errClientNotAllowed = ErrorCreateContract("client is not in the allowlist of the host")
+
+	// errShortContractDuration is returned if the contract duration is shorter than
+	// the minimum contract duration of the host
+	errShortContractDuration = ErrorCreateContract("contract duration is shorter than the host minimum")
+
+	// errLowContractFund is returned if the client collateral is lower than the
+	// minimum contract fund of the host
+	errLowContractFund = ErrorCreateContract("contract fund is lower than the host minimum")
+
+	// errClientStorageQuota is returned if the client storage would exceed the
+	// maximum storage per client of the host
+	errClientStorageQuota = ErrorCreateContract("client storage exceeds the host quota per client")
+
+	// errMaxContractsReached is returned if the host already has the maximum number
+	// of concurrent contracts
+	errMaxContractsReached = ErrorCreateContract("host has reached the maximum number of concurrent contracts")
+
+	// errZeroPricingInterval is returned if the pricing update interval is set to zero
+	errZeroPricingInterval = errors.New("pricing update interval must be positive")
+
+	// errInvalidAnnounceThreshold is returned if the announce threshold is not positive
+	errInvalidAnnounceThreshold = errors.New("announce threshold must be positive")
+
+	// errInvalidPriceBounds is returned if a minimum price is larger than the maximum price
+	errInvalidPriceBounds = errors.New("minimum price must not be larger than the maximum price")
+
+	// errInvalidUsageWindow is returned if the start of the usage report window is after the end
+	errInvalidUsageWindow = errors.New("start height must not be larger than the end height")
+
+	// errZeroProofRetryInterval is returned if the proof retry interval is set to zero
+	errZeroProofRetryInterval = errors.New("proof retry interval must be positive")
+
+	// errLowGasPriceBump is returned if the gas price bump is lower than the txpool accepts
+	errLowGasPriceBump = fmt.Errorf("gas price bump must be at least %v%%", minGasPriceBump)
+
+	// errZeroAnnounceInterval is returned if auto announcement is enabled with zero interval
+	errZeroAnnounceInterval = errors.New("announce interval must be positive")
+
+	// errNoAnnounceKeyAccount is returned if the announcement key is enabled without any
+	// announcement key account
+	errNoAnnounceKeyAccount = errors.New("no announcement key account configured")
+
 	errEmptyOriginStorageContract = errors.New("storage contract has no storage responsibility")
 	errEmptyRevisionSet           = errors.New("take the last revision ")
 	errInsaneRevision             = errors.New("revision is not necessary")
@@ -84,6 +84,15 @@ func UploadHandler(h *StorageHost, sp storage.Peer, uploadReqMsg p2p.Msg) {
 
 	if len(newRoots) > len(so.SectorRoots) {
 		bytesAdded := storage.SectorSize * uint64(len(newRoots)-len(so.SectorRoots))
+
+		// reserve the storage quota of the client until the upload is committed or failed
+		clientAddress := currentRevision.NewValidProofOutputs[0].Address
+		if err := h.reserveClientStorage(clientAddress, bytesAdded); err != nil {
+			hostNegotiateErr = err
+			return
+		}
+		defer h.releaseClientStorage(clientAddress, bytesAdded)
+
 		blocksRemaining := so.proofDeadline() - currentBlockHeight
 		blockBytesCurrency := common.NewBigIntUint64(blocksRemaining).Mult(common.NewBigIntUint64(bytesAdded))
 		storageRevenue = blockBytesCurrency.Mult(settings.StoragePrice)
@@ -262,7 +271,11 @@ func UploadHandler(h *StorageHost, sp storage.Peer, uploadReqMsg p2p.Msg) {
 		log.Error("storage host failed to send host ack msg", "err", err)
 		_ = h.rollbackStorageResponsibility(snapshotSo, sectorsGained, nil, nil)
 		h.ethBackend.CheckAndUpdateConnection(sp.PeerNode())
+		return
 	}
+
+	// record the upload bandwidth served for the client usage report
+	h.recordBandwidthUsage(so.id(), uint64(len(sectorsGained))*storage.SectorSize, 0)
 }
 
 // VerifyRevision checks that the revision pays the host correctly, and that
@@ -0,0 +1,174 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/DxChainNetwork/godx/common"
+)
+
+type (
+	// ClientUsage is the usage of the storage host by a client, aggregated from the
+	// storage responsibilities of the contracts paid by the client payment address
+	ClientUsage struct {
+		Client common.Address `json:"client"`
+
+		Contracts       uint64 `json:"contracts"`
+		ActiveContracts uint64 `json:"activeContracts"`
+
+		StoredBytes       uint64 `json:"storedBytes"`
+		UploadBandwidth   uint64 `json:"uploadBandwidth"`
+		DownloadBandwidth uint64 `json:"downloadBandwidth"`
+
+		RealizedRevenue  common.BigInt `json:"realizedRevenue"`
+		PotentialRevenue common.BigInt `json:"potentialRevenue"`
+		LostRevenue      common.BigInt `json:"lostRevenue"`
+
+		ProofsSubmitted uint64 `json:"proofsSubmitted"`
+		ProofsMissed    uint64 `json:"proofsMissed"`
+	}
+
+	// bandwidthUsage is the bandwidth served for a storage contract. It is saved
+	// separately from the storage responsibility in the database
+	bandwidthUsage struct {
+		Upload   uint64
+		Download uint64
+	}
+)
+
+// clientUsageCSVHeader is the header of the client usage report in csv format
+var clientUsageCSVHeader = []string{
+	"client", "contracts", "activeContracts", "storedBytes", "uploadBandwidth", "downloadBandwidth",
+	"realizedRevenue", "potentialRevenue", "lostRevenue", "proofsSubmitted", "proofsMissed",
+}
+
+// inWindow check whether the storage responsibility is active in the block window
+// [start, end]. Zero end means no upper bound
+func (so *StorageResponsibility) inWindow(start, end uint64) bool {
+	if end != 0 && so.NegotiationBlockNumber > end {
+		return false
+	}
+	return so.proofDeadline() >= start
+}
+
+// clientAddress returns the payment address of the client of the storage responsibility
+func (so *StorageResponsibility) clientAddress() (common.Address, bool) {
+	if len(so.OriginStorageContract.ValidProofOutputs) == 0 {
+		return common.Address{}, false
+	}
+	return so.OriginStorageContract.ValidProofOutputs[0].Address, true
+}
+
+// add adds the statistics of the storage responsibility to the client usage
+func (cu *ClientUsage) add(so StorageResponsibility, bu bandwidthUsage) {
+	cu.Contracts++
+	cu.UploadBandwidth += bu.Upload
+	cu.DownloadBandwidth += bu.Download
+
+	revenue := so.ContractCost.Add(so.PotentialStorageRevenue).Add(so.PotentialDownloadRevenue).Add(so.PotentialUploadRevenue)
+	switch so.ResponsibilityStatus {
+	case responsibilityUnresolved:
+		cu.ActiveContracts++
+		cu.StoredBytes += so.fileSize()
+		cu.PotentialRevenue = cu.PotentialRevenue.Add(revenue)
+		if so.StorageProofConfirmed {
+			cu.ProofsSubmitted++
+		}
+	case responsibilitySucceeded:
+		cu.RealizedRevenue = cu.RealizedRevenue.Add(revenue)
+		// empty contracts do not require storage proof
+		if so.StorageProofConfirmed {
+			cu.ProofsSubmitted++
+		}
+	case responsibilityFailed:
+		cu.LostRevenue = cu.LostRevenue.Add(revenue)
+		cu.ProofsMissed++
+	}
+}
+
+// clientUsages aggregates the storage responsibilities persisted in the database, which are
+// active in the block window [start, end], by the client payment address. The result is sorted
+// by the client address
+func (h *StorageHost) clientUsages(start, end uint64) ([]ClientUsage, error) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	usages := make(map[common.Address]*ClientUsage)
+	err := forEachStorageResponsibility(h.db, func(so StorageResponsibility) {
+		if !so.inWindow(start, end) {
+			return
+		}
+		client, ok := so.clientAddress()
+		if !ok {
+			return
+		}
+		cu, exist := usages[client]
+		if !exist {
+			cu = &ClientUsage{Client: client}
+			usages[client] = cu
+		}
+		cu.add(so, getBandwidthUsage(h.db, so.id()))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]ClientUsage, 0, len(usages))
+	for _, cu := range usages {
+		res = append(res, *cu)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return bytes.Compare(res[i].Client[:], res[j].Client[:]) < 0
+	})
+	return res, nil
+}
+
+// recordBandwidthUsage adds the upload and download bytes served to the bandwidth usage
+// of the storage contract
+func (h *StorageHost) recordBandwidthUsage(id common.Hash, upload, download uint64) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	bu := getBandwidthUsage(h.db, id)
+	bu.Upload += upload
+	bu.Download += download
+	if err := putBandwidthUsage(h.db, id, bu); err != nil {
+		h.log.Warn("failed to save the bandwidth usage", "id", id, "err", err)
+	}
+}
+
+// WriteClientUsageCSV writes the client usages to the writer in csv format. The amount of
+// data is in bytes and the revenue is in camel
+func WriteClientUsageCSV(w io.Writer, usages []ClientUsage) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(clientUsageCSVHeader); err != nil {
+		return err
+	}
+	for _, cu := range usages {
+		record := []string{
+			cu.Client.Hex(),
+			strconv.FormatUint(cu.Contracts, 10),
+			strconv.FormatUint(cu.ActiveContracts, 10),
+			strconv.FormatUint(cu.StoredBytes, 10),
+			strconv.FormatUint(cu.UploadBandwidth, 10),
+			strconv.FormatUint(cu.DownloadBandwidth, 10),
+			cu.RealizedRevenue.String(),
+			cu.PotentialRevenue.String(),
+			cu.LostRevenue.String(),
+			strconv.FormatUint(cu.ProofsSubmitted, 10),
+			strconv.FormatUint(cu.ProofsMissed, 10),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,136 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+)
+
+// newTestClientResponsibility create a storage responsibility paid by the client
+func newTestClientResponsibility(client common.Address, negotiation, windowEnd uint64, status storageResponsibilityStatus) StorageResponsibility {
+	return StorageResponsibility{
+		NegotiationBlockNumber:  negotiation,
+		ContractCost:            common.NewBigInt(10),
+		PotentialStorageRevenue: common.NewBigInt(100),
+		ResponsibilityStatus:    status,
+		StorageProofConfirmed:   status == responsibilitySucceeded,
+		OriginStorageContract: types.StorageContract{
+			FileSize:          1 << 20,
+			WindowStart:       windowEnd - 10,
+			WindowEnd:         windowEnd,
+			ValidProofOutputs: []types.DxcoinCharge{{Address: client}, {}},
+		},
+	}
+}
+
+func TestStorageHost_ClientUsages(t *testing.T) {
+	h := newTestStorageHost(t)
+	alice, bob := common.HexToAddress("0x1"), common.HexToAddress("0x2")
+
+	sos := []StorageResponsibility{
+		newTestClientResponsibility(bob, 10, 100, responsibilityUnresolved),
+		newTestClientResponsibility(alice, 10, 110, responsibilitySucceeded),
+		newTestClientResponsibility(alice, 20, 120, responsibilityFailed),
+		newTestClientResponsibility(alice, 200, 300, responsibilityUnresolved),
+	}
+	for _, so := range sos {
+		if err := putStorageResponsibility(h.db, so.id(), so); err != nil {
+			t.Fatal(err)
+		}
+		// the resolved responsibilities are only kept in the database
+		if so.ResponsibilityStatus == responsibilityUnresolved {
+			h.lockedStorageResponsibility[so.id()] = &TryMutex{}
+		}
+	}
+	h.recordBandwidthUsage(sos[1].id(), 100, 0)
+	h.recordBandwidthUsage(sos[1].id(), 100, 50)
+
+	usages, err := h.clientUsages(0, 150)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usages) != 2 {
+		t.Fatalf("expect 2 clients, got %v", len(usages))
+	}
+	cu := usages[0]
+	if cu.Client != alice || cu.Contracts != 2 || cu.ActiveContracts != 0 {
+		t.Errorf("alice contracts not expected: %+v", cu)
+	}
+	if cu.UploadBandwidth != 200 || cu.DownloadBandwidth != 50 {
+		t.Errorf("alice bandwidth not expected: %+v", cu)
+	}
+	if cu.ProofsSubmitted != 1 || cu.ProofsMissed != 1 {
+		t.Errorf("alice proofs not expected: %+v", cu)
+	}
+	if cu.RealizedRevenue.Cmp(common.NewBigInt(110)) != 0 || cu.LostRevenue.Cmp(common.NewBigInt(110)) != 0 {
+		t.Errorf("alice revenue not expected: %+v", cu)
+	}
+	cu = usages[1]
+	if cu.Client != bob || cu.ActiveContracts != 1 || cu.StoredBytes != 1<<20 {
+		t.Errorf("bob usage not expected: %+v", cu)
+	}
+	if cu.PotentialRevenue.Cmp(common.NewBigInt(110)) != 0 {
+		t.Errorf("bob potential revenue not expected: %v", cu.PotentialRevenue)
+	}
+
+	// only the latest contract of alice is in the window
+	if usages, err = h.clientUsages(150, 0); err != nil {
+		t.Fatal(err)
+	}
+	if len(usages) != 1 || usages[0].Client != alice || usages[0].ActiveContracts != 1 {
+		t.Errorf("usages not expected: %+v", usages)
+	}
+}
+
+func TestHostPrivateAPI_ExportClientUsage(t *testing.T) {
+	h := newTestStorageHost(t)
+	api := NewHostPrivateAPI(h)
+
+	so := newTestClientResponsibility(common.HexToAddress("0x1"), 10, 100, responsibilityUnresolved)
+	if err := putStorageResponsibility(h.db, so.id(), so); err != nil {
+		t.Fatal(err)
+	}
+	h.lockedStorageResponsibility[so.id()] = &TryMutex{}
+
+	report, err := api.ExportClientUsage(0, 0, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var usages []ClientUsage
+	if err = json.Unmarshal([]byte(report), &usages); err != nil {
+		t.Fatal(err)
+	}
+	if len(usages) != 1 || usages[0].PotentialRevenue.Cmp(common.NewBigInt(110)) != 0 {
+		t.Errorf("json report not expected: %v", report)
+	}
+
+	report, err = api.ExportClientUsage(0, 0, "CSV")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(bytes.NewBufferString(report)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || len(records[1]) != len(clientUsageCSVHeader) {
+		t.Fatalf("csv report not expected: %v", report)
+	}
+	if records[1][0] != so.OriginStorageContract.ValidProofOutputs[0].Address.Hex() || records[1][7] != "110" {
+		t.Errorf("csv record not expected: %v", records[1])
+	}
+
+	if _, err = api.ExportClientUsage(0, 0, "xml"); err == nil {
+		t.Error("unsupported format should give error")
+	}
+	if _, err = api.ExportClientUsage(10, 5, "json"); err != errInvalidUsageWindow {
+		t.Errorf("expect error %v, got %v", errInvalidUsageWindow, err)
+	}
+}
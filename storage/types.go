@@ -130,6 +130,9 @@ type (
 		HistoricUptime   time.Duration `json:"historicuptime"`
 		ScanRecords      HostPoolScans `json:"scanrecords"`
 
+		// ProbeRecords are the measured round trip time and throughput of the host
+		ProbeRecords HostProbes `json:"proberecords"`
+
 		HistoricFailedInteractions     float64 `json:"historicfailedinteractions"`
 		HistoricSuccessfulInteractions float64 `json:"historicsuccessfulinteractions"`
 		RecentFailedInteractions       float64 `json:"recentfailedinteractions"`
@@ -147,6 +150,10 @@ type (
 		EnodeURL   string   `json:"enodeurl"`
 		NodePubKey []byte   `json:"nodepubkey"`
 
+		// AltEnodeURLs are the alternative addresses announced by the host, which
+		// are tried when the host cannot be reached through the EnodeURL
+		AltEnodeURLs []string `json:"altenodeurls"`
+
 		Filtered bool `json:"filtered"`
 	}
 
@@ -191,12 +198,18 @@ type RentPayment struct {
 
 // ClientSetting defines the settings that client used to create contract with other peers,
 // where EnableIPViolation specifies if the host with same network IP addresses will be filtered
-// out or not
+// out or not, DownloadHedge is the maximum number of extra sectors of a segment requested
+// speculatively while downloading, SegmentCacheSize is the size limit of the local cache of
+// the downloaded segments, and AuditBudget is the maximum amount spent auditing the storage
+// hosts in each period
 type ClientSetting struct {
-	RentPayment       RentPayment `json:"rentpayment"`
-	EnableIPViolation bool        `json:"enableipviolation"`
-	MaxUploadSpeed    int64       `json:"maxuploadspeed"`
-	MaxDownloadSpeed  int64       `json:"maxdownloadspeed"`
+	RentPayment       RentPayment   `json:"rentpayment"`
+	EnableIPViolation bool          `json:"enableipviolation"`
+	MaxUploadSpeed    int64         `json:"maxuploadspeed"`
+	MaxDownloadSpeed  int64         `json:"maxdownloadspeed"`
+	DownloadHedge     uint64        `json:"downloadhedge"`
+	SegmentCacheSize  uint64        `json:"segmentcachesize"`
+	AuditBudget       common.BigInt `json:"auditbudget"`
 }
 
 type (
@@ -223,6 +236,9 @@ type (
 		EnableIPViolation string                `json:"IP Violation Check Status"`
 		MaxUploadSpeed    string                `json:"Max Upload Speed"`
 		MaxDownloadSpeed  string                `json:"Max Download Speed"`
+		DownloadHedge     string                `json:"Download Hedge"`
+		SegmentCacheSize  string                `json:"Segment Cache Size"`
+		AuditBudget       string                `json:"Audit Budget"`
 	}
 )
 
@@ -305,6 +321,10 @@ type (
 		DxPath      DxPath
 		ErasureCode erasurecode.ErasureCoder
 		Mode        int
+
+		// Dedup indicates whether the segments identical to the ones already uploaded
+		// in dedup mode share the uploaded sectors
+		Dedup bool
 	}
 
 	// UploadFileInfo provides information about a file